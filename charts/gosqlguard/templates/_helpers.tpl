@@ -74,3 +74,95 @@ Create a name for the metadata volume claim
 {{- define "gosqlguard.metadataVolumeName" -}}
 {{- printf "%s-metadata" (include "gosqlguard.fullname" .) }}
 {{- end }}
+
+{{/*
+Environment shared by the Deployment and the one-shot CronJobs
+*/}}
+{{- define "gosqlguard.env" -}}
+{{- if .Values.settings.debug }}
+- name: DEBUG
+  value: "true"
+{{- end }}
+# Metadata database configuration
+- name: METADATA_DB_ENABLED
+  value: {{ .Values.settings.metadata_database.enabled | quote }}
+{{- if .Values.settings.metadata_database.type }}
+- name: METADATA_DB_TYPE
+  value: {{ .Values.settings.metadata_database.type | quote }}
+{{- end }}
+{{- if .Values.settings.metadata_database.path }}
+- name: METADATA_DB_PATH
+  value: {{ .Values.settings.metadata_database.path | quote }}
+{{- end }}
+- name: METADATA_DB_HOST
+  value: {{ .Values.settings.metadata_database.host | quote }}
+- name: METADATA_DB_PORT
+  value: {{ .Values.settings.metadata_database.port | quote }}
+- name: METADATA_DB_USERNAME
+  value: {{ .Values.settings.metadata_database.username | quote }}
+- name: METADATA_DB_PASSWORD
+  value: {{ .Values.settings.metadata_database.password | quote }}
+- name: METADATA_DB_DATABASE
+  value: {{ .Values.settings.metadata_database.database | quote }}
+- name: METADATA_DB_MAX_OPEN_CONNS
+  value: {{ .Values.settings.metadata_database.maxOpenConns | quote }}
+- name: METADATA_DB_MAX_IDLE_CONNS
+  value: {{ .Values.settings.metadata_database.maxIdleConns | quote }}
+- name: METADATA_DB_CONN_MAX_LIFETIME
+  value: {{ .Values.settings.metadata_database.connMaxLifetime | quote }}
+- name: METADATA_DB_AUTO_MIGRATE
+  value: {{ .Values.settings.metadata_database.autoMigrate | quote }}
+# Local storage configuration
+- name: LOCAL_BACKUP_ENABLED
+  value: {{ .Values.settings.local.enabled | quote }}
+- name: LOCAL_BACKUP_DIRECTORY
+  value: {{ .Values.settings.local.backupDirectory | quote }}
+{{- if hasKey .Values.settings.local "organizationStrategy" }}
+- name: LOCAL_ORGANIZATION_STRATEGY
+  value: {{ .Values.settings.local.organizationStrategy | quote }}
+{{- end }}
+# S3 storage configuration
+- name: S3_BACKUP_ENABLED
+  value: {{ .Values.settings.s3.enabled | quote }}
+- name: S3_BUCKET
+  value: {{ .Values.settings.s3.bucket | quote }}
+- name: S3_REGION
+  value: {{ .Values.settings.s3.region | quote }}
+- name: S3_ENDPOINT
+  value: {{ .Values.settings.s3.endpoint | quote }}
+- name: S3_ACCESS_KEY
+  value: {{ .Values.settings.s3.accessKey | quote }}
+- name: S3_SECRET_KEY
+  value: {{ .Values.settings.s3.secretKey | quote }}
+{{- if hasKey .Values.settings.s3 "prefix" }}
+- name: S3_PREFIX
+  value: {{ .Values.settings.s3.prefix | quote }}
+{{- end }}
+- name: S3_USE_SSL
+  value: {{ .Values.settings.s3.useSSL | quote }}
+{{- if hasKey .Values.settings.s3 "skipCertValidation" }}
+- name: S3_SKIP_CERT_VALIDATION
+  value: {{ .Values.settings.s3.skipCertValidation | quote }}
+{{- end }}
+{{- if hasKey .Values.settings.s3 "organizationStrategy" }}
+- name: S3_ORGANIZATION_STRATEGY
+  value: {{ .Values.settings.s3.organizationStrategy | quote }}
+{{- end }}
+# Metrics configuration
+- name: METRICS_PORT
+  value: {{ .Values.settings.metrics.port | quote }}
+{{- if and .Values.settings.scheduler .Values.settings.scheduler.timezone }}
+# Scheduler configuration
+- name: SCHEDULER_TIMEZONE
+  value: {{ .Values.settings.scheduler.timezone | quote }}
+{{- end }}
+{{- if .Values.settings.shutdownGracePeriod }}
+- name: SHUTDOWN_GRACE_PERIOD
+  value: {{ .Values.settings.shutdownGracePeriod | quote }}
+{{- end }}
+# Support custom S3 endpoint regions
+- name: AWS_IGNORE_CONFIGURED_ENDPOINT_URLS
+  value: "true"
+- name: AWS_S3_FORCE_PATH_STYLE
+  value: "true"
+{{- end }}
@@ -0,0 +1,562 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/backup"
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/archive"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+	"github.com/supporttools/GoSQLGuard/pkg/metrics"
+)
+
+// Exit codes of the one-shot commands
+const (
+	exitOK     = 0 // Everything succeeded
+	exitFailed = 1 // The command ran but a database, deletion or backup copy failed
+	exitUsage  = 2 // Invalid arguments
+	exitSetup  = 3 // Configuration, metadata or storage could not be initialized
+)
+
+// oneShotCommands run a single pass and exit instead of starting the
+// scheduler, so GoSQLGuard can be driven by Kubernetes CronJobs
+var oneShotCommands = map[string]func(args []string) int{
+	"backup":    runBackupCommand,
+	"retention": runRetentionCommand,
+	"verify":    runVerifyCommand,
+	"migrate":   runMigrateCommand,
+	"metadata":  runMetadataCommand,
+}
+
+// listFlag collects a flag that may be repeated or given as a comma-separated list
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// newFlagSet returns a flag set that reports errors instead of exiting
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: gosqlguard %s %s\n\nFlags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses command arguments and returns the exit code to use when
+// the command should not run
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		fs.Usage()
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// initializeOnce loads the configuration and metadata store the same way the
+// long-running service does and returns a backup manager
+func initializeOnce() (*backup.Manager, error) {
+	config.LoadConfiguration()
+
+	if config.CFG.MetadataDB.Enabled {
+		if err := metadata.InitializeMetadataDatabase(); err != nil {
+			return nil, fmt.Errorf("failed to initialize metadata store: %w", err)
+		}
+		loadConfigurationFromDatabase()
+		loadSchedulesFromDatabase(nil)
+	} else if err := metadata.Initialize(); err != nil {
+		return nil, fmt.Errorf("failed to initialize metadata store: %w", err)
+	}
+
+	if err := config.ValidateConfig(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	backupManager, err := backup.NewManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize backup manager: %w", err)
+	}
+	return backupManager, nil
+}
+
+// finishCommand records the outcome of a one-shot command, pushes the
+// metrics when a Pushgateway is configured and returns the exit code. Pushed
+// metrics are grouped by operation, plus backup_type for backups, so the
+// CronJobs do not overwrite each other; the grouping labels must not clash
+// with the labels of the metrics themselves.
+func finishCommand(command string, grouping map[string]string, start time.Time, failures int, code int) int {
+	now := time.Now()
+	metrics.JobDuration.WithLabelValues(command).Set(now.Sub(start).Seconds())
+	metrics.JobLastRun.WithLabelValues(command).Set(float64(now.Unix()))
+	metrics.JobFailures.WithLabelValues(command).Set(float64(failures))
+	if code == exitOK {
+		metrics.JobLastSuccess.WithLabelValues(command).Set(float64(now.Unix()))
+	}
+
+	if url := config.CFG.Metrics.PushgatewayURL; url != "" {
+		labels := map[string]string{"operation": command}
+		for name, value := range grouping {
+			labels[name] = value
+		}
+		if err := metrics.Push(url, config.CFG.Metrics.PushgatewayJob, labels); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return code
+}
+
+// runBackupCommand runs one backup of a backup type and exits
+func runBackupCommand(args []string) int {
+	fs := newFlagSet("backup", "--type TYPE [--server NAME] [--database NAME]")
+	backupType := fs.String("type", "", "Backup type to run (required)")
+	var servers, databases, excludes listFlag
+	fs.Var(&servers, "server", "Server name or glob pattern to back up; repeatable, defaults to the backup type's targets")
+	fs.Var(&databases, "database", "Database name or glob pattern to back up; repeatable, defaults to the backup type's targets")
+	fs.Var(&excludes, "exclude-database", "Database name or glob pattern to skip; repeatable")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *backupType == "" {
+		fmt.Fprintln(fs.Output(), "--type is required")
+		fs.Usage()
+		return exitUsage
+	}
+
+	start := time.Now()
+	grouping := map[string]string{"backup_type": *backupType}
+
+	backupManager, err := initializeOnce()
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return finishCommand("backup", grouping, start, 1, exitSetup)
+	}
+
+	typeConfig, exists := config.CFG.BackupTypes[*backupType]
+	if !exists {
+		log.Printf("Error: unknown backup type %q", *backupType)
+		return finishCommand("backup", grouping, start, 1, exitUsage)
+	}
+
+	opts := backup.OptionsFromTargets(typeConfig.Targets)
+	if len(servers) > 0 || len(databases) > 0 || len(excludes) > 0 {
+		targets := config.BackupTargets{Servers: servers, Databases: databases, ExcludeDatabases: excludes}
+		if err := targets.Validate(); err != nil {
+			log.Printf("Error: %v", err)
+			return finishCommand("backup", grouping, start, 1, exitUsage)
+		}
+		opts = backup.OptionsFromTargets(targets)
+	}
+	if window, err := time.ParseDuration(typeConfig.MaxRunWindow); err == nil && window > 0 {
+		opts.Deadline = start.Add(window)
+	}
+
+	var mu sync.Mutex
+	var succeeded, failed, deferred int
+	opts.Result = func(serverName, database string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, backup.ErrDeferred):
+			deferred++
+		default:
+			failed++
+		}
+	}
+
+	stopSignals := shutdownOnSignal(backupManager)
+	defer stopSignals()
+
+	log.Printf("Running %s backup once...", *backupType)
+	if err := backupManager.PerformBackup(*backupType, opts); err != nil {
+		log.Printf("Error: %v", err)
+		failed += countErrors(err)
+	}
+
+	fmt.Printf("%s backup finished: %d succeeded, %d failed, %d deferred\n", *backupType, succeeded, failed, deferred)
+	if failed > 0 {
+		return finishCommand("backup", grouping, start, failed, exitFailed)
+	}
+	return finishCommand("backup", grouping, start, 0, exitOK)
+}
+
+// runRetentionCommand enforces retention policies once and exits
+func runRetentionCommand(args []string) int {
+	fs := newFlagSet("retention", "[--dry-run]")
+	dryRun := fs.Bool("dry-run", false, "List the backups retention would delete without deleting them")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	start := time.Now()
+	backupManager, err := initializeOnce()
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return finishCommand("retention", nil, start, 1, exitSetup)
+	}
+
+	if *dryRun {
+		deletions := backupManager.RetentionPlan().Deletions()
+		for _, decision := range deletions {
+			fmt.Printf("would delete %s copy of %s: %s\n", decision.Location, decision.BackupID, strings.Join(decision.Reasons, "; "))
+		}
+		fmt.Printf("retention dry run: %d copies would be deleted\n", len(deletions))
+		// Pushed separately so a dry run does not stand in for a real one
+		return finishCommand("retention", map[string]string{"dry_run": "true"}, start, 0, exitOK)
+	}
+
+	if err := backupManager.EnforceRetentionPolicies(); err != nil {
+		failures := countErrors(err)
+		fmt.Printf("retention finished: %d deletions failed\n", failures)
+		return finishCommand("retention", nil, start, failures, exitFailed)
+	}
+	fmt.Println("retention finished")
+	return finishCommand("retention", nil, start, 0, exitOK)
+}
+
+// runVerifyCommand checks the stored copies of recorded backups and exits
+func runVerifyCommand(args []string) int {
+	fs := newFlagSet("verify", "[--type TYPE] [--server NAME] [--database NAME] [--since DURATION]")
+	backupType := fs.String("type", "", "Only verify backups of this type")
+	server := fs.String("server", "", "Only verify backups of this server")
+	database := fs.String("database", "", "Only verify backups of this database")
+	since := fs.Duration("since", 0, "Only verify backups created within this duration, such as 24h; 0 verifies all")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	start := time.Now()
+	backupManager, err := initializeOnce()
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return finishCommand("verify", nil, start, 1, exitSetup)
+	}
+
+	var backups []types.BackupMeta
+	for _, b := range metadata.DefaultStore.GetBackupsFiltered(*server, *database, *backupType, true) {
+		if b.Status != types.StatusSuccess {
+			continue
+		}
+		if *since > 0 && b.CreatedAt.Before(start.Add(-*since)) {
+			continue
+		}
+		backups = append(backups, b)
+	}
+
+	failures := 0
+	results := backupManager.VerifyBackups(backups)
+	for _, r := range results {
+		if r.Err != nil {
+			failures++
+			fmt.Printf("FAILED %s %s %s: %v\n", r.BackupID, r.Location, r.Path, r.Err)
+		} else if config.CFG.Debug {
+			fmt.Printf("ok     %s %s %s\n", r.BackupID, r.Location, r.Path)
+		}
+	}
+
+	fmt.Printf("verify finished: %d backups, %d copies checked, %d failed\n", len(backups), len(results), failures)
+	if failures > 0 {
+		return finishCommand("verify", nil, start, failures, exitFailed)
+	}
+	return finishCommand("verify", nil, start, 0, exitOK)
+}
+
+// runMigrateCommand shows, applies or reverts the metadata schema migrations,
+// for deployments that disable METADATA_DB_AUTO_MIGRATE
+func runMigrateCommand(args []string) int {
+	fs := newFlagSet("migrate", "status|up|down [--to VERSION] [--steps N]")
+	to := fs.Int("to", 0, "Migrate up to and including this version; 0 applies all (up only)")
+	steps := fs.Int("steps", 1, "Number of migrations to revert (down only)")
+	if len(args) == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	action := args[0]
+	if code, ok := parseFlags(fs, args[1:]); !ok {
+		return code
+	}
+	if action != "status" && action != "up" && action != "down" {
+		fmt.Fprintf(fs.Output(), "unknown migrate action %q\n", action)
+		fs.Usage()
+		return exitUsage
+	}
+	if *steps < 1 || *to < 0 {
+		fmt.Fprintln(fs.Output(), "--steps must be at least 1 and --to must not be negative")
+		return exitUsage
+	}
+
+	config.LoadConfiguration()
+	if !config.CFG.MetadataDB.Enabled {
+		log.Println("Error: the metadata database is not enabled")
+		return exitSetup
+	}
+	db, err := dbmeta.Connect()
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitSetup
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+	migrator := dbmeta.NewMigrator(db)
+
+	switch action {
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitFailed
+		}
+		for _, s := range statuses {
+			state, appliedAt := "pending", "-"
+			if s.AppliedAt != nil {
+				state, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+			}
+			if !s.Known {
+				state = "unknown"
+			}
+			fmt.Printf("%4d  %-8s %-25s %s\n", s.Version, state, appliedAt, s.Name)
+		}
+	case "up":
+		applied, err := migrator.Up(*to)
+		for _, m := range applied {
+			fmt.Printf("applied  %d %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitFailed
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		reverted, err := migrator.Down(*steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %d %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitFailed
+		}
+	}
+	return exitOK
+}
+
+// runMetadataCommand exports the metadata of this instance to an archive or
+// imports one, for moving GoSQLGuard between clusters or metadata stores
+func runMetadataCommand(args []string) int {
+	fs := newFlagSet("metadata", "export [--file FILE] | import [--file FILE] [--conflict MODE] [--rewrite-local OLD=NEW] [--rewrite-s3 OLD=NEW] [--dry-run]")
+	file := fs.String("file", "-", "Archive to write or read, - for standard output or input; gzip-compressed when it ends in .gz")
+	conflict := fs.String("conflict", archive.ConflictSkip, "What to do with entries that already exist: skip, overwrite or merge (import only)")
+	var rewriteLocal, rewriteS3 listFlag
+	fs.Var(&rewriteLocal, "rewrite-local", "Replace the OLD prefix of local paths with NEW; repeatable (import only)")
+	fs.Var(&rewriteS3, "rewrite-s3", "Replace the OLD prefix of S3 keys with NEW; repeatable (import only)")
+	dryRun := fs.Bool("dry-run", false, "Report what would be imported without writing anything (import only)")
+	if len(args) == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	action := args[0]
+	if code, ok := parseFlags(fs, args[1:]); !ok {
+		return code
+	}
+	if action != "export" && action != "import" {
+		fmt.Fprintf(fs.Output(), "unknown metadata action %q\n", action)
+		fs.Usage()
+		return exitUsage
+	}
+
+	opts := archive.Options{Conflict: *conflict, DryRun: *dryRun}
+	for location, specs := range map[string]listFlag{archive.LocationLocal: rewriteLocal, archive.LocationS3: rewriteS3} {
+		for _, spec := range specs {
+			rw, err := archive.ParseRewrite(location, spec)
+			if err != nil {
+				fmt.Fprintln(fs.Output(), err)
+				return exitUsage
+			}
+			opts.Rewrites = append(opts.Rewrites, rw)
+		}
+	}
+
+	config.LoadConfiguration()
+	var err error
+	if config.CFG.MetadataDB.Enabled {
+		err = metadata.InitializeMetadataDatabase()
+		// The server falls back to the file store when the database is
+		// unusable; an archive must not end up in the wrong store
+		if _, ok := metadata.DefaultStore.(*metadata.DBStore); err == nil && !ok {
+			err = fmt.Errorf("the metadata database could not be used, see the log above")
+		}
+	} else {
+		err = metadata.Initialize()
+	}
+	if err != nil {
+		log.Printf("Error: failed to initialize metadata store: %v", err)
+		return exitSetup
+	}
+	store := archive.Store{Backups: metadata.DefaultStore, DB: metadata.DB}
+
+	if action == "export" {
+		return exportMetadata(*file, store)
+	}
+	return importMetadata(*file, store, opts)
+}
+
+// exportMetadata writes the metadata archive to path
+func exportMetadata(path string, store archive.Store) int {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitSetup
+		}
+		defer f.Close()
+		w = f
+	}
+	var gz *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+
+	header := archive.Header{}
+	if config.CFG.Local.Enabled {
+		header.LocalDirectory = config.CFG.Local.BackupDirectory
+	}
+	if config.CFG.S3.Enabled {
+		header.S3Bucket = config.CFG.S3.Bucket
+		header.S3Prefix = config.CFG.S3.Prefix
+	}
+	if store.DB == nil {
+		log.Println("Servers and schedules come from the configuration file and are not exported")
+	}
+
+	counts, err := archive.Export(w, store, header)
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitFailed
+	}
+	log.Printf("Exported %d servers, %d schedules and %d backups",
+		counts[archive.KindServer], counts[archive.KindSchedule], counts[archive.KindBackup])
+	return exitOK
+}
+
+// importMetadata reads the metadata archive at path into the store
+func importMetadata(path string, store archive.Store, opts archive.Options) int {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitSetup
+		}
+		defer f.Close()
+		r = f
+	}
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return exitSetup
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	result, err := archive.Import(r, store, opts)
+	header := result.Header
+	if len(opts.Rewrites) == 0 && err == nil {
+		if config.CFG.Local.Enabled && header.LocalDirectory != "" && header.LocalDirectory != config.CFG.Local.BackupDirectory {
+			log.Printf("Warning: the archive was exported with backup directory %s, use --rewrite-local if the backups moved", header.LocalDirectory)
+		}
+		if config.CFG.S3.Enabled && header.S3Bucket != "" && header.S3Prefix != config.CFG.S3.Prefix {
+			log.Printf("Warning: the archive was exported with S3 prefix %q, use --rewrite-s3 if the backups moved", header.S3Prefix)
+		}
+	}
+	for _, warning := range result.Warnings {
+		log.Printf("Warning: %s", warning)
+	}
+	verb := "Imported"
+	if opts.DryRun {
+		verb = "Would import"
+	}
+	for _, kind := range []string{archive.KindServer, archive.KindSchedule, archive.KindBackup} {
+		c := result.Counts[kind]
+		log.Printf("%s %ss: %d added, %d replaced, %d merged, %d skipped", verb, kind, c.Added, c.Replaced, c.Merged, c.Skipped)
+	}
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return exitFailed
+	}
+	return exitOK
+}
+
+// shutdownOnSignal drains the backup manager on SIGINT or SIGTERM, as when a
+// CronJob pod is deleted, so running backups get the shutdown grace period
+// and are marked interrupted if they do not finish. The returned function
+// stops listening for signals.
+func shutdownOnSignal(backupManager *backup.Manager) func() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-c:
+			log.Printf("Received signal %s, finishing running backups before exiting...", sig)
+			if err := backupManager.Shutdown(config.CFG.ShutdownGrace()); err != nil {
+				log.Printf("Shutdown did not finish cleanly: %v", err)
+			}
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
+
+// countErrors returns how many errors were joined into err
+func countErrors(err error) int {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return len(joined.Unwrap())
+	}
+	return 1
+}
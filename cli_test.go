@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListFlag(t *testing.T) {
+	var l listFlag
+	assert.NoError(t, l.Set("db1, db2"))
+	assert.NoError(t, l.Set("eu-*"))
+	assert.NoError(t, l.Set(""))
+	assert.Equal(t, listFlag{"db1", "db2", "eu-*"}, l)
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+		run  bool
+	}{
+		{"valid", []string{"--type", "daily"}, exitOK, true},
+		{"help", []string{"-h"}, exitOK, false},
+		{"unknown flag", []string{"--nope"}, exitUsage, false},
+		{"extra arguments", []string{"--type", "daily", "extra"}, exitUsage, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFlagSet("backup", "")
+			fs.SetOutput(io.Discard)
+			fs.String("type", "", "")
+			code, run := parseFlags(fs, tt.args)
+			assert.Equal(t, tt.code, code)
+			assert.Equal(t, tt.run, run)
+		})
+	}
+}
+
+func TestCountErrors(t *testing.T) {
+	assert.Equal(t, 1, countErrors(errors.New("one")))
+	assert.Equal(t, 2, countErrors(errors.Join(errors.New("one"), errors.New("two"))))
+}
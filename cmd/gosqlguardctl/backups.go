@@ -0,0 +1,595 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+// backupList is the response of GET /api/backups
+type backupList struct {
+	Backups []types.BackupMeta `json:"backups"`
+	Count   int                `json:"count"`
+}
+
+// backupsTable summarizes backups, newest first
+func backupsTable(backups []types.BackupMeta) table {
+	t := table{headers: []string{"ID", "SERVER", "DATABASE", "TYPE", "STATUS", "SIZE", "CREATED"}}
+	for _, b := range backups {
+		size := "-"
+		if b.Size > 0 {
+			size = humanize.IBytes(uint64(b.Size))
+		}
+		status := string(b.Status)
+		if b.IsHeld(time.Now()) {
+			status += " (held)"
+		}
+		t.rows = append(t.rows, []string{
+			b.ID, orDash(b.ServerName), b.Database, b.BackupType, status, size, formatTime(b.CreatedAt),
+		})
+	}
+	return t
+}
+
+// parseSince accepts a duration back from now, such as 24h, or an RFC 3339 time
+func parseSince(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, usageErrorf("invalid --since %q: expected a duration such as 24h or an RFC 3339 time", value)
+	}
+	return t, nil
+}
+
+// fetchBackups lists backups matching the query, newest first
+func fetchBackups(c *Client, query url.Values) ([]types.BackupMeta, error) {
+	var list backupList
+	if err := c.get("/api/backups", query, &list); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(list.Backups, func(i, j int) bool {
+		return list.Backups[i].CreatedAt.After(list.Backups[j].CreatedAt)
+	})
+	return list.Backups, nil
+}
+
+// fetchBackup returns a single backup by ID
+func fetchBackup(c *Client, id string) (types.BackupMeta, error) {
+	backups, err := fetchBackups(c, url.Values{"id": {id}})
+	if err != nil {
+		return types.BackupMeta{}, err
+	}
+	if len(backups) == 0 {
+		return types.BackupMeta{}, fmt.Errorf("backup %s not found", id)
+	}
+	return backups[0], nil
+}
+
+// printBackups writes backups in the selected format
+func (a *app) printBackups(backups []types.BackupMeta) error {
+	if backups == nil {
+		backups = []types.BackupMeta{}
+	}
+	raw, err := json.Marshal(backupList{Backups: backups, Count: len(backups)})
+	if err != nil {
+		return err
+	}
+	return printResult(a.out, a.format, raw, func() (table, error) {
+		return backupsTable(backups), nil
+	})
+}
+
+// listBackups lists backups matching the filters
+func listBackups(a *app, args []string) error {
+	fs := a.flags("backups list", "[--server NAME] [--database NAME] [--type TYPE] [--status STATUS] [--since 24h] [--active] [--limit N]")
+	server := fs.String("server", "", "Only list backups of this server")
+	database := fs.String("database", "", "Only list backups of this database")
+	backupType := fs.String("type", "", "Only list backups of this type")
+	status := fs.String("status", "", "Only list backups with this status: pending, success, error, deleted or deferred")
+	since := fs.String("since", "", "Only list backups created since a duration ago, such as 24h, or an RFC 3339 time")
+	active := fs.Bool("active", false, "Hide deleted backups")
+	limit := fs.Int("limit", 0, "Show at most this many backups; 0 shows all")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	for name, value := range map[string]string{"server": *server, "database": *database, "type": *backupType, "status": *status} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if *since != "" {
+		t, err := parseSince(*since)
+		if err != nil {
+			return err
+		}
+		query.Set("since", t.Format(time.RFC3339))
+	}
+	if *active {
+		query.Set("activeOnly", "true")
+	}
+
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	backups, err := fetchBackups(c, query)
+	if err != nil {
+		return err
+	}
+	if *limit > 0 && len(backups) > *limit {
+		backups = backups[:*limit]
+	}
+	return a.printBackups(backups)
+}
+
+// runBackup starts a manual backup and optionally waits for it to finish
+func runBackup(a *app, args []string) error {
+	fs := a.flags("backups run", "--type TYPE [--server NAME] [--database NAME] [--wait]")
+	backupType := fs.String("type", "", "Backup type to run (required)")
+	var servers, databases listFlag
+	fs.Var(&servers, "server", "Server to back up; repeatable, defaults to all")
+	fs.Var(&databases, "database", "Database to back up; repeatable, defaults to all")
+	wait := fs.Bool("wait", false, "Wait for the backup to finish and fail if any database failed")
+	timeout := fs.Duration("timeout", time.Hour, "How long to wait with --wait")
+	poll := fs.Duration("poll", 5*time.Second, "How often to check progress with --wait")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *backupType == "" {
+		fs.Usage()
+		return usageErrorf("--type is required")
+	}
+
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"type": {*backupType}}
+	if len(servers) > 0 {
+		query.Set("server", strings.Join(servers, ","))
+	}
+	if len(databases) > 0 {
+		query.Set("database", strings.Join(databases, ","))
+	}
+	var started struct {
+		Message   string `json:"message"`
+		StartedAt string `json:"started_at"`
+	}
+	if err := c.post("/api/backups/run", query, nil, &started); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.errOut, started.Message)
+	if !*wait {
+		return nil
+	}
+
+	startedAt, err := time.Parse(time.RFC3339, started.StartedAt)
+	if err != nil {
+		return fmt.Errorf("server did not report when the backup started; upgrade it to use --wait")
+	}
+	// Other runs may be in progress, so wait on the backups this run
+	// started rather than on the server being idle
+	query = url.Values{"type": {*backupType}, "since": {startedAt.Format(time.RFC3339)}}
+	if len(servers) == 1 {
+		query.Set("server", servers[0])
+	}
+	if len(databases) == 1 {
+		query.Set("database", databases[0])
+	}
+	deadline := time.Now().Add(*timeout)
+	for {
+		var status struct {
+			Running bool `json:"running"`
+		}
+		if err := c.get("/api/backups/run", nil, &status); err != nil {
+			return err
+		}
+		backups, err := fetchBackups(c, query)
+		if err != nil {
+			return err
+		}
+		backups = triggeredBackups(backups, servers, databases)
+		if !status.Running && !anyPending(backups) {
+			if err := a.printBackups(backups); err != nil {
+				return err
+			}
+			return backupOutcome(backups)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("backup still running after %s", *timeout)
+		}
+		time.Sleep(*poll)
+	}
+}
+
+// triggeredBackups keeps the backups of the given servers and databases; no
+// names match all of them
+func triggeredBackups(backups []types.BackupMeta, servers, databases []string) []types.BackupMeta {
+	matched := backups[:0]
+	for _, b := range backups {
+		if len(servers) > 0 && !contains(servers, b.ServerName) {
+			continue
+		}
+		if len(databases) > 0 && !contains(databases, b.Database) {
+			continue
+		}
+		matched = append(matched, b)
+	}
+	return matched
+}
+
+// anyPending reports whether any of the backups is still running
+func anyPending(backups []types.BackupMeta) bool {
+	for _, b := range backups {
+		if b.Status == types.StatusPending {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// backupOutcome reports failed backups of a finished run
+func backupOutcome(backups []types.BackupMeta) error {
+	if len(backups) == 0 {
+		return errors.New("the run recorded no backups; check the server logs")
+	}
+	var failed []string
+	for _, b := range backups {
+		if b.Status == types.StatusError || b.Status == types.StatusPending {
+			failed = append(failed, b.ServerName+"/"+b.Database)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d backups failed: %s", len(failed), len(backups), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// deleteBackup marks a backup as deleted
+func deleteBackup(a *app, args []string) error {
+	fs := a.flags("backups delete", "ID")
+	rest, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := c.post("/api/backups/delete", url.Values{"id": {rest[0]}}, nil, &result); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, result.Message)
+	return nil
+}
+
+// holdBackup places a hold on a backup so retention keeps it
+func holdBackup(a *app, args []string) error {
+	fs := a.flags("backups hold", "ID --reason TEXT [--until 2026-12-31]")
+	reason := fs.String("reason", "", "Why the backup must be kept (required)")
+	until := fs.String("until", "", "Release the hold by itself at this date or RFC 3339 time, or after a duration such as 720h")
+	rest, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *reason == "" {
+		return usageErrorf("--reason is required")
+	}
+
+	body := map[string]interface{}{"reason": *reason}
+	if *until != "" {
+		expiresAt, err := parseUntil(*until)
+		if err != nil {
+			return err
+		}
+		body["expiresAt"] = expiresAt
+	}
+
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Hold types.BackupHold `json:"hold"`
+	}
+	if err := c.post("/api/backups/hold", url.Values{"id": {rest[0]}}, body, &result); err != nil {
+		return err
+	}
+	if result.Hold.ExpiresAt.IsZero() {
+		fmt.Fprintf(a.out, "Backup %s held until released\n", rest[0])
+	} else {
+		fmt.Fprintf(a.out, "Backup %s held until %s\n", rest[0], formatTime(result.Hold.ExpiresAt))
+	}
+	return nil
+}
+
+// parseUntil accepts a duration from now, a date or an RFC 3339 time
+func parseUntil(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, usageErrorf("invalid --until %q: expected a duration, a date or an RFC 3339 time", value)
+	}
+	return t, nil
+}
+
+// releaseBackup releases the hold on a backup
+func releaseBackup(a *app, args []string) error {
+	fs := a.flags("backups release", "ID [--reason TEXT]")
+	reason := fs.String("reason", "", "Why the hold is released")
+	rest, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := c.post("/api/backups/hold/release", url.Values{"id": {rest[0]}}, map[string]string{"reason": *reason}, &result); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, result.Message)
+	return nil
+}
+
+// backupLogs prints the log of a backup, following it while the backup runs
+func backupLogs(a *app, args []string) error {
+	fs := a.flags("backups logs", "ID [--follow]")
+	follow := fs.Bool("f", false, "Keep printing the log until the backup finishes")
+	interval := fs.Duration("interval", 2*time.Second, "How often to check for new output with -f")
+	rest, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	for {
+		query := url.Values{"id": {rest[0]}, "format": {"text"}, "offset": {strconv.FormatInt(offset, 10)}}
+		resp, err := c.stream("/api/backups/log", query)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(a.out, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		next, err := strconv.ParseInt(resp.Header.Get("X-Log-Offset"), 10, 64)
+		if err != nil {
+			return errors.New("server does not support following logs; upgrade it to use -f")
+		}
+		offset = next
+
+		if !*follow || resp.Header.Get("X-Backup-Status") != string(types.StatusPending) {
+			return nil
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// openBackup streams the compressed dump of a backup from local storage or
+// S3. With from empty the local copy is preferred when it still exists.
+func openBackup(c *Client, backup types.BackupMeta, from string) (io.ReadCloser, error) {
+	if from == "" {
+		from = "s3"
+		if backup.LocalPath != "" && backup.LocalDeletedAt.IsZero() {
+			from = "local"
+		}
+	}
+
+	switch from {
+	case "local":
+		resp, err := c.stream("/api/backups/download/local", url.Values{"id": {backup.ID}})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	case "s3":
+		var link struct {
+			DownloadURL string `json:"download_url"`
+		}
+		if err := c.get("/api/backups/download/s3", url.Values{"id": {backup.ID}}, &link); err != nil {
+			return nil, err
+		}
+		// The presigned URL carries its own credentials and points at S3,
+		// not the admin server, so it is fetched without the context's TLS
+		// settings or token
+		resp, err := http.Get(link.DownloadURL) // #nosec G107 - URL signed by the admin server
+		if err != nil {
+			return nil, fmt.Errorf("failed to download from S3: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to download from S3: %s", resp.Status)
+		}
+		return resp.Body, nil
+	default:
+		return nil, usageErrorf("invalid --from %q: expected local or s3", from)
+	}
+}
+
+// downloadBackup saves the compressed dump of a backup
+func downloadBackup(a *app, args []string) error {
+	fs := a.flags("backups download", "ID [--from local|s3] [--to FILE]")
+	from := fs.String("from", "", "Storage to download from: local or s3; defaults to local when the copy still exists")
+	to := fs.String("to", "", "File to write, or - for standard output; defaults to DATABASE-TYPE-TIME.sql.gz")
+	rest, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	backup, err := fetchBackup(c, rest[0])
+	if err != nil {
+		return err
+	}
+
+	body, err := openBackup(c, backup, *from)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if *to == "-" {
+		_, err := io.Copy(a.out, body)
+		return err
+	}
+	path := *to
+	if path == "" {
+		path = fmt.Sprintf("%s-%s-%s.sql.gz", backup.Database, backup.BackupType, backup.CreatedAt.Format("2006-01-02-15-04-05"))
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	n, err := io.Copy(f, body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to download backup: %w", err)
+	}
+	fmt.Fprintf(a.errOut, "Saved %s (%s)\n", path, humanize.IBytes(uint64(n)))
+	return nil
+}
+
+// restoreCommand builds the client command that loads a dump into a database
+func restoreCommand(serverType, client, host, port, user, database string) (*exec.Cmd, error) {
+	var args []string
+	switch serverType {
+	case "mysql", "":
+		if client == "" {
+			client = "mysql"
+		}
+		args = []string{"-h", host, "-u", user}
+		if port != "" {
+			args = append(args, "-P", port)
+		}
+		args = append(args, database)
+	case "postgresql":
+		if client == "" {
+			client = "psql"
+		}
+		args = []string{"-h", host, "-U", user, "-d", database, "-v", "ON_ERROR_STOP=1", "-q"}
+		if port != "" {
+			args = append(args, "-p", port)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported server type %q", serverType)
+	}
+	return exec.Command(client, args...), nil // #nosec G204 - Arguments come from the operator
+}
+
+// restoreBackup downloads a backup and loads it with the mysql or psql client
+func restoreBackup(a *app, args []string) error {
+	fs := a.flags("backups restore", "ID --host HOST --user USER [--database NAME] --yes")
+	from := fs.String("from", "", "Storage to restore from: local or s3; defaults to local when the copy still exists")
+	host := fs.String("host", "", "Database host to restore into (required)")
+	port := fs.String("port", "", "Database port; defaults to the client's default")
+	user := fs.String("user", "", "Database user (required); the password is read from MYSQL_PWD or PGPASSWORD")
+	database := fs.String("database", "", "Database to restore into; defaults to the backed up database")
+	serverType := fs.String("server-type", "", "mysql or postgresql; defaults to the backup's server type")
+	client := fs.String("client", "", "Client binary to run; defaults to mysql or psql")
+	yes := fs.Bool("yes", false, "Confirm that the target database may be overwritten")
+	rest, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *host == "" || *user == "" {
+		fs.Usage()
+		return usageErrorf("--host and --user are required")
+	}
+
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	backup, err := fetchBackup(c, rest[0])
+	if err != nil {
+		return err
+	}
+	if backup.Status != types.StatusSuccess {
+		return fmt.Errorf("backup %s has status %s and cannot be restored", backup.ID, backup.Status)
+	}
+	if *database == "" {
+		*database = backup.Database
+	}
+	if *serverType == "" {
+		*serverType = backup.ServerType
+	}
+	if !*yes {
+		return usageErrorf("restoring %s into %s on %s may overwrite data; pass --yes to continue", backup.ID, *database, *host)
+	}
+
+	cmd, err := restoreCommand(*serverType, *client, *host, *port, *user, *database)
+	if err != nil {
+		return err
+	}
+
+	body, err := openBackup(c, backup, *from)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	dump, err := gzip.NewReader(body)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	cmd.Stdin = dump
+	cmd.Stdout = a.errOut
+	cmd.Stderr = a.errOut
+	fmt.Fprintf(a.errOut, "Restoring %s into %s on %s...\n", backup.ID, *database, *host)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+	fmt.Fprintln(a.errOut, "Restore complete")
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds API calls; downloads and log streams are not limited
+const requestTimeout = 30 * time.Second
+
+// Client calls the GoSQLGuard admin API
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// APIError is a non-success response from the admin API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// NewClient returns a client for the context's server
+func NewClient(ctx Context) (*Client, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: ctx.InsecureSkipTLSVerify, // #nosec G402 - Only when the context asks for it
+	}
+
+	if ctx.CAFile != "" {
+		pem, err := os.ReadFile(ctx.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", ctx.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if ctx.CertFile != "" || ctx.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(ctx.CertFile, ctx.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &Client{
+		baseURL: ctx.Server,
+		token:   ctx.Token,
+		http:    &http.Client{Transport: transport},
+	}, nil
+}
+
+// do sends a request and returns the response, or an APIError for
+// non-success statuses. The caller closes the body.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Response, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	return nil, errorFromResponse(resp)
+}
+
+// errorFromResponse reads the message of a failed request, which is plain
+// text for most endpoints and JSON for some
+func errorFromResponse(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var body struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil {
+		if body.Message != "" {
+			message = body.Message
+		} else if body.Error != "" {
+			message = body.Error
+		}
+	}
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: message}
+}
+
+// call sends a request with an optional JSON body and decodes a JSON
+// response into out when it is not nil
+func (c *Client) call(method, path string, query url.Values, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	resp, err := c.do(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", path, err)
+	}
+	return nil
+}
+
+// get decodes the JSON response of a GET request into out
+func (c *Client) get(path string, query url.Values, out interface{}) error {
+	return c.call(http.MethodGet, path, query, nil, out)
+}
+
+// post sends in as JSON and decodes the response into out
+func (c *Client) post(path string, query url.Values, in, out interface{}) error {
+	return c.call(http.MethodPost, path, query, in, out)
+}
+
+// stream issues a GET request without a timeout and returns the response for
+// the caller to read and close
+func (c *Client) stream(path string, query url.Values) (*http.Response, error) {
+	return c.do(context.Background(), http.MethodGet, path, query, nil)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Context is a named GoSQLGuard endpoint with the credentials to reach it
+type Context struct {
+	Name                  string `yaml:"name"`
+	Server                string `yaml:"server"`                          // Base URL of the admin server
+	Token                 string `yaml:"token,omitempty"`                 // API token sent as a bearer token
+	TokenFile             string `yaml:"tokenFile,omitempty"`             // Or a file holding it
+	CAFile                string `yaml:"caFile,omitempty"`                // CA that signed the admin server certificate
+	CertFile              string `yaml:"certFile,omitempty"`              // Client certificate, when the server requires one
+	KeyFile               string `yaml:"keyFile,omitempty"`               // Client certificate key
+	InsecureSkipTLSVerify bool   `yaml:"insecureSkipTLSVerify,omitempty"` // Do not verify the server certificate
+}
+
+// ContextFile holds the known contexts and which one is used by default
+type ContextFile struct {
+	CurrentContext string    `yaml:"currentContext"`
+	Contexts       []Context `yaml:"contexts"`
+}
+
+// defaultContextPath returns where the context file lives unless overridden
+func defaultContextPath() string {
+	if path := os.Getenv("GOSQLGUARDCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "gosqlguard", "contexts.yaml")
+}
+
+// loadContextFile reads the context file, returning an empty one when it does not exist
+func loadContextFile(path string) (*ContextFile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &ContextFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read context file: %w", err)
+	}
+
+	var file ContextFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse context file %s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// save writes the context file readable only by the owner, since it holds tokens
+func (f *ContextFile) save(path string) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("failed to encode context file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create context directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write context file: %w", err)
+	}
+	return nil
+}
+
+// find returns the context with the given name
+func (f *ContextFile) find(name string) (*Context, bool) {
+	for i := range f.Contexts {
+		if f.Contexts[i].Name == name {
+			return &f.Contexts[i], true
+		}
+	}
+	return nil, false
+}
+
+// resolve picks the context to use: the named one, else the current one. The
+// server and token overrides apply on top, and are enough without any context.
+func (f *ContextFile) resolve(name, server, token string) (Context, error) {
+	if name == "" {
+		name = f.CurrentContext
+	}
+
+	var ctx Context
+	if name != "" {
+		found, ok := f.find(name)
+		if !ok {
+			return Context{}, fmt.Errorf("context %q not found", name)
+		}
+		ctx = *found
+	}
+
+	if server != "" {
+		ctx.Server = server
+	}
+	if token != "" {
+		ctx.Token = token
+	}
+	if ctx.Server == "" {
+		return Context{}, usageErrorf("no server configured: pass --server, set GOSQLGUARD_SERVER or create a context with `gosqlguardctl context set`")
+	}
+
+	if ctx.Token == "" && ctx.TokenFile != "" {
+		data, err := os.ReadFile(ctx.TokenFile)
+		if err != nil {
+			return Context{}, fmt.Errorf("failed to read token file: %w", err)
+		}
+		ctx.Token = strings.TrimSpace(string(data))
+	}
+	ctx.Server = strings.TrimSuffix(ctx.Server, "/")
+	return ctx, nil
+}
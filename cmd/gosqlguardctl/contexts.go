@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+)
+
+// contextsTable lists contexts without their tokens
+func contextsTable(file *ContextFile) table {
+	t := table{headers: []string{"CURRENT", "NAME", "SERVER", "AUTH"}}
+	for _, ctx := range file.Contexts {
+		current := ""
+		if ctx.Name == file.CurrentContext {
+			current = "*"
+		}
+		t.rows = append(t.rows, []string{current, ctx.Name, ctx.Server, contextAuth(ctx)})
+	}
+	return t
+}
+
+// contextAuth describes how a context authenticates
+func contextAuth(ctx Context) string {
+	switch {
+	case ctx.Token != "":
+		return "token"
+	case ctx.TokenFile != "":
+		return "token file " + ctx.TokenFile
+	case ctx.CertFile != "":
+		return "client certificate"
+	default:
+		return "none"
+	}
+}
+
+// redacted returns the contexts with their inline tokens hidden, for printing
+func redacted(contexts []Context) []Context {
+	out := make([]Context, len(contexts))
+	for i, ctx := range contexts {
+		if ctx.Token != "" {
+			ctx.Token = "REDACTED"
+		}
+		out[i] = ctx
+	}
+	return out
+}
+
+func listContexts(a *app, args []string) error {
+	if _, err := parse(a.flags("context list", ""), args, 0); err != nil {
+		return err
+	}
+	file, err := loadContextFile(a.ctxPath)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(map[string]interface{}{
+		"currentContext": file.CurrentContext,
+		"contexts":       redacted(file.Contexts),
+	})
+	if err != nil {
+		return err
+	}
+	return printResult(a.out, a.format, raw, func() (table, error) {
+		return contextsTable(file), nil
+	})
+}
+
+func currentContext(a *app, args []string) error {
+	if _, err := parse(a.flags("context current", ""), args, 0); err != nil {
+		return err
+	}
+	file, err := loadContextFile(a.ctxPath)
+	if err != nil {
+		return err
+	}
+	if file.CurrentContext == "" {
+		return fmt.Errorf("no current context is set")
+	}
+	fmt.Fprintln(a.out, file.CurrentContext)
+	return nil
+}
+
+func useContext(a *app, args []string) error {
+	rest, err := parse(a.flags("context use", "NAME"), args, 1)
+	if err != nil {
+		return err
+	}
+	file, err := loadContextFile(a.ctxPath)
+	if err != nil {
+		return err
+	}
+	if _, ok := file.find(rest[0]); !ok {
+		return fmt.Errorf("context %q not found", rest[0])
+	}
+	file.CurrentContext = rest[0]
+	if err := file.save(a.ctxPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Switched to context %s\n", rest[0])
+	return nil
+}
+
+// setContext creates a context or updates the fields given on the command
+// line. The first context created becomes the current one.
+func setContext(a *app, args []string) error {
+	fs := a.flags("context set", "NAME [--server URL] [--token TOKEN | --token-file FILE] [--ca-file FILE] [--cert-file FILE --key-file FILE]")
+	server := fs.String("server", "", "Admin server URL, such as https://gosqlguard.example.com:8888")
+	token := fs.String("token", "", "API token; prefer --token-file to keep it out of shell history")
+	tokenFile := fs.String("token-file", "", "File holding the API token")
+	caFile := fs.String("ca-file", "", "CA that signed the admin server certificate")
+	certFile := fs.String("cert-file", "", "Client certificate")
+	keyFile := fs.String("key-file", "", "Client certificate key")
+	insecure := fs.Bool("insecure-skip-tls-verify", false, "Do not verify the admin server certificate")
+	rest, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	file, err := loadContextFile(a.ctxPath)
+	if err != nil {
+		return err
+	}
+	ctx, exists := file.find(rest[0])
+	if !exists {
+		file.Contexts = append(file.Contexts, Context{Name: rest[0]})
+		ctx = &file.Contexts[len(file.Contexts)-1]
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["server"] {
+		ctx.Server = *server
+	}
+	if set["token"] {
+		ctx.Token, ctx.TokenFile = *token, ""
+	}
+	if set["token-file"] {
+		ctx.Token, ctx.TokenFile = "", *tokenFile
+	}
+	if set["ca-file"] {
+		ctx.CAFile = *caFile
+	}
+	if set["cert-file"] {
+		ctx.CertFile = *certFile
+	}
+	if set["key-file"] {
+		ctx.KeyFile = *keyFile
+	}
+	if set["insecure-skip-tls-verify"] {
+		ctx.InsecureSkipTLSVerify = *insecure
+	}
+	if ctx.Server == "" {
+		return usageErrorf("--server is required for a new context")
+	}
+
+	if file.CurrentContext == "" {
+		file.CurrentContext = ctx.Name
+	}
+	if err := file.save(a.ctxPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Context %s saved\n", rest[0])
+	return nil
+}
+
+func deleteContext(a *app, args []string) error {
+	rest, err := parse(a.flags("context delete", "NAME"), args, 1)
+	if err != nil {
+		return err
+	}
+	file, err := loadContextFile(a.ctxPath)
+	if err != nil {
+		return err
+	}
+
+	kept := file.Contexts[:0]
+	for _, ctx := range file.Contexts {
+		if ctx.Name != rest[0] {
+			kept = append(kept, ctx)
+		}
+	}
+	if len(kept) == len(file.Contexts) {
+		return fmt.Errorf("context %q not found", rest[0])
+	}
+	file.Contexts = kept
+	if file.CurrentContext == rest[0] {
+		file.CurrentContext = ""
+	}
+	if err := file.save(a.ctxPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Context %s deleted\n", rest[0])
+	return nil
+}
@@ -0,0 +1,247 @@
+// gosqlguardctl is a command-line client for the GoSQLGuard admin API
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Exit codes
+const (
+	exitOK     = 0 // The command succeeded
+	exitFailed = 1 // The request or the operation it started failed
+	exitUsage  = 2 // Invalid arguments or missing configuration
+)
+
+// usageError marks errors caused by how the command was invoked
+type usageError struct{ msg string }
+
+func (e *usageError) Error() string { return e.msg }
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// app carries what every command needs
+type app struct {
+	out     io.Writer
+	errOut  io.Writer
+	format  string
+	ctxPath string
+
+	// Set from the global flags and environment
+	contextName string
+	server      string
+	token       string
+
+	client *Client // Created on first use so context commands work offline
+}
+
+// command runs a subcommand with its remaining arguments
+type command func(a *app, args []string) error
+
+// commands maps resources to their verbs
+var commands = map[string]map[string]command{
+	"backups": {
+		"list":     listBackups,
+		"run":      runBackup,
+		"logs":     backupLogs,
+		"download": downloadBackup,
+		"restore":  restoreBackup,
+		"delete":   deleteBackup,
+		"hold":     holdBackup,
+		"release":  releaseBackup,
+	},
+	"servers": {
+		"list":   listServers,
+		"get":    getServer,
+		"apply":  applyServer,
+		"delete": deleteServer,
+		"test":   testServer,
+	},
+	"schedules": {
+		"list":      listSchedules,
+		"get":       getSchedule,
+		"apply":     applySchedule,
+		"delete":    deleteSchedule,
+		"pause":     pauseSchedule,
+		"resume":    resumeSchedule,
+		"skip-next": skipNextSchedule,
+		"upcoming":  upcomingRuns,
+	},
+	"s3": {
+		"get":  getS3Config,
+		"set":  setS3Config,
+		"test": testS3Config,
+	},
+	"context": {
+		"list":    listContexts,
+		"current": currentContext,
+		"use":     useContext,
+		"set":     setContext,
+		"delete":  deleteContext,
+	},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the exit code
+func run(args []string, out, errOut io.Writer) int {
+	a := &app{out: out, errOut: errOut}
+
+	fs := flag.NewFlagSet("gosqlguardctl", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	fs.StringVar(&a.ctxPath, "config", defaultContextPath(), "Context file")
+	fs.StringVar(&a.contextName, "context", os.Getenv("GOSQLGUARD_CONTEXT"), "Context to use instead of the current one")
+	fs.StringVar(&a.server, "server", os.Getenv("GOSQLGUARD_SERVER"), "Admin server URL, overriding the context")
+	fs.StringVar(&a.token, "token", os.Getenv("GOSQLGUARD_TOKEN"), "API token, overriding the context")
+	fs.StringVar(&a.format, "o", formatTable, "Output format: table, json or yaml")
+	fs.Usage = func() { printUsage(errOut, fs) }
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if a.format != formatTable && a.format != formatJSON && a.format != formatYAML {
+		fmt.Fprintf(errOut, "Error: unknown output format %q\n", a.format)
+		return exitUsage
+	}
+
+	rest := fs.Args()
+	if len(rest) < 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	verbs, ok := commands[rest[0]]
+	if !ok {
+		fmt.Fprintf(errOut, "Error: unknown resource %q\n", rest[0])
+		fs.Usage()
+		return exitUsage
+	}
+	cmd, ok := verbs[rest[1]]
+	if !ok {
+		fmt.Fprintf(errOut, "Error: unknown command %q for %s (available: %s)\n", rest[1], rest[0], strings.Join(sortedKeys(verbs), ", "))
+		return exitUsage
+	}
+
+	if err := cmd(a, rest[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fmt.Fprintf(errOut, "Error: %v\n", err)
+		var usage *usageError
+		if errors.As(err, &usage) {
+			return exitUsage
+		}
+		return exitFailed
+	}
+	return exitOK
+}
+
+// api returns the client for the selected context
+func (a *app) api() (*Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+
+	file, err := loadContextFile(a.ctxPath)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := file.resolve(a.contextName, a.server, a.token)
+	if err != nil {
+		return nil, err
+	}
+	if a.client, err = NewClient(ctx); err != nil {
+		return nil, err
+	}
+	return a.client, nil
+}
+
+// flags returns a flag set for a subcommand that reports errors instead of exiting
+func (a *app) flags(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.errOut)
+	fs.Usage = func() {
+		fmt.Fprintf(a.errOut, "Usage: gosqlguardctl %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses subcommand flags, which may come before or after the
+// positional arguments, and checks how many positional arguments were given
+func parse(fs *flag.FlagSet, args []string, positional int) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &usageError{msg: err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+
+	if len(rest) != positional {
+		fs.Usage()
+		return nil, usageErrorf("%s expects %d argument(s), got %d", fs.Name(), positional, len(rest))
+	}
+	return rest, nil
+}
+
+// listFlag collects a flag that may be repeated or given as a comma-separated list
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]command) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// printUsage lists the global flags and every command
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: gosqlguardctl [flags] <resource> <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	resources := make([]string, 0, len(commands))
+	for resource := range commands {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	for _, resource := range resources {
+		fmt.Fprintf(w, "  %-10s %s\n", resource, strings.Join(sortedKeys(commands[resource]), ", "))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fs.PrintDefaults()
+}
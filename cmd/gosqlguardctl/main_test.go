@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+const testToken = "test-token-0123456789"
+
+// runCommand runs gosqlguardctl against a server with an empty context file
+func runCommand(t *testing.T, server string, args ...string) (int, string, string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	global := []string{"--config", filepath.Join(t.TempDir(), "contexts.yaml"), "--server", server, "--token", testToken}
+	code := run(append(global, args...), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+// requireToken fails requests without the test token like the admin server does
+func requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			http.Error(w, "Invalid API token", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeBackups(w http.ResponseWriter, backups []types.BackupMeta) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backupList{Backups: backups, Count: len(backups)})
+}
+
+func TestResolveContext(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("from-file\n"), 0600))
+
+	file := &ContextFile{
+		CurrentContext: "prod",
+		Contexts: []Context{
+			{Name: "prod", Server: "https://prod.example.com/", Token: "prod-token"},
+			{Name: "staging", Server: "https://staging.example.com", TokenFile: tokenFile},
+		},
+	}
+
+	ctx, err := file.resolve("", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://prod.example.com", ctx.Server)
+	assert.Equal(t, "prod-token", ctx.Token)
+
+	ctx, err = file.resolve("staging", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", ctx.Token)
+
+	ctx, err = file.resolve("", "http://localhost:8888", "override")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8888", ctx.Server)
+	assert.Equal(t, "override", ctx.Token)
+
+	_, err = file.resolve("missing", "", "")
+	assert.Error(t, err)
+
+	_, err = (&ContextFile{}).resolve("", "", "")
+	var usage *usageError
+	assert.ErrorAs(t, err, &usage)
+}
+
+func TestContextCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gosqlguard", "contexts.yaml")
+	ctl := func(args ...string) (int, string) {
+		var out bytes.Buffer
+		code := run(append([]string{"--config", path}, args...), &out, &out)
+		return code, out.String()
+	}
+
+	code, _ := ctl("context", "set", "prod", "--server", "https://prod.example.com", "--token", "secret-token")
+	require.Equal(t, exitOK, code)
+	code, _ = ctl("context", "set", "staging", "--server", "https://staging.example.com")
+	require.Equal(t, exitOK, code)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	_, out := ctl("context", "current")
+	assert.Equal(t, "prod\n", out, "the first context becomes the current one")
+
+	code, _ = ctl("context", "use", "staging")
+	assert.Equal(t, exitOK, code)
+	_, out = ctl("context", "current")
+	assert.Equal(t, "staging\n", out)
+
+	_, out = ctl("-o", "json", "context", "list")
+	assert.NotContains(t, out, "secret-token")
+
+	code, _ = ctl("context", "use", "missing")
+	assert.Equal(t, exitFailed, code)
+
+	code, _ = ctl("context", "delete", "staging")
+	assert.Equal(t, exitOK, code)
+	code, _ = ctl("context", "current")
+	assert.Equal(t, exitFailed, code)
+}
+
+func TestUsageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no command", []string{"backups"}},
+		{"unknown resource", []string{"widgets", "list"}},
+		{"unknown verb", []string{"backups", "explode"}},
+		{"unknown output", []string{"-o", "xml", "backups", "list"}},
+		{"missing type", []string{"backups", "run"}},
+		{"missing argument", []string{"backups", "delete"}},
+		{"no server", []string{"backups", "list"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			args := append([]string{"--config", filepath.Join(t.TempDir(), "none.yaml"), "--server", ""}, tt.args...)
+			assert.Equal(t, exitUsage, run(args, &out, &out))
+		})
+	}
+}
+
+func TestListBackups(t *testing.T) {
+	now := time.Now()
+	srv := httptest.NewServer(requireToken(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/backups", r.URL.Path)
+		assert.Equal(t, "db1", r.URL.Query().Get("database"))
+		assert.Equal(t, "error", r.URL.Query().Get("status"))
+		writeBackups(w, []types.BackupMeta{
+			{ID: "older", ServerName: "mysql-1", Database: "db1", BackupType: "daily", Status: types.StatusError, CreatedAt: now.Add(-time.Hour)},
+			{ID: "newer", ServerName: "mysql-1", Database: "db1", BackupType: "daily", Status: types.StatusError, Size: 2048, CreatedAt: now},
+		})
+	}))
+	defer srv.Close()
+
+	code, out, errOut := runCommand(t, srv.URL, "backups", "list", "--database", "db1", "--status", "error")
+	require.Equal(t, exitOK, code, errOut)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "ID"))
+	assert.True(t, strings.HasPrefix(lines[1], "newer"), "newest backup first")
+	assert.Contains(t, lines[1], "2.0 KiB")
+
+	code, out, _ = runCommand(t, srv.URL, "-o", "json", "backups", "list", "--database", "db1", "--status", "error", "--limit", "1")
+	require.Equal(t, exitOK, code)
+	var list backupList
+	require.NoError(t, json.Unmarshal([]byte(out), &list))
+	require.Len(t, list.Backups, 1)
+	assert.Equal(t, "newer", list.Backups[0].ID)
+
+	var stderr bytes.Buffer
+	code = run([]string{"--config", filepath.Join(t.TempDir(), "none.yaml"), "--server", srv.URL, "--token", "wrong", "backups", "list"}, &bytes.Buffer{}, &stderr)
+	assert.Equal(t, exitFailed, code)
+	assert.Contains(t, stderr.String(), "Invalid API token (HTTP 401)")
+}
+
+func TestRunBackupWait(t *testing.T) {
+	for _, status := range []types.BackupStatus{types.StatusSuccess, types.StatusError} {
+		t.Run(string(status), func(t *testing.T) {
+			startedAt := time.Now().Truncate(time.Second)
+			var polls int32
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/backups/run", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					assert.Equal(t, "daily", r.URL.Query().Get("type"))
+					assert.Equal(t, "db1,db2", r.URL.Query().Get("database"))
+					w.WriteHeader(http.StatusAccepted)
+					json.NewEncoder(w).Encode(map[string]string{"message": "started", "started_at": startedAt.Format(time.RFC3339)})
+					return
+				}
+				// Report the run as finished on the second poll
+				json.NewEncoder(w).Encode(map[string]bool{"running": atomic.AddInt32(&polls, 1) < 2})
+			})
+			mux.HandleFunc("/api/backups", func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, startedAt.Format(time.RFC3339), r.URL.Query().Get("since"))
+				// b2 is still writing when the run flag clears, and a
+				// backup of another database never finishes
+				b2 := status
+				if atomic.LoadInt32(&polls) < 3 {
+					b2 = types.StatusPending
+				}
+				writeBackups(w, []types.BackupMeta{
+					{ID: "b1", ServerName: "mysql-1", Database: "db1", BackupType: "daily", Status: types.StatusSuccess, CreatedAt: startedAt},
+					{ID: "b2", ServerName: "mysql-1", Database: "db2", BackupType: "daily", Status: b2, CreatedAt: startedAt},
+					{ID: "other", ServerName: "mysql-1", Database: "db3", BackupType: "daily", Status: types.StatusPending, CreatedAt: startedAt},
+				})
+			})
+			srv := httptest.NewServer(requireToken(mux.ServeHTTP))
+			defer srv.Close()
+
+			code, out, errOut := runCommand(t, srv.URL, "backups", "run", "--type", "daily", "--database", "db1,db2",
+				"--wait", "--poll", "10ms", "--timeout", "5s")
+			assert.Equal(t, int32(3), atomic.LoadInt32(&polls))
+			assert.Contains(t, out, "b2")
+			assert.NotContains(t, out, "other")
+			if status == types.StatusSuccess {
+				assert.Equal(t, exitOK, code, errOut)
+			} else {
+				assert.Equal(t, exitFailed, code)
+				assert.Contains(t, errOut, "1 of 2 backups failed: mysql-1/db2")
+			}
+		})
+	}
+}
+
+func TestBackupLogsFollow(t *testing.T) {
+	chunks := []string{"line 1\n", "line 2\n"}
+	srv := httptest.NewServer(requireToken(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "text", r.URL.Query().Get("format"))
+		offset := r.URL.Query().Get("offset")
+		status, body, next := "pending", chunks[0], "7"
+		if offset != "0" {
+			status, body, next = "success", chunks[1], "14"
+		}
+		w.Header().Set("X-Backup-Status", status)
+		w.Header().Set("X-Log-Offset", next)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	code, out, errOut := runCommand(t, srv.URL, "backups", "logs", "b1", "-f", "--interval", "10ms")
+	require.Equal(t, exitOK, code, errOut)
+	assert.Equal(t, "line 1\nline 2\n", out)
+}
+
+func TestWriteYAML(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeYAML(&out, json.RawMessage(`{"name":"prod","port":"3306","enabled":true,"tags":["a","b"]}`)))
+	assert.Equal(t, "name: prod\nport: \"3306\"\nenabled: true\ntags:\n  - a\n  - b\n", out.String())
+}
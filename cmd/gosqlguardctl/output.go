@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// table is the tabular form of a response
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+// printResult writes a response in the selected format. JSON and YAML print
+// the response as the API returned it; the table shows a summary.
+func printResult(w io.Writer, format string, raw json.RawMessage, summary func() (table, error)) error {
+	switch format {
+	case formatJSON:
+		var out interface{}
+		if err := json.Unmarshal(raw, &out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	case formatYAML:
+		return writeYAML(w, raw)
+	default:
+		t, err := summary()
+		if err != nil {
+			return err
+		}
+		return writeTable(w, t)
+	}
+}
+
+// writeTable aligns the rows under upper-case headers
+func writeTable(w io.Writer, t table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.headers, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeYAML converts JSON to block-style YAML, keeping the field order
+func writeYAML(w io.Writer, raw json.RawMessage) error {
+	var node yaml.Node
+	if err := yaml.Unmarshal(raw, &node); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	clearStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// clearStyle drops the flow and quoting styles JSON input carries so the
+// encoder picks the plain YAML forms
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// formatTime shows a time in the local zone, or "-" for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// orDash returns "-" for empty table cells
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
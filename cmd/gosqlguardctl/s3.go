@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// s3Response is the envelope of the S3 configuration endpoints, which report
+// failures with success=false rather than only the HTTP status
+type s3Response struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// postS3 sends an S3 definition and fails when the server reports an error
+func (a *app) postS3(path, file string) (s3Response, error) {
+	body, err := readManifest(file)
+	if err != nil {
+		return s3Response{}, err
+	}
+	c, err := a.api()
+	if err != nil {
+		return s3Response{}, err
+	}
+
+	var resp s3Response
+	if err := c.post(path, nil, body, &resp); err != nil {
+		return s3Response{}, err
+	}
+	if !resp.Success {
+		return s3Response{}, errors.New(resp.Message)
+	}
+	return resp, nil
+}
+
+// getS3Config shows the S3 settings; the secret key is never returned
+func getS3Config(a *app, args []string) error {
+	if _, err := parse(a.flags("s3 get", ""), args, 0); err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	var resp s3Response
+	if err := c.get("/api/s3", nil, &resp); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.Message)
+	}
+
+	return printResult(a.out, a.format, resp.Data, func() (table, error) {
+		var settings map[string]interface{}
+		if err := json.Unmarshal(resp.Data, &settings); err != nil {
+			return table{}, fmt.Errorf("failed to decode S3 settings: %w", err)
+		}
+		keys := make([]string, 0, len(settings))
+		for key := range settings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		t := table{headers: []string{"SETTING", "VALUE"}}
+		for _, key := range keys {
+			t.rows = append(t.rows, []string{key, orDash(fmt.Sprint(settings[key]))})
+		}
+		return t, nil
+	})
+}
+
+// setS3Config replaces the S3 settings. The response echoes the request,
+// secret key included, so only its message is printed.
+func setS3Config(a *app, args []string) error {
+	fs := a.flags("s3 set", "-f FILE")
+	file := fs.String("f", "", "JSON or YAML S3 settings with the keys enabled, region, bucket, prefix, endpoint, access_key, secret_key, use_ssl and insecure_ssl, or - for standard input (required)")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	resp, err := a.postS3("/api/s3", *file)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, resp.Message)
+	return nil
+}
+
+// testS3Config checks that a bucket can be reached with the given settings
+func testS3Config(a *app, args []string) error {
+	fs := a.flags("s3 test", "-f FILE")
+	file := fs.String("f", "", "JSON or YAML S3 settings, or - for standard input (required)")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	resp, err := a.postS3("/api/s3/test", *file)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, resp.Message)
+	return nil
+}
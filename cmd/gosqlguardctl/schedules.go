@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleSummary holds the schedule fields shown in tables and used for lookups
+type scheduleSummary struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	BackupType     string     `json:"backupType"`
+	CronExpression string     `json:"cronExpression"`
+	Timezone       string     `json:"timezone"`
+	Enabled        bool       `json:"enabled"`
+	TargetsSummary string     `json:"targetsSummary"`
+	Paused         bool       `json:"paused"`
+	PausedUntil    *time.Time `json:"pausedUntil"`
+	SkipNextAt     *time.Time `json:"skipNextAt"`
+}
+
+// state describes whether the schedule will fire
+func (s scheduleSummary) state() string {
+	switch {
+	case !s.Enabled:
+		return "disabled"
+	case s.Paused && s.PausedUntil != nil:
+		return "paused until " + formatTime(*s.PausedUntil)
+	case s.Paused:
+		return "paused"
+	case s.SkipNextAt != nil:
+		return "skipping " + formatTime(*s.SkipNextAt)
+	default:
+		return "active"
+	}
+}
+
+func schedulesTable(schedules []scheduleSummary) table {
+	t := table{headers: []string{"NAME", "TYPE", "CRON", "TIMEZONE", "TARGETS", "STATE", "ID"}}
+	for _, s := range schedules {
+		t.rows = append(t.rows, []string{
+			s.Name, s.BackupType, s.CronExpression, orDash(s.Timezone), orDash(s.TargetsSummary), s.state(), s.ID,
+		})
+	}
+	return t
+}
+
+// fetchSchedules returns the schedules as the API sent them along with their summaries
+func fetchSchedules(c *Client) ([]json.RawMessage, []scheduleSummary, error) {
+	var raw []json.RawMessage
+	if err := c.get("/api/schedules", nil, &raw); err != nil {
+		return nil, nil, err
+	}
+	summaries := make([]scheduleSummary, len(raw))
+	for i, item := range raw {
+		if err := json.Unmarshal(item, &summaries[i]); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode schedule: %w", err)
+		}
+	}
+	return raw, summaries, nil
+}
+
+// findSchedule returns the schedule with the given name, ID or backup type
+func findSchedule(c *Client, ref string) (json.RawMessage, scheduleSummary, error) {
+	raw, summaries, err := fetchSchedules(c)
+	if err != nil {
+		return nil, scheduleSummary{}, err
+	}
+	for i, s := range summaries {
+		if s.Name == ref || s.ID == ref {
+			return raw[i], s, nil
+		}
+	}
+	for i, s := range summaries {
+		if s.BackupType == ref {
+			return raw[i], s, nil
+		}
+	}
+	return nil, scheduleSummary{}, fmt.Errorf("schedule %q not found", ref)
+}
+
+// printSchedule writes a single schedule response
+func (a *app) printSchedule(raw json.RawMessage) error {
+	return printResult(a.out, a.format, raw, func() (table, error) {
+		var summary scheduleSummary
+		err := json.Unmarshal(raw, &summary)
+		return schedulesTable([]scheduleSummary{summary}), err
+	})
+}
+
+func listSchedules(a *app, args []string) error {
+	if _, err := parse(a.flags("schedules list", ""), args, 0); err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	raw, summaries, err := fetchSchedules(c)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		raw = []json.RawMessage{}
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return printResult(a.out, a.format, data, func() (table, error) {
+		return schedulesTable(summaries), nil
+	})
+}
+
+func getSchedule(a *app, args []string) error {
+	rest, err := parse(a.flags("schedules get", "NAME|ID|TYPE"), args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	raw, _, err := findSchedule(c, rest[0])
+	if err != nil {
+		return err
+	}
+	return a.printSchedule(raw)
+}
+
+// applySchedule creates a schedule, or updates the one with the same ID
+func applySchedule(a *app, args []string) error {
+	fs := a.flags("schedules apply", "-f FILE")
+	file := fs.String("f", "", "JSON or YAML schedule definition, or - for standard input (required)")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	body, err := readManifest(*file)
+	if err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+
+	var raw json.RawMessage
+	if err := c.post("/api/schedules", nil, body, &raw); err != nil {
+		return err
+	}
+	return a.printSchedule(raw)
+}
+
+func deleteSchedule(a *app, args []string) error {
+	rest, err := parse(a.flags("schedules delete", "NAME|ID|TYPE"), args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	_, summary, err := findSchedule(c, rest[0])
+	if err != nil {
+		return err
+	}
+	if err := c.post("/api/schedules/delete", url.Values{"id": {summary.ID}}, nil, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Schedule %s deleted\n", summary.Name)
+	return nil
+}
+
+// controlSchedule sends a pause, resume or skip-next request for a schedule
+func (a *app) controlSchedule(ref, path string, query url.Values, body interface{}) error {
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	_, summary, err := findSchedule(c, ref)
+	if err != nil {
+		return err
+	}
+
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("id", summary.ID)
+	var raw json.RawMessage
+	if err := c.post(path, query, body, &raw); err != nil {
+		return err
+	}
+	return a.printSchedule(raw)
+}
+
+func pauseSchedule(a *app, args []string) error {
+	fs := a.flags("schedules pause", "NAME|ID|TYPE [--for DURATION | --until TIME] [--reason TEXT]")
+	duration := fs.String("for", "", "Resume automatically after this long, such as 4h")
+	until := fs.String("until", "", "Resume automatically at this RFC 3339 time")
+	reason := fs.String("reason", "", "Why the schedule is paused")
+	rest, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *duration != "" && *until != "" {
+		return usageErrorf("set either --for or --until, not both")
+	}
+
+	body := map[string]string{}
+	if *duration != "" {
+		body["duration"] = *duration
+	}
+	if *until != "" {
+		if _, err := time.Parse(time.RFC3339, *until); err != nil {
+			return usageErrorf("invalid --until %q: expected an RFC 3339 time", *until)
+		}
+		body["until"] = *until
+	}
+	if *reason != "" {
+		body["reason"] = *reason
+	}
+	return a.controlSchedule(rest[0], "/api/schedules/pause", nil, body)
+}
+
+func resumeSchedule(a *app, args []string) error {
+	rest, err := parse(a.flags("schedules resume", "NAME|ID|TYPE"), args, 1)
+	if err != nil {
+		return err
+	}
+	return a.controlSchedule(rest[0], "/api/schedules/resume", nil, nil)
+}
+
+func skipNextSchedule(a *app, args []string) error {
+	fs := a.flags("schedules skip-next", "NAME|ID|TYPE [--cancel]")
+	cancel := fs.Bool("cancel", false, "Run the skipped run after all")
+	rest, err := parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	var query url.Values
+	if *cancel {
+		query = url.Values{"cancel": {"true"}}
+	}
+	return a.controlSchedule(rest[0], "/api/schedules/skip-next", query, nil)
+}
+
+// upcomingSchedule is an entry of GET /api/schedules/upcoming
+type upcomingSchedule struct {
+	BackupType string `json:"backupType"`
+	Timezone   string `json:"timezone"`
+	NextRuns   []struct {
+		Time     time.Time `json:"time"`
+		Blackout string    `json:"blackout"`
+		Action   string    `json:"action"`
+	} `json:"nextRuns"`
+}
+
+// upcomingRuns lists the next runs of each active schedule
+func upcomingRuns(a *app, args []string) error {
+	fs := a.flags("schedules upcoming", "[--type TYPE] [--count N]")
+	backupType := fs.String("type", "", "Only list runs of this backup type")
+	count := fs.Int("count", 0, "Runs to list per schedule; defaults to the server's default")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if *backupType != "" {
+		query.Set("backupType", *backupType)
+	}
+	if *count > 0 {
+		query.Set("count", strconv.Itoa(*count))
+	}
+
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	var raw json.RawMessage
+	if err := c.get("/api/schedules/upcoming", query, &raw); err != nil {
+		return err
+	}
+	return printResult(a.out, a.format, raw, func() (table, error) {
+		var schedules []upcomingSchedule
+		if err := json.Unmarshal(raw, &schedules); err != nil {
+			return table{}, fmt.Errorf("failed to decode upcoming runs: %w", err)
+		}
+		t := table{headers: []string{"TYPE", "TIME", "TIMEZONE", "NOTE"}}
+		for _, s := range schedules {
+			for _, run := range s.NextRuns {
+				var note []string
+				for _, part := range []string{run.Action, run.Blackout} {
+					if part != "" {
+						note = append(note, part)
+					}
+				}
+				t.rows = append(t.rows, []string{s.BackupType, formatTime(run.Time), s.Timezone, orDash(strings.Join(note, ": "))})
+			}
+		}
+		return t, nil
+	})
+}
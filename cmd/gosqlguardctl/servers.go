@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// serverSummary holds the server fields shown in tables and used for lookups
+type serverSummary struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Username string `json:"username"`
+}
+
+func serversTable(servers []serverSummary) table {
+	t := table{headers: []string{"NAME", "TYPE", "HOST", "PORT", "USER", "ID"}}
+	for _, s := range servers {
+		t.rows = append(t.rows, []string{s.Name, s.Type, s.Host, orDash(s.Port), s.Username, s.ID})
+	}
+	return t
+}
+
+// fetchServers returns the servers as the API sent them along with their summaries
+func fetchServers(c *Client) ([]json.RawMessage, []serverSummary, error) {
+	var raw []json.RawMessage
+	if err := c.get("/api/servers", nil, &raw); err != nil {
+		return nil, nil, err
+	}
+	summaries := make([]serverSummary, len(raw))
+	for i, item := range raw {
+		if err := json.Unmarshal(item, &summaries[i]); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode server: %w", err)
+		}
+	}
+	return raw, summaries, nil
+}
+
+// findServer returns the server with the given name or ID
+func findServer(c *Client, nameOrID string) (json.RawMessage, serverSummary, error) {
+	raw, summaries, err := fetchServers(c)
+	if err != nil {
+		return nil, serverSummary{}, err
+	}
+	for i, s := range summaries {
+		if s.Name == nameOrID || s.ID == nameOrID {
+			return raw[i], s, nil
+		}
+	}
+	return nil, serverSummary{}, fmt.Errorf("server %q not found", nameOrID)
+}
+
+func listServers(a *app, args []string) error {
+	if _, err := parse(a.flags("servers list", ""), args, 0); err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	raw, summaries, err := fetchServers(c)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		raw = []json.RawMessage{}
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return printResult(a.out, a.format, data, func() (table, error) {
+		return serversTable(summaries), nil
+	})
+}
+
+func getServer(a *app, args []string) error {
+	rest, err := parse(a.flags("servers get", "NAME|ID"), args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	raw, summary, err := findServer(c, rest[0])
+	if err != nil {
+		return err
+	}
+	return printResult(a.out, a.format, raw, func() (table, error) {
+		return serversTable([]serverSummary{summary}), nil
+	})
+}
+
+// applyServer creates a server, or updates the one with the same ID or name
+func applyServer(a *app, args []string) error {
+	fs := a.flags("servers apply", "-f FILE")
+	file := fs.String("f", "", "JSON or YAML server definition, or - for standard input (required)")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	body, err := readManifest(*file)
+	if err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+
+	var raw json.RawMessage
+	if err := c.post("/api/servers", nil, body, &raw); err != nil {
+		return err
+	}
+	return printResult(a.out, a.format, raw, func() (table, error) {
+		var summary serverSummary
+		err := json.Unmarshal(raw, &summary)
+		return serversTable([]serverSummary{summary}), err
+	})
+}
+
+func deleteServer(a *app, args []string) error {
+	rest, err := parse(a.flags("servers delete", "NAME|ID"), args, 1)
+	if err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	_, summary, err := findServer(c, rest[0])
+	if err != nil {
+		return err
+	}
+	if err := c.post("/api/servers/delete", url.Values{"id": {summary.ID}}, nil, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "Server %s deleted\n", summary.Name)
+	return nil
+}
+
+// testServer checks that the server in a definition can be reached
+func testServer(a *app, args []string) error {
+	fs := a.flags("servers test", "-f FILE")
+	file := fs.String("f", "", "JSON or YAML server definition, or - for standard input (required)")
+	if _, err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	body, err := readManifest(*file)
+	if err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Message   string   `json:"message"`
+		Databases []string `json:"databases"`
+	}
+	if err := c.post("/api/servers/test", nil, body, &result); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, result.Message)
+	if len(result.Databases) > 0 {
+		fmt.Fprintf(a.out, "Databases: %s\n", strings.Join(result.Databases, ", "))
+	}
+	return nil
+}
+
+// readManifest reads a JSON or YAML definition to send to the API
+func readManifest(path string) (interface{}, error) {
+	if path == "" {
+		return nil, usageErrorf("-f is required")
+	}
+
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	// YAML is a superset of JSON, so one decoder handles both
+	var body map[string]interface{}
+	if err := yaml.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if body == nil {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	return body, nil
+}
@@ -1,23 +1,25 @@
-// metadata-recovery is a command-line tool to reconstruct GoSQLGuard metadata from existing backup files
+// metadata-recovery is a command-line tool to reconstruct GoSQLGuard metadata from existing backup files.
+// Backups are rebuilt from the .manifest.json stored next to each file and S3
+// object; older backups without a manifest are recognized by their path.
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/supporttools/GoSQLGuard/pkg/config"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+	"github.com/supporttools/GoSQLGuard/pkg/storage/s3"
 )
 
 var (
@@ -28,40 +30,75 @@ var (
 	scanS3       = flag.Bool("s3", true, "Scan S3 storage for backups")
 	forceRebuild = flag.Bool("force", false, "Force rebuild even if metadata exists")
 	mergeMode    = flag.Bool("merge", false, "Merge with existing metadata instead of replacing")
-	
-	// Regex pattern for backup filenames
-	// Format: {server}-{database}-{type}-{timestamp}.sql.gz
-	backupFilePattern = regexp.MustCompile(`^(.+?)-(.+?)-(hourly|daily|weekly|monthly|yearly|manual)-(\d{8}-\d{6})\.sql\.gz$`)
+	outputFile   = flag.String("output", "", "Metadata file to write (default: metadata.json in the local backup directory)")
+
+	// backupFilePattern matches the backup file names GoSQLGuard writes:
+	// {database}-{YYYY-MM-DD-HH-MM-SS}.sql.gz, prefixed with "{server}_" in
+	// the by-type layout
+	backupFilePattern = regexp.MustCompile(`^(.+)-(\d{4}-\d{2}-\d{2}-\d{2}-\d{2}-\d{2})\.sql\.gz$`)
+)
+
+// timestampLayout is the layout of the timestamp in backup file names
+const timestampLayout = "2006-01-02-15-04-05"
+
+// Storage locations of a recovered copy
+const (
+	locationLocal = "local"
+	locationS3    = "s3"
 )
 
-// RecoveredBackup represents a backup found during recovery
+// pathInfo is what a backup's path tells about it
+type pathInfo struct {
+	Organization string // by-server or by-type
+	ServerName   string
+	Database     string
+	BackupType   string
+	Timestamp    string
+}
+
+// key identifies the backup a path belongs to, whichever layout holds it
+func (p pathInfo) key() string {
+	return strings.Join([]string{p.ServerName, p.Database, p.BackupType, p.Timestamp}, "|")
+}
+
+// RecoveredBackup is one stored copy of a backup found during recovery
 type RecoveredBackup struct {
-	Filename   string
-	Path       string
-	Size       int64
-	ModTime    time.Time
-	ServerName string
-	Database   string
-	BackupType string
-	Timestamp  string
-	IsS3       bool
-	S3Bucket   string
-	S3Key      string
+	Location string // local or s3
+	Path     string // File path or S3 key
+	Size     int64
+	ModTime  time.Time
+
+	// Manifest is the copy's manifest, nil for backups written before
+	// manifests existed
+	Manifest *types.Manifest
+
+	// Info is parsed from the path; Parsed is false when the path follows
+	// neither layout
+	Info   pathInfo
+	Parsed bool
 }
 
 func main() {
 	flag.Parse()
 
-	// Load configuration from MySQL
+	// Load configuration from the environment
 	config.LoadConfiguration()
 
-	// Initialize metadata system
-	if err := metadata.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize metadata: %v", err)
+	path := *outputFile
+	if path == "" {
+		if !config.CFG.Local.Enabled {
+			log.Fatal("Local storage is disabled; set -output to choose where to write the metadata file")
+		}
+		path = filepath.Join(config.CFG.Local.BackupDirectory, "metadata.json")
+	}
+
+	store, err := metadata.OpenFileStore(path)
+	if err != nil {
+		log.Fatalf("Failed to open metadata file %s: %v", path, err)
 	}
 
 	// Check if metadata already exists
-	existingBackups := metadata.DefaultStore.GetBackups()
+	existingBackups := store.GetBackups()
 	if len(existingBackups) > 0 && !*forceRebuild && !*mergeMode {
 		log.Printf("Found existing metadata with %d backups. Use -force to rebuild or -merge to merge.", len(existingBackups))
 		os.Exit(0)
@@ -70,261 +107,411 @@ func main() {
 	log.Println("Starting metadata recovery process...")
 
 	// Scan for backups
-	var recoveredBackups []RecoveredBackup
+	var copies []RecoveredBackup
+	scanned := map[string]bool{}
 
 	if *scanLocal && config.CFG.Local.Enabled {
-		localBackups := scanLocalStorage()
-		recoveredBackups = append(recoveredBackups, localBackups...)
-		log.Printf("Found %d backups in local storage", len(localBackups))
+		localCopies, err := scanLocalStorage(config.CFG.Local.BackupDirectory)
+		if err != nil {
+			log.Fatalf("Failed to scan local storage: %v", err)
+		}
+		copies = append(copies, localCopies...)
+		scanned[locationLocal] = true
+		log.Printf("Found %d backup files in local storage", len(localCopies))
 	}
 
 	if *scanS3 && config.CFG.S3.Enabled {
-		s3Backups := scanS3Storage()
-		recoveredBackups = append(recoveredBackups, s3Backups...)
-		log.Printf("Found %d backups in S3 storage", len(s3Backups))
+		s3Copies, err := scanS3Storage()
+		if err != nil {
+			log.Fatalf("Failed to scan S3 storage: %v", err)
+		}
+		copies = append(copies, s3Copies...)
+		scanned[locationS3] = true
+		log.Printf("Found %d backup objects in S3 storage", len(s3Copies))
 	}
 
-	// Process recovered backups
-	processRecoveredBackups(recoveredBackups)
+	// Server names may contain underscores, which the by-type layout also
+	// uses to separate the server from the database
+	servers := make([]string, 0, len(config.CFG.DatabaseServers))
+	for _, server := range config.CFG.DatabaseServers {
+		servers = append(servers, server.Name)
+	}
+	resolveByTypeServers(copies, servers)
+
+	backups := reconcileBackups(copies, scanned, serverType)
+	added := store.ImportBackups(backups, !*mergeMode)
 
 	// Summary
-	stats := metadata.DefaultStore.GetStats()
+	withManifest := 0
+	for _, c := range copies {
+		if c.Manifest != nil {
+			withManifest++
+		}
+	}
+	stats := store.GetStats()
 	log.Printf("\nRecovery Summary:")
-	log.Printf("- Total backups recovered: %d", stats["totalBackups"])
-	log.Printf("- Successful backups: %d", stats["successCount"])
-	log.Printf("- Failed backups: %d", stats["errorCount"])
+	log.Printf("- Copies found: %d (%d with a manifest)", len(copies), withManifest)
+	log.Printf("- Backups recovered: %d (%d added)", len(backups), added)
+	log.Printf("- Total backups in metadata: %d", stats["totalBackups"])
 	log.Printf("- Total local size: %s", formatBytes(stats["totalLocalSize"].(int64)))
 	log.Printf("- Total S3 size: %s", formatBytes(stats["totalS3Size"].(int64)))
 
 	if !*dryRun {
-		if err := metadata.DefaultStore.Save(); err != nil {
+		if err := store.Save(); err != nil {
 			log.Fatalf("Failed to save metadata: %v", err)
 		}
-		log.Println("Metadata saved successfully!")
+		log.Printf("Metadata saved to %s", path)
+		if config.CFG.MetadataDB.Enabled {
+			log.Println("GoSQLGuard imports the recovered backups into the metadata database on its next start")
+		}
 	} else {
 		log.Println("Dry run completed - no changes were saved")
 	}
 }
 
-// scanLocalStorage scans local filesystem for backup files
-func scanLocalStorage() []RecoveredBackup {
-	var backups []RecoveredBackup
-	backupDir := config.CFG.Local.BackupDirectory
+// serverType returns the configured type of a server, assuming MySQL for
+// servers that are no longer configured
+func serverType(serverName string) string {
+	for _, server := range config.CFG.DatabaseServers {
+		if server.Name == serverName && server.Type != "" {
+			return server.Type
+		}
+	}
+	return "mysql"
+}
 
-	err := filepath.Walk(backupDir, func(path string, info os.FileInfo, err error) error {
+// parseBackupPath recognizes the by-server and by-type layouts from a path
+// relative to the backup directory or S3 prefix:
+//
+//	by-server/{server}/{type}/{database}-{timestamp}.sql.gz
+//	by-type/{type}/{server}_{database}-{timestamp}.sql.gz
+//
+// A by-type file name is split at its first underscore; resolveByTypeServers
+// corrects the split for server names containing one.
+func parseBackupPath(rel string) (pathInfo, bool) {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+
+	var info pathInfo
+	var filename string
+	switch {
+	case len(parts) == 4 && parts[0] == "by-server":
+		info = pathInfo{Organization: parts[0], ServerName: parts[1], BackupType: parts[2]}
+		filename = parts[3]
+	case len(parts) == 3 && parts[0] == "by-type":
+		server, rest, ok := strings.Cut(parts[2], "_")
+		if !ok {
+			return pathInfo{}, false
+		}
+		info = pathInfo{Organization: parts[0], ServerName: server, BackupType: parts[1]}
+		filename = rest
+	default:
+		return pathInfo{}, false
+	}
+
+	matches := backupFilePattern.FindStringSubmatch(filename)
+	if matches == nil || info.ServerName == "" || info.BackupType == "" {
+		return pathInfo{}, false
+	}
+	info.Database = matches[1]
+	info.Timestamp = matches[2]
+	return info, true
+}
+
+// resolveByTypeServers splits the server from the database again in by-type
+// copies, using the longest of the given server names or those found in the
+// by-server layout that the file name starts with
+func resolveByTypeServers(copies []RecoveredBackup, servers []string) {
+	known := append([]string{}, servers...)
+	for _, c := range copies {
+		if c.Parsed && c.Info.Organization == "by-server" {
+			known = append(known, c.Info.ServerName)
+		}
+	}
+
+	for i := range copies {
+		info := &copies[i].Info
+		if !copies[i].Parsed || info.Organization != "by-type" {
+			continue
+		}
+		prefixed := info.ServerName + "_" + info.Database
+		server := ""
+		for _, name := range known {
+			if len(name) > len(server) && len(prefixed) > len(name)+1 && strings.HasPrefix(prefixed, name+"_") {
+				server = name
+			}
+		}
+		if server != "" {
+			info.ServerName = server
+			info.Database = prefixed[len(server)+1:]
+		}
+	}
+}
+
+// decodeManifest parses a manifest, rejecting ones without a backup ID
+func decodeManifest(data []byte) (*types.Manifest, error) {
+	var manifest types.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Backup.ID == "" {
+		return nil, fmt.Errorf("manifest has no backup ID")
+	}
+	return &manifest, nil
+}
+
+// scanLocalStorage finds the backup files under dir with their manifests
+func scanLocalStorage(dir string) ([]RecoveredBackup, error) {
+	var copies []RecoveredBackup
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			if *verbose {
 				log.Printf("Error accessing path %s: %v", path, err)
 			}
 			return nil // Continue walking
 		}
-
-		// Skip directories and non-backup files
-		if info.IsDir() || !strings.HasSuffix(info.Name(), ".sql.gz") {
+		if d.IsDir() || !strings.HasSuffix(path, ".sql.gz") {
 			return nil
 		}
 
-		// Skip metadata directory
-		if strings.Contains(path, ".metadata") {
+		info, err := d.Info()
+		if err != nil {
 			return nil
 		}
+		found := RecoveredBackup{Location: locationLocal, Path: path, Size: info.Size(), ModTime: info.ModTime()}
 
-		// Parse filename
-		matches := backupFilePattern.FindStringSubmatch(info.Name())
-		if matches == nil {
-			if *verbose {
-				log.Printf("Skipping file with non-standard name: %s", info.Name())
+		if data, err := os.ReadFile(types.ManifestPath(path)); err == nil {
+			if found.Manifest, err = decodeManifest(data); err != nil {
+				log.Printf("Ignoring unreadable manifest of %s: %v", path, err)
 			}
-			return nil
 		}
 
-		backup := RecoveredBackup{
-			Filename:   info.Name(),
-			Path:       path,
-			Size:       info.Size(),
-			ModTime:    info.ModTime(),
-			ServerName: matches[1],
-			Database:   matches[2],
-			BackupType: matches[3],
-			Timestamp:  matches[4],
-			IsS3:       false,
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			found.Info, found.Parsed = parseBackupPath(rel)
+		}
+		if found.Manifest == nil && !found.Parsed {
+			if *verbose {
+				log.Printf("Skipping file with non-standard path and no manifest: %s", path)
+			}
+			return nil
 		}
 
-		backups = append(backups, backup)
+		copies = append(copies, found)
 		return nil
 	})
-
 	if err != nil {
-		log.Printf("Error walking backup directory: %v", err)
+		return nil, err
 	}
 
-	return backups
+	return copies, nil
 }
 
-// scanS3Storage scans S3 bucket for backup files
-func scanS3Storage() []RecoveredBackup {
-	var backups []RecoveredBackup
-
-	// Create S3 session
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(config.CFG.S3.Region),
-		Credentials: credentials.NewStaticCredentials(
-			config.CFG.S3.AccessKey,
-			config.CFG.S3.SecretKey,
-			"",
-		),
-		Endpoint:         aws.String(config.CFG.S3.Endpoint),
-		S3ForcePathStyle: aws.Bool(config.CFG.S3.PathStyle),
-	})
+// scanS3Storage finds the backup objects under the configured prefix with
+// their manifests
+func scanS3Storage() ([]RecoveredBackup, error) {
+	client, err := s3.NewClient()
 	if err != nil {
-		log.Printf("Failed to create S3 session: %v", err)
-		return backups
+		return nil, err
 	}
 
-	svc := s3.New(sess)
+	objects, err := client.ListBackupObjects()
+	if err != nil {
+		return nil, err
+	}
+	manifestObjects, err := client.ListManifests()
+	if err != nil {
+		return nil, err
+	}
+	manifests := make(map[string]bool, len(manifestObjects))
+	for _, obj := range manifestObjects {
+		manifests[obj.Key] = true
+	}
 
-	// List objects in bucket
-	params := &s3.ListObjectsV2Input{
-		Bucket: aws.String(config.CFG.S3.Bucket),
-		Prefix: aws.String(config.CFG.S3.Prefix),
+	prefix := strings.TrimSuffix(config.CFG.S3.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
 	}
 
-	err = svc.ListObjectsV2Pages(params, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
-		for _, obj := range page.Contents {
-			key := *obj.Key
-			
-			// Skip non-backup files
-			if !strings.HasSuffix(key, ".sql.gz") {
-				continue
-			}
+	var copies []RecoveredBackup
+	for _, obj := range objects {
+		found := RecoveredBackup{Location: locationS3, Path: obj.Key, Size: obj.Size, ModTime: obj.LastModified}
 
-			// Extract filename from key
-			filename := filepath.Base(key)
-			
-			// Parse filename
-			matches := backupFilePattern.FindStringSubmatch(filename)
-			if matches == nil {
-				if *verbose {
-					log.Printf("Skipping S3 object with non-standard name: %s", key)
-				}
-				continue
+		if manifestKey := types.ManifestPath(obj.Key); manifests[manifestKey] {
+			data, err := client.ReadObject(manifestKey)
+			if err == nil {
+				found.Manifest, err = decodeManifest(data)
+			}
+			if err != nil {
+				log.Printf("Ignoring unreadable manifest of %s: %v", obj.Key, err)
 			}
+		}
 
-			backup := RecoveredBackup{
-				Filename:   filename,
-				Path:       key,
-				Size:       *obj.Size,
-				ModTime:    *obj.LastModified,
-				ServerName: matches[1],
-				Database:   matches[2],
-				BackupType: matches[3],
-				Timestamp:  matches[4],
-				IsS3:       true,
-				S3Bucket:   config.CFG.S3.Bucket,
-				S3Key:      key,
+		found.Info, found.Parsed = parseBackupPath(strings.TrimPrefix(obj.Key, prefix))
+		if found.Manifest == nil && !found.Parsed {
+			if *verbose {
+				log.Printf("Skipping S3 object with non-standard key and no manifest: %s", obj.Key)
 			}
+			continue
+		}
 
-			backups = append(backups, backup)
+		copies = append(copies, found)
+	}
+
+	return copies, nil
+}
+
+// reconcileBackups groups the copies found in storage into one metadata entry
+// per backup. A backup with a manifest keeps the metadata it records, with its
+// paths and keys replaced by the copies found in the scanned locations. A
+// backup without one is rebuilt from its path. typeOf gives the server type of
+// backups rebuilt from their path.
+func reconcileBackups(copies []RecoveredBackup, scanned map[string]bool, typeOf func(serverName string) string) []types.BackupMeta {
+	// Copies without a manifest join the backup of a manifest at another of
+	// its paths, as when a manifest upload failed
+	manifestIDs := make(map[string]string)
+	for _, c := range copies {
+		if c.Manifest != nil && c.Parsed {
+			manifestIDs[c.Info.key()] = c.Manifest.Backup.ID
 		}
-		return true // Continue to next page
-	})
+	}
 
-	if err != nil {
-		log.Printf("Error listing S3 objects: %v", err)
+	groups := make(map[string][]RecoveredBackup)
+	var order []string
+	for _, c := range copies {
+		var id string
+		switch {
+		case c.Manifest != nil:
+			id = c.Manifest.Backup.ID
+		case manifestIDs[c.Info.key()] != "":
+			id = manifestIDs[c.Info.key()]
+		default:
+			id = pathBackupID(c.Info)
+		}
+		if _, ok := groups[id]; !ok {
+			order = append(order, id)
+		}
+		groups[id] = append(groups[id], c)
+	}
+
+	backups := make([]types.BackupMeta, 0, len(groups))
+	for _, id := range order {
+		group := groups[id]
+		if *verbose && len(group) > 1 {
+			log.Printf("Found %d copies of backup %s", len(group), id)
+		}
+		backups = append(backups, rebuildBackup(id, group, scanned, typeOf))
 	}
 
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.Before(backups[j].CreatedAt) })
 	return backups
 }
 
-// processRecoveredBackups processes recovered backups and updates metadata
-func processRecoveredBackups(backups []RecoveredBackup) {
-	existingIDs := make(map[string]bool)
-	
-	// Get existing backup IDs if in merge mode
-	if *mergeMode {
-		for _, backup := range metadata.DefaultStore.GetBackups() {
-			existingIDs[backup.ID] = true
+// pathBackupID builds the ID of a backup without a manifest in the format
+// the metadata store uses
+func pathBackupID(info pathInfo) string {
+	id := info.Timestamp
+	if t, err := time.ParseInLocation(timestampLayout, info.Timestamp, time.Local); err == nil {
+		id = t.Format("20060102-150405")
+	}
+	return fmt.Sprintf("%s-%s-%s-%s", info.ServerName, info.Database, info.BackupType, id)
+}
+
+// rebuildBackup builds the metadata entry of one backup from its copies
+func rebuildBackup(id string, group []RecoveredBackup, scanned map[string]bool, typeOf func(serverName string) string) types.BackupMeta {
+	var meta types.BackupMeta
+	var manifest *types.Manifest
+	for _, c := range group {
+		if c.Manifest != nil && (manifest == nil || c.Manifest.WrittenAt.After(manifest.WrittenAt)) {
+			manifest = c.Manifest
 		}
 	}
 
-	// Process each recovered backup
-	for _, backup := range backups {
-		// Generate ID
-		id := fmt.Sprintf("%s-%s-%s-%s", backup.ServerName, backup.Database, backup.BackupType, backup.Timestamp)
-		
-		// Skip if already exists in merge mode
-		if existingIDs[id] {
-			if *verbose {
-				log.Printf("Skipping existing backup: %s", id)
-			}
-			continue
+	if manifest != nil {
+		meta = manifest.Backup
+	} else {
+		info := group[0].Info
+		meta = types.BackupMeta{
+			ID:         id,
+			ServerName: info.ServerName,
+			ServerType: typeOf(info.ServerName),
+			Database:   info.Database,
+			BackupType: info.BackupType,
+			Status:     types.StatusSuccess,
+		}
+		if t, err := time.ParseInLocation(timestampLayout, info.Timestamp, time.Local); err == nil {
+			meta.CreatedAt = t
 		}
+	}
 
-		// Parse timestamp
-		createdAt, err := time.Parse("20060102-150405", backup.Timestamp)
-		if err != nil {
-			log.Printf("Failed to parse timestamp for %s: %v", backup.Filename, err)
-			createdAt = backup.ModTime // Fallback to file modification time
-		}
-
-		// Create backup metadata entry
-		backupMeta := metadata.DefaultStore.CreateBackupMeta(
-			backup.ServerName,
-			"mysql", // Assume MySQL for now
-			backup.Database,
-			backup.BackupType,
-		)
-
-		// Set additional fields
-		backupMeta.ID = id
-		backupMeta.CreatedAt = createdAt
-		backupMeta.CompletedAt = createdAt.Add(5 * time.Minute) // Estimate
-		backupMeta.Size = backup.Size
-
-		// Set paths based on storage location
-		if backup.IsS3 {
-			backupMeta.S3Keys = map[string]string{
-				"default": backup.S3Key,
-			}
-			backupMeta.S3UploadStatus = types.StatusSuccess
+	// The copies found replace the recorded ones in the locations scanned
+	localPaths := make(map[string]string)
+	s3Keys := make(map[string]string)
+	var modTime time.Time
+	for _, c := range group {
+		org := c.Info.Organization
+		if !c.Parsed {
+			org = "recovered"
+		}
+		if c.Location == locationS3 {
+			s3Keys[org] = c.Path
 		} else {
-			// Make path relative to backup directory
-			relPath, err := filepath.Rel(config.CFG.Local.BackupDirectory, backup.Path)
-			if err != nil {
-				relPath = backup.Path
-			}
-			backupMeta.LocalPaths = map[string]string{
-				"default": relPath,
-			}
+			localPaths[org] = c.Path
 		}
+		if meta.Size == 0 {
+			meta.Size = c.Size
+		}
+		if modTime.IsZero() || c.ModTime.Before(modTime) {
+			modTime = c.ModTime
+		}
+	}
+	if meta.CreatedAt.IsZero() {
+		meta.CreatedAt = modTime
+	}
+	if meta.CompletedAt.IsZero() {
+		meta.CompletedAt = modTime
+	}
 
-		// Update status
-		if err := metadata.DefaultStore.UpdateBackupStatus(
-			backupMeta.ID,
-			types.StatusSuccess,
-			backupMeta.LocalPaths,
-			backup.Size,
-			"",
-		); err != nil {
-			log.Printf("Error updating backup status for %s: %v", backupMeta.ID, err)
-		}
-
-		// Update S3 status if applicable
-		if backup.IsS3 {
-			if err := metadata.DefaultStore.UpdateS3UploadStatus(
-				backupMeta.ID,
-				types.StatusSuccess,
-				backupMeta.S3Keys,
-				"",
-			); err != nil {
-				log.Printf("Error updating S3 upload status for %s: %v", backupMeta.ID, err)
+	if scanned[locationLocal] || meta.LocalPaths == nil {
+		meta.LocalPaths = localPaths
+		meta.LocalPath = primaryPath(localPaths)
+	}
+	if scanned[locationS3] || meta.S3Keys == nil {
+		meta.S3Keys = s3Keys
+		meta.S3Key = primaryPath(s3Keys)
+		switch {
+		case len(s3Keys) > 0:
+			meta.S3UploadStatus = types.StatusSuccess
+			meta.S3UploadError = ""
+			if meta.S3UploadComplete.IsZero() {
+				meta.S3UploadComplete = meta.CompletedAt
 			}
+		case meta.S3UploadStatus == types.StatusSuccess:
+			// The recorded copies are gone
+			meta.S3UploadStatus = types.StatusDeleted
 		}
+	}
 
-		if *verbose {
-			log.Printf("Recovered backup: %s", id)
-		}
+	// A copy in storage means the backup finished, whatever was recorded
+	// while it ran
+	meta.Status = types.StatusSuccess
+	meta.ErrorMessage = ""
+	return meta
+}
+
+// primaryPath returns the by-server path or key, or any other when there is none
+func primaryPath(paths map[string]string) string {
+	if path, ok := paths["by-server"]; ok {
+		return path
+	}
+	orgs := make([]string, 0, len(paths))
+	for org := range paths {
+		orgs = append(orgs, org)
 	}
+	sort.Strings(orgs)
+	if len(orgs) > 0 {
+		return paths[orgs[0]]
+	}
+	return ""
 }
 
 // formatBytes formats bytes into human-readable format
@@ -340,50 +527,3 @@ func formatBytes(bytes int64) string {
 	}
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
-
-// reconcileBackups reconciles local and S3 backups to handle duplicates
-func reconcileBackups(backups []RecoveredBackup) []RecoveredBackup {
-	// Group backups by ID
-	backupMap := make(map[string][]RecoveredBackup)
-	
-	for _, backup := range backups {
-		id := fmt.Sprintf("%s-%s-%s-%s", backup.ServerName, backup.Database, backup.BackupType, backup.Timestamp)
-		backupMap[id] = append(backupMap[id], backup)
-	}
-
-	// Reconcile duplicates
-	var reconciled []RecoveredBackup
-	for id, group := range backupMap {
-		if len(group) == 1 {
-			reconciled = append(reconciled, group[0])
-			continue
-		}
-
-		// Multiple copies found - merge information
-		merged := group[0]
-		hasLocal := false
-		hasS3 := false
-
-		for _, backup := range group {
-			if backup.IsS3 {
-				hasS3 = true
-				merged.S3Bucket = backup.S3Bucket
-				merged.S3Key = backup.S3Key
-			} else {
-				hasLocal = true
-				merged.Path = backup.Path
-			}
-		}
-
-		// Update merged backup info
-		if hasLocal && hasS3 {
-			if *verbose {
-				log.Printf("Found backup %s in both local and S3 storage", id)
-			}
-		}
-
-		reconciled = append(reconciled, merged)
-	}
-
-	return reconciled
-}
\ No newline at end of file
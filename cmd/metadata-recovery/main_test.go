@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,238 +10,253 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
 )
 
-func TestBackupFilePattern(t *testing.T) {
+func TestParseBackupPath(t *testing.T) {
 	tests := []struct {
-		name      string
-		filename  string
+		name        string
+		path        string
 		shouldMatch bool
-		expected  map[string]string
+		expected    pathInfo
 	}{
 		{
-			name:        "Valid hourly backup",
-			filename:    "server1-database1-hourly-20250523-120000.sql.gz",
+			name:        "By-server layout",
+			path:        "by-server/server1/hourly/database1-2025-05-23-12-00-00.sql.gz",
 			shouldMatch: true,
-			expected: map[string]string{
-				"server":   "server1",
-				"database": "database1",
-				"type":     "hourly",
-				"timestamp": "20250523-120000",
-			},
+			expected:    pathInfo{Organization: "by-server", ServerName: "server1", Database: "database1", BackupType: "hourly", Timestamp: "2025-05-23-12-00-00"},
 		},
 		{
-			name:        "Valid daily backup with hyphen in server name",
-			filename:    "prod-server-users-daily-20250523-000000.sql.gz",
+			name:        "By-server layout with hyphens in names",
+			path:        "by-server/prod-server/daily/user-data-2025-05-23-00-00-00.sql.gz",
 			shouldMatch: true,
-			expected: map[string]string{
-				"server":   "prod-server",
-				"database": "users",
-				"type":     "daily",
-				"timestamp": "20250523-000000",
-			},
+			expected:    pathInfo{Organization: "by-server", ServerName: "prod-server", Database: "user-data", BackupType: "daily", Timestamp: "2025-05-23-00-00-00"},
 		},
 		{
-			name:        "Valid manual backup",
-			filename:    "test-data-manual-20250523-143052.sql.gz",
+			name:        "By-type layout",
+			path:        "by-type/manual/prod-server_app_db-2025-05-23-14-30-52.sql.gz",
 			shouldMatch: true,
-			expected: map[string]string{
-				"server":   "test",
-				"database": "data",
-				"type":     "manual",
-				"timestamp": "20250523-143052",
-			},
+			expected:    pathInfo{Organization: "by-type", ServerName: "prod-server", Database: "app_db", BackupType: "manual", Timestamp: "2025-05-23-14-30-52"},
 		},
 		{
-			name:        "Invalid extension",
-			filename:    "server1-database1-hourly-20250523-120000.sql",
-			shouldMatch: false,
+			name:        "Custom backup type",
+			path:        "by-server/server1/quarterly/db1-2025-05-23-12-00-00.sql.gz",
+			shouldMatch: true,
+			expected:    pathInfo{Organization: "by-server", ServerName: "server1", Database: "db1", BackupType: "quarterly", Timestamp: "2025-05-23-12-00-00"},
+		},
+		{
+			name: "Invalid extension",
+			path: "by-server/server1/hourly/database1-2025-05-23-12-00-00.sql",
 		},
 		{
-			name:        "Invalid format - missing type",
-			filename:    "server1-database1-20250523-120000.sql.gz",
-			shouldMatch: false,
+			name: "By-type layout without server prefix",
+			path: "by-type/hourly/database1-2025-05-23-12-00-00.sql.gz",
 		},
 		{
-			name:        "Invalid format - wrong timestamp",
-			filename:    "server1-database1-hourly-2025-05-23.sql.gz",
-			shouldMatch: false,
+			name: "Wrong timestamp",
+			path: "by-server/server1/hourly/database1-20250523-120000.sql.gz",
 		},
 		{
-			name:        "Invalid type",
-			filename:    "server1-database1-custom-20250523-120000.sql.gz",
-			shouldMatch: false,
+			name: "Unknown layout",
+			path: "hourly/server1-database1-hourly-20250523-120000.sql.gz",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			matches := backupFilePattern.FindStringSubmatch(tt.filename)
-			
+			info, ok := parseBackupPath(tt.path)
+			assert.Equal(t, tt.shouldMatch, ok)
 			if tt.shouldMatch {
-				require.NotNil(t, matches, "Expected pattern to match %s", tt.filename)
-				assert.Equal(t, tt.expected["server"], matches[1])
-				assert.Equal(t, tt.expected["database"], matches[2])
-				assert.Equal(t, tt.expected["type"], matches[3])
-				assert.Equal(t, tt.expected["timestamp"], matches[4])
-			} else {
-				assert.Nil(t, matches, "Expected pattern not to match %s", tt.filename)
+				assert.Equal(t, tt.expected, info)
 			}
 		})
 	}
 }
 
-func TestScanLocalStorage(t *testing.T) {
-	// Create temporary directory structure
-	tempDir, err := os.MkdirTemp("", "gosqlguard_recovery_test")
+// writeFile creates a file and its parent directories under dir
+func writeFile(t *testing.T, dir, path, content string) string {
+	t.Helper()
+	full := filepath.Join(dir, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(full), 0750))
+	require.NoError(t, os.WriteFile(full, []byte(content), 0600))
+	return full
+}
+
+// writeManifest stores a manifest next to a backup file
+func writeManifest(t *testing.T, path string, backup types.BackupMeta) {
+	t.Helper()
+	data, err := json.Marshal(types.Manifest{ManifestVersion: types.ManifestVersion, Backup: backup, Checksum: "sha256:abc"})
 	require.NoError(t, err)
-	defer os.RemoveAll(tempDir)
+	require.NoError(t, os.WriteFile(types.ManifestPath(path), data, 0600))
+}
 
-	// Create test backup files
-	testFiles := []struct {
-		path     string
-		content  string
-		valid    bool
-	}{
-		{
-			path:    "hourly/server1-db1-hourly-20250523-100000.sql.gz",
-			content: "backup data",
-			valid:   true,
-		},
-		{
-			path:    "daily/server1-db1-daily-20250523-000000.sql.gz",
-			content: "backup data",
-			valid:   true,
-		},
-		{
-			path:    "manual/prod-server-users-manual-20250523-143052.sql.gz",
-			content: "backup data",
-			valid:   true,
-		},
-		{
-			path:    "invalid/server1-db1.sql.gz",
-			content: "backup data",
-			valid:   false,
-		},
-		{
-			path:    ".metadata/metadata.json",
-			content: "{}",
-			valid:   false,
-		},
-		{
-			path:    "other/README.md",
-			content: "readme",
-			valid:   false,
-		},
-	}
+func TestScanLocalStorage(t *testing.T) {
+	dir := t.TempDir()
 
-	for _, tf := range testFiles {
-		fullPath := filepath.Join(tempDir, tf.path)
-		err := os.MkdirAll(filepath.Dir(fullPath), 0750)
-		require.NoError(t, err)
-		
-		err = os.WriteFile(fullPath, []byte(tf.content), 0644)
+	byServer := writeFile(t, dir, "by-server/server1/hourly/db1-2025-05-23-10-00-00.sql.gz", "backup data")
+	writeFile(t, dir, "by-type/hourly/server1_db1-2025-05-23-10-00-00.sql.gz", "backup data")
+	writeFile(t, dir, "by-server/prod-server/manual/users-2025-05-23-14-30-52.sql.gz", "backup data")
+	custom := writeFile(t, dir, "imported/anything.sql.gz", "backup data")
+	writeFile(t, dir, "invalid/server1-db1.sql.gz", "backup data")
+	writeFile(t, dir, "metadata.json", "{}")
+	writeFile(t, dir, "logs/backup.log", "log")
+
+	writeManifest(t, byServer, types.BackupMeta{ID: "server1-db1-hourly-20250523-100000", ServerName: "server1"})
+	writeManifest(t, custom, types.BackupMeta{ID: "custom-backup", ServerName: "server2"})
+
+	copies, err := scanLocalStorage(dir)
+	require.NoError(t, err)
+	assert.Len(t, copies, 4, "files with neither a known path nor a manifest are skipped")
+
+	byPath := make(map[string]RecoveredBackup)
+	for _, c := range copies {
+		assert.Equal(t, locationLocal, c.Location)
+		rel, err := filepath.Rel(dir, c.Path)
 		require.NoError(t, err)
+		byPath[filepath.ToSlash(rel)] = c
 	}
 
-	// Mock config for testing
-	oldBackupDir := backupDir
-	backupDir = tempDir
-	defer func() { backupDir = oldBackupDir }()
+	hourly := byPath["by-server/server1/hourly/db1-2025-05-23-10-00-00.sql.gz"]
+	require.NotNil(t, hourly.Manifest)
+	assert.Equal(t, "server1-db1-hourly-20250523-100000", hourly.Manifest.Backup.ID)
+	assert.True(t, hourly.Parsed)
 
-	// Scan local storage
-	backups := scanLocalStorage()
+	byType := byPath["by-type/hourly/server1_db1-2025-05-23-10-00-00.sql.gz"]
+	assert.Nil(t, byType.Manifest)
+	assert.Equal(t, "server1", byType.Info.ServerName)
+	assert.Equal(t, "db1", byType.Info.Database)
 
-	// Verify results
-	expectedCount := 3
-	assert.Len(t, backups, expectedCount, "Expected %d valid backups", expectedCount)
+	imported := byPath["imported/anything.sql.gz"]
+	require.NotNil(t, imported.Manifest)
+	assert.False(t, imported.Parsed)
+}
 
-	// Check specific backups
-	backupMap := make(map[string]RecoveredBackup)
-	for _, b := range backups {
-		backupMap[b.Filename] = b
+func TestReconcileBackups(t *testing.T) {
+	now := time.Now()
+	created := time.Date(2025, 5, 23, 10, 0, 0, 0, time.Local)
+	byServer, _ := parseBackupPath("by-server/server1/hourly/db1-2025-05-23-10-00-00.sql.gz")
+	byType, _ := parseBackupPath("by-type/hourly/server1_db1-2025-05-23-10-00-00.sql.gz")
+	legacy, _ := parseBackupPath("by-server/server2/daily/db2-2025-05-23-00-00-00.sql.gz")
+
+	manifest := &types.Manifest{Backup: types.BackupMeta{
+		ID:             "server1-db1-hourly-20250523-100001",
+		ServerName:     "server1",
+		ServerType:     "postgresql",
+		Database:       "db1",
+		BackupType:     "hourly",
+		CreatedAt:      created,
+		Size:           1024,
+		Status:         types.StatusSuccess,
+		LocalPaths:     map[string]string{"by-server": "/old/path"},
+		S3UploadStatus: types.StatusSuccess,
+		S3Keys:         map[string]string{"by-server": "backups/by-server/server1/hourly/db1-2025-05-23-10-00-00.sql.gz"},
+		FencingToken:   7,
+	}}
+
+	copies := []RecoveredBackup{
+		// Local copy with a manifest
+		{Location: locationLocal, Path: "/backups/by-server/server1/hourly/db1-2025-05-23-10-00-00.sql.gz", Size: 1024, ModTime: now, Manifest: manifest, Info: byServer, Parsed: true},
+		// Local by-type copy whose manifest is missing
+		{Location: locationLocal, Path: "/backups/by-type/hourly/server1_db1-2025-05-23-10-00-00.sql.gz", Size: 1024, ModTime: now, Info: byType, Parsed: true},
+		// S3 copy with the same manifest
+		{Location: locationS3, Path: "backups/by-server/server1/hourly/db1-2025-05-23-10-00-00.sql.gz", Size: 1024, ModTime: now, Manifest: manifest, Info: byServer, Parsed: true},
+		// Backup written before manifests existed
+		{Location: locationLocal, Path: "/backups/by-server/server2/daily/db2-2025-05-23-00-00-00.sql.gz", Size: 2048, ModTime: now, Info: legacy, Parsed: true},
 	}
 
-	// Verify hourly backup
-	hourly, ok := backupMap["server1-db1-hourly-20250523-100000.sql.gz"]
-	assert.True(t, ok, "Expected to find hourly backup")
-	assert.Equal(t, "server1", hourly.ServerName)
-	assert.Equal(t, "db1", hourly.Database)
-	assert.Equal(t, "hourly", hourly.BackupType)
-	assert.Equal(t, "20250523-100000", hourly.Timestamp)
-	assert.False(t, hourly.IsS3)
+	scanned := map[string]bool{locationLocal: true, locationS3: true}
+	backups := reconcileBackups(copies, scanned, func(string) string { return "mysql" })
+	require.Len(t, backups, 2)
 
-	// Verify manual backup with hyphenated server name
-	manual, ok := backupMap["prod-server-users-manual-20250523-143052.sql.gz"]
-	assert.True(t, ok, "Expected to find manual backup")
-	assert.Equal(t, "prod-server", manual.ServerName)
-	assert.Equal(t, "users", manual.Database)
+	fromPath, fromManifest := backups[0], backups[1]
+
+	// The manifest's metadata is kept, with the copies actually found
+	assert.Equal(t, manifest.Backup.ID, fromManifest.ID)
+	assert.Equal(t, "postgresql", fromManifest.ServerType)
+	assert.Equal(t, int64(7), fromManifest.FencingToken)
+	assert.Equal(t, map[string]string{
+		"by-server": "/backups/by-server/server1/hourly/db1-2025-05-23-10-00-00.sql.gz",
+		"by-type":   "/backups/by-type/hourly/server1_db1-2025-05-23-10-00-00.sql.gz",
+	}, fromManifest.LocalPaths)
+	assert.Equal(t, "/backups/by-server/server1/hourly/db1-2025-05-23-10-00-00.sql.gz", fromManifest.LocalPath)
+	assert.Equal(t, map[string]string{"by-server": "backups/by-server/server1/hourly/db1-2025-05-23-10-00-00.sql.gz"}, fromManifest.S3Keys)
+	assert.Equal(t, types.StatusSuccess, fromManifest.S3UploadStatus)
+
+	// The backup without a manifest is rebuilt from its path
+	assert.Equal(t, "server2-db2-daily-20250523-000000", fromPath.ID)
+	assert.Equal(t, "mysql", fromPath.ServerType)
+	assert.Equal(t, "db2", fromPath.Database)
+	assert.Equal(t, "daily", fromPath.BackupType)
+	assert.Equal(t, time.Date(2025, 5, 23, 0, 0, 0, 0, time.Local), fromPath.CreatedAt)
+	assert.Equal(t, int64(2048), fromPath.Size)
+	assert.Equal(t, types.StatusSuccess, fromPath.Status)
+	assert.Empty(t, fromPath.S3Keys)
 }
 
-func TestReconcileBackups(t *testing.T) {
+func TestResolveByTypeServers(t *testing.T) {
 	now := time.Now()
-	
-	testBackups := []RecoveredBackup{
-		// Duplicate - local
-		{
-			Filename:   "server1-db1-hourly-20250523-100000.sql.gz",
-			Path:       "/backups/hourly/server1-db1-hourly-20250523-100000.sql.gz",
-			Size:       1024,
-			ModTime:    now,
-			ServerName: "server1",
-			Database:   "db1",
-			BackupType: "hourly",
-			Timestamp:  "20250523-100000",
-			IsS3:       false,
-		},
-		// Duplicate - S3
-		{
-			Filename:   "server1-db1-hourly-20250523-100000.sql.gz",
-			Path:       "backups/hourly/server1-db1-hourly-20250523-100000.sql.gz",
-			Size:       1024,
-			ModTime:    now,
-			ServerName: "server1",
-			Database:   "db1",
-			BackupType: "hourly",
-			Timestamp:  "20250523-100000",
-			IsS3:       true,
-			S3Bucket:   "backup-bucket",
-			S3Key:      "backups/hourly/server1-db1-hourly-20250523-100000.sql.gz",
-		},
-		// Unique backup
-		{
-			Filename:   "server2-db2-daily-20250523-000000.sql.gz",
-			Path:       "/backups/daily/server2-db2-daily-20250523-000000.sql.gz",
-			Size:       2048,
-			ModTime:    now,
-			ServerName: "server2",
-			Database:   "db2",
-			BackupType: "daily",
-			Timestamp:  "20250523-000000",
-			IsS3:       false,
-		},
+	parsed := func(location, path string) RecoveredBackup {
+		info, ok := parseBackupPath(path)
+		require.True(t, ok, path)
+		return RecoveredBackup{Location: location, Path: path, Size: 1024, ModTime: now, Info: info, Parsed: true}
 	}
 
-	reconciled := reconcileBackups(testBackups)
+	copies := []RecoveredBackup{
+		// prod_eu is known from its by-server copy
+		parsed(locationLocal, "by-server/prod_eu/hourly/app-2025-05-23-10-00-00.sql.gz"),
+		parsed(locationS3, "by-type/hourly/prod_eu_app-2025-05-23-10-00-00.sql.gz"),
+		// db_main is only known from the configuration
+		parsed(locationLocal, "by-type/daily/db_main_billing_v2-2025-05-23-00-00-00.sql.gz"),
+		// An unknown server falls back to the first underscore
+		parsed(locationLocal, "by-type/daily/legacy_crm-2025-05-23-00-00-00.sql.gz"),
+	}
+	assert.Equal(t, "prod", copies[1].Info.ServerName, "the path alone splits at the first underscore")
+
+	resolveByTypeServers(copies, []string{"db", "db_main"})
 
-	// Should have 2 unique backups
-	assert.Len(t, reconciled, 2)
+	assert.Equal(t, "prod_eu", copies[1].Info.ServerName)
+	assert.Equal(t, "app", copies[1].Info.Database)
+	assert.Equal(t, "db_main", copies[2].Info.ServerName, "the longest matching name wins")
+	assert.Equal(t, "billing_v2", copies[2].Info.Database)
+	assert.Equal(t, "legacy", copies[3].Info.ServerName)
+	assert.Equal(t, "crm", copies[3].Info.Database)
 
-	// Find the reconciled duplicate
-	var merged RecoveredBackup
-	for _, b := range reconciled {
-		if b.ServerName == "server1" && b.Database == "db1" {
-			merged = b
-			break
+	// Both layouts of the prod_eu backup make one entry
+	backups := reconcileBackups(copies, map[string]bool{locationLocal: true, locationS3: true}, func(string) string { return "mysql" })
+	require.Len(t, backups, 3)
+	var prodEU types.BackupMeta
+	for _, b := range backups {
+		if b.ServerName == "prod_eu" {
+			prodEU = b
 		}
 	}
+	assert.Equal(t, "prod_eu-app-hourly-20250523-100000", prodEU.ID)
+	assert.Len(t, prodEU.LocalPaths, 1)
+	assert.Len(t, prodEU.S3Keys, 1)
+}
+
+func TestReconcileBackupsKeepsUnscannedLocations(t *testing.T) {
+	info, _ := parseBackupPath("by-server/server1/hourly/db1-2025-05-23-10-00-00.sql.gz")
+	manifest := &types.Manifest{Backup: types.BackupMeta{
+		ID:             "server1-db1-hourly-20250523-100000",
+		Status:         types.StatusSuccess,
+		S3UploadStatus: types.StatusSuccess,
+		S3Keys:         map[string]string{"by-server": "by-server/server1/hourly/db1-2025-05-23-10-00-00.sql.gz"},
+	}}
+	copies := []RecoveredBackup{
+		{Location: locationLocal, Path: "/backups/by-server/server1/hourly/db1-2025-05-23-10-00-00.sql.gz", Manifest: manifest, Info: info, Parsed: true},
+	}
 
-	// Verify merged backup has info from both sources
-	assert.Equal(t, "server1", merged.ServerName)
-	assert.Equal(t, "db1", merged.Database)
-	assert.Equal(t, "/backups/hourly/server1-db1-hourly-20250523-100000.sql.gz", merged.Path)
-	assert.Equal(t, "backup-bucket", merged.S3Bucket)
-	assert.Equal(t, "backups/hourly/server1-db1-hourly-20250523-100000.sql.gz", merged.S3Key)
+	// S3 was not scanned, so the recorded keys cannot be checked
+	backups := reconcileBackups(copies, map[string]bool{locationLocal: true}, serverType)
+	require.Len(t, backups, 1)
+	assert.Equal(t, manifest.Backup.S3Keys, backups[0].S3Keys)
+	assert.Equal(t, types.StatusSuccess, backups[0].S3UploadStatus)
+
+	// Scanned and not found, the S3 copy is gone
+	backups = reconcileBackups(copies, map[string]bool{locationLocal: true, locationS3: true}, serverType)
+	assert.Empty(t, backups[0].S3Keys)
+	assert.Equal(t, types.StatusDeleted, backups[0].S3UploadStatus)
 }
 
 func TestFormatBytes(t *testing.T) {
@@ -264,11 +280,3 @@ func TestFormatBytes(t *testing.T) {
 		})
 	}
 }
-
-// Helper variable for testing
-var backupDir string
-
-// Override scanLocalStorage for testing
-func init() {
-	// Save original function if needed
-}
\ No newline at end of file
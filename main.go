@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/supporttools/GoSQLGuard/pkg/adminserver"
 	"github.com/supporttools/GoSQLGuard/pkg/backup"
@@ -14,12 +16,35 @@ import (
 	_ "github.com/supporttools/GoSQLGuard/pkg/backup/database/postgresql"
 	"github.com/supporttools/GoSQLGuard/pkg/config"
 	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/leader"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metrics"
 	"github.com/supporttools/GoSQLGuard/pkg/scheduler"
+	"github.com/supporttools/GoSQLGuard/pkg/worker"
 )
 
+// workerReloadInterval is how often a worker picks up changed servers and schedules
+const workerReloadInterval = time.Minute
+
+// httpShutdownTimeout bounds how long open admin requests may delay a shutdown
+const httpShutdownTimeout = 5 * time.Second
+
 func main() {
+	// Worker processes only run backups queued by the scheduler
+	if len(os.Args) > 1 && os.Args[1] == "worker" {
+		runWorker()
+		return
+	}
+
+	// One-shot commands run a single pass for CronJobs and exit
+	if len(os.Args) > 1 {
+		if run, ok := oneShotCommands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
+	}
+
 	log.Println("Starting GoSQLGuard...")
+	started := time.Now()
 
 	// Load and validate configuration
 	config.LoadConfiguration()
@@ -56,6 +81,12 @@ func main() {
 		log.Fatalf("Failed to initialize backup manager: %v", err)
 	}
 
+	// Queue database backups for workers instead of running them here
+	if config.CFG.Workers.QueueEnabled && metadata.DB != nil {
+		backupManager.SetTaskQueue(dbmeta.NewTaskRepository(metadata.DB))
+		log.Println("Backups will be queued for worker processes")
+	}
+
 	// Initialize scheduler
 	sched, err := scheduler.NewScheduler(backupManager)
 	if err != nil {
@@ -65,7 +96,10 @@ func main() {
 	// Load configuration from database if metadata database is enabled
 	if config.CFG.MetadataDB.Enabled && metadata.DB != nil {
 		loadConfigurationFromDatabase()
-		loadSchedulesFromDatabase()
+		loadSchedulesFromDatabase(sched)
+
+		// Keep run history in the database so missed runs are noticed after a restart
+		sched.SetRunStateStore(dbmeta.NewScheduleRunRepository(metadata.DB))
 		
 		// Now validate the complete configuration
 		if err := config.ValidateConfig(); err != nil {
@@ -73,45 +107,180 @@ func main() {
 		}
 	}
 
+	// With several replicas, only the elected leader runs scheduled jobs
+	elector := setupLeaderElection(sched, backupManager)
+
+	// Backups left pending by a process that was killed will never finish.
+	// Queued backups belong to the workers and are marked interrupted when
+	// their tasks are recovered from a worker that stopped responding. With
+	// leader election the new leader reconciles them once elected.
+	if elector == nil && !config.CFG.Workers.QueueEnabled {
+		backupManager.ReconcileInterrupted(started)
+	}
+
 	// Setup scheduled jobs
 	if err := sched.SetupJobs(); err != nil {
 		log.Fatalf("Failed to setup scheduled jobs: %v", err)
 	}
 
+	// Record runs missed while GoSQLGuard was down before new ones start
+	sched.CheckMissedRuns()
+
 	// Start the scheduler
 	sched.Start()
 
+	// Jobs only run once this replica is elected
+	if elector != nil {
+		elector.Start()
+	}
+
 	// Start the admin server
 	adminSrv := adminserver.NewServer(backupManager, sched)
+	adminSrv.SetElector(elector)
 	httpServer := adminSrv.Start()
 
 	// Setup signal handling for graceful shutdown
-	setupSignalHandling(sched, httpServer)
+	setupSignalHandling(sched, elector, httpServer)
 
 	// Block indefinitely
 	log.Println("GoSQLGuard is running. Press Ctrl+C to exit.")
 	sched.WaitForever()
 }
 
-// setupSignalHandling configures graceful shutdown on SIGINT or SIGTERM
-func setupSignalHandling(sched *scheduler.Scheduler, httpServer *http.Server) {
+// setupSignalHandling configures graceful shutdown on SIGINT or SIGTERM.
+// New backups stop being accepted, running ones get the shutdown grace period
+// to finish and are cancelled and marked interrupted after that.
+func setupSignalHandling(sched *scheduler.Scheduler, elector *leader.Elector, httpServer *http.Server) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		sig := <-c
 		fmt.Printf("Received signal %s, shutting down...\n", sig)
-		sched.Stop()
 
-		// Shutdown the HTTP server
+		// Stop accepting backups triggered through the admin server first
 		if httpServer != nil {
-			if err := httpServer.Close(); err != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+			if err := httpServer.Shutdown(ctx); err != nil {
 				log.Printf("Error shutting down HTTP server: %v", err)
+				httpServer.Close()
 			}
+			cancel()
+		}
+
+		if err := sched.Shutdown(config.CFG.ShutdownGrace()); err != nil {
+			log.Printf("Shutdown did not finish cleanly: %v", err)
+		}
+
+		// Keep the lease while backups drain so fencing stays valid, then
+		// hand leadership over to another replica straight away
+		if elector != nil {
+			elector.Stop()
+		}
+
+		os.Exit(0)
+	}()
+}
+
+// setupLeaderElection prepares the leader election when high availability is
+// enabled and returns the elector, or nil otherwise
+func setupLeaderElection(sched *scheduler.Scheduler, backupManager *backup.Manager) *leader.Elector {
+	haCfg := config.CFG.HA
+	if !haCfg.Enabled || metadata.DB == nil {
+		return nil
+	}
+
+	// Both durations were checked by ValidateConfig
+	ttl, _ := time.ParseDuration(haCfg.LeaseDuration)
+	renew, _ := time.ParseDuration(haCfg.RenewInterval)
+
+	elector := leader.NewElector(dbmeta.NewLeaseRepository(metadata.DB), haCfg.Identity, ttl, renew)
+	elector.OnElected(func(token int64) {
+		// Backups this replica starts as leader are created after this
+		elected := time.Now()
+
+		// Servers and schedules may have been changed through the previous leader
+		loadConfigurationFromDatabase()
+		loadSchedulesFromDatabase(sched)
+		if err := sched.ReloadSchedules(); err != nil {
+			log.Printf("Failed to reload schedules after election: %v", err)
+		}
+		sched.CheckMissedRuns()
+
+		// Backups the previous leader left pending will never finish,
+		// including those it started after this replica came up
+		if !config.CFG.Workers.QueueEnabled {
+			backupManager.ReconcileInterrupted(elected)
+		}
+	})
+	sched.SetLeadership(elector)
+
+	log.Printf("Leader election enabled as %s (lease %s, renewed every %s)", haCfg.Identity, ttl, renew)
+	return elector
+}
+
+// runWorker runs backups queued by the scheduler until interrupted
+func runWorker() {
+	log.Println("Starting GoSQLGuard worker...")
+
+	config.LoadConfiguration()
+	if !config.CFG.MetadataDB.Enabled {
+		log.Fatal("Worker mode requires the metadata database, which holds the task queue")
+	}
+	if config.CFG.MetadataDB.Embedded() {
+		log.Fatal("Worker mode requires a MySQL or PostgreSQL metadata database shared with the scheduler")
+	}
+	if err := metadata.InitializeMetadataDatabase(); err != nil {
+		log.Fatalf("Failed to initialize metadata store: %v", err)
+	}
+
+	// Servers and schedules are managed through the scheduler
+	loadConfigurationFromDatabase()
+	loadSchedulesFromDatabase(nil)
+	lastReload := time.Now()
+
+	if err := config.ValidateConfig(); err != nil {
+		log.Fatalf("Configuration validation failed after loading from database: %v", err)
+	}
+
+	backupManager, err := backup.NewManager()
+	if err != nil {
+		log.Fatalf("Failed to initialize backup manager: %v", err)
+	}
+
+	w, err := worker.New(dbmeta.NewTaskRepository(metadata.DB), backupManager, config.CFG.Workers)
+	if err != nil {
+		log.Fatalf("Invalid worker configuration: %v", err)
+	}
+	w.SetPrepare(func() {
+		if time.Since(lastReload) < workerReloadInterval {
+			return
 		}
+		loadConfigurationFromDatabase()
+		loadSchedulesFromDatabase(nil)
+		lastReload = time.Now()
+	})
+
+	go metrics.StartMetricsServer(config.CFG.Metrics.Port)
 
+	// Give the running backup the shutdown grace period to finish; one
+	// cancelled after that goes back to the queue. A worker killed mid-backup
+	// has its task requeued once its heartbeats stop.
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-c
+		log.Printf("Received signal %s, finishing the running backup before exiting...", sig)
+		go func() {
+			if err := backupManager.Shutdown(config.CFG.ShutdownGrace()); err != nil {
+				log.Printf("Shutdown did not finish cleanly: %v", err)
+			}
+		}()
+		w.Stop()
 		os.Exit(0)
 	}()
+
+	w.Run()
 }
 
 // loadConfigurationFromDatabase loads database server configurations from the database
@@ -154,6 +323,14 @@ func loadConfigurationFromDatabase() {
 			AuthPlugin:       server.AuthPlugin,
 			IncludeDatabases: includeDatabases,
 			ExcludeDatabases: excludeDatabases,
+			Labels:           server.LabelMap(),
+			DatabaseLabels:   server.DatabaseLabelRules(),
+			TLS: config.DatabaseTLSConfig{
+				Mode:     server.TLSMode,
+				CAFile:   server.TLSCAFile,
+				CertFile: server.TLSCertFile,
+				KeyFile:  server.TLSKeyFile,
+			},
 		}
 		
 		databaseServers = append(databaseServers, serverConfig)
@@ -183,8 +360,9 @@ func loadConfigurationFromDatabase() {
 	}
 }
 
-// loadSchedulesFromDatabase loads schedule configurations from the database
-func loadSchedulesFromDatabase() {
+// loadSchedulesFromDatabase loads schedule configurations and their pause and
+// skip settings from the database. Workers pass a nil scheduler.
+func loadSchedulesFromDatabase(sched *scheduler.Scheduler) {
 	log.Println("Loading schedules from database...")
 	
 	// Initialize schedule repository
@@ -199,46 +377,18 @@ func loadSchedulesFromDatabase() {
 	
 	// Convert database schedules to config format
 	backupTypes := make(map[string]config.BackupTypeConfig)
+	controls := make(map[string]scheduler.Control)
 	
 	for _, schedule := range schedules {
 		if !schedule.Enabled {
 			continue // Skip disabled schedules
 		}
 		
-		// Create backup type config
-		backupType := config.BackupTypeConfig{
-			Schedule: schedule.CronExpression,
-			Local: config.LocalBackupConfig{
-				Enabled: false,
-				Retention: config.RetentionRule{
-					Duration: "24h",
-					Forever:  false,
-				},
-			},
-			S3: config.S3BackupConfig{
-				Enabled: false,
-				Retention: config.RetentionRule{
-					Duration: "24h",
-					Forever:  false,
-				},
-			},
-		}
-		
-		// Process retention policies
-		for _, policy := range schedule.RetentionPolicies {
-			if policy.StorageType == "local" {
-				backupType.Local.Enabled = true
-				backupType.Local.Retention.Duration = policy.Duration
-				backupType.Local.Retention.Forever = policy.KeepForever
-			} else if policy.StorageType == "s3" {
-				backupType.S3.Enabled = true
-				backupType.S3.Retention.Duration = policy.Duration
-				backupType.S3.Retention.Forever = policy.KeepForever
-			}
-		}
-		
-		// Add to map
-		backupTypes[schedule.BackupType] = backupType
+		backupTypes[schedule.BackupType] = schedule.BackupTypeConfig()
+		controls[schedule.BackupType] = scheduler.ControlFromSchedule(&schedule)
+	}
+	if sched != nil {
+		sched.ReplaceControls(controls)
 	}
 	
 	// Update the global configuration
@@ -3,11 +3,14 @@ package adminserver
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -20,8 +23,11 @@ import (
 	"github.com/supporttools/GoSQLGuard/pkg/database"
 	"github.com/supporttools/GoSQLGuard/pkg/handlers"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
 	"github.com/supporttools/GoSQLGuard/pkg/pages"
+	"github.com/supporttools/GoSQLGuard/pkg/retention"
 	"github.com/supporttools/GoSQLGuard/pkg/scheduler"
+	"github.com/supporttools/GoSQLGuard/pkg/stats"
 	"github.com/supporttools/GoSQLGuard/pkg/storage/s3"
 )
 
@@ -32,9 +38,11 @@ var (
 
 // Server represents the admin HTTP server
 type Server struct {
-	httpServer *http.Server
-	scheduler  *scheduler.Scheduler
-	backupMgr  *backup.Manager
+	httpServer    *http.Server
+	metricsServer *http.Server
+	scheduler     *scheduler.Scheduler
+	backupMgr     *backup.Manager
+	elector       roleReporter // Nil when leader election is disabled
 }
 
 // NewServer creates a new admin server instance
@@ -47,39 +55,109 @@ func NewServer(backupMgr *backup.Manager, sched *scheduler.Scheduler) *Server {
 
 // Start starts the admin HTTP server
 func (s *Server) Start() *http.Server {
+	adminCfg := config.CFG.AdminServer
+	if err := config.ValidateAdminServerConfig(adminCfg); err != nil {
+		log.Fatalf("Invalid admin server configuration: %v", err)
+	}
+
 	mux := http.NewServeMux()
 
 	// Register routes
 	s.registerRoutes(mux)
 
+	// Followers serve the UI and API read-only
+	var handler http.Handler = s.followerReadOnlyMiddleware(mux)
+
+	// Wrap the UI and API with TLS-only protections
+	if adminCfg.AuthEnabled() {
+		handler = authMiddleware(adminCfg, handler)
+	}
+	if len(adminCfg.APITokens) > 0 && !adminCfg.TLSEnabled {
+		log.Printf("Warning: API tokens are accepted over plain HTTP; enable TLS or terminate it in front of GoSQLGuard")
+	}
+	if adminCfg.TLSEnabled {
+		handler = secureHeadersMiddleware(adminCfg.HSTSMaxAge, handler)
+	}
+
 	// Create HTTP server
 	s.httpServer = &http.Server{
 		Addr:         fmt.Sprintf(":%s", config.CFG.Metrics.Port),
-		Handler:      logRequestMiddleware(mux),
+		Handler:      logRequestMiddleware(handler),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  30 * time.Second,
 	}
 
+	// Load certificates up front so misconfiguration fails at startup
+	if adminCfg.TLSEnabled {
+		reloader, err := newCertReloader(adminCfg.CertFile, adminCfg.KeyFile, adminCfg.ClientCAFile)
+		if err != nil {
+			log.Fatalf("Failed to load admin server TLS configuration: %v", err)
+		}
+		reloader.clientCertOptional = len(adminCfg.APITokens) > 0
+		s.httpServer.TLSConfig = reloader.tlsConfig()
+	}
+
 	// Start HTTP server in a goroutine
 	go func() {
-		log.Printf("Admin server running on port %s", config.CFG.Metrics.Port)
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if adminCfg.TLSEnabled {
+			log.Printf("Admin server running with HTTPS on port %s (client certificates required: %t)",
+				config.CFG.Metrics.Port, adminCfg.ClientAuthEnabled())
+			err = s.httpServer.ListenAndServeTLS("", "")
+		} else {
+			log.Printf("Admin server running on port %s", config.CFG.Metrics.Port)
+			err = s.httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("HTTP server failed: %v", err)
 		}
 	}()
 
+	// Serve metrics and health checks separately so they can be scraped
+	// without client credentials
+	if adminCfg.MetricsPort != "" {
+		s.metricsServer = &http.Server{
+			Addr:         fmt.Sprintf(":%s", adminCfg.MetricsPort),
+			Handler:      s.metricsMux(),
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+			IdleTimeout:  30 * time.Second,
+		}
+
+		go func() {
+			log.Printf("Metrics and health server running on port %s", adminCfg.MetricsPort)
+			if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Metrics server failed: %v", err)
+			}
+		}()
+	}
+
 	return s.httpServer
 }
 
 // Stop gracefully stops the HTTP server
 func (s *Server) Stop() error {
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Close(); err != nil {
+			log.Printf("Error closing metrics server: %v", err)
+		}
+	}
 	if s.httpServer != nil {
 		return s.httpServer.Close()
 	}
 	return nil
 }
 
+// metricsMux returns the routes served by the separate metrics listener
+func (s *Server) metricsMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", s.healthCheckHandler)
+	mux.HandleFunc("/healthz/leader", s.leaderCheckHandler)
+	return mux
+}
+
 // registerRoutes registers all HTTP routes
 func (s *Server) registerRoutes(mux *http.ServeMux) {
 	// Static pages - Use new Templ-based handler for dashboard
@@ -93,25 +171,39 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/mysql-options", pages.MySQLOptionsPage)        // MySQL dump options configuration
 	mux.HandleFunc("/configuration", handlers.ConfigurationHandler) // Configuration management page
 
-	// Standard endpoints
-	mux.Handle("/metrics", promhttp.Handler())
+	// Standard endpoints; metrics move to their own listener when configured
+	if config.CFG.AdminServer.MetricsPort == "" {
+		mux.Handle("/metrics", promhttp.Handler())
+	}
 	mux.HandleFunc("/healthz", s.healthCheckHandler)
+	mux.HandleFunc("/healthz/leader", s.leaderCheckHandler)
 	mux.HandleFunc("/api/stats", s.statsHandler)
+	mux.HandleFunc("/api/stats/history", s.statsHistoryHandler)
 
 	// Backup operations
 	mux.HandleFunc("/api/backups", s.listBackupsHandler)
+	mux.HandleFunc("/api/v2/backups", s.queryBackupsHandler)
 	mux.HandleFunc("/api/backups/run", s.runBackupHandler)
 	mux.HandleFunc("/api/backups/delete", s.deleteBackupHandler)
+	mux.HandleFunc("/api/backups/annotations", s.backupAnnotationsHandler)
+	mux.HandleFunc("/api/backups/hold", s.backupHoldHandler)
+	mux.HandleFunc("/api/backups/hold/release", s.releaseHoldHandler)
+	mux.HandleFunc("/api/audit", s.auditHandler)
 	mux.HandleFunc("/api/backups/log", s.serveLogFileHandler)
 	mux.HandleFunc("/api/backups/download/local", s.downloadLocalBackupHandler)
 	mux.HandleFunc("/api/backups/download/s3", s.downloadS3BackupHandler)
 
 	// Storage operations
 	mux.HandleFunc("/api/storage", s.storageInfoHandler)
+	mux.HandleFunc("/api/storage/reconcile", s.reconcileHandler)
+	mux.HandleFunc("/api/storage/reconcile/action", s.reconcileActionHandler)
 	mux.HandleFunc("/api/retention/run", s.runRetentionHandler)
+	mux.HandleFunc("/api/retention/preview", s.retentionPreviewHandler)
 
 	// HTMX endpoints
 	mux.HandleFunc("/api/dashboard/recent-backups", handlers.RecentBackupsHandler)
+	mux.HandleFunc("/api/dashboard/schedule-calendar", handlers.ScheduleCalendarHandler(s.scheduler))
+	mux.HandleFunc("/api/dashboard/growth", handlers.GrowthChartsHandler)
 
 	// MySQL options operations
 	mux.HandleFunc("/api/mysql-options/global", s.mysqlOptionsHandler)
@@ -122,6 +214,9 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	serverHandler.RegisterRoutes(mux)
 	scheduleHandler.RegisterRoutes(mux)
 
+	// Backup task queue API
+	api.NewTaskHandler().RegisterRoutes(mux)
+
 	// Configuration management API
 	// TODO: Implement config handler
 	// configHandler, err := api.NewConfigHandler()
@@ -148,14 +243,16 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	postgresqlOptionsHandler.RegisterRoutes(mux)
 }
 
-// healthCheckHandler returns a simple health status
+// healthCheckHandler returns a simple health status along with the role of
+// this replica
 func (s *Server) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	response := s.healthFields()
+	response["status"] = "healthy"
+	response["time"] = time.Now().Format(time.RFC3339)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	err := json.NewEncoder(w).Encode(map[string]string{
-		"status": "healthy",
-		"time":   time.Now().Format(time.RFC3339),
-	})
+	err := json.NewEncoder(w).Encode(response)
 	if err != nil {
 		log.Printf("Error encoding health check response: %v", err)
 	}
@@ -181,12 +278,92 @@ func (s *Server) statsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// maxStatsHistoryDays is the longest stats history one request returns
+const maxStatsHistoryDays = 3650
+
+// statsHistoryHandler returns the daily stats of each database and their
+// totals as time series, with a forecast of when each storage location runs
+// out of space
+func (s *Server) statsHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	days := 90
+	if value := r.URL.Query().Get("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxStatsHistoryDays {
+			http.Error(w, fmt.Sprintf("Invalid days, expected 1 to %d: %s", maxStatsHistoryDays, value), http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	metadataStore := metadata.GetActiveStore()
+	if metadataStore == nil {
+		http.Error(w, "Metadata store not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	now := time.Now()
+	to := types.StatsDay(now).AddDate(0, 0, 1)
+	query := types.StatsHistoryQuery{
+		ServerName: r.URL.Query().Get("server"),
+		Database:   r.URL.Query().Get("database"),
+		From:       to.AddDate(0, 0, -days),
+		To:         to,
+	}
+	snapshots, err := metadataStore.GetStatsHistory(query)
+	if err != nil {
+		log.Printf("Error reading stats history: %v", err)
+		http.Error(w, "Error reading stats history", http.StatusInternalServerError)
+		return
+	}
+	series, totals := stats.History(snapshots)
+
+	// Storage runs out for all databases together
+	all := totals
+	if query.ServerName != "" || query.Database != "" {
+		snapshots, err := metadataStore.GetStatsHistory(types.StatsHistoryQuery{From: query.From, To: query.To})
+		if err != nil {
+			log.Printf("Error reading stats history: %v", err)
+			http.Error(w, "Error reading stats history", http.StatusInternalServerError)
+			return
+		}
+		_, all = stats.History(snapshots)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":      query.From,
+		"to":        query.To,
+		"series":    series,
+		"totals":    totals,
+		"forecasts": stats.Forecasts(all, now),
+	}); err != nil {
+		log.Printf("Error encoding stats history response: %v", err)
+	}
+}
+
 // listBackupsHandler returns a list of backups with optional filtering
 func (s *Server) listBackupsHandler(w http.ResponseWriter, r *http.Request) {
+	serverName := r.URL.Query().Get("server")
 	database := r.URL.Query().Get("database")
 	backupType := r.URL.Query().Get("type")
+	status := r.URL.Query().Get("status")
+	id := r.URL.Query().Get("id")
 	activeOnly := r.URL.Query().Get("activeOnly") == "true"
 
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "Invalid since, expected an RFC 3339 time: "+value, http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Get the active metadata store
 	metadataStore := metadata.GetActiveStore()
 	if metadataStore == nil {
@@ -195,7 +372,7 @@ func (s *Server) listBackupsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	backups := metadataStore.GetBackupsFiltered("", database, backupType, activeOnly)
+	backups := filterBackups(metadataStore.GetBackupsFiltered(serverName, database, backupType, activeOnly), id, status, since)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{
@@ -208,9 +385,77 @@ func (s *Server) listBackupsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// runBackupHandler triggers a manual backup
+// queryBackupsHandler returns one page of backups, filtered and sorted by the
+// metadata store; see metadata.ParseBackupQuery for the parameters
+func (s *Server) queryBackupsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query, err := metadata.ParseBackupQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	metadataStore := metadata.GetActiveStore()
+	if metadataStore == nil {
+		http.Error(w, "Metadata store not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	page, err := metadataStore.QueryBackups(query)
+	if err != nil {
+		log.Printf("Error querying backups: %v", err)
+		http.Error(w, "Error querying backups", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		log.Printf("Error encoding backups response: %v", err)
+	}
+}
+
+// filterBackups applies the list filters the metadata store does not handle
+func filterBackups(backups []types.BackupMeta, id, status string, since time.Time) []types.BackupMeta {
+	if id == "" && status == "" && since.IsZero() {
+		return backups
+	}
+
+	filtered := make([]types.BackupMeta, 0, len(backups))
+	for _, backup := range backups {
+		if id != "" && backup.ID != id {
+			continue
+		}
+		if status != "" && string(backup.Status) != status {
+			continue
+		}
+		if !since.IsZero() && backup.CreatedAt.Before(since) {
+			continue
+		}
+		filtered = append(filtered, backup)
+	}
+	return filtered
+}
+
+// runBackupHandler triggers a manual backup, or with GET reports whether one
+// is still running
 func (s *Server) runBackupHandler(w http.ResponseWriter, r *http.Request) {
-	// This should be a POST request
+	if r.Method == http.MethodGet {
+		taskLock.Lock()
+		running := isTaskRunning
+		taskLock.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]bool{"running": running}); err != nil {
+			log.Printf("Error encoding response: %v", err)
+		}
+		return
+	}
+
+	// Starting a backup must be a POST request
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -271,6 +516,7 @@ func (s *Server) runBackupHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if a task is already running
+	startedAt := time.Now()
 	if !triggerBackup(s, backupType, servers, databases) {
 		http.Error(w, "A backup task is already running", http.StatusConflict)
 		return
@@ -281,8 +527,9 @@ func (s *Server) runBackupHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusAccepted)
 
 	response := map[string]string{
-		"status":  "accepted",
-		"message": fmt.Sprintf("Backup of type %s initiated", backupType),
+		"status":     "accepted",
+		"message":    fmt.Sprintf("Backup of type %s initiated", backupType),
+		"started_at": startedAt.Format(time.RFC3339),
 	}
 
 	if len(databases) > 0 {
@@ -325,6 +572,12 @@ func (s *Server) deleteBackupHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Held backups must not be deleted until the hold is released
+	if backup.IsHeld(time.Now()) {
+		http.Error(w, fmt.Sprintf("Backup %s is held by %s: %s", backupID, backup.Hold.Actor, backup.Hold.Reason), http.StatusConflict)
+		return
+	}
+
 	// Mark as deleted in metadata
 	if err := metadataStore.MarkBackupDeleted(backupID); err != nil {
 		log.Printf("Error marking backup as deleted: %v", err)
@@ -347,6 +600,353 @@ func (s *Server) deleteBackupHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// backupAnnotationsHandler returns the annotations of a backup, or with POST
+// merges a JSON object into them; an empty value removes that annotation
+func (s *Server) backupAnnotationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	backupID := r.URL.Query().Get("id")
+	if backupID == "" {
+		http.Error(w, "Missing required parameter: id", http.StatusBadRequest)
+		return
+	}
+
+	metadataStore := metadata.GetActiveStore()
+	if metadataStore == nil {
+		http.Error(w, "Metadata store not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	backup, exists := metadataStore.GetBackupByID(backupID)
+	if !exists {
+		http.Error(w, fmt.Sprintf("Backup with ID %s not found", backupID), http.StatusNotFound)
+		return
+	}
+
+	annotations := backup.Annotations
+	if r.Method == http.MethodPost {
+		var changes map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for key := range changes {
+			if strings.TrimSpace(key) == "" {
+				http.Error(w, "Annotation keys must not be empty", http.StatusBadRequest)
+				return
+			}
+		}
+
+		// The store merges the changes, so concurrent requests changing
+		// different keys do not overwrite each other
+		var err error
+		annotations, err = metadataStore.MergeAnnotations(backupID, changes)
+		if err != nil {
+			log.Printf("Error updating annotations of backup %s: %v", backupID, err)
+			http.Error(w, "Error updating annotations", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":          backupID,
+		"labels":      backup.Labels,
+		"annotations": annotations,
+	}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// holdRequest is the body of hold and release requests. Actor names the
+// person acting when the client is not authenticated by token or certificate.
+type holdRequest struct {
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+}
+
+// requestActor returns who is making a request: the authenticated client,
+// else the actor named in the request
+func requestActor(r *http.Request, named string) string {
+	if actor := ActorFromContext(r.Context()); actor != "" {
+		return actor
+	}
+	if named = strings.TrimSpace(named); named != "" {
+		return named
+	}
+	return "anonymous"
+}
+
+// backupHoldHandler returns the hold on a backup and its audit entries, or
+// with POST places a hold on it
+func (s *Server) backupHoldHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	backupID := r.URL.Query().Get("id")
+	if backupID == "" {
+		http.Error(w, "Missing required parameter: id", http.StatusBadRequest)
+		return
+	}
+
+	metadataStore := metadata.GetActiveStore()
+	if metadataStore == nil {
+		http.Error(w, "Metadata store not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	meta, exists := metadataStore.GetBackupByID(backupID)
+	if !exists {
+		http.Error(w, fmt.Sprintf("Backup with ID %s not found", backupID), http.StatusNotFound)
+		return
+	}
+
+	hold := meta.Hold
+	if r.Method == http.MethodPost {
+		var req holdRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(req.Reason) == "" {
+			http.Error(w, "A reason is required to hold a backup", http.StatusBadRequest)
+			return
+		}
+		if !req.ExpiresAt.IsZero() && !req.ExpiresAt.After(time.Now()) {
+			http.Error(w, "expiresAt must be in the future", http.StatusBadRequest)
+			return
+		}
+		if s.backupMgr == nil {
+			http.Error(w, "Backup manager not available", http.StatusServiceUnavailable)
+			return
+		}
+
+		placed, err := s.backupMgr.PlaceHold(backupID, requestActor(r, req.Actor), strings.TrimSpace(req.Reason), req.ExpiresAt)
+		if errors.Is(err, backup.ErrNothingToHold) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Printf("Error holding backup %s: %v", backupID, err)
+			http.Error(w, "Error holding backup: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		hold = &placed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":    backupID,
+		"held":  hold != nil && hold.Active(time.Now()),
+		"hold":  hold,
+		"audit": metadataStore.GetAuditEntries(backupID, 100),
+	}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// releaseHoldHandler releases the hold on a backup
+func (s *Server) releaseHoldHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	backupID := r.URL.Query().Get("id")
+	if backupID == "" {
+		http.Error(w, "Missing required parameter: id", http.StatusBadRequest)
+		return
+	}
+
+	var req holdRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	metadataStore := metadata.GetActiveStore()
+	if metadataStore == nil {
+		http.Error(w, "Metadata store not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	backup, exists := metadataStore.GetBackupByID(backupID)
+	if !exists {
+		http.Error(w, fmt.Sprintf("Backup with ID %s not found", backupID), http.StatusNotFound)
+		return
+	}
+	if backup.Hold == nil {
+		http.Error(w, fmt.Sprintf("Backup %s is not held", backupID), http.StatusConflict)
+		return
+	}
+	if s.backupMgr == nil {
+		http.Error(w, "Backup manager not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := s.backupMgr.ReleaseHold(backupID, requestActor(r, req.Actor), strings.TrimSpace(req.Reason)); err != nil {
+		log.Printf("Error releasing hold on backup %s: %v", backupID, err)
+		http.Error(w, "Error releasing hold: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{
+		"status":  "success",
+		"message": fmt.Sprintf("Hold on backup %s released", backupID),
+		"id":      backupID,
+	}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// auditHandler returns audit log entries, newest first, optionally for one
+// backup
+func (s *Server) auditHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 100
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit: "+value, http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	metadataStore := metadata.GetActiveStore()
+	if metadataStore == nil {
+		http.Error(w, "Metadata store not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	entries := metadataStore.GetAuditEntries(r.URL.Query().Get("backup"), limit)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+	}); err != nil {
+		log.Printf("Error encoding audit response: %v", err)
+	}
+}
+
+// reconcileHandler returns the last storage reconciliation report on GET and
+// runs a new reconciliation on POST
+func (s *Server) reconcileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.backupMgr == nil {
+		http.Error(w, "Backup manager not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var report backup.ReconcileReport
+	if r.Method == http.MethodPost {
+		report = s.backupMgr.Reconcile()
+	} else {
+		var ok bool
+		if report, ok = s.backupMgr.LastReconcileReport(); !ok {
+			http.Error(w, "No reconciliation has run yet", http.StatusNotFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// reconcileActionRequest is the body of a reconciliation action. Path names
+// the file or S3 key; BackupID is required to mark a file missing.
+type reconcileActionRequest struct {
+	Action   string `json:"action"` // import, delete or markMissing
+	Location string `json:"location"`
+	Path     string `json:"path"`
+	BackupID string `json:"backupId,omitempty"`
+	Actor    string `json:"actor,omitempty"`
+}
+
+// reconcileActionHandler resolves a reconciliation finding: importing or
+// deleting an orphan, or marking a missing file as gone
+func (s *Server) reconcileActionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req reconcileActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Location != retention.LocationLocal && req.Location != retention.LocationS3 {
+		http.Error(w, "location must be local or s3", http.StatusBadRequest)
+		return
+	}
+	if req.Path == "" {
+		http.Error(w, "Missing required field: path", http.StatusBadRequest)
+		return
+	}
+	if s.backupMgr == nil {
+		http.Error(w, "Backup manager not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	actor := requestActor(r, req.Actor)
+	response := map[string]interface{}{"status": "success"}
+	var err error
+	switch req.Action {
+	case "import":
+		var imported types.BackupMeta
+		if imported, err = s.backupMgr.ImportOrphan(req.Location, req.Path, actor); err == nil {
+			response["message"] = fmt.Sprintf("Imported %s as backup %s", req.Path, imported.ID)
+			response["backup"] = imported
+		}
+	case "delete":
+		if err = s.backupMgr.DeleteOrphan(req.Location, req.Path, actor); err == nil {
+			response["message"] = fmt.Sprintf("Deleted orphaned file %s", req.Path)
+		}
+	case "markMissing":
+		if req.BackupID == "" {
+			http.Error(w, "Missing required field: backupId", http.StatusBadRequest)
+			return
+		}
+		if err = s.backupMgr.MarkMissing(req.BackupID, req.Location, req.Path, actor); err == nil {
+			response["message"] = fmt.Sprintf("Marked %s of backup %s as missing", req.Path, req.BackupID)
+		}
+	default:
+		http.Error(w, "action must be import, delete or markMissing", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error running reconciliation action %s on %s: %v", req.Action, req.Path, err)
+		http.Error(w, "Error running "+req.Action+": "+err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
 // storageInfoHandler returns information about storage destinations
 func (s *Server) storageInfoHandler(w http.ResponseWriter, r *http.Request) {
 	info := map[string]interface{}{
@@ -417,6 +1017,13 @@ func (s *Server) serveLogFileHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Command-line clients read the raw log, following it by asking for
+	// what was written after the offset they have already seen
+	if r.URL.Query().Get("format") == "text" {
+		serveLogText(w, r, backup)
+		return
+	}
+
 	// Read the log file
 	logContent, err := os.ReadFile(backup.LogFilePath)
 	if err != nil {
@@ -516,6 +1123,44 @@ func (s *Server) serveLogFileHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// serveLogText writes a backup log as plain text from the offset query
+// parameter onwards. The X-Backup-Status header tells followers whether the
+// backup is still running and X-Log-Offset where to continue.
+func serveLogText(w http.ResponseWriter, r *http.Request, backup types.BackupMeta) {
+	var offset int64
+	if value := r.URL.Query().Get("offset"); value != "" {
+		var err error
+		if offset, err = strconv.ParseInt(value, 10, 64); err != nil || offset < 0 {
+			http.Error(w, "Invalid offset: "+value, http.StatusBadRequest)
+			return
+		}
+	}
+
+	f, err := os.Open(backup.LogFilePath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error reading log file: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error reading log file: %v", err), http.StatusInternalServerError)
+		return
+	}
+	size := info.Size()
+	if offset > size {
+		offset = size
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Backup-Status", string(backup.Status))
+	w.Header().Set("X-Log-Offset", strconv.FormatInt(size, 10))
+	if _, err := io.Copy(w, io.NewSectionReader(f, offset, size-offset)); err != nil {
+		log.Printf("Error serving log file %s: %v", backup.LogFilePath, err)
+	}
+}
+
 // downloadLocalBackupHandler serves a backup file from local storage for download
 func (s *Server) downloadLocalBackupHandler(w http.ResponseWriter, r *http.Request) {
 	// Get backup ID from request
@@ -679,6 +1324,66 @@ func (s *Server) runRetentionHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// retentionPreviewHandler shows which backups retention would delete and why
+// without deleting anything
+func (s *Server) retentionPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.backupMgr == nil {
+		http.Error(w, "Backup manager not configured", http.StatusInternalServerError)
+		return
+	}
+
+	// Optional filters
+	query := r.URL.Query()
+	serverName := query.Get("server")
+	database := query.Get("database")
+	backupType := query.Get("type")
+	location := query.Get("location")
+	deletionsOnly := query.Get("deletionsOnly") == "true"
+
+	plan := s.backupMgr.RetentionPlan()
+
+	decisions := make([]retention.Decision, 0, len(plan.Decisions))
+	var keepCount, deleteCount int
+	var bytesFreed int64
+	for _, d := range plan.Decisions {
+		if (serverName != "" && d.ServerName != serverName) ||
+			(database != "" && d.Database != database) ||
+			(backupType != "" && d.BackupType != backupType) ||
+			(location != "" && d.Location != location) {
+			continue
+		}
+
+		if d.Keep {
+			keepCount++
+			if deletionsOnly {
+				continue
+			}
+		} else {
+			deleteCount++
+			bytesFreed += d.Size
+		}
+		decisions = append(decisions, d)
+	}
+
+	response := map[string]interface{}{
+		"generatedAt": plan.GeneratedAt,
+		"keepCount":   keepCount,
+		"deleteCount": deleteCount,
+		"bytesFreed":  bytesFreed,
+		"decisions":   decisions,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding retention preview: %v", err)
+	}
+}
+
 // mysqlOptionsHandler handles MySQL dump options configuration
 func (s *Server) mysqlOptionsHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
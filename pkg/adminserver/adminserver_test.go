@@ -4,9 +4,19 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/supporttools/GoSQLGuard/pkg/backup"
 	"github.com/supporttools/GoSQLGuard/pkg/config"
+	"github.com/supporttools/GoSQLGuard/pkg/leader"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+	"github.com/supporttools/GoSQLGuard/pkg/stats"
 )
 
 // TestRunBackupHandler_Validation tests the validation logic of the backup handler
@@ -36,10 +46,16 @@ func TestRunBackupHandler_Validation(t *testing.T) {
 	}{
 		{
 			name:           "Invalid method",
-			method:         "GET",
+			method:         "DELETE",
 			query:          "?type=daily",
 			expectedStatus: http.StatusMethodNotAllowed,
 		},
+		{
+			name:           "Run status",
+			method:         "GET",
+			query:          "",
+			expectedStatus: http.StatusOK,
+		},
 		{
 			name:           "Missing type parameter",
 			method:         "POST",
@@ -170,10 +186,495 @@ func TestHealthCheck(t *testing.T) {
 	if response["time"] == "" {
 		t.Errorf("Expected time field to be present")
 	}
+
+	if response["role"] != string(leader.RoleStandalone) {
+		t.Errorf("Expected role='standalone', got %v", response["role"])
+	}
+}
+
+// fixedRole reports a fixed leader election status
+type fixedRole leader.Status
+
+func (f fixedRole) Status() leader.Status { return leader.Status(f) }
+
+// TestFollowerReadOnly tests that followers refuse changes but serve reads
+func TestFollowerReadOnly(t *testing.T) {
+	server := &Server{elector: fixedRole{Role: leader.RoleFollower, Identity: "gosqlguard-1", Leader: "gosqlguard-0"}}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	handler := server.followerReadOnlyMiddleware(ok)
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{"GET", "/api/backups", http.StatusOK},
+		{"POST", "/api/backups/run", http.StatusServiceUnavailable},
+		{"DELETE", "/api/schedules", http.StatusServiceUnavailable},
+		{"POST", "/configuration", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != tt.want {
+			t.Errorf("%s %s: got status %d, want %d", tt.method, tt.path, rr.Code, tt.want)
+		}
+		if tt.want == http.StatusServiceUnavailable && rr.Header().Get(leaderHeader) != "gosqlguard-0" {
+			t.Errorf("%s %s: expected the leader to be named", tt.method, tt.path)
+		}
+	}
+
+	// The leader check tells load balancers this replica is not the leader
+	rr := httptest.NewRecorder()
+	server.leaderCheckHandler(rr, httptest.NewRequest("GET", "/healthz/leader", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("leader check on a follower: got status %d", rr.Code)
+	}
+
+	server.elector = fixedRole{Role: leader.RoleLeader, Identity: "gosqlguard-0", Leader: "gosqlguard-0", Token: 4}
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/api/backups/run", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("leader refused a change: got status %d", rr.Code)
+	}
 }
 
 // TestStatsHandler tests the stats endpoint
+func TestFilterBackups(t *testing.T) {
+	now := time.Now()
+	backups := []types.BackupMeta{
+		{ID: "old", Status: types.StatusSuccess, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "failed", Status: types.StatusError, CreatedAt: now},
+		{ID: "new", Status: types.StatusSuccess, CreatedAt: now},
+	}
+
+	ids := func(backups []types.BackupMeta) []string {
+		var ids []string
+		for _, b := range backups {
+			ids = append(ids, b.ID)
+		}
+		return ids
+	}
+
+	if got := ids(filterBackups(backups, "", "", time.Time{})); len(got) != 3 {
+		t.Errorf("no filters: got %v", got)
+	}
+	if got := ids(filterBackups(backups, "", "success", now.Add(-time.Hour))); len(got) != 1 || got[0] != "new" {
+		t.Errorf("status and since: got %v", got)
+	}
+	if got := ids(filterBackups(backups, "failed", "", time.Time{})); len(got) != 1 || got[0] != "failed" {
+		t.Errorf("id: got %v", got)
+	}
+}
+
+func TestServeLogText(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "backup.log")
+	if err := os.WriteFile(logFile, []byte("line one\nline two\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	backup := types.BackupMeta{ID: "b1", Status: types.StatusPending, LogFilePath: logFile}
+
+	rr := httptest.NewRecorder()
+	serveLogText(rr, httptest.NewRequest(http.MethodGet, "/api/backups/log?id=b1&format=text&offset=9", nil), backup)
+
+	if rr.Body.String() != "line two\n" {
+		t.Errorf("body = %q, want the log after the offset", rr.Body.String())
+	}
+	if got := rr.Header().Get("X-Log-Offset"); got != "18" {
+		t.Errorf("X-Log-Offset = %q, want 18", got)
+	}
+	if got := rr.Header().Get("X-Backup-Status"); got != string(types.StatusPending) {
+		t.Errorf("X-Backup-Status = %q", got)
+	}
+}
+
 func TestStatsHandler(t *testing.T) {
 	// Skip if metadata store is not initialized
 	t.Skip("Skipping stats handler test - requires metadata store initialization")
 }
+
+func TestQueryBackupsHandler(t *testing.T) {
+	store, err := metadata.OpenFileStore(filepath.Join(t.TempDir(), "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	store.ImportBackups([]types.BackupMeta{
+		{ID: "a", ServerName: "prod", Status: types.StatusSuccess, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "b", ServerName: "prod", Status: types.StatusError, CreatedAt: now.Add(-time.Hour)},
+		{ID: "c", ServerName: "prod", Status: types.StatusSuccess, CreatedAt: now},
+		{ID: "d", ServerName: "staging", Status: types.StatusSuccess, CreatedAt: now},
+	}, false)
+	original := metadata.DefaultStore
+	metadata.DefaultStore = store
+	defer func() { metadata.DefaultStore = original }()
+
+	server := &Server{}
+	get := func(query string) (*httptest.ResponseRecorder, types.BackupPage) {
+		rr := httptest.NewRecorder()
+		server.queryBackupsHandler(rr, httptest.NewRequest(http.MethodGet, "/api/v2/backups?"+query, nil))
+		var page types.BackupPage
+		if rr.Code == http.StatusOK {
+			if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rr, page
+	}
+
+	rr, page := get("server=prod&limit=2")
+	if rr.Code != http.StatusOK || len(page.Backups) != 2 || page.Backups[0].ID != "c" || page.Total != 3 || page.NextCursor == "" {
+		t.Fatalf("first page: %d %+v", rr.Code, page)
+	}
+	rr, page = get("server=prod&limit=2&cursor=" + page.NextCursor)
+	if rr.Code != http.StatusOK || len(page.Backups) != 1 || page.Backups[0].ID != "a" || page.NextCursor != "" {
+		t.Fatalf("last page: %d %+v", rr.Code, page)
+	}
+	if _, page = get("status=error,deleted"); len(page.Backups) != 1 || page.Backups[0].ID != "b" {
+		t.Errorf("status filter: %+v", page)
+	}
+	if rr, _ = get("sort=size&order=sideways"); rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid order: got %d, want 400", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	server.queryBackupsHandler(rr, httptest.NewRequest(http.MethodPost, "/api/v2/backups", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: got %d, want 405", rr.Code)
+	}
+}
+
+func TestBackupAnnotationsHandler(t *testing.T) {
+	store, err := metadata.OpenFileStore(filepath.Join(t.TempDir(), "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.ImportBackups([]types.BackupMeta{
+		{ID: "a", ServerName: "prod", Status: types.StatusSuccess, CreatedAt: time.Now(),
+			Annotations: map[string]string{"reason": "before migration"}},
+	}, false)
+	original := metadata.DefaultStore
+	metadata.DefaultStore = store
+	defer func() { metadata.DefaultStore = original }()
+
+	server := &Server{}
+	post := func(id, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/backups/annotations?id="+id, strings.NewReader(body))
+		server.backupAnnotationsHandler(rr, req)
+		return rr
+	}
+
+	if rr := post("a", `{"ticket": "OPS-1", "reason": ""}`); rr.Code != http.StatusOK {
+		t.Fatalf("POST: got %d: %s", rr.Code, rr.Body.String())
+	}
+	backup, _ := store.GetBackupByID("a")
+	if len(backup.Annotations) != 1 || backup.Annotations["ticket"] != "OPS-1" {
+		t.Errorf("annotations = %v, want only ticket", backup.Annotations)
+	}
+
+	if rr := post("missing", `{"ticket": "OPS-1"}`); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown backup: got %d, want 404", rr.Code)
+	}
+	if rr := post("a", `{"": "x"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("empty key: got %d, want 400", rr.Code)
+	}
+}
+
+func TestBackupHoldHandlers(t *testing.T) {
+	dir := t.TempDir()
+	originalCfg := config.CFG
+	config.CFG = config.AppConfig{}
+	config.CFG.Local.Enabled = true
+	config.CFG.Local.BackupDirectory = dir
+	defer func() { config.CFG = originalCfg }()
+
+	path := filepath.Join(dir, "by-server", "prod", "daily", "app-2025-01-01-00-00-00.sql.gz")
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("backup"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := metadata.OpenFileStore(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.ImportBackups([]types.BackupMeta{
+		{ID: "a", ServerName: "prod", Status: types.StatusSuccess, CreatedAt: time.Now(), LocalPaths: map[string]string{"by-server": path}},
+		{ID: "gone", ServerName: "prod", Status: types.StatusDeleted, CreatedAt: time.Now()},
+	}, false)
+	original := metadata.DefaultStore
+	metadata.DefaultStore = store
+	defer func() { metadata.DefaultStore = original }()
+
+	mgr, err := backup.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{backupMgr: mgr}
+	call := func(handler http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rr
+	}
+
+	if rr := call(server.backupHoldHandler, http.MethodPost, "/api/backups/hold?id=a", `{"actor": "legal"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("hold without reason: got %d, want 400", rr.Code)
+	}
+	if rr := call(server.backupHoldHandler, http.MethodPost, "/api/backups/hold?id=gone", `{"reason": "litigation"}`); rr.Code != http.StatusConflict {
+		t.Errorf("hold deleted backup: got %d, want 409", rr.Code)
+	}
+	if rr := call(server.backupHoldHandler, http.MethodPost, "/api/backups/hold?id=a", `{"reason": "litigation", "actor": "legal"}`); rr.Code != http.StatusOK {
+		t.Fatalf("hold: got %d: %s", rr.Code, rr.Body.String())
+	}
+	if held, _ := store.GetBackupByID("a"); held.Hold == nil || held.Hold.Actor != "legal" {
+		t.Fatalf("hold = %+v, want one placed by legal", held.Hold)
+	}
+
+	if rr := call(server.deleteBackupHandler, http.MethodPost, "/api/backups/delete?id=a", ""); rr.Code != http.StatusConflict {
+		t.Errorf("delete held backup: got %d, want 409", rr.Code)
+	}
+
+	if rr := call(server.releaseHoldHandler, http.MethodPost, "/api/backups/hold/release?id=a", `{"reason": "case closed"}`); rr.Code != http.StatusOK {
+		t.Fatalf("release: got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := call(server.releaseHoldHandler, http.MethodPost, "/api/backups/hold/release?id=a", ""); rr.Code != http.StatusConflict {
+		t.Errorf("release unheld backup: got %d, want 409", rr.Code)
+	}
+
+	rr := call(server.auditHandler, http.MethodGet, "/api/audit?backup=a", "")
+	var resp struct {
+		Entries []types.AuditEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Entries) != 2 || resp.Entries[0].Action != types.AuditHoldReleased || resp.Entries[1].Action != types.AuditHoldPlaced {
+		t.Errorf("audit = %+v, want release then placement", resp.Entries)
+	}
+}
+
+func TestReconcileHandlers(t *testing.T) {
+	dir := t.TempDir()
+	originalCfg := config.CFG
+	config.CFG = config.AppConfig{}
+	config.CFG.Local.Enabled = true
+	config.CFG.Local.BackupDirectory = dir
+	config.CFG.Retention.OrphanGracePeriod = "0s"
+	defer func() { config.CFG = originalCfg }()
+
+	store, err := metadata.OpenFileStore(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := metadata.DefaultStore
+	metadata.DefaultStore = store
+	defer func() { metadata.DefaultStore = original }()
+
+	orphan := filepath.Join(dir, "by-server", "prod", "daily", "app-2025-01-01-00-00-00.sql.gz")
+	if err := os.MkdirAll(filepath.Dir(orphan), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(orphan, []byte("backup"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr, err := backup.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{backupMgr: mgr}
+	call := func(handler http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rr
+	}
+
+	if rr := call(server.reconcileHandler, http.MethodGet, "/api/storage/reconcile", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("GET before any run: got %d, want 404", rr.Code)
+	}
+	rr := call(server.reconcileHandler, http.MethodPost, "/api/storage/reconcile", "")
+	var report backup.ReconcileReport
+	if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Kind != backup.FindingOrphan || report.Findings[0].Path != orphan {
+		t.Fatalf("findings = %+v, want the orphan", report.Findings)
+	}
+
+	if rr := call(server.reconcileActionHandler, http.MethodPost, "/api/storage/reconcile/action", `{"action": "delete", "location": "gcs", "path": "x.sql.gz"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown location: got %d, want 400", rr.Code)
+	}
+	if rr := call(server.reconcileActionHandler, http.MethodPost, "/api/storage/reconcile/action", `{"action": "import", "location": "local", "path": "`+orphan+`"}`); rr.Code != http.StatusConflict {
+		t.Errorf("import without manifest: got %d, want 409", rr.Code)
+	}
+	if rr := call(server.reconcileActionHandler, http.MethodPost, "/api/storage/reconcile/action", `{"action": "delete", "location": "local", "path": "`+orphan+`"}`); rr.Code != http.StatusOK {
+		t.Fatalf("delete: got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("orphan still exists: %v", err)
+	}
+	if entries := store.GetAuditEntries("", 0); len(entries) != 1 || entries[0].Action != types.AuditOrphanDeleted {
+		t.Errorf("audit = %+v, want the deletion", entries)
+	}
+}
+
+func TestStatsHistoryHandler(t *testing.T) {
+	originalCfg := config.CFG
+	config.CFG = config.AppConfig{}
+	config.CFG.S3.Enabled = true
+	config.CFG.S3.Quota = "100MB"
+	defer func() { config.CFG = originalCfg }()
+
+	store, err := metadata.OpenFileStore(filepath.Join(t.TempDir(), "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := metadata.DefaultStore
+	metadata.DefaultStore = store
+	defer func() { metadata.DefaultStore = original }()
+
+	// app grows by 10MB a day, other stays at 10MB
+	today := types.StatsDay(time.Now())
+	var snapshots []types.StatsSnapshot
+	for i := 0; i < 5; i++ {
+		day := today.AddDate(0, 0, i-4)
+		snapshots = append(snapshots,
+			types.StatsSnapshot{Day: day, ServerName: "prod", Database: "app", BackupCount: 1, BackupSize: 1e7, S3Size: int64(i+1) * 1e7},
+			types.StatsSnapshot{Day: day, ServerName: "prod", Database: "other", BackupCount: 1, BackupSize: 1e7, S3Size: 1e7})
+	}
+	if err := store.SaveStatsSnapshots(snapshots); err != nil {
+		t.Fatal(err)
+	}
+
+	server := &Server{}
+	rr := httptest.NewRecorder()
+	server.statsHistoryHandler(rr, httptest.NewRequest(http.MethodGet, "/api/stats/history?database=app&days=30", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Series    []stats.Series   `json:"series"`
+		Totals    []stats.Point    `json:"totals"`
+		Forecasts []stats.Forecast `json:"forecasts"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Series) != 1 || response.Series[0].Database != "app" || len(response.Series[0].Points) != 5 {
+		t.Fatalf("series: %+v", response.Series)
+	}
+	if last := response.Totals[len(response.Totals)-1]; last.S3Size != 5e7 {
+		t.Errorf("filtered totals end at %d, want 50MB", last.S3Size)
+	}
+
+	// The forecast covers every database: 60MB used of 100MB, growing 10MB a day
+	if len(response.Forecasts) != 1 {
+		t.Fatalf("forecasts: %+v", response.Forecasts)
+	}
+	forecast := response.Forecasts[0]
+	if forecast.Location != stats.LocationS3 || forecast.Used != 6e7 || forecast.Available != 4e7 || forecast.DaysLeft == nil {
+		t.Fatalf("forecast: %+v", forecast)
+	}
+	if *forecast.DaysLeft < 3.99 || *forecast.DaysLeft > 4.01 {
+		t.Errorf("days left = %f, want 4", *forecast.DaysLeft)
+	}
+
+	rr = httptest.NewRecorder()
+	server.statsHistoryHandler(rr, httptest.NewRequest(http.MethodGet, "/api/stats/history?days=0", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("days=0: got %d, want 400", rr.Code)
+	}
+}
+
+// TestUIActionWithTokenSession tests that a browser logged in with an API
+// token can use the UI's actions, which send no Authorization header
+func TestUIActionWithTokenSession(t *testing.T) {
+	adminCfg := config.AdminServerConfig{
+		APITokens: []config.APIToken{
+			{Name: "ops", Role: config.RoleOperator, Token: "operator-token-0123"},
+			{Name: "grafana", Role: config.RoleViewer, Token: "viewer-token-01234"},
+		},
+	}
+	taskLock.Lock()
+	isTaskRunning = false
+	taskLock.Unlock()
+
+	server := &Server{}
+	mux := http.NewServeMux()
+	server.registerRoutes(mux)
+	handler := authMiddleware(adminCfg, mux)
+
+	runRetention := func(cookies ...*http.Cookie) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/retention/run", nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	login := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(url.Values{"token": {token}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if code := runRetention(); code != http.StatusUnauthorized {
+		t.Errorf("action without a session: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/login", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `name="token"`) {
+		t.Errorf("login page: got status %d", rr.Code)
+	}
+	if rr := login("guess-guess-guess"); rr.Code != http.StatusUnauthorized || len(rr.Result().Cookies()) != 0 {
+		t.Errorf("login with an unknown token: got status %d and cookies %v", rr.Code, rr.Result().Cookies())
+	}
+
+	rr = login("operator-token-0123")
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("login: got status %d, want %d", rr.Code, http.StatusSeeOther)
+	}
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sessionCookieName || !cookies[0].HttpOnly {
+		t.Fatalf("login set cookies %v, want an HttpOnly session cookie", cookies)
+	}
+	session := cookies[0]
+
+	// The action reaches its handler, which has no scheduler in this test
+	if code := runRetention(session); code != http.StatusInternalServerError {
+		t.Errorf("action with an operator session: got status %d, want the handler's %d", code, http.StatusInternalServerError)
+	}
+
+	// The session carries the token's role
+	viewer := login("viewer-token-01234").Result().Cookies()[0]
+	if code := runRetention(viewer); code != http.StatusForbidden {
+		t.Errorf("action with a viewer session: got status %d, want %d", code, http.StatusForbidden)
+	}
+
+	// A session for one token cannot be passed off as another's
+	forged := *session
+	forged.Value = strings.Replace(session.Value, strings.Split(session.Value, ".")[0], strings.Split(viewer.Value, ".")[0], 1)
+	if code := runRetention(&forged); code != http.StatusUnauthorized {
+		t.Errorf("action with a forged session: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	// Rotating the token ends its sessions
+	adminCfg.APITokens[0].Token = "rotated-token-0123"
+	handler = authMiddleware(adminCfg, mux)
+	if code := runRetention(session); code != http.StatusUnauthorized {
+		t.Errorf("action with the session of a rotated token: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/logout", nil))
+	if cookies := rr.Result().Cookies(); rr.Code != http.StatusSeeOther || len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("logout: got status %d and cookies %v, want the session cookie cleared", rr.Code, cookies)
+	}
+}
@@ -0,0 +1,242 @@
+package adminserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+)
+
+// roleContextKey is the context key for the authenticated client's role
+type roleContextKey struct{}
+
+// actorContextKey is the context key for the authenticated client's name
+type actorContextKey struct{}
+
+// operatorActions are the state-changing endpoints an operator may call
+var operatorActions = map[string]bool{
+	"/api/backups/run":       true,
+	"/api/retention/run":     true,
+	"/api/storage/reconcile": true,
+}
+
+// publicPaths are served without credentials so probes keep working
+var publicPaths = map[string]bool{
+	"/healthz":        true,
+	"/healthz/leader": true,
+}
+
+// requiresAuth reports whether a request must present credentials. Client
+// certificates guard the whole UI; API tokens alone guard the API and every
+// change, leaving read-only pages and their dashboard fragments open. Browsers
+// log in with a token to get a session cookie for the UI's actions.
+func requiresAuth(adminCfg config.AdminServerConfig, r *http.Request) bool {
+	if publicPaths[r.URL.Path] {
+		return false
+	}
+	if adminCfg.ClientAuthEnabled() || len(adminCfg.APITokens) == 0 || !isReadOnly(r) {
+		return true
+	}
+	return strings.HasPrefix(r.URL.Path, "/api/") && !strings.HasPrefix(r.URL.Path, "/api/dashboard/")
+}
+
+// isReadOnly reports whether a request only reads
+func isReadOnly(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions
+}
+
+// RoleFromContext returns the role of the authenticated client, if any
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(roleContextKey{}).(string)
+	return role
+}
+
+// ActorFromContext returns the name of the authenticated client, if any: the
+// API token name or the certificate's common name
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorContextKey{}).(string)
+	return actor
+}
+
+// roleForCertificate maps a verified client certificate to a role. Common
+// name mappings take precedence over organizational unit mappings.
+func roleForCertificate(cert *x509.Certificate, adminCfg config.AdminServerConfig) string {
+	if role, ok := adminCfg.ClientRoles["CN="+cert.Subject.CommonName]; ok {
+		return role
+	}
+
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if role, ok := adminCfg.ClientRoles["OU="+ou]; ok {
+			return role
+		}
+	}
+
+	return adminCfg.DefaultRole
+}
+
+// roleAllows reports whether role may perform the request
+func roleAllows(role string, r *http.Request) bool {
+	readOnly := isReadOnly(r)
+
+	switch role {
+	case config.RoleAdmin:
+		return true
+	case config.RoleOperator:
+		return readOnly || operatorActions[r.URL.Path]
+	case config.RoleViewer:
+		return readOnly
+	default:
+		return false
+	}
+}
+
+// roleForToken returns the API token matching a bearer token. Every token is
+// compared so the time taken does not reveal which one nearly matched.
+func roleForToken(bearer string, tokens []config.APIToken) (config.APIToken, bool) {
+	var match config.APIToken
+	found := false
+	for _, token := range tokens {
+		if subtle.ConstantTimeCompare([]byte(bearer), []byte(token.Token)) == 1 {
+			match = token
+			found = true
+		}
+	}
+	return match, found
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(header[7:]), true
+}
+
+// authMiddleware authorizes requests using an API token, a session started
+// with one or, failing those, the verified client certificate. Requests that
+// need no credentials pass through unauthenticated. With API tokens it also
+// serves the login and logout pages.
+func authMiddleware(adminCfg config.AdminServerConfig, next http.Handler) http.Handler {
+	login := loginHandler(adminCfg)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(adminCfg.APITokens) > 0 {
+			switch r.URL.Path {
+			case loginPath:
+				login(w, r)
+				return
+			case logoutPath:
+				logoutHandler(w, r)
+				return
+			}
+		}
+		if !requiresAuth(adminCfg, r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var role, actor string
+		if bearer, ok := bearerToken(r); ok {
+			token, found := roleForToken(bearer, adminCfg.APITokens)
+			if !found {
+				http.Error(w, "Invalid API token", http.StatusUnauthorized)
+				return
+			}
+			role = token.Role
+			actor = "token:" + token.Name
+		} else if token, found := tokenForSession(r, adminCfg.APITokens); found {
+			role = token.Role
+			actor = "token:" + token.Name
+		} else {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+				if len(adminCfg.APITokens) > 0 {
+					http.Error(w, "API token or client certificate required; browsers log in at "+loginPath, http.StatusUnauthorized)
+				} else {
+					http.Error(w, "Client certificate required", http.StatusUnauthorized)
+				}
+				return
+			}
+
+			cert := r.TLS.VerifiedChains[0][0]
+			role = roleForCertificate(cert, adminCfg)
+			actor = "CN=" + cert.Subject.CommonName
+			if role == "" {
+				http.Error(w, fmt.Sprintf("No role assigned to client %q", cert.Subject.CommonName), http.StatusForbidden)
+				return
+			}
+		}
+
+		if !roleAllows(role, r) {
+			http.Error(w, fmt.Sprintf("Role %s may not %s %s", role, r.Method, r.URL.Path), http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), roleContextKey{}, role)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, actorContextKey{}, actor)))
+	})
+}
+
+// secureHeadersMiddleware adds HSTS and marks cookies Secure when serving HTTPS
+func secureHeadersMiddleware(hstsMaxAge int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hstsMaxAge > 0 {
+			w.Header().Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d; includeSubDomains", hstsMaxAge))
+		}
+		next.ServeHTTP(&secureCookieWriter{ResponseWriter: w}, r)
+	})
+}
+
+// secureCookieWriter adds Secure, HttpOnly and SameSite to cookies set by handlers
+type secureCookieWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+// WriteHeader rewrites Set-Cookie headers before they are sent
+func (w *secureCookieWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		cookies := w.Header().Values("Set-Cookie")
+		if len(cookies) > 0 {
+			w.Header().Del("Set-Cookie")
+			for _, cookie := range cookies {
+				w.Header().Add("Set-Cookie", secureCookie(cookie))
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write ensures headers are rewritten for handlers that never call WriteHeader
+func (w *secureCookieWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush supports streaming handlers such as log tailing
+func (w *secureCookieWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// secureCookie adds any missing security attributes to a Set-Cookie value
+func secureCookie(cookie string) string {
+	lower := strings.ToLower(cookie)
+	if !strings.Contains(lower, "; secure") {
+		cookie += "; Secure"
+	}
+	if !strings.Contains(lower, "; httponly") {
+		cookie += "; HttpOnly"
+	}
+	if !strings.Contains(lower, "; samesite") {
+		cookie += "; SameSite=Strict"
+	}
+	return cookie
+}
@@ -0,0 +1,99 @@
+package adminserver
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/leader"
+)
+
+// leaderHeader names the current leader in responses refused by a follower
+const leaderHeader = "X-GoSQLGuard-Leader"
+
+// roleReporter reports the role of this replica
+type roleReporter interface {
+	Status() leader.Status
+}
+
+// SetElector makes the server report this replica's role and refuse changes
+// while it is a follower
+func (s *Server) SetElector(elector *leader.Elector) {
+	if elector != nil {
+		s.elector = elector
+	}
+}
+
+// leaderStatus returns the role of this replica
+func (s *Server) leaderStatus() leader.Status {
+	if s.elector == nil {
+		return leader.Status{Role: leader.RoleStandalone}
+	}
+	return s.elector.Status()
+}
+
+// followerReadOnlyMiddleware refuses state-changing API requests while this
+// replica is a follower, so schedules, servers and backups are only changed
+// through the leader that acts on them
+func (s *Server) followerReadOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		readOnly := r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions
+		if readOnly || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		status := s.leaderStatus()
+		if status.Role != leader.RoleFollower {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		msg := "This replica is a follower and is read-only"
+		if status.Leader != "" {
+			w.Header().Set(leaderHeader, status.Leader)
+			msg += "; send changes to the leader " + status.Leader
+		} else {
+			msg += "; no leader is currently elected"
+		}
+		http.Error(w, msg, http.StatusServiceUnavailable)
+	})
+}
+
+// healthFields returns the leader election fields of the health response
+func (s *Server) healthFields() map[string]string {
+	status := s.leaderStatus()
+	fields := map[string]string{"role": string(status.Role)}
+	if status.Identity != "" {
+		fields["identity"] = status.Identity
+	}
+	if status.Leader != "" {
+		fields["leader"] = status.Leader
+	}
+	if status.Token > 0 {
+		fields["token"] = strconv.FormatInt(status.Token, 10)
+	}
+	return fields
+}
+
+// leaderCheckHandler answers 200 on the leader and 503 elsewhere, so a load
+// balancer or Service can send changes to the leader only
+func (s *Server) leaderCheckHandler(w http.ResponseWriter, r *http.Request) {
+	status := s.leaderStatus()
+	code := http.StatusOK
+	if status.Role == leader.RoleFollower {
+		code = http.StatusServiceUnavailable
+	}
+
+	response := s.healthFields()
+	response["time"] = time.Now().Format(time.RFC3339)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding leader check response: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package adminserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+)
+
+// sessionCookieName is the cookie a browser sends after logging in with an
+// API token
+const sessionCookieName = "gosqlguard_session"
+
+// sessionLifetime is how long a browser stays logged in
+const sessionLifetime = 12 * time.Hour
+
+// Login and logout pages, served when API tokens are configured
+const (
+	loginPath  = "/login"
+	logoutPath = "/logout"
+)
+
+// loginPage asks for an API token so browsers can use the UI's actions
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <title>GoSQLGuard - Log in</title>
+    <style>
+        body { font-family: sans-serif; background-color: #f5f5f5; }
+        form { max-width: 360px; margin: 80px auto; padding: 24px; background: #ffffff; border-radius: 4px; }
+        input { width: 100%; margin: 8px 0 16px; padding: 8px; box-sizing: border-box; }
+        .error { color: #b00020; }
+    </style>
+</head>
+<body>
+    <form method="post" action="/login">
+        <h1>GoSQLGuard</h1>
+        {{if .}}<p class="error">{{.}}</p>{{end}}
+        <label for="token">API token</label>
+        <input id="token" name="token" type="password" autocomplete="current-password" autofocus>
+        <button type="submit">Log in</button>
+    </form>
+</body>
+</html>`))
+
+// sessionValue returns the cookie value for a session of token lasting until
+// expires. The value is signed with the token itself, so every replica
+// accepts it and rotating or removing the token ends its sessions.
+func sessionValue(token config.APIToken, expires time.Time) string {
+	name := base64.RawURLEncoding.EncodeToString([]byte(token.Name))
+	payload := name + "." + strconv.FormatInt(expires.Unix(), 10)
+	return payload + "." + sessionSignature(token, payload)
+}
+
+// sessionSignature signs a session payload with the token
+func sessionSignature(token config.APIToken, payload string) string {
+	mac := hmac.New(sha256.New, []byte(token.Token))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// tokenForSession returns the API token a request's session cookie was
+// issued for, if the cookie is present, unexpired and correctly signed
+func tokenForSession(r *http.Request, tokens []config.APIToken) (config.APIToken, bool) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return config.APIToken{}, false
+	}
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		return config.APIToken{}, false
+	}
+	name, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return config.APIToken{}, false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return config.APIToken{}, false
+	}
+
+	for _, token := range tokens {
+		if token.Name != string(name) {
+			continue
+		}
+		expected := sessionSignature(token, parts[0]+"."+parts[1])
+		if hmac.Equal([]byte(parts[2]), []byte(expected)) {
+			return token, true
+		}
+	}
+	return config.APIToken{}, false
+}
+
+// setSessionCookie sets or, with an empty value, clears the session cookie
+func setSessionCookie(w http.ResponseWriter, r *http.Request, value string, expires time.Time) {
+	cookie := &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	}
+	if value == "" {
+		cookie.MaxAge = -1
+	} else {
+		cookie.Expires = expires
+	}
+	http.SetCookie(w, cookie)
+}
+
+// loginHandler shows the login page and, given a valid API token, starts a
+// session for it
+func loginHandler(adminCfg config.AdminServerConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			renderLoginPage(w, http.StatusOK, "")
+		case http.MethodPost:
+			token, found := roleForToken(r.PostFormValue("token"), adminCfg.APITokens)
+			if !found {
+				renderLoginPage(w, http.StatusUnauthorized, "Invalid API token")
+				return
+			}
+			expires := time.Now().Add(sessionLifetime)
+			setSessionCookie(w, r, sessionValue(token, expires), expires)
+			log.Printf("Admin UI session started for API token %s", token.Name)
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// logoutHandler ends the browser's session
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	setSessionCookie(w, r, "", time.Time{})
+	http.Redirect(w, r, loginPath, http.StatusSeeOther)
+}
+
+// renderLoginPage writes the login page with an optional error message
+func renderLoginPage(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := loginPage.Execute(w, message); err != nil {
+		log.Printf("Failed to render login page: %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+package adminserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+)
+
+// writeSelfSigned writes a self-signed certificate and key for commonName
+func writeSelfSigned(t *testing.T, dir, commonName string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+// servedCommonName returns the common name of the certificate currently served
+func servedCommonName(t *testing.T, r *certReloader) string {
+	t.Helper()
+
+	cert, err := r.getCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloaderPicksUpRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir, "first")
+
+	reloader, err := newCertReloader(certFile, keyFile, "")
+	require.NoError(t, err)
+	reloader.checkInterval = 0
+	assert.Equal(t, "first", servedCommonName(t, reloader))
+
+	// Rotate the certificate and make sure the modification time moves
+	writeSelfSigned(t, dir, "second")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+	assert.Equal(t, "second", servedCommonName(t, reloader))
+
+	// A broken rotation keeps the last good certificate
+	require.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0600))
+	later := future.Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+	assert.Equal(t, "second", servedCommonName(t, reloader))
+}
+
+func TestCertReloaderRequiresClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir, "server")
+
+	reloader, err := newCertReloader(certFile, keyFile, certFile)
+	require.NoError(t, err)
+
+	cfg, err := reloader.getConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+	assert.NotNil(t, cfg.ClientCAs)
+
+	_, err = newCertReloader(certFile, keyFile, filepath.Join(dir, "missing.pem"))
+	assert.Error(t, err)
+}
+
+// requestWithClientCert builds a request as if a verified client certificate was presented
+func requestWithClientCert(method, path, commonName string, ous ...string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName, OrganizationalUnit: ous}}
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	return req
+}
+
+func TestClientCertAuthMiddleware(t *testing.T) {
+	adminCfg := config.AdminServerConfig{
+		TLSEnabled: true,
+		ClientRoles: map[string]string{
+			"CN=alice":   config.RoleAdmin,
+			"OU=backups": config.RoleOperator,
+			"CN=grafana": config.RoleViewer,
+		},
+	}
+
+	var seenRole string
+	handler := authMiddleware(adminCfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenRole = RoleFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name         string
+		req          *http.Request
+		expectedCode int
+		expectedRole string
+	}{
+		{
+			name:         "No certificate",
+			req:          httptest.NewRequest(http.MethodGet, "/", nil),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Unmapped certificate without default role",
+			req:          requestWithClientCert(http.MethodGet, "/", "mallory"),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Admin can delete",
+			req:          requestWithClientCert(http.MethodPost, "/api/backups/delete", "alice"),
+			expectedCode: http.StatusOK,
+			expectedRole: config.RoleAdmin,
+		},
+		{
+			name:         "Operator mapped by OU can run backups",
+			req:          requestWithClientCert(http.MethodPost, "/api/backups/run", "bob", "backups"),
+			expectedCode: http.StatusOK,
+			expectedRole: config.RoleOperator,
+		},
+		{
+			name:         "Operator cannot delete",
+			req:          requestWithClientCert(http.MethodPost, "/api/backups/delete", "bob", "backups"),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Viewer can read",
+			req:          requestWithClientCert(http.MethodGet, "/api/backups", "grafana"),
+			expectedCode: http.StatusOK,
+			expectedRole: config.RoleViewer,
+		},
+		{
+			name:         "Viewer cannot run backups",
+			req:          requestWithClientCert(http.MethodPost, "/api/backups/run", "grafana"),
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seenRole = ""
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedRole, seenRole)
+		})
+	}
+
+	// A default role applies to verified but unmapped clients
+	adminCfg.DefaultRole = config.RoleViewer
+	handler = authMiddleware(adminCfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, requestWithClientCert(http.MethodGet, "/", "mallory"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestAPITokenAuth(t *testing.T) {
+	adminCfg := config.AdminServerConfig{
+		ClientRoles: map[string]string{"CN=alice": config.RoleAdmin},
+		APITokens: []config.APIToken{
+			{Name: "ci", Role: config.RoleOperator, Token: "operator-token-0123"},
+			{Name: "grafana", Role: config.RoleViewer, Token: "viewer-token-01234"},
+		},
+	}
+
+	var seenRole string
+	handler := authMiddleware(adminCfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenRole = RoleFromContext(r.Context())
+	}))
+
+	withToken := func(method, path, token string) *http.Request {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	tests := []struct {
+		name         string
+		req          *http.Request
+		expectedCode int
+		expectedRole string
+	}{
+		{"No credentials", httptest.NewRequest(http.MethodGet, "/api/backups", nil), http.StatusUnauthorized, ""},
+		{"Unknown token", withToken(http.MethodGet, "/api/backups", "guess"), http.StatusUnauthorized, ""},
+		{"Operator token can run backups", withToken(http.MethodPost, "/api/backups/run", "operator-token-0123"), http.StatusOK, config.RoleOperator},
+		{"Viewer token cannot run backups", withToken(http.MethodPost, "/api/backups/run", "viewer-token-01234"), http.StatusForbidden, ""},
+		{"Certificates still work", requestWithClientCert(http.MethodPost, "/api/backups/delete", "alice"), http.StatusOK, config.RoleAdmin},
+		{"UI pages stay open", httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, ""},
+		{"Dashboard fragments stay open", httptest.NewRequest(http.MethodGet, "/api/dashboard/recent-backups", nil), http.StatusOK, ""},
+		{"Health checks stay open", httptest.NewRequest(http.MethodGet, "/healthz", nil), http.StatusOK, ""},
+		{"Changes from the UI need a token", httptest.NewRequest(http.MethodPost, "/api/retention/run", nil), http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seenRole = ""
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedRole, seenRole)
+		})
+	}
+}
+
+func TestSecureHeadersMiddleware(t *testing.T) {
+	handler := secureHeadersMiddleware(3600, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+		w.Write([]byte("ok"))
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, "max-age=3600; includeSubDomains", rr.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "session=abc; Secure; HttpOnly; SameSite=Strict", rr.Header().Get("Set-Cookie"))
+	assert.Equal(t, "ok", rr.Body.String())
+}
+
+func TestMetricsMuxServesOnlyMetricsAndHealth(t *testing.T) {
+	s := &Server{}
+	mux := s.metricsMux()
+
+	for path, expected := range map[string]int{
+		"/metrics": http.StatusOK,
+		"/healthz": http.StatusOK,
+		"/":        http.StatusNotFound,
+	} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, expected, rr.Code, path)
+	}
+}
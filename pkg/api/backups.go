@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
 )
 
 // BackupsHandler handles backup-related API endpoints
@@ -96,70 +97,42 @@ func (h *BackupsHandler) handleBackups(w http.ResponseWriter, r *http.Request) {
 func (h *BackupsHandler) handleBackupsLegacy(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
-	// Get filter parameters
-	serverName := query.Get("server")
-	database := query.Get("database")
-	backupType := query.Get("type")
-	activeOnly := query.Get("activeOnly") == "true"
-
-	// Get filtered backups
-	var backups []metadata.BackupMeta
-	if serverName != "" || database != "" || backupType != "" || activeOnly {
-		backups = metadata.DefaultStore.GetBackupsFiltered(serverName, database, backupType, activeOnly)
-	} else {
-		backups = metadata.DefaultStore.GetBackups()
+	q := types.BackupQuery{
+		ServerName: query.Get("server"),
+		Database:   query.Get("database"),
+		BackupType: query.Get("type"),
+		ActiveOnly: query.Get("activeOnly") == "true",
+		Search:     query.Get("search"),
+		Limit:      types.MaxQueryLimit,
+	}
+	if status := query.Get("status"); status != "" {
+		q.Statuses = []types.BackupStatus{types.BackupStatus(status)}
 	}
-
-	// Apply additional filters if needed
-	filteredBackups := backups
-
-	// Date range filter
 	if startDate := query.Get("startDate"); startDate != "" {
 		if t, err := time.Parse("2006-01-02", startDate); err == nil {
-			var filtered []metadata.BackupMeta
-			for _, b := range filteredBackups {
-				if b.CreatedAt.After(t) || b.CreatedAt.Equal(t) {
-					filtered = append(filtered, b)
-				}
-			}
-			filteredBackups = filtered
+			q.CreatedAfter = t
 		}
 	}
-
 	if endDate := query.Get("endDate"); endDate != "" {
 		if t, err := time.Parse("2006-01-02", endDate); err == nil {
 			// Add 1 day to include the entire end date
-			endTime := t.AddDate(0, 0, 1)
-			var filtered []metadata.BackupMeta
-			for _, b := range filteredBackups {
-				if b.CreatedAt.Before(endTime) {
-					filtered = append(filtered, b)
-				}
-			}
-			filteredBackups = filtered
+			q.CreatedBefore = t.AddDate(0, 0, 1)
 		}
 	}
 
-	// Search filter
-	if search := query.Get("search"); search != "" {
-		var filtered []metadata.BackupMeta
-		for _, b := range filteredBackups {
-			if contains(b.ID, search) || contains(b.ServerName, search) || contains(b.Database, search) {
-				filtered = append(filtered, b)
-			}
+	// The store filters; collect every page for the single page response
+	var filteredBackups []metadata.BackupMeta
+	for {
+		page, err := metadata.DefaultStore.QueryBackups(q)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to retrieve backups: %v", err), http.StatusInternalServerError)
+			return
 		}
-		filteredBackups = filtered
-	}
-
-	// Status filter
-	if status := query.Get("status"); status != "" {
-		var filtered []metadata.BackupMeta
-		for _, b := range filteredBackups {
-			if string(b.Status) == status {
-				filtered = append(filtered, b)
-			}
+		filteredBackups = append(filteredBackups, page.Backups...)
+		if page.NextCursor == "" {
+			break
 		}
-		filteredBackups = filtered
+		q.Cursor = page.NextCursor
 	}
 
 	// Create legacy response format
@@ -223,19 +196,3 @@ func parseInt(s string, defaultValue int) int {
 	}
 	return defaultValue
 }
-
-func contains(s, substr string) bool {
-	return len(s) > 0 && len(substr) > 0 &&
-		(s == substr || (len(s) >= len(substr) && s[:len(substr)] == substr) ||
-			(len(s) >= len(substr) && s[len(s)-len(substr):] == substr) ||
-			(len(s) > len(substr) && findSubstring(s, substr)))
-}
-
-func findSubstring(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
-}
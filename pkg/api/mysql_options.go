@@ -8,6 +8,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/supporttools/GoSQLGuard/pkg/config"
 	"github.com/supporttools/GoSQLGuard/pkg/database"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata"
 )
 
@@ -272,10 +273,7 @@ func (h *MySQLOptionsHandler) saveMySQLOptionsToDatabase() error {
 	query := `
 		INSERT INTO mysql_options (id, name, options, updated_at)
 		VALUES (1, 'global', ?, NOW())
-		ON DUPLICATE KEY UPDATE
-			options = VALUES(options),
-			updated_at = NOW()
-	`
+	` + dbmeta.UpsertClause(db, "id", "options", "updated_at")
 
 	if err := db.Exec(query, string(globalJSON)).Error; err != nil {
 		return fmt.Errorf("failed to save global MySQL options to database: %w", err)
@@ -307,10 +305,7 @@ func (h *MySQLOptionsHandler) saveMySQLOptionsToDatabase() error {
 			query := `
 				INSERT INTO mysql_options (server_name, name, options, updated_at)
 				VALUES (?, 'server', ?, NOW())
-				ON DUPLICATE KEY UPDATE
-					options = VALUES(options),
-					updated_at = NOW()
-			`
+			` + dbmeta.UpsertClause(db, "server_name", "options", "updated_at")
 
 			if err := db.Exec(query, server.Name, string(serverJSON)).Error; err != nil {
 				if h.Logger != nil {
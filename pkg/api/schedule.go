@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -36,6 +37,10 @@ func NewScheduleHandler(sched *scheduler.Scheduler) *ScheduleHandler {
 func (h *ScheduleHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/schedules", h.handleSchedules)
 	mux.HandleFunc("/api/schedules/delete", h.handleDeleteSchedule)
+	mux.HandleFunc("/api/schedules/upcoming", h.handleUpcoming)
+	mux.HandleFunc("/api/schedules/pause", h.handlePauseSchedule)
+	mux.HandleFunc("/api/schedules/resume", h.handleResumeSchedule)
+	mux.HandleFunc("/api/schedules/skip-next", h.handleSkipNext)
 }
 
 // handleSchedules handles GET and POST requests for schedule management
@@ -96,13 +101,53 @@ func (h *ScheduleHandler) getSchedules(w http.ResponseWriter, r *http.Request) {
 
 // scheduleRequest is the request structure for creating/updating a schedule
 type scheduleRequest struct {
-	ID             string         `json:"id,omitempty"`
-	Name           string         `json:"name"`
-	BackupType     string         `json:"backupType"`
-	CronExpression string         `json:"cronExpression"`
-	Enabled        bool           `json:"enabled"`
-	LocalStorage   storageRequest `json:"localStorage"`
-	S3Storage      storageRequest `json:"s3Storage"`
+	ID             string          `json:"id,omitempty"`
+	Name           string          `json:"name"`
+	BackupType     string          `json:"backupType"`
+	CronExpression string          `json:"cronExpression"`
+	Enabled        bool            `json:"enabled"`
+	Targets        scheduleTargets `json:"targets"`
+	scheduleTiming
+	LocalStorage storageRequest `json:"localStorage"`
+	S3Storage    storageRequest `json:"s3Storage"`
+}
+
+// scheduleTiming holds when a schedule may run
+type scheduleTiming struct {
+	Timezone     string                  `json:"timezone,omitempty"`
+	Jitter       string                  `json:"jitter,omitempty"`
+	MaxRunWindow string                  `json:"maxRunWindow,omitempty"`
+	Blackouts    []config.BlackoutWindow `json:"blackouts,omitempty"`
+	CatchUp      string                  `json:"catchUp,omitempty"`
+}
+
+// scheduleTargets selects the servers and databases a schedule backs up.
+// Servers and databases may be glob patterns; empty lists match everything.
+type scheduleTargets struct {
+	Servers          []string          `json:"servers,omitempty"`
+	Databases        []string          `json:"databases,omitempty"`
+	ExcludeDatabases []string          `json:"excludeDatabases,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+}
+
+// toConfig converts the request targets to configuration targets
+func (t scheduleTargets) toConfig() config.BackupTargets {
+	return config.BackupTargets{
+		Servers:          t.Servers,
+		Databases:        t.Databases,
+		ExcludeDatabases: t.ExcludeDatabases,
+		Labels:           t.Labels,
+	}
+}
+
+// scheduleTargetsFromConfig converts configuration targets to the API format
+func scheduleTargetsFromConfig(t config.BackupTargets) scheduleTargets {
+	return scheduleTargets{
+		Servers:          t.Servers,
+		Databases:        t.Databases,
+		ExcludeDatabases: t.ExcludeDatabases,
+		Labels:           t.Labels,
+	}
 }
 
 // storageRequest defines storage settings for a backup schedule
@@ -110,6 +155,34 @@ type storageRequest struct {
 	Enabled     bool   `json:"enabled"`
 	Duration    string `json:"duration"`
 	KeepForever bool   `json:"keepForever"`
+	retentionCounts
+}
+
+// retentionCounts holds the count-based retention settings for a storage location
+type retentionCounts struct {
+	KeepLast    int `json:"keepLast,omitempty"`
+	KeepDaily   int `json:"keepDaily,omitempty"`
+	KeepWeekly  int `json:"keepWeekly,omitempty"`
+	KeepMonthly int `json:"keepMonthly,omitempty"`
+	KeepYearly  int `json:"keepYearly,omitempty"`
+	MinKeep     int `json:"minKeep,omitempty"`
+}
+
+// toPolicy converts the storage request to a stored retention policy
+func (req storageRequest) toPolicy(scheduleID, storageType string) dbmeta.ScheduleRetentionPolicy {
+	return dbmeta.ScheduleRetentionPolicy{
+		ScheduleID:  scheduleID,
+		StorageType: storageType,
+		Duration:    req.Duration,
+		KeepForever: req.KeepForever,
+		KeepLast:    req.KeepLast,
+		KeepDaily:   req.KeepDaily,
+		KeepWeekly:  req.KeepWeekly,
+		KeepMonthly: req.KeepMonthly,
+		KeepYearly:  req.KeepYearly,
+		MinKeep:     req.MinKeep,
+		CreatedAt:   time.Now(),
+	}
 }
 
 // scheduleResponse is the response structure for schedule information
@@ -119,10 +192,14 @@ type scheduleResponse struct {
 	BackupType     string          `json:"backupType"`
 	CronExpression string          `json:"cronExpression"`
 	Enabled        bool            `json:"enabled"`
-	LocalStorage   storageResponse `json:"localStorage"`
-	S3Storage      storageResponse `json:"s3Storage"`
-	CreatedAt      time.Time       `json:"createdAt"`
-	UpdatedAt      time.Time       `json:"updatedAt"`
+	Targets        scheduleTargets `json:"targets"`
+	TargetsSummary string          `json:"targetsSummary"`
+	scheduleTiming
+	scheduleControlResponse
+	LocalStorage storageResponse `json:"localStorage"`
+	S3Storage    storageResponse `json:"s3Storage"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	UpdatedAt    time.Time       `json:"updatedAt"`
 }
 
 // storageResponse defines storage response information
@@ -130,6 +207,24 @@ type storageResponse struct {
 	Enabled     bool   `json:"enabled"`
 	Duration    string `json:"duration"`
 	KeepForever bool   `json:"keepForever"`
+	retentionCounts
+}
+
+// storageResponseFromPolicy converts a stored retention policy to a response
+func storageResponseFromPolicy(policy dbmeta.ScheduleRetentionPolicy) storageResponse {
+	return storageResponse{
+		Enabled:     true,
+		Duration:    policy.Duration,
+		KeepForever: policy.KeepForever,
+		retentionCounts: retentionCounts{
+			KeepLast:    policy.KeepLast,
+			KeepDaily:   policy.KeepDaily,
+			KeepWeekly:  policy.KeepWeekly,
+			KeepMonthly: policy.KeepMonthly,
+			KeepYearly:  policy.KeepYearly,
+			MinKeep:     policy.MinKeep,
+		},
+	}
 }
 
 // convertScheduleToResponse converts a BackupSchedule to a scheduleResponse
@@ -140,8 +235,17 @@ func convertScheduleToResponse(schedule *dbmeta.BackupSchedule) scheduleResponse
 		BackupType:     schedule.BackupType,
 		CronExpression: schedule.CronExpression,
 		Enabled:        schedule.Enabled,
-		CreatedAt:      schedule.CreatedAt,
-		UpdatedAt:      schedule.UpdatedAt,
+		Targets:        scheduleTargetsFromConfig(schedule.Targets()),
+		TargetsSummary: schedule.Targets().Description(),
+		scheduleTiming: scheduleTiming{
+			Timezone:     schedule.Timezone,
+			Jitter:       schedule.Jitter,
+			MaxRunWindow: schedule.MaxRunWindow,
+			Blackouts:    schedule.BlackoutWindows(),
+			CatchUp:      schedule.CatchUp,
+		},
+		CreatedAt: schedule.CreatedAt,
+		UpdatedAt: schedule.UpdatedAt,
 		LocalStorage: storageResponse{
 			Enabled:     false,
 			Duration:    "24h",
@@ -154,16 +258,14 @@ func convertScheduleToResponse(schedule *dbmeta.BackupSchedule) scheduleResponse
 		},
 	}
 
+	resp.scheduleControlResponse = scheduleControlFromSchedule(schedule, time.Now())
+
 	// Process retention policies
 	for _, policy := range schedule.RetentionPolicies {
 		if policy.StorageType == "local" {
-			resp.LocalStorage.Enabled = true
-			resp.LocalStorage.Duration = policy.Duration
-			resp.LocalStorage.KeepForever = policy.KeepForever
+			resp.LocalStorage = storageResponseFromPolicy(policy)
 		} else if policy.StorageType == "s3" {
-			resp.S3Storage.Enabled = true
-			resp.S3Storage.Duration = policy.Duration
-			resp.S3Storage.KeepForever = policy.KeepForever
+			resp.S3Storage = storageResponseFromPolicy(policy)
 		}
 	}
 
@@ -185,12 +287,40 @@ func (h *ScheduleHandler) createOrUpdateSchedule(w http.ResponseWriter, r *http.
 		return
 	}
 
+	targets := req.Targets.toConfig()
+	if err := targets.Validate(); err != nil {
+		http.Error(w, "Invalid targets: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	timing := config.BackupTypeConfig{
+		Schedule:     req.CronExpression,
+		Timezone:     req.Timezone,
+		Jitter:       req.Jitter,
+		MaxRunWindow: req.MaxRunWindow,
+		Blackouts:    req.Blackouts,
+		CatchUp:      req.CatchUp,
+	}
+	if err := timing.ValidateSchedule(); err != nil {
+		http.Error(w, "Invalid schedule: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Create the schedule
 	schedule := dbmeta.BackupSchedule{
 		Name:           req.Name,
 		BackupType:     req.BackupType,
 		CronExpression: req.CronExpression,
 		Enabled:        req.Enabled,
+		Timezone:       req.Timezone,
+		Jitter:         req.Jitter,
+		MaxRunWindow:   req.MaxRunWindow,
+		CatchUp:        req.CatchUp,
+	}
+	schedule.SetTargets(targets)
+	if err := schedule.SetBlackoutWindows(req.Blackouts); err != nil {
+		http.Error(w, "Invalid blackout windows: "+err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Handle update vs create
@@ -205,6 +335,13 @@ func (h *ScheduleHandler) createOrUpdateSchedule(w http.ResponseWriter, r *http.
 		}
 		schedule.CreatedAt = existing.CreatedAt
 		isUpdate = true
+
+		// Editing a schedule keeps it paused or skipping
+		schedule.Paused = existing.Paused
+		schedule.PausedAt = existing.PausedAt
+		schedule.PausedUntil = existing.PausedUntil
+		schedule.PauseReason = existing.PauseReason
+		schedule.SkipNextAt = existing.SkipNextAt
 	} else {
 		// This is a new schedule, generate ID
 		schedule.ID = uuid.New().String()
@@ -213,25 +350,37 @@ func (h *ScheduleHandler) createOrUpdateSchedule(w http.ResponseWriter, r *http.
 
 	schedule.UpdatedAt = time.Now()
 
+	// Each enabled schedule owns its backup type, so two schedules cannot
+	// share one without overwriting each other's targets and retention
+	if req.Enabled {
+		conflict, err := h.findBackupTypeConflict(schedule.ID, req.BackupType)
+		if err != nil {
+			http.Error(w, "Failed to check schedules: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if conflict != "" {
+			http.Error(w, "Backup type "+req.BackupType+" is already used by enabled schedule "+conflict, http.StatusConflict)
+			return
+		}
+	}
+
 	// Add retention policies
 	if req.LocalStorage.Enabled {
-		schedule.RetentionPolicies = append(schedule.RetentionPolicies, dbmeta.ScheduleRetentionPolicy{
-			ScheduleID:  schedule.ID,
-			StorageType: "local",
-			Duration:    req.LocalStorage.Duration,
-			KeepForever: req.LocalStorage.KeepForever,
-			CreatedAt:   time.Now(),
-		})
+		policy := req.LocalStorage.toPolicy(schedule.ID, "local")
+		if err := policy.RetentionRule().Validate(); err != nil {
+			http.Error(w, "Invalid local retention: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		schedule.RetentionPolicies = append(schedule.RetentionPolicies, policy)
 	}
 
 	if req.S3Storage.Enabled {
-		schedule.RetentionPolicies = append(schedule.RetentionPolicies, dbmeta.ScheduleRetentionPolicy{
-			ScheduleID:  schedule.ID,
-			StorageType: "s3",
-			Duration:    req.S3Storage.Duration,
-			KeepForever: req.S3Storage.KeepForever,
-			CreatedAt:   time.Now(),
-		})
+		policy := req.S3Storage.toPolicy(schedule.ID, "s3")
+		if err := policy.RetentionRule().Validate(); err != nil {
+			http.Error(w, "Invalid S3 retention: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		schedule.RetentionPolicies = append(schedule.RetentionPolicies, policy)
 	}
 
 	// Save to database
@@ -255,6 +404,20 @@ func (h *ScheduleHandler) createOrUpdateSchedule(w http.ResponseWriter, r *http.
 	json.NewEncoder(w).Encode(response)
 }
 
+// findBackupTypeConflict returns the name of another enabled schedule using the backup type
+func (h *ScheduleHandler) findBackupTypeConflict(id, backupType string) (string, error) {
+	schedules, err := h.scheduleRepo.GetEnabledSchedules()
+	if err != nil {
+		return "", err
+	}
+	for _, schedule := range schedules {
+		if schedule.ID != id && schedule.BackupType == backupType {
+			return schedule.Name, nil
+		}
+	}
+	return "", nil
+}
+
 // handleDeleteSchedule handles deleting a schedule
 func (h *ScheduleHandler) handleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
 	// Only allow POST method
@@ -304,6 +467,142 @@ func (h *ScheduleHandler) handleDeleteSchedule(w http.ResponseWriter, r *http.Re
 	})
 }
 
+// Limits on the number of upcoming runs listed per schedule
+const (
+	defaultUpcomingRuns = 5
+	maxUpcomingRuns     = 100
+)
+
+// upcomingRun is a projected run of a schedule
+type upcomingRun struct {
+	Time     time.Time `json:"time"`
+	Blackout string    `json:"blackout,omitempty"`
+	Action   string    `json:"action,omitempty"`
+}
+
+// lastRunResponse describes how a schedule's most recent run went
+type lastRunResponse struct {
+	Status          string     `json:"status"`
+	ScheduledAt     *time.Time `json:"scheduledAt,omitempty"`
+	StartedAt       *time.Time `json:"startedAt,omitempty"`
+	CompletedAt     *time.Time `json:"completedAt,omitempty"`
+	Duration        string     `json:"duration,omitempty"`
+	DurationSeconds float64    `json:"durationSeconds,omitempty"`
+	Error           string     `json:"error,omitempty"`
+	MissedRuns      int        `json:"missedRuns"`
+}
+
+// upcomingScheduleResponse lists the next runs of a schedule
+type upcomingScheduleResponse struct {
+	BackupType     string           `json:"backupType"`
+	CronExpression string           `json:"cronExpression"`
+	Timezone       string           `json:"timezone"`
+	Targets        scheduleTargets  `json:"targets"`
+	TargetsSummary string           `json:"targetsSummary"`
+	NextRuns       []upcomingRun    `json:"nextRuns"`
+	LastRun        *lastRunResponse `json:"lastRun,omitempty"`
+}
+
+// handleUpcoming lists the next fire times of each active schedule along with
+// the outcome of its last run
+func (h *ScheduleHandler) handleUpcoming(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.scheduler == nil {
+		http.Error(w, "Schedule introspection is not available: scheduler not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	count := defaultUpcomingRuns
+	if value := r.URL.Query().Get("count"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxUpcomingRuns {
+			http.Error(w, "count must be a number between 1 and "+strconv.Itoa(maxUpcomingRuns), http.StatusBadRequest)
+			return
+		}
+		count = n
+	}
+
+	backupTypes := h.scheduler.ScheduledTypes()
+	if backupType := r.URL.Query().Get("backupType"); backupType != "" {
+		found := false
+		for _, scheduled := range backupTypes {
+			found = found || scheduled == backupType
+		}
+		if !found {
+			http.Error(w, "No active schedule for backup type "+backupType, http.StatusNotFound)
+			return
+		}
+		backupTypes = []string{backupType}
+	}
+
+	states, err := h.scheduler.RunStates()
+	if err != nil {
+		http.Error(w, "Failed to retrieve run history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	stateByType := make(map[string]*dbmeta.ScheduleRunState, len(states))
+	for i := range states {
+		stateByType[states[i].BackupType] = &states[i]
+	}
+
+	now := time.Now()
+	response := make([]upcomingScheduleResponse, 0, len(backupTypes))
+	for _, backupType := range backupTypes {
+		runs, err := h.scheduler.UpcomingRuns(backupType, now, count)
+		if err != nil {
+			// The schedule was removed by a concurrent reload
+			continue
+		}
+
+		typeConfig := config.CFG.BackupTypes[backupType]
+		upcoming := upcomingScheduleResponse{
+			BackupType:     backupType,
+			CronExpression: typeConfig.Schedule,
+			Timezone:       h.scheduler.Location(backupType).String(),
+			Targets:        scheduleTargetsFromConfig(typeConfig.Targets),
+			TargetsSummary: typeConfig.Targets.Description(),
+			NextRuns:       make([]upcomingRun, 0, len(runs)),
+			LastRun:        lastRunFromState(stateByType[backupType]),
+		}
+		for _, run := range runs {
+			upcoming.NextRuns = append(upcoming.NextRuns, upcomingRun{
+				Time:     run.Time,
+				Blackout: run.Blackout,
+				Action:   run.Action,
+			})
+		}
+		response = append(response, upcoming)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// lastRunFromState converts a schedule's run state, returning nil when the
+// schedule has never fired
+func lastRunFromState(state *dbmeta.ScheduleRunState) *lastRunResponse {
+	if state == nil || (state.LastScheduledAt == nil && state.MissedRuns == 0) {
+		return nil
+	}
+
+	last := &lastRunResponse{
+		Status:      state.LastStatus,
+		ScheduledAt: state.LastScheduledAt,
+		StartedAt:   state.LastStartedAt,
+		CompletedAt: state.LastCompletedAt,
+		Error:       state.LastError,
+		MissedRuns:  state.MissedRuns,
+	}
+	if duration := scheduler.LastRunDuration(state); duration > 0 {
+		last.Duration = duration.Round(time.Second).String()
+		last.DurationSeconds = duration.Seconds()
+	}
+	return last
+}
+
 // reloadSchedulesFromDatabase reloads schedule configurations from the database
 func (h *ScheduleHandler) reloadSchedulesFromDatabase() {
 	if metadata.DB == nil {
@@ -326,50 +625,22 @@ func (h *ScheduleHandler) reloadSchedulesFromDatabase() {
 
 	// Convert database schedules to config format
 	backupTypes := make(map[string]config.BackupTypeConfig)
+	controls := make(map[string]scheduler.Control)
 
 	for _, schedule := range schedules {
 		if !schedule.Enabled {
 			continue // Skip disabled schedules
 		}
 
-		// Create backup type config
-		backupType := config.BackupTypeConfig{
-			Schedule: schedule.CronExpression,
-			Local: config.LocalBackupConfig{
-				Enabled: false,
-				Retention: config.RetentionRule{
-					Duration: "24h",
-					Forever:  false,
-				},
-			},
-			S3: config.S3BackupConfig{
-				Enabled: false,
-				Retention: config.RetentionRule{
-					Duration: "24h",
-					Forever:  false,
-				},
-			},
-		}
-
-		// Process retention policies
-		for _, policy := range schedule.RetentionPolicies {
-			if policy.StorageType == "local" {
-				backupType.Local.Enabled = true
-				backupType.Local.Retention.Duration = policy.Duration
-				backupType.Local.Retention.Forever = policy.KeepForever
-			} else if policy.StorageType == "s3" {
-				backupType.S3.Enabled = true
-				backupType.S3.Retention.Duration = policy.Duration
-				backupType.S3.Retention.Forever = policy.KeepForever
-			}
-		}
-
-		// Add to map
-		backupTypes[schedule.BackupType] = backupType
+		backupTypes[schedule.BackupType] = schedule.BackupTypeConfig()
+		controls[schedule.BackupType] = scheduler.ControlFromSchedule(&schedule)
 	}
 
 	// Critical section: update the global configuration
 	config.CFG.BackupTypes = backupTypes
+	if h.scheduler != nil {
+		h.scheduler.ReplaceControls(controls)
+	}
 
 	// Reload the scheduler with new configuration
 	if h.scheduler != nil {
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/scheduler"
+)
+
+// scheduleControlResponse reports whether a schedule is paused or will skip
+// its next run
+type scheduleControlResponse struct {
+	Paused      bool       `json:"paused"`
+	PausedAt    *time.Time `json:"pausedAt,omitempty"`
+	PausedUntil *time.Time `json:"pausedUntil,omitempty"`
+	PauseReason string     `json:"pauseReason,omitempty"`
+	SkipNextAt  *time.Time `json:"skipNextAt,omitempty"`
+}
+
+// scheduleControlFromSchedule reports the controls in effect at now, leaving
+// out pauses that have ended and skipped runs that have passed
+func scheduleControlFromSchedule(schedule *dbmeta.BackupSchedule, now time.Time) scheduleControlResponse {
+	var resp scheduleControlResponse
+	if scheduler.ControlFromSchedule(schedule).IsPaused(now) {
+		resp.Paused = true
+		resp.PausedAt = schedule.PausedAt
+		resp.PausedUntil = schedule.PausedUntil
+		resp.PauseReason = schedule.PauseReason
+	}
+	if schedule.SkipNextAt != nil && schedule.SkipNextAt.After(now) {
+		resp.SkipNextAt = schedule.SkipNextAt
+	}
+	return resp
+}
+
+// pauseRequest is the request structure for pausing a schedule
+type pauseRequest struct {
+	Until    *time.Time `json:"until,omitempty"`    // Resume automatically at this time
+	Duration string     `json:"duration,omitempty"` // Or after this long
+	Reason   string     `json:"reason,omitempty"`
+}
+
+// handlePauseSchedule pauses a schedule, optionally until a given time
+func (h *ScheduleHandler) handlePauseSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.controlledSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	var req pauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Reason) > 255 {
+		http.Error(w, "Reason must be at most 255 characters", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	until := req.Until
+	if req.Duration != "" {
+		if until != nil {
+			http.Error(w, "Set either until or duration, not both", http.StatusBadRequest)
+			return
+		}
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			http.Error(w, "Invalid duration: "+req.Duration, http.StatusBadRequest)
+			return
+		}
+		resumeAt := now.Add(d)
+		until = &resumeAt
+	}
+	if until != nil && !until.After(now) {
+		http.Error(w, "Pause must end in the future", http.StatusBadRequest)
+		return
+	}
+
+	schedule.Paused = true
+	schedule.PausedAt = &now
+	schedule.PausedUntil = until
+	schedule.PauseReason = req.Reason
+	h.saveControl(w, schedule)
+}
+
+// handleResumeSchedule ends a pause
+func (h *ScheduleHandler) handleResumeSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.controlledSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	schedule.Paused = false
+	schedule.PausedAt = nil
+	schedule.PausedUntil = nil
+	schedule.PauseReason = ""
+	h.saveControl(w, schedule)
+}
+
+// handleSkipNext skips the next run of a schedule, or with cancel=true runs it
+// after all
+func (h *ScheduleHandler) handleSkipNext(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.controlledSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Get("cancel") == "true" {
+		schedule.SkipNextAt = nil
+		h.saveControl(w, schedule)
+		return
+	}
+
+	if h.scheduler == nil {
+		http.Error(w, "Skipping runs is not available: scheduler not running", http.StatusServiceUnavailable)
+		return
+	}
+	if !schedule.Enabled {
+		http.Error(w, "Schedule is disabled", http.StatusConflict)
+		return
+	}
+	next, err := h.scheduler.SkipNext(schedule.BackupType)
+	if err != nil {
+		http.Error(w, "Failed to find the next run: "+err.Error(), http.StatusConflict)
+		return
+	}
+
+	schedule.SkipNextAt = &next
+	h.saveControl(w, schedule)
+}
+
+// controlledSchedule loads the schedule named by the id query parameter of a
+// control request, writing an error response when it cannot
+func (h *ScheduleHandler) controlledSchedule(w http.ResponseWriter, r *http.Request) (*dbmeta.BackupSchedule, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	scheduleID := r.URL.Query().Get("id")
+	if scheduleID == "" {
+		http.Error(w, "Schedule ID is required", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if h.scheduleRepo == nil {
+		http.Error(w, "Schedule management is not available: database not initialized", http.StatusServiceUnavailable)
+		return nil, false
+	}
+
+	schedule, err := h.scheduleRepo.GetScheduleByID(scheduleID)
+	if err != nil {
+		http.Error(w, "Schedule not found: "+err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	return schedule, true
+}
+
+// saveControl stores a schedule's controls, applies them to the running
+// scheduler and responds with the schedule
+func (h *ScheduleHandler) saveControl(w http.ResponseWriter, schedule *dbmeta.BackupSchedule) {
+	if err := h.scheduleRepo.UpdateScheduleControl(schedule); err != nil {
+		http.Error(w, "Failed to save schedule: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if h.scheduler != nil && schedule.Enabled {
+		h.scheduler.SetControl(schedule.BackupType, scheduler.ControlFromSchedule(schedule))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(convertScheduleToResponse(schedule))
+}
@@ -6,8 +6,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/supporttools/GoSQLGuard/pkg/config"
 	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/scheduler"
 )
 
 // TestScheduleHandler_RequestValidation tests request validation for schedule endpoints
@@ -233,3 +236,210 @@ func TestScheduleHandler_JSONParsing(t *testing.T) {
 		t.Errorf("Expected status %v for invalid JSON, got %v", http.StatusServiceUnavailable, status)
 	}
 }
+
+// TestScheduleRetentionCounts tests that count-based retention settings round-trip
+func TestScheduleRetentionCounts(t *testing.T) {
+	body := `{
+		"name": "daily",
+		"backupType": "daily",
+		"cronExpression": "0 2 * * *",
+		"localStorage": {"enabled": true, "keepLast": 3, "keepDaily": 7, "keepWeekly": 4, "minKeep": 2}
+	}`
+
+	var req scheduleRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("Failed to parse request: %v", err)
+	}
+
+	policy := req.LocalStorage.toPolicy("schedule-id", "local")
+	if policy.KeepLast != 3 || policy.KeepDaily != 7 || policy.KeepWeekly != 4 || policy.MinKeep != 2 {
+		t.Errorf("Unexpected policy counts: %+v", policy)
+	}
+
+	// Counts without a duration are a valid rule
+	if err := policy.RetentionRule().Validate(); err != nil {
+		t.Errorf("Expected valid retention rule, got %v", err)
+	}
+
+	response := convertScheduleToResponse(&dbmeta.BackupSchedule{
+		ID:                "schedule-id",
+		RetentionPolicies: []dbmeta.ScheduleRetentionPolicy{policy},
+	})
+
+	data, err := json.Marshal(response.LocalStorage)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if decoded["keepDaily"] != float64(7) || decoded["minKeep"] != float64(2) {
+		t.Errorf("Expected counts in response, got %s", data)
+	}
+	if _, ok := decoded["keepYearly"]; ok {
+		t.Errorf("Expected unset counts to be omitted, got %s", data)
+	}
+
+	// Negative counts are rejected
+	policy.KeepDaily = -1
+	if err := policy.RetentionRule().Validate(); err == nil {
+		t.Errorf("Expected negative count to be rejected")
+	}
+}
+
+// TestScheduleTargets tests that target selectors round-trip through storage
+func TestScheduleTargets(t *testing.T) {
+	body := `{
+		"name": "hot-orders",
+		"backupType": "hourly",
+		"cronExpression": "0 * * * *",
+		"targets": {"servers": ["primary"], "databases": ["orders_*"], "labels": {"tier": "hot"}}
+	}`
+
+	var req scheduleRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("Failed to parse request: %v", err)
+	}
+
+	schedule := dbmeta.BackupSchedule{ID: "schedule-id"}
+	schedule.SetTargets(req.Targets.toConfig())
+	if schedule.TargetDatabases != "orders_*" || schedule.TargetLabels != "tier=hot" {
+		t.Errorf("Unexpected stored targets: %+v", schedule)
+	}
+
+	response := convertScheduleToResponse(&schedule)
+	if len(response.Targets.Servers) != 1 || response.Targets.Servers[0] != "primary" ||
+		response.Targets.Labels["tier"] != "hot" {
+		t.Errorf("Unexpected response targets: %+v", response.Targets)
+	}
+	if response.TargetsSummary != "servers primary; labels tier=hot; databases orders_*" {
+		t.Errorf("Unexpected targets summary: %q", response.TargetsSummary)
+	}
+
+	// Schedules without targets cover everything
+	empty := convertScheduleToResponse(&dbmeta.BackupSchedule{ID: "all"})
+	if empty.TargetsSummary != "All servers and databases" {
+		t.Errorf("Unexpected summary for empty targets: %q", empty.TargetsSummary)
+	}
+
+	// Malformed patterns are rejected
+	req.Targets.Databases = []string{"orders_["}
+	if err := req.Targets.toConfig().Validate(); err == nil {
+		t.Errorf("Expected invalid pattern to be rejected")
+	}
+}
+
+func TestUpcomingSchedules(t *testing.T) {
+	savedTypes, savedScheduler := config.CFG.BackupTypes, config.CFG.Scheduler
+	defer func() { config.CFG.BackupTypes, config.CFG.Scheduler = savedTypes, savedScheduler }()
+
+	config.CFG.Scheduler.Timezone = "UTC"
+	config.CFG.BackupTypes = map[string]config.BackupTypeConfig{
+		"hourly": {Schedule: "0 * * * *", Targets: config.BackupTargets{Databases: []string{"orders"}}},
+		"daily":  {Schedule: "0 2 * * *"},
+	}
+	sched, err := scheduler.NewScheduler(nil)
+	if err != nil {
+		t.Fatalf("NewScheduler() = %v", err)
+	}
+	if err := sched.SetupJobs(); err != nil {
+		t.Fatalf("SetupJobs() = %v", err)
+	}
+	handler := &ScheduleHandler{scheduler: sched}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/schedules/upcoming?count=3", nil)
+	rr := httptest.NewRecorder()
+	handler.handleUpcoming(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response []upcomingScheduleResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(response) != 2 || response[0].BackupType != "daily" || response[1].BackupType != "hourly" {
+		t.Fatalf("Unexpected schedules: %+v", response)
+	}
+
+	daily, hourly := response[0], response[1]
+	if len(daily.NextRuns) != 3 || daily.Timezone != "UTC" {
+		t.Fatalf("Unexpected daily runs: %+v", daily)
+	}
+	for i, run := range daily.NextRuns {
+		if run.Time.UTC().Hour() != 2 {
+			t.Errorf("Daily run %d at %s, expected 02:00 UTC", i, run.Time)
+		}
+		if i > 0 && run.Time.Sub(daily.NextRuns[i-1].Time) != 24*time.Hour {
+			t.Errorf("Daily runs %d and %d are not a day apart", i-1, i)
+		}
+	}
+	if hourly.TargetsSummary != "databases orders" || hourly.LastRun != nil {
+		t.Errorf("Unexpected hourly schedule: %+v", hourly)
+	}
+
+	for query, status := range map[string]int{
+		"?count=0":           http.StatusBadRequest,
+		"?count=many":        http.StatusBadRequest,
+		"?backupType=weekly": http.StatusNotFound,
+		"?backupType=hourly": http.StatusOK,
+	} {
+		rr := httptest.NewRecorder()
+		handler.handleUpcoming(rr, httptest.NewRequest(http.MethodGet, "/api/schedules/upcoming"+query, nil))
+		if rr.Code != status {
+			t.Errorf("%s: expected status %d, got %d", query, status, rr.Code)
+		}
+	}
+
+	// Without a scheduler there is nothing to introspect
+	rr = httptest.NewRecorder()
+	(&ScheduleHandler{}).handleUpcoming(rr, httptest.NewRequest(http.MethodGet, "/api/schedules/upcoming", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 without a scheduler, got %d", rr.Code)
+	}
+}
+
+func TestScheduleControl(t *testing.T) {
+	mux := http.NewServeMux()
+	(&ScheduleHandler{}).RegisterRoutes(mux)
+
+	for _, tc := range []struct {
+		method, endpoint string
+		status           int
+	}{
+		{http.MethodGet, "/api/schedules/pause?id=123", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/schedules/pause", http.StatusBadRequest},
+		{http.MethodPost, "/api/schedules/pause?id=123", http.StatusServiceUnavailable},
+		{http.MethodPost, "/api/schedules/resume?id=123", http.StatusServiceUnavailable},
+		{http.MethodPost, "/api/schedules/skip-next", http.StatusBadRequest},
+	} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.endpoint, nil))
+		if rr.Code != tc.status {
+			t.Errorf("%s %s: expected status %d, got %d", tc.method, tc.endpoint, tc.status, rr.Code)
+		}
+	}
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	pausedAt, until, skip := now.Add(-time.Hour), now.Add(time.Hour), now.Add(30*time.Minute)
+	schedule := dbmeta.BackupSchedule{
+		Paused:      true,
+		PausedAt:    &pausedAt,
+		PausedUntil: &until,
+		PauseReason: "storage maintenance",
+		SkipNextAt:  &skip,
+	}
+
+	control := scheduleControlFromSchedule(&schedule, now)
+	if !control.Paused || control.PauseReason != "storage maintenance" || control.SkipNextAt == nil {
+		t.Errorf("Unexpected control: %+v", control)
+	}
+
+	// Ended pauses and passed skips are not reported
+	control = scheduleControlFromSchedule(&schedule, now.Add(2*time.Hour))
+	if control.Paused || control.PausedUntil != nil || control.SkipNextAt != nil {
+		t.Errorf("Expected no control in effect, got %+v", control)
+	}
+}
@@ -1,15 +1,18 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/supporttools/GoSQLGuard/pkg/config"
+	"github.com/supporttools/GoSQLGuard/pkg/database/common"
 	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata"
 
@@ -98,45 +101,77 @@ func (h *ServerHandler) getServers(w http.ResponseWriter, r *http.Request) {
 
 // serverRequest is the request structure for creating/updating a server
 type serverRequest struct {
-	ID               string   `json:"id,omitempty"`
-	Name             string   `json:"name"`
-	Type             string   `json:"type"`
-	Host             string   `json:"host"`
-	Port             string   `json:"port"`
-	Username         string   `json:"username"`
-	Password         string   `json:"password"`
-	AuthPlugin       string   `json:"authPlugin,omitempty"`
-	IncludeDatabases []string `json:"includeDatabases,omitempty"`
-	ExcludeDatabases []string `json:"excludeDatabases,omitempty"`
+	ID               string                     `json:"id,omitempty"`
+	Name             string                     `json:"name"`
+	Type             string                     `json:"type"`
+	Host             string                     `json:"host"`
+	Port             string                     `json:"port"`
+	Username         string                     `json:"username"`
+	Password         string                     `json:"password"`
+	AuthPlugin       string                     `json:"authPlugin,omitempty"`
+	IncludeDatabases []string                   `json:"includeDatabases,omitempty"`
+	ExcludeDatabases []string                   `json:"excludeDatabases,omitempty"`
+	Labels           map[string]string          `json:"labels,omitempty"`
+	DatabaseLabels   []config.DatabaseLabelRule `json:"databaseLabels,omitempty"`
+	TLS              serverTLS                  `json:"tls,omitempty"`
+}
+
+// serverTLS is the TLS portion of server requests and responses
+type serverTLS struct {
+	Mode     string `json:"mode,omitempty"`
+	CAFile   string `json:"caFile,omitempty"`
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+}
+
+// toConfig converts the request TLS settings to the configuration type
+func (t serverTLS) toConfig() config.DatabaseTLSConfig {
+	return config.DatabaseTLSConfig{
+		Mode:     t.Mode,
+		CAFile:   t.CAFile,
+		CertFile: t.CertFile,
+		KeyFile:  t.KeyFile,
+	}
 }
 
 // serverResponse is the response structure for server information
 type serverResponse struct {
-	ID               string    `json:"id"`
-	Name             string    `json:"name"`
-	Type             string    `json:"type"`
-	Host             string    `json:"host"`
-	Port             string    `json:"port"`
-	Username         string    `json:"username"`
-	AuthPlugin       string    `json:"authPlugin,omitempty"`
-	IncludeDatabases []string  `json:"includeDatabases,omitempty"`
-	ExcludeDatabases []string  `json:"excludeDatabases,omitempty"`
-	CreatedAt        time.Time `json:"createdAt"`
-	UpdatedAt        time.Time `json:"updatedAt"`
+	ID               string                     `json:"id"`
+	Name             string                     `json:"name"`
+	Type             string                     `json:"type"`
+	Host             string                     `json:"host"`
+	Port             string                     `json:"port"`
+	Username         string                     `json:"username"`
+	AuthPlugin       string                     `json:"authPlugin,omitempty"`
+	IncludeDatabases []string                   `json:"includeDatabases,omitempty"`
+	ExcludeDatabases []string                   `json:"excludeDatabases,omitempty"`
+	Labels           map[string]string          `json:"labels,omitempty"`
+	DatabaseLabels   []config.DatabaseLabelRule `json:"databaseLabels,omitempty"`
+	TLS              serverTLS                  `json:"tls"`
+	CreatedAt        time.Time                  `json:"createdAt"`
+	UpdatedAt        time.Time                  `json:"updatedAt"`
 }
 
 // convertServerToResponse converts a ServerConfig to a serverResponse
 func convertServerToResponse(server *dbmeta.ServerConfig) serverResponse {
 	resp := serverResponse{
-		ID:         server.ID,
-		Name:       server.Name,
-		Type:       server.Type,
-		Host:       server.Host,
-		Port:       server.Port,
-		Username:   server.Username,
-		AuthPlugin: server.AuthPlugin,
-		CreatedAt:  server.CreatedAt,
-		UpdatedAt:  server.UpdatedAt,
+		ID:             server.ID,
+		Name:           server.Name,
+		Type:           server.Type,
+		Host:           server.Host,
+		Port:           server.Port,
+		Username:       server.Username,
+		AuthPlugin:     server.AuthPlugin,
+		Labels:         server.LabelMap(),
+		DatabaseLabels: server.DatabaseLabelRules(),
+		TLS: serverTLS{
+			Mode:     server.TLSMode,
+			CAFile:   server.TLSCAFile,
+			CertFile: server.TLSCertFile,
+			KeyFile:  server.TLSKeyFile,
+		},
+		CreatedAt: server.CreatedAt,
+		UpdatedAt: server.UpdatedAt,
 	}
 
 	// Process include/exclude databases
@@ -166,15 +201,41 @@ func (h *ServerHandler) createOrUpdateServer(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// Validate TLS settings
+	if err := req.TLS.toConfig().Validate(); err != nil {
+		http.Error(w, "Invalid TLS settings: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := config.ValidateLabels(req.Labels); err != nil {
+		http.Error(w, "Invalid labels: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, rule := range req.DatabaseLabels {
+		if err := rule.Validate(); err != nil {
+			http.Error(w, "Invalid database labels: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Create the server config
 	server := dbmeta.ServerConfig{
-		Name:       req.Name,
-		Type:       req.Type,
-		Host:       req.Host,
-		Port:       req.Port,
-		Username:   req.Username,
-		Password:   req.Password,
-		AuthPlugin: req.AuthPlugin,
+		Name:        req.Name,
+		Type:        req.Type,
+		Host:        req.Host,
+		Port:        req.Port,
+		Username:    req.Username,
+		Password:    req.Password,
+		AuthPlugin:  req.AuthPlugin,
+		TLSMode:     req.TLS.Mode,
+		TLSCAFile:   req.TLS.CAFile,
+		TLSCertFile: req.TLS.CertFile,
+		TLSKeyFile:  req.TLS.KeyFile,
+		Labels:      config.FormatLabels(req.Labels),
+	}
+	if err := server.SetDatabaseLabelRules(req.DatabaseLabels); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Handle update vs create
@@ -384,13 +445,21 @@ func reloadConfigurationFromDatabase() {
 	var dbServers []config.DatabaseServerConfig
 	for _, server := range servers {
 		dbServer := config.DatabaseServerConfig{
-			Name:       server.Name,
-			Type:       server.Type,
-			Host:       server.Host,
-			Port:       server.Port,
-			Username:   server.Username,
-			Password:   server.Password,
-			AuthPlugin: server.AuthPlugin,
+			Name:           server.Name,
+			Type:           server.Type,
+			Host:           server.Host,
+			Port:           server.Port,
+			Username:       server.Username,
+			Password:       server.Password,
+			AuthPlugin:     server.AuthPlugin,
+			Labels:         server.LabelMap(),
+			DatabaseLabels: server.DatabaseLabelRules(),
+			TLS: config.DatabaseTLSConfig{
+				Mode:     server.TLSMode,
+				CAFile:   server.TLSCAFile,
+				CertFile: server.TLSCertFile,
+				KeyFile:  server.TLSKeyFile,
+			},
 		}
 
 		// Process include/exclude databases
@@ -423,9 +492,25 @@ func testMySQLConnection(req serverRequest) ([]string, error) {
 	// Build connection string
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/", req.Username, req.Password, req.Host, port)
 
+	// Collect connection parameters
+	var params []string
+
 	// Add auth plugin if specified
 	if req.AuthPlugin != "" {
-		dsn += fmt.Sprintf("?auth=%s", req.AuthPlugin)
+		params = append(params, fmt.Sprintf("auth=%s", req.AuthPlugin))
+	}
+
+	// Enable TLS if configured
+	tlsParams, err := common.MySQLTLSParams(req.TLS.toConfig(), req.Host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS settings: %w", err)
+	}
+	if tlsParams != "" {
+		params = append(params, tlsParams)
+	}
+
+	if len(params) > 0 {
+		dsn += "?" + strings.Join(params, "&")
 	}
 
 	// Open connection
@@ -471,23 +556,23 @@ func testPostgreSQLConnection(req serverRequest) ([]string, error) {
 		port = "5432"
 	}
 
+	// Validate TLS settings before building the connection string
+	tlsConfig := req.TLS.toConfig()
+	if err := tlsConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid TLS settings: %w", err)
+	}
+
 	// Build connection string
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s sslmode=disable",
+	conninfo := fmt.Sprintf("host=%s port=%s user=%s password=%s",
 		req.Host, port, req.Username, req.Password)
 
-	// Open connection
-	db, err := sql.Open("postgres", dsn)
+	// Open and test the connection
+	db, err := common.OpenPostgreSQL(context.Background(), conninfo, tlsConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create connection: %w", err)
+		return nil, err
 	}
 	defer db.Close()
 
-	// Test connection
-	err = db.Ping()
-	if err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
-	}
-
 	// Get list of databases
 	query := `SELECT datname FROM pg_database 
 		WHERE datistemplate = false 
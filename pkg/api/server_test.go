@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -208,3 +209,52 @@ func TestServerHandler_TestConnection_InvalidJSON(t *testing.T) {
 		t.Errorf("Expected 400 for invalid JSON: got %v", status)
 	}
 }
+
+func TestServerHandler_TestConnection_InvalidTLS(t *testing.T) {
+	handler := &ServerHandler{}
+
+	tests := []struct {
+		name string
+		tls  serverTLS
+	}{
+		{name: "Unknown mode", tls: serverTLS{Mode: "sometimes"}},
+		{name: "verify-ca without CA", tls: serverTLS{Mode: "verify-ca"}},
+		{name: "Certificate without key", tls: serverTLS{Mode: "require", CertFile: "/tmp/client.crt"}},
+	}
+
+	for _, dbType := range []string{"mysql", "postgresql"} {
+		for _, tt := range tests {
+			t.Run(dbType+" "+tt.name, func(t *testing.T) {
+				reqBody := serverRequest{
+					Type:     dbType,
+					Host:     "localhost",
+					Username: "test",
+					Password: "test",
+					TLS:      tt.tls,
+				}
+				body, _ := json.Marshal(reqBody)
+
+				req, err := http.NewRequest("POST", "/api/servers/test", bytes.NewBuffer(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Content-Type", "application/json")
+
+				rr := httptest.NewRecorder()
+				handler.handleTestConnection(rr, req)
+
+				if status := rr.Code; status != http.StatusBadRequest {
+					t.Errorf("Expected 400 for invalid TLS settings: got %v", status)
+				}
+
+				var response map[string]interface{}
+				if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to parse response: %v", err)
+				}
+				if msg, _ := response["message"].(string); !strings.Contains(msg, "invalid TLS settings") {
+					t.Errorf("Expected TLS validation error, got %q", msg)
+				}
+			})
+		}
+	}
+}
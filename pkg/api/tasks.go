@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+)
+
+const (
+	// defaultTaskLimit is how many tasks are listed when no limit is given
+	defaultTaskLimit = 100
+
+	// maxTaskLimit bounds how many tasks one request lists
+	maxTaskLimit = 1000
+)
+
+// taskLister lists queued backup tasks
+type taskLister interface {
+	ListTasks(status string, limit int) ([]dbmeta.BackupTask, error)
+}
+
+// TaskHandler handles the backup task queue API endpoints
+type TaskHandler struct {
+	tasks taskLister
+}
+
+// NewTaskHandler creates a new task handler
+func NewTaskHandler() *TaskHandler {
+	if metadata.DB == nil {
+		log.Println("Warning: Database is not initialized, backup task API will not work")
+		return &TaskHandler{}
+	}
+
+	return &TaskHandler{tasks: dbmeta.NewTaskRepository(metadata.DB)}
+}
+
+// RegisterRoutes registers the task API routes on the provided mux
+func (h *TaskHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/tasks", h.handleTasks)
+}
+
+// taskResponse is the response structure for a backup task
+type taskResponse struct {
+	ID           uint       `json:"id"`
+	BackupType   string     `json:"backupType"`
+	ServerName   string     `json:"serverName"`
+	DatabaseName string     `json:"databaseName"`
+	Status       string     `json:"status"`
+	Attempts     int        `json:"attempts"`
+	WorkerID     string     `json:"workerId,omitempty"`
+	Deadline     *time.Time `json:"deadline,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ClaimedAt    *time.Time `json:"claimedAt,omitempty"`
+	HeartbeatAt  *time.Time `json:"heartbeatAt,omitempty"`
+	CompletedAt  *time.Time `json:"completedAt,omitempty"`
+}
+
+// handleTasks lists the most recent backup tasks, optionally filtered by status
+func (h *TaskHandler) handleTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.tasks == nil {
+		http.Error(w, "Backup task queue is not available: database not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", dbmeta.TaskStatusQueued, dbmeta.TaskStatusRunning, dbmeta.TaskStatusSuccess,
+		dbmeta.TaskStatusError, dbmeta.TaskStatusDeferred:
+	default:
+		http.Error(w, "Unknown task status: "+status, http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultTaskLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxTaskLimit {
+			http.Error(w, "limit must be a number between 1 and "+strconv.Itoa(maxTaskLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	tasks, err := h.tasks.ListTasks(status, limit)
+	if err != nil {
+		http.Error(w, "Failed to list backup tasks: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]taskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		response = append(response, taskResponse{
+			ID:           task.ID,
+			BackupType:   task.BackupType,
+			ServerName:   task.ServerName,
+			DatabaseName: task.DatabaseName,
+			Status:       task.Status,
+			Attempts:     task.Attempts,
+			WorkerID:     task.WorkerID,
+			Deadline:     task.Deadline,
+			Error:        task.Error,
+			CreatedAt:    task.CreatedAt,
+			ClaimedAt:    task.ClaimedAt,
+			HeartbeatAt:  task.HeartbeatAt,
+			CompletedAt:  task.CompletedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
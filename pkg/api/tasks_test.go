@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+)
+
+// fakeTaskLister records the filter it was asked for
+type fakeTaskLister struct {
+	status string
+	limit  int
+}
+
+func (f *fakeTaskLister) ListTasks(status string, limit int) ([]dbmeta.BackupTask, error) {
+	f.status, f.limit = status, limit
+	return []dbmeta.BackupTask{
+		{ID: 7, BackupType: "hourly", ServerName: "eu-db1", DatabaseName: "app", Status: status, WorkerID: "worker-eu"},
+	}, nil
+}
+
+// TestTaskHandler tests listing backup tasks
+func TestTaskHandler(t *testing.T) {
+	lister := &fakeTaskLister{}
+	handler := &TaskHandler{tasks: lister}
+
+	rr := httptest.NewRecorder()
+	handler.handleTasks(rr, httptest.NewRequest("GET", "/api/tasks?status=running&limit=10", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rr.Code, rr.Body.String())
+	}
+	if lister.status != dbmeta.TaskStatusRunning || lister.limit != 10 {
+		t.Errorf("listed status %q limit %d, want running and 10", lister.status, lister.limit)
+	}
+
+	var tasks []taskResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &tasks); err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].WorkerID != "worker-eu" {
+		t.Errorf("unexpected tasks %+v", tasks)
+	}
+
+	for _, url := range []string{"/api/tasks?status=lost", "/api/tasks?limit=0"} {
+		rr = httptest.NewRecorder()
+		handler.handleTasks(rr, httptest.NewRequest("GET", url, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want 400", url, rr.Code)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	(&TaskHandler{}).handleTasks(rr, httptest.NewRequest("GET", "/api/tasks", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a database: got status %d, want 503", rr.Code)
+	}
+}
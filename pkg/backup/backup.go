@@ -13,8 +13,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql" // MySQL driver
@@ -22,7 +24,9 @@ import (
 	"github.com/supporttools/GoSQLGuard/pkg/config"
 	"github.com/supporttools/GoSQLGuard/pkg/database/common"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
 	"github.com/supporttools/GoSQLGuard/pkg/metrics"
+	"github.com/supporttools/GoSQLGuard/pkg/retention"
 	"github.com/supporttools/GoSQLGuard/pkg/storage/local"
 	"github.com/supporttools/GoSQLGuard/pkg/storage/s3"
 )
@@ -37,8 +41,58 @@ const (
 
 // Options defines options for a backup operation
 type Options struct {
-	Servers   []string // List of server names to back up, empty means all servers
-	Databases []string // List of databases to back up, empty means all databases
+	Servers          []string          // Server names or glob patterns to back up, empty means all servers
+	Databases        []string          // Database names or glob patterns to back up, empty means all databases
+	ExcludeDatabases []string          // Database names or glob patterns to skip
+	Labels           map[string]string // Database labels that must all match
+
+	// Deadline, when set, is the latest time a database backup may start.
+	// Databases not started by then are deferred to the next run.
+	Deadline time.Time
+
+	// FencingToken is recorded on each backup to show which leader ran it.
+	// Fence, when set, is checked before each database so a replica that
+	// has lost leadership stops instead of racing the new leader.
+	FencingToken int64
+	Fence        func() error
+
+	// Result, when set, is called once per database with the outcome of its
+	// backup. Deferred databases report an error wrapping ErrDeferred.
+	Result func(serverName, database string, err error)
+}
+
+// ErrDeferred is reported through Options.Result for databases postponed to the next run
+var ErrDeferred = errors.New("backup deferred")
+
+// OptionsFromTargets builds backup options from a schedule's target selectors
+func OptionsFromTargets(t config.BackupTargets) Options {
+	return Options{
+		Servers:          t.Servers,
+		Databases:        t.Databases,
+		ExcludeDatabases: t.ExcludeDatabases,
+		Labels:           t.Labels,
+	}
+}
+
+// targets returns the options as target selectors
+func (o Options) targets() config.BackupTargets {
+	return config.BackupTargets{
+		Servers:          o.Servers,
+		Databases:        o.Databases,
+		ExcludeDatabases: o.ExcludeDatabases,
+		Labels:           o.Labels,
+	}
+}
+
+// filterDatabases returns the databases of the server selected by the targets
+func filterDatabases(targets config.BackupTargets, server config.DatabaseServerConfig, databases []string) []string {
+	var selected []string
+	for _, db := range databases {
+		if targets.MatchesDatabase(server, db) {
+			selected = append(selected, db)
+		}
+	}
+	return selected
 }
 
 // Manager handles backup operations
@@ -46,6 +100,21 @@ type Manager struct {
 	cfg        *config.AppConfig
 	localStore *local.Client
 	s3Store    *s3.Client
+
+	// deferred holds databases postponed by a run window, by backup type and
+	// server, so the next run of that type starts with them
+	deferredMu sync.Mutex
+	deferred   map[string]map[string][]string
+
+	// queue, when set, receives one task per database for workers to run
+	queue TaskQueue
+
+	// life tracks the running database backups for graceful shutdown
+	life lifecycle
+
+	// lastReconcile is the report of the last storage reconciliation
+	reconcileMu   sync.Mutex
+	lastReconcile *ReconcileReport
 }
 
 // constructBackupPaths creates the paths for backup files in both by-server and by-type organizations
@@ -113,6 +182,8 @@ func NewManager() (*Manager, error) {
 		cfg: &config.CFG,
 	}
 
+	metrics.ConfigureBackupLabels(config.CFG.Metrics.BackupLabels)
+
 	// Initialize local storage client if enabled
 	if config.CFG.Local.Enabled {
 		localClient, err := local.NewClient()
@@ -146,35 +217,26 @@ func (m *Manager) PerformBackup(backupType string, options ...Options) error {
 		opts = options[0]
 	}
 
-	// Convert server list to a lookup map for faster filtering
-	serverFilter := make(map[string]bool)
-	if len(opts.Servers) > 0 {
-		for _, server := range opts.Servers {
-			serverFilter[server] = true
-		}
-		log.Printf("Filtering backup to specific servers: %v", opts.Servers)
+	if m.ShuttingDown() {
+		return ErrShuttingDown
 	}
 
-	// Convert database list to a lookup map for faster filtering
-	dbFilter := make(map[string]bool)
-	if len(opts.Databases) > 0 {
-		for _, db := range opts.Databases {
-			dbFilter[db] = true
-		}
-		log.Printf("Filtering backup to specific databases: %v", opts.Databases)
+	targets := opts.targets()
+	if !targets.IsEmpty() {
+		log.Printf("Filtering %s backup to targets: %s", backupType, targets.Description())
 	}
+
+	// Databases deferred by the previous run go first
+	carried := m.takeDeferred(backupType)
+
 	// Check if this backup type is configured
 	typeConfig, exists := m.cfg.BackupTypes[backupType]
 	if !exists {
 		return fmt.Errorf("no configuration found for backup type: %s", backupType)
 	}
 
-	// Check if this backup type is enabled for local storage
-	localBackupEnabled := m.cfg.Local.Enabled && typeConfig.Local.Enabled && m.localStore != nil
-
 	// Skip if neither local nor S3 backup is enabled for this type
-	s3BackupEnabled := m.cfg.S3.Enabled && typeConfig.S3.Enabled && m.s3Store != nil
-	if !localBackupEnabled && !s3BackupEnabled {
+	if !m.storageEnabled(typeConfig) {
 		return fmt.Errorf("backup type %s is not enabled for any storage destination", backupType)
 	}
 
@@ -183,6 +245,11 @@ func (m *Manager) PerformBackup(backupType string, options ...Options) error {
 		// Process the legacy MySQL server using default name
 		log.Println("Using legacy MySQL configuration as default server")
 
+		legacyServer := config.DatabaseServerConfig{Name: "default", Type: "mysql"}
+		if !targets.MatchesServer(legacyServer) {
+			return fmt.Errorf("no servers matched the targets of backup type %s", backupType)
+		}
+
 		// Get appropriate database provider to allow us to query the MySQL server
 		dbProvider, err := getActiveDatabaseProvider()
 		if err != nil {
@@ -238,19 +305,30 @@ func (m *Manager) PerformBackup(backupType string, options ...Options) error {
 			}
 		}
 
-		// Process each database
-		for _, database := range databases {
-			if err := m.backupDatabase("default", "mysql", database, backupType, typeConfig); err != nil {
-				log.Printf("Failed to backup database %s: %v", database, err)
-				continue
-			}
+		databases = filterDatabases(targets, legacyServer, databases)
+		if len(databases) == 0 {
+			return fmt.Errorf("no databases matched the targets of backup type %s", backupType)
 		}
 
-		return nil
+		// Process each database, starting with any deferred by the last run
+		return m.backupDatabases("default", "mysql", prioritize(databases, carried["default"]), backupType, typeConfig, opts)
 	}
 
-	// Using multi-server configuration
-	for _, server := range m.cfg.DatabaseServers {
+	// Using multi-server configuration, starting with servers that have
+	// databases deferred by the last run
+	servers := make([]config.DatabaseServerConfig, len(m.cfg.DatabaseServers))
+	copy(servers, m.cfg.DatabaseServers)
+	sort.SliceStable(servers, func(i, j int) bool {
+		return len(carried[servers[i].Name]) > 0 && len(carried[servers[j].Name]) == 0
+	})
+
+	// Servers that could not be backed up fail the run once the others are done
+	var serverErrs []error
+	matched := 0
+	for _, server := range servers {
+		if !targets.MatchesServer(server) {
+			continue
+		}
 		log.Printf("Processing server: %s (%s)", server.Name, server.Type)
 
 		// Get databases to backup for this server
@@ -277,11 +355,13 @@ func (m *Manager) PerformBackup(backupType string, options ...Options) error {
 					Port:     portNum,
 					User:     server.Username,
 					Password: server.Password,
+					TLS:      server.TLS,
 				}
 
 				// Connect to the server
 				if err := provider.Connect(context.Background()); err != nil {
 					log.Printf("Error connecting to MySQL server %s: %v", server.Name, err)
+					serverErrs = append(serverErrs, fmt.Errorf("failed to connect to server %s: %w", server.Name, err))
 					continue
 				}
 
@@ -290,6 +370,7 @@ func (m *Manager) PerformBackup(backupType string, options ...Options) error {
 				if err != nil {
 					log.Printf("Error listing databases on server %s: %v", server.Name, err)
 					provider.Close()
+					serverErrs = append(serverErrs, fmt.Errorf("failed to list databases on server %s: %w", server.Name, err))
 					continue
 				}
 
@@ -325,10 +406,12 @@ func (m *Manager) PerformBackup(backupType string, options ...Options) error {
 			case "postgresql":
 				// TODO: Add PostgreSQL database listing
 				log.Printf("PostgreSQL support not yet implemented for server %s", server.Name)
+				serverErrs = append(serverErrs, fmt.Errorf("listing databases is not supported on PostgreSQL server %s; set includeDatabases", server.Name))
 				continue
 
 			default:
 				log.Printf("Unsupported database type '%s' for server %s", server.Type, server.Name)
+				serverErrs = append(serverErrs, fmt.Errorf("unsupported database type %q for server %s", server.Type, server.Name))
 				continue
 			}
 
@@ -340,18 +423,166 @@ func (m *Manager) PerformBackup(backupType string, options ...Options) error {
 			}
 		}
 
+		databases = filterDatabases(targets, server, databases)
+		if len(databases) == 0 {
+			log.Printf("No databases on server %s matched the targets of backup type %s", server.Name, backupType)
+			continue
+		}
+		matched += len(databases)
+
 		// Process each database for this server
-		for _, database := range databases {
-			if err := m.backupDatabase(server.Name, server.Type, database, backupType, typeConfig); err != nil {
-				log.Printf("Failed to backup database %s on server %s: %v", database, server.Name, err)
-				continue
+		if err := m.backupDatabases(server.Name, server.Type, prioritize(databases, carried[server.Name]), backupType, typeConfig, opts); err != nil {
+			return err
+		}
+	}
+
+	if len(serverErrs) > 0 {
+		return errors.Join(serverErrs...)
+	}
+	if matched == 0 && !targets.IsEmpty() {
+		return fmt.Errorf("no databases matched the targets of backup type %s", backupType)
+	}
+
+	return nil
+}
+
+// backupDatabases backs up databases in order. Once the options' deadline has
+// passed, the remaining databases are deferred to the next run instead, and
+// once the fence fails the rest are left to the new leader. With a task
+// queue the databases are queued for workers instead. It returns
+// ErrShuttingDown when a shutdown interrupted or skipped any database.
+func (m *Manager) backupDatabases(serverName, serverType string, databases []string, backupType string, typeConfig config.BackupTypeConfig, opts Options) error {
+	if m.queue != nil {
+		m.enqueueDatabases(serverName, serverType, databases, backupType, opts)
+		return nil
+	}
+
+	var stopped bool
+	for _, database := range databases {
+		if m.ShuttingDown() {
+			log.Printf("Shutting down, not starting %s backup of %s on server %s", backupType, database, serverName)
+			if opts.Result != nil {
+				opts.Result(serverName, database, ErrShuttingDown)
 			}
+			stopped = true
+			continue
+		}
+
+		if !opts.Deadline.IsZero() && time.Now().After(opts.Deadline) {
+			reason := fmt.Sprintf("maximum run window ended at %s", opts.Deadline.Format(time.RFC3339))
+			m.deferDatabase(serverName, serverType, database, backupType, reason)
+			if opts.Result != nil {
+				opts.Result(serverName, database, fmt.Errorf("%w: %s", ErrDeferred, reason))
+			}
+			continue
+		}
+
+		if opts.Fence != nil {
+			if err := opts.Fence(); err != nil {
+				log.Printf("Stopping %s backup on server %s before %s: %v", backupType, serverName, database, err)
+				return nil
+			}
+		}
+
+		err := m.backupDatabase(serverName, serverType, database, backupType, typeConfig, opts.FencingToken, nil)
+		if err != nil {
+			log.Printf("Failed to backup database %s on server %s: %v", database, serverName, err)
+			stopped = stopped || errors.Is(err, ErrShuttingDown)
+		}
+		if opts.Result != nil {
+			opts.Result(serverName, database, err)
 		}
 	}
 
+	if stopped {
+		return ErrShuttingDown
+	}
 	return nil
 }
 
+// deferDatabase records a database postponed to the next run of its backup type
+func (m *Manager) deferDatabase(serverName, serverType, database, backupType, reason string) {
+	m.deferredMu.Lock()
+	if m.deferred == nil {
+		m.deferred = make(map[string]map[string][]string)
+	}
+	if m.deferred[backupType] == nil {
+		m.deferred[backupType] = make(map[string][]string)
+	}
+	m.deferred[backupType][serverName] = append(m.deferred[backupType][serverName], database)
+	m.deferredMu.Unlock()
+
+	m.recordDeferral(serverName, serverType, database, backupType, reason)
+}
+
+// recordDeferral logs and counts a postponed database backup and records it
+// in metadata
+func (m *Manager) recordDeferral(serverName, serverType, database, backupType, reason string) {
+	log.Printf("Deferring %s backup of %s on server %s: %s", backupType, database, serverName, reason)
+	metrics.ScheduleDeferrals.WithLabelValues(backupType, "run_window").Inc()
+
+	// Record the deferral so it shows up alongside the backups
+	meta := metadata.DefaultStore.CreateBackupMeta(serverName, serverType, database, backupType)
+	m.recordLabels(meta.ID, serverName, database)
+	if err := metadata.DefaultStore.UpdateBackupStatus(meta.ID, types.StatusDeferred, nil, 0, reason); err != nil {
+		log.Printf("Warning: Failed to record deferred backup in metadata: %v", err)
+	}
+}
+
+// recordLabels copies the labels of a server's database onto a new backup
+func (m *Manager) recordLabels(id, serverName, database string) {
+	var labels map[string]string
+	for _, server := range m.cfg.DatabaseServers {
+		if server.Name == serverName {
+			labels = server.LabelsFor(database)
+			break
+		}
+	}
+	if len(labels) == 0 {
+		return
+	}
+
+	if err := metadata.DefaultStore.UpdateLabels(id, labels); err != nil {
+		log.Printf("Warning: Failed to record backup labels in metadata: %v", err)
+	}
+	metrics.SetBackupLabels(serverName, database, labels)
+}
+
+// takeDeferred returns and clears the databases deferred for a backup type
+func (m *Manager) takeDeferred(backupType string) map[string][]string {
+	m.deferredMu.Lock()
+	defer m.deferredMu.Unlock()
+
+	carried := m.deferred[backupType]
+	delete(m.deferred, backupType)
+	return carried
+}
+
+// prioritize moves the databases in first to the front, keeping the order otherwise
+func prioritize(databases, first []string) []string {
+	if len(first) == 0 {
+		return databases
+	}
+
+	wanted := make(map[string]bool, len(first))
+	for _, db := range first {
+		wanted[db] = true
+	}
+
+	ordered := make([]string, 0, len(databases))
+	for _, db := range databases {
+		if wanted[db] {
+			ordered = append(ordered, db)
+		}
+	}
+	for _, db := range databases {
+		if !wanted[db] {
+			ordered = append(ordered, db)
+		}
+	}
+	return ordered
+}
+
 // createLogFile creates a log file for a backup operation
 func (m *Manager) createLogFile(id string) (string, *os.File, error) {
 	// Determine log directory
@@ -385,8 +616,17 @@ func (m *Manager) createLogFile(id string) (string, *os.File, error) {
 	return logFilePath, logFile, nil
 }
 
-// backupDatabase handles the backup process for a single database
-func (m *Manager) backupDatabase(serverName, serverType, database, backupType string, typeConfig config.BackupTypeConfig) error {
+// backupDatabase handles the backup process for a single database. started,
+// when set, is called with the ID of the backup's metadata entry.
+func (m *Manager) backupDatabase(serverName, serverType, database, backupType string, typeConfig config.BackupTypeConfig, fencingToken int64, started func(backupID string)) error {
+	// Shutdown waits for registered backups and cancels runCtx once its
+	// grace period ends
+	runCtx, ok := m.begin()
+	if !ok {
+		return ErrShuttingDown
+	}
+	defer m.end()
+
 	startTime := time.Now()
 	timestamp := startTime.Format("2006-01-02-15-04-05")
 
@@ -395,6 +635,15 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 
 	// Create metadata entry for this backup
 	meta := metadata.DefaultStore.CreateBackupMeta(serverName, serverType, database, backupType)
+	if fencingToken > 0 {
+		if err := metadata.DefaultStore.UpdateFencingToken(meta.ID, fencingToken); err != nil {
+			log.Printf("Warning: Failed to record fencing token in metadata: %v", err)
+		}
+	}
+	if started != nil {
+		started(meta.ID)
+	}
+	m.recordLabels(meta.ID, serverName, database)
 
 	// Create log file for this backup
 	logFilePath, logFile, err := m.createLogFile(meta.ID)
@@ -437,7 +686,7 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 			if logFile != nil {
 				fmt.Fprintf(logFile, "ERROR: %s\n", errMsg)
 			}
-			metrics.BackupCount.WithLabelValues(backupType, database, "error").Inc()
+			metrics.BackupCount.WithLabelValues(backupType, serverName, database, "error").Inc()
 			metadata.DefaultStore.UpdateBackupStatus(meta.ID, metadata.StatusError, map[string]string{}, 0, errMsg)
 			return fmt.Errorf("failed to create backup directory: %w", err)
 		}
@@ -457,7 +706,7 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 			if logFile != nil {
 				fmt.Fprintf(logFile, "ERROR: %s\n", errMsg)
 			}
-			metrics.BackupCount.WithLabelValues(backupType, database, "error").Inc()
+			metrics.BackupCount.WithLabelValues(backupType, serverName, database, "error").Inc()
 			metadata.DefaultStore.UpdateBackupStatus(meta.ID, metadata.StatusError, map[string]string{}, 0, errMsg)
 			return fmt.Errorf("failed to create temp directory: %w", err)
 		}
@@ -516,6 +765,7 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 				Port:     portNum,
 				User:     serverConfig.Username,
 				Password: serverConfig.Password,
+				TLS:      serverConfig.TLS,
 			}
 			provider = mysqlProvider
 		}
@@ -526,7 +776,7 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 		if logFile != nil {
 			fmt.Fprintf(logFile, "ERROR: %s\n", errMsg)
 		}
-		metrics.BackupCount.WithLabelValues(backupType, database, "error").Inc()
+		metrics.BackupCount.WithLabelValues(backupType, serverName, database, "error").Inc()
 		metadata.DefaultStore.UpdateBackupStatus(meta.ID, metadata.StatusError, map[string]string{}, 0, errMsg)
 		return errors.New(errMsg)
 
@@ -535,13 +785,13 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 		if logFile != nil {
 			fmt.Fprintf(logFile, "ERROR: %s\n", errMsg)
 		}
-		metrics.BackupCount.WithLabelValues(backupType, database, "error").Inc()
+		metrics.BackupCount.WithLabelValues(backupType, serverName, database, "error").Inc()
 		metadata.DefaultStore.UpdateBackupStatus(meta.ID, metadata.StatusError, map[string]string{}, 0, errMsg)
 		return fmt.Errorf("unsupported database type: %s", serverType)
 	}
 
 	// Create context with backup type
-	ctx := context.WithValue(context.Background(), backupTypeKey, backupType)
+	ctx := context.WithValue(runCtx, backupTypeKey, backupType)
 
 	// Define backup options
 	backupOpts := common.BackupOptions{
@@ -550,6 +800,9 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 		SchemaOnly:      false,
 	}
 
+	// Record what is about to run for the manifests stored with the backup
+	manifest := describeBackup(ctx, provider, database, backupOpts)
+
 	// Log the command that will be executed
 	if logFile != nil {
 		// Create a sanitized version of the command for logging
@@ -563,6 +816,7 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 			// For MySQL, construct a simplified representation of the command
 			host := ""
 			port := ""
+			var tlsConfig config.DatabaseTLSConfig
 
 			// Get the correct host/port based on server configuration
 			if serverName == "default" && m.cfg.MySQL.Host != "" {
@@ -574,13 +828,15 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 					if s.Name == serverName {
 						host = s.Host
 						port = s.Port
+						tlsConfig = s.TLS
 						break
 					}
 				}
 			}
 
 			// Build a clean description of the command
-			maskedCmd = fmt.Sprintf("mysqldump -h %s -P %s -u <user> -p<masked> %s",
+			// The password is supplied through a private option file and never logged
+			maskedCmd = fmt.Sprintf("mysqldump --defaults-extra-file=<private-option-file> -h %s -P %s -u <user> %s",
 				host, port, database)
 
 			// Add the key flags being used
@@ -594,6 +850,9 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 			flags = append(flags, "--events")
 			flags = append(flags, "--set-gtid-purged=OFF")
 
+			// Add TLS flags for this server
+			flags = append(flags, common.MySQLDumpTLSArgs(tlsConfig)...)
+
 			// Add the flags to the command description
 			if len(flags) > 0 {
 				maskedCmd += " " + strings.Join(flags, " ")
@@ -624,7 +883,7 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 		if logFile != nil {
 			fmt.Fprintf(logFile, "ERROR: %s\n", errMsg)
 		}
-		metrics.BackupCount.WithLabelValues(backupType, database, "error").Inc()
+		metrics.BackupCount.WithLabelValues(backupType, serverName, database, "error").Inc()
 		metadata.DefaultStore.UpdateBackupStatus(meta.ID, metadata.StatusError, map[string]string{}, 0, errMsg)
 		return fmt.Errorf("failed to create backup file: %w", err)
 	}
@@ -649,6 +908,11 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 		fmt.Fprintf(logFile, "Command stderr output:\n%s\n", stderr.String())
 	}
 
+	if err != nil && runCtx.Err() != nil {
+		metrics.BackupCount.WithLabelValues(backupType, serverName, database, "interrupted").Inc()
+		return m.interrupted(meta.ID, logFile, primaryBackupPath)
+	}
+
 	if err != nil {
 		// Get error details
 		errOutput := stderr.String()
@@ -663,7 +927,7 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 			fmt.Fprintf(logFile, "ERROR: Backup failed: %s\n", errMsg)
 		}
 
-		metrics.BackupCount.WithLabelValues(backupType, database, "error").Inc()
+		metrics.BackupCount.WithLabelValues(backupType, serverName, database, "error").Inc()
 		metadata.DefaultStore.UpdateBackupStatus(meta.ID, metadata.StatusError, map[string]string{}, 0, errMsg)
 		return fmt.Errorf("database backup failed: %w", err)
 	}
@@ -672,6 +936,20 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 		fmt.Fprintf(logFile, "Backup command completed successfully\n")
 	}
 
+	// Close the dump before it is copied, checksummed or uploaded
+	if err := gzipWriter.Close(); err != nil {
+		log.Printf("Warning: Failed to finish compressed backup: %v", err)
+	}
+	if err := outputFile.Close(); err != nil {
+		log.Printf("Warning: Failed to close backup file: %v", err)
+	}
+
+	if checksum, err := fileChecksum(primaryBackupPath); err != nil {
+		log.Printf("Warning: Failed to checksum backup for manifest: %v", err)
+	} else {
+		manifest.Checksum = checksum
+	}
+
 	// If using local backup with multiple paths, copy the file to all paths
 	if localBackupEnabled && len(localPaths) > 1 {
 		// We already created the primary file (by-server), now copy to by-type
@@ -708,11 +986,11 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 
 	// Record backup duration
 	duration := time.Since(startTime)
-	metrics.BackupDuration.WithLabelValues(backupType, database).Observe(duration.Seconds())
+	metrics.BackupDuration.WithLabelValues(backupType, serverName, database).Observe(duration.Seconds())
 
 	// Record local storage metrics
 	if localBackupEnabled {
-		if err := m.localStore.RecordBackupMetrics(primaryBackupPath, backupType, database); err != nil {
+		if err := m.localStore.RecordBackupMetrics(primaryBackupPath, serverName, backupType, database); err != nil {
 			log.Printf("Warning: Failed to record local backup metrics: %v", err)
 		}
 	}
@@ -733,10 +1011,11 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 	metadata.DefaultStore.UpdateBackupStatus(meta.ID, metadata.StatusSuccess, localPaths, fileSize, "")
 
 	// Record success in metrics
-	metrics.BackupCount.WithLabelValues(backupType, database, "success").Inc()
-	metrics.LastBackupTimestamp.WithLabelValues(backupType, database).Set(float64(time.Now().Unix()))
+	metrics.BackupCount.WithLabelValues(backupType, serverName, database, "success").Inc()
+	metrics.LastBackupTimestamp.WithLabelValues(backupType, serverName, database).Set(float64(time.Now().Unix()))
 
 	// Upload to S3 if enabled for this backup type
+	var manifestKeys map[string]string
 	s3BackupEnabled := m.cfg.S3.Enabled && typeConfig.S3.Enabled && m.s3Store != nil
 	if s3BackupEnabled {
 		// For S3 upload, we need to handle each path separately
@@ -744,19 +1023,34 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 		uploadErrors := make([]string, 0)
 
 		// Upload the file to all S3 paths
+		uploaded := make([]string, 0, len(s3Keys))
 		for organization, s3Key := range s3Keys {
-			if err := m.s3Store.UploadBackupWithKey(primaryBackupPath, s3Key); err != nil {
+			if runCtx.Err() != nil {
+				break
+			}
+			if err := m.s3Store.UploadBackupWithKey(runCtx, primaryBackupPath, s3Key); err != nil {
 				log.Printf("Failed to upload backup to S3 (%s path): %v", organization, err)
 				uploadErrors = append(uploadErrors, fmt.Sprintf("%s path: %v", organization, err))
 				s3UploadSuccessful = false
-			} else if logFile != nil {
-				fmt.Fprintf(logFile, "Successfully uploaded backup to S3 (%s path): %s\n", organization, s3Key)
+			} else {
+				uploaded = append(uploaded, s3Key)
+				if logFile != nil {
+					fmt.Fprintf(logFile, "Successfully uploaded backup to S3 (%s path): %s\n", organization, s3Key)
+				}
 			}
 		}
 
+		// A cancelled upload leaves no object behind, but copies finished
+		// before the cancellation are removed so S3 holds all or none
+		if runCtx.Err() != nil {
+			return m.interruptedUpload(meta.ID, logFile, uploaded, localBackupEnabled)
+		}
+
 		// Update metadata based on overall success
 		if s3UploadSuccessful {
 			metadata.DefaultStore.UpdateS3UploadStatus(meta.ID, metadata.StatusSuccess, s3Keys, "")
+			manifestKeys = s3Keys
+			metrics.BackupSize.WithLabelValues(backupType, serverName, database, "s3").Set(float64(fileSize))
 		} else {
 			errMsg := fmt.Sprintf("S3 upload failed: %s", strings.Join(uploadErrors, "; "))
 			metadata.DefaultStore.UpdateS3UploadStatus(meta.ID, metadata.StatusError, map[string]string{}, errMsg)
@@ -767,6 +1061,12 @@ func (m *Manager) backupDatabase(serverName, serverType, database, backupType st
 			"S3 upload not enabled for this backup type")
 	}
 
+	var manifestPaths map[string]string
+	if localBackupEnabled {
+		manifestPaths = localPaths
+	}
+	m.writeManifests(meta.ID, manifest, manifestPaths, manifestKeys, logFile)
+
 	return nil
 }
 
@@ -843,27 +1143,69 @@ func queryAllDatabases(db *sql.DB) ([]string, error) {
 	return databases, nil
 }
 
+// RetentionPlan evaluates the retention rules against current backup metadata
+// without deleting anything
+func (m *Manager) RetentionPlan() retention.Plan {
+	backups := metadata.DefaultStore.GetBackupsFiltered("", "", "", true)
+	return retention.BuildPlan(backups, m.cfg.BackupTypes, time.Now())
+}
+
 // EnforceRetentionPolicies enforces retention policies across all storage types
-func (m *Manager) EnforceRetentionPolicies() {
+func (m *Manager) EnforceRetentionPolicies() error {
 	log.Println("Enforcing retention policies...")
+	m.releaseExpiredHolds(time.Now())
 
-	// Purge metadata records for backups deleted more than 7 days ago
+	// Purge metadata records for backups deleted more than 7 days ago, except
+	// held ones
 	purgedCount := metadata.DefaultStore.PurgeDeletedBackups(7 * 24 * time.Hour)
 	if purgedCount > 0 {
 		log.Printf("Purged %d deleted backup records from metadata", purgedCount)
 	}
 
-	// Enforce local retention if enabled
-	if m.cfg.Local.Enabled && m.localStore != nil {
-		if err := m.localStore.EnforceRetention(); err != nil {
-			log.Printf("Error enforcing local retention policies: %v", err)
+	var errs []error
+	plan := m.RetentionPlan()
+	for _, decision := range plan.Deletions() {
+		// A hold placed since the plan was built still counts
+		backup, found := metadata.DefaultStore.GetBackupByID(decision.BackupID)
+		if !found || backup.IsHeld(time.Now()) {
+			continue
+		}
+
+		var remaining map[string]string
+		var err error
+		switch decision.Location {
+		case retention.LocationLocal:
+			if !m.cfg.Local.Enabled || m.localStore == nil {
+				continue
+			}
+			remaining, err = m.localStore.DeleteBackup(backup)
+		case retention.LocationS3:
+			if !m.cfg.S3.Enabled || m.s3Store == nil {
+				continue
+			}
+			remaining, err = m.s3Store.DeleteBackup(backup)
+		default:
+			continue
+		}
+
+		// Paths that failed stay in metadata and are retried on the next run
+		errorMsg := ""
+		if err != nil {
+			errorMsg = err.Error()
+			errs = append(errs, fmt.Errorf("%s backup %s: %w", decision.Location, backup.ID, err))
+			log.Printf("Error enforcing %s retention for backup %s: %v", decision.Location, backup.ID, err)
+			metrics.RetentionDeleteErrors.WithLabelValues(backup.BackupType, decision.Location).Inc()
+		} else {
+			log.Printf("Removed %s backup %s: %s", decision.Location, backup.ID, strings.Join(decision.Reasons, "; "))
+			metrics.BackupRetentionDeletes.WithLabelValues(backup.BackupType, decision.Location).Inc()
 		}
-	}
 
-	// Enforce S3 retention if enabled
-	if m.cfg.S3.Enabled && m.s3Store != nil {
-		if err := m.s3Store.EnforceRetention(); err != nil {
-			log.Printf("Error enforcing S3 retention policies: %v", err)
+		if err := metadata.DefaultStore.UpdateRetentionStatus(backup.ID, decision.Location, remaining, errorMsg); err != nil {
+			log.Printf("Warning: Failed to record retention status for backup %s: %v", backup.ID, err)
 		}
 	}
+
+	m.reconcileOrphans()
+	m.recordStatsHistory()
+	return errors.Join(errs...)
 }
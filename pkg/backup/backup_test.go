@@ -0,0 +1,831 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+	"github.com/supporttools/GoSQLGuard/pkg/storage/local"
+)
+
+// setupTestManager points the global configuration and metadata store at a
+// temporary directory and returns a manager with local storage only
+func setupTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	originalCfg := config.CFG
+	originalStore := metadata.DefaultStore
+	t.Cleanup(func() {
+		config.CFG = originalCfg
+		metadata.DefaultStore = originalStore
+	})
+
+	config.CFG = config.AppConfig{}
+	config.CFG.Local.Enabled = true
+	config.CFG.Local.BackupDirectory = t.TempDir()
+
+	metadata.DefaultStore = nil
+	require.NoError(t, metadata.Initialize())
+
+	localClient, err := local.NewClient()
+	require.NoError(t, err)
+
+	return &Manager{cfg: &config.CFG, localStore: localClient}
+}
+
+// installFakeDumper puts a stub executable on PATH that prints its arguments
+func installFakeDumper(t *testing.T, name string) {
+	t.Helper()
+
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho \"$@\"\necho \"$@\" >&2\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, name), []byte(script), 0700))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestBackupDatabaseNeverLogsPassword(t *testing.T) {
+	const secret = "sup3r-s3cret-pa55"
+
+	m := setupTestManager(t)
+	installFakeDumper(t, "mysqldump")
+
+	config.CFG.DatabaseServers = []config.DatabaseServerConfig{
+		{Name: "primary", Type: "mysql", Host: "db.example.com", Port: "3306", Username: "backup", Password: secret},
+	}
+	typeConfig := config.BackupTypeConfig{Local: config.LocalBackupConfig{Enabled: true}}
+
+	require.NoError(t, m.backupDatabase("primary", "mysql", "app", "manual", typeConfig, 0, nil))
+
+	backups := metadata.DefaultStore.GetBackups()
+	require.Len(t, backups, 1)
+	require.NotEmpty(t, backups[0].LogFilePath)
+
+	logData, err := os.ReadFile(backups[0].LogFilePath)
+	require.NoError(t, err)
+	assert.NotEmpty(t, logData)
+	assert.NotContains(t, string(logData), secret)
+}
+
+func TestBackupDatabaseWritesManifest(t *testing.T) {
+	m := setupTestManager(t)
+	installFakeDumper(t, "mysqldump")
+
+	config.CFG.DatabaseServers = []config.DatabaseServerConfig{
+		{Name: "primary", Type: "mysql", Host: "db.example.com", Port: "3306", Username: "backup"},
+	}
+	typeConfig := config.BackupTypeConfig{Local: config.LocalBackupConfig{Enabled: true}}
+
+	require.NoError(t, m.backupDatabase("primary", "mysql", "app", "daily", typeConfig, 0, nil))
+
+	backups := metadata.DefaultStore.GetBackups()
+	require.Len(t, backups, 1)
+	require.Len(t, backups[0].LocalPaths, 2)
+
+	for _, path := range backups[0].LocalPaths {
+		data, err := os.ReadFile(types.ManifestPath(path))
+		require.NoError(t, err, "every copy has a manifest")
+
+		var manifest types.Manifest
+		require.NoError(t, json.Unmarshal(data, &manifest))
+		assert.Equal(t, types.ManifestVersion, manifest.ManifestVersion)
+		assert.Equal(t, backups[0].ID, manifest.Backup.ID)
+		assert.Equal(t, types.StatusSuccess, manifest.Backup.Status)
+		assert.Contains(t, manifest.DumpCommand, "--single-transaction")
+		assert.Equal(t, "--version", manifest.ToolVersions["mysqldump"], "the fake dumper echoes its arguments")
+
+		checksum, err := fileChecksum(path)
+		require.NoError(t, err)
+		assert.Equal(t, checksum, manifest.Checksum)
+	}
+}
+
+// memoryStore is an in-memory metadata store with caller-controlled backups
+type memoryStore struct {
+	backups []types.BackupMeta
+	audit   []types.AuditEntry
+	stats   []types.StatsSnapshot
+}
+
+func (s *memoryStore) CreateBackupMeta(serverName, serverType, database, backupType string) *types.BackupMeta {
+	return nil
+}
+
+func (s *memoryStore) UpdateBackupStatus(id string, status types.BackupStatus, localPaths map[string]string, size int64, errorMsg string) error {
+	return nil
+}
+
+func (s *memoryStore) UpdateS3UploadStatus(id string, status types.BackupStatus, s3Keys map[string]string, errorMsg string) error {
+	return nil
+}
+
+func (s *memoryStore) GetBackups() []types.BackupMeta { return s.backups }
+
+func (s *memoryStore) GetBackupsFiltered(serverName, database, backupType string, activeOnly bool) []types.BackupMeta {
+	var result []types.BackupMeta
+	for _, b := range s.backups {
+		if !activeOnly || b.Status == types.StatusSuccess {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+func (s *memoryStore) QueryBackups(q types.BackupQuery) (types.BackupPage, error) {
+	return types.BackupPage{}, nil
+}
+
+func (s *memoryStore) GetBackupByID(id string) (types.BackupMeta, bool) {
+	for _, b := range s.backups {
+		if b.ID == id {
+			return b, true
+		}
+	}
+	return types.BackupMeta{}, false
+}
+
+func (s *memoryStore) MarkBackupDeleted(id string) error {
+	for i := range s.backups {
+		if s.backups[i].ID == id {
+			s.backups[i].Status = types.StatusDeleted
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) UpdateRetentionStatus(id, location string, remaining map[string]string, errorMsg string) error {
+	for i := range s.backups {
+		b := &s.backups[i]
+		if b.ID != id {
+			continue
+		}
+		if location == "local" {
+			b.LocalPaths = remaining
+			if len(remaining) == 0 {
+				b.LocalDeletedAt = time.Now()
+			}
+		} else {
+			b.S3Keys = remaining
+			if len(remaining) == 0 {
+				b.S3DeletedAt = time.Now()
+			}
+		}
+		b.RetentionError = errorMsg
+		if len(b.LocalPaths) == 0 && len(b.S3Keys) == 0 {
+			b.Status = types.StatusDeleted
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) GetStats() map[string]interface{}                       { return nil }
+func (s *memoryStore) UpdateLogFilePath(id string, logFilePath string) error  { return nil }
+func (s *memoryStore) UpdateFencingToken(id string, token int64) error        { return nil }
+func (s *memoryStore) UpdateLabels(id string, labels map[string]string) error { return nil }
+func (s *memoryStore) MergeAnnotations(id string, changes map[string]string) (map[string]string, error) {
+	return nil, nil
+}
+func (s *memoryStore) PutBackup(backup types.BackupMeta) error {
+	for i := range s.backups {
+		if s.backups[i].ID == backup.ID {
+			s.backups[i] = backup
+			return nil
+		}
+	}
+	s.backups = append(s.backups, backup)
+	return nil
+}
+func (s *memoryStore) PutBackups(backups []types.BackupMeta) error {
+	for _, backup := range backups {
+		s.PutBackup(backup)
+	}
+	return nil
+}
+
+func (s *memoryStore) UpdateHold(id string, hold *types.BackupHold) error {
+	for i := range s.backups {
+		if s.backups[i].ID == id {
+			s.backups[i].Hold = hold
+			return nil
+		}
+	}
+	return fmt.Errorf("backup with ID %s not found", id)
+}
+
+func (s *memoryStore) AddAuditEntry(entry types.AuditEntry) error {
+	s.audit = append(s.audit, entry)
+	return nil
+}
+
+func (s *memoryStore) GetAuditEntries(backupID string, limit int) []types.AuditEntry {
+	return s.audit
+}
+
+func (s *memoryStore) SaveStatsSnapshots(snapshots []types.StatsSnapshot) error {
+	for _, snapshot := range snapshots {
+		kept := s.stats[:0]
+		for _, existing := range s.stats {
+			if !existing.Day.Equal(snapshot.Day) || existing.ServerName != snapshot.ServerName || existing.Database != snapshot.Database {
+				kept = append(kept, existing)
+			}
+		}
+		s.stats = append(kept, snapshot)
+	}
+	return nil
+}
+
+func (s *memoryStore) GetStatsHistory(q types.StatsHistoryQuery) ([]types.StatsSnapshot, error) {
+	var snapshots []types.StatsSnapshot
+	for _, snapshot := range s.stats {
+		if q.Matches(snapshot) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots, nil
+}
+
+func (s *memoryStore) PurgeDeletedBackups(olderThan time.Duration) int { return 0 }
+func (s *memoryStore) Load() error                                     { return nil }
+func (s *memoryStore) Save() error                                     { return nil }
+
+// seedRetentionBackups writes hourly backups for one database in both
+// organization layouts and records them in an in-memory metadata store
+func seedRetentionBackups(t *testing.T, count int) (*memoryStore, []map[string]string) {
+	t.Helper()
+
+	dir := config.CFG.Local.BackupDirectory
+	config.CFG.BackupTypes = map[string]config.BackupTypeConfig{
+		"hourly": {Local: config.LocalBackupConfig{Enabled: true, Retention: config.RetentionRule{KeepLast: 2}}},
+	}
+
+	store := &memoryStore{}
+	var allPaths []map[string]string
+	now := time.Now()
+	for i := 0; i < count; i++ {
+		paths := map[string]string{
+			"by-server": filepath.Join(dir, "by-server", "primary", "hourly", fmt.Sprintf("app-%d.sql.gz", i)),
+			"by-type":   filepath.Join(dir, "by-type", "hourly", fmt.Sprintf("primary_app-%d.sql.gz", i)),
+		}
+		for _, path := range paths {
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+			require.NoError(t, os.WriteFile(path, []byte("backup"), 0600))
+		}
+
+		// File age must not matter, only the recorded creation time
+		localPaths := make(map[string]string)
+		for org, path := range paths {
+			localPaths[org] = path
+		}
+		store.backups = append(store.backups, types.BackupMeta{
+			ID:         fmt.Sprintf("backup-%d", i),
+			ServerName: "primary",
+			Database:   "app",
+			BackupType: "hourly",
+			CreatedAt:  now.Add(-time.Duration(i) * time.Hour),
+			Status:     types.StatusSuccess,
+			LocalPaths: localPaths,
+		})
+		allPaths = append(allPaths, paths)
+	}
+	metadata.DefaultStore = store
+
+	return store, allPaths
+}
+
+func TestEnforceRetentionPoliciesUsesMetadata(t *testing.T) {
+	m := setupTestManager(t)
+	store, allPaths := seedRetentionBackups(t, 4)
+
+	plan := m.RetentionPlan()
+	assert.Len(t, plan.Deletions(), 2)
+
+	require.NoError(t, m.EnforceRetentionPolicies())
+
+	for i, b := range store.backups {
+		kept := i < 2
+		for _, path := range allPaths[i] {
+			_, err := os.Stat(path)
+			assert.Equal(t, kept, err == nil, "backup %d file %s", i, path)
+		}
+		if kept {
+			assert.Equal(t, types.StatusSuccess, b.Status)
+			assert.Len(t, b.LocalPaths, 2)
+		} else {
+			assert.Equal(t, types.StatusDeleted, b.Status)
+			assert.Empty(t, b.LocalPaths)
+			assert.False(t, b.LocalDeletedAt.IsZero())
+		}
+	}
+}
+
+func TestEnforceRetentionPoliciesPartialFailure(t *testing.T) {
+	m := setupTestManager(t)
+	store, allPaths := seedRetentionBackups(t, 3)
+
+	// A non-empty directory in place of the by-type copy cannot be removed
+	blocked := allPaths[2]["by-type"]
+	require.NoError(t, os.Remove(blocked))
+	require.NoError(t, os.MkdirAll(filepath.Join(blocked, "busy"), 0750))
+
+	assert.Error(t, m.EnforceRetentionPolicies(), "failed deletions must be reported")
+
+	b := store.backups[2]
+	assert.Equal(t, types.StatusSuccess, b.Status, "backup with a remaining copy must not be marked deleted")
+	assert.Equal(t, map[string]string{"by-type": blocked}, b.LocalPaths)
+	assert.True(t, b.LocalDeletedAt.IsZero())
+	assert.Contains(t, b.RetentionError, blocked)
+
+	_, err := os.Stat(allPaths[2]["by-server"])
+	assert.True(t, os.IsNotExist(err), "the deletable copy should still be removed")
+
+	// Once the blocker is gone the next run finishes the job
+	require.NoError(t, os.RemoveAll(blocked))
+	require.NoError(t, m.EnforceRetentionPolicies())
+
+	b = store.backups[2]
+	assert.Equal(t, types.StatusDeleted, b.Status)
+	assert.Empty(t, b.RetentionError)
+}
+
+func TestEnforceRetentionPoliciesSkipsHeldBackups(t *testing.T) {
+	m := setupTestManager(t)
+	store, allPaths := seedRetentionBackups(t, 4)
+
+	_, err := m.PlaceHold("backup-3", "legal", "litigation", time.Time{})
+	require.NoError(t, err)
+	store.backups[2].Hold = &types.BackupHold{Reason: "audit", Actor: "legal", ExpiresAt: time.Now().Add(-time.Minute)}
+
+	require.NoError(t, m.EnforceRetentionPolicies())
+
+	assert.Equal(t, types.StatusSuccess, store.backups[3].Status, "held backup must be kept")
+	for _, path := range allPaths[3] {
+		assert.FileExists(t, path)
+	}
+	assert.Equal(t, types.StatusDeleted, store.backups[2].Status, "backup with an expired hold must be deleted")
+	assert.Nil(t, store.backups[2].Hold, "expired hold must be released")
+
+	require.Len(t, store.audit, 2)
+	assert.Equal(t, types.AuditHoldPlaced, store.audit[0].Action)
+	assert.Equal(t, "legal", store.audit[0].Actor)
+	assert.Equal(t, types.AuditHoldExpired, store.audit[1].Action)
+	assert.Equal(t, "backup-2", store.audit[1].BackupID)
+
+	require.NoError(t, m.ReleaseHold("backup-3", "legal", "case closed"))
+	assert.Nil(t, store.backups[3].Hold)
+	assert.Error(t, m.ReleaseHold("backup-3", "legal", ""), "releasing twice must fail")
+	require.NoError(t, m.EnforceRetentionPolicies())
+	assert.Equal(t, types.StatusDeleted, store.backups[3].Status)
+
+	// A hold must protect something
+	_, err = m.PlaceHold("backup-3", "legal", "too late", time.Time{})
+	assert.ErrorIs(t, err, ErrNothingToHold)
+	for _, path := range allPaths[1] {
+		require.NoError(t, os.Remove(path))
+	}
+	_, err = m.PlaceHold("backup-1", "legal", "lost", time.Time{})
+	assert.ErrorIs(t, err, ErrNothingToHold)
+	assert.Len(t, store.audit, 3, "refused holds are not audited")
+}
+
+func TestReconcileOrphans(t *testing.T) {
+	m := setupTestManager(t)
+	_, allPaths := seedRetentionBackups(t, 1)
+	dir := config.CFG.Local.BackupDirectory
+	config.CFG.Retention.OrphanGracePeriod = "1h"
+
+	// An old file with no metadata and a recent one that may still be in progress
+	orphan := filepath.Join(dir, "hourly", "legacy-layout.sql.gz")
+	recent := filepath.Join(dir, "by-server", "primary", "hourly", "in-progress.sql.gz")
+	for _, path := range []string{orphan, recent} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte("backup"), 0600))
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(orphan, old, old))
+	for _, path := range allPaths[0] {
+		require.NoError(t, os.Chtimes(path, old, old))
+	}
+
+	// Orphans are only reported by default
+	m.reconcileOrphans()
+	assert.FileExists(t, orphan)
+
+	config.CFG.Retention.DeleteOrphans = true
+	m.reconcileOrphans()
+	assert.NoFileExists(t, orphan)
+	assert.FileExists(t, recent)
+	for _, path := range allPaths[0] {
+		assert.FileExists(t, path)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	m := setupTestManager(t)
+	store, allPaths := seedRetentionBackups(t, 4)
+	dir := config.CFG.Local.BackupDirectory
+	config.CFG.Retention.OrphanGracePeriod = "1h"
+	old := time.Now().Add(-2 * time.Hour)
+
+	// backup-0 matches storage, backup-1 lost a file, backup-2 has the wrong
+	// size and backup-3 was deleted by hand, leaving its files behind
+	for i := range store.backups {
+		store.backups[i].Size = int64(len("backup"))
+	}
+	require.NoError(t, os.Remove(allPaths[1]["by-type"]))
+	store.backups[2].Size = 100
+	store.backups[3].Status = types.StatusDeleted
+
+	// An orphan with a manifest, as left by a backup whose metadata was lost
+	orphan := filepath.Join(dir, "by-server", "primary", "hourly", "app-lost.sql.gz")
+	require.NoError(t, os.WriteFile(orphan, []byte("backup"), 0600))
+	manifest, err := json.Marshal(types.Manifest{Backup: types.BackupMeta{
+		ID: "lost", ServerName: "primary", Database: "app", BackupType: "hourly", Status: types.StatusSuccess,
+		LocalPaths: map[string]string{"by-server": orphan, "by-type": filepath.Join(dir, "by-type", "hourly", "primary_app-lost.sql.gz")},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(types.ManifestPath(orphan), manifest, 0600))
+	for _, path := range append([]string{orphan}, allPaths[3]["by-server"], allPaths[3]["by-type"]) {
+		require.NoError(t, os.Chtimes(path, old, old))
+	}
+
+	report := m.Reconcile()
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 8, report.Scanned["local"])
+	assert.Equal(t, 1, report.Count(FindingMissing, "local"))
+	assert.Equal(t, 2, report.Count(FindingSizeMismatch, "local"))
+	assert.Equal(t, 3, report.Count(FindingOrphan, "local"))
+	for _, f := range report.Findings {
+		if f.Kind == FindingOrphan && f.Path != orphan {
+			assert.Equal(t, "backup-3", f.BackupID, "orphans of deleted backups name them")
+		}
+	}
+
+	// Actions check the finding still holds
+	assert.Error(t, m.MarkMissing("backup-0", "local", allPaths[0]["by-type"], "ops"), "present files are not missing")
+	assert.Error(t, m.DeleteOrphan("local", allPaths[0]["by-type"], "ops"), "live files are not orphans")
+	assert.Error(t, m.DeleteOrphan("local", filepath.Join(dir, "..", "elsewhere.sql.gz"), "ops"))
+
+	require.NoError(t, m.MarkMissing("backup-1", "local", allPaths[1]["by-type"], "ops"))
+	assert.Equal(t, map[string]string{"by-server": allPaths[1]["by-server"]}, store.backups[1].LocalPaths)
+	assert.Equal(t, types.StatusSuccess, store.backups[1].Status)
+
+	imported, err := m.ImportOrphan("local", orphan, "ops")
+	require.NoError(t, err)
+	assert.Equal(t, "lost", imported.ID)
+	assert.Equal(t, map[string]string{"by-server": orphan}, imported.LocalPaths, "only the copy found is recorded")
+	_, found := store.GetBackupByID("lost")
+	assert.True(t, found)
+
+	require.NoError(t, m.DeleteOrphan("local", allPaths[3]["by-type"], "ops"))
+	assert.NoFileExists(t, allPaths[3]["by-type"])
+	assert.Equal(t, map[string]string{"by-server": allPaths[3]["by-server"]}, store.backups[3].LocalPaths)
+
+	last, ok := m.LastReconcileReport()
+	require.True(t, ok)
+	assert.Equal(t, 0, last.Count(FindingMissing, "local"))
+	assert.Equal(t, 1, last.Count(FindingOrphan, "local"))
+
+	// Files of a held backup are kept even once it is marked deleted
+	store.backups[3].Hold = &types.BackupHold{Reason: "litigation", PlacedAt: time.Now()}
+	assert.Zero(t, m.Reconcile().Count(FindingOrphan, "local"))
+	assert.ErrorContains(t, m.DeleteOrphan("local", allPaths[3]["by-server"], "ops"), "on hold")
+	assert.FileExists(t, allPaths[3]["by-server"])
+
+	var actions []string
+	for _, entry := range store.audit {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []string{types.AuditMarkedMissing, types.AuditOrphanImported, types.AuditOrphanDeleted}, actions)
+}
+
+func TestReconcileWithoutS3Prefix(t *testing.T) {
+	m := setupTestManager(t)
+	config.CFG.S3.Enabled = true
+	old := time.Now().Add(-2 * time.Hour)
+
+	// With no prefix the listing spans the whole bucket, so unrecorded keys
+	// are not classified as orphans
+	backups := []types.BackupMeta{{
+		ID: "backup-0", Status: types.StatusSuccess, S3UploadStatus: types.StatusSuccess,
+		S3Keys: map[string]string{"by-server": "by-server/primary/hourly/app.sql.gz"},
+	}}
+	report := ReconcileReport{Scanned: make(map[string]int)}
+	report.compare(backups, "s3", []storedFile{
+		{Path: "other-app/export.sql.gz", Size: 6, ModTime: old},
+	}, time.Now(), false)
+	assert.Equal(t, 1, report.Count(FindingMissing, "s3"))
+	assert.Zero(t, report.Count(FindingOrphan, "s3"))
+
+	assert.ErrorContains(t, m.DeleteOrphan("s3", "other-app/export.sql.gz", "ops"), "prefix")
+}
+
+func TestPerformBackupHonorsTargets(t *testing.T) {
+	m := setupTestManager(t)
+	installFakeDumper(t, "mysqldump")
+
+	databases := []string{"app", "orders_eu", "orders_us", "logs"}
+	config.CFG.DatabaseServers = []config.DatabaseServerConfig{
+		{Name: "primary", Type: "mysql", Host: "db1", Port: "3306", IncludeDatabases: databases, Labels: map[string]string{"tier": "hot"}},
+		{Name: "replica", Type: "mysql", Host: "db2", Port: "3306", IncludeDatabases: databases},
+	}
+	config.CFG.BackupTypes = map[string]config.BackupTypeConfig{
+		"hourly": {Schedule: "0 * * * *", Local: config.LocalBackupConfig{Enabled: true}},
+	}
+
+	targets := config.BackupTargets{
+		Databases:        []string{"orders_*", "app"},
+		ExcludeDatabases: []string{"orders_us"},
+		Labels:           map[string]string{"tier": "hot"},
+	}
+	require.NoError(t, m.PerformBackup("hourly", OptionsFromTargets(targets)))
+
+	var backedUp []string
+	for _, b := range metadata.DefaultStore.GetBackups() {
+		backedUp = append(backedUp, b.ServerName+"/"+b.Database)
+	}
+	assert.ElementsMatch(t, []string{"primary/app", "primary/orders_eu"}, backedUp)
+
+	// Targets that select nothing are reported rather than silently skipped
+	err := m.PerformBackup("hourly", Options{Servers: []string{"missing-*"}})
+	assert.ErrorContains(t, err, "no databases matched")
+}
+
+func TestPerformBackupReportsFailedServers(t *testing.T) {
+	m := setupTestManager(t)
+	installFakeDumper(t, "mysqldump")
+
+	config.CFG.DatabaseServers = []config.DatabaseServerConfig{
+		{Name: "primary", Type: "mysql", Host: "db1", Port: "3306", IncludeDatabases: []string{"app"}},
+		{Name: "legacy", Type: "oracle", Host: "db2", Port: "1521"},
+	}
+	config.CFG.BackupTypes = map[string]config.BackupTypeConfig{
+		"hourly": {Schedule: "0 * * * *", Local: config.LocalBackupConfig{Enabled: true}},
+	}
+
+	// The reachable server is still backed up, but the run fails
+	err := m.PerformBackup("hourly")
+	assert.ErrorContains(t, err, "legacy")
+	assert.Len(t, metadata.DefaultStore.GetBackups(), 1)
+}
+
+func TestPerformBackupDefersPastDeadline(t *testing.T) {
+	m := setupTestManager(t)
+	installFakeDumper(t, "mysqldump")
+
+	config.CFG.DatabaseServers = []config.DatabaseServerConfig{
+		{Name: "primary", Type: "mysql", Host: "db1", Port: "3306", IncludeDatabases: []string{"a", "b", "c"}},
+	}
+	config.CFG.BackupTypes = map[string]config.BackupTypeConfig{
+		"hourly": {Schedule: "0 * * * *", Local: config.LocalBackupConfig{Enabled: true}},
+	}
+
+	// A run window that has already ended defers every database
+	require.NoError(t, m.PerformBackup("hourly", Options{Deadline: time.Now().Add(-time.Second)}))
+
+	backups := metadata.DefaultStore.GetBackups()
+	require.Len(t, backups, 3)
+	for _, b := range backups {
+		assert.Equal(t, types.StatusDeferred, b.Status)
+		assert.Contains(t, b.ErrorMessage, "maximum run window")
+	}
+
+	// The next run starts with the deferred databases and clears them
+	carried := m.takeDeferred("hourly")
+	assert.Equal(t, []string{"a", "b", "c"}, carried["primary"])
+	assert.Empty(t, m.takeDeferred("hourly"))
+}
+
+func TestPerformBackupStopsWhenFenced(t *testing.T) {
+	m := setupTestManager(t)
+	installFakeDumper(t, "mysqldump")
+
+	config.CFG.DatabaseServers = []config.DatabaseServerConfig{
+		{Name: "primary", Type: "mysql", Host: "db1", Port: "3306", IncludeDatabases: []string{"a", "b", "c"}},
+	}
+	config.CFG.BackupTypes = map[string]config.BackupTypeConfig{
+		"hourly": {Schedule: "0 * * * *", Local: config.LocalBackupConfig{Enabled: true}},
+	}
+
+	// Leadership is lost after the first database
+	checks := 0
+	fence := func() error {
+		checks++
+		if checks > 1 {
+			return fmt.Errorf("no longer the leader")
+		}
+		return nil
+	}
+	require.NoError(t, m.PerformBackup("hourly", Options{FencingToken: 7, Fence: fence}))
+
+	backups := metadata.DefaultStore.GetBackups()
+	require.Len(t, backups, 1)
+	assert.Equal(t, "a", backups[0].Database)
+	assert.Equal(t, int64(7), backups[0].FencingToken)
+}
+
+func TestShutdownInterruptsRunningBackup(t *testing.T) {
+	m := setupTestManager(t)
+
+	// A dump that never finishes on its own
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho partial\nexec sleep 30\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "mysqldump"), []byte(script), 0700))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	config.CFG.DatabaseServers = []config.DatabaseServerConfig{
+		{Name: "primary", Type: "mysql", Host: "db1", Port: "3306"},
+	}
+	typeConfig := config.BackupTypeConfig{Local: config.LocalBackupConfig{Enabled: true}}
+
+	result := make(chan error, 1)
+	go func() { result <- m.backupDatabase("primary", "mysql", "app", "manual", typeConfig, 0, nil) }()
+	require.Eventually(t, func() bool {
+		m.life.mu.Lock()
+		defer m.life.mu.Unlock()
+		return m.life.running == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.ErrorIs(t, m.Shutdown(50*time.Millisecond), ErrShuttingDown)
+	assert.ErrorIs(t, <-result, ErrShuttingDown)
+
+	backups := metadata.DefaultStore.GetBackups()
+	require.Len(t, backups, 1)
+	assert.Equal(t, types.StatusInterrupted, backups[0].Status)
+
+	var dumps []string
+	require.NoError(t, filepath.Walk(config.CFG.Local.BackupDirectory, func(path string, info os.FileInfo, err error) error {
+		if err == nil && filepath.Ext(path) == ".gz" {
+			dumps = append(dumps, path)
+		}
+		return err
+	}))
+	assert.Empty(t, dumps, "partial dumps are removed")
+
+	// No new backups start once shutting down
+	assert.ErrorIs(t, m.backupDatabase("primary", "mysql", "app", "manual", typeConfig, 0, nil), ErrShuttingDown)
+	assert.Len(t, metadata.DefaultStore.GetBackups(), 1)
+}
+
+func TestReconcileInterrupted(t *testing.T) {
+	setupTestManager(t)
+	m := &Manager{cfg: &config.CFG}
+
+	stale := metadata.DefaultStore.CreateBackupMeta("primary", "mysql", "app", "daily")
+	done := metadata.DefaultStore.CreateBackupMeta("primary", "mysql", "other", "daily")
+	require.NoError(t, metadata.DefaultStore.UpdateBackupStatus(done.ID, types.StatusSuccess, map[string]string{}, 10, ""))
+
+	// Entries created by this process are left alone
+	assert.Equal(t, 0, m.ReconcileInterrupted(stale.CreatedAt))
+
+	assert.Equal(t, 1, m.ReconcileInterrupted(time.Now().Add(time.Minute)))
+	backup, _ := metadata.DefaultStore.GetBackupByID(stale.ID)
+	assert.Equal(t, types.StatusInterrupted, backup.Status)
+	backup, _ = metadata.DefaultStore.GetBackupByID(done.ID)
+	assert.Equal(t, types.StatusSuccess, backup.Status)
+}
+
+// fakeQueue records enqueued tasks
+type fakeQueue struct {
+	tasks []dbmeta.BackupTask
+}
+
+func (q *fakeQueue) Enqueue(tasks []dbmeta.BackupTask) (int, error) {
+	q.tasks = append(q.tasks, tasks...)
+	return len(tasks), nil
+}
+
+func (q *fakeQueue) RequeueStale(timeout time.Duration, maxAttempts int) (int, int, error) {
+	return 0, 0, nil
+}
+
+func (q *fakeQueue) PurgeTasks(olderThan time.Duration) (int64, error) { return 0, nil }
+
+func (q *fakeQueue) Progress(backupType string, since time.Time) (int, int, error) { return 0, 0, nil }
+
+func TestPerformBackupEnqueuesForWorkers(t *testing.T) {
+	m := setupTestManager(t)
+	queue := &fakeQueue{}
+	m.SetTaskQueue(queue)
+
+	config.CFG.DatabaseServers = []config.DatabaseServerConfig{
+		{Name: "eu-db1", Type: "mysql", Host: "db1", Port: "3306", IncludeDatabases: []string{"a", "b"}},
+	}
+	config.CFG.BackupTypes = map[string]config.BackupTypeConfig{
+		"hourly": {Schedule: "0 * * * *", Local: config.LocalBackupConfig{Enabled: true}},
+	}
+
+	deadline := time.Now().Add(time.Hour)
+	require.NoError(t, m.PerformBackup("hourly", Options{Deadline: deadline, FencingToken: 3}))
+
+	// Nothing runs here; each database becomes a task
+	assert.Empty(t, metadata.DefaultStore.GetBackups())
+	require.Len(t, queue.tasks, 2)
+	for _, task := range queue.tasks {
+		assert.Equal(t, "hourly", task.BackupType)
+		assert.Equal(t, "eu-db1", task.ServerName)
+		assert.Equal(t, int64(3), task.FencingToken)
+		require.NotNil(t, task.Deadline)
+		assert.True(t, task.Deadline.Equal(deadline))
+	}
+
+	// A worker picking a task up after the run window defers it
+	expired := time.Now().Add(-time.Minute)
+	status, err := m.RunTask(dbmeta.BackupTask{
+		BackupType: "hourly", ServerName: "eu-db1", ServerType: "mysql", DatabaseName: "a", Deadline: &expired,
+	}, nil)
+	assert.Equal(t, dbmeta.TaskStatusDeferred, status)
+	assert.ErrorContains(t, err, "maximum run window")
+	assert.Empty(t, m.takeDeferred("hourly"), "the task records the deferral, not the worker")
+}
+
+func TestPrioritize(t *testing.T) {
+	assert.Equal(t, []string{"a", "c", "b", "d"}, prioritize([]string{"a", "b", "c", "d"}, []string{"c", "a"}))
+	assert.Equal(t, []string{"a", "b"}, prioritize([]string{"a", "b"}, nil))
+}
+
+func TestVerifyBackups(t *testing.T) {
+	m := setupTestManager(t)
+	dir := config.CFG.Local.BackupDirectory
+
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	_, err := gz.Write([]byte("CREATE TABLE t (id int);\n"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	good := filepath.Join(dir, "good.sql.gz")
+	require.NoError(t, os.WriteFile(good, archive.Bytes(), 0600))
+	truncated := filepath.Join(dir, "truncated.sql.gz")
+	require.NoError(t, os.WriteFile(truncated, archive.Bytes()[:archive.Len()-4], 0600))
+
+	results := m.VerifyBackups([]types.BackupMeta{
+		{ID: "ok", Size: int64(archive.Len()), LocalPaths: map[string]string{"by-server": good, "by-type": good}},
+		{ID: "truncated", LocalPaths: map[string]string{"by-server": truncated}},
+		{ID: "missing", LocalPath: filepath.Join(dir, "missing.sql.gz")},
+		{ID: "resized", Size: 1, LocalPaths: map[string]string{"by-server": good}},
+	})
+
+	require.Len(t, results, 4, "duplicate paths are checked once")
+	failed := make(map[string]bool)
+	for _, r := range results {
+		failed[r.BackupID] = r.Err != nil
+	}
+	assert.Equal(t, map[string]bool{"ok": false, "truncated": true, "missing": true, "resized": true}, failed)
+}
+
+func TestRecordStatsHistory(t *testing.T) {
+	m := setupTestManager(t)
+	store := &memoryStore{}
+	metadata.DefaultStore = store
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	today := types.StatsDay(now)
+	for i := 0; i < 4; i++ {
+		store.backups = append(store.backups, types.BackupMeta{
+			ID: fmt.Sprintf("backup-%d", i), ServerName: "primary", Database: "app",
+			CreatedAt: today.AddDate(0, 0, -i).Add(time.Hour), Status: types.StatusSuccess,
+			Size: 10, LocalPaths: map[string]string{"by-server": fmt.Sprintf("/backups/app-%d.sql.gz", i)},
+		})
+	}
+
+	// The first run fills in every day since the oldest backup
+	require.NoError(t, m.RecordStatsHistory(now))
+	history, err := store.GetStatsHistory(types.StatsHistoryQuery{})
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	sizes := make(map[time.Time]int64)
+	for _, snapshot := range history {
+		sizes[snapshot.Day] = snapshot.LocalSize
+	}
+	assert.Equal(t, map[time.Time]int64{
+		today.AddDate(0, 0, -3): 10,
+		today.AddDate(0, 0, -2): 20,
+		today.AddDate(0, 0, -1): 30,
+		today:                   40,
+	}, sizes)
+
+	// Once purged from the catalog, backups stay in the recorded history, but
+	// today and yesterday are brought up to date
+	store.backups = store.backups[:2]
+	require.NoError(t, m.RecordStatsHistory(now))
+	history, err = store.GetStatsHistory(types.StatsHistoryQuery{From: today.AddDate(0, 0, -3), To: today.AddDate(0, 0, -1)})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	for _, snapshot := range history {
+		assert.Equal(t, 1, snapshot.BackupCount)
+	}
+	history, err = store.GetStatsHistory(types.StatsHistoryQuery{From: today})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, int64(20), history[0].LocalSize)
+}
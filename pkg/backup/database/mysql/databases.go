@@ -8,6 +8,7 @@ import (
 
 	_ "github.com/go-sql-driver/mysql" // MySQL driver
 	"github.com/supporttools/GoSQLGuard/pkg/config"
+	"github.com/supporttools/GoSQLGuard/pkg/database/common"
 )
 
 // GetAllDatabases returns a list of all databases from the MySQL server
@@ -55,12 +56,29 @@ func GetDatabasesFromServer(server config.DatabaseServerConfig) ([]string, error
 	}
 
 	// Create connection string
-	connStr := fmt.Sprintf("%s:%s@tcp(%s:%s)/",
-		server.Username, server.Password, server.Host, server.Port)
+	connStr, err := buildDSN(server.Username, server.Password, server.Host, server.Port, server.TLS)
+	if err != nil {
+		return nil, err
+	}
 
 	return connectAndListDatabases(connStr, server.ExcludeDatabases)
 }
 
+// buildDSN creates a MySQL driver connection string, enabling TLS when configured
+func buildDSN(username, password, host, port string, tlsCfg config.DatabaseTLSConfig) (string, error) {
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/", username, password, host, port)
+
+	tlsParams, err := common.MySQLTLSParams(tlsCfg, host)
+	if err != nil {
+		return "", fmt.Errorf("invalid TLS settings for MySQL server %s: %w", host, err)
+	}
+	if tlsParams != "" {
+		dsn += "?" + tlsParams
+	}
+
+	return dsn, nil
+}
+
 // connectAndListDatabases handles the common database connection and listing logic
 func connectAndListDatabases(connStr string, excludeList []string) ([]string, error) {
 
@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"strings"
 
 	_ "github.com/go-sql-driver/mysql" // MySQL driver
 	"github.com/supporttools/GoSQLGuard/pkg/config"
@@ -23,6 +24,7 @@ type Provider struct {
 	Password         string
 	IncludeDatabases []string
 	ExcludeDatabases []string
+	TLS              config.DatabaseTLSConfig
 
 	db *sql.DB
 }
@@ -34,10 +36,11 @@ func (p *Provider) Name() string {
 
 // Connect establishes a connection to the database server
 func (p *Provider) Connect(ctx context.Context) error {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/",
-		p.User, p.Password, p.Host, p.Port)
+	dsn, err := buildDSN(p.User, p.Password, p.Host, fmt.Sprintf("%d", p.Port), p.TLS)
+	if err != nil {
+		return err
+	}
 
-	var err error
 	p.db, err = sql.Open("mysql", dsn)
 	if err != nil {
 		return fmt.Errorf("database backup failed: %v", err)
@@ -61,6 +64,35 @@ func (p *Provider) Close() error {
 	return nil
 }
 
+// ServerVersion returns the version reported by the MySQL server
+func (p *Provider) ServerVersion(ctx context.Context) (string, error) {
+	if p.db == nil {
+		if err := p.Connect(ctx); err != nil {
+			p.db = nil
+			return "", err
+		}
+		defer func() {
+			p.db.Close()
+			p.db = nil
+		}()
+	}
+
+	var version string
+	if err := p.db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to query MySQL server version: %w", err)
+	}
+	return version, nil
+}
+
+// ToolVersion returns the version line printed by mysqldump
+func (p *Provider) ToolVersion(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "mysqldump", "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get mysqldump version: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 // ListDatabases returns a list of available databases
 func (p *Provider) ListDatabases(ctx context.Context) ([]string, error) {
 	if p.db == nil {
@@ -108,7 +140,19 @@ func (p *Provider) Backup(ctx context.Context, dbName string, output io.Writer,
 		}
 	}
 
-	cmd := p.createBackupCommand(dbName, options, mysqlDumpOptions)
+	// Hand the password to mysqldump through a private option file rather
+	// than argv, where it would be visible to anyone running ps
+	var defaultsFile string
+	if p.Password != "" {
+		credFile, err := common.NewCredentialFile("my.cnf", common.MySQLOptionFileContents(p.Password))
+		if err != nil {
+			return err
+		}
+		defer credFile.Remove()
+		defaultsFile = credFile.Path
+	}
+
+	cmd := p.createBackupCommand(dbName, options, mysqlDumpOptions, defaultsFile)
 	cmd.Stdout = output
 	cmd.Stderr = os.Stderr
 
@@ -130,6 +174,8 @@ func (p *Provider) Backup(ctx context.Context, dbName string, output io.Writer,
 		if cmd.Process != nil {
 			cmd.Process.Kill()
 		}
+		// Wait for the output to stop before the caller closes the writer
+		<-done
 		return ctx.Err()
 	case err := <-done:
 		// Command completed
@@ -174,24 +220,39 @@ func (p *Provider) BackupCommand(dbName string, options common.BackupOptions) st
 		}
 	}
 
+	// The real option file only exists for the duration of a backup, so
+	// show a placeholder in its place
+	var defaultsFile string
+	if p.Password != "" {
+		defaultsFile = defaultsFilePlaceholder
+	}
+
 	// Create and return the command string
-	cmd := p.createBackupCommand(dbName, options, mysqlDumpOptions)
+	cmd := p.createBackupCommand(dbName, options, mysqlDumpOptions, defaultsFile)
 	return cmd.String()
 }
 
-// createBackupCommand creates the exec.Cmd for mysqldump with hardcoded options
-func (p *Provider) createBackupCommand(dbName string, options common.BackupOptions, _ config.MySQLDumpOptionsConfig) *exec.Cmd {
+// defaultsFilePlaceholder stands in for the option file path when describing a command
+const defaultsFilePlaceholder = "<private-option-file>"
+
+// createBackupCommand creates the exec.Cmd for mysqldump with hardcoded options.
+// If defaultsFile is set it is passed as --defaults-extra-file, which mysqldump
+// requires to be the first argument.
+func (p *Provider) createBackupCommand(dbName string, options common.BackupOptions, _ config.MySQLDumpOptionsConfig, defaultsFile string) *exec.Cmd {
+	var args []string
+	if defaultsFile != "" {
+		args = append(args, "--defaults-extra-file="+defaultsFile)
+	}
+
 	// Basic required args that we still need to set dynamically
-	args := []string{
+	args = append(args,
 		"-h", p.Host,
 		"-P", fmt.Sprintf("%d", p.Port),
 		"-u", p.User,
-	}
+	)
 
-	// Add password if provided
-	if p.Password != "" {
-		args = append(args, fmt.Sprintf("-p%s", p.Password))
-	}
+	// Add TLS flags for this server
+	args = append(args, common.MySQLDumpTLSArgs(p.TLS)...)
 
 	// Hardcoded options as per requirements
 	args = append(args,
@@ -310,6 +371,7 @@ type Factory struct {
 	Password         string
 	IncludeDatabases []string
 	ExcludeDatabases []string
+	TLS              config.DatabaseTLSConfig
 }
 
 // Create returns a new Provider instance
@@ -321,6 +383,7 @@ func (f *Factory) Create() (common.Provider, error) {
 		Password:         f.Password,
 		IncludeDatabases: f.IncludeDatabases,
 		ExcludeDatabases: f.ExcludeDatabases,
+		TLS:              f.TLS,
 	}
 
 	if err := provider.Validate(); err != nil {
@@ -0,0 +1,101 @@
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	"github.com/supporttools/GoSQLGuard/pkg/database/common"
+)
+
+const testPassword = "sup3r-s3cret-pa55"
+
+// installFakeMysqldump puts a mysqldump stub on PATH that records its
+// arguments and a copy of the option file it was given
+func installFakeMysqldump(t *testing.T) (argsFile, credCopy string) {
+	t.Helper()
+
+	binDir := t.TempDir()
+	argsFile = filepath.Join(binDir, "args")
+	credCopy = filepath.Join(binDir, "cred")
+
+	script := "#!/bin/sh\n" +
+		"echo \"$@\" > " + argsFile + "\n" +
+		"cat \"${1#--defaults-extra-file=}\" > " + credCopy + "\n" +
+		"echo '-- dump'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "mysqldump"), []byte(script), 0700))
+
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return argsFile, credCopy
+}
+
+func TestCreateBackupCommandOmitsPassword(t *testing.T) {
+	p := &Provider{Host: "db.example.com", Port: 3306, User: "backup", Password: testPassword}
+
+	cmd := p.createBackupCommand("app", common.BackupOptions{}, config.MySQLDumpOptionsConfig{}, "/tmp/private/my.cnf")
+
+	// --defaults-extra-file is only honoured as the first argument
+	require.NotEmpty(t, cmd.Args)
+	assert.Equal(t, "--defaults-extra-file=/tmp/private/my.cnf", cmd.Args[1])
+	for _, arg := range cmd.Args {
+		assert.NotContains(t, arg, testPassword)
+	}
+
+	assert.NotContains(t, p.BackupCommand("app", common.BackupOptions{}), testPassword)
+}
+
+func TestCreateBackupCommandWithoutPassword(t *testing.T) {
+	p := &Provider{Host: "db.example.com", Port: 3306, User: "backup"}
+
+	cmd := p.createBackupCommand("app", common.BackupOptions{}, config.MySQLDumpOptionsConfig{}, "")
+	for _, arg := range cmd.Args {
+		assert.False(t, strings.HasPrefix(arg, "--defaults-extra-file"))
+	}
+	assert.NotContains(t, p.BackupCommand("app", common.BackupOptions{}), "--defaults-extra-file")
+}
+
+func TestBackupUsesPrivateOptionFile(t *testing.T) {
+	argsFile, credCopy := installFakeMysqldump(t)
+
+	p := &Provider{Host: "db.example.com", Port: 3306, User: "backup", Password: testPassword}
+
+	var out bytes.Buffer
+	require.NoError(t, p.Backup(context.Background(), "app", &out, common.BackupOptions{}))
+	assert.Equal(t, "-- dump\n", out.String())
+
+	// The secret must not reach argv
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(args), testPassword)
+	require.True(t, strings.HasPrefix(string(args), "--defaults-extra-file="))
+
+	// mysqldump must have been able to read the password from the option file
+	cred, err := os.ReadFile(credCopy)
+	require.NoError(t, err)
+	assert.Contains(t, string(cred), testPassword)
+
+	// The option file is removed once the backup finishes
+	optionFile := strings.TrimPrefix(strings.Fields(string(args))[0], "--defaults-extra-file=")
+	_, err = os.Stat(filepath.Dir(optionFile))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCreateBackupCommandTLS(t *testing.T) {
+	p := &Provider{
+		Host: "db.example.com", Port: 3306, User: "backup", Password: testPassword,
+		TLS: config.DatabaseTLSConfig{Mode: config.TLSModeVerifyCA, CAFile: "/etc/tls/ca.pem"},
+	}
+
+	cmd := p.createBackupCommand("app", common.BackupOptions{}, config.MySQLDumpOptionsConfig{}, "/tmp/private/my.cnf")
+
+	// The option file stays first and the TLS flags are passed through
+	assert.Equal(t, "--defaults-extra-file=/tmp/private/my.cnf", cmd.Args[1])
+	assert.Contains(t, cmd.Args, "--ssl-mode=VERIFY_CA")
+	assert.Contains(t, cmd.Args, "--ssl-ca=/etc/tls/ca.pem")
+}
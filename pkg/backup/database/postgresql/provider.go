@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/supporttools/GoSQLGuard/pkg/config"
 	"github.com/supporttools/GoSQLGuard/pkg/database/common"
 )
 
@@ -23,6 +24,7 @@ type Provider struct {
 	Password  string
 	Databases []string
 	Schemas   []string
+	TLS       config.DatabaseTLSConfig
 
 	db *sql.DB
 }
@@ -35,23 +37,12 @@ func (p *Provider) Name() string {
 // Connect establishes a connection to the database server
 func (p *Provider) Connect(ctx context.Context) error {
 	// Use the 'postgres' database to connect initially
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=postgres sslmode=disable",
+	conninfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=postgres",
 		p.Host, p.Port, p.User, p.Password)
 
 	var err error
-	p.db, err = sql.Open("postgres", dsn)
-	if err != nil {
-		return fmt.Errorf("failed to open PostgreSQL connection: %w", err)
-	}
-
-	// Test the connection
-	err = p.db.PingContext(ctx)
-	if err != nil {
-		p.db.Close()
-		return fmt.Errorf("failed to ping PostgreSQL server: %w", err)
-	}
-
-	return nil
+	p.db, err = common.OpenPostgreSQL(ctx, conninfo, p.TLS)
+	return err
 }
 
 // Close closes the database connection
@@ -62,6 +53,35 @@ func (p *Provider) Close() error {
 	return nil
 }
 
+// ServerVersion returns the version reported by the PostgreSQL server
+func (p *Provider) ServerVersion(ctx context.Context) (string, error) {
+	if p.db == nil {
+		if err := p.Connect(ctx); err != nil {
+			p.db = nil
+			return "", err
+		}
+		defer func() {
+			p.db.Close()
+			p.db = nil
+		}()
+	}
+
+	var version string
+	if err := p.db.QueryRowContext(ctx, "SHOW server_version").Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to query PostgreSQL server version: %w", err)
+	}
+	return version, nil
+}
+
+// ToolVersion returns the version line printed by pg_dump
+func (p *Provider) ToolVersion(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "pg_dump", "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get pg_dump version: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 // ListDatabases returns a list of available databases
 func (p *Provider) ListDatabases(ctx context.Context) ([]string, error) {
 	if p.db == nil {
@@ -104,8 +124,19 @@ func (p *Provider) Backup(ctx context.Context, dbName string, output io.Writer,
 	cmd.Stdout = output
 	cmd.Stderr = os.Stderr
 
-	// Add environment variables for password authentication
-	cmd.Env = append(os.Environ(), fmt.Sprintf("PGPASSWORD=%s", p.Password))
+	// Apply this server's TLS settings
+	cmd.Env = append(os.Environ(), common.PostgreSQLTLSEnv(p.TLS)...)
+
+	// Point pg_dump at a private password file; PGPASSWORD would expose the
+	// secret through the process environment
+	if p.Password != "" {
+		credFile, err := common.NewCredentialFile("pgpass", common.PGPassFileContents(p.User, p.Password))
+		if err != nil {
+			return err
+		}
+		defer credFile.Remove()
+		cmd.Env = append(cmd.Env, "PGPASSFILE="+credFile.Path)
+	}
 
 	// Start the command
 	if err := cmd.Start(); err != nil {
@@ -125,6 +156,8 @@ func (p *Provider) Backup(ctx context.Context, dbName string, output io.Writer,
 		if cmd.Process != nil {
 			cmd.Process.Kill()
 		}
+		// Wait for the output to stop before the caller closes the writer
+		<-done
 		return ctx.Err()
 	case err := <-done:
 		// Command completed
@@ -147,7 +180,7 @@ func (p *Provider) createBackupCommand(dbName string, options common.BackupOptio
 		"--host", p.Host,
 		"--port", fmt.Sprintf("%d", p.Port),
 		"--username", p.User,
-		"--no-password", // Don't prompt for password; use PGPASSWORD env var
+		"--no-password", // Don't prompt for password; use PGPASSFILE
 	}
 
 	// Add schema-only option if requested
@@ -250,6 +283,7 @@ type Factory struct {
 	Password  string
 	Databases []string
 	SchemaStr string
+	TLS       config.DatabaseTLSConfig
 }
 
 // Create returns a new Provider instance
@@ -260,6 +294,7 @@ func (f *Factory) Create() (common.Provider, error) {
 		User:      f.User,
 		Password:  f.Password,
 		Databases: f.Databases,
+		TLS:       f.TLS,
 	}
 
 	// Parse schemas
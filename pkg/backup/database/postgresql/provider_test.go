@@ -0,0 +1,63 @@
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supporttools/GoSQLGuard/pkg/database/common"
+)
+
+const testPassword = "sup3r-s3cret-pa55"
+
+func TestBackupUsesPGPassFile(t *testing.T) {
+	// Fake pg_dump that records its argv, environment and password file
+	binDir := t.TempDir()
+	argsFile := filepath.Join(binDir, "args")
+	envFile := filepath.Join(binDir, "env")
+	credCopy := filepath.Join(binDir, "cred")
+	script := "#!/bin/sh\n" +
+		"echo \"$@\" > " + argsFile + "\n" +
+		"env > " + envFile + "\n" +
+		"cat \"$PGPASSFILE\" > " + credCopy + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "pg_dump"), []byte(script), 0700))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("PGPASSWORD", "")
+
+	p := &Provider{Host: "db.example.com", Port: 5432, User: "backup", Password: testPassword}
+
+	var out bytes.Buffer
+	require.NoError(t, p.Backup(context.Background(), "app", &out, common.BackupOptions{}))
+
+	// Neither argv nor the environment may carry the secret
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(args), testPassword)
+
+	env, err := os.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(env), testPassword)
+
+	// pg_dump reads it from the password file instead
+	cred, err := os.ReadFile(credCopy)
+	require.NoError(t, err)
+	assert.Equal(t, "*:*:*:backup:"+testPassword+"\n", string(cred))
+
+	// The password file is removed once the backup finishes
+	var passFile string
+	for _, line := range strings.Split(string(env), "\n") {
+		if strings.HasPrefix(line, "PGPASSFILE=") {
+			passFile = strings.TrimPrefix(line, "PGPASSFILE=")
+		}
+	}
+	require.NotEmpty(t, passFile)
+	_, err = os.Stat(passFile)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NotContains(t, p.BackupCommand("app", common.BackupOptions{}), testPassword)
+}
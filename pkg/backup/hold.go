@@ -0,0 +1,160 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+	"github.com/supporttools/GoSQLGuard/pkg/retention"
+)
+
+// systemActor is the actor recorded for changes GoSQLGuard makes by itself
+const systemActor = "gosqlguard"
+
+// ErrNothingToHold is returned when a hold is placed on a backup that was
+// deleted or whose files are gone from storage
+var ErrNothingToHold = errors.New("backup has no stored files to hold")
+
+// PlaceHold places a hold on a backup so retention keeps it until the hold
+// is released or expires; a zero expiresAt holds it indefinitely. Placing a
+// hold on a held backup replaces it. With S3 Object Lock legal hold enabled,
+// the backup's S3 objects are locked too. Deleted backups and backups whose
+// files are gone cannot be held.
+func (m *Manager) PlaceHold(id, actor, reason string, expiresAt time.Time) (types.BackupHold, error) {
+	backup, found := metadata.DefaultStore.GetBackupByID(id)
+	if !found {
+		return types.BackupHold{}, fmt.Errorf("backup with ID %s not found", id)
+	}
+	if err := m.checkStored(backup); err != nil {
+		return types.BackupHold{}, err
+	}
+
+	hold := types.BackupHold{
+		Reason:    reason,
+		Actor:     actor,
+		PlacedAt:  time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	if backup.Hold != nil {
+		hold.ObjectLock = backup.Hold.ObjectLock
+	}
+
+	if m.objectLockApplies(backup) && !hold.ObjectLock {
+		if err := m.s3Store.SetLegalHold(backup, true); err != nil {
+			return types.BackupHold{}, fmt.Errorf("failed to apply S3 legal hold: %w", err)
+		}
+		hold.ObjectLock = true
+	}
+
+	if err := metadata.DefaultStore.UpdateHold(id, &hold); err != nil {
+		return types.BackupHold{}, fmt.Errorf("failed to record hold: %w", err)
+	}
+
+	detail := reason
+	if !expiresAt.IsZero() {
+		detail += " (until " + expiresAt.Format(time.RFC3339) + ")"
+	}
+	m.audit(actor, types.AuditHoldPlaced, id, detail)
+	log.Printf("Backup %s held by %s: %s", id, actor, detail)
+	return hold, nil
+}
+
+// checkStored returns an error unless a copy of the backup is still in storage
+func (m *Manager) checkStored(backup types.BackupMeta) error {
+	if backup.Status == types.StatusDeleted {
+		return fmt.Errorf("%w: backup %s is deleted", ErrNothingToHold, backup.ID)
+	}
+
+	var checkErr error
+	for _, location := range []string{retention.LocationLocal, retention.LocationS3} {
+		if !liveAt(backup, location) {
+			continue
+		}
+		for _, path := range artifactPaths(backup, location) {
+			_, err := m.statArtifact(location, path)
+			if err == nil {
+				return nil
+			}
+			if !isNotExist(err) {
+				checkErr = err
+			}
+		}
+	}
+	if checkErr != nil {
+		return fmt.Errorf("failed to check the files of backup %s: %w", backup.ID, checkErr)
+	}
+	return fmt.Errorf("%w: backup %s is missing from storage", ErrNothingToHold, backup.ID)
+}
+
+// ReleaseHold releases the hold on a backup, lifting its S3 legal hold if
+// one was applied, so retention treats it like any other backup again
+func (m *Manager) ReleaseHold(id, actor, reason string) error {
+	return m.releaseHold(id, actor, types.AuditHoldReleased, reason)
+}
+
+// releaseHold releases a hold and records it in the audit log under action
+func (m *Manager) releaseHold(id, actor, action, reason string) error {
+	backup, found := metadata.DefaultStore.GetBackupByID(id)
+	if !found {
+		return fmt.Errorf("backup with ID %s not found", id)
+	}
+	if backup.Hold == nil {
+		return fmt.Errorf("backup %s is not held", id)
+	}
+
+	if backup.Hold.ObjectLock {
+		if m.s3Store == nil {
+			return fmt.Errorf("S3 legal hold of backup %s cannot be lifted without S3 storage", id)
+		}
+		if err := m.s3Store.SetLegalHold(backup, false); err != nil {
+			return fmt.Errorf("failed to lift S3 legal hold: %w", err)
+		}
+	}
+
+	if err := metadata.DefaultStore.UpdateHold(id, nil); err != nil {
+		return fmt.Errorf("failed to release hold: %w", err)
+	}
+
+	m.audit(actor, action, id, reason)
+	log.Printf("Hold on backup %s released by %s", id, actor)
+	return nil
+}
+
+// releaseExpiredHolds releases holds that have run out, so their S3 legal
+// holds are lifted before retention looks at the backups
+func (m *Manager) releaseExpiredHolds(now time.Time) {
+	for _, backup := range metadata.DefaultStore.GetBackups() {
+		if backup.Hold == nil || backup.Hold.Active(now) {
+			continue
+		}
+		reason := "expired " + backup.Hold.ExpiresAt.Format(time.RFC3339)
+		if err := m.releaseHold(backup.ID, systemActor, types.AuditHoldExpired, reason); err != nil {
+			log.Printf("Warning: Failed to release expired hold on backup %s: %v", backup.ID, err)
+		}
+	}
+}
+
+// objectLockApplies reports whether holds on the backup should also set an
+// S3 Object Lock legal hold
+func (m *Manager) objectLockApplies(backup types.BackupMeta) bool {
+	return m.cfg.S3.ObjectLockLegalHold && m.s3Store != nil &&
+		backup.S3DeletedAt.IsZero() && (len(backup.S3Keys) > 0 || backup.S3Key != "")
+}
+
+// audit records an action in the audit log, logging rather than failing when
+// it cannot be written
+func (m *Manager) audit(actor, action, backupID, detail string) {
+	entry := types.AuditEntry{
+		Time:     time.Now(),
+		Actor:    actor,
+		Action:   action,
+		BackupID: backupID,
+		Detail:   detail,
+	}
+	if err := metadata.DefaultStore.AddAuditEntry(entry); err != nil {
+		log.Printf("Warning: Failed to write audit entry %s for backup %s: %v", action, backupID, err)
+	}
+}
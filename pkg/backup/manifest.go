@@ -0,0 +1,124 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/database/common"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+	"github.com/supporttools/GoSQLGuard/pkg/version"
+)
+
+// manifestTimeout bounds the version queries made for a backup's manifest
+const manifestTimeout = 10 * time.Second
+
+// dumpTools names the dump program of each provider in manifests
+var dumpTools = map[string]string{
+	"mysql":      "mysqldump",
+	"postgresql": "pg_dump",
+}
+
+// describeBackup starts the manifest of a backup with the versions and dump
+// command in use. Versions that cannot be determined are left out; they never
+// fail the backup.
+func describeBackup(ctx context.Context, provider common.Provider, database string, opts common.BackupOptions) types.Manifest {
+	manifest := types.Manifest{
+		ManifestVersion: types.ManifestVersion,
+		ToolVersions:    map[string]string{"gosqlguard": version.Version},
+		DumpCommand:     provider.BackupCommand(database, opts),
+	}
+
+	reporter, ok := provider.(common.VersionReporter)
+	if !ok {
+		return manifest
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, manifestTimeout)
+	defer cancel()
+
+	if tool, err := reporter.ToolVersion(ctx); err != nil {
+		log.Printf("Warning: Failed to determine dump tool version for manifest: %v", err)
+	} else {
+		name := dumpTools[provider.Name()]
+		if name == "" {
+			name = provider.Name()
+		}
+		manifest.ToolVersions[name] = tool
+	}
+
+	if server, err := reporter.ServerVersion(ctx); err != nil {
+		log.Printf("Warning: Failed to determine server version for manifest: %v", err)
+	} else {
+		manifest.ServerVersion = server
+	}
+
+	return manifest
+}
+
+// fileChecksum returns the SHA-256 of a file in the form recorded in manifests
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeManifests stores the manifest of a backup next to each of its local
+// files and S3 objects, so its metadata can be rebuilt from storage alone.
+// Failures are logged; a backup without a manifest can still be recovered
+// from its path.
+func (m *Manager) writeManifests(id string, manifest types.Manifest, localPaths, s3Keys map[string]string, logFile *os.File) {
+	backup, ok := metadata.DefaultStore.GetBackupByID(id)
+	if !ok {
+		log.Printf("Warning: Backup %s not found, not writing its manifest", id)
+		return
+	}
+	manifest.Backup = backup
+	manifest.WrittenAt = time.Now()
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		log.Printf("Warning: Failed to encode manifest of backup %s: %v", id, err)
+		return
+	}
+
+	for _, path := range localPaths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		manifestPath := types.ManifestPath(path)
+		if err := os.WriteFile(manifestPath, data, 0640); err != nil {
+			log.Printf("Warning: Failed to write backup manifest %s: %v", manifestPath, err)
+			continue
+		}
+		if logFile != nil {
+			fmt.Fprintf(logFile, "Wrote manifest: %s\n", manifestPath)
+		}
+	}
+
+	for _, key := range s3Keys {
+		manifestKey := types.ManifestPath(key)
+		if err := m.s3Store.UploadBytes(context.Background(), manifestKey, data, "application/json"); err != nil {
+			log.Printf("Warning: Failed to upload backup manifest %s: %v", manifestKey, err)
+			continue
+		}
+		if logFile != nil {
+			fmt.Fprintf(logFile, "Uploaded manifest to S3: %s\n", manifestKey)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metrics"
+)
+
+// taskRetention is how long finished tasks are kept in the queue
+const taskRetention = 7 * 24 * time.Hour
+
+// TaskQueue hands database backups to worker processes instead of running
+// them in this process. Deferred tasks stay in the queue and are queued
+// again, ahead of the others, by the next run of their backup type.
+type TaskQueue interface {
+	Enqueue(tasks []dbmeta.BackupTask) (int, error)
+	RequeueStale(timeout time.Duration, maxAttempts int) (requeued, failed int, err error)
+	PurgeTasks(olderThan time.Duration) (int64, error)
+	Progress(backupType string, since time.Time) (pending, failed int, err error)
+}
+
+// SetTaskQueue makes backups queue one task per database for workers rather
+// than run here
+func (m *Manager) SetTaskQueue(queue TaskQueue) {
+	m.queue = queue
+}
+
+// HasTaskQueue reports whether backups are queued for workers
+func (m *Manager) HasTaskQueue() bool {
+	return m.queue != nil
+}
+
+// enqueueDatabases queues a task for each database, carrying the run window
+// deadline and fencing token of the run
+func (m *Manager) enqueueDatabases(serverName, serverType string, databases []string, backupType string, opts Options) {
+	tasks := make([]dbmeta.BackupTask, 0, len(databases))
+	for _, database := range databases {
+		task := dbmeta.BackupTask{
+			BackupType:   backupType,
+			ServerName:   serverName,
+			ServerType:   serverType,
+			DatabaseName: database,
+			FencingToken: opts.FencingToken,
+		}
+		if !opts.Deadline.IsZero() {
+			deadline := opts.Deadline
+			task.Deadline = &deadline
+		}
+		tasks = append(tasks, task)
+	}
+
+	added, err := m.queue.Enqueue(tasks)
+	if err != nil {
+		log.Printf("Failed to queue %s backups of server %s: %v", backupType, serverName, err)
+		return
+	}
+	metrics.TasksEnqueued.WithLabelValues(backupType).Add(float64(added))
+
+	log.Printf("Queued %d %s backup(s) of server %s for workers", added, backupType, serverName)
+	if waiting := len(tasks) - added; waiting > 0 {
+		log.Printf("%d %s backup(s) of server %s were still waiting in the queue", waiting, backupType, serverName)
+	}
+}
+
+// QueuedRunProgress reports whether the tasks queued for a backup type have
+// all finished, and how many failed since the run started
+func (m *Manager) QueuedRunProgress(backupType string, since time.Time) (finished bool, failed int, err error) {
+	pending, failed, err := m.queue.Progress(backupType, since)
+	if err != nil {
+		return false, 0, err
+	}
+	return pending == 0, failed, nil
+}
+
+// RecoverTasks requeues the tasks of workers that stopped sending heartbeats
+// and removes finished tasks older than a week
+func (m *Manager) RecoverTasks() {
+	if m.queue == nil {
+		return
+	}
+
+	timeout, err := time.ParseDuration(m.cfg.Workers.HeartbeatTimeout)
+	if err != nil {
+		log.Printf("Invalid worker heartbeat timeout %q: %v", m.cfg.Workers.HeartbeatTimeout, err)
+		return
+	}
+
+	requeued, failed, err := m.queue.RequeueStale(timeout, m.cfg.Workers.MaxAttempts)
+	if err != nil {
+		log.Printf("Failed to recover backup tasks: %v", err)
+	} else if requeued > 0 || failed > 0 {
+		log.Printf("Workers stopped responding: requeued %d backup task(s), failed %d", requeued, failed)
+		metrics.TasksRecovered.WithLabelValues("requeued").Add(float64(requeued))
+		metrics.TasksRecovered.WithLabelValues("failed").Add(float64(failed))
+	}
+
+	if purged, err := m.queue.PurgeTasks(taskRetention); err != nil {
+		log.Printf("Failed to purge finished backup tasks: %v", err)
+	} else if purged > 0 {
+		log.Printf("Purged %d finished backup task(s)", purged)
+	}
+}
+
+// RunTask runs a database backup claimed from the queue and returns the
+// status to record on the task, with the reason when it did not succeed.
+// started is called with the ID of the backup's metadata entry once it
+// exists. Backups stopped by a shutdown report the task as queued so it runs
+// again.
+func (m *Manager) RunTask(task dbmeta.BackupTask, started func(backupID string)) (string, error) {
+	typeConfig, exists := m.cfg.BackupTypes[task.BackupType]
+	if !exists {
+		return dbmeta.TaskStatusError, fmt.Errorf("no configuration found for backup type: %s", task.BackupType)
+	}
+
+	// A task that waited in the queue past its run window goes to the next
+	// run, which queues the deferred task again
+	if task.Deadline != nil && time.Now().After(*task.Deadline) {
+		reason := fmt.Sprintf("maximum run window ended at %s", task.Deadline.Format(time.RFC3339))
+		m.recordDeferral(task.ServerName, task.ServerType, task.DatabaseName, task.BackupType, reason)
+		return dbmeta.TaskStatusDeferred, errors.New(reason)
+	}
+
+	if !m.storageEnabled(typeConfig) {
+		return dbmeta.TaskStatusError, fmt.Errorf("backup type %s is not enabled for any storage destination", task.BackupType)
+	}
+
+	err := m.backupDatabase(task.ServerName, task.ServerType, task.DatabaseName, task.BackupType, typeConfig, task.FencingToken, started)
+	if errors.Is(err, ErrShuttingDown) {
+		return dbmeta.TaskStatusQueued, err
+	}
+	if err != nil {
+		return dbmeta.TaskStatusError, err
+	}
+	return dbmeta.TaskStatusSuccess, nil
+}
+
+// storageEnabled reports whether a backup type stores its backups anywhere
+func (m *Manager) storageEnabled(typeConfig config.BackupTypeConfig) bool {
+	localBackupEnabled := m.cfg.Local.Enabled && typeConfig.Local.Enabled && m.localStore != nil
+	s3BackupEnabled := m.cfg.S3.Enabled && typeConfig.S3.Enabled && m.s3Store != nil
+	return localBackupEnabled || s3BackupEnabled
+}
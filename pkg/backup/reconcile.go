@@ -0,0 +1,652 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+	"github.com/supporttools/GoSQLGuard/pkg/metrics"
+	"github.com/supporttools/GoSQLGuard/pkg/retention"
+	"github.com/supporttools/GoSQLGuard/pkg/storage/s3"
+)
+
+// Kinds of differences between storage and metadata
+const (
+	// FindingMissing is a file recorded in metadata that storage lacks
+	FindingMissing = "missing"
+	// FindingOrphan is a file in storage that no live backup references
+	FindingOrphan = "orphan"
+	// FindingSizeMismatch is a file whose size differs from the recorded size
+	FindingSizeMismatch = "sizeMismatch"
+)
+
+// Finding is one difference between storage and metadata
+type Finding struct {
+	Kind     string `json:"kind"`
+	Location string `json:"location"` // local or s3
+	Path     string `json:"path"`     // File path or S3 key
+
+	// BackupID and Organization name the backup entry that records the
+	// path. Orphans only have one when the entry is marked deleted.
+	BackupID     string `json:"backupId,omitempty"`
+	Organization string `json:"organization,omitempty"`
+
+	Size         int64     `json:"size"`                   // Size in storage
+	RecordedSize int64     `json:"recordedSize,omitempty"` // Size recorded in metadata
+	ModTime      time.Time `json:"modTime,omitempty"`
+}
+
+// ReconcileReport is the outcome of comparing storage with metadata
+type ReconcileReport struct {
+	StartedAt   time.Time      `json:"startedAt"`
+	CompletedAt time.Time      `json:"completedAt"`
+	Scanned     map[string]int `json:"scanned"` // Backup files found, by location
+	Findings    []Finding      `json:"findings"`
+	Errors      []string       `json:"errors,omitempty"`
+}
+
+// Count returns the number of findings of a kind in a location
+func (r ReconcileReport) Count(kind, location string) int {
+	count := 0
+	for _, f := range r.Findings {
+		if f.Kind == kind && f.Location == location {
+			count++
+		}
+	}
+	return count
+}
+
+// storedFile is a backup file found in either storage location
+type storedFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Reconcile lists every backup file in local storage and S3 and compares them
+// with the paths and keys recorded in metadata. Files newer than the orphan
+// grace period are not reported as orphans, since they may belong to backups
+// still being written. The report is kept for LastReconcileReport.
+func (m *Manager) Reconcile() ReconcileReport {
+	report := ReconcileReport{StartedAt: time.Now(), Scanned: make(map[string]int)}
+	cutoff := report.StartedAt.Add(-m.orphanGracePeriod())
+	backups := metadata.DefaultStore.GetBackups()
+
+	if m.cfg.Local.Enabled && m.localStore != nil {
+		files, err := m.localStore.ListBackupFiles()
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		} else {
+			stored := make([]storedFile, 0, len(files))
+			for _, file := range files {
+				stored = append(stored, storedFile{Path: filepath.Clean(file.Path), Size: file.Size, ModTime: file.ModTime})
+			}
+			report.compare(backups, retention.LocationLocal, stored, cutoff, true)
+		}
+	}
+
+	if m.cfg.S3.Enabled && m.s3Store != nil {
+		objects, err := m.s3Store.ListBackupObjects()
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		} else {
+			stored := make([]storedFile, 0, len(objects))
+			for _, obj := range objects {
+				stored = append(stored, storedFile{Path: obj.Key, Size: obj.Size, ModTime: obj.LastModified})
+			}
+
+			// Without a prefix the listing covers the whole bucket, where
+			// unrecorded keys may belong to anything
+			orphans := s3Prefix(m.cfg.S3.Prefix) != ""
+			if !orphans {
+				report.Errors = append(report.Errors, "S3 prefix is not set, so the whole bucket was listed and no S3 orphans are reported")
+			}
+			report.compare(backups, retention.LocationS3, stored, cutoff, orphans)
+		}
+	}
+
+	sort.Slice(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return a.Path < b.Path
+	})
+	report.CompletedAt = time.Now()
+
+	m.reconcileMu.Lock()
+	m.lastReconcile = &report
+	m.reconcileMu.Unlock()
+	return report
+}
+
+// LastReconcileReport returns the report of the last reconciliation, if any
+// has run since startup
+func (m *Manager) LastReconcileReport() (ReconcileReport, bool) {
+	m.reconcileMu.Lock()
+	defer m.reconcileMu.Unlock()
+
+	if m.lastReconcile == nil {
+		return ReconcileReport{}, false
+	}
+	return *m.lastReconcile, true
+}
+
+// compare adds the findings of one storage location to the report. Orphans
+// are only classified when orphans is set.
+func (r *ReconcileReport) compare(backups []types.BackupMeta, location string, stored []storedFile, cutoff time.Time, orphans bool) {
+	r.Scanned[location] = len(stored)
+
+	byPath := make(map[string]storedFile, len(stored))
+	for _, file := range stored {
+		byPath[file.Path] = file
+	}
+
+	// Paths of live backups are never orphans; those of deleted backups
+	// are, and the orphan names the deleted backup
+	live := make(map[string]bool)
+	deleted := make(map[string]Finding)
+	for _, backup := range backups {
+		isLive := liveAt(backup, location)
+		for org, path := range artifactPaths(backup, location) {
+			if !isLive {
+				deleted[path] = Finding{BackupID: backup.ID, Organization: org}
+				continue
+			}
+			live[path] = true
+
+			if !hasArtifacts(backup, location) {
+				continue
+			}
+			finding := Finding{
+				Location:     location,
+				Path:         path,
+				BackupID:     backup.ID,
+				Organization: org,
+				RecordedSize: backup.Size,
+			}
+			file, found := byPath[path]
+			switch {
+			case !found:
+				finding.Kind = FindingMissing
+			case backup.Size > 0 && file.Size != backup.Size:
+				finding.Kind = FindingSizeMismatch
+				finding.Size = file.Size
+				finding.ModTime = file.ModTime
+			default:
+				continue
+			}
+			r.Findings = append(r.Findings, finding)
+		}
+	}
+
+	if !orphans {
+		return
+	}
+	for _, file := range stored {
+		if live[file.Path] || file.ModTime.After(cutoff) {
+			continue
+		}
+		finding := deleted[file.Path]
+		finding.Kind = FindingOrphan
+		finding.Location = location
+		finding.Path = file.Path
+		finding.Size = file.Size
+		finding.ModTime = file.ModTime
+		r.Findings = append(r.Findings, finding)
+	}
+}
+
+// artifactPaths returns the paths or keys a backup records in a location by
+// organization, falling back to the legacy single path
+func artifactPaths(backup types.BackupMeta, location string) map[string]string {
+	recorded, legacy := backup.LocalPaths, backup.LocalPath
+	if location == retention.LocationS3 {
+		recorded, legacy = backup.S3Keys, backup.S3Key
+	}
+
+	paths := make(map[string]string, len(recorded)+1)
+	for org, path := range recorded {
+		paths[org] = cleanArtifactPath(location, path)
+	}
+	if len(paths) == 0 && legacy != "" {
+		legacy = cleanArtifactPath(location, legacy)
+		paths[layoutOf(legacy)] = legacy
+	}
+	return paths
+}
+
+// cleanArtifactPath normalizes local paths so they compare equal to listed
+// ones; S3 keys are compared as they are
+func cleanArtifactPath(location, path string) string {
+	if location == retention.LocationLocal {
+		return filepath.Clean(path)
+	}
+	return path
+}
+
+// liveAt reports whether metadata still counts on a backup's copies in a
+// location, so its files there are not orphans. Files of held backups are
+// kept even when the backup was marked deleted.
+func liveAt(backup types.BackupMeta, location string) bool {
+	if backup.IsHeld(time.Now()) {
+		return true
+	}
+	if backup.Status == types.StatusDeleted {
+		return false
+	}
+	if location == retention.LocationS3 {
+		return backup.S3DeletedAt.IsZero()
+	}
+	return backup.LocalDeletedAt.IsZero()
+}
+
+// hasArtifacts reports whether a backup's files in a location should exist,
+// so their absence is reported
+func hasArtifacts(backup types.BackupMeta, location string) bool {
+	if location == retention.LocationS3 {
+		return backup.S3UploadStatus == types.StatusSuccess && backup.S3DeletedAt.IsZero()
+	}
+	return backup.Status == types.StatusSuccess && backup.LocalDeletedAt.IsZero()
+}
+
+// orphanGracePeriod returns how old an unreferenced file must be to count as
+// an orphan
+func (m *Manager) orphanGracePeriod() time.Duration {
+	if m.cfg.Retention.OrphanGracePeriod != "" {
+		if d, err := time.ParseDuration(m.cfg.Retention.OrphanGracePeriod); err == nil {
+			return d
+		}
+	}
+	return 24 * time.Hour
+}
+
+// reconcileOrphans reconciles storage after retention, records the findings
+// in metrics, and deletes orphans when configured to
+func (m *Manager) reconcileOrphans() {
+	report := m.Reconcile()
+	for _, message := range report.Errors {
+		log.Printf("Error scanning storage for orphaned backups: %s", message)
+	}
+
+	for location := range report.Scanned {
+		orphans := 0
+		for _, finding := range report.Findings {
+			if finding.Kind != FindingOrphan || finding.Location != location {
+				continue
+			}
+			if m.removeOrphan(finding) {
+				continue
+			}
+			orphans++
+		}
+		metrics.OrphanedBackupFiles.WithLabelValues(location).Set(float64(orphans))
+		metrics.MissingBackupFiles.WithLabelValues(location).Set(float64(report.Count(FindingMissing, location)))
+		metrics.BackupSizeMismatches.WithLabelValues(location).Set(float64(report.Count(FindingSizeMismatch, location)))
+	}
+}
+
+// removeOrphan deletes an orphaned file when orphan deletion is enabled and
+// reports whether it was removed
+func (m *Manager) removeOrphan(orphan Finding) bool {
+	if !m.cfg.Retention.DeleteOrphans {
+		log.Printf("Found orphaned %s backup with no metadata: %s", orphan.Location, orphan.Path)
+		return false
+	}
+
+	if err := m.DeleteOrphan(orphan.Location, orphan.Path, systemActor); err != nil {
+		log.Printf("Failed to remove orphaned %s backup %s: %v", orphan.Location, orphan.Path, err)
+		return false
+	}
+	return true
+}
+
+// ImportOrphan adds an orphaned file to metadata. The backup is taken from the
+// manifest stored next to the file or, without one, from a backup recorded
+// under the same relative path in the other location, such as the local copy
+// of an S3 upload that failed halfway. A backup that is already recorded,
+// deleted or not, gets the file added back.
+func (m *Manager) ImportOrphan(location, path, actor string) (types.BackupMeta, error) {
+	file, err := m.statArtifact(location, path)
+	if err != nil {
+		return types.BackupMeta{}, err
+	}
+	backups := metadata.DefaultStore.GetBackups()
+	if owner, ok := liveOwner(backups, location, file.Path); ok {
+		return types.BackupMeta{}, fmt.Errorf("%s is recorded for backup %s and is not an orphan", file.Path, owner)
+	}
+
+	backup, org, err := m.orphanBackup(backups, location, file.Path)
+	if err != nil {
+		return types.BackupMeta{}, err
+	}
+
+	switch location {
+	case retention.LocationLocal:
+		backup.LocalPaths = withPath(backup.LocalPaths, org, file.Path)
+		if backup.LocalPath == "" {
+			backup.LocalPath = file.Path
+		}
+		backup.LocalDeletedAt = time.Time{}
+	case retention.LocationS3:
+		backup.S3Keys = withPath(backup.S3Keys, org, file.Path)
+		if backup.S3Key == "" {
+			backup.S3Key = file.Path
+		}
+		backup.S3DeletedAt = time.Time{}
+		backup.S3UploadStatus = types.StatusSuccess
+		backup.S3UploadError = ""
+		if backup.S3UploadComplete.IsZero() {
+			backup.S3UploadComplete = file.ModTime
+		}
+	}
+	if backup.Status != types.StatusSuccess {
+		backup.Status = types.StatusSuccess
+		backup.ErrorMessage = ""
+	}
+	if backup.Size == 0 {
+		backup.Size = file.Size
+	}
+
+	if err := metadata.DefaultStore.PutBackup(backup); err != nil {
+		return types.BackupMeta{}, fmt.Errorf("failed to record backup %s: %w", backup.ID, err)
+	}
+
+	m.audit(actor, types.AuditOrphanImported, backup.ID, location+": "+file.Path)
+	m.forgetFinding(FindingOrphan, location, file.Path)
+	log.Printf("Imported orphaned %s backup %s as backup %s", location, file.Path, backup.ID)
+	return backup, nil
+}
+
+// orphanBackup returns the backup entry an orphan is imported into, and the
+// organization to record its path under
+func (m *Manager) orphanBackup(backups []types.BackupMeta, location, path string) (types.BackupMeta, string, error) {
+	if manifest, err := m.readManifest(location, path); err == nil {
+		org := organizationOf(artifactPaths(manifest.Backup, location), path)
+		for _, backup := range backups {
+			if backup.ID == manifest.Backup.ID {
+				return backup, org, nil
+			}
+		}
+
+		// Only the copy that was found is recorded; others are
+		// imported when they are found too
+		backup := manifest.Backup
+		backup.LocalPaths, backup.LocalPath = nil, ""
+		backup.S3Keys, backup.S3Key = nil, ""
+		backup.LocalDeletedAt, backup.S3DeletedAt = time.Time{}, time.Time{}
+		backup.Hold = nil
+		return backup, org, nil
+	}
+
+	rel := m.relativePath(location, path)
+	for _, backup := range backups {
+		for _, other := range []string{retention.LocationLocal, retention.LocationS3} {
+			for org, recorded := range artifactPaths(backup, other) {
+				if m.relativePath(other, recorded) == rel {
+					return backup, org, nil
+				}
+			}
+		}
+	}
+
+	return types.BackupMeta{}, "", fmt.Errorf("%s has no manifest and no backup records the same path; use metadata-recovery to rebuild it", path)
+}
+
+// DeleteOrphan removes an orphaned file and its manifest from storage. If a
+// deleted backup still records the path, the path is dropped from it. Files
+// recorded for a held backup are never removed, nor are S3 keys when no
+// prefix confines backups to part of the bucket.
+func (m *Manager) DeleteOrphan(location, path, actor string) error {
+	if location == retention.LocationS3 && s3Prefix(m.cfg.S3.Prefix) == "" {
+		return fmt.Errorf("S3 prefix is not set; refusing to delete keys that may not be backups")
+	}
+	file, err := m.statArtifact(location, path)
+	if err != nil {
+		return err
+	}
+	backups := metadata.DefaultStore.GetBackups()
+	if owner, ok := heldOwner(backups, location, file.Path); ok {
+		return fmt.Errorf("%s is recorded for backup %s, which is on hold", file.Path, owner)
+	}
+	if owner, ok := liveOwner(backups, location, file.Path); ok {
+		return fmt.Errorf("%s is recorded for backup %s and is not an orphan", file.Path, owner)
+	}
+
+	switch location {
+	case retention.LocationLocal:
+		err = m.localStore.DeleteFile(file.Path)
+	case retention.LocationS3:
+		if err = m.s3Store.DeleteObject(file.Path); err == nil {
+			err = m.s3Store.DeleteObject(types.ManifestPath(file.Path))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	backupID := ""
+	for _, backup := range backups {
+		paths := artifactPaths(backup, location)
+		if org := organizationOf(paths, file.Path); paths[org] == file.Path {
+			backupID = backup.ID
+			delete(paths, org)
+			if err := metadata.DefaultStore.UpdateRetentionStatus(backup.ID, location, paths, ""); err != nil {
+				log.Printf("Warning: Failed to drop %s from deleted backup %s: %v", file.Path, backup.ID, err)
+			}
+		}
+	}
+
+	m.audit(actor, types.AuditOrphanDeleted, backupID, location+": "+file.Path)
+	m.forgetFinding(FindingOrphan, location, file.Path)
+	log.Printf("Removed orphaned %s backup with no metadata: %s", location, file.Path)
+	return nil
+}
+
+// MarkMissing drops a file that storage no longer holds from a backup's
+// metadata. Once no copy remains in the location it is marked deleted there,
+// and the backup is marked deleted when no location holds a copy.
+func (m *Manager) MarkMissing(id, location, path, actor string) error {
+	backup, found := metadata.DefaultStore.GetBackupByID(id)
+	if !found {
+		return fmt.Errorf("backup with ID %s not found", id)
+	}
+
+	path = cleanArtifactPath(location, path)
+	paths := artifactPaths(backup, location)
+	org := organizationOf(paths, path)
+	if paths[org] != path {
+		return fmt.Errorf("backup %s does not record %s in %s storage", id, path, location)
+	}
+
+	if _, err := m.statArtifact(location, path); err == nil {
+		return fmt.Errorf("%s still exists in %s storage", path, location)
+	} else if !isNotExist(err) {
+		return err
+	}
+
+	delete(paths, org)
+	if err := metadata.DefaultStore.UpdateRetentionStatus(id, location, paths, ""); err != nil {
+		return fmt.Errorf("failed to update backup %s: %w", id, err)
+	}
+
+	m.audit(actor, types.AuditMarkedMissing, id, location+": "+path)
+	m.forgetFinding(FindingMissing, location, path)
+	log.Printf("Marked %s backup %s of backup %s as missing", location, path, id)
+	return nil
+}
+
+// statArtifact returns a backup file in a location, refusing paths outside
+// the backup directory or S3 prefix and files that are not backups
+func (m *Manager) statArtifact(location, path string) (storedFile, error) {
+	if !strings.HasSuffix(path, ".sql.gz") {
+		return storedFile{}, fmt.Errorf("%s is not a backup file", path)
+	}
+
+	switch location {
+	case retention.LocationLocal:
+		if !m.cfg.Local.Enabled || m.localStore == nil {
+			return storedFile{}, fmt.Errorf("local storage is not enabled")
+		}
+		path = filepath.Clean(path)
+		if rel := m.relativePath(location, path); rel == "" || strings.HasPrefix(rel, "../") {
+			return storedFile{}, fmt.Errorf("%s is outside the backup directory", path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return storedFile{}, err
+		}
+		return storedFile{Path: path, Size: info.Size(), ModTime: info.ModTime()}, nil
+	case retention.LocationS3:
+		if !m.cfg.S3.Enabled || m.s3Store == nil {
+			return storedFile{}, fmt.Errorf("S3 storage is not enabled")
+		}
+		if prefix := s3Prefix(m.cfg.S3.Prefix); !strings.HasPrefix(path, prefix) {
+			return storedFile{}, fmt.Errorf("%s is outside the S3 prefix", path)
+		}
+		obj, err := m.s3Store.StatObject(path)
+		if err != nil {
+			return storedFile{}, err
+		}
+		return storedFile{Path: obj.Key, Size: obj.Size, ModTime: obj.LastModified}, nil
+	default:
+		return storedFile{}, fmt.Errorf("unknown storage location %q", location)
+	}
+}
+
+// readManifest reads the manifest stored next to a backup file
+func (m *Manager) readManifest(location, path string) (types.Manifest, error) {
+	var data []byte
+	var err error
+	if location == retention.LocationS3 {
+		data, err = m.s3Store.ReadObject(types.ManifestPath(path))
+	} else {
+		data, err = os.ReadFile(types.ManifestPath(path))
+	}
+	if err != nil {
+		return types.Manifest{}, err
+	}
+
+	var manifest types.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return types.Manifest{}, fmt.Errorf("invalid manifest for %s: %w", path, err)
+	}
+	if manifest.Backup.ID == "" {
+		return types.Manifest{}, fmt.Errorf("manifest for %s has no backup ID", path)
+	}
+	return manifest, nil
+}
+
+// relativePath returns a path relative to the backup directory, or a key
+// relative to the S3 prefix, with forward slashes
+func (m *Manager) relativePath(location, path string) string {
+	if location == retention.LocationS3 {
+		return strings.TrimPrefix(path, s3Prefix(m.cfg.S3.Prefix))
+	}
+	rel, err := filepath.Rel(m.cfg.Local.BackupDirectory, path)
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// s3Prefix returns the configured S3 prefix as a key prefix
+func s3Prefix(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	return strings.TrimSuffix(prefix, "/") + "/"
+}
+
+// forgetFinding drops a finding that has been acted on from the last report
+func (m *Manager) forgetFinding(kind, location, path string) {
+	m.reconcileMu.Lock()
+	defer m.reconcileMu.Unlock()
+
+	if m.lastReconcile == nil {
+		return
+	}
+	findings := m.lastReconcile.Findings[:0:0]
+	for _, f := range m.lastReconcile.Findings {
+		if f.Kind != kind || f.Location != location || f.Path != path {
+			findings = append(findings, f)
+		}
+	}
+	m.lastReconcile.Findings = findings
+}
+
+// liveOwner returns the live backup that records a path, if any
+func liveOwner(backups []types.BackupMeta, location, path string) (string, bool) {
+	for _, backup := range backups {
+		if !liveAt(backup, location) {
+			continue
+		}
+		for _, recorded := range artifactPaths(backup, location) {
+			if recorded == path {
+				return backup.ID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// heldOwner returns the held backup that records a path, if any, whatever
+// its status
+func heldOwner(backups []types.BackupMeta, location, path string) (string, bool) {
+	now := time.Now()
+	for _, backup := range backups {
+		if !backup.IsHeld(now) {
+			continue
+		}
+		for _, recorded := range artifactPaths(backup, location) {
+			if recorded == path {
+				return backup.ID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// organizationOf returns the organization a path is recorded under, or the
+// layout named in the path when it is not recorded
+func organizationOf(paths map[string]string, path string) string {
+	for org, recorded := range paths {
+		if recorded == path {
+			return org
+		}
+	}
+	return layoutOf(path)
+}
+
+// layoutOf returns the organization layout a path was written in
+func layoutOf(path string) string {
+	if strings.Contains(filepath.ToSlash(path), "by-type/") {
+		return "by-type"
+	}
+	return "by-server"
+}
+
+// withPath returns paths with one more path recorded under org
+func withPath(paths map[string]string, org, path string) map[string]string {
+	updated := make(map[string]string, len(paths)+1)
+	for k, v := range paths {
+		updated[k] = v
+	}
+	updated[org] = path
+	return updated
+}
+
+// isNotExist reports whether an error means a file or object does not exist
+func isNotExist(err error) bool {
+	return os.IsNotExist(err) || s3.IsNotFound(err)
+}
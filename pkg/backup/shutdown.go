@@ -0,0 +1,178 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+// ErrShuttingDown is returned for backups that were not started, or were cut
+// short, because the manager is shutting down
+var ErrShuttingDown = errors.New("backup manager is shutting down")
+
+// cancelTimeout bounds how long cancelled backups may take to clean up
+const cancelTimeout = 30 * time.Second
+
+// Messages recorded on backups interrupted by a shutdown
+const (
+	interruptedMessage      = "interrupted: GoSQLGuard shut down before the backup finished"
+	staleInterruptedMessage = "interrupted: GoSQLGuard stopped before the backup finished"
+)
+
+// lifecycle tracks the database backups running in a manager so a shutdown
+// can wait for them, and cancel those still running after the grace period
+type lifecycle struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	running  int
+	draining bool
+	idle     chan struct{} // Closed once no backup is running during a shutdown
+}
+
+// context returns the context backups run under; the caller holds mu
+func (l *lifecycle) context() context.Context {
+	if l.ctx == nil {
+		l.ctx, l.cancel = context.WithCancel(context.Background())
+	}
+	return l.ctx
+}
+
+// begin registers a database backup, returning the context to run it under,
+// or false when the manager is shutting down
+func (m *Manager) begin() (context.Context, bool) {
+	m.life.mu.Lock()
+	defer m.life.mu.Unlock()
+
+	if m.life.draining {
+		return nil, false
+	}
+	m.life.running++
+	return m.life.context(), true
+}
+
+// end unregisters a database backup started with begin
+func (m *Manager) end() {
+	m.life.mu.Lock()
+	defer m.life.mu.Unlock()
+
+	m.life.running--
+	if m.life.running == 0 && m.life.idle != nil {
+		close(m.life.idle)
+		m.life.idle = nil
+	}
+}
+
+// ShuttingDown reports whether Shutdown has been called
+func (m *Manager) ShuttingDown() bool {
+	m.life.mu.Lock()
+	defer m.life.mu.Unlock()
+	return m.life.draining
+}
+
+// Shutdown stops new database backups from starting and waits up to grace
+// for the running ones to finish. Backups still running after that are
+// cancelled, their partial files removed and their metadata marked
+// interrupted. It returns an error when backups had to be cancelled.
+func (m *Manager) Shutdown(grace time.Duration) error {
+	m.life.mu.Lock()
+	m.life.draining = true
+	running := m.life.running
+	if running > 0 && m.life.idle == nil {
+		m.life.idle = make(chan struct{})
+	}
+	idle := m.life.idle
+	m.life.context()
+	cancel := m.life.cancel
+	m.life.mu.Unlock()
+
+	if running == 0 {
+		return nil
+	}
+
+	log.Printf("Waiting up to %s for %d running backup(s) to finish", grace, running)
+	select {
+	case <-idle:
+		log.Println("Running backups finished")
+		return nil
+	case <-time.After(grace):
+	}
+
+	log.Printf("Backups still running after %s, cancelling them", grace)
+	cancel()
+	select {
+	case <-idle:
+		return fmt.Errorf("%w: running backups were cancelled after the %s grace period", ErrShuttingDown, grace)
+	case <-time.After(cancelTimeout):
+		return fmt.Errorf("%w: cancelled backups did not stop within %s", ErrShuttingDown, cancelTimeout)
+	}
+}
+
+// interrupted records a backup cut short by a shutdown and removes the files
+// it had written, so no partial dump is mistaken for a complete one
+func (m *Manager) interrupted(id string, logFile *os.File, paths ...string) error {
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Warning: Failed to remove partial backup file %s: %v", path, err)
+		}
+	}
+	if logFile != nil {
+		fmt.Fprintf(logFile, "INTERRUPTED: %s\n", interruptedMessage)
+	}
+	if err := metadata.DefaultStore.UpdateBackupStatus(id, types.StatusInterrupted, map[string]string{}, 0, interruptedMessage); err != nil {
+		log.Printf("Warning: Failed to mark backup %s as interrupted: %v", id, err)
+	}
+	return fmt.Errorf("%w: backup %s interrupted", ErrShuttingDown, id)
+}
+
+// interruptedUpload records S3 uploads cut short by a shutdown, deleting the
+// copies already uploaded. Without a local copy the backup itself is marked
+// interrupted.
+func (m *Manager) interruptedUpload(id string, logFile *os.File, uploaded []string, localCopy bool) error {
+	for _, key := range uploaded {
+		if err := m.s3Store.DeleteObject(key); err != nil {
+			log.Printf("Warning: Failed to remove S3 copy %s of interrupted backup: %v", key, err)
+		}
+	}
+	if logFile != nil {
+		fmt.Fprintf(logFile, "INTERRUPTED: S3 upload %s\n", interruptedMessage)
+	}
+	if err := metadata.DefaultStore.UpdateS3UploadStatus(id, types.StatusInterrupted, map[string]string{}, interruptedMessage); err != nil {
+		log.Printf("Warning: Failed to mark S3 upload of backup %s as interrupted: %v", id, err)
+	}
+	if !localCopy {
+		if err := metadata.DefaultStore.UpdateBackupStatus(id, types.StatusInterrupted, map[string]string{}, 0, interruptedMessage); err != nil {
+			log.Printf("Warning: Failed to mark backup %s as interrupted: %v", id, err)
+		}
+	}
+	return fmt.Errorf("%w: S3 upload of backup %s interrupted", ErrShuttingDown, id)
+}
+
+// ReconcileInterrupted marks backups still pending from before startedBefore
+// as interrupted. Such entries were left by a process that stopped without a
+// graceful shutdown; backups created by this process are never touched.
+func (m *Manager) ReconcileInterrupted(startedBefore time.Time) int {
+	reconciled := 0
+	for _, b := range metadata.DefaultStore.GetBackups() {
+		if b.Status != types.StatusPending || !b.CreatedAt.Before(startedBefore) {
+			continue
+		}
+		if err := metadata.DefaultStore.UpdateBackupStatus(b.ID, types.StatusInterrupted, map[string]string{}, 0, staleInterruptedMessage); err != nil {
+			log.Printf("Warning: Failed to mark stale backup %s as interrupted: %v", b.ID, err)
+			continue
+		}
+		reconciled++
+	}
+
+	if reconciled > 0 {
+		log.Printf("Marked %d backup(s) left pending by an earlier run as interrupted", reconciled)
+	}
+	return reconciled
+}
@@ -0,0 +1,68 @@
+package backup
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+	"github.com/supporttools/GoSQLGuard/pkg/stats"
+)
+
+// statsBackfillDays is how far back the stats history is rebuilt from the
+// backups in the catalog for days without snapshots
+const statsBackfillDays = 90
+
+// RecordStatsHistory records the daily stats snapshots of every database.
+// Today and yesterday are recomputed, as backups may still finish or be
+// deleted; earlier days are only filled in when they have no snapshots, so
+// the history keeps what retention has since purged from the catalog.
+func (m *Manager) RecordStatsHistory(now time.Time) error {
+	today := types.StatsDay(now)
+	backups := metadata.DefaultStore.GetBackups()
+
+	first := today.AddDate(0, 0, -statsBackfillDays)
+	earliest := today
+	for _, backup := range backups {
+		if day := types.StatsDay(backup.CreatedAt); !backup.CreatedAt.IsZero() && day.Before(earliest) {
+			earliest = day
+		}
+	}
+	if earliest.After(first) {
+		first = earliest
+	}
+
+	recorded, err := metadata.DefaultStore.GetStatsHistory(types.StatsHistoryQuery{From: first})
+	if err != nil {
+		return fmt.Errorf("failed to read stats history: %w", err)
+	}
+	recordedDays := make(map[int64]bool)
+	for _, snapshot := range recorded {
+		recordedDays[snapshot.Day.Unix()] = true
+	}
+
+	var snapshots []types.StatsSnapshot
+	yesterday := today.AddDate(0, 0, -1)
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		if recordedDays[day.Unix()] && day.Before(yesterday) {
+			continue
+		}
+		snapshots = append(snapshots, stats.Snapshots(backups, day)...)
+	}
+	if len(snapshots) == 0 {
+		return nil
+	}
+	if err := metadata.DefaultStore.SaveStatsSnapshots(snapshots); err != nil {
+		return fmt.Errorf("failed to save stats history: %w", err)
+	}
+	return nil
+}
+
+// recordStatsHistory records the stats history after retention, logging
+// rather than failing the run when it cannot
+func (m *Manager) recordStatsHistory() {
+	if err := m.RecordStatsHistory(time.Now()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
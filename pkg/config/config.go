@@ -5,9 +5,15 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/robfig/cron/v3"
 )
 
 // MySQLConfig defines MySQL connection settings
@@ -22,11 +28,12 @@ type MySQLConfig struct {
 
 // PostgreSQLConfig defines PostgreSQL connection settings
 type PostgreSQLConfig struct {
-	Host      string   `yaml:"host"`
-	Port      string   `yaml:"port"`
-	Username  string   `yaml:"username"`
-	Password  string   `yaml:"password"`
-	Databases []string `yaml:"databases"`
+	Host      string            `yaml:"host"`
+	Port      string            `yaml:"port"`
+	Username  string            `yaml:"username"`
+	Password  string            `yaml:"password"`
+	Databases []string          `yaml:"databases"`
+	TLS       DatabaseTLSConfig `yaml:"tls,omitempty"`
 }
 
 // MySQLDumpOptionsConfig is a placeholder to maintain compatibility
@@ -66,6 +73,44 @@ type PostgreSQLDumpOptionsConfig struct {
 	CustomOptions       []string `yaml:"customOptions"`
 }
 
+// TLS modes for connections to database servers
+const (
+	TLSModeDisable    = "disable"     // Never use TLS
+	TLSModePrefer     = "prefer"      // Encrypt when the server supports it
+	TLSModeRequire    = "require"     // Encrypt without verifying the server certificate
+	TLSModeVerifyCA   = "verify-ca"   // Encrypt and verify the certificate chain
+	TLSModeVerifyFull = "verify-full" // Encrypt and verify the chain and host name
+)
+
+// DatabaseTLSConfig defines TLS settings for connections to a database server.
+// An empty Mode leaves MySQL clients at their defaults and means prefer for
+// PostgreSQL.
+type DatabaseTLSConfig struct {
+	Mode     string `yaml:"mode"`     // disable, prefer, require, verify-ca, verify-full
+	CAFile   string `yaml:"caFile"`   // CA bundle used to verify the server certificate
+	CertFile string `yaml:"certFile"` // Client certificate for mutual TLS
+	KeyFile  string `yaml:"keyFile"`  // Client private key for mutual TLS
+}
+
+// Validate checks that the TLS settings are consistent
+func (t DatabaseTLSConfig) Validate() error {
+	switch t.Mode {
+	case "", TLSModeDisable, TLSModePrefer, TLSModeRequire, TLSModeVerifyCA, TLSModeVerifyFull:
+	default:
+		return fmt.Errorf("invalid TLS mode %q (must be disable, prefer, require, verify-ca or verify-full)", t.Mode)
+	}
+
+	if t.Mode == TLSModeVerifyCA && t.CAFile == "" {
+		return fmt.Errorf("TLS mode verify-ca requires a CA file")
+	}
+
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("TLS client certificate and key must be specified together")
+	}
+
+	return nil
+}
+
 // DatabaseServerConfig defines configuration for a single database server
 type DatabaseServerConfig struct {
 	Name                  string                      `yaml:"name"`
@@ -79,6 +124,64 @@ type DatabaseServerConfig struct {
 	ExcludeDatabases      []string                    `yaml:"excludeDatabases"`
 	MySQLDumpOptions      MySQLDumpOptionsConfig      `yaml:"mysqlDumpOptions,omitempty"`
 	PostgreSQLDumpOptions PostgreSQLDumpOptionsConfig `yaml:"postgresqlDumpOptions,omitempty"`
+	TLS                   DatabaseTLSConfig           `yaml:"tls,omitempty"`
+	Labels                map[string]string           `yaml:"labels,omitempty"` // Used by schedule target selectors
+	DatabaseLabels        []DatabaseLabelRule         `yaml:"databaseLabels,omitempty"`
+}
+
+// DatabaseLabelRule adds labels to the databases of a server whose names
+// match one of its glob patterns
+type DatabaseLabelRule struct {
+	Databases []string          `yaml:"databases" json:"databases"`
+	Labels    map[string]string `yaml:"labels" json:"labels"`
+}
+
+// Validate checks the rule's patterns and labels
+func (r DatabaseLabelRule) Validate() error {
+	if len(r.Databases) == 0 {
+		return fmt.Errorf("database label rules need at least one database pattern")
+	}
+	for _, pattern := range r.Databases {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid database pattern %q: %w", pattern, err)
+		}
+	}
+	return ValidateLabels(r.Labels)
+}
+
+// LabelsFor returns the labels of one of the server's databases: the server
+// labels with those of every matching rule on top, later rules winning
+func (s DatabaseServerConfig) LabelsFor(database string) map[string]string {
+	labels := make(map[string]string, len(s.Labels))
+	for key, value := range s.Labels {
+		labels[key] = value
+	}
+	for _, rule := range s.DatabaseLabels {
+		if !matchAny(rule.Databases, database) {
+			continue
+		}
+		for key, value := range rule.Labels {
+			labels[key] = value
+		}
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+// mayHaveLabel reports whether the server or one of its database rules sets
+// the label
+func (s DatabaseServerConfig) mayHaveLabel(key, value string) bool {
+	if s.Labels[key] == value {
+		return true
+	}
+	for _, rule := range s.DatabaseLabels {
+		if v, ok := rule.Labels[key]; ok && v == value {
+			return true
+		}
+	}
+	return false
 }
 
 // LocalConfig defines local backup settings
@@ -102,11 +205,34 @@ type S3Config struct {
 	CustomCAPath         string `yaml:"customCAPath"`         // Path to custom CA certificate
 	SkipCertValidation   bool   `yaml:"skipCertValidation"`   // Skip certificate validation
 	OrganizationStrategy string `yaml:"organizationStrategy"` // server-only, type-only, combined
+	ObjectLockLegalHold  bool   `yaml:"objectLockLegalHold"`  // Also apply S3 Object Lock legal hold to held backups
+	Quota                string `yaml:"quota"`                // Space available for backups, such as 500GB; empty for no quota
+}
+
+// QuotaBytes returns the S3 quota in bytes, 0 when none or invalid
+func (s S3Config) QuotaBytes() int64 {
+	if s.Quota == "" {
+		return 0
+	}
+	quota, err := humanize.ParseBytes(s.Quota)
+	if err != nil {
+		return 0
+	}
+	return int64(quota)
 }
 
-// MetadataDBConfig defines MySQL connection settings for metadata database
+// Metadata database engines
+const (
+	MetadataDBTypeMySQL      = "mysql"
+	MetadataDBTypePostgreSQL = "postgresql"
+	MetadataDBTypeSQLite     = "sqlite"
+)
+
+// MetadataDBConfig defines connection settings for the metadata database
 type MetadataDBConfig struct {
 	Enabled         bool   `yaml:"enabled"`
+	Type            string `yaml:"type"` // mysql, postgresql or sqlite
+	Path            string `yaml:"path"` // Database file of the embedded SQLite store
 	Host            string `yaml:"host"`
 	Port            int    `yaml:"port"`
 	Username        string `yaml:"username"`
@@ -120,35 +246,691 @@ type MetadataDBConfig struct {
 
 // MetricsConfig defines metrics server settings
 type MetricsConfig struct {
-	Port string `yaml:"port"`
+	Port           string `yaml:"port"`
+	PushgatewayURL string `yaml:"pushgatewayURL"` // Where one-shot commands push their metrics before exiting
+	PushgatewayJob string `yaml:"pushgatewayJob"` // Job label for pushed metrics
+
+	// BackupLabels are the backup label keys exported on the
+	// mysql_backup_labels info metric, for joining with other metrics
+	BackupLabels []string `yaml:"backupLabels,omitempty"`
+}
+
+// Admin server roles granted to authenticated clients
+const (
+	RoleAdmin    = "admin"    // Full access
+	RoleOperator = "operator" // Read access plus running backups and retention
+	RoleViewer   = "viewer"   // Read-only access
+)
+
+// AdminServerConfig defines HTTPS and access control settings for the admin server
+type AdminServerConfig struct {
+	TLSEnabled   bool              `yaml:"tlsEnabled"`
+	CertFile     string            `yaml:"certFile"`     // Reloaded automatically when it changes
+	KeyFile      string            `yaml:"keyFile"`      // Reloaded automatically when it changes
+	ClientCAFile string            `yaml:"clientCAFile"` // Require client certificates signed by this CA
+	ClientRoles  map[string]string `yaml:"clientRoles"`  // "CN=<name>" or "OU=<name>" -> role
+	DefaultRole  string            `yaml:"defaultRole"`  // Role for verified clients without a mapping; empty denies access
+	HSTSMaxAge   int               `yaml:"hstsMaxAge"`   // Strict-Transport-Security max-age in seconds; 0 disables
+	MetricsPort  string            `yaml:"metricsPort"`  // Serve /metrics and /healthz on a separate unauthenticated port
+	APITokens    []APIToken        `yaml:"apiTokens"`    // Bearer tokens accepted instead of client certificates
+}
+
+// APIToken grants a role to clients that send it as a bearer token
+type APIToken struct {
+	Name  string `yaml:"name"` // Identifies the token without revealing it
+	Role  string `yaml:"role"`
+	Token string `yaml:"token"`
+}
+
+// minAPITokenLength keeps tokens long enough that guessing them is impractical
+const minAPITokenLength = 16
+
+// ClientAuthEnabled reports whether clients must present a certificate
+func (a AdminServerConfig) ClientAuthEnabled() bool {
+	return a.TLSEnabled && a.ClientCAFile != ""
 }
 
-// RetentionRule defines retention policy rules
+// AuthEnabled reports whether clients must authenticate with a certificate or an API token
+func (a AdminServerConfig) AuthEnabled() bool {
+	return a.ClientAuthEnabled() || len(a.APITokens) > 0
+}
+
+// isValidRole reports whether role is a known admin server role
+func isValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleOperator || role == RoleViewer
+}
+
+// RetentionRule defines retention policy rules. A backup is kept when any of
+// the configured rules selects it; an empty rule keeps everything.
 type RetentionRule struct {
 	Duration string `yaml:"duration"`
 	Forever  bool   `yaml:"forever"`
+
+	// Count-based rules, evaluated per server and database
+	KeepLast    int `yaml:"keepLast,omitempty"`
+	KeepDaily   int `yaml:"keepDaily,omitempty"`
+	KeepWeekly  int `yaml:"keepWeekly,omitempty"`
+	KeepMonthly int `yaml:"keepMonthly,omitempty"`
+	KeepYearly  int `yaml:"keepYearly,omitempty"`
+
+	// MinKeep is the number of most recent successful backups that are never
+	// deleted, regardless of the other rules. At least one is always kept.
+	MinKeep int `yaml:"minKeep,omitempty"`
+}
+
+// HasCountRules reports whether any count-based rule is configured
+func (r RetentionRule) HasCountRules() bool {
+	return r.KeepLast > 0 || r.KeepDaily > 0 || r.KeepWeekly > 0 || r.KeepMonthly > 0 || r.KeepYearly > 0
+}
+
+// Validate checks the rule's duration and counts
+func (r RetentionRule) Validate() error {
+	for name, count := range map[string]int{
+		"keepLast":    r.KeepLast,
+		"keepDaily":   r.KeepDaily,
+		"keepWeekly":  r.KeepWeekly,
+		"keepMonthly": r.KeepMonthly,
+		"keepYearly":  r.KeepYearly,
+		"minKeep":     r.MinKeep,
+	} {
+		if count < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+
+	if r.Forever {
+		return nil
+	}
+
+	// A duration is optional when count-based rules are configured
+	if r.Duration == "" && r.HasCountRules() {
+		return nil
+	}
+
+	if _, err := time.ParseDuration(r.Duration); err != nil {
+		return fmt.Errorf("invalid duration: %v", err)
+	}
+
+	return nil
+}
+
+// Description returns a human readable summary of the rule
+func (r RetentionRule) Description() string {
+	if r.Forever {
+		return "Keep forever"
+	}
+
+	var parts []string
+	if r.Duration != "" {
+		parts = append(parts, "for "+r.Duration)
+	}
+	for _, count := range []struct {
+		n     int
+		label string
+	}{
+		{r.KeepLast, "last"},
+		{r.KeepDaily, "daily"},
+		{r.KeepWeekly, "weekly"},
+		{r.KeepMonthly, "monthly"},
+		{r.KeepYearly, "yearly"},
+	} {
+		if count.n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", count.label, count.n))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "Keep forever"
+	}
+	return "Keep " + strings.Join(parts, ", ")
+}
+
+// RetentionConfig defines global retention enforcement settings
+type RetentionConfig struct {
+	// DeleteOrphans removes backup files that no metadata entry references.
+	// When false, orphans are only reported.
+	DeleteOrphans bool `yaml:"deleteOrphans"`
+	// OrphanGracePeriod is how old an unreferenced file must be before it is
+	// treated as an orphan, so backups still being written are left alone
+	OrphanGracePeriod string `yaml:"orphanGracePeriod"`
+}
+
+// LabeledRetentionRule replaces a backup type's retention rule for backups
+// whose labels match its selector
+type LabeledRetentionRule struct {
+	Selector  map[string]string `yaml:"selector"`
+	Retention RetentionRule     `yaml:"retention"`
 }
 
 // LocalBackupConfig defines local storage settings for a backup type
 type LocalBackupConfig struct {
-	Enabled   bool          `yaml:"enabled"`
-	Retention RetentionRule `yaml:"retention"`
+	Enabled    bool                   `yaml:"enabled"`
+	Retention  RetentionRule          `yaml:"retention"`
+	LabelRules []LabeledRetentionRule `yaml:"labelRules,omitempty"` // First match wins over Retention
 }
 
 // S3BackupConfig defines S3 storage settings for a backup type
 type S3BackupConfig struct {
-	Enabled   bool          `yaml:"enabled"`
-	Retention RetentionRule `yaml:"retention"`
+	Enabled    bool                   `yaml:"enabled"`
+	Retention  RetentionRule          `yaml:"retention"`
+	LabelRules []LabeledRetentionRule `yaml:"labelRules,omitempty"` // First match wins over Retention
+}
+
+// RetentionFor returns the rule of the first label rule whose selector
+// matches the labels, or rule when none does
+func RetentionFor(rule RetentionRule, labelRules []LabeledRetentionRule, labels map[string]string) RetentionRule {
+	for _, labelRule := range labelRules {
+		if MatchLabels(labelRule.Selector, labels) {
+			return labelRule.Retention
+		}
+	}
+	return rule
+}
+
+// validateRetention checks a retention rule and its label rules
+func validateRetention(rule RetentionRule, labelRules []LabeledRetentionRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	for _, labelRule := range labelRules {
+		if len(labelRule.Selector) == 0 {
+			return fmt.Errorf("retention label rules need a selector")
+		}
+		if err := ValidateLabels(labelRule.Selector); err != nil {
+			return err
+		}
+		if err := labelRule.Retention.Validate(); err != nil {
+			return fmt.Errorf("retention for labels %s: %w", FormatLabels(labelRule.Selector), err)
+		}
+	}
+	return nil
+}
+
+// BackupTargets selects the servers and databases a scheduled backup covers.
+// Server and database entries may be glob patterns (e.g. "orders_*"); empty
+// selectors match everything.
+type BackupTargets struct {
+	Servers          []string          `yaml:"servers,omitempty"`
+	Databases        []string          `yaml:"databases,omitempty"`
+	ExcludeDatabases []string          `yaml:"excludeDatabases,omitempty"`
+	Labels           map[string]string `yaml:"labels,omitempty"` // Database labels that must all match
+}
+
+// IsEmpty reports whether the targets select every server and database
+func (t BackupTargets) IsEmpty() bool {
+	return len(t.Servers) == 0 && len(t.Databases) == 0 && len(t.ExcludeDatabases) == 0 && len(t.Labels) == 0
+}
+
+// Validate checks that every pattern is a valid glob
+func (t BackupTargets) Validate() error {
+	for _, list := range [][]string{t.Servers, t.Databases, t.ExcludeDatabases} {
+		for _, pattern := range list {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid target pattern %q: %w", pattern, err)
+			}
+			if strings.Contains(pattern, ",") {
+				return fmt.Errorf("invalid target pattern %q: must not contain a comma", pattern)
+			}
+		}
+	}
+	return ValidateLabels(t.Labels)
+}
+
+// MatchesServer reports whether the server is selected by name and could
+// have databases with the selected labels
+func (t BackupTargets) MatchesServer(server DatabaseServerConfig) bool {
+	if len(t.Servers) > 0 && !matchAny(t.Servers, server.Name) {
+		return false
+	}
+	for key, value := range t.Labels {
+		if !server.mayHaveLabel(key, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesDatabase reports whether a database of the server is selected by
+// name and by its labels
+func (t BackupTargets) MatchesDatabase(server DatabaseServerConfig, database string) bool {
+	if len(t.Databases) > 0 && !matchAny(t.Databases, database) {
+		return false
+	}
+	if matchAny(t.ExcludeDatabases, database) {
+		return false
+	}
+	return MatchLabels(t.Labels, server.LabelsFor(database))
+}
+
+// Description returns a human readable summary of the targets
+func (t BackupTargets) Description() string {
+	if t.IsEmpty() {
+		return "All servers and databases"
+	}
+
+	var parts []string
+	if len(t.Servers) > 0 {
+		parts = append(parts, "servers "+strings.Join(t.Servers, ", "))
+	}
+	if len(t.Labels) > 0 {
+		parts = append(parts, "labels "+FormatLabels(t.Labels))
+	}
+	if len(t.Databases) > 0 {
+		parts = append(parts, "databases "+strings.Join(t.Databases, ", "))
+	}
+	if len(t.ExcludeDatabases) > 0 {
+		parts = append(parts, "excluding "+strings.Join(t.ExcludeDatabases, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// matchAny reports whether name matches any of the glob patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLabels parses "env=prod,tier=hot" into a label map
+func ParseLabels(value string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, val, ok := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", entry)
+		}
+		labels[key] = strings.TrimSpace(val)
+	}
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	return labels, nil
+}
+
+// MatchLabels reports whether labels has every key and value of the selector
+func MatchLabels(selector, labels map[string]string) bool {
+	for key, value := range selector {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateLabels checks that labels can be stored as "key=value" pairs
+func ValidateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if key == "" || strings.ContainsAny(key, "=,") {
+			return fmt.Errorf("invalid label key %q", key)
+		}
+		if strings.Contains(value, ",") {
+			return fmt.Errorf("invalid value for label %s: must not contain a comma", key)
+		}
+	}
+	return nil
+}
+
+// FormatLabels renders a label map as "key=value" pairs sorted by key
+func FormatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + labels[key]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Blackout window actions
+const (
+	// BlackoutSkip drops a run that falls inside the window
+	BlackoutSkip = "skip"
+	// BlackoutDefer postpones a run until the window ends
+	BlackoutDefer = "defer"
+)
+
+// Catch-up policies for scheduled runs missed while GoSQLGuard was down
+const (
+	// CatchUpSkip reports missed runs without running them
+	CatchUpSkip = "skip"
+	// CatchUpOnce runs a single backup after any number of missed runs
+	CatchUpOnce = "once"
+)
+
+// BlackoutWindow is a period during which scheduled backups do not start.
+// A window is either recurring, starting at each Cron activation and lasting
+// Duration, or a fixed range from Start to End. Dates without a time cover
+// whole days, so an End of "2026-12-31" includes that day.
+type BlackoutWindow struct {
+	Name     string `yaml:"name,omitempty" json:"name,omitempty"`
+	Cron     string `yaml:"cron,omitempty" json:"cron,omitempty"`         // e.g. "0 0 28-31 * *"
+	Duration string `yaml:"duration,omitempty" json:"duration,omitempty"` // Length of a recurring window
+	Start    string `yaml:"start,omitempty" json:"start,omitempty"`       // "2006-01-02" or RFC3339
+	End      string `yaml:"end,omitempty" json:"end,omitempty"`           // "2006-01-02" or RFC3339
+	Action   string `yaml:"action,omitempty" json:"action,omitempty"`     // skip (default) or defer
+}
+
+// Validate checks that the window is either recurring or a date range
+func (b BlackoutWindow) Validate() error {
+	switch b.Action {
+	case "", BlackoutSkip, BlackoutDefer:
+	default:
+		return fmt.Errorf("invalid blackout action %q, expected skip or defer", b.Action)
+	}
+
+	if b.Cron != "" {
+		if b.Start != "" || b.End != "" {
+			return fmt.Errorf("blackout window must use either cron or start/end, not both")
+		}
+		if _, err := cron.ParseStandard(b.Cron); err != nil {
+			return fmt.Errorf("invalid blackout cron expression: %w", err)
+		}
+		d, err := time.ParseDuration(b.Duration)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("recurring blackout window requires a positive duration")
+		}
+		return nil
+	}
+
+	start, end, err := b.dateRange(time.UTC)
+	if err != nil {
+		return err
+	}
+	if !end.After(start) {
+		return fmt.Errorf("blackout window end must be after its start")
+	}
+	return nil
+}
+
+// ActionOrDefault returns the window's action, defaulting to skip
+func (b BlackoutWindow) ActionOrDefault() string {
+	if b.Action == "" {
+		return BlackoutSkip
+	}
+	return b.Action
+}
+
+// Description returns a short summary of the window for logs and the UI
+func (b BlackoutWindow) Description() string {
+	if b.Name != "" {
+		return b.Name
+	}
+	if b.Cron != "" {
+		return fmt.Sprintf("%s for %s", b.Cron, b.Duration)
+	}
+	return fmt.Sprintf("%s to %s", b.Start, b.End)
+}
+
+// Active reports whether t falls inside the window, evaluated in loc, and
+// when the window ends
+func (b BlackoutWindow) Active(t time.Time, loc *time.Location) (bool, time.Time) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	if b.Cron != "" {
+		schedule, err := ParseSchedule(b.Cron, loc)
+		if err != nil {
+			return false, time.Time{}
+		}
+		d, err := time.ParseDuration(b.Duration)
+		if err != nil || d <= 0 {
+			return false, time.Time{}
+		}
+
+		// The window is active if it started within the last Duration
+		start := schedule.Next(t.Add(-d))
+		if !start.After(t) {
+			return true, start.Add(d)
+		}
+		return false, time.Time{}
+	}
+
+	start, end, err := b.dateRange(loc)
+	if err != nil {
+		return false, time.Time{}
+	}
+	if !t.Before(start) && t.Before(end) {
+		return true, end
+	}
+	return false, time.Time{}
+}
+
+// dateRange parses Start and End in loc
+func (b BlackoutWindow) dateRange(loc *time.Location) (time.Time, time.Time, error) {
+	if b.Start == "" || b.End == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("blackout window requires cron and duration, or start and end")
+	}
+	start, _, err := parseWindowTime(b.Start, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid blackout start: %w", err)
+	}
+	end, dateOnly, err := parseWindowTime(b.End, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid blackout end: %w", err)
+	}
+	if dateOnly {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+// parseWindowTime parses a date or RFC3339 time, reporting whether it was a date
+func parseWindowTime(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// LoadLocation returns the named IANA time zone, or the local zone when empty
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// ParseSchedule parses a standard cron expression evaluated in loc
+func ParseSchedule(spec string, loc *time.Location) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, err
+	}
+	// Expressions with an explicit CRON_TZ keep their own zone
+	if s, ok := schedule.(*cron.SpecSchedule); ok && loc != nil && s.Location == time.Local {
+		s.Location = loc
+	}
+	return schedule, nil
 }
 
 // BackupTypeConfig defines configuration for a specific backup type
 type BackupTypeConfig struct {
 	Schedule         string                 `yaml:"schedule"` // Cron schedule format
+	Targets          BackupTargets          `yaml:"targets,omitempty"`
+	Timezone         string                 `yaml:"timezone,omitempty"`     // IANA zone for the schedule and its blackout windows
+	Jitter           string                 `yaml:"jitter,omitempty"`       // Random delay before a run starts, e.g. "10m"
+	MaxRunWindow     string                 `yaml:"maxRunWindow,omitempty"` // Databases not started within this time are deferred to the next run
+	Blackouts        []BlackoutWindow       `yaml:"blackouts,omitempty"`
+	CatchUp          string                 `yaml:"catchUp,omitempty"` // What to do after missed runs: skip (default) or once
 	Local            LocalBackupConfig      `yaml:"local"`
 	S3               S3BackupConfig         `yaml:"s3"`
 	MySQLDumpOptions MySQLDumpOptionsConfig `yaml:"mysqlDumpOptions,omitempty"`
 }
 
+// ValidateSchedule checks the cron expression, timezone, jitter, run window,
+// targets and blackout windows
+func (c BackupTypeConfig) ValidateSchedule() error {
+	if _, err := LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+	if c.Schedule != "" {
+		if _, err := cron.ParseStandard(c.Schedule); err != nil {
+			return fmt.Errorf("invalid cron expression: %w", err)
+		}
+	}
+	for name, value := range map[string]string{"jitter": c.Jitter, "maxRunWindow": c.MaxRunWindow} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("invalid %s %q", name, value)
+		}
+	}
+	switch c.CatchUp {
+	case "", CatchUpSkip, CatchUpOnce:
+	default:
+		return fmt.Errorf("invalid catchUp %q, expected skip or once", c.CatchUp)
+	}
+	if err := c.Targets.Validate(); err != nil {
+		return err
+	}
+	for _, window := range c.Blackouts {
+		if err := window.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ActiveBlackout returns the first blackout window that t falls inside and
+// when it ends
+func (c BackupTypeConfig) ActiveBlackout(t time.Time, loc *time.Location) (BlackoutWindow, time.Time, bool) {
+	for _, window := range c.Blackouts {
+		if active, end := window.Active(t, loc); active {
+			return window, end, true
+		}
+	}
+	return BlackoutWindow{}, time.Time{}, false
+}
+
+// TimingDescription summarizes the schedule's timezone, jitter, run window
+// and blackout windows, or returns an empty string when none are set
+func (c BackupTypeConfig) TimingDescription() string {
+	var parts []string
+	if c.Timezone != "" {
+		parts = append(parts, c.Timezone)
+	}
+	if c.Jitter != "" {
+		parts = append(parts, "jitter "+c.Jitter)
+	}
+	if c.MaxRunWindow != "" {
+		parts = append(parts, "window "+c.MaxRunWindow)
+	}
+	if c.CatchUp == CatchUpOnce {
+		parts = append(parts, "catch up once")
+	}
+	for _, window := range c.Blackouts {
+		parts = append(parts, fmt.Sprintf("blackout %s (%s)", window.Description(), window.ActionOrDefault()))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SchedulerConfig defines global scheduling settings
+type SchedulerConfig struct {
+	// Timezone is the IANA zone schedules use unless they set their own;
+	// empty means the container's local zone
+	Timezone string `yaml:"timezone"`
+}
+
+// HAConfig configures leader election between replicas sharing a metadata
+// database. Only the leader runs scheduled backups and retention.
+type HAConfig struct {
+	Enabled       bool   `yaml:"enabled"`
+	Identity      string `yaml:"identity"`      // Name of this replica; defaults to the host name
+	LeaseDuration string `yaml:"leaseDuration"` // How long a lease lasts without renewal
+	RenewInterval string `yaml:"renewInterval"` // How often the lease is renewed or contested
+}
+
+// Embedded reports whether the metadata database is an embedded SQLite file
+// rather than a server several processes can share
+func (c MetadataDBConfig) Embedded() bool {
+	return c.Type == MetadataDBTypeSQLite
+}
+
+// DefaultPort returns the standard port of the metadata database engine
+func (c MetadataDBConfig) DefaultPort() int {
+	if c.Type == MetadataDBTypePostgreSQL {
+		return 5432
+	}
+	return 3306
+}
+
+// Validate checks the HA settings
+func (c HAConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	lease, err := time.ParseDuration(c.LeaseDuration)
+	if err != nil || lease <= 0 {
+		return fmt.Errorf("invalid HA lease duration %q", c.LeaseDuration)
+	}
+	renew, err := time.ParseDuration(c.RenewInterval)
+	if err != nil || renew <= 0 {
+		return fmt.Errorf("invalid HA renew interval %q", c.RenewInterval)
+	}
+	if renew >= lease {
+		return fmt.Errorf("HA renew interval %s must be shorter than the lease duration %s", renew, lease)
+	}
+	return nil
+}
+
+// WorkerConfig configures the backup task queue and the worker processes
+// that drain it. With the queue enabled the scheduler only enqueues one task
+// per database, and `gosqlguard worker` processes run them.
+type WorkerConfig struct {
+	QueueEnabled      bool     `yaml:"queueEnabled"`
+	ID                string   `yaml:"id"`                // Name of this worker; defaults to the host name
+	Servers           []string `yaml:"servers"`           // Server names or glob patterns this worker backs up, empty means all
+	PollInterval      string   `yaml:"pollInterval"`      // How often an idle worker looks for tasks
+	HeartbeatInterval string   `yaml:"heartbeatInterval"` // How often a worker reports it is still running a task
+	HeartbeatTimeout  string   `yaml:"heartbeatTimeout"`  // How long without a heartbeat before a task is released
+	MaxAttempts       int      `yaml:"maxAttempts"`       // How many workers may start a task before it fails
+}
+
+// Validate checks the worker settings
+func (c WorkerConfig) Validate() error {
+	durations := map[string]string{
+		"poll interval":      c.PollInterval,
+		"heartbeat interval": c.HeartbeatInterval,
+		"heartbeat timeout":  c.HeartbeatTimeout,
+	}
+	for name, value := range durations {
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("invalid worker %s %q", name, value)
+		}
+	}
+	interval, _ := time.ParseDuration(c.HeartbeatInterval)
+	timeout, _ := time.ParseDuration(c.HeartbeatTimeout)
+	if interval >= timeout {
+		return fmt.Errorf("worker heartbeat interval %s must be shorter than the heartbeat timeout %s", interval, timeout)
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("worker max attempts must be at least 1")
+	}
+	// Workers claim tasks by matching servers in SQL, which has no character classes
+	for _, pattern := range c.Servers {
+		if strings.ContainsAny(pattern, "[]\\") {
+			return fmt.Errorf("worker server pattern %q may only use the * and ? wildcards", pattern)
+		}
+	}
+	return BackupTargets{Servers: c.Servers}.Validate()
+}
+
 // AppConfig contains the complete application configuration
 type AppConfig struct {
 	// Legacy single-server configuration (for backward compatibility)
@@ -161,14 +943,36 @@ type AppConfig struct {
 	Local                 LocalConfig                 `yaml:"local"`
 	S3                    S3Config                    `yaml:"s3"`
 	Metrics               MetricsConfig               `yaml:"metrics"`
+	AdminServer           AdminServerConfig           `yaml:"admin_server"`
 	MetadataDB            MetadataDBConfig            `yaml:"metadata_database"`
+	Retention             RetentionConfig             `yaml:"retention"`
+	Scheduler             SchedulerConfig             `yaml:"scheduler"`
+	HA                    HAConfig                    `yaml:"ha"`
+	Workers               WorkerConfig                `yaml:"workers"`
 	BackupTypes           map[string]BackupTypeConfig `yaml:"backupTypes"`
 	MySQLDumpOptions      MySQLDumpOptionsConfig      `yaml:"mysqlDumpOptions,omitempty"`      // Default MySQL dump options
 	PostgreSQLDumpOptions PostgreSQLDumpOptionsConfig `yaml:"postgresqlDumpOptions,omitempty"` // Default PostgreSQL dump options
 	Debug                 bool                        `yaml:"debug"`
 	ConfigFile            string                      `json:"configFile,omitempty"`
+
+	// ShutdownGracePeriod is how long running backups may take to finish
+	// after a shutdown signal before they are cancelled
+	ShutdownGracePeriod string `yaml:"shutdownGracePeriod"`
+}
+
+// ShutdownGrace returns the shutdown grace period, falling back to the
+// default when it is unset or invalid
+func (c *AppConfig) ShutdownGrace() time.Duration {
+	grace, err := time.ParseDuration(c.ShutdownGracePeriod)
+	if err != nil || grace < 0 {
+		return defaultShutdownGrace
+	}
+	return grace
 }
 
+// defaultShutdownGrace is the shutdown grace period unless configured
+const defaultShutdownGrace = 5 * time.Minute
+
 // CFG is the global configuration object
 var CFG AppConfig
 
@@ -199,14 +1003,18 @@ func loadFromEnvironment() {
 	CFG.S3.UseSSL = parseEnvBool("S3_USE_SSL", true)
 	CFG.S3.CustomCAPath = getEnvOrDefault("S3_CUSTOM_CA_PATH", "")
 	CFG.S3.SkipCertValidation = parseEnvBool("S3_SKIP_CERT_VALIDATION", false)
+	CFG.S3.ObjectLockLegalHold = parseEnvBool("S3_OBJECT_LOCK_LEGAL_HOLD", false)
+	CFG.S3.Quota = getEnvOrDefault("S3_QUOTA", "")
 
 	// Metadata DB settings
 	CFG.MetadataDB.Enabled = parseEnvBool("METADATA_DB_ENABLED", false)
+	CFG.MetadataDB.Type = getEnvOrDefault("METADATA_DB_TYPE", MetadataDBTypeMySQL)
+	CFG.MetadataDB.Path = getEnvOrDefault("METADATA_DB_PATH", "")
 	CFG.MetadataDB.Host = getEnvOrDefault("METADATA_DB_HOST", "localhost")
-	if port, err := strconv.Atoi(getEnvOrDefault("METADATA_DB_PORT", "3306")); err == nil {
+	if port, err := strconv.Atoi(getEnvOrDefault("METADATA_DB_PORT", strconv.Itoa(CFG.MetadataDB.DefaultPort()))); err == nil {
 		CFG.MetadataDB.Port = port
 	} else {
-		CFG.MetadataDB.Port = 3306
+		CFG.MetadataDB.Port = CFG.MetadataDB.DefaultPort()
 	}
 	CFG.MetadataDB.Username = getEnvOrDefault("METADATA_DB_USERNAME", "gosqlguard")
 	CFG.MetadataDB.Password = getEnvOrDefault("METADATA_DB_PASSWORD", "")
@@ -227,8 +1035,57 @@ func loadFromEnvironment() {
 	CFG.MetadataDB.ConnMaxLifetime = getEnvOrDefault("METADATA_DB_CONN_MAX_LIFETIME", "5m")
 	CFG.MetadataDB.AutoMigrate = parseEnvBool("METADATA_DB_AUTO_MIGRATE", true)
 
+	// Retention enforcement settings
+	CFG.Retention.DeleteOrphans = parseEnvBool("RETENTION_DELETE_ORPHANS", false)
+	CFG.Retention.OrphanGracePeriod = getEnvOrDefault("RETENTION_ORPHAN_GRACE_PERIOD", "24h")
+
+	// Scheduler settings
+	CFG.Scheduler.Timezone = getEnvOrDefault("SCHEDULER_TIMEZONE", "")
+	CFG.ShutdownGracePeriod = getEnvOrDefault("SHUTDOWN_GRACE_PERIOD", "5m")
+
+	// High availability settings
+	CFG.HA.Enabled = parseEnvBool("HA_ENABLED", false)
+	CFG.HA.Identity = getEnvOrDefault("HA_IDENTITY", os.Getenv("POD_NAME"))
+	if CFG.HA.Identity == "" {
+		CFG.HA.Identity, _ = os.Hostname()
+	}
+	CFG.HA.LeaseDuration = getEnvOrDefault("HA_LEASE_DURATION", "15s")
+	CFG.HA.RenewInterval = getEnvOrDefault("HA_RENEW_INTERVAL", "5s")
+
+	// Backup task queue and worker settings
+	CFG.Workers.QueueEnabled = parseEnvBool("BACKUP_QUEUE_ENABLED", false)
+	CFG.Workers.ID = getEnvOrDefault("WORKER_ID", os.Getenv("POD_NAME"))
+	if CFG.Workers.ID == "" {
+		CFG.Workers.ID, _ = os.Hostname()
+	}
+	CFG.Workers.Servers = parseEnvList("WORKER_SERVERS")
+	CFG.Workers.PollInterval = getEnvOrDefault("WORKER_POLL_INTERVAL", "5s")
+	CFG.Workers.HeartbeatInterval = getEnvOrDefault("WORKER_HEARTBEAT_INTERVAL", "10s")
+	CFG.Workers.HeartbeatTimeout = getEnvOrDefault("WORKER_HEARTBEAT_TIMEOUT", "1m")
+	CFG.Workers.MaxAttempts = 3
+	if attempts, err := strconv.Atoi(getEnvOrDefault("WORKER_MAX_ATTEMPTS", "3")); err == nil {
+		CFG.Workers.MaxAttempts = attempts
+	}
+
 	// Metrics settings
 	CFG.Metrics.Port = getEnvOrDefault("METRICS_PORT", "8080")
+	CFG.Metrics.PushgatewayURL = getEnvOrDefault("PUSHGATEWAY_URL", "")
+	CFG.Metrics.PushgatewayJob = getEnvOrDefault("PUSHGATEWAY_JOB", "gosqlguard")
+
+	// Admin server HTTPS and access control settings
+	CFG.AdminServer.TLSEnabled = parseEnvBool("ADMIN_TLS_ENABLED", false)
+	CFG.AdminServer.CertFile = getEnvOrDefault("ADMIN_TLS_CERT_FILE", "")
+	CFG.AdminServer.KeyFile = getEnvOrDefault("ADMIN_TLS_KEY_FILE", "")
+	CFG.AdminServer.ClientCAFile = getEnvOrDefault("ADMIN_TLS_CLIENT_CA_FILE", "")
+	CFG.AdminServer.ClientRoles = parseRoleMap(getEnvOrDefault("ADMIN_TLS_CLIENT_ROLES", ""))
+	CFG.AdminServer.DefaultRole = getEnvOrDefault("ADMIN_TLS_DEFAULT_ROLE", "")
+	if maxAge, err := strconv.Atoi(getEnvOrDefault("ADMIN_HSTS_MAX_AGE", "31536000")); err == nil {
+		CFG.AdminServer.HSTSMaxAge = maxAge
+	} else {
+		CFG.AdminServer.HSTSMaxAge = 31536000
+	}
+	CFG.AdminServer.MetricsPort = getEnvOrDefault("ADMIN_METRICS_PORT", "")
+	CFG.AdminServer.APITokens = parseAPITokens(getEnvOrDefault("ADMIN_API_TOKENS", ""))
 
 	// Set organization strategies (optional)
 	if orgStrategy := getEnvOrDefault("LOCAL_ORGANIZATION_STRATEGY", ""); orgStrategy != "" {
@@ -269,8 +1126,14 @@ func setDefaults() {
 		if CFG.MetadataDB.Host == "" {
 			CFG.MetadataDB.Host = "localhost"
 		}
+		if CFG.MetadataDB.Type == "" {
+			CFG.MetadataDB.Type = MetadataDBTypeMySQL
+		}
 		if CFG.MetadataDB.Port == 0 {
-			CFG.MetadataDB.Port = 3306
+			CFG.MetadataDB.Port = CFG.MetadataDB.DefaultPort()
+		}
+		if CFG.MetadataDB.Embedded() && CFG.MetadataDB.Path == "" {
+			CFG.MetadataDB.Path = filepath.Join(CFG.Local.BackupDirectory, "metadata.db")
 		}
 		if CFG.MetadataDB.Database == "" {
 			CFG.MetadataDB.Database = "gosqlguard_metadata"
@@ -368,6 +1231,60 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// parseRoleMap parses "CN=alice:admin,OU=ops:operator" into a subject to role map
+func parseRoleMap(value string) map[string]string {
+	roles := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		idx := strings.LastIndex(entry, ":")
+		if idx <= 0 || idx == len(entry)-1 {
+			log.Printf("Warning: ignoring malformed client role mapping %q", entry)
+			continue
+		}
+		roles[strings.TrimSpace(entry[:idx])] = strings.TrimSpace(entry[idx+1:])
+	}
+	return roles
+}
+
+// parseAPITokens parses comma separated "name:role:token" entries
+func parseAPITokens(value string) []APIToken {
+	var tokens []APIToken
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			// Never log the entry itself, it contains the token
+			log.Printf("Warning: ignoring malformed API token entry %d (expected name:role:token)", len(tokens)+1)
+			continue
+		}
+		tokens = append(tokens, APIToken{
+			Name:  strings.TrimSpace(parts[0]),
+			Role:  strings.TrimSpace(parts[1]),
+			Token: strings.TrimSpace(parts[2]),
+		})
+	}
+	return tokens
+}
+
+// parseEnvList parses a comma separated environment variable, dropping empty entries
+func parseEnvList(key string) []string {
+	var list []string
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
 func parseEnvBool(key string, defaultValue bool) bool {
 	value, exists := os.LookupEnv(key)
 	if !exists {
@@ -417,6 +1334,9 @@ func DisplayConfiguration() {
 			log.Printf("Port: %s", server.Port)
 			log.Printf("Username: %s", server.Username)
 			log.Printf("Password: %s", maskSensitiveInfo(server.Password))
+			if server.TLS.Mode != "" {
+				log.Printf("TLS Mode: %s", server.TLS.Mode)
+			}
 
 			log.Println("Include Databases:")
 			if len(server.IncludeDatabases) > 0 {
@@ -501,11 +1421,15 @@ func DisplayConfiguration() {
 		log.Printf("Use SSL: %t", CFG.S3.UseSSL)
 		log.Printf("Custom CA Path: %s", CFG.S3.CustomCAPath)
 		log.Printf("Skip Cert Validation: %t", CFG.S3.SkipCertValidation)
+		log.Printf("Quota: %s", CFG.S3.Quota)
 	}
 
 	// Metrics settings
 	log.Println("\n----- Metrics Configuration -----")
 	log.Printf("Port: %s", CFG.Metrics.Port)
+	if CFG.Metrics.PushgatewayURL != "" {
+		log.Printf("Pushgateway: %s (job %s)", CFG.Metrics.PushgatewayURL, CFG.Metrics.PushgatewayJob)
+	}
 
 	// Backup types
 	log.Println("\n----- Backup Types Configuration -----")
@@ -533,6 +1457,10 @@ func DisplayConfiguration() {
 	// Metadata DB settings if enabled
 	if CFG.MetadataDB.Enabled {
 		log.Println("\n----- Metadata Database Configuration -----")
+		log.Printf("Type: %s", CFG.MetadataDB.Type)
+		if CFG.MetadataDB.Embedded() {
+			log.Printf("Path: %s", CFG.MetadataDB.Path)
+		}
 		log.Printf("Host: %s", CFG.MetadataDB.Host)
 		log.Printf("Port: %d", CFG.MetadataDB.Port)
 		log.Printf("Username: %s", CFG.MetadataDB.Username)
@@ -572,6 +1500,21 @@ func ValidateConfig() error {
 		return fmt.Errorf("at least one database system (MySQL or PostgreSQL) must be configured")
 	}
 
+	// Validate per-server TLS settings
+	for _, server := range CFG.DatabaseServers {
+		if err := server.TLS.Validate(); err != nil {
+			return fmt.Errorf("server %s: %w", server.Name, err)
+		}
+		if err := ValidateLabels(server.Labels); err != nil {
+			return fmt.Errorf("server %s: %w", server.Name, err)
+		}
+		for _, rule := range server.DatabaseLabels {
+			if err := rule.Validate(); err != nil {
+				return fmt.Errorf("server %s: %w", server.Name, err)
+			}
+		}
+	}
+
 	// Validate MySQL configuration
 	if mysqlConfigured {
 		if CFG.MySQL.Host == "" {
@@ -592,6 +1535,10 @@ func ValidateConfig() error {
 		if CFG.PostgreSQL.Username == "" {
 			return fmt.Errorf("PostgreSQL username is required when PostgreSQL databases are configured")
 		}
+
+		if err := CFG.PostgreSQL.TLS.Validate(); err != nil {
+			return fmt.Errorf("PostgreSQL: %w", err)
+		}
 	}
 
 	// Validate storage configuration
@@ -618,22 +1565,87 @@ func ValidateConfig() error {
 			}
 		}
 
+		if CFG.S3.Quota != "" {
+			if _, err := humanize.ParseBytes(CFG.S3.Quota); err != nil {
+				return fmt.Errorf("invalid S3 quota %q: %v", CFG.S3.Quota, err)
+			}
+		}
+
 		// Validate that both custom CA and skip validation are not set
 		if CFG.S3.CustomCAPath != "" && CFG.S3.SkipCertValidation {
 			log.Printf("Warning: Both custom CA path and skip certificate validation are set. Custom CA will be ignored.")
 		}
 	}
 
+	// Validate retention settings
+	if CFG.Retention.OrphanGracePeriod != "" {
+		if _, err := time.ParseDuration(CFG.Retention.OrphanGracePeriod); err != nil {
+			return fmt.Errorf("invalid retention orphan grace period: %v", err)
+		}
+	}
+
+	// Validate scheduler settings
+	if _, err := LoadLocation(CFG.Scheduler.Timezone); err != nil {
+		return fmt.Errorf("invalid scheduler timezone: %v", err)
+	}
+	if CFG.ShutdownGracePeriod != "" {
+		if grace, err := time.ParseDuration(CFG.ShutdownGracePeriod); err != nil || grace < 0 {
+			return fmt.Errorf("invalid shutdown grace period %q", CFG.ShutdownGracePeriod)
+		}
+	}
+
+	// Validate admin server settings
+	if err := ValidateAdminServerConfig(CFG.AdminServer); err != nil {
+		return err
+	}
+
+	// Leader election keeps its lease in the metadata database
+	if err := CFG.HA.Validate(); err != nil {
+		return err
+	}
+	if CFG.HA.Enabled && !CFG.MetadataDB.Enabled {
+		return fmt.Errorf("high availability requires the metadata database to be enabled")
+	}
+	if CFG.HA.Enabled && CFG.MetadataDB.Embedded() {
+		return fmt.Errorf("high availability requires a MySQL or PostgreSQL metadata database shared by all replicas")
+	}
+
+	// The task queue lives in the metadata database
+	if CFG.Workers.QueueEnabled {
+		if !CFG.MetadataDB.Enabled {
+			return fmt.Errorf("the backup task queue requires the metadata database to be enabled")
+		}
+		if CFG.MetadataDB.Embedded() {
+			return fmt.Errorf("the backup task queue requires a MySQL or PostgreSQL metadata database shared with the workers")
+		}
+		if err := CFG.Workers.Validate(); err != nil {
+			return err
+		}
+	}
+
 	// Validate metadata database configuration if enabled
 	if CFG.MetadataDB.Enabled {
-		if CFG.MetadataDB.Host == "" {
-			return fmt.Errorf("metadata database host is required when enabled")
+		switch CFG.MetadataDB.Type {
+		case MetadataDBTypeMySQL, MetadataDBTypePostgreSQL, MetadataDBTypeSQLite:
+		default:
+			return fmt.Errorf("unsupported metadata database type %q (must be %s, %s or %s)",
+				CFG.MetadataDB.Type, MetadataDBTypeMySQL, MetadataDBTypePostgreSQL, MetadataDBTypeSQLite)
 		}
-		if CFG.MetadataDB.Username == "" {
-			return fmt.Errorf("metadata database username is required when enabled")
-		}
-		if CFG.MetadataDB.Database == "" {
-			return fmt.Errorf("metadata database name is required when enabled")
+
+		if CFG.MetadataDB.Embedded() {
+			if CFG.MetadataDB.Path == "" {
+				return fmt.Errorf("metadata database path is required for %s", MetadataDBTypeSQLite)
+			}
+		} else {
+			if CFG.MetadataDB.Host == "" {
+				return fmt.Errorf("metadata database host is required when enabled")
+			}
+			if CFG.MetadataDB.Username == "" {
+				return fmt.Errorf("metadata database username is required when enabled")
+			}
+			if CFG.MetadataDB.Database == "" {
+				return fmt.Errorf("metadata database name is required when enabled")
+			}
 		}
 
 		// Validate connection max lifetime is a valid duration
@@ -664,17 +1676,73 @@ func ValidateConfig() error {
 			return fmt.Errorf("S3 backup is enabled for type %s but global S3 backup is disabled", name)
 		}
 
-		// Validate retention durations
-		if !backupType.Local.Retention.Forever {
-			if _, err := time.ParseDuration(backupType.Local.Retention.Duration); err != nil {
-				return fmt.Errorf("invalid local retention duration for backup type %s: %v", name, err)
-			}
+		if err := backupType.ValidateSchedule(); err != nil {
+			return fmt.Errorf("invalid schedule for backup type %s: %w", name, err)
 		}
 
-		if !backupType.S3.Retention.Forever {
-			if _, err := time.ParseDuration(backupType.S3.Retention.Duration); err != nil {
-				return fmt.Errorf("invalid S3 retention duration for backup type %s: %v", name, err)
-			}
+		// Validate retention rules
+		if err := validateRetention(backupType.Local.Retention, backupType.Local.LabelRules); err != nil {
+			return fmt.Errorf("invalid local retention for backup type %s: %w", name, err)
+		}
+
+		if err := validateRetention(backupType.S3.Retention, backupType.S3.LabelRules); err != nil {
+			return fmt.Errorf("invalid S3 retention for backup type %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateAdminServerConfig validates the admin server HTTPS and access control settings
+func ValidateAdminServerConfig(a AdminServerConfig) error {
+	names := make(map[string]bool)
+	for _, token := range a.APITokens {
+		if token.Name == "" {
+			return fmt.Errorf("admin API tokens must have a name")
+		}
+		if names[token.Name] {
+			return fmt.Errorf("duplicate admin API token name %q", token.Name)
+		}
+		names[token.Name] = true
+		if !isValidRole(token.Role) {
+			return fmt.Errorf("invalid admin role %q for API token %s", token.Role, token.Name)
+		}
+		if len(token.Token) < minAPITokenLength {
+			return fmt.Errorf("admin API token %s must be at least %d characters", token.Name, minAPITokenLength)
+		}
+	}
+
+	if a.TLSEnabled {
+		if a.CertFile == "" || a.KeyFile == "" {
+			return fmt.Errorf("admin server TLS requires both a certificate and a key file")
+		}
+	} else if a.ClientCAFile != "" {
+		return fmt.Errorf("admin server client CA requires TLS to be enabled")
+	}
+
+	for subject, role := range a.ClientRoles {
+		if !strings.HasPrefix(subject, "CN=") && !strings.HasPrefix(subject, "OU=") {
+			return fmt.Errorf("invalid admin client role subject %q (must start with CN= or OU=)", subject)
+		}
+		if !isValidRole(role) {
+			return fmt.Errorf("invalid admin role %q for %s", role, subject)
+		}
+	}
+
+	if a.DefaultRole != "" && !isValidRole(a.DefaultRole) {
+		return fmt.Errorf("invalid admin default role %q", a.DefaultRole)
+	}
+
+	if a.HSTSMaxAge < 0 {
+		return fmt.Errorf("invalid admin HSTS max-age %d", a.HSTSMaxAge)
+	}
+
+	if a.MetricsPort != "" {
+		if port, err := strconv.Atoi(a.MetricsPort); err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("invalid admin metrics port %q", a.MetricsPort)
+		}
+		if a.MetricsPort == CFG.Metrics.Port {
+			return fmt.Errorf("admin metrics port must differ from the main admin port")
 		}
 	}
 
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlackoutWindowRecurring(t *testing.T) {
+	// Month-end close: the last four days of the month, all day
+	window := BlackoutWindow{Cron: "0 0 28-31 * *", Duration: "24h"}
+	if err := window.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	inside := time.Date(2026, 10, 30, 23, 30, 0, 0, loc)
+	active, end := window.Active(inside, loc)
+	if !active {
+		t.Fatalf("window not active at %s", inside)
+	}
+	if want := time.Date(2026, 10, 31, 0, 0, 0, 0, loc); !end.Equal(want) {
+		t.Errorf("end = %s, want %s", end, want)
+	}
+
+	// In UTC this instant is already October 28th, so the zone decides
+	outside := time.Date(2026, 10, 27, 23, 59, 0, 0, loc)
+	if active, _ := window.Active(outside, loc); active {
+		t.Errorf("window active at %s", outside)
+	}
+	if active, _ := window.Active(outside, time.UTC); !active {
+		t.Errorf("window in UTC not active at %s (%s UTC)", outside, outside.UTC())
+	}
+}
+
+func TestBlackoutWindowDateRange(t *testing.T) {
+	window := BlackoutWindow{Start: "2026-12-24", End: "2026-12-26", Action: BlackoutDefer}
+	if err := window.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+
+	for _, tc := range []struct {
+		at     time.Time
+		active bool
+	}{
+		{time.Date(2026, 12, 23, 23, 59, 0, 0, time.UTC), false},
+		{time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2026, 12, 26, 23, 59, 0, 0, time.UTC), true}, // End date is inclusive
+		{time.Date(2026, 12, 27, 0, 0, 0, 0, time.UTC), false},
+	} {
+		active, end := window.Active(tc.at, time.UTC)
+		if active != tc.active {
+			t.Errorf("Active(%s) = %v, want %v", tc.at, active, tc.active)
+		}
+		if active && !end.Equal(time.Date(2026, 12, 27, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("end = %s, want the start of 2026-12-27", end)
+		}
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	valid := BackupTypeConfig{
+		Schedule:     "0 * * * *",
+		Timezone:     "UTC",
+		Jitter:       "10m",
+		MaxRunWindow: "1h",
+		Blackouts:    []BlackoutWindow{{Cron: "0 0 28-31 * *", Duration: "24h"}},
+		CatchUp:      CatchUpOnce,
+	}
+	if err := valid.ValidateSchedule(); err != nil {
+		t.Errorf("ValidateSchedule() = %v", err)
+	}
+
+	for name, c := range map[string]BackupTypeConfig{
+		"bad timezone":       {Timezone: "Mars/Olympus"},
+		"bad cron":           {Schedule: "every hour"},
+		"bad jitter":         {Jitter: "soon"},
+		"negative window":    {MaxRunWindow: "-1h"},
+		"window no duration": {Blackouts: []BlackoutWindow{{Cron: "0 0 * * *"}}},
+		"window both kinds":  {Blackouts: []BlackoutWindow{{Cron: "0 0 * * *", Duration: "1h", Start: "2026-01-01", End: "2026-01-02"}}},
+		"window reversed":    {Blackouts: []BlackoutWindow{{Start: "2026-01-02", End: "2026-01-01"}}},
+		"window bad action":  {Blackouts: []BlackoutWindow{{Start: "2026-01-01", End: "2026-01-02", Action: "pause"}}},
+		"bad catch up":       {CatchUp: "all"},
+	} {
+		if err := c.ValidateSchedule(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestValidateHA(t *testing.T) {
+	if err := (HAConfig{}).Validate(); err != nil {
+		t.Errorf("disabled HA: Validate() = %v", err)
+	}
+	valid := HAConfig{Enabled: true, LeaseDuration: "15s", RenewInterval: "5s"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+
+	for name, c := range map[string]HAConfig{
+		"bad lease":         {Enabled: true, LeaseDuration: "soon", RenewInterval: "5s"},
+		"zero renew":        {Enabled: true, LeaseDuration: "15s", RenewInterval: "0s"},
+		"renew not shorter": {Enabled: true, LeaseDuration: "15s", RenewInterval: "15s"},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestParseAPITokens(t *testing.T) {
+	tokens := parseAPITokens("ci:operator:0123456789abcdef, grafana:viewer:a:b:c:d:e:f:g:h:i, malformed")
+	want := []APIToken{
+		{Name: "ci", Role: RoleOperator, Token: "0123456789abcdef"},
+		{Name: "grafana", Role: RoleViewer, Token: "a:b:c:d:e:f:g:h:i"},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("parseAPITokens() = %v, want %v", tokens, want)
+	}
+	for i := range want {
+		if tokens[i] != want[i] {
+			t.Errorf("token %d = %+v, want %+v", i, tokens[i], want[i])
+		}
+	}
+
+	if err := ValidateAdminServerConfig(AdminServerConfig{APITokens: want}); err != nil {
+		t.Errorf("ValidateAdminServerConfig() = %v", err)
+	}
+	for name, token := range map[string]APIToken{
+		"short token":  {Name: "ci", Role: RoleAdmin, Token: "short"},
+		"unknown role": {Name: "ci", Role: "root", Token: "0123456789abcdef"},
+		"no name":      {Role: RoleAdmin, Token: "0123456789abcdef"},
+	} {
+		if err := ValidateAdminServerConfig(AdminServerConfig{APITokens: []APIToken{token}}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestValidateAdminServerConfigWithoutTLS(t *testing.T) {
+	previous := CFG.Metrics.Port
+	CFG.Metrics.Port = "8080"
+	defer func() { CFG.Metrics.Port = previous }()
+
+	if err := ValidateAdminServerConfig(AdminServerConfig{MetricsPort: "9090"}); err != nil {
+		t.Errorf("ValidateAdminServerConfig() = %v", err)
+	}
+
+	// Settings after the TLS ones are still checked when TLS is off
+	for name, cfg := range map[string]AdminServerConfig{
+		"metrics port clash":  {MetricsPort: "8080"},
+		"invalid port":        {MetricsPort: "metrics"},
+		"port out of range":   {MetricsPort: "70000"},
+		"unknown client role": {ClientRoles: map[string]string{"CN=ci": "root"}},
+		"unknown default":     {DefaultRole: "root"},
+		"negative HSTS":       {HSTSMaxAge: -1},
+		"client CA":           {ClientCAFile: "/ca.pem"},
+	} {
+		if err := ValidateAdminServerConfig(cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestDatabaseLabels(t *testing.T) {
+	server := DatabaseServerConfig{
+		Name:   "primary",
+		Labels: map[string]string{"env": "prod", "team": "core"},
+		DatabaseLabels: []DatabaseLabelRule{
+			{Databases: []string{"orders_*"}, Labels: map[string]string{"team": "payments"}},
+			{Databases: []string{"orders_archive"}, Labels: map[string]string{"tier": "cold"}},
+		},
+	}
+
+	labels := server.LabelsFor("orders_archive")
+	want := map[string]string{"env": "prod", "team": "payments", "tier": "cold"}
+	if len(labels) != len(want) {
+		t.Fatalf("LabelsFor() = %v, want %v", labels, want)
+	}
+	for key, value := range want {
+		if labels[key] != value {
+			t.Errorf("LabelsFor()[%s] = %q, want %q", key, labels[key], value)
+		}
+	}
+	if got := server.LabelsFor("users")["team"]; got != "core" {
+		t.Errorf("LabelsFor(users)[team] = %q, want core", got)
+	}
+
+	targets := BackupTargets{Labels: map[string]string{"team": "payments"}}
+	if !targets.MatchesServer(server) {
+		t.Error("MatchesServer() = false for a server with a matching database rule")
+	}
+	if !targets.MatchesDatabase(server, "orders_2026") {
+		t.Error("MatchesDatabase(orders_2026) = false")
+	}
+	if targets.MatchesDatabase(server, "users") {
+		t.Error("MatchesDatabase(users) = true")
+	}
+	if (BackupTargets{Labels: map[string]string{"team": "search"}}).MatchesServer(server) {
+		t.Error("MatchesServer() = true for a label no rule sets")
+	}
+
+	if err := (DatabaseLabelRule{Labels: map[string]string{"team": "x"}}).Validate(); err == nil {
+		t.Error("rule without databases: expected an error")
+	}
+}
+
+func TestRetentionFor(t *testing.T) {
+	rule := RetentionRule{KeepDaily: 7}
+	labelRules := []LabeledRetentionRule{
+		{Selector: map[string]string{"team": "payments"}, Retention: RetentionRule{KeepDaily: 30}},
+		{Selector: map[string]string{"env": "prod"}, Retention: RetentionRule{KeepDaily: 14}},
+	}
+
+	for _, tc := range []struct {
+		labels map[string]string
+		want   int
+	}{
+		{map[string]string{"team": "payments", "env": "prod"}, 30},
+		{map[string]string{"env": "prod"}, 14},
+		{map[string]string{"env": "dev"}, 7},
+		{nil, 7},
+	} {
+		if got := RetentionFor(rule, labelRules, tc.labels).KeepDaily; got != tc.want {
+			t.Errorf("RetentionFor(%v).KeepDaily = %d, want %d", tc.labels, got, tc.want)
+		}
+	}
+}
@@ -57,6 +57,7 @@ func (m *MySQLConfigLoader) LoadConfiguration() (*AppConfig, error) {
 		BackupTypes: make(map[string]BackupTypeConfig),
 		MetadataDB: MetadataDBConfig{
 			// Set sensible defaults
+			Type:            MetadataDBTypeMySQL,
 			MaxOpenConns:    25,
 			MaxIdleConns:    25,
 			ConnMaxLifetime: "5m",
@@ -99,6 +100,7 @@ func (m *MySQLConfigLoader) LoadConfiguration() (*AppConfig, error) {
 					Username:  server.Username,
 					Password:  server.Password,
 					Databases: server.IncludeDatabases,
+					TLS:       server.TLS,
 				}
 			}
 		}
@@ -151,6 +153,12 @@ func (m *MySQLConfigLoader) loadGlobalConfig(config *AppConfig) error {
 			// Parse JSON config for backward compatibility
 			var metaConfig map[string]interface{}
 			if err := json.Unmarshal([]byte(value), &metaConfig); err == nil {
+				if dbType, ok := metaConfig["type"].(string); ok {
+					config.MetadataDB.Type = dbType
+				}
+				if path, ok := metaConfig["path"].(string); ok {
+					config.MetadataDB.Path = path
+				}
 				if host, ok := metaConfig["host"].(string); ok {
 					config.MetadataDB.Host = host
 				}
@@ -169,6 +177,10 @@ func (m *MySQLConfigLoader) loadGlobalConfig(config *AppConfig) error {
 					config.MetadataDB.Password = password
 				}
 			}
+		case "metadata_database_type":
+			config.MetadataDB.Type = value
+		case "metadata_database_path":
+			config.MetadataDB.Path = value
 		case "metadata_database_host":
 			config.MetadataDB.Host = value
 		case "metadata_database_port":
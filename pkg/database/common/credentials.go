@@ -0,0 +1,66 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CredentialFile is a per-run file holding database credentials. It lives in a
+// private temporary directory so the secret never has to appear in a
+// process's argv or environment, where other users on the host could see it.
+type CredentialFile struct {
+	// Path is the location of the credential file
+	Path string
+
+	dir string
+}
+
+// NewCredentialFile writes contents to a 0600 file named name inside a fresh
+// 0700 temporary directory. Callers must call Remove once the command that
+// reads the file has finished.
+func NewCredentialFile(name string, contents []byte) (*CredentialFile, error) {
+	dir, err := os.MkdirTemp("", "gosqlguard-cred-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credential directory: %w", err)
+	}
+
+	// MkdirTemp already uses 0700, but be explicit in case of an unusual umask
+	if err := os.Chmod(dir, 0700); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to secure credential directory: %w", err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, contents, 0600); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to write credential file: %w", err)
+	}
+
+	return &CredentialFile{Path: path, dir: dir}, nil
+}
+
+// Remove deletes the credential file and its private directory
+func (c *CredentialFile) Remove() error {
+	if c == nil || c.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(c.dir)
+}
+
+// MySQLOptionFileContents returns a MySQL option file that supplies the
+// password to client programs such as mysqldump via --defaults-extra-file
+func MySQLOptionFileContents(password string) []byte {
+	// Option file values may be double-quoted, with backslash escapes
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(password)
+	return []byte(fmt.Sprintf("[client]\npassword=\"%s\"\n", escaped))
+}
+
+// PGPassFileContents returns a single-entry .pgpass file matching any host,
+// port and database for the given user
+func PGPassFileContents(user, password string) []byte {
+	// Backslashes and colons must be escaped in .pgpass fields
+	escape := strings.NewReplacer(`\`, `\\`, `:`, `\:`).Replace
+	return []byte(fmt.Sprintf("*:*:*:%s:%s\n", escape(user), escape(password)))
+}
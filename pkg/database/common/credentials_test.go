@@ -0,0 +1,68 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCredentialFile(t *testing.T) {
+	credFile, err := NewCredentialFile("my.cnf", []byte("secret"))
+	require.NoError(t, err)
+
+	// File and directory must only be accessible by the current user
+	info, err := os.Stat(credFile.Path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	dirInfo, err := os.Stat(filepath.Dir(credFile.Path))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), dirInfo.Mode().Perm())
+
+	data, err := os.ReadFile(credFile.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(data))
+
+	// Remove deletes the whole private directory
+	require.NoError(t, credFile.Remove())
+	_, err = os.Stat(filepath.Dir(credFile.Path))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCredentialFileContents(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      []byte
+		expected string
+	}{
+		{
+			name:     "MySQL plain password",
+			got:      MySQLOptionFileContents("s3cret"),
+			expected: "[client]\npassword=\"s3cret\"\n",
+		},
+		{
+			name:     "MySQL password with quotes and backslashes",
+			got:      MySQLOptionFileContents(`a"b\c`),
+			expected: "[client]\npassword=\"a\\\"b\\\\c\"\n",
+		},
+		{
+			name:     "pgpass plain password",
+			got:      PGPassFileContents("backup", "s3cret"),
+			expected: "*:*:*:backup:s3cret\n",
+		},
+		{
+			name:     "pgpass password with colons and backslashes",
+			got:      PGPassFileContents("backup", `a:b\c`),
+			expected: "*:*:*:backup:a\\:b\\\\c\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(tt.got))
+		})
+	}
+}
@@ -38,6 +38,16 @@ type Provider interface {
 	GetDatabases() []string
 }
 
+// VersionReporter is implemented by providers that can report the versions
+// recorded in backup manifests
+type VersionReporter interface {
+	// ServerVersion returns the version the database server reports
+	ServerVersion(ctx context.Context) (string, error)
+
+	// ToolVersion returns the version of the dump tool
+	ToolVersion(ctx context.Context) (string, error)
+}
+
 // BackupOptions contains options for the backup operation
 type BackupOptions struct {
 	// Compression indicates whether the backup should be compressed
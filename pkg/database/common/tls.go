@@ -0,0 +1,239 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+)
+
+// BuildTLSConfig creates a crypto/tls configuration for the given settings.
+// It returns nil when TLS is not enabled.
+func BuildTLSConfig(cfg config.DatabaseTLSConfig, serverName string) (*tls.Config, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Mode == "" || cfg.Mode == config.TLSModeDisable {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	// Load the CA bundle used to verify the server
+	var roots *x509.CertPool
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA file %s: %w", cfg.CAFile, err)
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in TLS CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	// Load the client certificate for mutual TLS
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	switch cfg.Mode {
+	case config.TLSModePrefer, config.TLSModeRequire:
+		// Encrypt only, as libpq and mysqldump do for this mode
+		tlsConfig.InsecureSkipVerify = true
+	case config.TLSModeVerifyCA:
+		// Verify the chain ourselves since the standard check also
+		// insists on a matching host name
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(rawCerts, roots)
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// verifyChain checks that the presented certificates chain to one of roots
+func verifyChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("server presented no certificate")
+	}
+
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse server certificate: %w", err)
+		}
+		certs[i] = cert
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// MySQLTLSParams registers the TLS settings with the MySQL driver and returns
+// the DSN parameters that use them, or "" when TLS is not enabled. In prefer
+// mode the driver falls back to plain text when the server does not offer
+// TLS, still presenting the client certificate when it does.
+func MySQLTLSParams(cfg config.DatabaseTLSConfig, host string) (string, error) {
+	tlsConfig, err := BuildTLSConfig(cfg, host)
+	if err != nil {
+		return "", err
+	}
+	if tlsConfig == nil {
+		return "", nil
+	}
+
+	// Name the registration after its settings so repeated connections to
+	// the same server reuse one entry while picking up rotated files
+	sum := sha256.Sum256([]byte(strings.Join([]string{cfg.Mode, cfg.CAFile, cfg.CertFile, cfg.KeyFile, host}, "|")))
+	name := "gosqlguard-" + hex.EncodeToString(sum[:8])
+
+	if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", fmt.Errorf("failed to register MySQL TLS config: %w", err)
+	}
+
+	params := "tls=" + name
+	if cfg.Mode == config.TLSModePrefer {
+		params += "&allowFallbackToPlaintext=true"
+	}
+	return params, nil
+}
+
+// MySQLDumpTLSArgs returns the mysqldump flags for the TLS settings
+func MySQLDumpTLSArgs(cfg config.DatabaseTLSConfig) []string {
+	var args []string
+
+	switch cfg.Mode {
+	case config.TLSModeDisable:
+		args = append(args, "--ssl-mode=DISABLED")
+	case config.TLSModePrefer:
+		args = append(args, "--ssl-mode=PREFERRED")
+	case config.TLSModeRequire:
+		args = append(args, "--ssl-mode=REQUIRED")
+	case config.TLSModeVerifyCA:
+		args = append(args, "--ssl-mode=VERIFY_CA")
+	case config.TLSModeVerifyFull:
+		args = append(args, "--ssl-mode=VERIFY_IDENTITY")
+	default:
+		return nil
+	}
+
+	if cfg.Mode == config.TLSModeDisable {
+		return args
+	}
+
+	if cfg.CAFile != "" {
+		args = append(args, "--ssl-ca="+cfg.CAFile)
+	}
+	if cfg.CertFile != "" {
+		args = append(args, "--ssl-cert="+cfg.CertFile, "--ssl-key="+cfg.KeyFile)
+	}
+
+	return args
+}
+
+// PostgreSQLSSLMode returns the libpq sslmode for the TLS settings. An empty
+// mode means prefer, libpq's own default.
+func PostgreSQLSSLMode(cfg config.DatabaseTLSConfig) string {
+	if cfg.Mode == "" {
+		return config.TLSModePrefer
+	}
+	return cfg.Mode
+}
+
+// PostgreSQLDSNParams returns the lib/pq connection string parameters for the
+// TLS settings. lib/pq has no prefer mode, so prefer is sent as require and
+// OpenPostgreSQL falls back to plain text.
+func PostgreSQLDSNParams(cfg config.DatabaseTLSConfig) string {
+	mode := PostgreSQLSSLMode(cfg)
+	if mode == config.TLSModeDisable {
+		return "sslmode=" + mode
+	}
+	if mode == config.TLSModePrefer {
+		mode = config.TLSModeRequire
+	}
+
+	params := "sslmode=" + mode
+	if cfg.CAFile != "" {
+		params += " sslrootcert=" + quotePQValue(cfg.CAFile)
+	}
+	if cfg.CertFile != "" {
+		params += " sslcert=" + quotePQValue(cfg.CertFile) + " sslkey=" + quotePQValue(cfg.KeyFile)
+	}
+
+	return params
+}
+
+// OpenPostgreSQL opens and pings a lib/pq connection for conninfo with the TLS
+// settings. In prefer mode it retries without TLS when the server refuses it.
+func OpenPostgreSQL(ctx context.Context, conninfo string, cfg config.DatabaseTLSConfig) (*sql.DB, error) {
+	db, err := pingPostgreSQL(ctx, conninfo+" "+PostgreSQLDSNParams(cfg))
+	if errors.Is(err, pq.ErrSSLNotSupported) && PostgreSQLSSLMode(cfg) == config.TLSModePrefer {
+		db, err = pingPostgreSQL(ctx, conninfo+" sslmode=disable")
+	}
+	return db, err
+}
+
+// pingPostgreSQL opens a lib/pq connection for dsn and checks it is usable
+func pingPostgreSQL(ctx context.Context, dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PostgreSQL connection: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping PostgreSQL server: %w", err)
+	}
+	return db, nil
+}
+
+// PostgreSQLTLSEnv returns the libpq environment variables for pg_dump
+func PostgreSQLTLSEnv(cfg config.DatabaseTLSConfig) []string {
+	env := []string{"PGSSLMODE=" + PostgreSQLSSLMode(cfg)}
+	if PostgreSQLSSLMode(cfg) == config.TLSModeDisable {
+		return env
+	}
+
+	if cfg.CAFile != "" {
+		env = append(env, "PGSSLROOTCERT="+cfg.CAFile)
+	}
+	if cfg.CertFile != "" {
+		env = append(env, "PGSSLCERT="+cfg.CertFile, "PGSSLKEY="+cfg.KeyFile)
+	}
+
+	return env
+}
+
+// quotePQValue quotes a value for a lib/pq key=value connection string
+func quotePQValue(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return "'" + escaped + "'"
+}
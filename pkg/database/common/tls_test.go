@@ -0,0 +1,292 @@
+package common
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+)
+
+// writeTestCA creates a CA and a server certificate for dnsName, returning
+// the CA file path and the server certificate
+func writeTestCA(t *testing.T, dnsName string) (string, tls.Certificate) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serverTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	serverDER, err := x509.CreateCertificate(rand.Reader, serverTemplate, caCert, &serverKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600))
+
+	return caFile, tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}
+}
+
+// writeTestClientCert writes a self-signed client certificate and its key,
+// returning their file paths
+func writeTestClientCert(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "gosqlguard"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+// handshake runs a TLS handshake against a server presenting cert
+func handshake(t *testing.T, cert tls.Certificate, clientConfig *tls.Config) error {
+	t.Helper()
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	go func() {
+		server := tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{cert}})
+		_ = server.Handshake()
+	}()
+
+	return tls.Client(clientConn, clientConfig).Handshake()
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	caFile, serverCert := writeTestCA(t, "db.internal")
+
+	t.Run("disabled returns nil", func(t *testing.T) {
+		for _, mode := range []string{"", config.TLSModeDisable} {
+			tlsConfig, err := BuildTLSConfig(config.DatabaseTLSConfig{Mode: mode}, "db.internal")
+			require.NoError(t, err)
+			assert.Nil(t, tlsConfig)
+		}
+	})
+
+	t.Run("verify-full checks host name", func(t *testing.T) {
+		good, err := BuildTLSConfig(config.DatabaseTLSConfig{Mode: config.TLSModeVerifyFull, CAFile: caFile}, "db.internal")
+		require.NoError(t, err)
+		assert.NoError(t, handshake(t, serverCert, good))
+
+		wrongHost, err := BuildTLSConfig(config.DatabaseTLSConfig{Mode: config.TLSModeVerifyFull, CAFile: caFile}, "other.internal")
+		require.NoError(t, err)
+		assert.Error(t, handshake(t, serverCert, wrongHost))
+	})
+
+	t.Run("verify-ca ignores host name but checks chain", func(t *testing.T) {
+		tlsConfig, err := BuildTLSConfig(config.DatabaseTLSConfig{Mode: config.TLSModeVerifyCA, CAFile: caFile}, "10.0.0.5")
+		require.NoError(t, err)
+		assert.NoError(t, handshake(t, serverCert, tlsConfig))
+
+		otherCA, _ := writeTestCA(t, "db.internal")
+		untrusted, err := BuildTLSConfig(config.DatabaseTLSConfig{Mode: config.TLSModeVerifyCA, CAFile: otherCA}, "db.internal")
+		require.NoError(t, err)
+		assert.Error(t, handshake(t, serverCert, untrusted))
+	})
+
+	t.Run("require skips verification", func(t *testing.T) {
+		tlsConfig, err := BuildTLSConfig(config.DatabaseTLSConfig{Mode: config.TLSModeRequire}, "anything")
+		require.NoError(t, err)
+		assert.NoError(t, handshake(t, serverCert, tlsConfig))
+	})
+
+	t.Run("invalid settings are rejected", func(t *testing.T) {
+		_, err := BuildTLSConfig(config.DatabaseTLSConfig{Mode: "bogus"}, "db.internal")
+		assert.Error(t, err)
+
+		_, err = BuildTLSConfig(config.DatabaseTLSConfig{Mode: config.TLSModeVerifyFull, CAFile: "/nonexistent/ca.pem"}, "db.internal")
+		assert.Error(t, err)
+	})
+}
+
+func TestMySQLDumpTLSArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.DatabaseTLSConfig
+		expected []string
+	}{
+		{name: "Unset keeps client default", cfg: config.DatabaseTLSConfig{}, expected: nil},
+		{name: "Disable", cfg: config.DatabaseTLSConfig{Mode: "disable", CAFile: "/ca.pem"}, expected: []string{"--ssl-mode=DISABLED"}},
+		{name: "Prefer", cfg: config.DatabaseTLSConfig{Mode: "prefer"}, expected: []string{"--ssl-mode=PREFERRED"}},
+		{name: "Require", cfg: config.DatabaseTLSConfig{Mode: "require"}, expected: []string{"--ssl-mode=REQUIRED"}},
+		{
+			name:     "Verify CA",
+			cfg:      config.DatabaseTLSConfig{Mode: "verify-ca", CAFile: "/ca.pem"},
+			expected: []string{"--ssl-mode=VERIFY_CA", "--ssl-ca=/ca.pem"},
+		},
+		{
+			name:     "Verify full with client certificate",
+			cfg:      config.DatabaseTLSConfig{Mode: "verify-full", CAFile: "/ca.pem", CertFile: "/c.pem", KeyFile: "/k.pem"},
+			expected: []string{"--ssl-mode=VERIFY_IDENTITY", "--ssl-ca=/ca.pem", "--ssl-cert=/c.pem", "--ssl-key=/k.pem"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MySQLDumpTLSArgs(tt.cfg))
+		})
+	}
+}
+
+func TestPostgreSQLTLSParams(t *testing.T) {
+	// An unset mode means prefer, which lib/pq only knows as require
+	assert.Equal(t, "sslmode=require", PostgreSQLDSNParams(config.DatabaseTLSConfig{}))
+	assert.Equal(t, []string{"PGSSLMODE=prefer"}, PostgreSQLTLSEnv(config.DatabaseTLSConfig{}))
+
+	disabled := config.DatabaseTLSConfig{Mode: "disable", CAFile: "/ca.pem"}
+	assert.Equal(t, "sslmode=disable", PostgreSQLDSNParams(disabled))
+	assert.Equal(t, []string{"PGSSLMODE=disable"}, PostgreSQLTLSEnv(disabled))
+
+	cfg := config.DatabaseTLSConfig{Mode: "verify-full", CAFile: "/etc/ssl/ca.pem", CertFile: "/c.pem", KeyFile: "/k.pem"}
+	assert.Equal(t,
+		"sslmode=verify-full sslrootcert='/etc/ssl/ca.pem' sslcert='/c.pem' sslkey='/k.pem'",
+		PostgreSQLDSNParams(cfg))
+	assert.Equal(t,
+		[]string{"PGSSLMODE=verify-full", "PGSSLROOTCERT=/etc/ssl/ca.pem", "PGSSLCERT=/c.pem", "PGSSLKEY=/k.pem"},
+		PostgreSQLTLSEnv(cfg))
+}
+
+func TestMySQLTLSParams(t *testing.T) {
+	caFile, _ := writeTestCA(t, "db.internal")
+
+	params, err := MySQLTLSParams(config.DatabaseTLSConfig{}, "db.internal")
+	require.NoError(t, err)
+	assert.Empty(t, params)
+
+	cfg := config.DatabaseTLSConfig{Mode: config.TLSModeVerifyFull, CAFile: caFile}
+	params, err = MySQLTLSParams(cfg, "db.internal")
+	require.NoError(t, err)
+	assert.Regexp(t, `^tls=gosqlguard-[0-9a-f]{16}$`, params)
+
+	// The same settings map to the same registration
+	again, err := MySQLTLSParams(cfg, "db.internal")
+	require.NoError(t, err)
+	assert.Equal(t, params, again)
+
+	// Prefer mode keeps the CA and client certificate, and may fall back to
+	// plain text
+	certFile, keyFile := writeTestClientCert(t)
+	prefer := config.DatabaseTLSConfig{Mode: config.TLSModePrefer, CAFile: caFile, CertFile: certFile, KeyFile: keyFile}
+	params, err = MySQLTLSParams(prefer, "db.internal")
+	require.NoError(t, err)
+	assert.Regexp(t, `^tls=gosqlguard-[0-9a-f]{16}&allowFallbackToPlaintext=true$`, params)
+
+	dsn, err := mysql.ParseDSN("user:pass@tcp(db.internal:3306)/?" + params)
+	require.NoError(t, err)
+	assert.True(t, dsn.AllowFallbackToPlaintext)
+	require.NotNil(t, dsn.TLS)
+	assert.Len(t, dsn.TLS.Certificates, 1)
+	assert.NotNil(t, dsn.TLS.RootCAs)
+}
+
+// refusingPostgreSQL serves connections that decline TLS and then hang up,
+// recording whether each one asked for TLS first
+func refusingPostgreSQL(t *testing.T) (string, <-chan bool) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	attempts := make(chan bool, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			header := make([]byte, 8)
+			if _, err := io.ReadFull(conn, header); err == nil {
+				sslRequest := binary.BigEndian.Uint32(header[4:]) == 80877103
+				attempts <- sslRequest
+				if sslRequest {
+					_, _ = conn.Write([]byte{'N'})
+				}
+			}
+			conn.Close()
+		}
+	}()
+
+	return listener.Addr().String(), attempts
+}
+
+func TestOpenPostgreSQL(t *testing.T) {
+	addr, attempts := refusingPostgreSQL(t)
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	conninfo := fmt.Sprintf("host=%s port=%s user=backup dbname=postgres connect_timeout=5", host, port)
+
+	t.Run("prefer falls back to plain text", func(t *testing.T) {
+		_, err := OpenPostgreSQL(context.Background(), conninfo, config.DatabaseTLSConfig{})
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, pq.ErrSSLNotSupported))
+		assert.True(t, <-attempts, "the first attempt asks for TLS")
+		assert.False(t, <-attempts, "the retry does not")
+	})
+
+	t.Run("require does not fall back", func(t *testing.T) {
+		_, err := OpenPostgreSQL(context.Background(), conninfo, config.DatabaseTLSConfig{Mode: config.TLSModeRequire})
+		assert.ErrorIs(t, err, pq.ErrSSLNotSupported)
+		assert.True(t, <-attempts)
+		assert.Empty(t, attempts)
+	})
+}
@@ -80,6 +80,7 @@ func Initialize() error {
 			postgresFactory.User = config.CFG.PostgreSQL.Username
 			postgresFactory.Password = config.CFG.PostgreSQL.Password
 			postgresFactory.Databases = config.CFG.PostgreSQL.Databases
+			postgresFactory.TLS = config.CFG.PostgreSQL.TLS
 
 			// Create provider instance
 			provider, err := postgresFactory.Create()
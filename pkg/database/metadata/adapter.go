@@ -59,56 +59,10 @@ func (s *DBMetadataStore) MigrateFromFileStore() error {
 	// Convert file backups to DB model
 	var dbBackups []Backup
 	for _, fb := range fileBackups {
-		// Create database model backup
-		backup := Backup{
-			ID:              fb.ID,
-			ServerName:      fb.ServerName,
-			ServerType:      fb.ServerType,
-			DatabaseName:    fb.Database,
-			BackupType:      fb.BackupType,
-			CreatedAt:       fb.CreatedAt,
-			Size:            fb.Size,
-			Status:          string(fb.Status),
-			ErrorMessage:    fb.ErrorMessage,
-			RetentionPolicy: fb.RetentionPolicy,
-			LogFilePath:     fb.LogFilePath,
-			S3UploadStatus:  string(fb.S3UploadStatus),
-			S3UploadError:   fb.S3UploadError,
-		}
-
-		// Handle times (some might be zero)
-		if !fb.CompletedAt.IsZero() {
-			completedAt := fb.CompletedAt
-			backup.CompletedAt = &completedAt
-		}
-
-		if !fb.ExpiresAt.IsZero() {
-			expiresAt := fb.ExpiresAt
-			backup.ExpiresAt = &expiresAt
-		}
-
-		if !fb.S3UploadComplete.IsZero() {
-			s3UploadComplete := fb.S3UploadComplete
-			backup.S3UploadComplete = &s3UploadComplete
-		}
-
-		// Handle related data
-		for org, path := range fb.LocalPaths {
-			backup.LocalPaths = append(backup.LocalPaths, LocalPath{
-				BackupID:     fb.ID,
-				Organization: org,
-				Path:         path,
-			})
-		}
-
-		for org, key := range fb.S3Keys {
-			backup.S3Keys = append(backup.S3Keys, S3Key{
-				BackupID:     fb.ID,
-				Organization: org,
-				Key:          key,
-			})
+		backup, err := NewBackup(fb)
+		if err != nil {
+			return err
 		}
-
 		dbBackups = append(dbBackups, backup)
 	}
 
@@ -141,6 +95,9 @@ func (s *DBMetadataStore) CreateBackupMeta(serverName, serverType, database, bac
 	if typeConfig, exists := config.CFG.BackupTypes[backupType]; exists {
 		if typeConfig.Local.Enabled && typeConfig.Local.Retention.Forever {
 			retentionText = "Keep forever"
+		} else if typeConfig.Local.Enabled && typeConfig.Local.Retention.HasCountRules() {
+			// Count-based rules have no fixed expiry; it depends on later backups
+			retentionText = typeConfig.Local.Retention.Description()
 		} else if typeConfig.Local.Enabled {
 			duration, err := time.ParseDuration(typeConfig.Local.Retention.Duration)
 			if err == nil {
@@ -247,6 +204,90 @@ func (s *DBMetadataStore) UpdateLogFilePath(id string, logFilePath string) error
 	return s.repo.UpdateLogFilePath(id, logFilePath)
 }
 
+// UpdateFencingToken records the leader lease token of the replica running a backup
+func (s *DBMetadataStore) UpdateFencingToken(id string, token int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.repo.UpdateFencingToken(id, token)
+}
+
+// UpdateLabels replaces the labels of a backup
+func (s *DBMetadataStore) UpdateLabels(id string, labels map[string]string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.repo.UpdateLabels(id, labels)
+}
+
+// MergeAnnotations applies changes to the annotations of a backup in a
+// single step and returns the resulting annotations
+func (s *DBMetadataStore) MergeAnnotations(id string, changes map[string]string) (map[string]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.repo.MergeAnnotations(id, changes)
+}
+
+// PutBackup stores a backup as given, replacing any backup with the same ID
+func (s *DBMetadataStore) PutBackup(backup types.BackupMeta) error {
+	return s.PutBackups([]types.BackupMeta{backup})
+}
+
+// PutBackups stores backups as given, replacing any backups with the same
+// IDs, and recalculates the stats once
+func (s *DBMetadataStore) PutBackups(backups []types.BackupMeta) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.repo.PutBackups(backups); err != nil {
+		return err
+	}
+	if err := s.repo.RecalculateStats(); err != nil {
+		log.Printf("Warning: Failed to recalculate stats: %v", err)
+	}
+	return nil
+}
+
+// UpdateHold places a hold on a backup, or releases it when hold is nil
+func (s *DBMetadataStore) UpdateHold(id string, hold *types.BackupHold) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.repo.UpdateHold(id, hold)
+}
+
+// AddAuditEntry appends an entry to the audit log
+func (s *DBMetadataStore) AddAuditEntry(entry types.AuditEntry) error {
+	return s.repo.AddAuditEntry(entry)
+}
+
+// GetAuditEntries returns up to limit audit entries, newest first, for one
+// backup or for all of them when backupID is empty
+func (s *DBMetadataStore) GetAuditEntries(backupID string, limit int) []types.AuditEntry {
+	records, err := s.repo.GetAuditEntries(backupID, limit)
+	if err != nil {
+		log.Printf("Error retrieving audit entries from database: %v", err)
+		return nil
+	}
+	entries := make([]types.AuditEntry, 0, len(records))
+	for _, record := range records {
+		entries = append(entries, record.Entry())
+	}
+	return entries
+}
+
+// SaveStatsSnapshots stores daily stats snapshots, replacing those of the
+// same day, server and database
+func (s *DBMetadataStore) SaveStatsSnapshots(snapshots []types.StatsSnapshot) error {
+	return s.repo.SaveStatsSnapshots(snapshots)
+}
+
+// GetStatsHistory returns the stats snapshots matching a query, oldest first
+func (s *DBMetadataStore) GetStatsHistory(q types.StatsHistoryQuery) ([]types.StatsSnapshot, error) {
+	return s.repo.GetStatsHistory(q)
+}
+
 // GetBackups returns all backups
 func (s *DBMetadataStore) GetBackups() []types.BackupMeta {
 	s.mutex.RLock()
@@ -285,6 +326,22 @@ func (s *DBMetadataStore) GetBackupsFiltered(serverName, database, backupType st
 	return s.convertToOriginalFormat(dbBackups)
 }
 
+// QueryBackups returns one page of the backups matching a query
+func (s *DBMetadataStore) QueryBackups(q types.BackupQuery) (types.BackupPage, error) {
+	if err := q.Normalize(); err != nil {
+		return types.BackupPage{}, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	dbBackups, total, err := s.repo.QueryBackups(q)
+	if err != nil {
+		return types.BackupPage{}, err
+	}
+	return types.NewBackupPage(q, s.convertToOriginalFormat(dbBackups), total), nil
+}
+
 // GetBackupByID returns a specific backup by ID
 func (s *DBMetadataStore) GetBackupByID(id string) (types.BackupMeta, bool) {
 	s.mutex.RLock()
@@ -319,6 +376,14 @@ func (s *DBMetadataStore) MarkBackupDeleted(id string) error {
 	return s.repo.MarkBackupDeleted(id)
 }
 
+// UpdateRetentionStatus records the outcome of retention deleting a backup from a storage location
+func (s *DBMetadataStore) UpdateRetentionStatus(id, location string, remaining map[string]string, errorMsg string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.repo.UpdateRetentionStatus(id, location, remaining, errorMsg)
+}
+
 // GetStats returns statistics about the backups
 func (s *DBMetadataStore) GetStats() map[string]interface{} {
 	s.mutex.RLock()
@@ -345,7 +410,7 @@ func (s *DBMetadataStore) GetStats() map[string]interface{} {
 }
 
 // PurgeDeletedBackups removes backup entries that have been marked as deleted
-// and are older than the specified duration
+// and are older than the specified duration, except held ones
 func (s *DBMetadataStore) PurgeDeletedBackups(olderThan time.Duration) int {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -392,6 +457,11 @@ func (s *DBMetadataStore) convertToOriginalFormat(dbBackups []Backup) []types.Ba
 			LogFilePath:     db.LogFilePath,
 			S3UploadStatus:  types.BackupStatus(db.S3UploadStatus),
 			S3UploadError:   db.S3UploadError,
+			RetentionError:  db.RetentionError,
+			FencingToken:    db.FencingToken,
+			Labels:          db.LabelMap(),
+			Annotations:     db.AnnotationMap(),
+			Hold:            db.Hold(),
 			LocalPaths:      make(map[string]string),
 			S3Keys:          make(map[string]string),
 		}
@@ -409,6 +479,14 @@ func (s *DBMetadataStore) convertToOriginalFormat(dbBackups []Backup) []types.Ba
 			backup.S3UploadComplete = *db.S3UploadComplete
 		}
 
+		if db.LocalDeletedAt != nil {
+			backup.LocalDeletedAt = *db.LocalDeletedAt
+		}
+
+		if db.S3DeletedAt != nil {
+			backup.S3DeletedAt = *db.S3DeletedAt
+		}
+
 		// Add local paths
 		for _, path := range db.LocalPaths {
 			backup.LocalPaths[path.Organization] = path.Path
@@ -5,7 +5,6 @@ import (
 	"log"
 	"time"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
@@ -44,9 +43,10 @@ func Initialize() error {
 func Connect() (*gorm.DB, error) {
 	cfg := config.CFG.MetadataDB
 
-	// Build DSN
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
+	dialector, err := Dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// Set up logger config based on debug mode
 	logLevel := logger.Silent
@@ -55,7 +55,7 @@ func Connect() (*gorm.DB, error) {
 	}
 
 	// Connect to database
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logLevel),
 	})
 	if err != nil {
@@ -82,43 +82,26 @@ func Connect() (*gorm.DB, error) {
 		sqlDB.SetConnMaxLifetime(duration)
 	}
 
-	log.Printf("Connected to metadata database at %s:%d", cfg.Host, cfg.Port)
+	log.Printf("Connected to %s metadata database at %s", cfg.Type, Location(cfg))
 	return db, nil
 }
 
-// RunMigrations runs all necessary database migrations
+// RunMigrations applies the pending schema migrations
 func RunMigrations(db *gorm.DB) error {
-	// Create the tables if they don't exist
-	err := db.AutoMigrate(
-		&Backup{},
-		&LocalPath{},
-		&S3Key{},
-		&Stats{},
-		&ServerConfig{},
-		&ServerDatabaseFilter{},
-		&ServerMySQLOption{},
-		&BackupSchedule{},
-		&ScheduleRetentionPolicy{},
-	)
+	_, err := NewMigrator(db).Up(0)
+	return err
+}
+
+// CheckMigrations warns when the schema is behind this release, for
+// deployments that run `gosqlguard migrate up` themselves
+func CheckMigrations(db *gorm.DB) error {
+	pending, err := NewMigrator(db).Pending()
 	if err != nil {
-		return fmt.Errorf("failed to migrate tables: %w", err)
+		return err
 	}
-
-	// Initialize stats record if it doesn't exist
-	var count int64
-	db.Model(&Stats{}).Count(&count)
-	if count == 0 {
-		log.Println("Initializing metadata stats record")
-		stats := Stats{
-			ID:          1,
-			Version:     "1.0",
-			LastUpdated: time.Now(),
-		}
-		if err := db.Create(&stats).Error; err != nil {
-			return fmt.Errorf("failed to create initial stats record: %w", err)
-		}
+	if len(pending) > 0 {
+		log.Printf("Warning: %d metadata schema migrations are pending; run `gosqlguard migrate up`", len(pending))
 	}
-
 	return nil
 }
 
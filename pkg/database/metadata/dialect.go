@@ -0,0 +1,116 @@
+package metadata
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+)
+
+// Dialector returns the gorm dialector for the configured metadata database
+func Dialector(cfg config.MetadataDBConfig) (gorm.Dialector, error) {
+	switch cfg.Type {
+	case "", config.MetadataDBTypeMySQL:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
+		return mysql.Open(dsn), nil
+	case config.MetadataDBTypePostgreSQL:
+		dsn := url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(cfg.Username, cfg.Password),
+			Host:   net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+			Path:   "/" + cfg.Database,
+		}
+		return postgres.Open(dsn.String()), nil
+	case config.MetadataDBTypeSQLite:
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), 0750); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", cfg.Path, err)
+		}
+		// Transactions take the write lock when they begin and wait for it,
+		// so concurrent writers queue up instead of failing as busy. SQLite
+		// leaves foreign keys off unless asked, which skips cascading deletes.
+		dsn := "file:" + cfg.Path + "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_txlock=immediate"
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported metadata database type %q", cfg.Type)
+	}
+}
+
+// Location describes where the configured metadata database lives, for logs
+func Location(cfg config.MetadataDBConfig) string {
+	if cfg.Embedded() {
+		return cfg.Path
+	}
+	return net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+}
+
+// IsPostgres reports whether db is connected to PostgreSQL
+func IsPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == "postgres"
+}
+
+// IsSQLite reports whether db is the embedded SQLite store
+func IsSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == "sqlite"
+}
+
+// likeEscape escapes wildcards in LIKE patterns. A backslash would need
+// escaping itself in MySQL string literals, so another character is used.
+const likeEscape = '!'
+
+// ContainsCondition returns a condition matching rows where any of the
+// columns contains term, ignoring case, with its arguments. Wildcards in term
+// match literally.
+func ContainsCondition(db *gorm.DB, term string, columns ...string) (string, []interface{}) {
+	// MySQL's default collation and SQLite's LIKE already ignore case
+	like := "LIKE"
+	if IsPostgres(db) {
+		like = "ILIKE"
+	}
+
+	var pattern strings.Builder
+	pattern.WriteRune('%')
+	for _, r := range term {
+		if r == '%' || r == '_' || r == likeEscape {
+			pattern.WriteRune(likeEscape)
+		}
+		pattern.WriteRune(r)
+	}
+	pattern.WriteRune('%')
+
+	conditions := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		conditions[i] = fmt.Sprintf("%s %s ? ESCAPE '%c'", column, like, likeEscape)
+		args[i] = pattern.String()
+	}
+	return strings.Join(conditions, " OR "), args
+}
+
+// UpsertClause returns the clause that turns an INSERT into an update of
+// columns when a row with the same conflict key already exists. MySQL finds
+// the conflicting row through any unique key; PostgreSQL and SQLite need it
+// named.
+func UpsertClause(db *gorm.DB, conflict string, columns ...string) string {
+	updates := make([]string, 0, len(columns))
+	if IsPostgres(db) || IsSQLite(db) {
+		for _, column := range columns {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+		}
+		return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", conflict, strings.Join(updates, ", "))
+	}
+	for _, column := range columns {
+		updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", column, column))
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+}
@@ -0,0 +1,133 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LeaseRepository handles database operations for leader leases
+type LeaseRepository struct {
+	db *gorm.DB
+}
+
+// NewLeaseRepository creates a new LeaseRepository instance
+func NewLeaseRepository(db *gorm.DB) *LeaseRepository {
+	return &LeaseRepository{db: db}
+}
+
+// Acquire renews the named lease for holder or takes it over once it has
+// expired. It returns the lease as stored and whether holder now holds it.
+// Each step is a single conditional statement, so two replicas racing for
+// the lease cannot both win. Expiry is set and checked against the database
+// clock, so replicas with skewed clocks still agree on when a lease lapses.
+func (r *LeaseRepository) Acquire(name, holder string, ttl time.Duration) (*LeaderLease, bool, error) {
+	now, expires := dbClock(r.db, ttl)
+
+	// Renew a lease we still hold
+	result := r.db.Model(&LeaderLease{}).
+		Where("name = ? AND holder = ? AND expires_at > ?", name, holder, now).
+		Updates(map[string]interface{}{"renewed_at": now, "expires_at": expires})
+	if result.Error != nil {
+		return nil, false, fmt.Errorf("failed to renew lease %s: %w", name, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// Take over an expired lease, fencing off the previous holder
+		result = r.db.Model(&LeaderLease{}).
+			Where("name = ? AND expires_at <= ?", name, now).
+			Updates(map[string]interface{}{
+				"holder":      holder,
+				"token":       gorm.Expr("token + 1"),
+				"acquired_at": now,
+				"renewed_at":  now,
+				"expires_at":  expires,
+			})
+		if result.Error != nil {
+			return nil, false, fmt.Errorf("failed to take over lease %s: %w", name, result.Error)
+		}
+	}
+	if result.RowsAffected == 0 {
+		lease, err := r.Get(name)
+		if err != nil {
+			return nil, false, err
+		}
+		if lease != nil {
+			return lease, false, nil
+		}
+
+		// Nobody has held the lease yet. Creating it fails on the primary key
+		// if another replica gets there first.
+		err = r.db.Model(&LeaderLease{}).Create(map[string]interface{}{
+			"name":        name,
+			"holder":      holder,
+			"token":       1,
+			"acquired_at": now,
+			"renewed_at":  now,
+			"expires_at":  expires,
+		}).Error
+		if err != nil {
+			current, getErr := r.Get(name)
+			if getErr != nil || current == nil {
+				return nil, false, fmt.Errorf("failed to create lease %s: %w", name, err)
+			}
+			return current, false, nil
+		}
+	}
+
+	lease, err := r.Get(name)
+	if err != nil {
+		return nil, false, err
+	}
+	if lease == nil || lease.Holder != holder {
+		return lease, false, nil
+	}
+	return lease, true, nil
+}
+
+// dbClock returns expressions for the current time and the time ttl from
+// now, read from the database clock when the statement runs
+func dbClock(db *gorm.DB, ttl time.Duration) (now, expires clause.Expr) {
+	switch {
+	case IsPostgres(db):
+		return gorm.Expr("CURRENT_TIMESTAMP"),
+			gorm.Expr("CURRENT_TIMESTAMP + make_interval(secs => ?)", ttl.Seconds())
+	case IsSQLite(db):
+		// The embedded database is never shared between hosts, so the local
+		// clock is the database clock
+		local := time.Now()
+		return gorm.Expr("?", local), gorm.Expr("?", local.Add(ttl))
+	default:
+		return gorm.Expr("NOW(3)"), gorm.Expr("NOW(3) + INTERVAL ? MICROSECOND", ttl.Microseconds())
+	}
+}
+
+// Release gives up the named lease if holder still holds it with the given
+// token, letting another replica take over without waiting for it to expire
+func (r *LeaseRepository) Release(name, holder string, token int64) error {
+	now, _ := dbClock(r.db, 0)
+	err := r.db.Model(&LeaderLease{}).
+		Where("name = ? AND holder = ? AND token = ?", name, holder, token).
+		Update("expires_at", now).Error
+	if err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+	return nil
+}
+
+// Get retrieves the named lease, or nil if it has never been held
+func (r *LeaseRepository) Get(name string) (*LeaderLease, error) {
+	var lease LeaderLease
+
+	err := r.db.Where("name = ?", name).First(&lease).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get lease %s: %w", name, err)
+	}
+
+	return &lease, nil
+}
@@ -0,0 +1,42 @@
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaseRepositoryAcquire(t *testing.T) {
+	db := openSQLite(t)
+	_, err := NewMigrator(db).Up(0)
+	require.NoError(t, err)
+	repo := NewLeaseRepository(db)
+
+	lease, held, err := repo.Acquire("scheduler", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, int64(1), lease.Token)
+	assert.True(t, lease.ExpiresAt.After(time.Now()))
+
+	// Another replica cannot take over a live lease
+	lease, held, err = repo.Acquire("scheduler", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, held)
+	assert.Equal(t, "a", lease.Holder)
+
+	// The holder renews it within the same term
+	lease, held, err = repo.Acquire("scheduler", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, int64(1), lease.Token)
+
+	// Once released, the next replica takes over with a new token
+	require.NoError(t, repo.Release("scheduler", "a", 1))
+	lease, held, err = repo.Acquire("scheduler", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, "b", lease.Holder)
+	assert.Equal(t, int64(2), lease.Token)
+}
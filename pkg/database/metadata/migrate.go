@@ -0,0 +1,322 @@
+package metadata
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockName names the advisory lock held while migrating, so
+// replicas starting together do not apply the same migration twice
+const migrationLockName = "gosqlguard_schema_migrations"
+
+// migrationLockKey is the PostgreSQL advisory lock key for migrations
+const migrationLockKey int64 = 0x6773716c6d6967 // "gsqlmig"
+
+// migrationLockTimeout is how long to wait for another process's migrations
+const migrationLockTimeout = 5 * time.Minute
+
+// Migration is one numbered change to the metadata schema. Up applies it and
+// Down reverts it; both run in a transaction, although MySQL commits schema
+// changes immediately.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for the SchemaMigration model
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus describes a migration and whether it has been applied
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // nil while pending
+	Known     bool       // false for migrations applied by a newer release
+}
+
+// Migrator applies and reverts the metadata schema migrations
+type Migrator struct {
+	db          *gorm.DB
+	migrations  []Migration
+	lockTimeout time.Duration
+}
+
+// NewMigrator creates a Migrator for the migrations of this release
+func NewMigrator(db *gorm.DB) *Migrator {
+	return newMigrator(db, migrations)
+}
+
+func newMigrator(db *gorm.DB, list []Migration) *Migrator {
+	sorted := append([]Migration(nil), list...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &Migrator{db: db, migrations: sorted, lockTimeout: migrationLockTimeout}
+}
+
+// Latest returns the schema version this release migrates to
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists every migration of this release, and any applied by a newer
+// one, in version order
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, Known: true}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, MigrationStatus{Version: record.Version, Name: record.Name, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies the pending migrations up to and including version target, or
+// all of them when target is 0, and returns those it applied
+func (m *Migrator) Up(target int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func(conn *gorm.DB) error {
+		if err := conn.AutoMigrate(&SchemaMigration{}); err != nil {
+			return fmt.Errorf("failed to create schema_migrations table: %w", err)
+		}
+
+		for _, migration := range m.migrations {
+			if target > 0 && migration.Version > target {
+				break
+			}
+			ran, err := m.apply(conn, migration)
+			if err != nil {
+				return err
+			}
+			if ran {
+				done = append(done, migration)
+			}
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// those it reverted
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	known := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	var done []Migration
+	err := m.withLock(func(conn *gorm.DB) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+		for i := 0; i < steps && i < len(versions); i++ {
+			migration, ok := known[versions[i]]
+			if !ok {
+				return fmt.Errorf("migration %d was applied by a newer release and cannot be reverted by this one", versions[i])
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := migration.Down(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&SchemaMigration{}, "version = ?", migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d (%s): %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("Reverted metadata schema migration %d: %s", migration.Version, migration.Name)
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// apply runs one migration unless it has been applied in the meantime
+func (m *Migrator) apply(conn *gorm.DB, migration Migration) (bool, error) {
+	ran := false
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&SchemaMigration{}).Where("version = ?", migration.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		if err := migration.Up(tx); err != nil {
+			return err
+		}
+		ran = true
+		return tx.Create(&SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to apply migration %d (%s): %w", migration.Version, migration.Name, err)
+	}
+	if ran {
+		log.Printf("Applied metadata schema migration %d: %s", migration.Version, migration.Name)
+	}
+	return ran, nil
+}
+
+// applied returns the recorded migrations by version
+func (m *Migrator) applied(db *gorm.DB) (map[int]SchemaMigration, error) {
+	applied := make(map[int]SchemaMigration)
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return applied, nil
+	}
+
+	var records []SchemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// withLock runs fn while holding the migration lock. MySQL and PostgreSQL
+// advisory locks belong to a session, so fn gets the connection holding it.
+// SQLite has no advisory locks; fn runs in one transaction instead, which
+// holds the database's write lock throughout. Each migration is a savepoint
+// within it, so a failed one is rolled back and those before it committed.
+// Foreign keys are off meanwhile, as SQLite alters columns by copying the
+// table and dropping the original, which would cascade to its children.
+func (m *Migrator) withLock(fn func(conn *gorm.DB) error) error {
+	if IsSQLite(m.db) {
+		return m.db.Connection(func(conn *gorm.DB) error {
+			conn = conn.Session(&gorm.Session{})
+			var foreignKeys int
+			if err := conn.Raw("PRAGMA foreign_keys").Scan(&foreignKeys).Error; err != nil {
+				return fmt.Errorf("failed to read foreign key setting: %w", err)
+			}
+			if foreignKeys == 1 {
+				if err := conn.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+					return fmt.Errorf("failed to disable foreign keys: %w", err)
+				}
+				defer func() {
+					if err := conn.Exec("PRAGMA foreign_keys = ON").Error; err != nil {
+						log.Printf("Warning: Failed to re-enable foreign keys: %v", err)
+					}
+				}()
+			}
+
+			var fnErr error
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				fnErr = fn(tx)
+				return nil
+			})
+			if fnErr != nil {
+				return fnErr
+			}
+			return err
+		})
+	}
+
+	return m.db.Connection(func(conn *gorm.DB) error {
+		if err := m.lock(conn); err != nil {
+			return err
+		}
+		defer m.unlock(conn)
+		return fn(conn)
+	})
+}
+
+// lock waits for the advisory migration lock
+func (m *Migrator) lock(conn *gorm.DB) error {
+	if IsPostgres(conn) {
+		deadline := time.Now().Add(m.lockTimeout)
+		for {
+			var locked bool
+			if err := conn.Raw("SELECT pg_try_advisory_lock(?)", migrationLockKey).Scan(&locked).Error; err != nil {
+				return fmt.Errorf("failed to take migration lock: %w", err)
+			}
+			if locked {
+				return nil
+			}
+			if time.Now().After(deadline) {
+				return errors.New("timed out waiting for another process to finish migrating")
+			}
+			time.Sleep(time.Second)
+		}
+	}
+
+	var locked sql.NullInt64
+	if err := conn.Raw("SELECT GET_LOCK(?, ?)", migrationLockName, int(m.lockTimeout.Seconds())).Scan(&locked).Error; err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return errors.New("timed out waiting for another process to finish migrating")
+	}
+	return nil
+}
+
+// unlock releases the advisory migration lock
+func (m *Migrator) unlock(conn *gorm.DB) {
+	query, arg := "SELECT RELEASE_LOCK(?)", interface{}(migrationLockName)
+	if IsPostgres(conn) {
+		query, arg = "SELECT pg_advisory_unlock(?)", migrationLockKey
+	}
+	if err := conn.Exec(query, arg).Error; err != nil {
+		log.Printf("Warning: Failed to release migration lock: %v", err)
+	}
+}
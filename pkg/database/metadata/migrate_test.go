@@ -0,0 +1,244 @@
+package metadata
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+// openSQLite opens an embedded metadata database in a temporary directory
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	dialector, err := Dialector(config.MetadataDBConfig{
+		Type: config.MetadataDBTypeSQLite,
+		Path: filepath.Join(t.TempDir(), "metadata.db"),
+	})
+	require.NoError(t, err)
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	db := openSQLite(t)
+	migrator := NewMigrator(db)
+
+	pending, err := migrator.Pending()
+	require.NoError(t, err)
+	assert.Len(t, pending, len(migrations))
+
+	applied, err := migrator.Up(0)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+	assert.True(t, db.Migrator().HasTable(&Backup{}))
+	assert.True(t, db.Migrator().HasIndex("backups", "idx_backups_filter"))
+
+	var stats Stats
+	require.NoError(t, db.First(&stats).Error)
+	assert.Equal(t, "1.0", stats.Version)
+
+	// Nothing is left to apply
+	applied, err = migrator.Up(0)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, len(migrations))
+	for _, status := range statuses {
+		assert.True(t, status.Known)
+		assert.NotNil(t, status.AppliedAt, "migration %d", status.Version)
+	}
+
+	// Reverting the latest migration leaves the baseline in place
+	reverted, err := migrator.Down(1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, migrator.Latest(), reverted[0].Version)
+	assert.False(t, db.Migrator().HasColumn(&BackupTask{}, "BackupID"))
+	assert.True(t, db.Migrator().HasTable(&StatsSnapshot{}))
+
+	pending, err = migrator.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	// Reverting everything drops the tables
+	_, err = migrator.Down(len(migrations))
+	require.NoError(t, err)
+	assert.False(t, db.Migrator().HasTable(&Backup{}))
+	assert.False(t, db.Migrator().HasTable(&BackupTask{}))
+}
+
+func TestMigratorUpToTarget(t *testing.T) {
+	db := openSQLite(t)
+	migrator := NewMigrator(db)
+
+	applied, err := migrator.Up(1)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, 1, applied[0].Version)
+	assert.False(t, db.Migrator().HasIndex("backups", "idx_backups_filter"))
+	assert.False(t, db.Migrator().HasTable("backup_labels"), "labels belong to migration 3")
+	assert.False(t, db.Migrator().HasTable("audit_entries"), "the audit log belongs to migration 4")
+	assert.False(t, db.Migrator().HasTable("stats_snapshots"), "stats history belongs to migration 5")
+	assert.False(t, db.Migrator().HasColumn(&Backup{}, "Annotations"))
+	assert.False(t, db.Migrator().HasColumn(&Backup{}, "HeldAt"))
+}
+
+// TestMigrationsMatchModels checks that the migrations create every column
+// the models use
+func TestMigrationsMatchModels(t *testing.T) {
+	db := openSQLite(t)
+	_, err := NewMigrator(db).Up(0)
+	require.NoError(t, err)
+
+	models := []interface{}{
+		&Backup{}, &LocalPath{}, &S3Key{}, &BackupLabel{}, &AuditEntry{}, &StatsSnapshot{}, &Stats{},
+		&ServerConfig{}, &ServerDatabaseFilter{}, &ServerMySQLOption{}, &BackupSchedule{},
+		&ScheduleRetentionPolicy{}, &ScheduleRunState{}, &LeaderLease{}, &BackupTask{},
+	}
+	for _, model := range models {
+		parsed, err := schema.Parse(model, &sync.Map{}, db.NamingStrategy)
+		require.NoError(t, err)
+		for _, field := range parsed.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			assert.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s", parsed.Table, field.DBName)
+		}
+	}
+}
+
+// TestMigratorAdoptsExistingSchema upgrades a database whose tables were
+// created before migrations were versioned
+func TestMigratorAdoptsExistingSchema(t *testing.T) {
+	db := openSQLite(t)
+	require.NoError(t, db.AutoMigrate(baselineModels...))
+	require.NoError(t, db.Create(&baselineBackup{ID: "existing", ServerName: "s", ServerType: "mysql", DatabaseName: "d", BackupType: "daily", Status: "success"}).Error)
+	require.NoError(t, db.Exec("CREATE INDEX idx_backups_expires ON backups (expires_at, status)").Error)
+
+	_, err := NewMigrator(db).Up(0)
+	require.NoError(t, err)
+
+	var count int64
+	require.NoError(t, db.Model(&Backup{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+	assert.True(t, db.Migrator().HasIndex("backups", "idx_backups_filter"))
+}
+
+func TestMigratorFailedMigration(t *testing.T) {
+	db := openSQLite(t)
+	failing := append(append([]Migration(nil), migrations...), Migration{
+		Version: 1000,
+		Name:    "broken",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE half_done (id INTEGER)").Error; err != nil {
+				return err
+			}
+			return errors.New("backfill failed")
+		},
+		Down: func(tx *gorm.DB) error { return nil },
+	})
+	migrator := newMigrator(db, failing)
+
+	_, err := migrator.Up(0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 1000 (broken)")
+
+	// The failed migration is neither recorded nor partly applied
+	pending, err := migrator.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 1000, pending[0].Version)
+	assert.False(t, db.Migrator().HasTable("half_done"))
+
+	// A release without it sees the schema as current, and one that lost a
+	// migration refuses to revert it
+	statuses, err := NewMigrator(db).Status()
+	require.NoError(t, err)
+	assert.Len(t, statuses, len(migrations))
+
+	_, err = newMigrator(db, migrations[:1]).Down(1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "newer release")
+}
+
+func TestMigratorConcurrentUp(t *testing.T) {
+	db := openSQLite(t)
+
+	var wg sync.WaitGroup
+	counts := make([]int, 4)
+	errs := make([]error, len(counts))
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			applied, err := NewMigrator(db).Up(0)
+			counts[i], errs[i] = len(applied), err
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range counts {
+		require.NoError(t, errs[i])
+		total += counts[i]
+	}
+	assert.Equal(t, len(migrations), total, "each migration is applied exactly once")
+}
+
+// TestSQLiteCascades checks that purging a backup removes its paths and
+// labels, and that reverting migrations does not
+func TestSQLiteCascades(t *testing.T) {
+	db := openSQLite(t)
+	require.NoError(t, RunMigrations(db))
+
+	deletedAt := time.Now().Add(-48 * time.Hour)
+	var records []Backup
+	for _, meta := range []types.BackupMeta{
+		{ID: "kept", ServerName: "prod", Database: "app", Status: types.StatusSuccess, CreatedAt: deletedAt,
+			LocalPaths: map[string]string{"by-server": "/backups/kept.sql.gz"}, Labels: map[string]string{"tier": "hot"}},
+		{ID: "purged", ServerName: "prod", Database: "app", Status: types.StatusDeleted, CreatedAt: deletedAt, CompletedAt: deletedAt,
+			LocalPaths: map[string]string{"by-server": "/backups/purged.sql.gz"}, S3Keys: map[string]string{"by-server": "purged.sql.gz"},
+			Labels: map[string]string{"tier": "hot"}},
+	} {
+		record, err := NewBackup(meta)
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+	repo := NewRepository(db)
+	require.NoError(t, repo.ImportBackups(records))
+
+	_, err := NewMigrator(db).Down(2)
+	require.NoError(t, err)
+	require.NoError(t, RunMigrations(db))
+	count := func(model interface{}) int64 {
+		var n int64
+		require.NoError(t, db.Model(model).Count(&n).Error)
+		return n
+	}
+	assert.Equal(t, int64(2), count(&LocalPath{}), "rebuilding tables must not cascade")
+
+	purged, err := repo.PurgeDeletedBackups(24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	assert.Equal(t, int64(1), count(&LocalPath{}))
+	assert.Equal(t, int64(0), count(&S3Key{}))
+	assert.Equal(t, int64(1), count(&BackupLabel{}))
+}
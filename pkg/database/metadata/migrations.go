@@ -0,0 +1,305 @@
+package metadata
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrations is the metadata schema history, in version order. Never change
+// a released migration; add a new one instead.
+//
+// Migrations work on snapshots of the tables they change rather than on the
+// models, so that a version means the same schema on every database whichever
+// release created it.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "baseline schema",
+		Up:      baselineUp,
+		Down:    baselineDown,
+	},
+	{
+		Version: 2,
+		Name:    "backup query indexes",
+		Up:      queryIndexesUp,
+		Down:    queryIndexesDown,
+	},
+	{
+		Version: 3,
+		Name:    "backup labels and annotations",
+		Up:      labelsUp,
+		Down:    labelsDown,
+	},
+	{
+		Version: 4,
+		Name:    "backup holds and audit log",
+		Up:      holdsUp,
+		Down:    holdsDown,
+	},
+	{
+		Version: 5,
+		Name:    "stats history",
+		Up:      statsHistoryUp,
+		Down:    statsHistoryDown,
+	},
+	{
+		Version: 6,
+		Name:    "backup task backup ids",
+		Up:      taskBackupsUp,
+		Down:    taskBackupsDown,
+	},
+}
+
+// baselineUp creates the tables, or completes those of a database created
+// before migrations were versioned, and the stats record
+func baselineUp(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(baselineModels...); err != nil {
+		return fmt.Errorf("failed to migrate tables: %w", err)
+	}
+
+	var count int64
+	if err := tx.Model(&baselineStats{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		stats := baselineStats{
+			ID:          1,
+			Version:     "1.0",
+			LastUpdated: time.Now(),
+		}
+		if err := tx.Create(&stats).Error; err != nil {
+			return fmt.Errorf("failed to create initial stats record: %w", err)
+		}
+	}
+	return nil
+}
+
+// baselineDown drops every table, dependents first
+func baselineDown(tx *gorm.DB) error {
+	for i := len(baselineModels) - 1; i >= 0; i-- {
+		if err := tx.Migrator().DropTable(baselineModels[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryIndex is an index for a common backup query
+type queryIndex struct {
+	Table   string
+	Name    string
+	Columns []string
+}
+
+// queryIndexes serve the filters, sorting and retention queries of the
+// backup list
+var queryIndexes = []queryIndex{
+	// For filtering by server, database, type, and status
+	{Table: "backups", Name: "idx_backups_filter", Columns: []string{"server_name", "database_name", "backup_type", "status"}},
+	// For sorting by creation date
+	{Table: "backups", Name: "idx_backups_created_desc", Columns: []string{"created_at DESC"}},
+	// For retention queries
+	{Table: "backups", Name: "idx_backups_expires", Columns: []string{"expires_at", "status"}},
+	// For S3 upload status queries
+	{Table: "backups", Name: "idx_backups_s3_status", Columns: []string{"s3_upload_status", "created_at"}},
+	// For foreign key lookups
+	{Table: "local_paths", Name: "idx_local_paths_backup", Columns: []string{"backup_id"}},
+	{Table: "s3_keys", Name: "idx_s3_keys_backup", Columns: []string{"backup_id"}},
+}
+
+// queryIndexesUp creates the query indexes. Earlier releases created them at
+// startup, so some may exist already.
+func queryIndexesUp(tx *gorm.DB) error {
+	for _, idx := range queryIndexes {
+		if tx.Migrator().HasIndex(idx.Table, idx.Name) {
+			continue
+		}
+		sql := fmt.Sprintf("CREATE INDEX %s ON %s (%s)", idx.Name, idx.Table, strings.Join(idx.Columns, ", "))
+		if err := tx.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to create index %s: %w", idx.Name, err)
+		}
+	}
+	return nil
+}
+
+// queryIndexesDown drops the query indexes
+func queryIndexesDown(tx *gorm.DB) error {
+	for _, idx := range queryIndexes {
+		if !tx.Migrator().HasIndex(idx.Table, idx.Name) {
+			continue
+		}
+		if err := tx.Migrator().DropIndex(idx.Table, idx.Name); err != nil {
+			return fmt.Errorf("failed to drop index %s: %w", idx.Name, err)
+		}
+	}
+	return nil
+}
+
+// labelsBackup is the backups table as migration 3 changes it
+type labelsBackup struct {
+	ID          string `gorm:"primaryKey;type:varchar(255)"`
+	Annotations string `gorm:"type:text"`
+
+	Labels []labelsBackupLabel `gorm:"foreignKey:BackupID;constraint:OnDelete:CASCADE"`
+}
+
+func (labelsBackup) TableName() string { return "backups" }
+
+// labelsBackupLabel is the backup labels table created by migration 3
+type labelsBackupLabel struct {
+	BackupID string `gorm:"primaryKey;type:varchar(255)"`
+	Key      string `gorm:"column:label_key;primaryKey;type:varchar(255)"`
+	Value    string `gorm:"column:label_value;type:varchar(255);not null;index"`
+}
+
+func (labelsBackupLabel) TableName() string { return "backup_labels" }
+
+// labelsServerConfig is the server configs table as migration 3 changes it
+type labelsServerConfig struct {
+	ID             string `gorm:"primaryKey;type:varchar(255)"`
+	DatabaseLabels string `gorm:"type:text"`
+}
+
+func (labelsServerConfig) TableName() string { return "server_configs" }
+
+// labelsUp adds the annotation and database label rule columns and creates
+// the backup labels table. The backups snapshot is used first so the labels
+// table gets its foreign key.
+func labelsUp(tx *gorm.DB) error {
+	if err := tx.Migrator().AddColumn(&labelsBackup{}, "Annotations"); err != nil {
+		return fmt.Errorf("failed to add column annotations: %w", err)
+	}
+	if err := tx.Migrator().AddColumn(&labelsServerConfig{}, "DatabaseLabels"); err != nil {
+		return fmt.Errorf("failed to add column database_labels: %w", err)
+	}
+	if err := tx.Migrator().CreateTable(&labelsBackupLabel{}); err != nil {
+		return fmt.Errorf("failed to create backup labels table: %w", err)
+	}
+	return nil
+}
+
+// labelsDown drops the backup labels table and the added columns
+func labelsDown(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&labelsBackupLabel{}); err != nil {
+		return err
+	}
+	if err := tx.Migrator().DropColumn(&labelsBackup{}, "Annotations"); err != nil {
+		return fmt.Errorf("failed to drop column annotations: %w", err)
+	}
+	if err := tx.Migrator().DropColumn(&labelsServerConfig{}, "DatabaseLabels"); err != nil {
+		return fmt.Errorf("failed to drop column database_labels: %w", err)
+	}
+	return nil
+}
+
+// holdsBackup is the backups table as migration 4 changes it
+type holdsBackup struct {
+	ID             string     `gorm:"primaryKey;type:varchar(255)"`
+	HeldAt         *time.Time `gorm:"index"`
+	HoldReason     string     `gorm:"type:text"`
+	HoldActor      string     `gorm:"type:varchar(255)"`
+	HoldExpiresAt  *time.Time
+	HoldObjectLock bool `gorm:"not null;default:false"`
+}
+
+func (holdsBackup) TableName() string { return "backups" }
+
+// holdColumns are the backup columns that store a hold
+var holdColumns = []string{"HeldAt", "HoldReason", "HoldActor", "HoldExpiresAt", "HoldObjectLock"}
+
+// holdsAuditEntry is the audit log table created by migration 4
+type holdsAuditEntry struct {
+	ID       uint      `gorm:"primaryKey;autoIncrement"`
+	Time     time.Time `gorm:"not null;index"`
+	Actor    string    `gorm:"type:varchar(255);not null"`
+	Action   string    `gorm:"type:varchar(50);not null"`
+	BackupID string    `gorm:"type:varchar(255);index"`
+	Detail   string    `gorm:"type:text"`
+}
+
+func (holdsAuditEntry) TableName() string { return "audit_entries" }
+
+// holdsUp adds the hold columns and creates the audit log table
+func holdsUp(tx *gorm.DB) error {
+	for _, field := range holdColumns {
+		if err := tx.Migrator().AddColumn(&holdsBackup{}, field); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", field, err)
+		}
+	}
+	if err := tx.Migrator().CreateIndex(&holdsBackup{}, "HeldAt"); err != nil {
+		return fmt.Errorf("failed to create hold index: %w", err)
+	}
+	if err := tx.Migrator().CreateTable(&holdsAuditEntry{}); err != nil {
+		return fmt.Errorf("failed to create audit log table: %w", err)
+	}
+	return nil
+}
+
+// holdsDown drops the audit log table and the hold columns
+func holdsDown(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&holdsAuditEntry{}); err != nil {
+		return err
+	}
+	if err := tx.Migrator().DropIndex(&holdsBackup{}, "HeldAt"); err != nil {
+		return fmt.Errorf("failed to drop hold index: %w", err)
+	}
+	for _, field := range holdColumns {
+		if err := tx.Migrator().DropColumn(&holdsBackup{}, field); err != nil {
+			return fmt.Errorf("failed to drop column %s: %w", field, err)
+		}
+	}
+	return nil
+}
+
+// statsHistorySnapshot is the stats history table created by migration 5
+type statsHistorySnapshot struct {
+	ID              uint      `gorm:"primaryKey;autoIncrement"`
+	Day             time.Time `gorm:"not null;uniqueIndex:idx_stats_snapshot_key"`
+	ServerName      string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_stats_snapshot_key"`
+	DatabaseName    string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_stats_snapshot_key"`
+	BackupCount     int       `gorm:"not null;default:0"`
+	FailedCount     int       `gorm:"not null;default:0"`
+	BackupSize      int64     `gorm:"not null;default:0"`
+	DurationSeconds float64   `gorm:"not null;default:0"`
+	LocalSize       int64     `gorm:"not null;default:0"`
+	S3Size          int64     `gorm:"not null;default:0"`
+}
+
+func (statsHistorySnapshot) TableName() string { return "stats_snapshots" }
+
+// statsHistoryUp creates the stats history table
+func statsHistoryUp(tx *gorm.DB) error {
+	if err := tx.Migrator().CreateTable(&statsHistorySnapshot{}); err != nil {
+		return fmt.Errorf("failed to create stats history table: %w", err)
+	}
+	return nil
+}
+
+// statsHistoryDown drops the stats history table
+func statsHistoryDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&statsHistorySnapshot{})
+}
+
+// taskBackupsTask is the backup ID column migration 6 adds to the task queue
+type taskBackupsTask struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement"`
+	BackupID string `gorm:"type:varchar(255)"`
+}
+
+func (taskBackupsTask) TableName() string { return "backup_tasks" }
+
+// taskBackupsUp records which backup entry a task's attempt created
+func taskBackupsUp(tx *gorm.DB) error {
+	if err := tx.Migrator().AddColumn(&taskBackupsTask{}, "BackupID"); err != nil {
+		return fmt.Errorf("failed to add column backup_id: %w", err)
+	}
+	return nil
+}
+
+// taskBackupsDown drops the backup ID column of the task queue
+func taskBackupsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropColumn(&taskBackupsTask{}, "BackupID")
+}
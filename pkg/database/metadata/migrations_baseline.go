@@ -0,0 +1,213 @@
+package metadata
+
+import "time"
+
+// The baseline schema as released with versioned migrations. These snapshots
+// are frozen: the models in models.go change with later migrations, but
+// migration 1 must create the same tables on every database.
+
+// baselineModels are the tables created by the baseline migration
+var baselineModels = []interface{}{
+	&baselineBackup{},
+	&baselineLocalPath{},
+	&baselineS3Key{},
+	&baselineStats{},
+	&baselineServerConfig{},
+	&baselineServerDatabaseFilter{},
+	&baselineServerMySQLOption{},
+	&baselineBackupSchedule{},
+	&baselineScheduleRetentionPolicy{},
+	&baselineScheduleRunState{},
+	&baselineLeaderLease{},
+	&baselineBackupTask{},
+}
+
+type baselineServerConfig struct {
+	ID          string    `gorm:"primaryKey;type:varchar(255)"`
+	Name        string    `gorm:"type:varchar(255);not null;uniqueIndex"`
+	Type        string    `gorm:"type:varchar(50);not null"`
+	Host        string    `gorm:"type:varchar(255);not null"`
+	Port        string    `gorm:"type:varchar(10);not null"`
+	Username    string    `gorm:"type:varchar(255);not null"`
+	Password    string    `gorm:"type:varchar(255);not null"`
+	AuthPlugin  string    `gorm:"type:varchar(100)"`
+	CreatedAt   time.Time `gorm:"not null"`
+	UpdatedAt   time.Time `gorm:"not null"`
+	TLSMode     string    `gorm:"type:varchar(20)"`
+	TLSCAFile   string    `gorm:"type:varchar(1024)"`
+	TLSCertFile string    `gorm:"type:varchar(1024)"`
+	TLSKeyFile  string    `gorm:"type:varchar(1024)"`
+	Labels      string    `gorm:"type:varchar(1024)"`
+
+	DatabaseFilters []baselineServerDatabaseFilter `gorm:"foreignKey:ServerID;constraint:OnDelete:CASCADE"`
+	MySQLOptions    []baselineServerMySQLOption    `gorm:"foreignKey:ServerID;constraint:OnDelete:CASCADE"`
+}
+
+func (baselineServerConfig) TableName() string { return "server_configs" }
+
+type baselineServerDatabaseFilter struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement"`
+	ServerID     string    `gorm:"type:varchar(255);not null;index"`
+	FilterType   string    `gorm:"type:varchar(10);not null"`
+	DatabaseName string    `gorm:"type:varchar(255);not null"`
+	CreatedAt    time.Time `gorm:"not null"`
+}
+
+func (baselineServerDatabaseFilter) TableName() string { return "server_database_filters" }
+
+type baselineServerMySQLOption struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	ServerID    string    `gorm:"type:varchar(255);not null;index"`
+	OptionName  string    `gorm:"type:varchar(100);not null"`
+	OptionValue string    `gorm:"type:varchar(255)"`
+	CreatedAt   time.Time `gorm:"not null"`
+}
+
+func (baselineServerMySQLOption) TableName() string { return "server_mysql_options" }
+
+type baselineBackupSchedule struct {
+	ID                     string    `gorm:"primaryKey;type:varchar(255)"`
+	Name                   string    `gorm:"type:varchar(100);not null;uniqueIndex"`
+	BackupType             string    `gorm:"type:varchar(50);not null"`
+	CronExpression         string    `gorm:"type:varchar(100);not null"`
+	Enabled                bool      `gorm:"not null;default:true"`
+	CreatedAt              time.Time `gorm:"not null"`
+	UpdatedAt              time.Time `gorm:"not null"`
+	TargetServers          string    `gorm:"type:varchar(1024)"`
+	TargetDatabases        string    `gorm:"type:varchar(1024)"`
+	TargetExcludeDatabases string    `gorm:"type:varchar(1024)"`
+	TargetLabels           string    `gorm:"type:varchar(1024)"`
+	Timezone               string    `gorm:"type:varchar(64)"`
+	Jitter                 string    `gorm:"type:varchar(50)"`
+	MaxRunWindow           string    `gorm:"type:varchar(50)"`
+	Blackouts              string    `gorm:"type:text"`
+	CatchUp                string    `gorm:"type:varchar(10)"`
+	Paused                 bool      `gorm:"not null;default:false"`
+	PausedAt               *time.Time
+	PausedUntil            *time.Time
+	PauseReason            string `gorm:"type:varchar(255)"`
+	SkipNextAt             *time.Time
+
+	RetentionPolicies []baselineScheduleRetentionPolicy `gorm:"foreignKey:ScheduleID;constraint:OnDelete:CASCADE"`
+}
+
+func (baselineBackupSchedule) TableName() string { return "backup_schedules" }
+
+type baselineScheduleRetentionPolicy struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	ScheduleID  string    `gorm:"type:varchar(255);not null;index"`
+	StorageType string    `gorm:"type:varchar(10);not null"`
+	Duration    string    `gorm:"type:varchar(50)"`
+	KeepForever bool      `gorm:"not null;default:false"`
+	KeepLast    int       `gorm:"not null;default:0"`
+	KeepDaily   int       `gorm:"not null;default:0"`
+	KeepWeekly  int       `gorm:"not null;default:0"`
+	KeepMonthly int       `gorm:"not null;default:0"`
+	KeepYearly  int       `gorm:"not null;default:0"`
+	MinKeep     int       `gorm:"not null;default:0"`
+	CreatedAt   time.Time `gorm:"not null"`
+}
+
+func (baselineScheduleRetentionPolicy) TableName() string { return "schedule_retention_policies" }
+
+type baselineScheduleRunState struct {
+	BackupType      string    `gorm:"primaryKey;type:varchar(50)"`
+	Schedule        string    `gorm:"type:varchar(100)"`
+	TrackedSince    time.Time `gorm:"not null"`
+	LastScheduledAt *time.Time
+	LastStartedAt   *time.Time
+	LastCompletedAt *time.Time
+	LastStatus      string `gorm:"type:varchar(20)"`
+	LastError       string `gorm:"type:text"`
+	MissedRuns      int    `gorm:"not null;default:0"`
+	LastMissedAt    *time.Time
+	FencingToken    int64     `gorm:"not null;default:0"`
+	UpdatedAt       time.Time `gorm:"not null"`
+}
+
+func (baselineScheduleRunState) TableName() string { return "schedule_run_states" }
+
+type baselineLeaderLease struct {
+	Name       string    `gorm:"primaryKey;type:varchar(100)"`
+	Holder     string    `gorm:"type:varchar(255);not null"`
+	Token      int64     `gorm:"not null"`
+	AcquiredAt time.Time `gorm:"not null"`
+	RenewedAt  time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+}
+
+func (baselineLeaderLease) TableName() string { return "leader_leases" }
+
+type baselineBackupTask struct {
+	ID           uint   `gorm:"primaryKey;autoIncrement"`
+	BackupType   string `gorm:"type:varchar(50);not null;index"`
+	ServerName   string `gorm:"type:varchar(255);not null;index"`
+	ServerType   string `gorm:"type:varchar(50);not null"`
+	DatabaseName string `gorm:"column:database_name;type:varchar(255);not null"`
+	Status       string `gorm:"type:varchar(20);not null;index"`
+	Attempts     int    `gorm:"not null;default:0"`
+	WorkerID     string `gorm:"type:varchar(255)"`
+	Deadline     *time.Time
+	FencingToken int64     `gorm:"not null;default:0"`
+	Error        string    `gorm:"type:text"`
+	CreatedAt    time.Time `gorm:"not null;index"`
+	ClaimedAt    *time.Time
+	HeartbeatAt  *time.Time
+	CompletedAt  *time.Time
+}
+
+func (baselineBackupTask) TableName() string { return "backup_tasks" }
+
+type baselineBackup struct {
+	ID               string    `gorm:"primaryKey;type:varchar(255)"`
+	ServerName       string    `gorm:"type:varchar(255);not null;index"`
+	ServerType       string    `gorm:"type:varchar(50);not null"`
+	DatabaseName     string    `gorm:"column:database_name;type:varchar(255);not null;index"`
+	BackupType       string    `gorm:"type:varchar(50);not null;index"`
+	CreatedAt        time.Time `gorm:"not null"`
+	CompletedAt      *time.Time
+	Size             int64
+	Status           string `gorm:"type:varchar(50);not null;index"`
+	ErrorMessage     string `gorm:"type:text"`
+	RetentionPolicy  string `gorm:"type:varchar(255)"`
+	ExpiresAt        *time.Time
+	LogFilePath      string `gorm:"type:varchar(1024)"`
+	S3UploadStatus   string `gorm:"type:varchar(50)"`
+	S3UploadError    string `gorm:"type:text"`
+	S3UploadComplete *time.Time
+	LocalDeletedAt   *time.Time
+	S3DeletedAt      *time.Time
+	RetentionError   string `gorm:"type:text"`
+	FencingToken     int64  `gorm:"not null;default:0"`
+
+	LocalPaths []baselineLocalPath `gorm:"foreignKey:BackupID;constraint:OnDelete:CASCADE"`
+	S3Keys     []baselineS3Key     `gorm:"foreignKey:BackupID;constraint:OnDelete:CASCADE"`
+}
+
+func (baselineBackup) TableName() string { return "backups" }
+
+type baselineLocalPath struct {
+	BackupID     string `gorm:"primaryKey;type:varchar(255)"`
+	Organization string `gorm:"primaryKey;type:varchar(50)"`
+	Path         string `gorm:"type:varchar(1024);not null"`
+}
+
+func (baselineLocalPath) TableName() string { return "local_paths" }
+
+type baselineS3Key struct {
+	BackupID     string `gorm:"primaryKey;type:varchar(255)"`
+	Organization string `gorm:"primaryKey;type:varchar(50)"`
+	Key          string `gorm:"column:s3_key;type:varchar(1024);not null"`
+}
+
+func (baselineS3Key) TableName() string { return "s3_keys" }
+
+type baselineStats struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement:false;default:1"`
+	TotalLocalSize int64     `gorm:"not null;default:0"`
+	TotalS3Size    int64     `gorm:"not null;default:0"`
+	LastUpdated    time.Time `gorm:"not null"`
+	Version        string    `gorm:"type:varchar(50);not null"`
+}
+
+func (baselineStats) TableName() string { return "metadata_stats" }
@@ -2,7 +2,13 @@
 package metadata
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
 )
 
 // ServerConfig represents a database server configuration
@@ -18,6 +24,17 @@ type ServerConfig struct {
 	CreatedAt  time.Time `gorm:"not null"`
 	UpdatedAt  time.Time `gorm:"not null"`
 
+	// TLS settings
+	TLSMode     string `gorm:"type:varchar(20)"` // disable, require, verify-ca, verify-full
+	TLSCAFile   string `gorm:"type:varchar(1024)"`
+	TLSCertFile string `gorm:"type:varchar(1024)"`
+	TLSKeyFile  string `gorm:"type:varchar(1024)"`
+
+	// Labels are stored as "key=value" pairs separated by commas, and the
+	// per-database label rules as a JSON list
+	Labels         string `gorm:"type:varchar(1024)"`
+	DatabaseLabels string `gorm:"type:text"`
+
 	// Relationships
 	DatabaseFilters []ServerDatabaseFilter `gorm:"foreignKey:ServerID;constraint:OnDelete:CASCADE"`
 	MySQLOptions    []ServerMySQLOption    `gorm:"foreignKey:ServerID;constraint:OnDelete:CASCADE"`
@@ -28,6 +45,38 @@ func (ServerConfig) TableName() string {
 	return "server_configs"
 }
 
+// LabelMap returns the server's labels, ignoring a malformed column
+func (s ServerConfig) LabelMap() map[string]string {
+	labels, _ := config.ParseLabels(s.Labels)
+	return labels
+}
+
+// DatabaseLabelRules decodes the stored database label rules, ignoring a malformed column
+func (s ServerConfig) DatabaseLabelRules() []config.DatabaseLabelRule {
+	if s.DatabaseLabels == "" {
+		return nil
+	}
+	var rules []config.DatabaseLabelRule
+	if err := json.Unmarshal([]byte(s.DatabaseLabels), &rules); err != nil {
+		return nil
+	}
+	return rules
+}
+
+// SetDatabaseLabelRules stores database label rules on the server
+func (s *ServerConfig) SetDatabaseLabelRules(rules []config.DatabaseLabelRule) error {
+	if len(rules) == 0 {
+		s.DatabaseLabels = ""
+		return nil
+	}
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return fmt.Errorf("failed to encode database label rules: %w", err)
+	}
+	s.DatabaseLabels = string(data)
+	return nil
+}
+
 // ServerDatabaseFilter represents include/exclude database filters for a server
 type ServerDatabaseFilter struct {
 	ID           uint      `gorm:"primaryKey;autoIncrement"`
@@ -66,6 +115,26 @@ type BackupSchedule struct {
 	CreatedAt      time.Time `gorm:"not null"`
 	UpdatedAt      time.Time `gorm:"not null"`
 
+	// Target selectors, stored as comma separated lists; empty means all
+	TargetServers          string `gorm:"type:varchar(1024)"`
+	TargetDatabases        string `gorm:"type:varchar(1024)"`
+	TargetExcludeDatabases string `gorm:"type:varchar(1024)"`
+	TargetLabels           string `gorm:"type:varchar(1024)"`
+
+	// Timing settings; Blackouts holds a JSON list of blackout windows
+	Timezone     string `gorm:"type:varchar(64)"`
+	Jitter       string `gorm:"type:varchar(50)"`
+	MaxRunWindow string `gorm:"type:varchar(50)"`
+	Blackouts    string `gorm:"type:text"`
+	CatchUp      string `gorm:"type:varchar(10)"`
+
+	// Operator controls; a pause with PausedUntil set ends by itself
+	Paused      bool `gorm:"not null;default:false"`
+	PausedAt    *time.Time
+	PausedUntil *time.Time
+	PauseReason string     `gorm:"type:varchar(255)"`
+	SkipNextAt  *time.Time // Fire time of the next run to skip
+
 	// Relationships
 	RetentionPolicies []ScheduleRetentionPolicy `gorm:"foreignKey:ScheduleID;constraint:OnDelete:CASCADE"`
 }
@@ -75,6 +144,102 @@ func (BackupSchedule) TableName() string {
 	return "backup_schedules"
 }
 
+// Targets converts the stored selectors to configuration targets
+func (s BackupSchedule) Targets() config.BackupTargets {
+	labels, _ := config.ParseLabels(s.TargetLabels)
+	return config.BackupTargets{
+		Servers:          splitList(s.TargetServers),
+		Databases:        splitList(s.TargetDatabases),
+		ExcludeDatabases: splitList(s.TargetExcludeDatabases),
+		Labels:           labels,
+	}
+}
+
+// SetTargets stores configuration targets on the schedule
+func (s *BackupSchedule) SetTargets(t config.BackupTargets) {
+	s.TargetServers = strings.Join(t.Servers, ",")
+	s.TargetDatabases = strings.Join(t.Databases, ",")
+	s.TargetExcludeDatabases = strings.Join(t.ExcludeDatabases, ",")
+	s.TargetLabels = config.FormatLabels(t.Labels)
+}
+
+// BlackoutWindows decodes the stored blackout windows, ignoring a malformed column
+func (s BackupSchedule) BlackoutWindows() []config.BlackoutWindow {
+	if s.Blackouts == "" {
+		return nil
+	}
+	var windows []config.BlackoutWindow
+	if err := json.Unmarshal([]byte(s.Blackouts), &windows); err != nil {
+		return nil
+	}
+	return windows
+}
+
+// SetBlackoutWindows stores blackout windows on the schedule
+func (s *BackupSchedule) SetBlackoutWindows(windows []config.BlackoutWindow) error {
+	if len(windows) == 0 {
+		s.Blackouts = ""
+		return nil
+	}
+	data, err := json.Marshal(windows)
+	if err != nil {
+		return fmt.Errorf("failed to encode blackout windows: %w", err)
+	}
+	s.Blackouts = string(data)
+	return nil
+}
+
+// BackupTypeConfig converts the schedule and its retention policies to the
+// backup type configuration the scheduler runs
+func (s BackupSchedule) BackupTypeConfig() config.BackupTypeConfig {
+	backupType := config.BackupTypeConfig{
+		Schedule:     s.CronExpression,
+		Targets:      s.Targets(),
+		Timezone:     s.Timezone,
+		Jitter:       s.Jitter,
+		MaxRunWindow: s.MaxRunWindow,
+		Blackouts:    s.BlackoutWindows(),
+		CatchUp:      s.CatchUp,
+		Local: config.LocalBackupConfig{
+			Enabled: false,
+			Retention: config.RetentionRule{
+				Duration: "24h",
+				Forever:  false,
+			},
+		},
+		S3: config.S3BackupConfig{
+			Enabled: false,
+			Retention: config.RetentionRule{
+				Duration: "24h",
+				Forever:  false,
+			},
+		},
+	}
+
+	for _, policy := range s.RetentionPolicies {
+		if policy.StorageType == "local" {
+			backupType.Local.Enabled = true
+			backupType.Local.Retention = policy.RetentionRule()
+		} else if policy.StorageType == "s3" {
+			backupType.S3.Enabled = true
+			backupType.S3.Retention = policy.RetentionRule()
+		}
+	}
+
+	return backupType
+}
+
+// splitList splits a comma separated column, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // ScheduleRetentionPolicy represents retention settings for a backup schedule
 type ScheduleRetentionPolicy struct {
 	ID          uint      `gorm:"primaryKey;autoIncrement"`
@@ -82,6 +247,12 @@ type ScheduleRetentionPolicy struct {
 	StorageType string    `gorm:"type:varchar(10);not null"` // local or s3
 	Duration    string    `gorm:"type:varchar(50)"`
 	KeepForever bool      `gorm:"not null;default:false"`
+	KeepLast    int       `gorm:"not null;default:0"`
+	KeepDaily   int       `gorm:"not null;default:0"`
+	KeepWeekly  int       `gorm:"not null;default:0"`
+	KeepMonthly int       `gorm:"not null;default:0"`
+	KeepYearly  int       `gorm:"not null;default:0"`
+	MinKeep     int       `gorm:"not null;default:0"`
 	CreatedAt   time.Time `gorm:"not null"`
 }
 
@@ -90,6 +261,104 @@ func (ScheduleRetentionPolicy) TableName() string {
 	return "schedule_retention_policies"
 }
 
+// RetentionRule converts the stored policy to a configuration retention rule
+func (p ScheduleRetentionPolicy) RetentionRule() config.RetentionRule {
+	return config.RetentionRule{
+		Duration:    p.Duration,
+		Forever:     p.KeepForever,
+		KeepLast:    p.KeepLast,
+		KeepDaily:   p.KeepDaily,
+		KeepWeekly:  p.KeepWeekly,
+		KeepMonthly: p.KeepMonthly,
+		KeepYearly:  p.KeepYearly,
+		MinKeep:     p.MinKeep,
+	}
+}
+
+// Schedule run statuses recorded in ScheduleRunState
+const (
+	RunStatusRunning    = "running"
+	RunStatusSuccess    = "success"
+	RunStatusError      = "error"
+	RunStatusSkipped    = "skipped"
+	RunStatusDeferred   = "deferred"
+	RunStatusIncomplete = "incomplete"
+)
+
+// ScheduleRunState tracks when a schedule last fired and finished, so runs
+// lost to downtime can be detected after a restart
+type ScheduleRunState struct {
+	BackupType      string    `gorm:"primaryKey;type:varchar(50)"`
+	Schedule        string    `gorm:"type:varchar(100)"` // Cron expression the state was tracked against
+	TrackedSince    time.Time `gorm:"not null"`
+	LastScheduledAt *time.Time
+	LastStartedAt   *time.Time
+	LastCompletedAt *time.Time
+	LastStatus      string `gorm:"type:varchar(20)"`
+	LastError       string `gorm:"type:text"`
+	MissedRuns      int    `gorm:"not null;default:0"`
+	LastMissedAt    *time.Time
+	FencingToken    int64     `gorm:"not null;default:0"` // Lease token of the replica that last recorded a run
+	UpdatedAt       time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for the ScheduleRunState model
+func (ScheduleRunState) TableName() string {
+	return "schedule_run_states"
+}
+
+// LeaderLease is a lease held by the replica elected to run scheduled jobs.
+// Token grows each time the lease changes hands, so work recorded with an
+// older token can be told apart from that of the current leader.
+type LeaderLease struct {
+	Name       string    `gorm:"primaryKey;type:varchar(100)"`
+	Holder     string    `gorm:"type:varchar(255);not null"`
+	Token      int64     `gorm:"not null"`
+	AcquiredAt time.Time `gorm:"not null"`
+	RenewedAt  time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for the LeaderLease model
+func (LeaderLease) TableName() string {
+	return "leader_leases"
+}
+
+// Backup task statuses recorded in BackupTask
+const (
+	TaskStatusQueued   = "queued"
+	TaskStatusRunning  = "running"
+	TaskStatusSuccess  = "success"
+	TaskStatusError    = "error"
+	TaskStatusDeferred = "deferred"
+)
+
+// BackupTask is the backup of one database, queued by the scheduler for a
+// worker process to claim and run
+type BackupTask struct {
+	ID           uint       `gorm:"primaryKey;autoIncrement"`
+	BackupType   string     `gorm:"type:varchar(50);not null;index"`
+	ServerName   string     `gorm:"type:varchar(255);not null;index"`
+	ServerType   string     `gorm:"type:varchar(50);not null"`
+	DatabaseName string     `gorm:"column:database_name;type:varchar(255);not null"`
+	Status       string     `gorm:"type:varchar(20);not null;index"`
+	Attempts     int        `gorm:"not null;default:0"`
+	WorkerID     string     `gorm:"type:varchar(255)"`
+	Deadline     *time.Time // Latest time the backup may start, from the schedule's run window
+	FencingToken int64      `gorm:"not null;default:0"`
+	BackupID     string     `gorm:"type:varchar(255)"` // Backup entry created by the current attempt
+	Error        string     `gorm:"type:text"`
+	CreatedAt    time.Time  `gorm:"not null;index"`
+	ClaimedAt    *time.Time
+	HeartbeatAt  *time.Time
+	CompletedAt  *time.Time
+}
+
+// TableName specifies the table name for the BackupTask model
+func (BackupTask) TableName() string {
+	return "backup_tasks"
+}
+
 // Backup represents a database backup record
 type Backup struct {
 	ID               string    `gorm:"primaryKey;type:varchar(255)"`
@@ -108,10 +377,23 @@ type Backup struct {
 	S3UploadStatus   string `gorm:"type:varchar(50)"`
 	S3UploadError    string `gorm:"type:text"`
 	S3UploadComplete *time.Time
+	LocalDeletedAt   *time.Time
+	S3DeletedAt      *time.Time
+	RetentionError   string `gorm:"type:text"`
+	FencingToken     int64  `gorm:"not null;default:0"` // Leader lease token of the replica that ran the backup
+	Annotations      string `gorm:"type:text"`          // JSON object of free-form notes
+
+	// A hold keeps the backup from being deleted; HeldAt is nil without one
+	HeldAt         *time.Time `gorm:"index"`
+	HoldReason     string     `gorm:"type:text"`
+	HoldActor      string     `gorm:"type:varchar(255)"`
+	HoldExpiresAt  *time.Time
+	HoldObjectLock bool `gorm:"not null;default:false"`
 
 	// Relationships
-	LocalPaths []LocalPath `gorm:"foreignKey:BackupID;constraint:OnDelete:CASCADE"`
-	S3Keys     []S3Key     `gorm:"foreignKey:BackupID;constraint:OnDelete:CASCADE"`
+	LocalPaths []LocalPath   `gorm:"foreignKey:BackupID;constraint:OnDelete:CASCADE"`
+	S3Keys     []S3Key       `gorm:"foreignKey:BackupID;constraint:OnDelete:CASCADE"`
+	Labels     []BackupLabel `gorm:"foreignKey:BackupID;constraint:OnDelete:CASCADE"`
 }
 
 // TableName specifies the table name for the Backup model
@@ -143,6 +425,257 @@ func (S3Key) TableName() string {
 	return "s3_keys"
 }
 
+// BackupLabel is a label of a backup, in its own table so backups can be
+// filtered by label
+type BackupLabel struct {
+	BackupID string `gorm:"primaryKey;type:varchar(255)"`
+	Key      string `gorm:"column:label_key;primaryKey;type:varchar(255)"`
+	Value    string `gorm:"column:label_value;type:varchar(255);not null;index"`
+}
+
+// TableName specifies the table name for the BackupLabel model
+func (BackupLabel) TableName() string {
+	return "backup_labels"
+}
+
+// LabelMap returns the backup's labels as a map
+func (b Backup) LabelMap() map[string]string {
+	if len(b.Labels) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(b.Labels))
+	for _, label := range b.Labels {
+		labels[label.Key] = label.Value
+	}
+	return labels
+}
+
+// AnnotationMap decodes the backup's annotations, ignoring a malformed column
+func (b Backup) AnnotationMap() map[string]string {
+	if b.Annotations == "" {
+		return nil
+	}
+	var annotations map[string]string
+	if err := json.Unmarshal([]byte(b.Annotations), &annotations); err != nil {
+		return nil
+	}
+	return annotations
+}
+
+// EncodeAnnotations encodes backup annotations for the annotations column
+func EncodeAnnotations(annotations map[string]string) (string, error) {
+	if len(annotations) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(annotations)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode annotations: %w", err)
+	}
+	return string(data), nil
+}
+
+// NotHeldCondition selects backups without an active hold at the time given
+// as its argument
+const NotHeldCondition = "(held_at IS NULL OR (hold_expires_at IS NOT NULL AND hold_expires_at <= ?))"
+
+// Hold returns the backup's hold, nil when it has none
+func (b Backup) Hold() *types.BackupHold {
+	if b.HeldAt == nil {
+		return nil
+	}
+	hold := &types.BackupHold{
+		Reason:     b.HoldReason,
+		Actor:      b.HoldActor,
+		PlacedAt:   *b.HeldAt,
+		ObjectLock: b.HoldObjectLock,
+	}
+	if b.HoldExpiresAt != nil {
+		hold.ExpiresAt = *b.HoldExpiresAt
+	}
+	return hold
+}
+
+// HoldColumns returns the column values storing a hold, clearing them when
+// hold is nil
+func HoldColumns(hold *types.BackupHold) map[string]interface{} {
+	if hold == nil {
+		return map[string]interface{}{
+			"held_at":          nil,
+			"hold_reason":      "",
+			"hold_actor":       "",
+			"hold_expires_at":  nil,
+			"hold_object_lock": false,
+		}
+	}
+	var expiresAt *time.Time
+	if !hold.ExpiresAt.IsZero() {
+		expiresAt = &hold.ExpiresAt
+	}
+	return map[string]interface{}{
+		"held_at":          hold.PlacedAt,
+		"hold_reason":      hold.Reason,
+		"hold_actor":       hold.Actor,
+		"hold_expires_at":  expiresAt,
+		"hold_object_lock": hold.ObjectLock,
+	}
+}
+
+// SetHold stores a hold on a backup record that is about to be created
+func (b *Backup) SetHold(hold *types.BackupHold) {
+	if hold == nil {
+		return
+	}
+	placedAt := hold.PlacedAt
+	b.HeldAt = &placedAt
+	b.HoldReason = hold.Reason
+	b.HoldActor = hold.Actor
+	b.HoldObjectLock = hold.ObjectLock
+	if !hold.ExpiresAt.IsZero() {
+		expiresAt := hold.ExpiresAt
+		b.HoldExpiresAt = &expiresAt
+	}
+}
+
+// NewBackup converts backup metadata to a record with its paths, keys and
+// labels
+func NewBackup(meta types.BackupMeta) (Backup, error) {
+	backup := Backup{
+		ID:               meta.ID,
+		ServerName:       meta.ServerName,
+		ServerType:       meta.ServerType,
+		DatabaseName:     meta.Database,
+		BackupType:       meta.BackupType,
+		CreatedAt:        meta.CreatedAt,
+		Size:             meta.Size,
+		Status:           string(meta.Status),
+		ErrorMessage:     meta.ErrorMessage,
+		RetentionPolicy:  meta.RetentionPolicy,
+		LogFilePath:      meta.LogFilePath,
+		S3UploadStatus:   string(meta.S3UploadStatus),
+		S3UploadError:    meta.S3UploadError,
+		RetentionError:   meta.RetentionError,
+		FencingToken:     meta.FencingToken,
+		CompletedAt:      optionalTime(meta.CompletedAt),
+		ExpiresAt:        optionalTime(meta.ExpiresAt),
+		S3UploadComplete: optionalTime(meta.S3UploadComplete),
+		LocalDeletedAt:   optionalTime(meta.LocalDeletedAt),
+		S3DeletedAt:      optionalTime(meta.S3DeletedAt),
+	}
+
+	annotations, err := EncodeAnnotations(meta.Annotations)
+	if err != nil {
+		return Backup{}, err
+	}
+	backup.Annotations = annotations
+	backup.SetHold(meta.Hold)
+
+	for org, path := range meta.LocalPaths {
+		backup.LocalPaths = append(backup.LocalPaths, LocalPath{BackupID: meta.ID, Organization: org, Path: path})
+	}
+	for org, key := range meta.S3Keys {
+		backup.S3Keys = append(backup.S3Keys, S3Key{BackupID: meta.ID, Organization: org, Key: key})
+	}
+	for key, value := range meta.Labels {
+		backup.Labels = append(backup.Labels, BackupLabel{BackupID: meta.ID, Key: key, Value: value})
+	}
+	return backup, nil
+}
+
+// optionalTime returns nil for a zero time, for nullable time columns
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// AuditEntry is an entry of the audit log. Entries outlive the backups they
+// refer to, so there is no foreign key.
+type AuditEntry struct {
+	ID       uint      `gorm:"primaryKey;autoIncrement"`
+	Time     time.Time `gorm:"not null;index"`
+	Actor    string    `gorm:"type:varchar(255);not null"`
+	Action   string    `gorm:"type:varchar(50);not null"`
+	BackupID string    `gorm:"type:varchar(255);index"`
+	Detail   string    `gorm:"type:text"`
+}
+
+// TableName specifies the table name for the AuditEntry model
+func (AuditEntry) TableName() string {
+	return "audit_entries"
+}
+
+// NewAuditEntry converts an audit entry to its record
+func NewAuditEntry(entry types.AuditEntry) AuditEntry {
+	return AuditEntry{
+		Time:     entry.Time,
+		Actor:    entry.Actor,
+		Action:   entry.Action,
+		BackupID: entry.BackupID,
+		Detail:   entry.Detail,
+	}
+}
+
+// Entry converts the record to an audit entry
+func (e AuditEntry) Entry() types.AuditEntry {
+	return types.AuditEntry{
+		Time:     e.Time,
+		Actor:    e.Actor,
+		Action:   e.Action,
+		BackupID: e.BackupID,
+		Detail:   e.Detail,
+	}
+}
+
+// StatsSnapshot is the daily stats snapshot of one database
+type StatsSnapshot struct {
+	ID              uint      `gorm:"primaryKey;autoIncrement"`
+	Day             time.Time `gorm:"not null;uniqueIndex:idx_stats_snapshot_key"`
+	ServerName      string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_stats_snapshot_key"`
+	DatabaseName    string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_stats_snapshot_key"`
+	BackupCount     int       `gorm:"not null;default:0"`
+	FailedCount     int       `gorm:"not null;default:0"`
+	BackupSize      int64     `gorm:"not null;default:0"`
+	DurationSeconds float64   `gorm:"not null;default:0"`
+	LocalSize       int64     `gorm:"not null;default:0"`
+	S3Size          int64     `gorm:"not null;default:0"`
+}
+
+// TableName specifies the table name for the StatsSnapshot model
+func (StatsSnapshot) TableName() string {
+	return "stats_snapshots"
+}
+
+// NewStatsSnapshot converts a stats snapshot to its record
+func NewStatsSnapshot(snapshot types.StatsSnapshot) StatsSnapshot {
+	return StatsSnapshot{
+		Day:             snapshot.Day.UTC(),
+		ServerName:      snapshot.ServerName,
+		DatabaseName:    snapshot.Database,
+		BackupCount:     snapshot.BackupCount,
+		FailedCount:     snapshot.FailedCount,
+		BackupSize:      snapshot.BackupSize,
+		DurationSeconds: snapshot.DurationSeconds,
+		LocalSize:       snapshot.LocalSize,
+		S3Size:          snapshot.S3Size,
+	}
+}
+
+// Snapshot converts the record to a stats snapshot
+func (s StatsSnapshot) Snapshot() types.StatsSnapshot {
+	return types.StatsSnapshot{
+		Day:             types.StatsDay(s.Day),
+		ServerName:      s.ServerName,
+		Database:        s.DatabaseName,
+		BackupCount:     s.BackupCount,
+		FailedCount:     s.FailedCount,
+		BackupSize:      s.BackupSize,
+		DurationSeconds: s.DurationSeconds,
+		LocalSize:       s.LocalSize,
+		S3Size:          s.S3Size,
+	}
+}
+
 // Stats represents global metadata statistics
 type Stats struct {
 	ID             uint      `gorm:"primaryKey;autoIncrement:false;default:1"`
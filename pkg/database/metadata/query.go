@@ -0,0 +1,106 @@
+package metadata
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+// backupSortColumns maps the sort fields of a backup query to columns
+var backupSortColumns = map[string]string{
+	types.SortByCreatedAt: "created_at",
+	types.SortBySize:      "size",
+	types.SortByServer:    "server_name",
+	types.SortByDatabase:  "database_name",
+	types.SortByType:      "backup_type",
+	types.SortByStatus:    "status",
+}
+
+// QueryBackups runs a normalized backup query. It returns up to one backup
+// more than the limit, with paths and keys loaded, and the number of backups
+// matching the filters. Pages are read by keyset, so later pages cost no more
+// than the first.
+func QueryBackups(db *gorm.DB, q types.BackupQuery) ([]Backup, int64, error) {
+	column, ok := backupSortColumns[q.SortBy]
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported sort field %q", q.SortBy)
+	}
+
+	filtered := applyBackupFilters(db.Model(&Backup{}), q)
+	var total int64
+	if err := filtered.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count backups: %w", err)
+	}
+
+	direction, op := "DESC", "<"
+	if q.Ascending {
+		direction, op = "ASC", ">"
+	}
+	query := applyBackupFilters(db.Model(&Backup{}), q)
+	if after, ok := q.After(); ok {
+		value := q.SortValue(after)
+		query = query.Where(fmt.Sprintf("%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?)", column, op), value, value, after.ID)
+	}
+
+	var backups []Backup
+	err := query.
+		Preload("LocalPaths").
+		Preload("S3Keys").
+		Preload("Labels").
+		Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(q.Limit + 1).
+		Find(&backups).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query backups: %w", err)
+	}
+	return backups, total, nil
+}
+
+// applyBackupFilters adds the filters of a backup query to a query on the
+// backups table
+func applyBackupFilters(query *gorm.DB, q types.BackupQuery) *gorm.DB {
+	if q.ServerName != "" {
+		query = query.Where("server_name = ?", q.ServerName)
+	}
+	if q.Database != "" {
+		query = query.Where("database_name = ?", q.Database)
+	}
+	if q.BackupType != "" {
+		query = query.Where("backup_type = ?", q.BackupType)
+	}
+	if len(q.Statuses) > 0 {
+		statuses := make([]string, len(q.Statuses))
+		for i, status := range q.Statuses {
+			statuses[i] = string(status)
+		}
+		query = query.Where("status IN ?", statuses)
+	}
+	if q.ActiveOnly {
+		query = query.Where("status = ?", string(types.StatusSuccess))
+	}
+	if !q.CreatedAfter.IsZero() {
+		query = query.Where("created_at >= ?", q.CreatedAfter)
+	}
+	if !q.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", q.CreatedBefore)
+	}
+	if q.MinSize > 0 {
+		query = query.Where("size >= ?", q.MinSize)
+	}
+	if q.MaxSize > 0 {
+		query = query.Where("size <= ?", q.MaxSize)
+	}
+	for key, value := range q.Labels {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM backup_labels WHERE backup_labels.backup_id = backups.id AND backup_labels.label_key = ? AND backup_labels.label_value = ?)",
+			key, value,
+		)
+	}
+	if q.Search != "" {
+		condition, args := ContainsCondition(query, q.Search, "id", "server_name", "database_name")
+		query = query.Where(condition, args...)
+	}
+	return query
+}
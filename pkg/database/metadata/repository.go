@@ -1,11 +1,16 @@
 package metadata
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
 )
 
 // Repository handles database operations for backup metadata
@@ -116,7 +121,7 @@ func (r *Repository) GetBackupByID(id string) (*Backup, error) {
 	defer r.mutex.RUnlock()
 
 	var backup Backup
-	err := r.db.Preload("LocalPaths").Preload("S3Keys").Where("id = ?", id).First(&backup).Error
+	err := r.db.Preload("LocalPaths").Preload("S3Keys").Preload("Labels").Where("id = ?", id).First(&backup).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -136,7 +141,7 @@ func (r *Repository) GetAllBackups(preload bool) ([]Backup, error) {
 	query := r.db
 
 	if preload {
-		query = query.Preload("LocalPaths").Preload("S3Keys")
+		query = query.Preload("LocalPaths").Preload("S3Keys").Preload("Labels")
 	}
 
 	err := query.Find(&backups).Error
@@ -152,7 +157,7 @@ func (r *Repository) GetBackupsFiltered(serverName, database, backupType, status
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	query := r.db.Preload("LocalPaths").Preload("S3Keys")
+	query := r.db.Preload("LocalPaths").Preload("S3Keys").Preload("Labels")
 
 	if serverName != "" {
 		query = query.Where("server_name = ?", serverName)
@@ -179,6 +184,15 @@ func (r *Repository) GetBackupsFiltered(serverName, database, backupType, status
 	return backups, nil
 }
 
+// QueryBackups returns up to one backup more than the query's limit, and the
+// number of backups matching its filters
+func (r *Repository) QueryBackups(q types.BackupQuery) ([]Backup, int64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return QueryBackups(r.db, q)
+}
+
 // MarkBackupDeleted marks a backup as deleted
 func (r *Repository) MarkBackupDeleted(id string) error {
 	r.mutex.Lock()
@@ -187,6 +201,59 @@ func (r *Repository) MarkBackupDeleted(id string) error {
 	return r.db.Model(&Backup{}).Where("id = ?", id).Update("status", "deleted").Error
 }
 
+// UpdateRetentionStatus replaces a location's paths or keys with those that
+// could not be deleted, and marks the backup deleted once no copy remains
+func (r *Repository) UpdateRetentionStatus(id, location string, remaining map[string]string, errorMsg string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"retention_error": errorMsg}
+
+		switch location {
+		case "local":
+			if err := tx.Where("backup_id = ?", id).Delete(&LocalPath{}).Error; err != nil {
+				return err
+			}
+			for org, path := range remaining {
+				if err := tx.Create(&LocalPath{BackupID: id, Organization: org, Path: path}).Error; err != nil {
+					return err
+				}
+			}
+			if len(remaining) == 0 {
+				updates["local_deleted_at"] = time.Now()
+			}
+		case "s3":
+			if err := tx.Where("backup_id = ?", id).Delete(&S3Key{}).Error; err != nil {
+				return err
+			}
+			for org, key := range remaining {
+				if err := tx.Create(&S3Key{BackupID: id, Organization: org, Key: key}).Error; err != nil {
+					return err
+				}
+			}
+			if len(remaining) == 0 {
+				updates["s3_deleted_at"] = time.Now()
+			}
+		default:
+			return fmt.Errorf("unknown storage location %q", location)
+		}
+
+		var localCount, s3Count int64
+		if err := tx.Model(&LocalPath{}).Where("backup_id = ?", id).Count(&localCount).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&S3Key{}).Where("backup_id = ?", id).Count(&s3Count).Error; err != nil {
+			return err
+		}
+		if localCount == 0 && s3Count == 0 {
+			updates["status"] = "deleted"
+		}
+
+		return tx.Model(&Backup{}).Where("id = ?", id).Updates(updates).Error
+	})
+}
+
 // UpdateLogFilePath updates the log file path for a backup
 func (r *Repository) UpdateLogFilePath(id, logFilePath string) error {
 	r.mutex.Lock()
@@ -195,6 +262,217 @@ func (r *Repository) UpdateLogFilePath(id, logFilePath string) error {
 	return r.db.Model(&Backup{}).Where("id = ?", id).Update("log_file_path", logFilePath).Error
 }
 
+// UpdateFencingToken records the leader lease token of the replica running a backup
+func (r *Repository) UpdateFencingToken(id string, token int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.db.Model(&Backup{}).Where("id = ?", id).Update("fencing_token", token).Error
+}
+
+// UpdateLabels replaces the labels of a backup
+func (r *Repository) UpdateLabels(id string, labels map[string]string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return ReplaceBackupLabels(tx, id, labels)
+	})
+}
+
+// MergeAnnotations applies changes to the annotations of a backup in one
+// transaction and returns the resulting annotations
+func (r *Repository) MergeAnnotations(id string, changes map[string]string) (map[string]string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var merged map[string]string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		merged, err = MergeAnnotations(tx, id, changes)
+		return err
+	})
+	return merged, err
+}
+
+// UpdateHold places a hold on a backup, or releases it when hold is nil
+func (r *Repository) UpdateHold(id string, hold *types.BackupHold) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.db.Model(&Backup{}).Where("id = ?", id).Updates(HoldColumns(hold)).Error
+}
+
+// AddAuditEntry appends an entry to the audit log
+func (r *Repository) AddAuditEntry(entry types.AuditEntry) error {
+	record := NewAuditEntry(entry)
+	return r.db.Create(&record).Error
+}
+
+// GetAuditEntries returns up to limit audit entries, newest first, for one
+// backup or for all of them when backupID is empty
+func (r *Repository) GetAuditEntries(backupID string, limit int) ([]AuditEntry, error) {
+	query := r.db.Order("time DESC").Order("id DESC")
+	if backupID != "" {
+		query = query.Where("backup_id = ?", backupID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var entries []AuditEntry
+	err := query.Find(&entries).Error
+	return entries, err
+}
+
+// PutBackups stores backups as given in one transaction, replacing any
+// backups with the same IDs
+func (r *Repository) PutBackups(metas []types.BackupMeta) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, meta := range metas {
+			if err := SaveBackup(tx, meta); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SaveStatsSnapshots writes daily stats snapshots within a transaction,
+// replacing those of the same day, server and database
+func SaveStatsSnapshots(tx *gorm.DB, snapshots []types.StatsSnapshot) error {
+	for _, snapshot := range snapshots {
+		record := NewStatsSnapshot(snapshot)
+		if err := tx.Where("day = ? AND server_name = ? AND database_name = ?",
+			record.Day, record.ServerName, record.DatabaseName).Delete(&StatsSnapshot{}).Error; err != nil {
+			return fmt.Errorf("failed to replace stats snapshot: %w", err)
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return fmt.Errorf("failed to save stats snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// QueryStatsHistory returns the stats snapshots matching a query, oldest first
+func QueryStatsHistory(db *gorm.DB, q types.StatsHistoryQuery) ([]types.StatsSnapshot, error) {
+	query := db.Order("day").Order("server_name").Order("database_name")
+	if q.ServerName != "" {
+		query = query.Where("server_name = ?", q.ServerName)
+	}
+	if q.Database != "" {
+		query = query.Where("database_name = ?", q.Database)
+	}
+	if !q.From.IsZero() {
+		query = query.Where("day >= ?", q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		query = query.Where("day < ?", q.To.UTC())
+	}
+
+	var records []StatsSnapshot
+	if err := query.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read stats history: %w", err)
+	}
+	snapshots := make([]types.StatsSnapshot, 0, len(records))
+	for _, record := range records {
+		snapshots = append(snapshots, record.Snapshot())
+	}
+	return snapshots, nil
+}
+
+// SaveStatsSnapshots stores daily stats snapshots, replacing those of the
+// same day, server and database
+func (r *Repository) SaveStatsSnapshots(snapshots []types.StatsSnapshot) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return SaveStatsSnapshots(tx, snapshots)
+	})
+}
+
+// GetStatsHistory returns the stats snapshots matching a query, oldest first
+func (r *Repository) GetStatsHistory(q types.StatsHistoryQuery) ([]types.StatsSnapshot, error) {
+	return QueryStatsHistory(r.db, q)
+}
+
+// MergeAnnotations applies changes to the annotations of a backup within a
+// transaction, an empty value removing that annotation. The row is locked
+// while it is read, so concurrent changes to other keys are not lost; SQLite
+// transactions take the write lock as they begin.
+func MergeAnnotations(tx *gorm.DB, id string, changes map[string]string) (map[string]string, error) {
+	query := tx.Select("id", "annotations").Where("id = ?", id)
+	if !IsSQLite(tx) {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var backup Backup
+	if err := query.First(&backup).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("backup with ID %s not found", id)
+		}
+		return nil, err
+	}
+
+	merged := types.ApplyAnnotationChanges(backup.AnnotationMap(), changes)
+	encoded, err := EncodeAnnotations(merged)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&Backup{}).Where("id = ?", id).Update("annotations", encoded).Error; err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// SaveBackup writes a backup with its paths, keys and labels within a
+// transaction, replacing any backup with the same ID
+func SaveBackup(tx *gorm.DB, meta types.BackupMeta) error {
+	backup, err := NewBackup(meta)
+	if err != nil {
+		return err
+	}
+	localPaths, s3Keys, labels := backup.LocalPaths, backup.S3Keys, backup.Labels
+	backup.LocalPaths, backup.S3Keys, backup.Labels = nil, nil, nil
+
+	for _, model := range []interface{}{&LocalPath{}, &S3Key{}, &BackupLabel{}} {
+		if err := tx.Where("backup_id = ?", meta.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Save(&backup).Error; err != nil {
+		return err
+	}
+	for i := range localPaths {
+		if err := tx.Create(&localPaths[i]).Error; err != nil {
+			return err
+		}
+	}
+	for i := range s3Keys {
+		if err := tx.Create(&s3Keys[i]).Error; err != nil {
+			return err
+		}
+	}
+	for i := range labels {
+		if err := tx.Create(&labels[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceBackupLabels replaces the label rows of a backup within a transaction
+func ReplaceBackupLabels(tx *gorm.DB, id string, labels map[string]string) error {
+	if err := tx.Where("backup_id = ?", id).Delete(&BackupLabel{}).Error; err != nil {
+		return err
+	}
+	for key, value := range labels {
+		if err := tx.Create(&BackupLabel{BackupID: id, Key: key, Value: value}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetBackupStats calculates statistics about backups
 func (r *Repository) GetBackupStats() (*Stats, map[string]interface{}, error) {
 	r.mutex.RLock()
@@ -344,12 +622,14 @@ func (r *Repository) PurgeDeletedBackups(olderThan time.Duration) (int64, error)
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	threshold := time.Now().Add(-olderThan)
+	now := time.Now()
+	threshold := now.Add(-olderThan)
 
-	// Count records that will be deleted
+	// Count records that will be deleted; held backups are never purged
 	var count int64
 	if err := r.db.Model(&Backup{}).
 		Where("status = ? AND completed_at < ?", "deleted", threshold).
+		Where(NotHeldCondition, now).
 		Count(&count).Error; err != nil {
 		return 0, err
 	}
@@ -359,6 +639,7 @@ func (r *Repository) PurgeDeletedBackups(olderThan time.Duration) (int64, error)
 		// Using unscoped delete to remove the related records via cascading deletes
 		result := r.db.Unscoped().
 			Where("status = ? AND completed_at < ?", "deleted", threshold).
+			Where(NotHeldCondition, now).
 			Delete(&Backup{})
 
 		if result.Error != nil {
@@ -390,10 +671,12 @@ func (r *Repository) ImportBackups(backups []Backup) error {
 			// Extract related records
 			localPaths := backups[i].LocalPaths
 			s3Keys := backups[i].S3Keys
+			labels := backups[i].Labels
 
 			// Clear related records for creation
 			backups[i].LocalPaths = nil
 			backups[i].S3Keys = nil
+			backups[i].Labels = nil
 
 			// Create the backup record
 			if err := tx.Create(&backups[i]).Error; err != nil {
@@ -415,6 +698,14 @@ func (r *Repository) ImportBackups(backups []Backup) error {
 					return err
 				}
 			}
+
+			// Create labels
+			for j := range labels {
+				labels[j].BackupID = backups[i].ID
+				if err := tx.Create(&labels[j]).Error; err != nil {
+					return err
+				}
+			}
 		}
 
 		return nil
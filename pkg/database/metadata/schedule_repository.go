@@ -144,6 +144,24 @@ func (r *ScheduleRepository) UpdateSchedule(schedule *BackupSchedule) error {
 	return nil
 }
 
+// UpdateScheduleControl saves the pause and skip settings of a schedule
+// without touching the rest of it
+func (r *ScheduleRepository) UpdateScheduleControl(schedule *BackupSchedule) error {
+	schedule.UpdatedAt = time.Now()
+
+	result := r.db.Model(&BackupSchedule{ID: schedule.ID}).
+		Select("paused", "paused_at", "paused_until", "pause_reason", "skip_next_at", "updated_at").
+		Updates(schedule)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update schedule control: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("schedule not found: %s", schedule.ID)
+	}
+
+	return nil
+}
+
 // DeleteSchedule deletes a backup schedule
 func (r *ScheduleRepository) DeleteSchedule(id string) error {
 	// Start a transaction
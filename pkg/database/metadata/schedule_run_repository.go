@@ -0,0 +1,56 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ScheduleRunRepository handles database operations for schedule run states
+type ScheduleRunRepository struct {
+	db *gorm.DB
+}
+
+// NewScheduleRunRepository creates a new ScheduleRunRepository instance
+func NewScheduleRunRepository(db *gorm.DB) *ScheduleRunRepository {
+	return &ScheduleRunRepository{db: db}
+}
+
+// GetRunState retrieves the run state of a backup type, or nil if it has none
+func (r *ScheduleRunRepository) GetRunState(backupType string) (*ScheduleRunState, error) {
+	var state ScheduleRunState
+
+	err := r.db.Where("backup_type = ?", backupType).First(&state).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get schedule run state: %w", err)
+	}
+
+	return &state, nil
+}
+
+// SaveRunState creates or updates the run state of a backup type
+func (r *ScheduleRunRepository) SaveRunState(state *ScheduleRunState) error {
+	state.UpdatedAt = time.Now()
+
+	if err := r.db.Save(state).Error; err != nil {
+		return fmt.Errorf("failed to save schedule run state: %w", err)
+	}
+
+	return nil
+}
+
+// ListRunStates retrieves the run states of all backup types
+func (r *ScheduleRunRepository) ListRunStates() ([]ScheduleRunState, error) {
+	var states []ScheduleRunState
+
+	if err := r.db.Order("backup_type").Find(&states).Error; err != nil {
+		return nil, fmt.Errorf("failed to list schedule run states: %w", err)
+	}
+
+	return states, nil
+}
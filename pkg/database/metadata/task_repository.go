@@ -0,0 +1,357 @@
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+// ErrTaskNotClaimed is returned when a worker reports on a task it no longer
+// holds, typically because it was released after missing heartbeats
+var ErrTaskNotClaimed = errors.New("task is no longer claimed by this worker")
+
+// TaskRepository handles database operations for the backup task queue
+type TaskRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskRepository creates a new TaskRepository instance
+func NewTaskRepository(db *gorm.DB) *TaskRepository {
+	return &TaskRepository{db: db}
+}
+
+// Enqueue adds tasks to the queue and returns how many were added. A task
+// whose database is already waiting in the queue for the same backup type
+// is not added again. A task deferred by the last run is queued again in
+// place, so it keeps its place ahead of the tasks added after it.
+func (r *TaskRepository) Enqueue(tasks []BackupTask) (int, error) {
+	added := 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range tasks {
+			task := tasks[i]
+			key := tx.Model(&BackupTask{}).
+				Where("backup_type = ? AND server_name = ? AND database_name = ?",
+					task.BackupType, task.ServerName, task.DatabaseName)
+
+			var waiting int64
+			if err := key.Session(&gorm.Session{}).Where("status = ?", TaskStatusQueued).Count(&waiting).Error; err != nil {
+				return err
+			}
+			if waiting > 0 {
+				continue
+			}
+
+			var deferred BackupTask
+			err := key.Session(&gorm.Session{}).Where("status = ?", TaskStatusDeferred).Order("id").Limit(1).Find(&deferred).Error
+			if err != nil {
+				return err
+			}
+			if deferred.ID != 0 {
+				err := tx.Model(&BackupTask{}).Where("id = ?", deferred.ID).Updates(map[string]interface{}{
+					"status":        TaskStatusQueued,
+					"server_type":   task.ServerType,
+					"worker_id":     "",
+					"attempts":      0,
+					"deadline":      task.Deadline,
+					"fencing_token": task.FencingToken,
+					"backup_id":     "",
+					"error":         "",
+					"claimed_at":    nil,
+					"heartbeat_at":  nil,
+					"completed_at":  nil,
+				}).Error
+				if err != nil {
+					return err
+				}
+				added++
+				continue
+			}
+
+			task.Status = TaskStatusQueued
+			task.CreatedAt = time.Now()
+			if err := tx.Create(&task).Error; err != nil {
+				return err
+			}
+			added++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue backup tasks: %w", err)
+	}
+	return added, nil
+}
+
+// Claim hands the oldest queued task of a server matching one of the glob
+// patterns to a worker, or returns nil when there is none; no patterns match
+// every server. Rows are locked with SKIP LOCKED, so workers claiming at the
+// same time never get the same task.
+func (r *TaskRepository) Claim(workerID string, servers []string) (*BackupTask, error) {
+	var claimed *BackupTask
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("status = ?", TaskStatusQueued)
+		if !IsSQLite(tx) {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		if len(servers) > 0 {
+			conditions := make([]string, len(servers))
+			args := make([]interface{}, len(servers))
+			for i, pattern := range servers {
+				conditions[i] = fmt.Sprintf("server_name LIKE ? ESCAPE '%c'", likeEscape)
+				args[i] = likePattern(pattern)
+			}
+			query = query.Where(strings.Join(conditions, " OR "), args...)
+		}
+
+		var queued []BackupTask
+		if err := query.Order("id").Limit(1).Find(&queued).Error; err != nil {
+			return err
+		}
+		if len(queued) == 0 {
+			return nil
+		}
+
+		task := queued[0]
+		now := time.Now()
+		task.Status = TaskStatusRunning
+		task.WorkerID = workerID
+		task.Attempts++
+		task.BackupID = ""
+		task.ClaimedAt = &now
+		task.HeartbeatAt = &now
+
+		// Heartbeats are stamped by the database clock, so a worker with a
+		// skewed clock is not taken for stale
+		dbNow, _ := dbClock(tx, 0)
+		err := tx.Model(&BackupTask{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+			"status":       task.Status,
+			"worker_id":    task.WorkerID,
+			"attempts":     task.Attempts,
+			"backup_id":    "",
+			"claimed_at":   dbNow,
+			"heartbeat_at": dbNow,
+		}).Error
+		if err != nil {
+			return err
+		}
+		claimed = &task
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim backup task: %w", err)
+	}
+	return claimed, nil
+}
+
+// likePattern converts a glob pattern using * and ? into a LIKE pattern
+// escaped with likeEscape
+func likePattern(glob string) string {
+	var b strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteRune('%')
+		case '?':
+			b.WriteRune('_')
+		case '%', '_', likeEscape:
+			b.WriteRune(likeEscape)
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Heartbeat records that a worker is still running a task, by the database
+// clock
+func (r *TaskRepository) Heartbeat(id uint, workerID string) error {
+	now, _ := dbClock(r.db, 0)
+	result := r.db.Model(&BackupTask{}).
+		Where("id = ? AND worker_id = ? AND status = ?", id, workerID, TaskStatusRunning).
+		Update("heartbeat_at", now)
+	if result.Error != nil {
+		return fmt.Errorf("failed to record heartbeat of task %d: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskNotClaimed
+	}
+	return nil
+}
+
+// Started records the backup entry a worker created for a task, so the entry
+// can be marked interrupted if the worker stops responding before it finishes
+func (r *TaskRepository) Started(id uint, workerID, backupID string) error {
+	result := r.db.Model(&BackupTask{}).
+		Where("id = ? AND worker_id = ? AND status = ?", id, workerID, TaskStatusRunning).
+		Update("backup_id", backupID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to record the backup of task %d: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskNotClaimed
+	}
+	return nil
+}
+
+// Complete records the outcome of a task run by a worker
+func (r *TaskRepository) Complete(id uint, workerID, status, errorMsg string) error {
+	result := r.db.Model(&BackupTask{}).
+		Where("id = ? AND worker_id = ? AND status = ?", id, workerID, TaskStatusRunning).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"error":        errorMsg,
+			"completed_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to complete task %d: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskNotClaimed
+	}
+	return nil
+}
+
+// Release returns a task a worker has not started to the queue without
+// counting the attempt, for example when the worker shuts down
+func (r *TaskRepository) Release(id uint, workerID string) error {
+	err := r.db.Model(&BackupTask{}).
+		Where("id = ? AND worker_id = ? AND status = ?", id, workerID, TaskStatusRunning).
+		Updates(map[string]interface{}{
+			"status":    TaskStatusQueued,
+			"worker_id": "",
+			"attempts":  gorm.Expr("attempts - 1"),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to release task %d: %w", id, err)
+	}
+	return nil
+}
+
+// RequeueStale releases tasks whose worker has not sent a heartbeat within
+// timeout, as happens when a worker crashes. Tasks already started
+// maxAttempts times fail instead of being queued again. The backup entries
+// the stale attempts left pending are marked interrupted. Heartbeats are
+// compared against the database clock, which also stamped them.
+func (r *TaskRepository) RequeueStale(timeout time.Duration, maxAttempts int) (requeued, failed int, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		_, cutoff := dbClock(tx, -timeout)
+		query := tx.Where("status = ? AND heartbeat_at < ?", TaskStatusRunning, cutoff)
+		if !IsSQLite(tx) {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		var stale []BackupTask
+		if err := query.Find(&stale).Error; err != nil {
+			return err
+		}
+
+		var failIDs, requeueIDs []uint
+		var backupIDs []string
+		for _, task := range stale {
+			if task.Attempts >= maxAttempts {
+				failIDs = append(failIDs, task.ID)
+			} else {
+				requeueIDs = append(requeueIDs, task.ID)
+			}
+			if task.BackupID != "" {
+				backupIDs = append(backupIDs, task.BackupID)
+			}
+		}
+
+		if len(failIDs) > 0 {
+			result := tx.Model(&BackupTask{}).Where("id IN ?", failIDs).Updates(map[string]interface{}{
+				"status":       TaskStatusError,
+				"error":        fmt.Sprintf("worker stopped responding, giving up after %d attempts", maxAttempts),
+				"completed_at": time.Now(),
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			failed = int(result.RowsAffected)
+		}
+
+		if len(requeueIDs) > 0 {
+			result := tx.Model(&BackupTask{}).Where("id IN ?", requeueIDs).Updates(map[string]interface{}{
+				"status":    TaskStatusQueued,
+				"worker_id": "",
+				"backup_id": "",
+				"error":     "worker stopped responding",
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			requeued = int(result.RowsAffected)
+		}
+
+		if len(backupIDs) > 0 {
+			err := tx.Model(&Backup{}).
+				Where("id IN ? AND status = ?", backupIDs, string(types.StatusPending)).
+				Updates(map[string]interface{}{
+					"status":        string(types.StatusInterrupted),
+					"error_message": "interrupted: the worker running the backup stopped responding",
+					"completed_at":  time.Now(),
+				}).Error
+			if err != nil {
+				return fmt.Errorf("failed to mark backups interrupted: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to requeue stale backup tasks: %w", err)
+	}
+	return requeued, failed, nil
+}
+
+// Progress counts the tasks of a backup type still waiting or running, and
+// those that failed since a run started
+func (r *TaskRepository) Progress(backupType string, since time.Time) (pending, failed int, err error) {
+	var counts struct {
+		Pending int
+		Failed  int
+	}
+	err = r.db.Model(&BackupTask{}).
+		Select("COALESCE(SUM(CASE WHEN status IN (?, ?) THEN 1 ELSE 0 END), 0) AS pending, "+
+			"COALESCE(SUM(CASE WHEN status = ? AND completed_at >= ? THEN 1 ELSE 0 END), 0) AS failed",
+			TaskStatusQueued, TaskStatusRunning, TaskStatusError, since).
+		Where("backup_type = ?", backupType).
+		Scan(&counts).Error
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count %s backup tasks: %w", backupType, err)
+	}
+	return counts.Pending, counts.Failed, nil
+}
+
+// ListTasks retrieves the most recent tasks, optionally only those with the
+// given status
+func (r *TaskRepository) ListTasks(status string, limit int) ([]BackupTask, error) {
+	var tasks []BackupTask
+
+	query := r.db.Order("id DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list backup tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// PurgeTasks deletes finished tasks completed longer ago than olderThan
+func (r *TaskRepository) PurgeTasks(olderThan time.Duration) (int64, error) {
+	result := r.db.
+		Where("status IN ? AND completed_at < ?",
+			[]string{TaskStatusSuccess, TaskStatusError, TaskStatusDeferred}, time.Now().Add(-olderThan)).
+		Delete(&BackupTask{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge backup tasks: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,149 @@
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+func newTaskRepository(t *testing.T) *TaskRepository {
+	t.Helper()
+	db := openSQLite(t)
+	_, err := NewMigrator(db).Up(0)
+	require.NoError(t, err)
+	return NewTaskRepository(db)
+}
+
+func TestTaskRepositoryClaimFiltersServers(t *testing.T) {
+	repo := newTaskRepository(t)
+	added, err := repo.Enqueue([]BackupTask{
+		{BackupType: "hourly", ServerName: "db_1", ServerType: "mysql", DatabaseName: "app"},
+		{BackupType: "hourly", ServerName: "dbx1", ServerType: "mysql", DatabaseName: "app"},
+		{BackupType: "hourly", ServerName: "pg-eu-1", ServerType: "postgresql", DatabaseName: "app"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+
+	// An underscore in a pattern is literal, not a LIKE wildcard
+	task, err := repo.Claim("w1", []string{"pg-eu-*", "db_?"})
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, "db_1", task.ServerName)
+
+	task, err = repo.Claim("w1", []string{"pg-eu-*", "db_?"})
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, "pg-eu-1", task.ServerName)
+
+	task, err = repo.Claim("w1", []string{"pg-eu-*", "db_?"})
+	require.NoError(t, err)
+	assert.Nil(t, task, "dbx1 matches none of the patterns")
+
+	// No patterns match every server
+	task, err = repo.Claim("w2", nil)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, "dbx1", task.ServerName)
+}
+
+func TestTaskRepositoryRequeuesDeferredTasks(t *testing.T) {
+	repo := newTaskRepository(t)
+	start := time.Now().Add(-time.Second)
+	task := BackupTask{BackupType: "hourly", ServerName: "db1", ServerType: "mysql", DatabaseName: "app"}
+	_, err := repo.Enqueue([]BackupTask{task})
+	require.NoError(t, err)
+
+	claimed, err := repo.Claim("w1", nil)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	require.NoError(t, repo.Complete(claimed.ID, "w1", TaskStatusDeferred, "blackout"))
+
+	// A deferred task no longer holds up the run
+	pending, failed, err := repo.Progress("hourly", start)
+	require.NoError(t, err)
+	assert.Equal(t, 0, pending)
+	assert.Equal(t, 0, failed)
+
+	// The next run queues the deferred row again instead of adding one
+	added, err := repo.Enqueue([]BackupTask{task, {BackupType: "hourly", ServerName: "db1", ServerType: "mysql", DatabaseName: "other"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	requeued, err := repo.Claim("w2", nil)
+	require.NoError(t, err)
+	require.NotNil(t, requeued)
+	assert.Equal(t, claimed.ID, requeued.ID)
+	assert.Equal(t, 1, requeued.Attempts)
+	require.NoError(t, repo.Complete(requeued.ID, "w2", TaskStatusError, "dump failed"))
+
+	pending, failed, err = repo.Progress("hourly", start)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+	assert.Equal(t, 1, failed)
+
+	tasks, err := repo.ListTasks("", 10)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 2)
+}
+
+func TestTaskRepositoryRequeueStaleInterruptsBackups(t *testing.T) {
+	repo := newTaskRepository(t)
+	_, err := repo.Enqueue([]BackupTask{
+		{BackupType: "hourly", ServerName: "db1", ServerType: "mysql", DatabaseName: "app"},
+		{BackupType: "hourly", ServerName: "db1", ServerType: "mysql", DatabaseName: "billing"},
+	})
+	require.NoError(t, err)
+
+	// A worker claims both tasks, creates their backup entries and dies
+	// before finishing either of them
+	for _, database := range []string{"app", "billing"} {
+		task, err := repo.Claim("w1", nil)
+		require.NoError(t, err)
+		require.NotNil(t, task)
+
+		backup := Backup{
+			ID: "db1-" + database, ServerName: "db1", ServerType: "mysql", DatabaseName: database,
+			BackupType: "hourly", CreatedAt: time.Now(), Status: string(types.StatusPending),
+		}
+		require.NoError(t, repo.db.Create(&backup).Error)
+		require.NoError(t, repo.Started(task.ID, "w1", backup.ID))
+	}
+	require.NoError(t, repo.db.Model(&BackupTask{}).Where("database_name = ?", "billing").Update("attempts", 3).Error)
+	require.NoError(t, repo.db.Model(&BackupTask{}).Where("1 = 1").Update("heartbeat_at", time.Now().Add(-time.Hour)).Error)
+
+	requeued, failed, err := repo.RequeueStale(time.Minute, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, requeued)
+	assert.Equal(t, 1, failed)
+
+	for _, id := range []string{"db1-app", "db1-billing"} {
+		var backup Backup
+		require.NoError(t, repo.db.First(&backup, "id = ?", id).Error)
+		assert.Equal(t, string(types.StatusInterrupted), backup.Status, id)
+		assert.NotEmpty(t, backup.ErrorMessage, id)
+	}
+
+	// The next attempt starts without the previous attempt's backup
+	task, err := repo.Claim("w2", nil)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, "app", task.DatabaseName)
+	assert.Empty(t, task.BackupID)
+
+	// Only backups left pending are interrupted
+	require.NoError(t, repo.Started(task.ID, "w2", "db1-app-2"))
+	require.NoError(t, repo.db.Create(&Backup{
+		ID: "db1-app-2", ServerName: "db1", ServerType: "mysql", DatabaseName: "app",
+		BackupType: "hourly", CreatedAt: time.Now(), Status: string(types.StatusSuccess),
+	}).Error)
+	require.NoError(t, repo.db.Model(&BackupTask{}).Where("id = ?", task.ID).Update("heartbeat_at", time.Now().Add(-time.Hour)).Error)
+	_, _, err = repo.RequeueStale(time.Minute, 3)
+	require.NoError(t, err)
+	var backup Backup
+	require.NoError(t, repo.db.First(&backup, "id = ?", "db1-app-2").Error)
+	assert.Equal(t, string(types.StatusSuccess), backup.Status)
+}
@@ -98,7 +98,19 @@ func (p *Provider) ListDatabases(ctx context.Context) ([]string, error) {
 
 // Backup performs a database backup and writes it to the provided writer
 func (p *Provider) Backup(ctx context.Context, dbName string, output io.Writer, options common.BackupOptions) error {
-	cmd := p.createBackupCommand(dbName, options)
+	// Hand the password to mysqldump through a private option file rather
+	// than argv, where it would be visible to anyone running ps
+	var defaultsFile string
+	if p.Password != "" {
+		credFile, err := common.NewCredentialFile("my.cnf", common.MySQLOptionFileContents(p.Password))
+		if err != nil {
+			return err
+		}
+		defer credFile.Remove()
+		defaultsFile = credFile.Path
+	}
+
+	cmd := p.createBackupCommand(dbName, options, defaultsFile)
 	cmd.Stdout = output
 	cmd.Stderr = os.Stderr
 
@@ -132,22 +144,33 @@ func (p *Provider) Backup(ctx context.Context, dbName string, output io.Writer,
 
 // BackupCommand returns the command that would be used for backup
 func (p *Provider) BackupCommand(dbName string, options common.BackupOptions) string {
-	cmd := p.createBackupCommand(dbName, options)
+	// The real option file only exists for the duration of a backup, so
+	// show a placeholder in its place
+	var defaultsFile string
+	if p.Password != "" {
+		defaultsFile = defaultsFilePlaceholder
+	}
+
+	cmd := p.createBackupCommand(dbName, options, defaultsFile)
 	return cmd.String()
 }
 
-// createBackupCommand creates the exec.Cmd for mysqldump
-func (p *Provider) createBackupCommand(dbName string, options common.BackupOptions) *exec.Cmd {
-	args := []string{
+// defaultsFilePlaceholder stands in for the option file path when describing a command
+const defaultsFilePlaceholder = "<private-option-file>"
+
+// createBackupCommand creates the exec.Cmd for mysqldump. If defaultsFile is
+// set it is passed as --defaults-extra-file, which must be the first argument.
+func (p *Provider) createBackupCommand(dbName string, options common.BackupOptions, defaultsFile string) *exec.Cmd {
+	var args []string
+	if defaultsFile != "" {
+		args = append(args, "--defaults-extra-file="+defaultsFile)
+	}
+
+	args = append(args,
 		"-h", p.Host,
 		"-P", fmt.Sprintf("%d", p.Port),
 		"-u", p.User,
-	}
-
-	// Add password if provided
-	if p.Password != "" {
-		args = append(args, fmt.Sprintf("-p%s", p.Password))
-	}
+	)
 
 	// Add transaction consistency options
 	if options.TransactionMode {
@@ -2,10 +2,13 @@ package handlers
 
 import (
 	"context"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata"
 	"github.com/supporttools/GoSQLGuard/templates/pages"
 	"github.com/supporttools/GoSQLGuard/templates/types"
 )
@@ -19,6 +22,18 @@ func ConfigurationHandler(w http.ResponseWriter, r *http.Request) {
 	configData := pages.ConfigurationPageData{
 		IsYAMLConfig: isYAMLConfig,
 		Config:       &config.CFG,
+		Schedules:    make(map[string]dbmeta.BackupSchedule),
+	}
+
+	// Stored schedules carry their pause and skip settings
+	if metadata.DB != nil {
+		schedules, err := dbmeta.NewScheduleRepository(metadata.DB).GetEnabledSchedules()
+		if err != nil {
+			log.Printf("Failed to load schedules: %v", err)
+		}
+		for _, schedule := range schedules {
+			configData.Schedules[schedule.BackupType] = schedule
+		}
 	}
 
 	// Prepare page data
@@ -3,11 +3,16 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	metadataTypes "github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+	"github.com/supporttools/GoSQLGuard/pkg/scheduler"
+	"github.com/supporttools/GoSQLGuard/pkg/stats"
 	"github.com/supporttools/GoSQLGuard/templates/pages"
 	"github.com/supporttools/GoSQLGuard/templates/types"
 )
@@ -60,6 +65,78 @@ func RecentBackupsHandler(w http.ResponseWriter, r *http.Request) {
 	component.Render(context.Background(), w)
 }
 
+// ScheduleCalendarHandler returns an HTML fragment for HTMX with the backups
+// planned for the next 7 days
+func ScheduleCalendarHandler(sched *scheduler.Scheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		loc, err := config.LoadLocation(config.CFG.Scheduler.Timezone)
+		if err != nil {
+			loc = time.Local
+		}
+
+		// Days run midnight to midnight in the scheduler's default time zone
+		now := time.Now().In(loc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		days := make([]pages.CalendarDay, 7)
+		for i := range days {
+			days[i].Date = today.AddDate(0, 0, i)
+		}
+
+		// Without a scheduler, as when the admin server runs on its own,
+		// nothing is planned
+		var runs []scheduler.PlannedRun
+		if sched != nil {
+			runs, err = sched.PlanRuns(now, today.AddDate(0, 0, len(days)))
+			if err != nil {
+				http.Error(w, "Failed to plan scheduled backups: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		for _, run := range runs {
+			run.Time = run.Time.In(loc)
+			for i := range days {
+				if run.Time.Before(days[i].Date.AddDate(0, 0, 1)) {
+					days[i].Runs = append(days[i].Runs, run)
+					break
+				}
+			}
+		}
+
+		component := pages.RenderScheduleCalendar(days)
+		component.Render(context.Background(), w)
+	}
+}
+
+// growthChartDays is how many days of stats history the growth charts show
+const growthChartDays = 90
+
+// GrowthChartsHandler returns an HTML fragment for HTMX with the storage
+// growth charts and forecasts
+func GrowthChartsHandler(w http.ResponseWriter, r *http.Request) {
+	growth := pages.Growth{
+		LocalEnabled: config.CFG.Local.Enabled,
+		S3Enabled:    config.CFG.S3.Enabled,
+	}
+
+	if metadata.DefaultStore != nil {
+		now := time.Now()
+		to := metadataTypes.StatsDay(now).AddDate(0, 0, 1)
+		snapshots, err := metadata.DefaultStore.GetStatsHistory(metadataTypes.StatsHistoryQuery{
+			From: to.AddDate(0, 0, -growthChartDays),
+			To:   to,
+		})
+		if err != nil {
+			http.Error(w, "Failed to load stats history: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, growth.Totals = stats.History(snapshots)
+		growth.Forecasts = stats.Forecasts(growth.Totals, now)
+	}
+
+	component := pages.RenderGrowthCharts(growth)
+	component.Render(context.Background(), w)
+}
+
 // getDashboardData retrieves data for the dashboard
 func getDashboardData() pages.DashboardPageData {
 	dashboardData := pages.DashboardPageData{
@@ -88,11 +165,20 @@ func getDashboardData() pages.DashboardPageData {
 		}
 
 		// Get recent backups (last 5)
-		allBackups := metadata.DefaultStore.GetBackups()
-		if len(allBackups) > 5 {
-			dashboardData.RecentBackups = allBackups[len(allBackups)-5:]
+		page, err := metadata.DefaultStore.QueryBackups(metadataTypes.BackupQuery{Limit: 5})
+		if err != nil {
+			log.Printf("Failed to load recent backups: %v", err)
+		}
+		dashboardData.RecentBackups = page.Backups
+	}
+
+	// Get the run history of each schedule
+	if metadata.DB != nil {
+		runs, err := dbmeta.NewScheduleRunRepository(metadata.DB).ListRunStates()
+		if err != nil {
+			log.Printf("Failed to load schedule run states: %v", err)
 		} else {
-			dashboardData.RecentBackups = allBackups
+			dashboardData.ScheduleRuns = runs
 		}
 	}
 
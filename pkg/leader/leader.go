@@ -0,0 +1,242 @@
+// Package leader elects the one GoSQLGuard replica that runs scheduled jobs
+// when several replicas share a metadata database.
+package leader
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metrics"
+)
+
+// LeaseName is the name of the lease replicas compete for
+const LeaseName = "scheduler"
+
+// Role is the part a replica plays
+type Role string
+
+// Replica roles
+const (
+	RoleLeader     Role = "leader"     // Runs scheduled backups and retention
+	RoleFollower   Role = "follower"   // Serves the UI and API read-only
+	RoleStandalone Role = "standalone" // Leader election is disabled
+)
+
+// LeaseStore stores the lease replicas compete for
+type LeaseStore interface {
+	Acquire(name, holder string, ttl time.Duration) (*dbmeta.LeaderLease, bool, error)
+	Release(name, holder string, token int64) error
+}
+
+// Status describes the role of a replica and who leads
+type Status struct {
+	Role           Role      `json:"role"`
+	Identity       string    `json:"identity"`
+	Leader         string    `json:"leader,omitempty"`
+	Token          int64     `json:"token,omitempty"`
+	LeaseExpiresAt time.Time `json:"leaseExpiresAt,omitempty"`
+}
+
+// Elector holds or contests the leader lease on behalf of a replica
+type Elector struct {
+	store    LeaseStore
+	identity string
+	ttl      time.Duration
+	renew    time.Duration
+	now      func() time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+
+	mu        sync.Mutex
+	started   bool
+	leader    bool
+	token     int64
+	holder    string    // Current leader as last seen
+	expiresAt time.Time // When the lease last seen expires, on the local clock
+	onElected []func(token int64)
+}
+
+// NewElector creates an elector for the given replica identity. The lease is
+// renewed every renew interval and lapses after ttl without renewal.
+func NewElector(store LeaseStore, identity string, ttl, renew time.Duration) *Elector {
+	metrics.IsLeader.Set(0)
+	return &Elector{
+		store:    store,
+		identity: identity,
+		ttl:      ttl,
+		renew:    renew,
+		now:      time.Now,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// OnElected registers a function to run, in its own goroutine, each time this
+// replica becomes leader
+func (e *Elector) OnElected(fn func(token int64)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onElected = append(e.onElected, fn)
+}
+
+// Start contests the lease in the background until Stop is called
+func (e *Elector) Start() {
+	e.mu.Lock()
+	e.started = true
+	e.mu.Unlock()
+
+	go e.run()
+}
+
+// Stop stops contesting the lease and releases it if held, so another
+// replica can take over without waiting for it to expire
+func (e *Elector) Stop() {
+	e.mu.Lock()
+	started := e.started
+	e.mu.Unlock()
+	if !started {
+		return
+	}
+
+	e.stopOnce.Do(func() {
+		close(e.stop)
+		<-e.done
+	})
+}
+
+// run renews or contests the lease every renew interval
+func (e *Elector) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.renew)
+	defer ticker.Stop()
+
+	e.tick()
+	for {
+		select {
+		case <-ticker.C:
+			e.tick()
+		case <-e.stop:
+			e.release()
+			return
+		}
+	}
+}
+
+// tick renews or contests the lease once
+func (e *Elector) tick() {
+	// Leases expire on the database clock, which may differ from ours.
+	// Counting ttl from the request on the local clock instead never
+	// outlasts a lease this replica holds, as it was renewed after that.
+	requested := e.now()
+	lease, held, err := e.store.Acquire(LeaseName, e.identity, e.ttl)
+
+	e.mu.Lock()
+	var elected []func(token int64)
+	switch {
+	case err != nil:
+		log.Printf("Failed to renew leader lease: %v", err)
+		// The lease may still be ours, but once it expires another replica
+		// is free to take over
+		if e.leader && !e.now().Before(e.expiresAt) {
+			e.stepDown("lease expired without renewal")
+		}
+	case held:
+		if !e.leader || e.token != lease.Token {
+			log.Printf("Elected leader as %s with fencing token %d", e.identity, lease.Token)
+			e.leader = true
+			e.token = lease.Token
+			metrics.IsLeader.Set(1)
+			metrics.LeaderTransitions.WithLabelValues("elected").Inc()
+			elected = e.onElected
+		}
+		e.holder = lease.Holder
+		e.expiresAt = requested.Add(e.ttl)
+	default:
+		if e.leader {
+			e.stepDown(fmt.Sprintf("lease taken over by %s", lease.Holder))
+		}
+		e.holder = lease.Holder
+		e.expiresAt = requested.Add(e.ttl)
+	}
+	token := e.token
+	e.mu.Unlock()
+
+	for _, fn := range elected {
+		go fn(token)
+	}
+}
+
+// stepDown gives up leadership locally. Callers hold mu.
+func (e *Elector) stepDown(reason string) {
+	log.Printf("Stepping down as leader: %s", reason)
+	e.leader = false
+	metrics.IsLeader.Set(0)
+	metrics.LeaderTransitions.WithLabelValues("stepped_down").Inc()
+}
+
+// release gives up the lease if held
+func (e *Elector) release() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.leader {
+		return
+	}
+	if err := e.store.Release(LeaseName, e.identity, e.token); err != nil {
+		log.Printf("Failed to release leader lease: %v", err)
+	}
+	e.stepDown("shutting down")
+	e.holder = ""
+}
+
+// IsLeader reports whether this replica holds an unexpired lease
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader && e.now().Before(e.expiresAt)
+}
+
+// Token returns the fencing token of the current term, which only grows as
+// the lease changes hands
+func (e *Elector) Token() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.token
+}
+
+// Fence returns an error unless this replica still leads with the given
+// token, so work started in an earlier term can stop
+func (e *Elector) Fence(token int64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.leader || !e.now().Before(e.expiresAt) {
+		return fmt.Errorf("no longer the leader")
+	}
+	if e.token != token {
+		return fmt.Errorf("fencing token %d superseded by %d", token, e.token)
+	}
+	return nil
+}
+
+// Status returns the role of this replica and who leads
+func (e *Elector) Status() Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	status := Status{Role: RoleFollower, Identity: e.identity}
+	if e.leader && e.now().Before(e.expiresAt) {
+		status.Role = RoleLeader
+		status.Token = e.token
+	}
+	if e.holder != "" && e.now().Before(e.expiresAt) {
+		status.Leader = e.holder
+		status.LeaseExpiresAt = e.expiresAt
+	}
+	return status
+}
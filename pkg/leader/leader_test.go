@@ -0,0 +1,159 @@
+package leader
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+)
+
+// fakeLeaseStore mirrors the conditional updates of the lease repository
+type fakeLeaseStore struct {
+	mu    sync.Mutex
+	lease *dbmeta.LeaderLease
+	now   time.Time
+	err   error
+}
+
+func (f *fakeLeaseStore) Acquire(name, holder string, ttl time.Duration) (*dbmeta.LeaderLease, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, false, f.err
+	}
+	switch {
+	case f.lease == nil:
+		f.lease = &dbmeta.LeaderLease{Name: name, Holder: holder, Token: 1, AcquiredAt: f.now}
+	case f.lease.Holder == holder && f.now.Before(f.lease.ExpiresAt):
+	case !f.now.Before(f.lease.ExpiresAt):
+		f.lease.Holder = holder
+		f.lease.Token++
+		f.lease.AcquiredAt = f.now
+	default:
+		lease := *f.lease
+		return &lease, false, nil
+	}
+	f.lease.RenewedAt = f.now
+	f.lease.ExpiresAt = f.now.Add(ttl)
+	lease := *f.lease
+	return &lease, true, nil
+}
+
+func (f *fakeLeaseStore) Release(name, holder string, token int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.lease != nil && f.lease.Holder == holder && f.lease.Token == token {
+		f.lease.ExpiresAt = f.now
+	}
+	return nil
+}
+
+func (f *fakeLeaseStore) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func (f *fakeLeaseStore) clock() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func newTestElector(store *fakeLeaseStore, identity string) *Elector {
+	e := NewElector(store, identity, 15*time.Second, 5*time.Second)
+	e.now = store.clock
+	return e
+}
+
+func TestElectionAndFailover(t *testing.T) {
+	store := &fakeLeaseStore{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	a := newTestElector(store, "a")
+	b := newTestElector(store, "b")
+
+	elected := make(chan int64, 1)
+	b.OnElected(func(token int64) { elected <- token })
+
+	a.tick()
+	b.tick()
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("expected a to lead and b to follow, got a=%v b=%v", a.IsLeader(), b.IsLeader())
+	}
+	if status := b.Status(); status.Role != RoleFollower || status.Leader != "a" {
+		t.Errorf("unexpected follower status %+v", status)
+	}
+
+	// a stops renewing, so b takes over once the lease expires
+	store.advance(10 * time.Second)
+	b.tick()
+	if b.IsLeader() {
+		t.Fatal("b took over an unexpired lease")
+	}
+	store.advance(10 * time.Second)
+	if a.IsLeader() {
+		t.Error("a still considers itself leader after its lease expired")
+	}
+	b.tick()
+	if !b.IsLeader() || b.Token() != 2 {
+		t.Fatalf("expected b to lead with token 2, got leader=%v token=%d", b.IsLeader(), b.Token())
+	}
+	select {
+	case token := <-elected:
+		if token != 2 {
+			t.Errorf("expected elected callback with token 2, got %d", token)
+		}
+	case <-time.After(time.Second):
+		t.Error("elected callback did not run")
+	}
+
+	// Work fenced with a's token must stop, even after a notices
+	a.tick()
+	if a.IsLeader() {
+		t.Error("a did not step down after losing the lease")
+	}
+	if err := a.Fence(1); err == nil {
+		t.Error("expected fence with the old token to fail")
+	}
+	if err := b.Fence(2); err != nil {
+		t.Errorf("unexpected fence error for the current leader: %v", err)
+	}
+}
+
+func TestReleaseHandsOverImmediately(t *testing.T) {
+	store := &fakeLeaseStore{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	a := newTestElector(store, "a")
+	b := newTestElector(store, "b")
+
+	a.tick()
+	a.release()
+	if a.IsLeader() {
+		t.Error("a still leads after releasing the lease")
+	}
+	b.tick()
+	if !b.IsLeader() {
+		t.Error("b did not take over a released lease")
+	}
+}
+
+func TestStepDownWhenRenewalFails(t *testing.T) {
+	store := &fakeLeaseStore{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	a := newTestElector(store, "a")
+	a.tick()
+
+	store.err = errors.New("database unavailable")
+	store.advance(5 * time.Second)
+	a.tick()
+	if !a.IsLeader() {
+		t.Error("a stepped down before its lease expired")
+	}
+
+	store.advance(10 * time.Second)
+	a.tick()
+	if a.IsLeader() || a.Status().Role != RoleFollower {
+		t.Errorf("a did not step down once its lease expired: %+v", a.Status())
+	}
+}
@@ -0,0 +1,140 @@
+// Package archive exports and imports the metadata of a GoSQLGuard instance
+// as a versioned NDJSON archive, for moving it between clusters or from the
+// file store to a metadata database.
+//
+// An archive is one JSON record per line: a header, then the servers and
+// schedules with their database filters, options and retention policies,
+// then the backups with their paths and keys, and finally an end record
+// holding the number of records of each kind so truncated archives are
+// detected on import.
+package archive
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+// FormatVersion is the archive format written by Export. Import reads
+// archives up to this version.
+const FormatVersion = 1
+
+// Record kinds
+const (
+	KindHeader   = "header"
+	KindServer   = "server"
+	KindSchedule = "schedule"
+	KindBackup   = "backup"
+	KindEnd      = "end"
+)
+
+// Header is the first record of an archive
+type Header struct {
+	Version        int       `json:"version"`
+	ExportedAt     time.Time `json:"exportedAt"`
+	LocalDirectory string    `json:"localDirectory,omitempty"` // Backup directory of the exporting instance
+	S3Bucket       string    `json:"s3Bucket,omitempty"`
+	S3Prefix       string    `json:"s3Prefix,omitempty"`
+}
+
+// Record is one line of an archive; the field matching Kind is set
+type Record struct {
+	Kind     string                 `json:"kind"`
+	Header   *Header                `json:"header,omitempty"`
+	Server   *dbmeta.ServerConfig   `json:"server,omitempty"`
+	Schedule *dbmeta.BackupSchedule `json:"schedule,omitempty"`
+	Backup   *types.BackupMeta      `json:"backup,omitempty"`
+	Counts   map[string]int         `json:"counts,omitempty"` // End record: records of each kind
+}
+
+// Store is the metadata an archive is exported from or imported into
+type Store struct {
+	Backups types.MetadataStore
+
+	// DB holds the servers and schedules; nil when they come from the
+	// configuration file, in which case they are not exported or imported
+	DB *gorm.DB
+}
+
+// Export writes the metadata of store to w. Backups are read a page at a
+// time, oldest first, so large catalogs are not held in memory. It returns
+// the number of records written of each kind.
+func Export(w io.Writer, store Store, header Header) (map[string]int, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	counts := make(map[string]int)
+
+	header.Version = FormatVersion
+	if header.ExportedAt.IsZero() {
+		header.ExportedAt = time.Now()
+	}
+	if err := enc.Encode(Record{Kind: KindHeader, Header: &header}); err != nil {
+		return counts, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	write := func(record Record) error {
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("failed to write %s record: %w", record.Kind, err)
+		}
+		counts[record.Kind]++
+		return nil
+	}
+
+	if store.DB != nil {
+		servers, err := dbmeta.NewServerRepository(store.DB).GetAllServers()
+		if err != nil {
+			return counts, err
+		}
+		for i := range servers {
+			if err := write(Record{Kind: KindServer, Server: &servers[i]}); err != nil {
+				return counts, err
+			}
+		}
+
+		schedules, err := dbmeta.NewScheduleRepository(store.DB).GetAllSchedules()
+		if err != nil {
+			return counts, err
+		}
+		for i := range schedules {
+			if err := write(Record{Kind: KindSchedule, Schedule: &schedules[i]}); err != nil {
+				return counts, err
+			}
+		}
+	}
+
+	query := types.BackupQuery{
+		SortBy:    types.SortByCreatedAt,
+		Ascending: true,
+		Limit:     types.MaxQueryLimit,
+	}
+	for {
+		page, err := store.Backups.QueryBackups(query)
+		if err != nil {
+			return counts, fmt.Errorf("failed to read backups: %w", err)
+		}
+		for i := range page.Backups {
+			if err := write(Record{Kind: KindBackup, Backup: &page.Backups[i]}); err != nil {
+				return counts, err
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	if err := enc.Encode(Record{Kind: KindEnd, Counts: counts}); err != nil {
+		return counts, fmt.Errorf("failed to write end record: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return counts, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,347 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+// openStore opens an empty file store, with an embedded metadata database
+// for servers and schedules when withDB is set
+func openStore(t *testing.T, withDB bool) Store {
+	t.Helper()
+	dir := t.TempDir()
+	files, err := metadata.OpenFileStore(filepath.Join(dir, "metadata.json"))
+	require.NoError(t, err)
+	store := Store{Backups: files}
+	if !withDB {
+		return store
+	}
+
+	dialector, err := dbmeta.Dialector(config.MetadataDBConfig{
+		Type: config.MetadataDBTypeSQLite,
+		Path: filepath.Join(dir, "metadata.db"),
+	})
+	require.NoError(t, err)
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	require.NoError(t, dbmeta.RunMigrations(db))
+	store.DB = db
+	return store
+}
+
+func testBackup(id string, created time.Time) types.BackupMeta {
+	return types.BackupMeta{
+		ID:          id,
+		ServerName:  "db1",
+		ServerType:  "mysql",
+		Database:    "app",
+		BackupType:  "daily",
+		CreatedAt:   created,
+		CompletedAt: created.Add(time.Minute),
+		Size:        1024,
+		Status:      types.StatusSuccess,
+		LocalPaths:  map[string]string{"by-server": "/backups/db1/daily/app-" + id + ".sql.gz"},
+		S3Keys:      map[string]string{"by-server": "prod/db1/daily/app-" + id + ".sql.gz"},
+		LocalPath:   "/backups/db1/daily/app-" + id + ".sql.gz",
+		S3Key:       "prod/db1/daily/app-" + id + ".sql.gz",
+		LogFilePath: "/backups/logs/" + id + ".log",
+		Labels:      map[string]string{"team": "payments"},
+	}
+}
+
+func export(t *testing.T, store Store) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	_, err := Export(&buf, store, Header{LocalDirectory: "/backups", S3Prefix: "prod"})
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	src := openStore(t, true)
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, id := range []string{"b1", "b2", "b3"} {
+		require.NoError(t, src.Backups.PutBackup(testBackup(id, now.Add(time.Duration(i)*time.Hour))))
+	}
+	require.NoError(t, dbmeta.NewServerRepository(src.DB).CreateServer(&dbmeta.ServerConfig{
+		Name: "db1", Type: "mysql", Host: "db1.internal", Port: "3306", Username: "backup", Password: "secret",
+		Labels:          "team=payments",
+		DatabaseFilters: []dbmeta.ServerDatabaseFilter{{FilterType: "exclude", DatabaseName: "tmp"}},
+		MySQLOptions:    []dbmeta.ServerMySQLOption{{OptionName: "single-transaction", OptionValue: "true"}},
+	}))
+	require.NoError(t, dbmeta.NewScheduleRepository(src.DB).CreateSchedule(&dbmeta.BackupSchedule{
+		Name: "daily", BackupType: "daily", CronExpression: "0 2 * * *", Enabled: true,
+		RetentionPolicies: []dbmeta.ScheduleRetentionPolicy{{StorageType: "local", Duration: "168h"}, {StorageType: "s3", KeepDaily: 30}},
+	}))
+
+	data := export(t, src)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 7)
+	var first, last Record
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[6]), &last))
+	assert.Equal(t, KindHeader, first.Kind)
+	assert.Equal(t, FormatVersion, first.Header.Version)
+	assert.Equal(t, map[string]int{KindServer: 1, KindSchedule: 1, KindBackup: 3}, last.Counts)
+
+	dst := openStore(t, true)
+	result, err := Import(bytes.NewReader(data), dst, Options{Rewrites: []Rewrite{
+		{Location: LocationLocal, From: "/backups", To: "/data/backups"},
+		{Location: LocationS3, From: "prod/", To: "archive/prod"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "/backups", result.Header.LocalDirectory)
+	assert.Equal(t, Count{Added: 3}, result.Counts[KindBackup])
+	assert.Equal(t, Count{Added: 1}, result.Counts[KindServer])
+	assert.Equal(t, Count{Added: 1}, result.Counts[KindSchedule])
+
+	backup, found := dst.Backups.GetBackupByID("b2")
+	require.True(t, found)
+	assert.Equal(t, "/data/backups/db1/daily/app-b2.sql.gz", backup.LocalPaths["by-server"])
+	assert.Equal(t, "/data/backups/db1/daily/app-b2.sql.gz", backup.LocalPath)
+	assert.Equal(t, "/data/backups/logs/b2.log", backup.LogFilePath)
+	assert.Equal(t, "archive/prod/db1/daily/app-b2.sql.gz", backup.S3Keys["by-server"])
+	assert.Equal(t, "archive/prod/db1/daily/app-b2.sql.gz", backup.S3Key)
+	assert.Equal(t, map[string]string{"team": "payments"}, backup.Labels)
+	assert.True(t, backup.CreatedAt.Equal(now.Add(time.Hour)))
+
+	server, err := dbmeta.NewServerRepository(dst.DB).GetServerByName("db1")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", server.Password)
+	require.Len(t, server.DatabaseFilters, 1)
+	assert.Equal(t, "tmp", server.DatabaseFilters[0].DatabaseName)
+	require.Len(t, server.MySQLOptions, 1)
+	assert.Equal(t, "single-transaction", server.MySQLOptions[0].OptionName)
+
+	schedule, err := dbmeta.NewScheduleRepository(dst.DB).GetScheduleByName("daily")
+	require.NoError(t, err)
+	assert.Len(t, schedule.RetentionPolicies, 2)
+
+	// Importing again changes nothing by default
+	result, err = Import(bytes.NewReader(data), dst, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, Count{Skipped: 3}, result.Counts[KindBackup])
+	assert.Equal(t, Count{Skipped: 1}, result.Counts[KindServer])
+	assert.Equal(t, Count{Skipped: 1}, result.Counts[KindSchedule])
+	backup, _ = dst.Backups.GetBackupByID("b2")
+	assert.Equal(t, "/data/backups/db1/daily/app-b2.sql.gz", backup.LocalPath)
+}
+
+func TestImportConflictModes(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	src := openStore(t, false)
+	archived := testBackup("b1", now)
+	archived.S3Keys["by-type"] = "prod/daily/db1/app-b1.sql.gz"
+	archived.Annotations = map[string]string{"ticket": "OPS-1"}
+	archived.RetentionPolicy = "archived"
+	require.NoError(t, src.Backups.PutBackup(archived))
+	data := export(t, src)
+
+	tests := []struct {
+		conflict string
+		want     Count
+		check    func(t *testing.T, b types.BackupMeta)
+	}{
+		{ConflictSkip, Count{Skipped: 1}, func(t *testing.T, b types.BackupMeta) {
+			assert.Equal(t, "existing", b.RetentionPolicy)
+			assert.Len(t, b.S3Keys, 1)
+			assert.Empty(t, b.Annotations)
+		}},
+		{ConflictOverwrite, Count{Replaced: 1}, func(t *testing.T, b types.BackupMeta) {
+			assert.Equal(t, "archived", b.RetentionPolicy)
+			assert.Equal(t, "/backups/db1/daily/app-b1.sql.gz", b.LocalPaths["by-server"])
+			assert.NotContains(t, b.Labels, "owner")
+		}},
+		{ConflictMerge, Count{Merged: 1}, func(t *testing.T, b types.BackupMeta) {
+			assert.Equal(t, "existing", b.RetentionPolicy)
+			assert.Equal(t, "/moved/app-b1.sql.gz", b.LocalPaths["by-server"])
+			assert.Equal(t, "prod/daily/db1/app-b1.sql.gz", b.S3Keys["by-type"])
+			assert.Equal(t, map[string]string{"team": "payments", "owner": "dba"}, b.Labels)
+			assert.Equal(t, map[string]string{"ticket": "OPS-1"}, b.Annotations)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.conflict, func(t *testing.T) {
+			dst := openStore(t, false)
+			existing := testBackup("b1", now)
+			existing.LocalPaths = map[string]string{"by-server": "/moved/app-b1.sql.gz"}
+			existing.Labels = map[string]string{"owner": "dba"}
+			existing.RetentionPolicy = "existing"
+			require.NoError(t, dst.Backups.PutBackup(existing))
+
+			result, err := Import(bytes.NewReader(data), dst, Options{Conflict: tt.conflict})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.Counts[KindBackup])
+			backup, found := dst.Backups.GetBackupByID("b1")
+			require.True(t, found)
+			tt.check(t, backup)
+		})
+	}
+}
+
+func TestImportMergesServers(t *testing.T) {
+	src := openStore(t, true)
+	archived := dbmeta.ServerConfig{
+		Name: "db1", Type: "mysql", Host: "db1", Port: "3306", Username: "backup", Password: "secret",
+	}
+	require.NoError(t, archived.SetDatabaseLabelRules([]config.DatabaseLabelRule{
+		{Databases: []string{"app*"}, Labels: map[string]string{"team": "archived"}},
+		{Databases: []string{"logs"}, Labels: map[string]string{"tier": "cold"}},
+	}))
+	require.NoError(t, dbmeta.NewServerRepository(src.DB).CreateServer(&archived))
+	data := export(t, src)
+
+	dst := openStore(t, true)
+	existing := dbmeta.ServerConfig{
+		Name: "db1", Type: "mysql", Host: "db1", Port: "3306", Username: "backup", Password: "secret",
+	}
+	require.NoError(t, existing.SetDatabaseLabelRules([]config.DatabaseLabelRule{
+		{Databases: []string{"app*"}, Labels: map[string]string{"team": "existing"}},
+	}))
+	require.NoError(t, dbmeta.NewServerRepository(dst.DB).CreateServer(&existing))
+
+	result, err := Import(bytes.NewReader(data), dst, Options{Conflict: ConflictMerge})
+	require.NoError(t, err)
+	assert.Equal(t, Count{Merged: 1}, result.Counts[KindServer])
+
+	server, err := dbmeta.NewServerRepository(dst.DB).GetServerByName("db1")
+	require.NoError(t, err)
+	assert.Equal(t, []config.DatabaseLabelRule{
+		{Databases: []string{"app*"}, Labels: map[string]string{"team": "existing"}},
+		{Databases: []string{"logs"}, Labels: map[string]string{"tier": "cold"}},
+	}, server.DatabaseLabelRules())
+}
+
+// countingStore counts the batches written to a metadata store
+type countingStore struct {
+	types.MetadataStore
+	batches int
+}
+
+func (s *countingStore) PutBackups(backups []types.BackupMeta) error {
+	s.batches++
+	return s.MetadataStore.PutBackups(backups)
+}
+
+func TestImportStoresBackupsInBatches(t *testing.T) {
+	src := openStore(t, false)
+	now := time.Now().UTC()
+	backups := make([]types.BackupMeta, importBatchSize+10)
+	for i := range backups {
+		backups[i] = testBackup(fmt.Sprintf("b%04d", i), now.Add(time.Duration(i)*time.Second))
+	}
+	require.NoError(t, src.Backups.PutBackups(backups))
+	data := export(t, src)
+
+	dst := openStore(t, false)
+	counting := &countingStore{MetadataStore: dst.Backups}
+	dst.Backups = counting
+	result, err := Import(bytes.NewReader(data), dst, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, Count{Added: len(backups)}, result.Counts[KindBackup])
+	assert.Equal(t, 2, counting.batches)
+	assert.Len(t, counting.GetBackups(), len(backups))
+}
+
+func TestImportDryRun(t *testing.T) {
+	src := openStore(t, false)
+	require.NoError(t, src.Backups.PutBackup(testBackup("b1", time.Now())))
+	data := export(t, src)
+
+	dst := openStore(t, false)
+	result, err := Import(bytes.NewReader(data), dst, Options{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, Count{Added: 1}, result.Counts[KindBackup])
+	_, found := dst.Backups.GetBackupByID("b1")
+	assert.False(t, found)
+}
+
+func TestImportWithoutDatabaseSkipsConfiguration(t *testing.T) {
+	src := openStore(t, true)
+	require.NoError(t, dbmeta.NewServerRepository(src.DB).CreateServer(&dbmeta.ServerConfig{
+		Name: "db1", Type: "mysql", Host: "db1", Port: "3306", Username: "backup", Password: "secret",
+	}))
+	data := export(t, src)
+
+	result, err := Import(bytes.NewReader(data), openStore(t, false), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, Count{Skipped: 1}, result.Counts[KindServer])
+	assert.Len(t, result.Warnings, 1)
+}
+
+func TestImportRejectsInvalidArchives(t *testing.T) {
+	src := openStore(t, false)
+	require.NoError(t, src.Backups.PutBackup(testBackup("b1", time.Now())))
+	require.NoError(t, src.Backups.PutBackup(testBackup("b2", time.Now())))
+	data := string(export(t, src))
+	lines := strings.SplitAfter(data, "\n")
+
+	tests := []struct {
+		name    string
+		archive string
+		opts    Options
+		want    string
+	}{
+		{"empty", "", Options{}, "failed to read header"},
+		{"no header", lines[1], Options{}, "does not start with a header"},
+		{"newer version", strings.Replace(data, `"version":1`, `"version":2`, 1), Options{}, "unsupported archive version 2"},
+		{"truncated", strings.Join(lines[:3], ""), Options{}, ErrTruncated.Error()},
+		{"missing record", lines[0] + lines[1] + lines[3], Options{}, "archive lists 2 backup records but holds 1"},
+		{"unknown kind", lines[0] + `{"kind":"widget"}` + "\n" + lines[3], Options{}, `record 2: invalid "widget" record`},
+		{"unknown conflict mode", data, Options{Conflict: "replace"}, `unknown conflict mode "replace"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Import(strings.NewReader(tt.archive), openStore(t, false), tt.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		from, to, path, want string
+		matched              bool
+	}{
+		{"/backups", "/data", "/backups/db1/app.sql.gz", "/data/db1/app.sql.gz", true},
+		{"/backups/", "/data/", "/backups/db1/app.sql.gz", "/data/db1/app.sql.gz", true},
+		{"/backups", "/data", "/backups-old/app.sql.gz", "/backups-old/app.sql.gz", false},
+		{"prod", "", "prod/db1/app.sql.gz", "db1/app.sql.gz", true},
+		{"", "prod", "db1/app.sql.gz", "prod/db1/app.sql.gz", true},
+		{"/backups", "/data", "", "", false},
+	}
+	for _, tt := range tests {
+		got, matched := Rewrite{From: tt.from, To: tt.to}.apply(tt.path)
+		assert.Equal(t, tt.want, got, "%s=%s on %s", tt.from, tt.to, tt.path)
+		assert.Equal(t, tt.matched, matched, "%s=%s on %s", tt.from, tt.to, tt.path)
+	}
+
+	rw, err := ParseRewrite(LocationS3, "old/=new/")
+	require.NoError(t, err)
+	assert.Equal(t, Rewrite{Location: LocationS3, From: "old/", To: "new/"}, rw)
+	_, err = ParseRewrite(LocationS3, "old")
+	assert.Error(t, err)
+	_, err = ParseRewrite(LocationS3, "same=same")
+	assert.Error(t, err)
+}
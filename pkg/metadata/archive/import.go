@@ -0,0 +1,548 @@
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+// Conflict modes, deciding what happens to an imported backup whose ID, or
+// a server or schedule whose name, already exists
+const (
+	ConflictSkip      = "skip"      // Keep the existing entry
+	ConflictOverwrite = "overwrite" // Replace it with the archived one
+	ConflictMerge     = "merge"     // Keep it and add what only the archived one has
+)
+
+// Rewrite locations
+const (
+	LocationLocal = "local"
+	LocationS3    = "s3"
+)
+
+// importBatchSize is how many backups are stored with each write, so stores
+// that rewrite a file or recompute stats on every write do so once a batch
+const importBatchSize = 500
+
+// ErrTruncated is returned for an archive that ends without its end record
+var ErrTruncated = errors.New("archive ends without its end record and may be truncated")
+
+// Rewrite replaces the From prefix of local paths or S3 keys with To, for
+// when the backup directory or S3 prefix differs between instances
+type Rewrite struct {
+	Location string // LocationLocal or LocationS3
+	From     string
+	To       string
+}
+
+// ParseRewrite parses an OLD=NEW prefix rewrite for location
+func ParseRewrite(location, spec string) (Rewrite, error) {
+	from, to, ok := strings.Cut(spec, "=")
+	if !ok {
+		return Rewrite{}, fmt.Errorf("invalid rewrite %q: expected OLD=NEW", spec)
+	}
+	if from == to {
+		return Rewrite{}, fmt.Errorf("invalid rewrite %q: prefixes are the same", spec)
+	}
+	return Rewrite{Location: location, From: from, To: to}, nil
+}
+
+// apply rewrites p when it starts with the From prefix; an empty From
+// matches every path
+func (r Rewrite) apply(p string) (string, bool) {
+	from := strings.TrimSuffix(r.From, "/")
+	to := strings.TrimSuffix(r.To, "/")
+	switch {
+	case p == "":
+		return p, false
+	case from == "":
+		if to == "" {
+			return p, true
+		}
+		return to + "/" + strings.TrimPrefix(p, "/"), true
+	case p == from:
+		return to, true
+	case strings.HasPrefix(p, from+"/"):
+		rest := p[len(from)+1:]
+		if to == "" {
+			return rest, true
+		}
+		return to + "/" + rest, true
+	}
+	return p, false
+}
+
+// Options control an import
+type Options struct {
+	Conflict string // One of the Conflict modes, ConflictSkip by default
+	Rewrites []Rewrite
+	DryRun   bool // Count what would change without writing anything
+}
+
+// Count is what an import did with the records of one kind
+type Count struct {
+	Added    int `json:"added"`
+	Replaced int `json:"replaced"`
+	Merged   int `json:"merged"`
+	Skipped  int `json:"skipped"`
+}
+
+// Result summarizes an import
+type Result struct {
+	Header   Header           `json:"header"`
+	Counts   map[string]Count `json:"counts"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
+
+// Import reads an archive from r into store, one record at a time, storing
+// backups in batches. Records before an error are still imported; since conflicts are resolved by ID
+// and name, importing the same archive again is safe.
+func Import(r io.Reader, store Store, opts Options) (Result, error) {
+	if opts.Conflict == "" {
+		opts.Conflict = ConflictSkip
+	}
+	if opts.Conflict != ConflictSkip && opts.Conflict != ConflictOverwrite && opts.Conflict != ConflictMerge {
+		return Result{}, fmt.Errorf("unknown conflict mode %q", opts.Conflict)
+	}
+	for _, rw := range opts.Rewrites {
+		if rw.Location != LocationLocal && rw.Location != LocationS3 {
+			return Result{}, fmt.Errorf("unknown rewrite location %q", rw.Location)
+		}
+	}
+
+	im := &importer{store: store, opts: opts, records: make(map[string]int), queued: make(map[string]int)}
+	im.result.Counts = make(map[string]Count)
+	err := im.read(json.NewDecoder(r))
+	// Backups read before an error are imported as well
+	if flushErr := im.flush(); err == nil {
+		err = flushErr
+	}
+	return im.result, err
+}
+
+// read imports the records of an archive up to its end record
+func (im *importer) read(dec *json.Decoder) error {
+	var header Record
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+	if header.Kind != KindHeader || header.Header == nil {
+		return fmt.Errorf("archive does not start with a header")
+	}
+	if header.Header.Version < 1 || header.Header.Version > FormatVersion {
+		return fmt.Errorf("unsupported archive version %d, this version of GoSQLGuard reads up to %d",
+			header.Header.Version, FormatVersion)
+	}
+	im.result.Header = *header.Header
+
+	for n := 2; ; n++ {
+		var record Record
+		if err := dec.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return ErrTruncated
+			}
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+		if record.Kind == KindEnd {
+			if err := im.finish(record.Counts); err != nil {
+				return err
+			}
+			if dec.More() {
+				return fmt.Errorf("record %d: data after the end record", n+1)
+			}
+			return nil
+		}
+		if err := im.importRecord(record); err != nil {
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+	}
+}
+
+// importer holds the state of one import
+type importer struct {
+	store   Store
+	opts    Options
+	result  Result
+	records map[string]int // Records read of each kind
+	warned  bool           // Whether servers and schedules were reported as skipped
+
+	pending []types.BackupMeta // Backups waiting to be stored
+	queued  map[string]int     // Index in pending by backup ID
+}
+
+// importRecord imports one server, schedule or backup record
+func (im *importer) importRecord(record Record) error {
+	switch {
+	case record.Kind == KindServer && record.Server != nil:
+		im.records[KindServer]++
+		return im.importServer(*record.Server)
+	case record.Kind == KindSchedule && record.Schedule != nil:
+		im.records[KindSchedule]++
+		return im.importSchedule(*record.Schedule)
+	case record.Kind == KindBackup && record.Backup != nil:
+		im.records[KindBackup]++
+		return im.importBackup(*record.Backup)
+	case record.Kind == KindHeader:
+		return fmt.Errorf("unexpected second header")
+	}
+	return fmt.Errorf("invalid %q record", record.Kind)
+}
+
+// finish checks the end record against the records read
+func (im *importer) finish(counts map[string]int) error {
+	for _, kind := range []string{KindServer, KindSchedule, KindBackup} {
+		if counts[kind] != im.records[kind] {
+			return fmt.Errorf("archive lists %d %s records but holds %d", counts[kind], kind, im.records[kind])
+		}
+	}
+	return nil
+}
+
+// count records the outcome of one record
+func (im *importer) count(kind string, outcome func(*Count)) {
+	c := im.result.Counts[kind]
+	outcome(&c)
+	im.result.Counts[kind] = c
+}
+
+// resolve counts how a record is imported and returns false when it is to
+// be skipped
+func (im *importer) resolve(kind string, exists bool) bool {
+	switch {
+	case !exists:
+		im.count(kind, func(c *Count) { c.Added++ })
+	case im.opts.Conflict == ConflictOverwrite:
+		im.count(kind, func(c *Count) { c.Replaced++ })
+	case im.opts.Conflict == ConflictMerge:
+		im.count(kind, func(c *Count) { c.Merged++ })
+	default:
+		im.count(kind, func(c *Count) { c.Skipped++ })
+		return false
+	}
+	return true
+}
+
+// importBackup rewrites the paths of a backup and stores it
+func (im *importer) importBackup(backup types.BackupMeta) error {
+	if backup.ID == "" {
+		return fmt.Errorf("backup without an ID")
+	}
+	im.rewrite(&backup)
+
+	var existing types.BackupMeta
+	i, found := im.queued[backup.ID]
+	if found {
+		existing = im.pending[i]
+	} else {
+		existing, found = im.store.Backups.GetBackupByID(backup.ID)
+	}
+	if !im.resolve(KindBackup, found) {
+		return nil
+	}
+	if found && im.opts.Conflict == ConflictMerge {
+		backup = mergeBackup(existing, backup)
+	}
+	if im.opts.DryRun {
+		return nil
+	}
+
+	if i, ok := im.queued[backup.ID]; ok {
+		im.pending[i] = backup
+		return nil
+	}
+	im.queued[backup.ID] = len(im.pending)
+	im.pending = append(im.pending, backup)
+	if len(im.pending) < importBatchSize {
+		return nil
+	}
+	return im.flush()
+}
+
+// flush stores the pending backups
+func (im *importer) flush() error {
+	if len(im.pending) == 0 {
+		return nil
+	}
+	batch := im.pending
+	im.pending = nil
+	im.queued = make(map[string]int)
+	if err := im.store.Backups.PutBackups(batch); err != nil {
+		return fmt.Errorf("failed to import backups %s to %s: %w", batch[0].ID, batch[len(batch)-1].ID, err)
+	}
+	return nil
+}
+
+// rewrite applies the prefix rewrites to the paths and keys of a backup
+func (im *importer) rewrite(backup *types.BackupMeta) {
+	backup.LocalPaths = im.rewriteMap(LocationLocal, backup.LocalPaths)
+	backup.S3Keys = im.rewriteMap(LocationS3, backup.S3Keys)
+	backup.LocalPath = im.rewritePath(LocationLocal, backup.LocalPath)
+	backup.LogFilePath = im.rewritePath(LocationLocal, backup.LogFilePath)
+	backup.S3Key = im.rewritePath(LocationS3, backup.S3Key)
+}
+
+func (im *importer) rewriteMap(location string, paths map[string]string) map[string]string {
+	if len(paths) == 0 {
+		return paths
+	}
+	rewritten := make(map[string]string, len(paths))
+	for org, p := range paths {
+		rewritten[org] = im.rewritePath(location, p)
+	}
+	return rewritten
+}
+
+// rewritePath applies the first matching rewrite for location
+func (im *importer) rewritePath(location, p string) string {
+	for _, rw := range im.opts.Rewrites {
+		if rw.Location != location {
+			continue
+		}
+		if rewritten, ok := rw.apply(p); ok {
+			return rewritten
+		}
+	}
+	return p
+}
+
+// mergeBackup keeps the existing backup and adds the paths, keys, labels,
+// annotations, log file and hold only the archived one has
+func mergeBackup(existing, archived types.BackupMeta) types.BackupMeta {
+	merged := existing
+	merged.LocalPaths = mergeMaps(existing.LocalPaths, archived.LocalPaths)
+	merged.S3Keys = mergeMaps(existing.S3Keys, archived.S3Keys)
+	merged.Labels = mergeMaps(existing.Labels, archived.Labels)
+	merged.Annotations = mergeMaps(existing.Annotations, archived.Annotations)
+	if merged.LocalPath == "" {
+		merged.LocalPath = archived.LocalPath
+	}
+	if merged.S3Key == "" {
+		merged.S3Key = archived.S3Key
+	}
+	if merged.LogFilePath == "" {
+		merged.LogFilePath = archived.LogFilePath
+	}
+	if merged.Hold == nil {
+		merged.Hold = archived.Hold
+	}
+	return merged
+}
+
+// mergeMaps returns the entries of a with those of b whose keys a lacks
+func mergeMaps(a, b map[string]string) map[string]string {
+	if len(b) == 0 {
+		return a
+	}
+	merged := make(map[string]string, len(a)+len(b))
+	for k, v := range b {
+		merged[k] = v
+	}
+	for k, v := range a {
+		merged[k] = v
+	}
+	return merged
+}
+
+// configInDB reports whether servers and schedules can be imported, warning
+// once when they cannot
+func (im *importer) configInDB(kind string) bool {
+	if im.store.DB != nil {
+		return true
+	}
+	im.count(kind, func(c *Count) { c.Skipped++ })
+	if !im.warned {
+		im.warned = true
+		im.result.Warnings = append(im.result.Warnings,
+			"servers and schedules were skipped: they are only imported into a metadata database, add them to the configuration file instead")
+	}
+	return false
+}
+
+// importServer creates or updates a server by name
+func (im *importer) importServer(server dbmeta.ServerConfig) error {
+	if server.Name == "" {
+		return fmt.Errorf("server without a name")
+	}
+	if !im.configInDB(KindServer) {
+		return nil
+	}
+	repo := dbmeta.NewServerRepository(im.store.DB)
+
+	exists, err := repo.ServerExistsByName(server.Name)
+	if err != nil {
+		return err
+	}
+	if !im.resolve(KindServer, exists) {
+		return nil
+	}
+
+	if exists {
+		existing, err := repo.GetServerByName(server.Name)
+		if err != nil {
+			return err
+		}
+		if im.opts.Conflict == ConflictMerge {
+			if server, err = mergeServer(*existing, server); err != nil {
+				return fmt.Errorf("failed to merge server %s: %w", server.Name, err)
+			}
+		}
+		server.ID = existing.ID
+		server.CreatedAt = existing.CreatedAt
+	} else if server.ID != "" {
+		// Another server may already use the archived ID under a different name
+		taken, err := repo.ServerExists(server.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			server.ID = ""
+		}
+	}
+	if im.opts.DryRun {
+		return nil
+	}
+
+	for i := range server.DatabaseFilters {
+		server.DatabaseFilters[i].ID = 0
+		server.DatabaseFilters[i].ServerID = server.ID
+	}
+	for i := range server.MySQLOptions {
+		server.MySQLOptions[i].ID = 0
+		server.MySQLOptions[i].ServerID = server.ID
+	}
+
+	if exists {
+		err = repo.UpdateServer(&server)
+	} else {
+		err = repo.CreateServer(&server)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to import server %s: %w", server.Name, err)
+	}
+	return nil
+}
+
+// mergeServer keeps the existing server and adds the labels, database label
+// rules, database filters and options only the archived one has
+func mergeServer(existing, archived dbmeta.ServerConfig) (dbmeta.ServerConfig, error) {
+	merged := existing
+	merged.Labels = config.FormatLabels(mergeMaps(existing.LabelMap(), archived.LabelMap()))
+
+	// Rules are matched by their database patterns
+	rules := existing.DatabaseLabelRules()
+	patterns := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		patterns[strings.Join(rule.Databases, "\x00")] = true
+	}
+	for _, rule := range archived.DatabaseLabelRules() {
+		if !patterns[strings.Join(rule.Databases, "\x00")] {
+			rules = append(rules, rule)
+		}
+	}
+	if err := merged.SetDatabaseLabelRules(rules); err != nil {
+		return merged, err
+	}
+
+	filters := make(map[string]bool, len(existing.DatabaseFilters))
+	for _, f := range existing.DatabaseFilters {
+		filters[f.FilterType+"/"+f.DatabaseName] = true
+	}
+	merged.DatabaseFilters = append([]dbmeta.ServerDatabaseFilter(nil), existing.DatabaseFilters...)
+	for _, f := range archived.DatabaseFilters {
+		if !filters[f.FilterType+"/"+f.DatabaseName] {
+			merged.DatabaseFilters = append(merged.DatabaseFilters, f)
+		}
+	}
+
+	options := make(map[string]bool, len(existing.MySQLOptions))
+	for _, o := range existing.MySQLOptions {
+		options[o.OptionName] = true
+	}
+	merged.MySQLOptions = append([]dbmeta.ServerMySQLOption(nil), existing.MySQLOptions...)
+	for _, o := range archived.MySQLOptions {
+		if !options[o.OptionName] {
+			merged.MySQLOptions = append(merged.MySQLOptions, o)
+		}
+	}
+	return merged, nil
+}
+
+// importSchedule creates or updates a schedule by name
+func (im *importer) importSchedule(schedule dbmeta.BackupSchedule) error {
+	if schedule.Name == "" {
+		return fmt.Errorf("schedule without a name")
+	}
+	if !im.configInDB(KindSchedule) {
+		return nil
+	}
+	repo := dbmeta.NewScheduleRepository(im.store.DB)
+
+	exists, err := repo.ScheduleExistsByName(schedule.Name)
+	if err != nil {
+		return err
+	}
+	if !im.resolve(KindSchedule, exists) {
+		return nil
+	}
+
+	if exists {
+		existing, err := repo.GetScheduleByName(schedule.Name)
+		if err != nil {
+			return err
+		}
+		if im.opts.Conflict == ConflictMerge {
+			schedule = mergeSchedule(*existing, schedule)
+		}
+		schedule.ID = existing.ID
+		schedule.CreatedAt = existing.CreatedAt
+	} else if schedule.ID != "" {
+		taken, err := repo.ScheduleExists(schedule.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			schedule.ID = ""
+		}
+	}
+	if im.opts.DryRun {
+		return nil
+	}
+
+	for i := range schedule.RetentionPolicies {
+		schedule.RetentionPolicies[i].ID = 0
+		schedule.RetentionPolicies[i].ScheduleID = schedule.ID
+	}
+
+	if exists {
+		err = repo.UpdateSchedule(&schedule)
+	} else {
+		err = repo.CreateSchedule(&schedule)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to import schedule %s: %w", schedule.Name, err)
+	}
+	return nil
+}
+
+// mergeSchedule keeps the existing schedule and adds the retention policies
+// of storage types only the archived one has
+func mergeSchedule(existing, archived dbmeta.BackupSchedule) dbmeta.BackupSchedule {
+	merged := existing
+	storage := make(map[string]bool, len(existing.RetentionPolicies))
+	for _, p := range existing.RetentionPolicies {
+		storage[p.StorageType] = true
+	}
+	merged.RetentionPolicies = append([]dbmeta.ScheduleRetentionPolicy(nil), existing.RetentionPolicies...)
+	for _, p := range archived.RetentionPolicies {
+		if !storage[p.StorageType] {
+			merged.RetentionPolicies = append(merged.RetentionPolicies, p)
+		}
+	}
+	return merged
+}
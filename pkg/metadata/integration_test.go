@@ -16,7 +16,7 @@ import (
 	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
 )
 
-// TestMetadataPersistenceIntegration tests both file and MySQL storage
+// TestMetadataPersistenceIntegration tests file, MySQL and PostgreSQL storage
 func TestMetadataPersistenceIntegration(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -43,6 +43,7 @@ func TestMetadataPersistenceIntegration(t *testing.T) {
 
 		config.CFG.MetadataDB = config.MetadataDBConfig{
 			Enabled:  true,
+			Type:     config.MetadataDBTypeMySQL,
 			Host:     os.Getenv("MYSQL_TEST_HOST"),
 			Port:     3306,
 			Username: os.Getenv("MYSQL_TEST_USER"),
@@ -52,6 +53,25 @@ func TestMetadataPersistenceIntegration(t *testing.T) {
 
 		testMetadataPersistence(t)
 	})
+
+	// Test PostgreSQL storage (requires PostgreSQL to be running)
+	t.Run("PostgreSQLStorage", func(t *testing.T) {
+		if os.Getenv("POSTGRES_TEST_HOST") == "" {
+			t.Skip("Skipping PostgreSQL test - POSTGRES_TEST_HOST not set")
+		}
+
+		config.CFG.MetadataDB = config.MetadataDBConfig{
+			Enabled:  true,
+			Type:     config.MetadataDBTypePostgreSQL,
+			Host:     os.Getenv("POSTGRES_TEST_HOST"),
+			Port:     5432,
+			Username: os.Getenv("POSTGRES_TEST_USER"),
+			Password: os.Getenv("POSTGRES_TEST_PASSWORD"),
+			Database: "gosqlguard_test",
+		}
+
+		testMetadataPersistence(t)
+	})
 }
 
 // testMetadataPersistence runs the same tests for both storage backends
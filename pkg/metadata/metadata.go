@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -31,6 +32,8 @@ const (
 	StatusError = types.StatusError
 	// StatusDeleted indicates a backup that was deleted by retention policy
 	StatusDeleted = types.StatusDeleted
+	// StatusInterrupted indicates a backup cut short by GoSQLGuard shutting down
+	StatusInterrupted = types.StatusInterrupted
 )
 
 // Data holds the backup metadata information
@@ -40,6 +43,10 @@ type Data struct {
 	TotalLocalSize int64              `json:"totalLocalSize"`
 	TotalS3Size    int64              `json:"totalS3Size"`
 	Version        string             `json:"version"`
+	Audit          []types.AuditEntry `json:"audit,omitempty"`
+
+	// StatsHistory holds the daily stats snapshots, oldest first
+	StatsHistory []types.StatsSnapshot `json:"statsHistory,omitempty"`
 }
 
 // Store is the global metadata store instance
@@ -193,12 +200,12 @@ func (s *Store) recalculateTotals() {
 
 	for _, backup := range s.metadata.Backups {
 		// Only count active backups (not deleted or errored)
-		if backup.Status == types.StatusSuccess {
+		if backup.Status == types.StatusSuccess && backup.LocalDeletedAt.IsZero() {
 			localSize += backup.Size
 		}
 
 		// Only count successful S3 uploads
-		if backup.S3UploadStatus == types.StatusSuccess {
+		if backup.S3UploadStatus == types.StatusSuccess && backup.S3DeletedAt.IsZero() {
 			s3Size += backup.Size
 		}
 	}
@@ -222,6 +229,9 @@ func (s *Store) CreateBackupMeta(serverName, serverType, database, backupType st
 	if typeConfig, exists := config.CFG.BackupTypes[backupType]; exists {
 		if typeConfig.Local.Enabled && typeConfig.Local.Retention.Forever {
 			retentionText = "Keep forever"
+		} else if typeConfig.Local.Enabled && typeConfig.Local.Retention.HasCountRules() {
+			// Count-based rules have no fixed expiry; it depends on later backups
+			retentionText = typeConfig.Local.Retention.Description()
 		} else if typeConfig.Local.Enabled {
 			duration, err := time.ParseDuration(typeConfig.Local.Retention.Duration)
 			if err == nil {
@@ -335,6 +345,73 @@ func (s *Store) UpdateS3UploadStatus(id string, status types.BackupStatus, s3Key
 	return fmt.Errorf("backup with ID %s not found", id)
 }
 
+// UpdateRetentionStatus records the outcome of retention deleting a backup from a storage location
+func (s *Store) UpdateRetentionStatus(id, location string, remaining map[string]string, errorMsg string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range s.metadata.Backups {
+		backup := &s.metadata.Backups[i]
+		if backup.ID != id {
+			continue
+		}
+
+		if err := applyRetentionStatus(backup, location, remaining, errorMsg, time.Now()); err != nil {
+			return err
+		}
+
+		s.recalculateTotals()
+		return s.save()
+	}
+
+	return fmt.Errorf("backup with ID %s not found", id)
+}
+
+// applyRetentionStatus updates a backup's locations after a retention deletion
+func applyRetentionStatus(backup *types.BackupMeta, location string, remaining map[string]string, errorMsg string, now time.Time) error {
+	if remaining == nil {
+		remaining = make(map[string]string)
+	}
+
+	switch location {
+	case "local":
+		backup.LocalPaths = remaining
+		backup.LocalPath = primaryLocation(remaining)
+		if len(remaining) == 0 {
+			backup.LocalDeletedAt = now
+		}
+	case "s3":
+		backup.S3Keys = remaining
+		backup.S3Key = primaryLocation(remaining)
+		if len(remaining) == 0 {
+			backup.S3DeletedAt = now
+		}
+	default:
+		return fmt.Errorf("unknown storage location %q", location)
+	}
+
+	backup.RetentionError = errorMsg
+
+	// The backup is gone once no location holds a copy
+	if len(backup.LocalPaths) == 0 && backup.LocalPath == "" && len(backup.S3Keys) == 0 && backup.S3Key == "" {
+		backup.Status = types.StatusDeleted
+	}
+
+	return nil
+}
+
+// primaryLocation returns the by-server entry of a path map, or any entry if
+// there is none, for the legacy single-path fields
+func primaryLocation(paths map[string]string) string {
+	if byServer, ok := paths["by-server"]; ok {
+		return byServer
+	}
+	for _, path := range paths {
+		return path
+	}
+	return ""
+}
+
 // GetBackups returns all backups
 func (s *Store) GetBackups() []types.BackupMeta {
 	s.mutex.RLock()
@@ -381,6 +458,37 @@ func (s *Store) GetBackupsFiltered(serverName, database, backupType string, acti
 	return result
 }
 
+// QueryBackups returns one page of the backups matching a query. Backups up
+// to the cursor are skipped before sorting, so later pages sort fewer.
+func (s *Store) QueryBackups(q types.BackupQuery) (types.BackupPage, error) {
+	if err := q.Normalize(); err != nil {
+		return types.BackupPage{}, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	after, paging := q.After()
+	var total int64
+	var page []types.BackupMeta
+	for _, backup := range s.metadata.Backups {
+		if !q.Matches(backup) {
+			continue
+		}
+		total++
+		if paging && !q.Less(after, backup) {
+			continue
+		}
+		page = append(page, backup)
+	}
+
+	sort.Slice(page, func(i, j int) bool { return q.Less(page[i], page[j]) })
+	if len(page) > q.Limit+1 {
+		page = page[:q.Limit+1]
+	}
+	return types.NewBackupPage(q, page, total), nil
+}
+
 // GetBackupByID returns a specific backup by ID
 func (s *Store) GetBackupByID(id string) (types.BackupMeta, bool) {
 	s.mutex.RLock()
@@ -486,8 +594,164 @@ func (s *Store) UpdateLogFilePath(id string, logFilePath string) error {
 	return fmt.Errorf("backup with ID %s not found", id)
 }
 
+// UpdateFencingToken records the leader lease token of the replica running a backup
+func (s *Store) UpdateFencingToken(id string, token int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, backup := range s.metadata.Backups {
+		if backup.ID == id {
+			s.metadata.Backups[i].FencingToken = token
+			return s.save()
+		}
+	}
+
+	return fmt.Errorf("backup with ID %s not found", id)
+}
+
+// UpdateLabels replaces the labels of a backup
+func (s *Store) UpdateLabels(id string, labels map[string]string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, backup := range s.metadata.Backups {
+		if backup.ID == id {
+			s.metadata.Backups[i].Labels = labels
+			return s.save()
+		}
+	}
+
+	return fmt.Errorf("backup with ID %s not found", id)
+}
+
+// MergeAnnotations applies changes to the annotations of a backup, an empty
+// value removing that annotation, and returns the resulting annotations
+func (s *Store) MergeAnnotations(id string, changes map[string]string) (map[string]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, backup := range s.metadata.Backups {
+		if backup.ID == id {
+			merged := types.ApplyAnnotationChanges(backup.Annotations, changes)
+			s.metadata.Backups[i].Annotations = merged
+			return merged, s.save()
+		}
+	}
+
+	return nil, fmt.Errorf("backup with ID %s not found", id)
+}
+
+// PutBackup stores a backup as given, replacing any backup with the same ID
+func (s *Store) PutBackup(backup types.BackupMeta) error {
+	return s.PutBackups([]types.BackupMeta{backup})
+}
+
+// PutBackups stores backups as given, replacing any backups with the same
+// IDs, and saves the file once
+func (s *Store) PutBackups(backups []types.BackupMeta) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	index := make(map[string]int, len(s.metadata.Backups))
+	for i, backup := range s.metadata.Backups {
+		index[backup.ID] = i
+	}
+	for _, backup := range backups {
+		if i, ok := index[backup.ID]; ok {
+			s.metadata.Backups[i] = backup
+			continue
+		}
+		index[backup.ID] = len(s.metadata.Backups)
+		s.metadata.Backups = append(s.metadata.Backups, backup)
+	}
+	return s.save()
+}
+
+// UpdateHold places a hold on a backup, or releases it when hold is nil
+func (s *Store) UpdateHold(id string, hold *types.BackupHold) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, backup := range s.metadata.Backups {
+		if backup.ID == id {
+			s.metadata.Backups[i].Hold = hold
+			return s.save()
+		}
+	}
+
+	return fmt.Errorf("backup with ID %s not found", id)
+}
+
+// AddAuditEntry appends an entry to the audit log
+func (s *Store) AddAuditEntry(entry types.AuditEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.metadata.Audit = append(s.metadata.Audit, entry)
+	return s.save()
+}
+
+// GetAuditEntries returns up to limit audit entries, newest first, for one
+// backup or for all of them when backupID is empty
+func (s *Store) GetAuditEntries(backupID string, limit int) []types.AuditEntry {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var entries []types.AuditEntry
+	for i := len(s.metadata.Audit) - 1; i >= 0 && (limit <= 0 || len(entries) < limit); i-- {
+		if backupID == "" || s.metadata.Audit[i].BackupID == backupID {
+			entries = append(entries, s.metadata.Audit[i])
+		}
+	}
+	return entries
+}
+
+// SaveStatsSnapshots stores daily stats snapshots, replacing those of the
+// same day, server and database
+func (s *Store) SaveStatsSnapshots(snapshots []types.StatsSnapshot) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	type key struct {
+		day              int64
+		server, database string
+	}
+	replaced := make(map[key]bool, len(snapshots))
+	for _, snapshot := range snapshots {
+		replaced[key{snapshot.Day.Unix(), snapshot.ServerName, snapshot.Database}] = true
+	}
+
+	history := make([]types.StatsSnapshot, 0, len(s.metadata.StatsHistory)+len(snapshots))
+	for _, snapshot := range s.metadata.StatsHistory {
+		if !replaced[key{snapshot.Day.Unix(), snapshot.ServerName, snapshot.Database}] {
+			history = append(history, snapshot)
+		}
+	}
+	history = append(history, snapshots...)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Day.Before(history[j].Day)
+	})
+
+	s.metadata.StatsHistory = history
+	return s.save()
+}
+
+// GetStatsHistory returns the stats snapshots matching a query, oldest first
+func (s *Store) GetStatsHistory(q types.StatsHistoryQuery) ([]types.StatsSnapshot, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var snapshots []types.StatsSnapshot
+	for _, snapshot := range s.metadata.StatsHistory {
+		if q.Matches(snapshot) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots, nil
+}
+
 // PurgeDeletedBackups removes backup entries that have been marked as deleted
-// and are older than the specified duration
+// and are older than the specified duration, except held ones
 func (s *Store) PurgeDeletedBackups(olderThan time.Duration) int {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -504,8 +768,8 @@ func (s *Store) PurgeDeletedBackups(olderThan time.Duration) int {
 			continue
 		}
 
-		// Keep deleted backups that are newer than threshold
-		if backup.CompletedAt.After(threshold) {
+		// Keep deleted backups that are newer than threshold or held
+		if backup.CompletedAt.After(threshold) || backup.IsHeld(now) {
 			newBackups = append(newBackups, backup)
 			continue
 		}
@@ -522,3 +786,53 @@ func (s *Store) PurgeDeletedBackups(olderThan time.Duration) int {
 
 	return removedCount
 }
+
+// OpenFileStore opens the file-based metadata store at path without making it
+// the default store, for tools that rebuild a catalog such as
+// metadata-recovery. A missing file gives an empty store, written on Save.
+func OpenFileStore(path string) (*Store, error) {
+	store := &Store{
+		metadata: Data{
+			Backups:     make([]types.BackupMeta, 0),
+			LastUpdated: time.Now(),
+			Version:     "1.0",
+		},
+		filepath: path,
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return store, nil
+	}
+	if err := store.Load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// ImportBackups adds complete backup entries, such as those rebuilt from
+// storage. With replace the existing entries are dropped first; otherwise
+// entries whose ID is already present are skipped. It returns the number of
+// entries added. Call Save to persist them.
+func (s *Store) ImportBackups(backups []types.BackupMeta, replace bool) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if replace {
+		s.metadata.Backups = make([]types.BackupMeta, 0, len(backups))
+	}
+
+	existing := make(map[string]bool, len(s.metadata.Backups))
+	for _, backup := range s.metadata.Backups {
+		existing[backup.ID] = true
+	}
+
+	added := 0
+	for _, backup := range backups {
+		if existing[backup.ID] {
+			continue
+		}
+		existing[backup.ID] = true
+		s.metadata.Backups = append(s.metadata.Backups, backup)
+		added++
+	}
+	return added
+}
@@ -364,3 +364,51 @@ func TestFileStoreMetadataIntegrity(t *testing.T) {
 	assert.Equal(t, store.metadata.TotalLocalSize, store2.metadata.TotalLocalSize)
 	assert.Equal(t, store.metadata.TotalS3Size, store2.metadata.TotalS3Size)
 }
+
+// TestFileStoreRetentionStatus tests per-location retention status tracking
+func TestFileStoreRetentionStatus(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "metadata_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	store := &Store{
+		filepath: filepath.Join(tmpDir, "retention.json"),
+		metadata: Data{
+			Backups: make([]types.BackupMeta, 0),
+			Version: "1.0",
+		},
+	}
+
+	backup := store.CreateBackupMeta("server", "mysql", "db", "daily")
+	require.NoError(t, store.UpdateBackupStatus(backup.ID, types.StatusSuccess,
+		map[string]string{"by-server": "/a.sql.gz", "by-type": "/b.sql.gz"}, 1024, ""))
+	require.NoError(t, store.UpdateS3UploadStatus(backup.ID, types.StatusSuccess,
+		map[string]string{"by-server": "a.sql.gz"}, ""))
+
+	// A partial local failure keeps the remaining path and the backup
+	require.NoError(t, store.UpdateRetentionStatus(backup.ID, "local", map[string]string{"by-type": "/b.sql.gz"}, "permission denied"))
+	got, _ := store.GetBackupByID(backup.ID)
+	assert.Equal(t, types.StatusSuccess, got.Status)
+	assert.Equal(t, "/b.sql.gz", got.LocalPath)
+	assert.True(t, got.LocalDeletedAt.IsZero())
+	assert.Equal(t, "permission denied", got.RetentionError)
+
+	// Removing every local copy marks the location deleted but S3 still holds one
+	require.NoError(t, store.UpdateRetentionStatus(backup.ID, "local", nil, ""))
+	got, _ = store.GetBackupByID(backup.ID)
+	assert.Equal(t, types.StatusSuccess, got.Status)
+	assert.Empty(t, got.LocalPath)
+	assert.False(t, got.LocalDeletedAt.IsZero())
+	assert.Equal(t, int64(0), store.metadata.TotalLocalSize)
+	assert.Equal(t, int64(1024), store.metadata.TotalS3Size)
+
+	// The backup is deleted once no location holds a copy
+	require.NoError(t, store.UpdateRetentionStatus(backup.ID, "s3", nil, ""))
+	got, _ = store.GetBackupByID(backup.ID)
+	assert.Equal(t, types.StatusDeleted, got.Status)
+	assert.False(t, got.S3DeletedAt.IsZero())
+	assert.Equal(t, int64(0), store.metadata.TotalS3Size)
+
+	assert.Error(t, store.UpdateRetentionStatus(backup.ID, "tape", nil, ""))
+	assert.Error(t, store.UpdateRetentionStatus("missing", "local", nil, ""))
+}
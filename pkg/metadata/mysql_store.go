@@ -1,4 +1,4 @@
-// Package metadata provides support for MySQL-backed metadata storage
+// Package metadata provides support for database-backed metadata storage
 package metadata
 
 import (
@@ -12,7 +12,6 @@ import (
 	"github.com/supporttools/GoSQLGuard/pkg/config"
 	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -20,74 +19,16 @@ import (
 // DB is the global database instance
 var DB *gorm.DB
 
-// DatabaseBackup represents a database backup record in MySQL
-type DatabaseBackup struct {
-	ID               string    `gorm:"primaryKey;type:varchar(255)"`
-	ServerName       string    `gorm:"type:varchar(255);not null;index"`
-	ServerType       string    `gorm:"type:varchar(50);not null"`
-	DatabaseName     string    `gorm:"column:database_name;type:varchar(255);not null;index"`
-	BackupType       string    `gorm:"type:varchar(50);not null;index"`
-	CreatedAt        time.Time `gorm:"not null"`
-	CompletedAt      *time.Time
-	Size             int64
-	Status           string `gorm:"type:varchar(50);not null;index"`
-	ErrorMessage     string `gorm:"type:text"`
-	RetentionPolicy  string `gorm:"type:varchar(255)"`
-	ExpiresAt        *time.Time
-	LogFilePath      string `gorm:"type:varchar(1024)"`
-	S3UploadStatus   string `gorm:"type:varchar(50)"`
-	S3UploadError    string `gorm:"type:text"`
-	S3UploadComplete *time.Time
-
-	// Relationships
-	LocalPaths []DatabaseLocalPath `gorm:"foreignKey:BackupID;constraint:OnDelete:CASCADE"`
-	S3Keys     []DatabaseS3Key     `gorm:"foreignKey:BackupID;constraint:OnDelete:CASCADE"`
-}
-
-// TableName specifies the table name for the DatabaseBackup model
-func (DatabaseBackup) TableName() string {
-	return "backups"
-}
-
-// DatabaseLocalPath represents a local file path for a backup
-type DatabaseLocalPath struct {
-	BackupID     string `gorm:"primaryKey;type:varchar(255)"`
-	Organization string `gorm:"primaryKey;type:varchar(50)"`
-	Path         string `gorm:"type:varchar(1024);not null"`
-}
-
-// TableName specifies the table name for the DatabaseLocalPath model
-func (DatabaseLocalPath) TableName() string {
-	return "local_paths"
-}
-
-// DatabaseS3Key represents an S3 object key for a backup
-type DatabaseS3Key struct {
-	BackupID     string `gorm:"primaryKey;type:varchar(255)"`
-	Organization string `gorm:"primaryKey;type:varchar(50)"`
-	Key          string `gorm:"column:s3_key;type:varchar(1024);not null"`
-}
-
-// TableName specifies the table name for the DatabaseS3Key model
-func (DatabaseS3Key) TableName() string {
-	return "s3_keys"
-}
-
-// DBStats represents global metadata statistics stored in database
-type DBStats struct {
-	ID             uint      `gorm:"primaryKey;autoIncrement:false;default:1"`
-	TotalLocalSize int64     `gorm:"not null;default:0"`
-	TotalS3Size    int64     `gorm:"not null;default:0"`
-	LastUpdated    time.Time `gorm:"not null"`
-	Version        string    `gorm:"type:varchar(50);not null"`
-}
-
-// TableName specifies the table name for the DBStats model
-func (DBStats) TableName() string {
-	return "metadata_stats"
-}
+// The store reads and writes the backup tables through the models of the
+// database/metadata package, which also owns their migrations
+type (
+	DatabaseBackup    = dbmeta.Backup
+	DatabaseLocalPath = dbmeta.LocalPath
+	DatabaseS3Key     = dbmeta.S3Key
+	DBStats           = dbmeta.Stats
+)
 
-// DBStore implements metadata storage using MySQL
+// DBStore implements metadata storage using a MySQL or PostgreSQL database
 type DBStore struct {
 	db          *gorm.DB
 	mutex       sync.RWMutex
@@ -117,11 +58,13 @@ func InitializeMetadataDatabase() error {
 	// Run auto-migrations if enabled
 	if config.CFG.MetadataDB.AutoMigrate {
 		log.Println("Running database migrations for metadata tables")
-		if err := runMigrations(db); err != nil {
+		if err := dbmeta.RunMigrations(db); err != nil {
 			log.Printf("Failed to run migrations: %v", err)
 			log.Println("Falling back to file-based metadata")
 			return Initialize()
 		}
+	} else if err := dbmeta.CheckMigrations(db); err != nil {
+		log.Printf("Warning: Failed to check metadata schema migrations: %v", err)
 	}
 
 	// Create the database store
@@ -149,23 +92,18 @@ func InitializeMetadataDatabase() error {
 	// Set as the default store
 	DefaultStore = dbStore
 
-	// Add performance indexes
-	if err := AddPerformanceIndexes(db); err != nil {
-		log.Printf("Warning: Failed to add performance indexes: %v", err)
-		// Continue anyway - indexes are for optimization only
-	}
-
-	log.Println("Using MySQL-backed metadata store")
+	log.Printf("Using %s-backed metadata store", config.CFG.MetadataDB.Type)
 	return nil
 }
 
-// connect establishes a connection to the MySQL database
+// connect establishes a connection to the metadata database
 func connect() (*gorm.DB, error) {
 	cfg := config.CFG.MetadataDB
 
-	// Build DSN
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database)
+	dialector, err := dbmeta.Dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// Set up logger config based on debug mode
 	logLevel := logger.Silent
@@ -174,7 +112,7 @@ func connect() (*gorm.DB, error) {
 	}
 
 	// Connect to database
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logLevel),
 	})
 	if err != nil {
@@ -201,47 +139,10 @@ func connect() (*gorm.DB, error) {
 		sqlDB.SetConnMaxLifetime(duration)
 	}
 
-	log.Printf("Connected to metadata database at %s:%d", cfg.Host, cfg.Port)
+	log.Printf("Connected to %s metadata database at %s", cfg.Type, dbmeta.Location(cfg))
 	return db, nil
 }
 
-// runMigrations runs all necessary database migrations
-func runMigrations(db *gorm.DB) error {
-	// Create the tables if they don't exist
-	err := db.AutoMigrate(
-		&DatabaseBackup{},
-		&DatabaseLocalPath{},
-		&DatabaseS3Key{},
-		&DBStats{},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to migrate tables: %w", err)
-	}
-
-	// Also run migrations for server and schedule management tables
-	// Import them from the database/metadata package
-	if err := runServerMigrations(db); err != nil {
-		return fmt.Errorf("failed to migrate server tables: %w", err)
-	}
-
-	// Initialize stats record if it doesn't exist
-	var count int64
-	db.Model(&DBStats{}).Count(&count)
-	if count == 0 {
-		log.Println("Initializing metadata stats record")
-		stats := DBStats{
-			ID:          1,
-			Version:     "1.0",
-			LastUpdated: time.Now(),
-		}
-		if err := db.Create(&stats).Error; err != nil {
-			return fmt.Errorf("failed to create initial stats record: %w", err)
-		}
-	}
-
-	return nil
-}
-
 // migrateFromFile attempts to migrate data from an existing metadata file
 func migrateFromFile(dbStore *DBStore) error {
 	// Check if file exists
@@ -305,7 +206,17 @@ func migrateFromFile(dbStore *DBStore) error {
 			LogFilePath:     fb.LogFilePath,
 			S3UploadStatus:  string(fb.S3UploadStatus),
 			S3UploadError:   fb.S3UploadError,
+			RetentionError:  fb.RetentionError,
+			FencingToken:    fb.FencingToken,
+		}
+
+		annotations, err := dbmeta.EncodeAnnotations(fb.Annotations)
+		if err != nil {
+			tx.Rollback()
+			return err
 		}
+		backup.Annotations = annotations
+		backup.SetHold(fb.Hold)
 
 		// Set times that might be zero
 		if !fb.CompletedAt.IsZero() {
@@ -355,13 +266,19 @@ func migrateFromFile(dbStore *DBStore) error {
 			}
 		}
 
+		// Add labels
+		if err := dbmeta.ReplaceBackupLabels(tx, fb.ID, fb.Labels); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to create labels: %w", err)
+		}
+
 		migrated++
 	}
 
 	// Calculate statistics
 	var totalLocalSize, totalS3Size int64
 	err := tx.Model(&DatabaseBackup{}).
-		Where("status = ?", string(StatusSuccess)).
+		Where("status = ? AND local_deleted_at IS NULL", string(StatusSuccess)).
 		Select("COALESCE(SUM(size), 0)").
 		Scan(&totalLocalSize).Error
 	if err != nil {
@@ -370,7 +287,7 @@ func migrateFromFile(dbStore *DBStore) error {
 	}
 
 	err = tx.Model(&DatabaseBackup{}).
-		Where("s3_upload_status = ?", string(StatusSuccess)).
+		Where("s3_upload_status = ? AND s3_deleted_at IS NULL", string(StatusSuccess)).
 		Select("COALESCE(SUM(size), 0)").
 		Scan(&totalS3Size).Error
 	if err != nil {
@@ -413,6 +330,9 @@ func (s *DBStore) CreateBackupMeta(serverName, serverType, database, backupType
 	if typeConfig, exists := config.CFG.BackupTypes[backupType]; exists {
 		if typeConfig.Local.Enabled && typeConfig.Local.Retention.Forever {
 			retentionText = "Keep forever"
+		} else if typeConfig.Local.Enabled && typeConfig.Local.Retention.HasCountRules() {
+			// Count-based rules have no fixed expiry; it depends on later backups
+			retentionText = typeConfig.Local.Retention.Description()
 		} else if typeConfig.Local.Enabled {
 			duration, err := time.ParseDuration(typeConfig.Local.Retention.Duration)
 			if err == nil {
@@ -575,6 +495,77 @@ func (s *DBStore) UpdateS3UploadStatus(id string, status types.BackupStatus, s3K
 	return tx.Commit().Error
 }
 
+// UpdateRetentionStatus records the outcome of retention deleting a backup from a storage location
+func (s *DBStore) UpdateRetentionStatus(id, location string, remaining map[string]string, errorMsg string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var model interface{}
+	var deletedColumn string
+	switch location {
+	case "local":
+		model, deletedColumn = &DatabaseLocalPath{}, "local_deleted_at"
+	case "s3":
+		model, deletedColumn = &DatabaseS3Key{}, "s3_deleted_at"
+	default:
+		return fmt.Errorf("unknown storage location %q", location)
+	}
+
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("failed to start transaction: %w", tx.Error)
+	}
+
+	// Replace the location's entries with those that could not be deleted
+	if err := tx.Where("backup_id = ?", id).Delete(model).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for org, path := range remaining {
+		var row interface{}
+		if location == "local" {
+			row = &DatabaseLocalPath{BackupID: id, Organization: org, Path: path}
+		} else {
+			row = &DatabaseS3Key{BackupID: id, Organization: org, Key: path}
+		}
+		if err := tx.Create(row).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	updates := map[string]interface{}{"retention_error": errorMsg}
+	if len(remaining) == 0 {
+		updates[deletedColumn] = time.Now()
+	}
+
+	// The backup is gone once no location holds a copy
+	var localCount, s3Count int64
+	if err := tx.Model(&DatabaseLocalPath{}).Where("backup_id = ?", id).Count(&localCount).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&DatabaseS3Key{}).Where("backup_id = ?", id).Count(&s3Count).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if localCount == 0 && s3Count == 0 {
+		updates["status"] = string(StatusDeleted)
+	}
+
+	if err := tx.Model(&DatabaseBackup{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := s.updateStats(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
 // updateStats recalculates and updates the metadata stats
 func (s *DBStore) updateStats(tx *gorm.DB) error {
 	// Calculate total local size
@@ -613,13 +604,118 @@ func (s *DBStore) UpdateLogFilePath(id string, logFilePath string) error {
 	return s.db.Model(&DatabaseBackup{}).Where("id = ?", id).Update("log_file_path", logFilePath).Error
 }
 
+// UpdateFencingToken records the leader lease token of the replica running a backup
+func (s *DBStore) UpdateFencingToken(id string, token int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.db.Model(&DatabaseBackup{}).Where("id = ?", id).Update("fencing_token", token).Error
+}
+
+// UpdateLabels replaces the labels of a backup
+func (s *DBStore) UpdateLabels(id string, labels map[string]string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return dbmeta.ReplaceBackupLabels(tx, id, labels)
+	})
+}
+
+// MergeAnnotations applies changes to the annotations of a backup in one
+// transaction, an empty value removing that annotation, and returns the
+// resulting annotations
+func (s *DBStore) MergeAnnotations(id string, changes map[string]string) (map[string]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var merged map[string]string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		merged, err = dbmeta.MergeAnnotations(tx, id, changes)
+		return err
+	})
+	return merged, err
+}
+
+// PutBackup stores a backup as given, replacing any backup with the same ID
+func (s *DBStore) PutBackup(backup types.BackupMeta) error {
+	return s.PutBackups([]types.BackupMeta{backup})
+}
+
+// PutBackups stores backups as given in one transaction, replacing any
+// backups with the same IDs, and updates the stats once
+func (s *DBStore) PutBackups(backups []types.BackupMeta) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, backup := range backups {
+			if err := dbmeta.SaveBackup(tx, backup); err != nil {
+				return err
+			}
+		}
+		return s.updateStats(tx)
+	})
+}
+
+// UpdateHold places a hold on a backup, or releases it when hold is nil
+func (s *DBStore) UpdateHold(id string, hold *types.BackupHold) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.db.Model(&DatabaseBackup{}).Where("id = ?", id).Updates(dbmeta.HoldColumns(hold)).Error
+}
+
+// AddAuditEntry appends an entry to the audit log
+func (s *DBStore) AddAuditEntry(entry types.AuditEntry) error {
+	record := dbmeta.NewAuditEntry(entry)
+	return s.db.Create(&record).Error
+}
+
+// GetAuditEntries returns up to limit audit entries, newest first, for one
+// backup or for all of them when backupID is empty
+func (s *DBStore) GetAuditEntries(backupID string, limit int) []types.AuditEntry {
+	query := s.db.Order("time DESC").Order("id DESC")
+	if backupID != "" {
+		query = query.Where("backup_id = ?", backupID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var records []dbmeta.AuditEntry
+	if err := query.Find(&records).Error; err != nil {
+		log.Printf("Error retrieving audit entries from database: %v", err)
+		return nil
+	}
+	entries := make([]types.AuditEntry, 0, len(records))
+	for _, record := range records {
+		entries = append(entries, record.Entry())
+	}
+	return entries
+}
+
+// SaveStatsSnapshots stores daily stats snapshots, replacing those of the
+// same day, server and database
+func (s *DBStore) SaveStatsSnapshots(snapshots []types.StatsSnapshot) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return dbmeta.SaveStatsSnapshots(tx, snapshots)
+	})
+}
+
+// GetStatsHistory returns the stats snapshots matching a query, oldest first
+func (s *DBStore) GetStatsHistory(q types.StatsHistoryQuery) ([]types.StatsSnapshot, error) {
+	return dbmeta.QueryStatsHistory(s.db, q)
+}
+
 // GetBackups returns all backups
 func (s *DBStore) GetBackups() []types.BackupMeta {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
 	var dbBackups []DatabaseBackup
-	if err := s.db.Preload("LocalPaths").Preload("S3Keys").Find(&dbBackups).Error; err != nil {
+	if err := s.db.Preload("LocalPaths").Preload("S3Keys").Preload("Labels").Find(&dbBackups).Error; err != nil {
 		log.Printf("Error retrieving backups from database: %v", err)
 		return []types.BackupMeta{}
 	}
@@ -633,7 +729,7 @@ func (s *DBStore) GetBackupsFiltered(serverName, database, backupType string, ac
 	defer s.mutex.RUnlock()
 
 	// Build the query
-	query := s.db.Model(&DatabaseBackup{}).Preload("LocalPaths").Preload("S3Keys")
+	query := s.db.Model(&DatabaseBackup{}).Preload("LocalPaths").Preload("S3Keys").Preload("Labels")
 
 	if serverName != "" {
 		query = query.Where("server_name = ?", serverName)
@@ -661,13 +757,29 @@ func (s *DBStore) GetBackupsFiltered(serverName, database, backupType string, ac
 	return convertToBackupMetas(dbBackups)
 }
 
+// QueryBackups returns one page of the backups matching a query
+func (s *DBStore) QueryBackups(q types.BackupQuery) (types.BackupPage, error) {
+	if err := q.Normalize(); err != nil {
+		return types.BackupPage{}, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	dbBackups, total, err := dbmeta.QueryBackups(s.db, q)
+	if err != nil {
+		return types.BackupPage{}, err
+	}
+	return types.NewBackupPage(q, convertToBackupMetas(dbBackups), total), nil
+}
+
 // GetBackupByID returns a specific backup by ID
 func (s *DBStore) GetBackupByID(id string) (types.BackupMeta, bool) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
 	var dbBackup DatabaseBackup
-	if err := s.db.Preload("LocalPaths").Preload("S3Keys").Where("id = ?", id).First(&dbBackup).Error; err != nil {
+	if err := s.db.Preload("LocalPaths").Preload("S3Keys").Preload("Labels").Where("id = ?", id).First(&dbBackup).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return types.BackupMeta{}, false
 		}
@@ -826,12 +938,13 @@ func createEmptyStats() map[string]interface{} {
 }
 
 // PurgeDeletedBackups removes backup records that have been marked as deleted
-// and are older than the specified duration
+// and are older than the specified duration, except held ones
 func (s *DBStore) PurgeDeletedBackups(olderThan time.Duration) int {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	threshold := time.Now().Add(-olderThan)
+	now := time.Now()
+	threshold := now.Add(-olderThan)
 
 	// Start a transaction
 	tx := s.db.Begin()
@@ -844,6 +957,7 @@ func (s *DBStore) PurgeDeletedBackups(olderThan time.Duration) int {
 	var count int64
 	if err := tx.Model(&DatabaseBackup{}).
 		Where("status = ? AND completed_at < ?", string(StatusDeleted), threshold).
+		Where(dbmeta.NotHeldCondition, now).
 		Count(&count).Error; err != nil {
 		tx.Rollback()
 		log.Printf("Failed to count records to delete: %v", err)
@@ -858,6 +972,7 @@ func (s *DBStore) PurgeDeletedBackups(olderThan time.Duration) int {
 	// Delete the records
 	if err := tx.Unscoped().
 		Where("status = ? AND completed_at < ?", string(StatusDeleted), threshold).
+		Where(dbmeta.NotHeldCondition, now).
 		Delete(&DatabaseBackup{}).Error; err != nil {
 		tx.Rollback()
 		log.Printf("Failed to delete records: %v", err)
@@ -912,6 +1027,11 @@ func convertToBackupMetas(dbBackups []DatabaseBackup) []types.BackupMeta {
 			LogFilePath:     db.LogFilePath,
 			S3UploadStatus:  types.BackupStatus(db.S3UploadStatus),
 			S3UploadError:   db.S3UploadError,
+			RetentionError:  db.RetentionError,
+			FencingToken:    db.FencingToken,
+			Labels:          db.LabelMap(),
+			Annotations:     db.AnnotationMap(),
+			Hold:            db.Hold(),
 			LocalPaths:      make(map[string]string),
 			S3Keys:          make(map[string]string),
 		}
@@ -929,6 +1049,14 @@ func convertToBackupMetas(dbBackups []DatabaseBackup) []types.BackupMeta {
 			backup.S3UploadComplete = *db.S3UploadComplete
 		}
 
+		if db.LocalDeletedAt != nil {
+			backup.LocalDeletedAt = *db.LocalDeletedAt
+		}
+
+		if db.S3DeletedAt != nil {
+			backup.S3DeletedAt = *db.S3DeletedAt
+		}
+
 		// Add local paths
 		for _, path := range db.LocalPaths {
 			backup.LocalPaths[path.Organization] = path.Path
@@ -969,9 +1097,3 @@ func convertToBackupMetas(dbBackups []DatabaseBackup) []types.BackupMeta {
 
 	return result
 }
-
-// runServerMigrations runs the database migrations for server and schedule management
-func runServerMigrations(db *gorm.DB) error {
-	// Run the migrations from the database/metadata package
-	return dbmeta.RunMigrations(db)
-}
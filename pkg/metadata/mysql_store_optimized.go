@@ -1,4 +1,4 @@
-// Package metadata provides optimized MySQL-backed metadata storage
+// Package metadata provides optimized database-backed metadata storage
 package metadata
 
 import (
@@ -7,6 +7,7 @@ import (
 	"log"
 	"time"
 
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
 	"gorm.io/gorm"
 )
@@ -53,75 +54,8 @@ type QueryOptions struct {
 	SelectFields []string
 }
 
-// AddPerformanceIndexes adds optimized indexes for common query patterns
-func AddPerformanceIndexes(db *gorm.DB) error {
-	log.Println("Adding performance indexes to metadata tables...")
-
-	// Composite indexes for common query patterns
-	indexes := []struct {
-		Table   string
-		Name    string
-		Columns []string
-	}{
-		// For filtering by server, database, type, and status
-		{
-			Table:   "backups",
-			Name:    "idx_backups_filter",
-			Columns: []string{"server_name", "database_name", "backup_type", "status"},
-		},
-		// For sorting by creation date
-		{
-			Table:   "idx_backups_created_desc",
-			Name:    "backups",
-			Columns: []string{"created_at DESC"},
-		},
-		// For retention queries
-		{
-			Table:   "backups",
-			Name:    "idx_backups_expires",
-			Columns: []string{"expires_at", "status"},
-		},
-		// For S3 upload status queries
-		{
-			Table:   "backups",
-			Name:    "idx_backups_s3_status",
-			Columns: []string{"s3_upload_status", "created_at"},
-		},
-		// For foreign key lookups
-		{
-			Table:   "local_paths",
-			Name:    "idx_local_paths_backup",
-			Columns: []string{"backup_id"},
-		},
-		{
-			Table:   "s3_keys",
-			Name:    "idx_s3_keys_backup",
-			Columns: []string{"backup_id"},
-		},
-	}
-
-	// Create indexes
-	for _, idx := range indexes {
-		sql := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)",
-			idx.Name, idx.Table, joinColumns(idx.Columns))
-
-		if err := db.Exec(sql).Error; err != nil {
-			log.Printf("Warning: Failed to create index %s: %v", idx.Name, err)
-			// Continue with other indexes even if one fails
-		}
-	}
-
-	// Analyze tables to update statistics
-	tables := []string{"backups", "local_paths", "s3_keys", "metadata_stats"}
-	for _, table := range tables {
-		if err := db.Exec(fmt.Sprintf("ANALYZE TABLE %s", table)).Error; err != nil {
-			log.Printf("Warning: Failed to analyze table %s: %v", table, err)
-		}
-	}
-
-	log.Println("Performance indexes added successfully")
-	return nil
-}
+// maintainedTables are the metadata tables optimized for query performance
+var maintainedTables = []string{"backups", "local_paths", "s3_keys", "metadata_stats"}
 
 // GetBackupsPaginated returns paginated backup results with optimized queries
 func (s *DBStore) GetBackupsPaginated(opts QueryOptions) (*PaginatedResult, error) {
@@ -172,7 +106,7 @@ func (s *DBStore) GetBackupsPaginated(opts QueryOptions) (*PaginatedResult, erro
 
 	// Conditionally preload relationships
 	if opts.PreloadPaths {
-		query = query.Preload("LocalPaths").Preload("S3Keys")
+		query = query.Preload("LocalPaths").Preload("S3Keys").Preload("Labels")
 	}
 
 	// Execute query
@@ -235,11 +169,8 @@ func applyFilters(query *gorm.DB, opts QueryOptions) *gorm.DB {
 
 	// Search filter
 	if opts.SearchTerm != "" {
-		searchPattern := "%" + opts.SearchTerm + "%"
-		query = query.Where(
-			"id LIKE ? OR server_name LIKE ? OR database_name LIKE ?",
-			searchPattern, searchPattern, searchPattern,
-		)
+		condition, args := dbmeta.ContainsCondition(query, opts.SearchTerm, "id", "server_name", "database_name")
+		query = query.Where(condition, args...)
 	}
 
 	return query
@@ -411,16 +342,19 @@ func (s *DBStore) GetStatsOptimized() (map[string]interface{}, error) {
 	return result, nil
 }
 
-// CleanupOldBackups removes backups older than the retention period
+// CleanupOldBackups removes backups older than the retention period, except
+// held ones
 func (s *DBStore) CleanupOldBackups(retentionDays int) (int64, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	cutoffDate := time.Now().AddDate(0, 0, -retentionDays)
+	now := time.Now()
+	cutoffDate := now.AddDate(0, 0, -retentionDays)
 
 	// Use soft delete first
 	result := s.db.Model(&DatabaseBackup{}).
 		Where("created_at < ? AND status != ?", cutoffDate, string(StatusDeleted)).
+		Where(dbmeta.NotHeldCondition, now).
 		Update("status", string(StatusDeleted))
 
 	if result.Error != nil {
@@ -435,10 +369,22 @@ func (s *DBStore) VacuumDatabase() error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	// Optimize tables
-	tables := []string{"backups", "local_paths", "s3_keys", "metadata_stats"}
-	for _, table := range tables {
-		if err := s.db.Exec(fmt.Sprintf("OPTIMIZE TABLE %s", table)).Error; err != nil {
+	// SQLite rebuilds the whole database file at once
+	if dbmeta.IsSQLite(s.db) {
+		if err := s.db.Exec("VACUUM").Error; err != nil {
+			log.Printf("Warning: Failed to vacuum metadata database: %v", err)
+		}
+		return nil
+	}
+
+	// Optimize tables; PostgreSQL reclaims space and refreshes statistics
+	// with VACUUM, which cannot run inside a transaction
+	optimize := "OPTIMIZE TABLE %s"
+	if dbmeta.IsPostgres(s.db) {
+		optimize = "VACUUM ANALYZE %s"
+	}
+	for _, table := range maintainedTables {
+		if err := s.db.Exec(fmt.Sprintf(optimize, table)).Error; err != nil {
 			log.Printf("Warning: Failed to optimize table %s: %v", table, err)
 			// Continue with other tables
 		}
@@ -486,18 +432,6 @@ func (s *DBStore) BulkUpdateStatus(updates map[string]types.BackupStatus) error
 	})
 }
 
-// joinColumns joins column names for SQL
-func joinColumns(columns []string) string {
-	result := ""
-	for i, col := range columns {
-		if i > 0 {
-			result += ", "
-		}
-		result += col
-	}
-	return result
-}
-
 // EnableQueryLogging enables slow query logging for performance monitoring
 func EnableQueryLogging(db *gorm.DB, threshold time.Duration) {
 	db.Callback().Query().After("gorm:query").Register("log_slow_queries", func(tx *gorm.DB) {
@@ -0,0 +1,51 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// openDryRun opens a session that builds statements for each metadata
+// database engine without running them
+func openDryRun(t *testing.T) map[string]*gorm.DB {
+	t.Helper()
+	sqlDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	dialectors := map[string]gorm.Dialector{
+		"mysql":      mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		"postgresql": postgres.New(postgres.Config{Conn: sqlDB}),
+	}
+	sessions := make(map[string]*gorm.DB)
+	for name, dialector := range dialectors {
+		db, err := gorm.Open(dialector, &gorm.Config{DryRun: true})
+		require.NoError(t, err)
+		sessions[name] = db
+	}
+	return sessions
+}
+
+func TestApplyFiltersSearch(t *testing.T) {
+	expected := map[string]string{
+		"mysql":      "id LIKE ? ESCAPE '!' OR server_name LIKE ? ESCAPE '!' OR database_name LIKE ? ESCAPE '!'",
+		"postgresql": "id ILIKE $1 ESCAPE '!' OR server_name ILIKE $2 ESCAPE '!' OR database_name ILIKE $3 ESCAPE '!'",
+	}
+
+	for name, db := range openDryRun(t) {
+		t.Run(name, func(t *testing.T) {
+			query := applyFilters(db.Model(&DatabaseBackup{}), QueryOptions{SearchTerm: "prod_1%"})
+			stmt := query.Find(&[]DatabaseBackup{}).Statement
+
+			// Wildcards in the search term match literally
+			assert.Contains(t, stmt.SQL.String(), expected[name])
+			assert.Equal(t, []interface{}{"%prod!_1!%%", "%prod!_1!%%", "%prod!_1!%%"}, stmt.Vars)
+		})
+	}
+}
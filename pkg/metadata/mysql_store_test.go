@@ -1,6 +1,10 @@
 package metadata
 
 import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -8,9 +12,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 // TestMySQLStoreInitialization tests MySQL store initialization
@@ -239,3 +245,196 @@ func TestDatabaseConnectionError(t *testing.T) {
 	// Restore config
 	config.CFG.MetadataDB = originalConfig
 }
+
+// storeDialects returns the metadata databases the store tests run against:
+// an embedded SQLite file in dir, plus MySQL and PostgreSQL when
+// MYSQL_TEST_HOST or POSTGRES_TEST_HOST name a server to use
+func storeDialects(dir string) map[string]config.MetadataDBConfig {
+	dialects := map[string]config.MetadataDBConfig{
+		"sqlite": {
+			Type: config.MetadataDBTypeSQLite,
+			Path: filepath.Join(dir, "metadata.db"),
+		},
+	}
+	if host := os.Getenv("MYSQL_TEST_HOST"); host != "" {
+		dialects["mysql"] = config.MetadataDBConfig{
+			Type:     config.MetadataDBTypeMySQL,
+			Host:     host,
+			Port:     3306,
+			Username: os.Getenv("MYSQL_TEST_USER"),
+			Password: os.Getenv("MYSQL_TEST_PASSWORD"),
+			Database: "gosqlguard_test",
+		}
+	}
+	if host := os.Getenv("POSTGRES_TEST_HOST"); host != "" {
+		dialects["postgresql"] = config.MetadataDBConfig{
+			Type:     config.MetadataDBTypePostgreSQL,
+			Host:     host,
+			Port:     5432,
+			Username: os.Getenv("POSTGRES_TEST_USER"),
+			Password: os.Getenv("POSTGRES_TEST_PASSWORD"),
+			Database: "gosqlguard_test",
+		}
+	}
+	return dialects
+}
+
+// openDBStore initializes the metadata database described by db the way
+// the server does, with local backups in dir. A MySQL or PostgreSQL
+// database is emptied first; pass reset false to reopen it as a restart.
+func openDBStore(t *testing.T, dir string, db config.MetadataDBConfig, reset bool) *DBStore {
+	t.Helper()
+	original := config.CFG
+	t.Cleanup(func() {
+		config.CFG = original
+		DefaultStore = nil
+		DB = nil
+	})
+
+	db.Enabled = true
+	db.MaxOpenConns = 10
+	db.MaxIdleConns = 5
+	db.AutoMigrate = true
+	if reset && !db.Embedded() {
+		resetDatabase(t, db)
+	}
+
+	config.CFG.Local.Enabled = true
+	config.CFG.Local.BackupDirectory = dir
+	config.CFG.S3.Enabled = false
+	config.CFG.MetadataDB = db
+
+	DefaultStore = nil
+	require.NoError(t, InitializeMetadataDatabase())
+	store, ok := DefaultStore.(*DBStore)
+	require.True(t, ok, "%s store should be used instead of the file store", db.Type)
+	t.Cleanup(func() { closeStore(store) })
+	return store
+}
+
+// resetDatabase reverts every migration of a shared test database, dropping
+// the tables and backups left by earlier runs
+func resetDatabase(t *testing.T, cfg config.MetadataDBConfig) {
+	t.Helper()
+	dialector, err := dbmeta.Dialector(cfg)
+	require.NoError(t, err)
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+	_, err = dbmeta.NewMigrator(db).Down(math.MaxInt)
+	require.NoError(t, err)
+}
+
+// closeStore closes the database of a DBStore
+func closeStore(store types.MetadataStore) {
+	if dbStore, ok := store.(*DBStore); ok {
+		if sqlDB, err := dbStore.db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+}
+
+// TestDBStore runs the database store against each metadata database
+func TestDBStore(t *testing.T) {
+	for name, db := range storeDialects(t.TempDir()) {
+		t.Run(name, func(t *testing.T) {
+			testDBStore(t, db)
+		})
+	}
+}
+
+func testDBStore(t *testing.T, db config.MetadataDBConfig) {
+	dir := t.TempDir()
+	if db.Embedded() {
+		db.Path = filepath.Join(dir, "metadata.db")
+	}
+
+	// Backups recorded by the file store before switching
+	created := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	data, err := json.Marshal(Data{
+		Backups: []types.BackupMeta{
+			{
+				ID:         "prod-server-orders-daily-1",
+				ServerName: "prod-server",
+				ServerType: "mysql",
+				Database:   "orders",
+				BackupType: "daily",
+				CreatedAt:  created,
+				Status:     types.StatusSuccess,
+				Size:       1024,
+				LocalPaths: map[string]string{"by-server": "/backups/orders.sql.gz"},
+			},
+			{
+				ID:           "staging-users-hourly-1",
+				ServerName:   "staging",
+				ServerType:   "postgresql",
+				Database:     "users",
+				BackupType:   "hourly",
+				CreatedAt:    created.Add(time.Hour),
+				Status:       types.StatusError,
+				ErrorMessage: "connection refused",
+			},
+		},
+		Version: "1.0",
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "metadata.json"), data, 0600))
+
+	store := openDBStore(t, dir, db, true)
+
+	// The JSON file is migrated on first start
+	migrated, found := store.GetBackupByID("prod-server-orders-daily-1")
+	require.True(t, found)
+	assert.Equal(t, types.StatusSuccess, migrated.Status)
+	assert.Equal(t, "/backups/orders.sql.gz", migrated.LocalPaths["by-server"])
+	assert.True(t, created.Equal(migrated.CreatedAt))
+
+	backup := store.CreateBackupMeta("prod-server", "mysql", "customers", "hourly")
+	require.NoError(t, store.UpdateBackupStatus(backup.ID, types.StatusSuccess,
+		map[string]string{"by-server": "/backups/customers.sql.gz"}, 2048, ""))
+	assert.Len(t, store.GetBackups(), 3)
+	assert.Len(t, store.GetBackupsFiltered("prod-server", "", "", true), 2)
+
+	// Paginated queries used by the backup status page
+	page, err := store.GetBackupsPaginated(QueryOptions{Page: 1, PageSize: 2, PreloadPaths: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.Equal(t, 2, page.TotalPages)
+	require.Len(t, page.Data, 2)
+	assert.Equal(t, backup.ID, page.Data[0].ID, "newest backup comes first")
+	assert.Equal(t, "/backups/customers.sql.gz", page.Data[0].LocalPaths["by-server"])
+
+	page, err = store.GetBackupsPaginated(QueryOptions{SearchTerm: "PROD", Status: string(types.StatusSuccess)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), page.Total)
+
+	start := created.Add(-time.Hour)
+	page, err = store.GetBackupsPaginated(QueryOptions{StartDate: &start, BackupType: "hourly"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), page.Total)
+
+	stats, err := store.GetStatsOptimized()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats["totals"].(map[string]interface{})["totalCount"])
+	require.NoError(t, store.VacuumDatabase())
+
+	// Annotation changes merge into what is stored
+	_, err = store.MergeAnnotations(backup.ID, map[string]string{"ticket": "OPS-1", "owner": "dba"})
+	require.NoError(t, err)
+	annotations, err := store.MergeAnnotations(backup.ID, map[string]string{"owner": "", "reviewed": "yes"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ticket": "OPS-1", "reviewed": "yes"}, annotations)
+	stored, _ := store.GetBackupByID(backup.ID)
+	assert.Equal(t, annotations, stored.Annotations)
+	_, err = store.MergeAnnotations("missing", map[string]string{"ticket": "OPS-2"})
+	assert.Error(t, err)
+
+	// A restart neither loses nor duplicates backups
+	closeStore(store)
+	store = openDBStore(t, dir, db, false)
+	assert.Len(t, store.GetBackups(), 3)
+}
@@ -0,0 +1,106 @@
+package metadata
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+// ParseBackupQuery reads a backup query from URL parameters:
+//
+//	server, database, type  exact matches
+//	status                  comma separated statuses; "active" for successful backups
+//	from, to                creation time range, RFC 3339 or a date; a date in
+//	                        to includes that whole day
+//	minSize, maxSize        size range in bytes
+//	search                  part of the ID, server or database name
+//	labels                  labels that must all match, as key=value pairs
+//	                        separated by commas
+//	sort, order             sort field and asc or desc (default: createdAt desc)
+//	limit, cursor           page size and the nextCursor of the previous page
+func ParseBackupQuery(values url.Values) (types.BackupQuery, error) {
+	q := types.BackupQuery{
+		ServerName: values.Get("server"),
+		Database:   values.Get("database"),
+		BackupType: values.Get("type"),
+		ActiveOnly: values.Get("activeOnly") == "true",
+		Search:     strings.TrimSpace(values.Get("search")),
+		SortBy:     values.Get("sort"),
+		Cursor:     values.Get("cursor"),
+	}
+
+	for _, value := range values["status"] {
+		for _, status := range strings.Split(value, ",") {
+			switch status = strings.TrimSpace(status); status {
+			case "":
+			case "active":
+				q.ActiveOnly = true
+			default:
+				q.Statuses = append(q.Statuses, types.BackupStatus(status))
+			}
+		}
+	}
+
+	var err error
+	if q.Labels, err = config.ParseLabels(values.Get("labels")); err != nil {
+		return q, fmt.Errorf("invalid labels: %w", err)
+	}
+	if q.CreatedAfter, err = parseQueryTime(values.Get("from"), false); err != nil {
+		return q, fmt.Errorf("invalid from: %w", err)
+	}
+	if q.CreatedBefore, err = parseQueryTime(values.Get("to"), true); err != nil {
+		return q, fmt.Errorf("invalid to: %w", err)
+	}
+	if q.MinSize, err = parseQueryInt(values.Get("minSize")); err != nil {
+		return q, fmt.Errorf("invalid minSize: %w", err)
+	}
+	if q.MaxSize, err = parseQueryInt(values.Get("maxSize")); err != nil {
+		return q, fmt.Errorf("invalid maxSize: %w", err)
+	}
+	limit, err := parseQueryInt(values.Get("limit"))
+	if err != nil {
+		return q, fmt.Errorf("invalid limit: %w", err)
+	}
+	q.Limit = int(limit)
+
+	switch order := values.Get("order"); order {
+	case "", "desc":
+	case "asc":
+		q.Ascending = true
+	default:
+		return q, fmt.Errorf("invalid order %q, expected asc or desc", order)
+	}
+
+	return q, q.Normalize()
+}
+
+// parseQueryTime parses an RFC 3339 time or a date. A date that ends a range
+// stands for the end of that day.
+func parseQueryTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 time or a date, got %q", value)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+func parseQueryInt(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
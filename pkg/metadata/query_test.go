@@ -0,0 +1,196 @@
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+func TestParseBackupQuery(t *testing.T) {
+	q, err := ParseBackupQuery(url.Values{
+		"server":  {"prod"},
+		"status":  {"success,error", "active"},
+		"from":    {"2026-03-01"},
+		"to":      {"2026-03-02"},
+		"minSize": {"1024"},
+		"sort":    {"size"},
+		"order":   {"asc"},
+		"limit":   {"5000"},
+		"labels":  {"env=prod, team=payments"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "prod", q.ServerName)
+	assert.Equal(t, []types.BackupStatus{types.StatusSuccess, types.StatusError}, q.Statuses)
+	assert.True(t, q.ActiveOnly)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), q.CreatedAfter)
+	assert.Equal(t, time.Date(2026, 3, 3, 0, 0, 0, 0, time.Local), q.CreatedBefore, "a date in to includes the whole day")
+	assert.Equal(t, int64(1024), q.MinSize)
+	assert.Equal(t, types.SortBySize, q.SortBy)
+	assert.True(t, q.Ascending)
+	assert.Equal(t, types.MaxQueryLimit, q.Limit)
+	assert.Equal(t, map[string]string{"env": "prod", "team": "payments"}, q.Labels)
+
+	q, err = ParseBackupQuery(url.Values{"to": {"2026-03-02T10:00:00Z"}})
+	require.NoError(t, err)
+	assert.Equal(t, types.SortByCreatedAt, q.SortBy)
+	assert.Equal(t, types.DefaultQueryLimit, q.Limit)
+	assert.True(t, q.CreatedBefore.Equal(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)))
+
+	for _, values := range []url.Values{
+		{"from": {"yesterday"}},
+		{"maxSize": {"1GB"}},
+		{"order": {"up"}},
+		{"sort": {"errorMessage"}},
+		{"cursor": {"not-a-cursor"}},
+		{"labels": {"prod"}},
+	} {
+		_, err := ParseBackupQuery(values)
+		assert.Error(t, err, "%v", values)
+	}
+}
+
+// queryTestBackups returns backups in three groups created at the same time,
+// so paging has to break ties
+func queryTestBackups() []types.BackupMeta {
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	servers := []string{"prod", "staging"}
+	databases := []string{"orders", "users", "billing"}
+	statuses := []types.BackupStatus{types.StatusSuccess, types.StatusSuccess, types.StatusError, types.StatusDeleted}
+
+	var backups []types.BackupMeta
+	for i := 0; i < 21; i++ {
+		backups = append(backups, types.BackupMeta{
+			ID:         fmt.Sprintf("backup-%02d", i),
+			ServerName: servers[i%len(servers)],
+			ServerType: "mysql",
+			Database:   databases[i%len(databases)],
+			BackupType: []string{"hourly", "daily"}[i%2],
+			CreatedAt:  base.Add(time.Duration(i/3) * time.Hour),
+			Size:       int64(1000 * (i % 5)),
+			Status:     statuses[i%len(statuses)],
+			Labels:     map[string]string{"env": servers[i%len(servers)]},
+		})
+		if i%3 == 0 {
+			backups[i].Labels["team"] = "payments"
+		}
+	}
+	return backups
+}
+
+// collectPages reads every page of a query and returns the backup IDs
+func collectPages(t *testing.T, store types.MetadataStore, q types.BackupQuery) ([]string, int64) {
+	t.Helper()
+	var ids []string
+	var total int64
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 50, "paging does not end")
+		page, err := store.QueryBackups(q)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page.Backups), q.Limit)
+		for _, b := range page.Backups {
+			ids = append(ids, b.ID)
+		}
+		total = page.Total
+		if page.NextCursor == "" {
+			return ids, total
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// TestQueryBackups pages through the same backups in the file store and each
+// metadata database and compares them with filtering and sorting in memory
+func TestQueryBackups(t *testing.T) {
+	backups := queryTestBackups()
+	dir := t.TempDir()
+	data, err := json.Marshal(Data{Backups: backups, Version: "1.0"})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "metadata.json"), data, 0600))
+
+	fileStore, err := OpenFileStore(filepath.Join(dir, "metadata.json"))
+	require.NoError(t, err)
+	stores := map[string]types.MetadataStore{"file": fileStore}
+	for name, db := range storeDialects(dir) {
+		stores[name] = openDBStore(t, dir, db, true)
+	}
+
+	base := backups[0].CreatedAt
+	queries := map[string]types.BackupQuery{
+		"newest first":       {},
+		"by size":            {SortBy: types.SortBySize, Ascending: true},
+		"by server":          {SortBy: types.SortByServer},
+		"by database":        {SortBy: types.SortByDatabase, Ascending: true, Statuses: []types.BackupStatus{types.StatusError, types.StatusDeleted}},
+		"by type and status": {SortBy: types.SortByStatus, BackupType: "daily"},
+		"active on prod":     {ServerName: "prod", ActiveOnly: true, SortBy: types.SortByType},
+		"time and size":      {CreatedAfter: base.Add(2 * time.Hour), CreatedBefore: base.Add(5 * time.Hour), MinSize: 1000, MaxSize: 3000},
+		"search":             {Search: "USERS", Database: "users"},
+		"search underscore":  {Search: "backup-0_"},
+		"search percent":     {Search: "%"},
+		"labels":             {Labels: map[string]string{"env": "prod", "team": "payments"}},
+		"no match":           {ServerName: "missing"},
+	}
+
+	for name, q := range queries {
+		q.Limit = 4
+		reference := q
+		require.NoError(t, reference.Normalize())
+		var want []string
+		for _, b := range backups {
+			if reference.Matches(b) {
+				want = append(want, b.ID)
+			}
+		}
+		byID := make(map[string]types.BackupMeta)
+		for _, b := range backups {
+			byID[b.ID] = b
+		}
+		sort.Slice(want, func(i, j int) bool { return reference.Less(byID[want[i]], byID[want[j]]) })
+
+		for storeName, store := range stores {
+			t.Run(name+"/"+storeName, func(t *testing.T) {
+				got, total := collectPages(t, store, q)
+				assert.Equal(t, want, got)
+				assert.Equal(t, int64(len(want)), total)
+			})
+		}
+	}
+
+	// Spot check the reference order itself
+	q := types.BackupQuery{ServerName: "staging", CreatedBefore: base.Add(2 * time.Hour), Limit: 2}
+	for storeName, store := range stores {
+		got, _ := collectPages(t, store, q)
+		assert.Equal(t, []string{"backup-05", "backup-03", "backup-01"}, got, storeName)
+	}
+}
+
+func TestQueryBackupsCursor(t *testing.T) {
+	store, err := OpenFileStore(filepath.Join(t.TempDir(), "metadata.json"))
+	require.NoError(t, err)
+	store.ImportBackups(queryTestBackups(), false)
+
+	page, err := store.QueryBackups(types.BackupQuery{Limit: 20})
+	require.NoError(t, err)
+	assert.Len(t, page.Backups, 20)
+	require.NotEmpty(t, page.NextCursor)
+
+	// A cursor only continues the order it was issued for
+	_, err = store.QueryBackups(types.BackupQuery{Limit: 20, Cursor: page.NextCursor, SortBy: types.SortBySize})
+	assert.True(t, errors.Is(err, types.ErrInvalidCursor))
+
+	last, err := store.QueryBackups(types.BackupQuery{Limit: 20, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, last.Backups, 1)
+	assert.Equal(t, "backup-00", last.Backups[0].ID)
+	assert.Empty(t, last.NextCursor)
+}
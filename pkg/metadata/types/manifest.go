@@ -0,0 +1,39 @@
+package types
+
+import "time"
+
+// ManifestSuffix is appended to a backup file's path or S3 key to name the
+// manifest stored next to it
+const ManifestSuffix = ".manifest.json"
+
+// ManifestVersion is the version of the manifest format written by this
+// release
+const ManifestVersion = 1
+
+// Manifest describes a backup artifact well enough to rebuild its metadata
+// from storage alone, should the metadata store be lost
+type Manifest struct {
+	ManifestVersion int        `json:"manifestVersion"`
+	Backup          BackupMeta `json:"backup"`
+
+	// ToolVersions holds the version of GoSQLGuard and of the dump tool
+	// that wrote the backup, keyed by program name
+	ToolVersions map[string]string `json:"toolVersions"`
+
+	// DumpCommand is the dump command with its options; credentials are
+	// never included
+	DumpCommand string `json:"dumpCommand"`
+
+	// ServerVersion is the version the database server reported
+	ServerVersion string `json:"serverVersion,omitempty"`
+
+	// Checksum is the SHA-256 of the compressed backup file, as "sha256:<hex>"
+	Checksum string `json:"checksum"`
+
+	WrittenAt time.Time `json:"writtenAt"`
+}
+
+// ManifestPath returns the path or S3 key of the manifest for a backup file
+func ManifestPath(artifact string) string {
+	return artifact + ManifestSuffix
+}
@@ -0,0 +1,277 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fields backups can be sorted by
+const (
+	SortByCreatedAt = "createdAt"
+	SortBySize      = "size"
+	SortByServer    = "server"
+	SortByDatabase  = "database"
+	SortByType      = "type"
+	SortByStatus    = "status"
+)
+
+// Page sizes of a backup query
+const (
+	DefaultQueryLimit = 50
+	MaxQueryLimit     = 1000
+)
+
+// ErrInvalidCursor is returned for a cursor that was not issued for the query
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// BackupQuery selects one page of backups. Empty fields do not filter.
+type BackupQuery struct {
+	ServerName    string
+	Database      string
+	BackupType    string
+	Statuses      []BackupStatus // any of these
+	ActiveOnly    bool           // only successful backups
+	CreatedAfter  time.Time      // inclusive
+	CreatedBefore time.Time      // exclusive
+	MinSize       int64
+	MaxSize       int64             // 0 for no limit
+	Search        string            // case-insensitive part of the ID, server or database name
+	Labels        map[string]string // labels that must all match
+
+	SortBy    string // one of the SortBy constants, SortByCreatedAt by default
+	Ascending bool   // newest or largest first unless set
+	Limit     int    // DefaultQueryLimit when 0, at most MaxQueryLimit
+	Cursor    string // NextCursor of the previous page
+
+	after *BackupMeta
+}
+
+// BackupPage is one page of a backup query
+type BackupPage struct {
+	Backups    []BackupMeta `json:"backups"`
+	Total      int64        `json:"total"`                // backups matching the filters on all pages
+	NextCursor string       `json:"nextCursor,omitempty"` // empty on the last page
+}
+
+// queryCursor is the position of the last backup of a page
+type queryCursor struct {
+	SortBy    string `json:"s"`
+	Ascending bool   `json:"a,omitempty"`
+	Value     string `json:"v"`
+	ID        string `json:"id"`
+}
+
+// Normalize applies the defaults and decodes the cursor. Stores call it
+// before running a query; handlers can call it first to reject bad input.
+func (q *BackupQuery) Normalize() error {
+	if q.SortBy == "" {
+		q.SortBy = SortByCreatedAt
+	}
+	switch q.SortBy {
+	case SortByCreatedAt, SortBySize, SortByServer, SortByDatabase, SortByType, SortByStatus:
+	default:
+		return fmt.Errorf("unsupported sort field %q", q.SortBy)
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultQueryLimit
+	}
+	if q.Limit > MaxQueryLimit {
+		q.Limit = MaxQueryLimit
+	}
+	if q.MinSize < 0 || q.MaxSize < 0 {
+		return errors.New("size limits must not be negative")
+	}
+
+	q.after = nil
+	if q.Cursor == "" {
+		return nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	var c queryCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return ErrInvalidCursor
+	}
+	if c.SortBy != q.SortBy || c.Ascending != q.Ascending {
+		return fmt.Errorf("%w: it was issued for a different sort order", ErrInvalidCursor)
+	}
+	after := BackupMeta{ID: c.ID}
+	if err := setSortValue(&after, q.SortBy, c.Value); err != nil {
+		return ErrInvalidCursor
+	}
+	q.after = &after
+	return nil
+}
+
+// After returns the sort position of the cursor, the last backup of the
+// previous page, or false on the first page
+func (q BackupQuery) After() (BackupMeta, bool) {
+	if q.after == nil {
+		return BackupMeta{}, false
+	}
+	return *q.after, true
+}
+
+// Matches reports whether a backup passes the query's filters
+func (q BackupQuery) Matches(b BackupMeta) bool {
+	if q.ServerName != "" && b.ServerName != q.ServerName {
+		return false
+	}
+	if q.Database != "" && b.Database != q.Database {
+		return false
+	}
+	if q.BackupType != "" && b.BackupType != q.BackupType {
+		return false
+	}
+	if len(q.Statuses) > 0 && !containsStatus(q.Statuses, b.Status) {
+		return false
+	}
+	if q.ActiveOnly && b.Status != StatusSuccess {
+		return false
+	}
+	if !q.CreatedAfter.IsZero() && b.CreatedAt.Before(q.CreatedAfter) {
+		return false
+	}
+	if !q.CreatedBefore.IsZero() && !b.CreatedAt.Before(q.CreatedBefore) {
+		return false
+	}
+	if b.Size < q.MinSize || (q.MaxSize > 0 && b.Size > q.MaxSize) {
+		return false
+	}
+	for key, value := range q.Labels {
+		if v, ok := b.Labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	if q.Search != "" {
+		search := strings.ToLower(q.Search)
+		if !strings.Contains(strings.ToLower(b.ID), search) &&
+			!strings.Contains(strings.ToLower(b.ServerName), search) &&
+			!strings.Contains(strings.ToLower(b.Database), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// Less reports whether a comes before b in the query's order. Backups with
+// the same sort value are ordered by ID, so every backup has one position.
+func (q BackupQuery) Less(a, b BackupMeta) bool {
+	c := compareSortValue(a, b, q.SortBy)
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+	if q.Ascending {
+		return c < 0
+	}
+	return c > 0
+}
+
+// SortValue returns the value a backup is sorted by
+func (q BackupQuery) SortValue(b BackupMeta) interface{} {
+	switch q.SortBy {
+	case SortBySize:
+		return b.Size
+	case SortByServer:
+		return b.ServerName
+	case SortByDatabase:
+		return b.Database
+	case SortByType:
+		return b.BackupType
+	case SortByStatus:
+		return string(b.Status)
+	default:
+		return b.CreatedAt
+	}
+}
+
+// NewBackupPage builds the page for backups fetched in the query's order.
+// Stores fetch one backup more than the limit; when it is there, the page
+// gets a cursor pointing after its last backup.
+func NewBackupPage(q BackupQuery, backups []BackupMeta, total int64) BackupPage {
+	page := BackupPage{Backups: backups, Total: total}
+	if page.Backups == nil {
+		page.Backups = []BackupMeta{}
+	}
+	if len(backups) > q.Limit {
+		page.Backups = backups[:q.Limit]
+		last := page.Backups[q.Limit-1]
+		raw, _ := json.Marshal(queryCursor{
+			SortBy:    q.SortBy,
+			Ascending: q.Ascending,
+			Value:     formatSortValue(q.SortValue(last)),
+			ID:        last.ID,
+		})
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	return page
+}
+
+func containsStatus(statuses []BackupStatus, status BackupStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func compareSortValue(a, b BackupMeta, sortBy string) int {
+	switch sortBy {
+	case SortBySize:
+		switch {
+		case a.Size < b.Size:
+			return -1
+		case a.Size > b.Size:
+			return 1
+		}
+		return 0
+	case SortByServer:
+		return strings.Compare(a.ServerName, b.ServerName)
+	case SortByDatabase:
+		return strings.Compare(a.Database, b.Database)
+	case SortByType:
+		return strings.Compare(a.BackupType, b.BackupType)
+	case SortByStatus:
+		return strings.Compare(string(a.Status), string(b.Status))
+	default:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}
+}
+
+func formatSortValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func setSortValue(b *BackupMeta, sortBy, value string) error {
+	var err error
+	switch sortBy {
+	case SortBySize:
+		b.Size, err = strconv.ParseInt(value, 10, 64)
+	case SortByServer:
+		b.ServerName = value
+	case SortByDatabase:
+		b.Database = value
+	case SortByType:
+		b.BackupType = value
+	case SortByStatus:
+		b.Status = BackupStatus(value)
+	default:
+		b.CreatedAt, err = time.Parse(time.RFC3339Nano, value)
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+package types
+
+import "time"
+
+// StatsSnapshot is the backup activity and storage use of one database on
+// one day, recorded so trends survive retention purging old backups
+type StatsSnapshot struct {
+	Day             time.Time `json:"day"` // Midnight UTC
+	ServerName      string    `json:"serverName"`
+	Database        string    `json:"database"`
+	BackupCount     int       `json:"backupCount"`     // Successful backups created that day
+	FailedCount     int       `json:"failedCount"`     // Failed or interrupted backups created that day
+	BackupSize      int64     `json:"backupSize"`      // Total size of the successful backups
+	DurationSeconds float64   `json:"durationSeconds"` // Total duration of the successful backups
+	LocalSize       int64     `json:"localSize"`       // Size of the backups held in local storage at the end of the day
+	S3Size          int64     `json:"s3Size"`          // Size of the backups held in S3 at the end of the day
+}
+
+// StatsDay returns the day a time falls on, as midnight UTC
+func StatsDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// StatsHistoryQuery selects stats snapshots. Empty fields do not filter.
+type StatsHistoryQuery struct {
+	ServerName string
+	Database   string
+	From       time.Time // inclusive
+	To         time.Time // exclusive
+}
+
+// Matches reports whether a snapshot is selected by the query
+func (q StatsHistoryQuery) Matches(s StatsSnapshot) bool {
+	if q.ServerName != "" && s.ServerName != q.ServerName {
+		return false
+	}
+	if q.Database != "" && s.Database != q.Database {
+		return false
+	}
+	if !q.From.IsZero() && s.Day.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !s.Day.Before(q.To) {
+		return false
+	}
+	return true
+}
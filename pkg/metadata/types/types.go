@@ -17,6 +17,10 @@ const (
 	StatusError BackupStatus = "error"
 	// StatusDeleted indicates a backup that was deleted by retention policy
 	StatusDeleted BackupStatus = "deleted"
+	// StatusDeferred indicates a scheduled backup postponed to the next run
+	StatusDeferred BackupStatus = "deferred"
+	// StatusInterrupted indicates a backup cut short by GoSQLGuard shutting down
+	StatusInterrupted BackupStatus = "interrupted"
 )
 
 // BackupMeta represents metadata for a single backup
@@ -39,12 +43,84 @@ type BackupMeta struct {
 	S3UploadError    string            `json:"s3UploadError"`    // S3 upload error if any
 	CompletedAt      time.Time         `json:"completedAt"`      // When backup completed
 	S3UploadComplete time.Time         `json:"s3UploadComplete"` // When S3 upload completed
+	LocalDeletedAt   time.Time         `json:"localDeletedAt"`   // When retention removed the local copies
+	S3DeletedAt      time.Time         `json:"s3DeletedAt"`      // When retention removed the S3 copies
+	RetentionError   string            `json:"retentionError"`   // Last retention deletion error if any
+
+	// Labels are copied from the server and database labels when the backup
+	// is created; Annotations are free-form notes added to it later
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// FencingToken is the leader lease token of the replica that ran the
+	// backup, zero when leader election is disabled
+	FencingToken int64 `json:"fencingToken,omitempty"`
+
+	// Hold keeps the backup from being deleted while it is active
+	Hold *BackupHold `json:"hold,omitempty"`
 
 	// For backward compatibility - these will be populated from the maps above
 	LocalPath string `json:"localPath"` // Legacy field - primary local path
 	S3Key     string `json:"s3Key"`     // Legacy field - primary S3 key
 }
 
+// IsHeld reports whether an active hold keeps the backup from being deleted
+func (b BackupMeta) IsHeld(now time.Time) bool {
+	return b.Hold != nil && b.Hold.Active(now)
+}
+
+// BackupHold is a legal hold or manual pin placed on a backup. Retention
+// skips held backups until the hold is released or expires.
+type BackupHold struct {
+	Reason     string    `json:"reason"`
+	Actor      string    `json:"actor"`                // Who placed the hold
+	PlacedAt   time.Time `json:"placedAt"`             // When the hold was placed
+	ExpiresAt  time.Time `json:"expiresAt,omitempty"`  // Zero for a hold without expiry
+	ObjectLock bool      `json:"objectLock,omitempty"` // Whether S3 Object Lock legal hold was applied
+}
+
+// Active reports whether the hold is still in force
+func (h BackupHold) Active(now time.Time) bool {
+	return h.ExpiresAt.IsZero() || now.Before(h.ExpiresAt)
+}
+
+// ApplyAnnotationChanges returns annotations with changes applied; an empty
+// value removes that annotation
+func ApplyAnnotationChanges(annotations, changes map[string]string) map[string]string {
+	merged := make(map[string]string, len(annotations)+len(changes))
+	for key, value := range annotations {
+		merged[key] = value
+	}
+	for key, value := range changes {
+		if value == "" {
+			delete(merged, key)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// Audit actions
+const (
+	AuditHoldPlaced   = "hold.placed"
+	AuditHoldReleased = "hold.released"
+	AuditHoldExpired  = "hold.expired"
+
+	AuditOrphanImported = "orphan.imported"
+	AuditOrphanDeleted  = "orphan.deleted"
+	AuditMarkedMissing  = "artifact.missing"
+)
+
+// AuditEntry records an action taken on backups, and who took it
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor"`
+	Action   string    `json:"action"`
+	BackupID string    `json:"backupId,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+}
+
 // MetadataStore defines the interface for metadata operations
 type MetadataStore interface {
 	// CreateBackupMeta creates a new backup metadata entry
@@ -62,6 +138,10 @@ type MetadataStore interface {
 	// GetBackupsFiltered returns backups filtered by server, database and/or type
 	GetBackupsFiltered(serverName, database, backupType string, activeOnly bool) []BackupMeta
 
+	// QueryBackups returns one page of the backups matching a query, in the
+	// query's order
+	QueryBackups(q BackupQuery) (BackupPage, error)
+
 	// GetBackupByID returns a specific backup by ID
 	GetBackupByID(id string) (BackupMeta, bool)
 
@@ -74,8 +154,52 @@ type MetadataStore interface {
 	// UpdateLogFilePath updates the log file path for a backup
 	UpdateLogFilePath(id string, logFilePath string) error
 
+	// UpdateFencingToken records the leader lease token of the replica
+	// running a backup
+	UpdateFencingToken(id string, token int64) error
+
+	// UpdateLabels replaces the labels of a backup
+	UpdateLabels(id string, labels map[string]string) error
+
+	// MergeAnnotations applies changes to the annotations of a backup in a
+	// single step, an empty value removing that annotation, and returns the
+	// resulting annotations
+	MergeAnnotations(id string, changes map[string]string) (map[string]string, error)
+
+	// PutBackup stores a backup as given, replacing any backup with the same
+	// ID
+	PutBackup(backup BackupMeta) error
+
+	// PutBackups stores backups as given in a single write, replacing any
+	// backups with the same IDs
+	PutBackups(backups []BackupMeta) error
+
+	// UpdateHold places a hold on a backup, or releases it when hold is nil
+	UpdateHold(id string, hold *BackupHold) error
+
+	// AddAuditEntry appends an entry to the audit log
+	AddAuditEntry(entry AuditEntry) error
+
+	// GetAuditEntries returns up to limit audit entries, newest first, for one
+	// backup or for all of them when backupID is empty
+	GetAuditEntries(backupID string, limit int) []AuditEntry
+
+	// SaveStatsSnapshots stores daily stats snapshots, replacing those of the
+	// same day, server and database
+	SaveStatsSnapshots(snapshots []StatsSnapshot) error
+
+	// GetStatsHistory returns the stats snapshots matching a query, oldest
+	// first
+	GetStatsHistory(q StatsHistoryQuery) ([]StatsSnapshot, error)
+
+	// UpdateRetentionStatus records the outcome of retention deleting a backup
+	// from a storage location ("local" or "s3"). remaining holds the paths or
+	// keys that could not be deleted; once none remain the location is marked
+	// deleted, and the backup is marked deleted when no location holds a copy.
+	UpdateRetentionStatus(id, location string, remaining map[string]string, errorMsg string) error
+
 	// PurgeDeletedBackups removes backup entries that have been marked as deleted
-	// and are older than the specified duration
+	// and are older than the specified duration, except held ones
 	PurgeDeletedBackups(olderThan time.Duration) int
 
 	// Load loads the metadata
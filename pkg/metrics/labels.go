@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// backupLabels is the labels info metric, registered by ConfigureBackupLabels
+var (
+	backupLabelsMu  sync.Mutex
+	backupLabels    *prometheus.GaugeVec
+	backupLabelKeys []string
+)
+
+// ConfigureBackupLabels registers the mysql_backup_labels info metric, which
+// has the value 1 for every database backed up, with its server and database
+// and a label_<key> label for each of the given backup label keys. Joining
+// it with the per-database backup metrics on server and database groups them
+// by team or environment. Without keys the metric is not registered.
+func ConfigureBackupLabels(keys []string) {
+	backupLabelsMu.Lock()
+	defer backupLabelsMu.Unlock()
+
+	if backupLabels != nil {
+		prometheus.Unregister(backupLabels)
+		backupLabels = nil
+	}
+	backupLabelKeys = nil
+	if len(keys) == 0 {
+		return
+	}
+
+	// Keys that differ only in characters Prometheus does not allow share a
+	// label; the first one is exported
+	names := []string{"server", "database"}
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		name := "label_" + sanitizeLabelName(key)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		backupLabelKeys = append(backupLabelKeys, key)
+	}
+
+	backupLabels = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mysql_backup_labels",
+		Help: "Labels of the databases backed up, for joining with other backup metrics",
+	}, names)
+	prometheus.MustRegister(backupLabels)
+}
+
+// SetBackupLabels records the labels of a server's database on the info
+// metric, replacing those recorded before
+func SetBackupLabels(server, database string, labels map[string]string) {
+	backupLabelsMu.Lock()
+	defer backupLabelsMu.Unlock()
+
+	if backupLabels == nil {
+		return
+	}
+
+	backupLabels.DeletePartialMatch(prometheus.Labels{"server": server, "database": database})
+	values := []string{server, database}
+	for _, key := range backupLabelKeys {
+		values = append(values, labels[key])
+	}
+	backupLabels.WithLabelValues(values...).Set(1)
+}
+
+// sanitizeLabelName replaces the characters Prometheus does not allow in
+// label names
+func sanitizeLabelName(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, key)
+}
@@ -17,20 +17,20 @@ var (
 	BackupCount = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "mysql_backup_total",
 		Help: "The total number of MySQL backups performed",
-	}, []string{"type", "database", "status"})
+	}, []string{"type", "server", "database", "status"})
 
 	// BackupDuration measures time taken to perform MySQL backup
 	BackupDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    "mysql_backup_duration_seconds",
 		Help:    "Time taken to perform MySQL backup",
 		Buckets: prometheus.DefBuckets,
-	}, []string{"type", "database"})
+	}, []string{"type", "server", "database"})
 
 	// BackupSize tracks size of the backup file in bytes
 	BackupSize = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "mysql_backup_size_bytes",
 		Help: "Size of the backup file in bytes",
-	}, []string{"type", "database", "storage"})
+	}, []string{"type", "server", "database", "storage"})
 
 	// BackupRetentionDeletes counts backups deleted by retention policy
 	BackupRetentionDeletes = promauto.NewCounterVec(prometheus.CounterOpts{
@@ -38,11 +38,118 @@ var (
 		Help: "The total number of backups deleted by retention policy",
 	}, []string{"type", "storage"})
 
+	// RetentionDeleteErrors counts failed attempts to delete backups by retention policy
+	RetentionDeleteErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mysql_backup_deletion_errors_total",
+		Help: "The total number of failed retention deletions",
+	}, []string{"type", "storage"})
+
+	// OrphanedBackupFiles tracks backup files in storage with no metadata entry
+	OrphanedBackupFiles = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mysql_backup_orphaned_files",
+		Help: "Number of backup files in storage that no metadata entry references",
+	}, []string{"storage"})
+
+	// MissingBackupFiles tracks backup files recorded in metadata that storage
+	// no longer holds
+	MissingBackupFiles = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mysql_backup_missing_files",
+		Help: "Number of backup files recorded in metadata that are missing from storage",
+	}, []string{"storage"})
+
+	// BackupSizeMismatches tracks backup files whose size in storage differs
+	// from the size recorded in metadata
+	BackupSizeMismatches = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mysql_backup_size_mismatches",
+		Help: "Number of backup files whose stored size differs from the recorded size",
+	}, []string{"storage"})
+
+	// ScheduleDeferrals counts scheduled backups skipped or postponed by
+	// blackout windows, pauses and skipped occurrences, and databases
+	// deferred by the maximum run window
+	ScheduleDeferrals = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mysql_backup_schedule_deferrals_total",
+		Help: "The total number of scheduled backups skipped or deferred",
+	}, []string{"type", "reason"})
+
+	// ScheduleMissedRuns counts scheduled runs that did not happen or did
+	// not complete, typically because GoSQLGuard was down
+	ScheduleMissedRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mysql_backup_schedule_missed_runs_total",
+		Help: "The total number of scheduled backup runs that were missed or did not complete",
+	}, []string{"type", "reason"})
+
+	// SchedulePaused reports which schedules are paused
+	SchedulePaused = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mysql_backup_schedule_paused",
+		Help: "Whether a backup schedule is paused (1) or active (0)",
+	}, []string{"type"})
+
+	// SchedulePausedSince records when a paused schedule was paused
+	SchedulePausedSince = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mysql_backup_schedule_paused_since_timestamp",
+		Help: "Unix timestamp at which a currently paused backup schedule was paused",
+	}, []string{"type"})
+
+	// IsLeader reports whether this replica runs scheduled jobs
+	IsLeader = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mysql_backup_is_leader",
+		Help: "Whether this replica holds the leader lease and runs scheduled jobs (1) or is a follower (0)",
+	})
+
+	// TasksEnqueued counts database backups queued for workers
+	TasksEnqueued = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mysql_backup_tasks_enqueued_total",
+		Help: "The total number of database backups queued for worker processes",
+	}, []string{"type"})
+
+	// TasksCompleted counts queued backups finished by workers
+	TasksCompleted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mysql_backup_tasks_completed_total",
+		Help: "The total number of queued database backups finished by worker processes",
+	}, []string{"type", "status"})
+
+	// TasksRecovered counts tasks taken back from workers that stopped responding
+	TasksRecovered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mysql_backup_tasks_recovered_total",
+		Help: "The total number of backup tasks requeued or failed after their worker stopped sending heartbeats",
+	}, []string{"outcome"})
+
+	// LeaderTransitions counts how often this replica gained or lost leadership
+	LeaderTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mysql_backup_leader_transitions_total",
+		Help: "The total number of times this replica became leader or stepped down",
+	}, []string{"event"})
+
+	// JobLastRun records when a one-shot command last finished
+	JobLastRun = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mysql_backup_job_last_run_timestamp_seconds",
+		Help: "Timestamp of the last run of a one-shot backup, retention or verify command",
+	}, []string{"command"})
+
+	// JobLastSuccess records when a one-shot command last finished without failures
+	JobLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mysql_backup_job_last_success_timestamp_seconds",
+		Help: "Timestamp of the last run of a one-shot command that finished without failures",
+	}, []string{"command"})
+
+	// JobFailures records how many items failed in the last run of a one-shot command
+	JobFailures = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mysql_backup_job_failures",
+		Help: "Number of databases, deletions or backup copies that failed in the last run of a one-shot command",
+	}, []string{"command"})
+
+	// JobDuration records how long the last run of a one-shot command took
+	JobDuration = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mysql_backup_job_duration_seconds",
+		Help: "Time taken by the last run of a one-shot command",
+	}, []string{"command"})
+
 	// LastBackupTimestamp records timestamp of the last successful backup
 	LastBackupTimestamp = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "mysql_backup_last_timestamp",
 		Help: "Timestamp of the last successful backup",
-	}, []string{"type", "database"})
+	}, []string{"type", "server", "database"})
 
 	// S3UploadCount tracks the total number of S3 uploads performed
 	S3UploadCount = promauto.NewCounterVec(prometheus.CounterOpts{
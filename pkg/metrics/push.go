@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Push sends every registered metric to a Pushgateway-compatible endpoint.
+// Only metrics with the same name are replaced in the job's group, so a gauge
+// this process never set, like the last success time after a failed run,
+// keeps the value an earlier run pushed. One-shot commands use it because
+// they exit before Prometheus can scrape them.
+func Push(url, job string, grouping map[string]string) error {
+	pusher := push.New(url, job).Gatherer(prometheus.DefaultGatherer)
+	for name, value := range grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+	if err := pusher.Add(); err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", url, err)
+	}
+	return nil
+}
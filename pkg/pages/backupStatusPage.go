@@ -2,9 +2,10 @@
 package pages
 
 import (
+	"html/template"
 	"log"
 	"net/http"
-	"strings"
+	"net/url"
 	"time"
 
 	"github.com/supporttools/GoSQLGuard/pkg/backup/database/mysql"
@@ -31,6 +32,11 @@ type BackupStatusPageData struct {
 	FilterStartDate string
 	FilterEndDate   string
 	FilterSearch    string
+	FilterLabels    string
+	TotalMatching   int64        // Backups matching the filters on all pages
+	Paged           bool         // Whether this is a page after the first
+	FirstPageLink   template.URL // Query of the first page
+	NextPageLink    template.URL // Query of the next page, empty on the last
 }
 
 // BackupStatusPage renders the backup status page
@@ -137,16 +143,20 @@ func BackupStatusPage(w http.ResponseWriter, r *http.Request) {
             <!-- Date Range Filters -->
             <div class="col-md-2">
                 <label for="filterStartDate" class="form-label">Start Date</label>
-                <input type="date" class="form-control" id="filterStartDate" name="startDate" value="{{.Content.FilterStartDate}}">
+                <input type="date" class="form-control" id="filterStartDate" name="from" value="{{.Content.FilterStartDate}}">
             </div>
             <div class="col-md-2">
                 <label for="filterEndDate" class="form-label">End Date</label>
-                <input type="date" class="form-control" id="filterEndDate" name="endDate" value="{{.Content.FilterEndDate}}">
+                <input type="date" class="form-control" id="filterEndDate" name="to" value="{{.Content.FilterEndDate}}">
             </div>
             <!-- Search Field -->
             <div class="col-md-4">
-                <label for="filterSearch" class="form-label">Search Backup ID</label>
-                <input type="text" class="form-control" id="filterSearch" name="search" value="{{.Content.FilterSearch}}" placeholder="Enter backup ID to search">
+                <label for="filterSearch" class="form-label">Search</label>
+                <input type="text" class="form-control" id="filterSearch" name="search" value="{{.Content.FilterSearch}}" placeholder="Backup ID, server or database">
+            </div>
+            <div class="col-md-4">
+                <label for="filterLabels" class="form-label">Labels</label>
+                <input type="text" class="form-control" id="filterLabels" name="labels" value="{{.Content.FilterLabels}}" placeholder="env=prod,team=payments">
             </div>
             <div class="col-12 mt-3">
                 <button type="submit" class="btn btn-primary">Apply Filters</button>
@@ -208,7 +218,7 @@ func BackupStatusPage(w http.ResponseWriter, r *http.Request) {
 <!-- Backup Table -->
 <div class="card">
     <div class="card-header d-flex justify-content-between align-items-center">
-        <span>Backup History</span>
+        <span>Backup History <span class="text-muted small">({{.Content.TotalMatching}} matching)</span></span>
         <span class="text-muted small">Last updated: {{formatTime .Content.LastUpdated}}</span>
     </div>
     <div class="card-body">
@@ -234,9 +244,17 @@ func BackupStatusPage(w http.ResponseWriter, r *http.Request) {
                     <tr>
                         <td title="{{.ID}}">
                             <span class="d-inline-block text-truncate" style="max-width: 150px;">{{.ID}}</span>
+                            {{range $key, $value := .Annotations}}
+                            <div class="small text-muted" title="Annotation">{{$key}}: {{$value}}</div>
+                            {{end}}
                         </td>
                         <td>{{.ServerName}}</td>
-                        <td>{{.Database}}</td>
+                        <td>
+                            {{.Database}}
+                            {{range $key, $value := .Labels}}
+                            <span class="badge bg-light text-dark border">{{$key}}={{$value}}</span>
+                            {{end}}
+                        </td>
                         <td>{{.BackupType}}</td>
                         <td>{{formatTime .CreatedAt}}</td>
                         <td>{{if not .CompletedAt.IsZero}}{{formatTime .CompletedAt}}{{else}}-{{end}}</td>
@@ -258,12 +276,26 @@ func BackupStatusPage(w http.ResponseWriter, r *http.Request) {
                                 {{if not .ExpiresAt.IsZero}}
                                 Expires: {{formatTime .ExpiresAt}}
                                 {{else}}
-                                Keep forever
+                                {{.RetentionPolicy}}
                                 {{end}}
                             </span>
                             {{else}}
                             -
                             {{end}}
+                            {{if not .LocalDeletedAt.IsZero}}
+                            <span class="badge bg-secondary" title="Removed {{formatTime .LocalDeletedAt}}">Local removed</span>
+                            {{end}}
+                            {{if not .S3DeletedAt.IsZero}}
+                            <span class="badge bg-secondary" title="Removed {{formatTime .S3DeletedAt}}">S3 removed</span>
+                            {{end}}
+                            {{if .RetentionError}}
+                            <span class="badge bg-warning text-dark" title="{{.RetentionError}}">Retention error</span>
+                            {{end}}
+                            {{with .Hold}}
+                            <span class="badge bg-dark" title="Held by {{.Actor}} since {{formatTime .PlacedAt}}: {{.Reason}}">
+                                Held{{if not .ExpiresAt.IsZero}} until {{formatTime .ExpiresAt}}{{end}}
+                            </span>
+                            {{end}}
                         </td>
                         <td>
                             <div class="btn-group">
@@ -285,6 +317,20 @@ func BackupStatusPage(w http.ResponseWriter, r *http.Request) {
                                 </a>
                                 {{end}}
                                 
+                                <button class="btn btn-sm btn-outline-dark annotate-backup" data-id="{{.ID}}" title="Annotate Backup">
+                                    <i data-feather="edit-3"></i>
+                                </button>
+                                
+                                {{if .Hold}}
+                                <button class="btn btn-sm btn-dark release-hold" data-id="{{.ID}}" title="Release Hold">
+                                    <i data-feather="unlock"></i>
+                                </button>
+                                {{else}}
+                                <button class="btn btn-sm btn-outline-dark hold-backup" data-id="{{.ID}}" title="Hold Backup">
+                                    <i data-feather="lock"></i>
+                                </button>
+                                {{end}}
+                                
                                 <button class="btn btn-sm btn-outline-danger delete-backup" data-id="{{.ID}}" title="Delete Backup">
                                     <i data-feather="trash-2"></i>
                                 </button>
@@ -295,6 +341,18 @@ func BackupStatusPage(w http.ResponseWriter, r *http.Request) {
                 </tbody>
             </table>
         </div>
+        {{if or .Content.Paged .Content.NextPageLink}}
+        <nav aria-label="Backup pages">
+            <ul class="pagination justify-content-center mb-0">
+                <li class="page-item {{if not .Content.Paged}}disabled{{end}}">
+                    <a class="page-link" href="?{{.Content.FirstPageLink}}">First page</a>
+                </li>
+                <li class="page-item {{if not .Content.NextPageLink}}disabled{{end}}">
+                    <a class="page-link" href="?{{.Content.NextPageLink}}">Next page</a>
+                </li>
+            </ul>
+        </nav>
+        {{end}}
         {{else}}
         <p class="card-text text-muted">No backups found matching your criteria.</p>
         {{end}}
@@ -440,6 +498,90 @@ document.addEventListener('DOMContentLoaded', function() {
         });
     });
     
+    // Handle annotate backup buttons; an empty value removes the annotation
+    document.querySelectorAll('.annotate-backup').forEach(function(button) {
+        button.addEventListener('click', function() {
+            var backupId = this.dataset.id;
+            var input = prompt('Annotation as key=value (leave the value empty to remove it):');
+            if (!input) {
+                return;
+            }
+            var separator = input.indexOf('=');
+            if (separator < 1) {
+                alert('Annotations must be given as key=value');
+                return;
+            }
+            var changes = {};
+            changes[input.substring(0, separator).trim()] = input.substring(separator + 1).trim();
+            
+            fetch('/api/backups/annotations?id=' + encodeURIComponent(backupId), {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify(changes)
+            })
+            .then(function(response) {
+                if (!response.ok) {
+                    return response.text().then(function(text) { throw new Error(text); });
+                }
+                window.location.reload();
+            })
+            .catch(function(error) {
+                alert('Error: ' + error.message);
+            });
+        });
+    });
+    
+    // Post a hold change and reload the page once it is recorded
+    function postHold(url, body) {
+        fetch(url, {
+            method: 'POST',
+            headers: {'Content-Type': 'application/json'},
+            body: JSON.stringify(body)
+        })
+        .then(function(response) {
+            if (!response.ok) {
+                return response.text().then(function(text) { throw new Error(text); });
+            }
+            window.location.reload();
+        })
+        .catch(function(error) {
+            alert('Error: ' + error.message);
+        });
+    }
+    
+    // Handle hold buttons; retention keeps held backups until released
+    document.querySelectorAll('.hold-backup').forEach(function(button) {
+        button.addEventListener('click', function() {
+            var backupId = this.dataset.id;
+            var reason = prompt('Why must this backup be kept?');
+            if (!reason) {
+                return;
+            }
+            var body = {reason: reason};
+            var until = prompt('Hold until (YYYY-MM-DD), or leave empty to hold until released:');
+            if (until) {
+                var expiresAt = new Date(until + 'T00:00:00');
+                if (isNaN(expiresAt.getTime())) {
+                    alert('Invalid date: ' + until);
+                    return;
+                }
+                body.expiresAt = expiresAt.toISOString();
+            }
+            postHold('/api/backups/hold?id=' + encodeURIComponent(backupId), body);
+        });
+    });
+    
+    document.querySelectorAll('.release-hold').forEach(function(button) {
+        button.addEventListener('click', function() {
+            var backupId = this.dataset.id;
+            var reason = prompt('Why is the hold being released?');
+            if (reason === null) {
+                return;
+            }
+            postHold('/api/backups/hold/release?id=' + encodeURIComponent(backupId), {reason: reason});
+        });
+    });
+    
     // Initialize feather icons
     feather.replace();
     
@@ -512,7 +654,8 @@ function saveFilterPreferences() {
         status: document.getElementById('filterStatus').value,
         startDate: document.getElementById('filterStartDate').value,
         endDate: document.getElementById('filterEndDate').value,
-        search: document.getElementById('filterSearch').value
+        search: document.getElementById('filterSearch').value,
+        labels: document.getElementById('filterLabels').value
     };
     
     localStorage.setItem('gosqlguard_filters', JSON.stringify(filters));
@@ -529,7 +672,7 @@ function loadFilterPreferences() {
         var urlParams = new URLSearchParams(window.location.search);
         var hasUrlFilters = false;
         for (var pair of urlParams.entries()) {
-            if (['server', 'type', 'database', 'status', 'startDate', 'endDate', 'search'].includes(pair[0])) {
+            if (['server', 'type', 'database', 'status', 'from', 'to', 'search', 'labels', 'cursor'].includes(pair[0])) {
                 hasUrlFilters = true;
                 break;
             }
@@ -544,6 +687,7 @@ function loadFilterPreferences() {
             if (filters.startDate) document.getElementById('filterStartDate').value = filters.startDate;
             if (filters.endDate) document.getElementById('filterEndDate').value = filters.endDate;
             if (filters.search) document.getElementById('filterSearch').value = filters.search;
+            if (filters.labels) document.getElementById('filterLabels').value = filters.labels;
             
             // Auto-submit form to apply filters
             document.getElementById('filterForm').submit();
@@ -565,15 +709,13 @@ document.addEventListener('DOMContentLoaded', function() {
 		return
 	}
 
-	// Get filter parameters
-	filterType := r.URL.Query().Get("type")
-	filterDB := r.URL.Query().Get("database")
-	filterServer := r.URL.Query().Get("server")
-	filterStatus := r.URL.Query().Get("status")
-	filterActive := filterStatus == "active"
-	filterStartDate := r.URL.Query().Get("startDate")
-	filterEndDate := r.URL.Query().Get("endDate")
-	filterSearch := r.URL.Query().Get("search")
+	// Get filter parameters; the page takes the same ones as /api/v2/backups
+	values := r.URL.Query()
+	query, err := metadata.ParseBackupQuery(values)
+	if err != nil {
+		http.Error(w, "Invalid filter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Get data for the page
 	var data BackupStatusPageData
@@ -581,71 +723,41 @@ document.addEventListener('DOMContentLoaded', function() {
 	// Initialize backups list
 	data.Backups = []types.BackupMeta{}
 
-	// Get backups with applied filters
-	var filteredBackups []types.BackupMeta
-
 	// Get the appropriate metadata store
 	metadataStore := metadata.GetActiveStore()
 	if metadataStore != nil {
-		// Get backups with basic filters (don't use activeOnly if we have a specific status filter)
-		useActiveOnly := filterActive && filterStatus == ""
-		filteredBackups = metadataStore.GetBackupsFiltered(filterServer, filterDB, filterType, useActiveOnly)
-
-		// Apply additional filters
-		var finalBackups []types.BackupMeta
-
-		// Parse date filters
-		var startDate, endDate time.Time
-		if filterStartDate != "" {
-			startDate, _ = time.Parse("2006-01-02", filterStartDate)
-		}
-		if filterEndDate != "" {
-			endDate, _ = time.Parse("2006-01-02", filterEndDate)
-			// Add 23:59:59 to include the entire end date
-			endDate = endDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-		}
-
-		for _, backup := range filteredBackups {
-			// Apply status filter
-			if filterStatus != "" && filterStatus != "active" {
-				if string(backup.Status) != filterStatus {
-					continue
-				}
-			}
-
-			// Apply date range filter
-			if !startDate.IsZero() && backup.CreatedAt.Before(startDate) {
-				continue
-			}
-			if !endDate.IsZero() && backup.CreatedAt.After(endDate) {
-				continue
+		page, err := metadataStore.QueryBackups(query)
+		if err != nil {
+			log.Printf("Error querying backups: %v", err)
+		} else {
+			data.Backups = page.Backups
+			data.TotalMatching = page.Total
+			data.Paged = query.Cursor != ""
+
+			// Page links keep the filters and move the cursor
+			link := url.Values{}
+			for key, value := range values {
+				link[key] = value
 			}
-
-			// Apply search filter (case-insensitive)
-			if filterSearch != "" {
-				searchLower := strings.ToLower(filterSearch)
-				if !strings.Contains(strings.ToLower(backup.ID), searchLower) &&
-					!strings.Contains(strings.ToLower(backup.Database), searchLower) &&
-					!strings.Contains(strings.ToLower(backup.ServerName), searchLower) {
-					continue
-				}
+			link.Del("cursor")
+			data.FirstPageLink = template.URL(link.Encode())
+			if page.NextCursor != "" {
+				link.Set("cursor", page.NextCursor)
+				data.NextPageLink = template.URL(link.Encode())
 			}
-
-			finalBackups = append(finalBackups, backup)
 		}
-
-		data.Backups = finalBackups
 	}
 
 	// Set filter values for the form
-	data.FilterType = filterType
-	data.FilterDB = filterDB
-	data.FilterServer = filterServer
-	data.FilterActive = filterActive
-	data.FilterStatus = filterStatus
-	data.FilterStartDate = filterStartDate
-	data.FilterEndDate = filterEndDate
-	data.FilterSearch = filterSearch
+	data.FilterType = values.Get("type")
+	data.FilterDB = values.Get("database")
+	data.FilterServer = values.Get("server")
+	data.FilterStatus = values.Get("status")
+	data.FilterActive = data.FilterStatus == "active"
+	data.FilterStartDate = values.Get("from")
+	data.FilterEndDate = values.Get("to")
+	data.FilterSearch = values.Get("search")
+	data.FilterLabels = values.Get("labels")
 
 	// Collect unique server names for the server dropdown
 	serverSet := make(map[string]bool)
@@ -688,18 +800,19 @@ document.addEventListener('DOMContentLoaded', function() {
 	data.LastUpdated = time.Now()
 
 	// Get recent errors (last 10 failed backups) - only if we're not already filtering by error status
-	if filterStatus != "error" && metadataStore != nil {
-		allBackups := metadataStore.GetBackupsFiltered("", "", "", false)
-		var errors []types.BackupMeta
-		for _, backup := range allBackups {
-			if backup.Status == types.StatusError && backup.ErrorMessage != "" {
-				errors = append(errors, backup)
-				if len(errors) >= 10 {
-					break
-				}
+	if data.FilterStatus != "error" && metadataStore != nil {
+		page, err := metadataStore.QueryBackups(types.BackupQuery{
+			Statuses: []types.BackupStatus{types.StatusError},
+			Limit:    10,
+		})
+		if err != nil {
+			log.Printf("Error querying recent backup errors: %v", err)
+		}
+		for _, backup := range page.Backups {
+			if backup.ErrorMessage != "" {
+				data.RecentErrors = append(data.RecentErrors, backup)
 			}
 		}
-		data.RecentErrors = errors
 	}
 
 	// Render the template
@@ -191,6 +191,34 @@ func StorageStatusPage(w http.ResponseWriter, r *http.Request) {
     </div>
 </div>
 
+<!-- Storage Reconciliation -->
+<div class="card mb-4">
+    <div class="card-header d-flex justify-content-between align-items-center">
+        <h5 class="card-title mb-0">Storage Reconciliation</h5>
+        <button class="btn btn-sm btn-outline-primary" id="run-reconcile">Scan Storage</button>
+    </div>
+    <div class="card-body">
+        <p class="text-muted mb-3" id="reconcile-summary">
+            Compares the files in storage with the backup metadata. Files newer than the orphan grace period are not reported.
+        </p>
+        <div class="table-responsive">
+            <table class="table table-sm table-striped d-none" id="reconcile-table">
+                <thead>
+                    <tr>
+                        <th>Finding</th>
+                        <th>Storage</th>
+                        <th>Path</th>
+                        <th>Backup</th>
+                        <th>Size</th>
+                        <th>Actions</th>
+                    </tr>
+                </thead>
+                <tbody id="reconcile-findings"></tbody>
+            </table>
+        </div>
+    </div>
+</div>
+
 <!-- Storage Actions -->
 <div class="card">
     <div class="card-header">
@@ -236,6 +264,120 @@ document.addEventListener('DOMContentLoaded', () => {
         }
     });
     
+    // Storage reconciliation report
+    const findingLabels = {
+        missing: ['Missing', 'bg-danger'],
+        orphan: ['Orphan', 'bg-warning text-dark'],
+        sizeMismatch: ['Size mismatch', 'bg-info text-dark'],
+    };
+
+    const formatSize = (bytes) => {
+        const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+        let i = 0;
+        while (bytes >= 1024 && i < units.length - 1) {
+            bytes /= 1024;
+            i++;
+        }
+        return bytes.toFixed(i ? 2 : 0) + ' ' + units[i];
+    };
+
+    const cell = (row, text) => {
+        const td = row.insertCell();
+        td.textContent = text;
+        return td;
+    };
+
+    const actionButton = (td, label, style, request, question) => {
+        const button = document.createElement('button');
+        button.className = 'btn btn-sm me-1 ' + style;
+        button.textContent = label;
+        button.addEventListener('click', async () => {
+            if (!confirm(question)) {
+                return;
+            }
+            button.disabled = true;
+            try {
+                const response = await fetch('/api/storage/reconcile/action', {
+                    method: 'POST',
+                    headers: {'Content-Type': 'application/json'},
+                    body: JSON.stringify(request),
+                });
+                if (!response.ok) {
+                    throw new Error(await response.text());
+                }
+                td.parentElement.remove();
+            } catch (error) {
+                alert('Error: ' + error.message);
+                button.disabled = false;
+            }
+        });
+        td.appendChild(button);
+    };
+
+    const showReport = (report) => {
+        const findings = report.findings || [];
+        const scanned = Object.entries(report.scanned || {}).map(([location, count]) => count + ' ' + location).join(', ');
+        let summary = 'Last scan ' + new Date(report.completedAt).toLocaleString() + ' (' + (scanned || 'no storage') + ' files): ';
+        summary += findings.length ? findings.length + ' finding(s).' : 'storage matches the metadata.';
+        if (report.errors && report.errors.length) {
+            summary += ' Errors: ' + report.errors.join('; ');
+        }
+        document.getElementById('reconcile-summary').textContent = summary;
+
+        const body = document.getElementById('reconcile-findings');
+        body.replaceChildren();
+        for (const f of findings) {
+            const row = body.insertRow();
+            const [label, style] = findingLabels[f.kind] || [f.kind, 'bg-secondary'];
+            const badge = document.createElement('span');
+            badge.className = 'badge ' + style;
+            badge.textContent = label;
+            row.insertCell().appendChild(badge);
+            cell(row, f.location);
+            cell(row, f.path).classList.add('text-break');
+            cell(row, f.backupId || '-');
+            let size = f.kind === 'missing' ? '-' : formatSize(f.size);
+            if (f.kind === 'sizeMismatch') {
+                size += ' (recorded ' + formatSize(f.recordedSize) + ')';
+            }
+            cell(row, size);
+
+            const actions = row.insertCell();
+            const target = {location: f.location, path: f.path, backupId: f.backupId};
+            if (f.kind === 'orphan') {
+                actionButton(actions, 'Import', 'btn-outline-success', {...target, action: 'import'}, 'Add ' + f.path + ' to the backup metadata?');
+                actionButton(actions, 'Delete', 'btn-outline-danger', {...target, action: 'delete'}, 'Permanently delete ' + f.path + '?');
+            } else if (f.kind === 'missing') {
+                actionButton(actions, 'Mark missing', 'btn-outline-warning', {...target, action: 'markMissing'}, 'Remove ' + f.path + ' from backup ' + f.backupId + '?');
+            }
+        }
+        document.getElementById('reconcile-table').classList.toggle('d-none', findings.length === 0);
+    };
+
+    fetch('/api/storage/reconcile').then((response) => response.ok ? response.json() : null).then((report) => {
+        if (report) {
+            showReport(report);
+        }
+    });
+
+    document.getElementById('run-reconcile').addEventListener('click', async () => {
+        const button = document.getElementById('run-reconcile');
+        button.disabled = true;
+        button.textContent = 'Scanning...';
+        try {
+            const response = await fetch('/api/storage/reconcile', {method: 'POST'});
+            if (!response.ok) {
+                throw new Error(await response.text());
+            }
+            showReport(await response.json());
+        } catch (error) {
+            alert('Error: ' + error.message);
+        } finally {
+            button.disabled = false;
+            button.textContent = 'Scan Storage';
+        }
+    });
+
     // Handle refresh button
     document.getElementById('refresh-metadata').addEventListener('click', () => {
         window.location.reload();
@@ -299,7 +441,7 @@ document.addEventListener('DOMContentLoaded', () => {
 		backups := metadata.DefaultStore.GetBackups()
 		s3Count := 0
 		for _, backup := range backups {
-			if backup.S3UploadStatus == types.StatusSuccess {
+			if backup.S3UploadStatus == types.StatusSuccess && backup.S3DeletedAt.IsZero() {
 				s3Count++
 			}
 		}
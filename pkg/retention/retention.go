@@ -0,0 +1,270 @@
+// Package retention decides which backups to keep from backup metadata.
+package retention
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+// Storage locations a retention rule applies to
+const (
+	LocationLocal = "local"
+	LocationS3    = "s3"
+)
+
+// Decision records whether a backup is kept in a storage location and why
+type Decision struct {
+	BackupID   string    `json:"backupId"`
+	ServerName string    `json:"serverName"`
+	Database   string    `json:"database"`
+	BackupType string    `json:"backupType"`
+	Location   string    `json:"location"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Size       int64     `json:"size"`
+	Keep       bool      `json:"keep"`
+	Reasons    []string  `json:"reasons"`
+}
+
+// Plan is the set of retention decisions for all backups at a point in time
+type Plan struct {
+	GeneratedAt time.Time  `json:"generatedAt"`
+	Decisions   []Decision `json:"decisions"`
+}
+
+// Deletions returns the decisions that delete a backup
+func (p Plan) Deletions() []Decision {
+	var deletions []Decision
+	for _, d := range p.Decisions {
+		if !d.Keep {
+			deletions = append(deletions, d)
+		}
+	}
+	return deletions
+}
+
+// BuildPlan evaluates the retention rules of every backup type against the
+// given backups. Only successful backups stored in a location are considered
+// for that location, and each server/database pair is evaluated on its own,
+// under the first label rule matching the labels of its newest backup.
+func BuildPlan(backups []types.BackupMeta, backupTypes map[string]config.BackupTypeConfig, now time.Time) Plan {
+	plan := Plan{GeneratedAt: now}
+
+	for backupType, typeConfig := range backupTypes {
+		if typeConfig.Local.Enabled {
+			candidates := filterCandidates(backups, backupType, LocationLocal)
+			plan.Decisions = append(plan.Decisions, evaluateGroups(candidates, typeConfig.Local.Retention, typeConfig.Local.LabelRules, LocationLocal, now)...)
+		}
+		if typeConfig.S3.Enabled {
+			candidates := filterCandidates(backups, backupType, LocationS3)
+			plan.Decisions = append(plan.Decisions, evaluateGroups(candidates, typeConfig.S3.Retention, typeConfig.S3.LabelRules, LocationS3, now)...)
+		}
+	}
+
+	// Keep the output stable for previews
+	sort.Slice(plan.Decisions, func(i, j int) bool {
+		a, b := plan.Decisions[i], plan.Decisions[j]
+		if a.ServerName != b.ServerName {
+			return a.ServerName < b.ServerName
+		}
+		if a.Database != b.Database {
+			return a.Database < b.Database
+		}
+		if a.BackupType != b.BackupType {
+			return a.BackupType < b.BackupType
+		}
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+
+	return plan
+}
+
+// StoredIn reports whether a successful backup has a copy in the location
+func StoredIn(b types.BackupMeta, location string) bool {
+	switch location {
+	case LocationLocal:
+		return b.Status == types.StatusSuccess && (len(b.LocalPaths) > 0 || b.LocalPath != "")
+	case LocationS3:
+		return b.S3UploadStatus == types.StatusSuccess && (len(b.S3Keys) > 0 || b.S3Key != "")
+	default:
+		return false
+	}
+}
+
+// filterCandidates returns the backups of a type that exist in a location
+func filterCandidates(backups []types.BackupMeta, backupType, location string) []types.BackupMeta {
+	var candidates []types.BackupMeta
+	for _, b := range backups {
+		if b.BackupType == backupType && StoredIn(b, location) {
+			candidates = append(candidates, b)
+		}
+	}
+	return candidates
+}
+
+// evaluateGroups applies the rule to each server/database pair separately.
+// Label rules are chosen by the newest backup, so relabeling a database
+// changes the retention of its older backups too.
+func evaluateGroups(backups []types.BackupMeta, rule config.RetentionRule, labelRules []config.LabeledRetentionRule, location string, now time.Time) []Decision {
+	groups := make(map[string][]types.BackupMeta)
+	for _, b := range backups {
+		key := b.ServerName + "\x00" + b.Database
+		groups[key] = append(groups[key], b)
+	}
+
+	var decisions []Decision
+	for _, group := range groups {
+		newest := group[0]
+		for _, b := range group[1:] {
+			if b.CreatedAt.After(newest.CreatedAt) {
+				newest = b
+			}
+		}
+		groupRule := config.RetentionFor(rule, labelRules, newest.Labels)
+		decisions = append(decisions, Evaluate(group, groupRule, location, now)...)
+	}
+	return decisions
+}
+
+// Evaluate applies a retention rule to the backups of a single server and
+// database. A backup is kept when any rule selects it.
+func Evaluate(backups []types.BackupMeta, rule config.RetentionRule, location string, now time.Time) []Decision {
+	sorted := make([]types.BackupMeta, len(backups))
+	copy(sorted, backups)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	reasons := make([][]string, len(sorted))
+	keep := func(i int, reason string) {
+		reasons[i] = append(reasons[i], reason)
+	}
+
+	// Age-based rule
+	var maxAge time.Duration
+	if rule.Duration != "" {
+		if d, err := time.ParseDuration(rule.Duration); err == nil {
+			maxAge = d
+		}
+	}
+
+	// An empty or unparseable rule keeps everything, as before count rules existed
+	keepAll := rule.Forever || (maxAge == 0 && !rule.HasCountRules())
+
+	for i, b := range sorted {
+		switch {
+		case rule.Forever:
+			keep(i, "keep forever")
+		case keepAll:
+			keep(i, "no retention rule configured")
+		case maxAge > 0 && now.Sub(b.CreatedAt) <= maxAge:
+			keep(i, fmt.Sprintf("within %s", rule.Duration))
+		}
+
+		if i < rule.KeepLast {
+			keep(i, fmt.Sprintf("last %d", rule.KeepLast))
+		}
+	}
+
+	// Grandfather-father-son rules keep the newest backup of each period
+	for _, period := range []struct {
+		count int
+		name  string
+		key   func(time.Time) string
+	}{
+		{rule.KeepDaily, "daily", func(t time.Time) string { return t.Format("2006-01-02") }},
+		{rule.KeepWeekly, "weekly", func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{rule.KeepMonthly, "monthly", func(t time.Time) string { return t.Format("2006-01") }},
+		{rule.KeepYearly, "yearly", func(t time.Time) string { return t.Format("2006") }},
+	} {
+		if period.count <= 0 {
+			continue
+		}
+
+		kept := 0
+		lastKey := ""
+		for i, b := range sorted {
+			if kept >= period.count {
+				break
+			}
+			key := period.key(b.CreatedAt.Local())
+			if key == lastKey {
+				continue
+			}
+			lastKey = key
+			kept++
+			keep(i, fmt.Sprintf("%s %s", period.name, key))
+		}
+	}
+
+	// Never delete the most recent successful backups of a database
+	minKeep := rule.MinKeep
+	if minKeep < 1 {
+		minKeep = 1
+	}
+	for i := 0; i < len(sorted) && i < minKeep; i++ {
+		if len(reasons[i]) == 0 {
+			keep(i, fmt.Sprintf("minimum keep %d", minKeep))
+		}
+	}
+
+	// Held backups are kept whatever the rule says
+	for i, b := range sorted {
+		if b.IsHeld(now) {
+			keep(i, holdReason(*b.Hold))
+		}
+	}
+
+	decisions := make([]Decision, len(sorted))
+	for i, b := range sorted {
+		d := Decision{
+			BackupID:   b.ID,
+			ServerName: b.ServerName,
+			Database:   b.Database,
+			BackupType: b.BackupType,
+			Location:   location,
+			CreatedAt:  b.CreatedAt,
+			Size:       b.Size,
+			Keep:       len(reasons[i]) > 0,
+			Reasons:    reasons[i],
+		}
+		if !d.Keep {
+			d.Reasons = []string{deleteReason(rule, maxAge)}
+		}
+		decisions[i] = d
+	}
+
+	return decisions
+}
+
+// holdReason explains the hold keeping a backup
+func holdReason(hold types.BackupHold) string {
+	reason := "held by " + hold.Actor
+	if !hold.ExpiresAt.IsZero() {
+		reason += " until " + hold.ExpiresAt.Format(time.RFC3339)
+	}
+	if hold.Reason != "" {
+		reason += ": " + hold.Reason
+	}
+	return reason
+}
+
+// deleteReason explains why a backup was not kept
+func deleteReason(rule config.RetentionRule, maxAge time.Duration) string {
+	if maxAge > 0 && rule.HasCountRules() {
+		return fmt.Sprintf("older than %s and not selected by any count rule", rule.Duration)
+	}
+	if maxAge > 0 {
+		return fmt.Sprintf("older than %s", rule.Duration)
+	}
+	return "not selected by any count rule"
+}
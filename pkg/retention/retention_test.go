@@ -0,0 +1,264 @@
+package retention
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+// testNow is a fixed reference time for retention decisions
+var testNow = time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+
+// makeBackups creates successful local and S3 backups for one database,
+// one every interval going back from testNow
+func makeBackups(server, database, backupType string, count int, interval time.Duration) []types.BackupMeta {
+	backups := make([]types.BackupMeta, count)
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("%s-%s-%s-%d", server, database, backupType, i)
+		backups[i] = types.BackupMeta{
+			ID:             id,
+			ServerName:     server,
+			Database:       database,
+			BackupType:     backupType,
+			CreatedAt:      testNow.Add(-time.Duration(i) * interval),
+			Status:         types.StatusSuccess,
+			S3UploadStatus: types.StatusSuccess,
+			LocalPaths:     map[string]string{"by-server": "/backups/" + id + ".sql.gz"},
+			S3Keys:         map[string]string{"by-server": "backups/" + id + ".sql.gz"},
+		}
+	}
+	return backups
+}
+
+// keptIDs returns the IDs of kept backups in decision order
+func keptIDs(decisions []Decision) []string {
+	var ids []string
+	for _, d := range decisions {
+		if d.Keep {
+			ids = append(ids, d.BackupID)
+		}
+	}
+	return ids
+}
+
+func TestEvaluateKeepLast(t *testing.T) {
+	backups := makeBackups("db1", "app", "hourly", 5, time.Hour)
+
+	decisions := Evaluate(backups, config.RetentionRule{KeepLast: 2}, LocationLocal, testNow)
+
+	kept := keptIDs(decisions)
+	if strings.Join(kept, ",") != "db1-app-hourly-0,db1-app-hourly-1" {
+		t.Errorf("kept = %v, want the two newest backups", kept)
+	}
+	for _, d := range decisions[2:] {
+		if d.Keep || d.Reasons[0] != "not selected by any count rule" {
+			t.Errorf("decision for %s = %+v, want delete with a reason", d.BackupID, d)
+		}
+	}
+}
+
+func TestEvaluateKeepsHeldBackups(t *testing.T) {
+	backups := makeBackups("db1", "app", "hourly", 4, time.Hour)
+	backups[2].Hold = &types.BackupHold{Reason: "litigation", Actor: "legal", PlacedAt: testNow}
+	backups[3].Hold = &types.BackupHold{Reason: "audit", Actor: "legal", PlacedAt: testNow.Add(-48 * time.Hour), ExpiresAt: testNow.Add(-time.Hour)}
+
+	decisions := Evaluate(backups, config.RetentionRule{KeepLast: 1}, LocationLocal, testNow)
+
+	kept := keptIDs(decisions)
+	if strings.Join(kept, ",") != "db1-app-hourly-0,db1-app-hourly-2" {
+		t.Errorf("kept = %v, want the newest and the held backup", kept)
+	}
+	if reasons := decisions[2].Reasons; len(reasons) != 1 || reasons[0] != "held by legal: litigation" {
+		t.Errorf("reasons = %v, want the hold", reasons)
+	}
+	if decisions[3].Keep {
+		t.Error("a backup whose hold expired was kept")
+	}
+}
+
+func TestEvaluateGFS(t *testing.T) {
+	// Two backups a day for 60 days
+	backups := makeBackups("db1", "app", "daily", 120, 12*time.Hour)
+
+	rule := config.RetentionRule{KeepDaily: 3, KeepWeekly: 2, KeepMonthly: 2}
+	decisions := Evaluate(backups, rule, LocationLocal, testNow)
+
+	kept := 0
+	for _, d := range decisions {
+		if !d.Keep {
+			continue
+		}
+		kept++
+
+		// Only the newest backup of a period may be kept
+		if d.CreatedAt.Hour() != testNow.Hour() {
+			t.Errorf("kept %s from %s, want the newest backup of each day", d.BackupID, d.CreatedAt)
+		}
+	}
+
+	// Oct 18-16 (daily), Oct 11 (weekly) and Sep 30 (monthly)
+	if kept != 5 {
+		t.Errorf("kept %d backups, want 5", kept)
+	}
+
+	// The newest backup satisfies the daily, weekly and monthly rules at once
+	newest := decisions[0]
+	if len(newest.Reasons) != 3 {
+		t.Errorf("newest backup reasons = %v, want daily, weekly and monthly", newest.Reasons)
+	}
+
+	// The second monthly pick is the newest backup from the previous month
+	previousMonth := testNow.AddDate(0, -1, 0).Format("2006-01")
+	found := false
+	for _, d := range decisions {
+		for _, reason := range d.Reasons {
+			if reason == "monthly "+previousMonth {
+				found = true
+				if d.CreatedAt.Format("2006-01") != previousMonth || d.CreatedAt.Day() != 30 {
+					t.Errorf("monthly pick for %s is %s, want the last day of that month", previousMonth, d.CreatedAt)
+				}
+			}
+		}
+	}
+	if !found {
+		t.Errorf("no monthly pick for %s", previousMonth)
+	}
+}
+
+func TestEvaluateDurationAndCounts(t *testing.T) {
+	backups := makeBackups("db1", "app", "daily", 10, 24*time.Hour)
+
+	rule := config.RetentionRule{Duration: "72h", KeepWeekly: 2}
+	decisions := Evaluate(backups, rule, LocationS3, testNow)
+
+	for _, d := range decisions {
+		age := testNow.Sub(d.CreatedAt)
+		if age <= 72*time.Hour && !d.Keep {
+			t.Errorf("%s is within the duration but was not kept", d.BackupID)
+		}
+		if !d.Keep && d.Reasons[0] != "older than 72h and not selected by any count rule" {
+			t.Errorf("%s delete reason = %q", d.BackupID, d.Reasons[0])
+		}
+		if d.Location != LocationS3 {
+			t.Errorf("%s location = %s, want s3", d.BackupID, d.Location)
+		}
+	}
+}
+
+func TestEvaluateMinimumKeep(t *testing.T) {
+	backups := makeBackups("db1", "app", "daily", 4, 30*24*time.Hour)
+
+	// Every backup is past the duration, but the newest is always kept
+	later := testNow.Add(48 * time.Hour)
+	decisions := Evaluate(backups, config.RetentionRule{Duration: "24h"}, LocationLocal, later)
+	kept := keptIDs(decisions)
+	if len(kept) != 1 || kept[0] != "db1-app-daily-0" {
+		t.Fatalf("kept = %v, want only the newest backup", kept)
+	}
+	if decisions[0].Reasons[0] != "minimum keep 1" {
+		t.Errorf("reason = %v, want minimum keep", decisions[0].Reasons)
+	}
+
+	decisions = Evaluate(backups, config.RetentionRule{Duration: "24h", MinKeep: 3}, LocationLocal, later)
+	if len(keptIDs(decisions)) != 3 {
+		t.Errorf("kept = %v, want the three newest backups", keptIDs(decisions))
+	}
+}
+
+func TestEvaluateKeepsEverythingWithoutRules(t *testing.T) {
+	backups := makeBackups("db1", "app", "manual", 3, 365*24*time.Hour)
+
+	for name, rule := range map[string]config.RetentionRule{
+		"forever": {Forever: true, KeepLast: 1},
+		"empty":   {},
+		"invalid": {Duration: "7d"},
+	} {
+		decisions := Evaluate(backups, rule, LocationLocal, testNow)
+		if len(keptIDs(decisions)) != len(backups) {
+			t.Errorf("%s: kept %v, want every backup", name, keptIDs(decisions))
+		}
+	}
+}
+
+func TestBuildPlanGroupsByDatabaseAndLocation(t *testing.T) {
+	backups := append(makeBackups("db1", "app", "hourly", 3, time.Hour),
+		makeBackups("db2", "app", "hourly", 3, time.Hour)...)
+
+	// A failed backup and one that never reached S3 are not candidates there
+	backups = append(backups, types.BackupMeta{
+		ID: "failed", ServerName: "db1", Database: "app", BackupType: "hourly",
+		CreatedAt: testNow, Status: types.StatusError,
+	})
+	backups[0].S3UploadStatus = types.StatusError
+
+	backupTypes := map[string]config.BackupTypeConfig{
+		"hourly": {
+			Local: config.LocalBackupConfig{Enabled: true, Retention: config.RetentionRule{KeepLast: 1}},
+			S3:    config.S3BackupConfig{Enabled: true, Retention: config.RetentionRule{Forever: true}},
+		},
+		"daily": {
+			Local: config.LocalBackupConfig{Enabled: true, Retention: config.RetentionRule{KeepLast: 1}},
+		},
+	}
+
+	plan := BuildPlan(backups, backupTypes, testNow)
+
+	// Each server keeps its newest local backup and deletes the other two
+	deletions := plan.Deletions()
+	if len(deletions) != 4 {
+		t.Fatalf("deletions = %d, want 4", len(deletions))
+	}
+	for _, d := range deletions {
+		if d.Location != LocationLocal {
+			t.Errorf("unexpected %s deletion of %s", d.Location, d.BackupID)
+		}
+		if strings.HasSuffix(d.BackupID, "-0") {
+			t.Errorf("newest backup %s was deleted", d.BackupID)
+		}
+	}
+
+	// 6 local decisions and 5 S3 decisions; the failed backup is skipped
+	if len(plan.Decisions) != 11 {
+		t.Errorf("decisions = %d, want 11", len(plan.Decisions))
+	}
+	for _, d := range plan.Decisions {
+		if d.BackupID == "failed" || (d.BackupID == "db1-app-hourly-0" && d.Location == LocationS3) {
+			t.Errorf("unexpected decision for %s in %s", d.BackupID, d.Location)
+		}
+	}
+}
+
+func TestBuildPlanLabelRules(t *testing.T) {
+	backups := append(makeBackups("db1", "orders", "hourly", 3, time.Hour),
+		makeBackups("db1", "users", "hourly", 3, time.Hour)...)
+	for i := range backups[:3] {
+		backups[i].Labels = map[string]string{"team": "payments"}
+	}
+
+	backupTypes := map[string]config.BackupTypeConfig{
+		"hourly": {
+			Local: config.LocalBackupConfig{
+				Enabled:   true,
+				Retention: config.RetentionRule{KeepLast: 1},
+				LabelRules: []config.LabeledRetentionRule{
+					{Selector: map[string]string{"team": "payments"}, Retention: config.RetentionRule{KeepLast: 3}},
+				},
+			},
+		},
+	}
+
+	// Only the unlabeled database falls back to the backup type's rule
+	deletions := BuildPlan(backups, backupTypes, testNow).Deletions()
+	if len(deletions) != 2 {
+		t.Fatalf("deletions = %d, want 2", len(deletions))
+	}
+	for _, d := range deletions {
+		if !strings.HasPrefix(d.BackupID, "db1-users-") {
+			t.Errorf("unexpected deletion of %s", d.BackupID)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metrics"
+)
+
+// Control holds operator overrides of a schedule. They take effect when the
+// schedule fires, so changing them never re-registers cron entries.
+type Control struct {
+	Paused      bool
+	PausedAt    time.Time
+	PausedUntil time.Time // Zero keeps the schedule paused until it is resumed
+	Reason      string
+	SkipNextAt  time.Time // Fire time of the next run to skip
+}
+
+// IsPaused reports whether the schedule is paused at t, taking an automatic
+// resume time into account
+func (c Control) IsPaused(t time.Time) bool {
+	return c.Paused && (c.PausedUntil.IsZero() || t.Before(c.PausedUntil))
+}
+
+// skips reports whether a run starting at t is the occurrence marked to be
+// skipped. A marked occurrence that passed without firing no longer applies.
+func (c Control) skips(t time.Time) bool {
+	if c.SkipNextAt.IsZero() || t.Before(c.SkipNextAt) {
+		return false
+	}
+	return t.Sub(c.SkipNextAt) <= missedRunGrace
+}
+
+// ControlFromSchedule returns the overrides stored on a database schedule
+func ControlFromSchedule(schedule *dbmeta.BackupSchedule) Control {
+	c := Control{Paused: schedule.Paused, Reason: schedule.PauseReason}
+	if schedule.PausedAt != nil {
+		c.PausedAt = *schedule.PausedAt
+	}
+	if schedule.PausedUntil != nil {
+		c.PausedUntil = *schedule.PausedUntil
+	}
+	if schedule.SkipNextAt != nil {
+		c.SkipNextAt = *schedule.SkipNextAt
+	}
+	return c
+}
+
+// SetControl replaces the overrides of a backup type
+func (s *Scheduler) SetControl(backupType string, c Control) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, ok := s.resumeTimers[backupType]; ok {
+		timer.Stop()
+		delete(s.resumeTimers, backupType)
+	}
+	if c == (Control{}) {
+		delete(s.controls, backupType)
+	} else {
+		s.controls[backupType] = c
+	}
+	s.updatePauseMetrics(backupType, c)
+
+	// Clear the paused metric when the schedule resumes by itself
+	if c.IsPaused(time.Now()) && !c.PausedUntil.IsZero() {
+		s.resumeTimers[backupType] = time.AfterFunc(time.Until(c.PausedUntil), func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.resumeTimers, backupType)
+			log.Printf("Pause of %s backups ended, resuming the schedule", backupType)
+			s.updatePauseMetrics(backupType, s.controls[backupType])
+		})
+	}
+}
+
+// ReplaceControls sets the overrides of every backup type at once, clearing
+// those of backup types that are not listed
+func (s *Scheduler) ReplaceControls(controls map[string]Control) {
+	s.mu.Lock()
+	var cleared []string
+	for backupType := range s.controls {
+		if _, ok := controls[backupType]; !ok {
+			cleared = append(cleared, backupType)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, backupType := range cleared {
+		s.SetControl(backupType, Control{})
+	}
+	for backupType, c := range controls {
+		s.SetControl(backupType, c)
+	}
+}
+
+// GetControl returns the overrides of a backup type
+func (s *Scheduler) GetControl(backupType string) Control {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.controls[backupType]
+}
+
+// SkipNext marks the next run of a backup type to be skipped and returns its
+// scheduled time
+func (s *Scheduler) SkipNext(backupType string) (time.Time, error) {
+	runs, err := s.UpcomingRuns(backupType, time.Now(), 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(runs) == 0 {
+		return time.Time{}, fmt.Errorf("backup type %s has no future runs", backupType)
+	}
+
+	s.mu.Lock()
+	c := s.controls[backupType]
+	c.SkipNextAt = runs[0].Time
+	s.controls[backupType] = c
+	s.mu.Unlock()
+
+	log.Printf("The %s backup scheduled at %s will be skipped", backupType, runs[0].Time.Format(time.RFC3339))
+	return runs[0].Time, nil
+}
+
+// checkControl reports why a run starting at start must not go ahead, if it
+// must not. A skipped occurrence is cleared so later runs go ahead.
+func (s *Scheduler) checkControl(backupType string, start time.Time) (reason, detail string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.controls[backupType]
+	if c.IsPaused(start) {
+		detail = "schedule paused"
+		if c.Reason != "" {
+			detail += ": " + c.Reason
+		}
+		return "paused", detail
+	}
+	if c.skips(start) {
+		c.SkipNextAt = time.Time{}
+		s.controls[backupType] = c
+		return "skip_next", "occurrence skipped by an operator"
+	}
+	return "", ""
+}
+
+// stopResumeTimers stops the timers that clear the paused metric. Callers hold mu.
+func (s *Scheduler) stopResumeTimers() {
+	for backupType, timer := range s.resumeTimers {
+		timer.Stop()
+		delete(s.resumeTimers, backupType)
+	}
+}
+
+// updatePauseMetrics publishes whether a backup type is paused. Callers hold mu.
+func (s *Scheduler) updatePauseMetrics(backupType string, c Control) {
+	if !c.IsPaused(time.Now()) {
+		metrics.SchedulePaused.WithLabelValues(backupType).Set(0)
+		metrics.SchedulePausedSince.DeleteLabelValues(backupType)
+		return
+	}
+
+	metrics.SchedulePaused.WithLabelValues(backupType).Set(1)
+	pausedAt := c.PausedAt
+	if pausedAt.IsZero() {
+		pausedAt = time.Now()
+	}
+	metrics.SchedulePausedSince.WithLabelValues(backupType).Set(float64(pausedAt.Unix()))
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"log"
+
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+)
+
+// Leadership tells the scheduler whether this replica may run scheduled jobs
+// when several replicas share a metadata database
+type Leadership interface {
+	IsLeader() bool
+	Token() int64
+	Fence(token int64) error
+}
+
+// SetLeadership makes scheduled jobs run only while this replica leads. Without
+// it every job runs, as for a single replica.
+func (s *Scheduler) SetLeadership(l Leadership) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leadership = l
+}
+
+// currentLeadership returns the leadership, or nil when running standalone
+func (s *Scheduler) currentLeadership() Leadership {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leadership
+}
+
+// isLeader reports whether this replica may run scheduled jobs
+func (s *Scheduler) isLeader() bool {
+	l := s.currentLeadership()
+	return l == nil || l.IsLeader()
+}
+
+// superseded reports whether a run state was last recorded by a newer leader
+// than this replica, and otherwise stamps it with this replica's token.
+// Callers hold stateMu.
+func (s *Scheduler) superseded(state *dbmeta.ScheduleRunState) bool {
+	l := s.currentLeadership()
+	if l == nil {
+		return false
+	}
+	token := l.Token()
+	if state.FencingToken > token {
+		log.Printf("Ignoring run state of %s schedule: recorded by a newer leader (token %d, ours %d)",
+			state.BackupType, state.FencingToken, token)
+		return true
+	}
+	state.FencingToken = token
+	return false
+}
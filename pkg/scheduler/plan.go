@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+)
+
+// maxPlannedRuns bounds how many fire times are projected for one schedule
+const maxPlannedRuns = 1000
+
+// PlannedRun is a projected future run of a schedule
+type PlannedRun struct {
+	BackupType   string
+	Time         time.Time
+	Duration     time.Duration // Expected duration from the last run; zero when unknown
+	Blackout     string        // Blackout window the run falls in, if any
+	Action       string        // What the blackout does to the run: skip or defer
+	OverlapsWith []string      // Other schedules expected to be running at the same time
+}
+
+// End returns when the run is expected to finish
+func (p PlannedRun) End() time.Time {
+	return p.Time.Add(p.Duration)
+}
+
+// overlaps reports whether two planned runs are expected to run at the same
+// time. Runs of unknown duration only overlap runs starting at the same time.
+func (p PlannedRun) overlaps(other PlannedRun) bool {
+	if p.Time.Equal(other.Time) {
+		return true
+	}
+	return p.Time.Before(other.End()) && other.Time.Before(p.End())
+}
+
+// LastRunDuration returns how long a schedule's last finished run took, or
+// zero when it has not finished one since it last started
+func LastRunDuration(state *dbmeta.ScheduleRunState) time.Duration {
+	if state == nil || state.LastStartedAt == nil || state.LastCompletedAt == nil {
+		return 0
+	}
+	if state.LastCompletedAt.Before(*state.LastStartedAt) {
+		return 0
+	}
+	return state.LastCompletedAt.Sub(*state.LastStartedAt)
+}
+
+// ScheduledTypes returns the backup types that have a cron entry, sorted
+func (s *Scheduler) ScheduledTypes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	types := make([]string, 0, len(s.jobIDs))
+	for backupType := range s.jobIDs {
+		types = append(types, backupType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Location returns the time zone a backup type's schedule runs in
+func (s *Scheduler) Location(backupType string) *time.Location {
+	return s.location(s.cfg.BackupTypes[backupType])
+}
+
+// entrySchedule returns the cron schedule registered for a backup type
+func (s *Scheduler) entrySchedule(backupType string) (cron.Schedule, error) {
+	s.mu.Lock()
+	jobID, ok := s.jobIDs[backupType]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no scheduled job found for backup type: %s", backupType)
+	}
+
+	entry := s.cronScheduler.Entry(jobID)
+	if !entry.Valid() {
+		return nil, fmt.Errorf("no scheduled job found for backup type: %s", backupType)
+	}
+	return entry.Schedule, nil
+}
+
+// UpcomingRuns returns the next n runs of a backup type after the given time
+func (s *Scheduler) UpcomingRuns(backupType string, after time.Time, n int) ([]PlannedRun, error) {
+	return s.plannedRuns(backupType, after, time.Time{}, n)
+}
+
+// PlanRuns returns every run scheduled between from and to across all
+// schedules, sorted by time, with the schedules each is expected to overlap
+func (s *Scheduler) PlanRuns(from, to time.Time) ([]PlannedRun, error) {
+	var runs []PlannedRun
+	for _, backupType := range s.ScheduledTypes() {
+		planned, err := s.plannedRuns(backupType, from, to, maxPlannedRuns)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, planned...)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		if runs[i].Time.Equal(runs[j].Time) {
+			return runs[i].BackupType < runs[j].BackupType
+		}
+		return runs[i].Time.Before(runs[j].Time)
+	})
+
+	// Runs are sorted by start, so only later runs starting before this one
+	// ends can overlap it
+	for i := range runs {
+		for j := i + 1; j < len(runs); j++ {
+			if runs[j].Time.After(runs[i].End()) {
+				break
+			}
+			if runs[i].BackupType == runs[j].BackupType || !runs[i].overlaps(runs[j]) {
+				continue
+			}
+			runs[i].OverlapsWith = appendUnique(runs[i].OverlapsWith, runs[j].BackupType)
+			runs[j].OverlapsWith = appendUnique(runs[j].OverlapsWith, runs[i].BackupType)
+		}
+	}
+	return runs, nil
+}
+
+// plannedRuns projects up to limit runs of a backup type after from, stopping
+// at to unless it is zero
+func (s *Scheduler) plannedRuns(backupType string, from, to time.Time, limit int) ([]PlannedRun, error) {
+	schedule, err := s.entrySchedule(backupType)
+	if err != nil {
+		return nil, err
+	}
+
+	typeConfig := s.cfg.BackupTypes[backupType]
+	loc := s.location(typeConfig)
+
+	s.stateMu.Lock()
+	state, err := s.runStates.GetRunState(backupType)
+	s.stateMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run state of %s schedule: %w", backupType, err)
+	}
+	duration := LastRunDuration(state)
+
+	var runs []PlannedRun
+	for next := schedule.Next(from); !next.IsZero() && len(runs) < limit; next = schedule.Next(next) {
+		if !to.IsZero() && next.After(to) {
+			break
+		}
+		run := PlannedRun{BackupType: backupType, Time: next, Duration: duration}
+		if window, _, active := typeConfig.ActiveBlackout(next, loc); active {
+			run.Blackout = window.Description()
+			run.Action = window.ActionOrDefault()
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// appendUnique appends value to list unless it is already present
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
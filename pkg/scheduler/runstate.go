@@ -0,0 +1,286 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metrics"
+)
+
+const (
+	// missedRunGrace keeps a run that is just firing from being counted as missed
+	missedRunGrace = 2 * time.Minute
+
+	// maxMissedScan bounds how many fire times a single check walks through
+	maxMissedScan = 10000
+
+	// missedRunCheckSchedule is how often missed runs are looked for while running
+	missedRunCheckSchedule = "*/5 * * * *"
+)
+
+// RunStateStore persists when each schedule last fired and finished
+type RunStateStore interface {
+	GetRunState(backupType string) (*dbmeta.ScheduleRunState, error)
+	SaveRunState(state *dbmeta.ScheduleRunState) error
+	ListRunStates() ([]dbmeta.ScheduleRunState, error)
+}
+
+// memoryRunStateStore keeps run states in memory when no metadata database is
+// configured. Missed runs are then only detected while the process stays up.
+type memoryRunStateStore struct {
+	mu     sync.Mutex
+	states map[string]dbmeta.ScheduleRunState
+}
+
+func newMemoryRunStateStore() *memoryRunStateStore {
+	return &memoryRunStateStore{states: make(map[string]dbmeta.ScheduleRunState)}
+}
+
+func (m *memoryRunStateStore) GetRunState(backupType string) (*dbmeta.ScheduleRunState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.states[backupType]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (m *memoryRunStateStore) SaveRunState(state *dbmeta.ScheduleRunState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state.UpdatedAt = time.Now()
+	m.states[state.BackupType] = *state
+	return nil
+}
+
+func (m *memoryRunStateStore) ListRunStates() ([]dbmeta.ScheduleRunState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	states := make([]dbmeta.ScheduleRunState, 0, len(m.states))
+	for _, state := range m.states {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].BackupType < states[j].BackupType })
+	return states, nil
+}
+
+// SetRunStateStore replaces the in-memory run state store, typically with one
+// backed by the metadata database so missed runs survive a restart
+func (s *Scheduler) SetRunStateStore(store RunStateStore) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.runStates = store
+}
+
+// RunStates returns the recorded run state of every schedule
+func (s *Scheduler) RunStates() ([]dbmeta.ScheduleRunState, error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.runStates.ListRunStates()
+}
+
+// loadRunState returns the stored state of a backup type, starting a fresh
+// one when none exists or the schedule has changed. Callers hold stateMu.
+func (s *Scheduler) loadRunState(backupType string, typeConfig config.BackupTypeConfig, now time.Time) (*dbmeta.ScheduleRunState, error) {
+	state, err := s.runStates.GetRunState(backupType)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		state = &dbmeta.ScheduleRunState{BackupType: backupType}
+	}
+	if state.Schedule != typeConfig.Schedule || state.TrackedSince.IsZero() {
+		// Fire times of a previous schedule say nothing about the new one
+		state.Schedule = typeConfig.Schedule
+		state.TrackedSince = now
+	}
+	return state, nil
+}
+
+// updateRunState applies a change to the run state of a backup type. Failures
+// are logged rather than returned so they never stop a backup.
+func (s *Scheduler) updateRunState(backupType string, typeConfig config.BackupTypeConfig, update func(*dbmeta.ScheduleRunState)) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	state, err := s.loadRunState(backupType, typeConfig, time.Now())
+	if err != nil {
+		log.Printf("Failed to load run state of %s schedule: %v", backupType, err)
+		return
+	}
+	if s.superseded(state) {
+		return
+	}
+	update(state)
+	if err := s.runStates.SaveRunState(state); err != nil {
+		log.Printf("Failed to save run state of %s schedule: %v", backupType, err)
+	}
+}
+
+// isPending reports whether a backup type has a run in progress or deferred
+// in this process
+func (s *Scheduler) isPending(backupType string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, deferred := s.deferredRuns[backupType]
+	return s.running[backupType] || deferred
+}
+
+// CheckMissedRuns records scheduled runs that never fired or never finished,
+// for example because GoSQLGuard was down, and starts a catch-up backup for
+// schedules configured with catchUp "once"
+func (s *Scheduler) CheckMissedRuns() {
+	if !s.isLeader() {
+		return
+	}
+	for _, catchUp := range s.checkMissedRuns(time.Now()) {
+		typeConfig := s.cfg.BackupTypes[catchUp]
+		log.Printf("Catching up on missed %s backup", catchUp)
+		go s.runScheduled(catchUp, typeConfig)
+	}
+}
+
+// checkMissedRuns updates the run states as of now and returns the backup
+// types that should catch up
+func (s *Scheduler) checkMissedRuns(now time.Time) []string {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	var catchUp []string
+	for backupType, typeConfig := range s.cfg.BackupTypes {
+		if typeConfig.Schedule == "" {
+			continue
+		}
+		schedule, err := config.ParseSchedule(typeConfig.Schedule, s.location(typeConfig))
+		if err != nil {
+			continue
+		}
+
+		state, err := s.loadRunState(backupType, typeConfig, now)
+		if err != nil {
+			log.Printf("Failed to load run state of %s schedule: %v", backupType, err)
+			continue
+		}
+		if s.superseded(state) {
+			continue
+		}
+
+		pending := s.isPending(backupType)
+		// A queued run stays running until its tasks finish, which
+		// completeQueuedRuns records
+		queued := state.LastStatus == dbmeta.RunStatusRunning && s.hasTaskQueue()
+		incomplete := !pending && !queued &&
+			(state.LastStatus == dbmeta.RunStatusRunning || state.LastStatus == dbmeta.RunStatusDeferred)
+		if incomplete {
+			log.Printf("The %s backup scheduled at %s did not complete", backupType, formatRunTime(state.LastScheduledAt))
+			state.LastStatus = dbmeta.RunStatusIncomplete
+			state.LastError = "run did not complete, GoSQLGuard may have stopped while it was in progress"
+			metrics.ScheduleMissedRuns.WithLabelValues(backupType, "incomplete").Inc()
+		}
+
+		// Count fire times since the schedule was last seen to fire or checked
+		from := state.TrackedSince
+		if state.LastScheduledAt != nil && state.LastScheduledAt.After(from) {
+			from = *state.LastScheduledAt
+		}
+		if state.LastMissedAt != nil && state.LastMissedAt.After(from) {
+			from = *state.LastMissedAt
+		}
+		cutoff := now.Add(-missedRunGrace)
+		missed := 0
+		var lastMissed time.Time
+		for next := schedule.Next(from); !next.IsZero() && !next.After(cutoff); next = schedule.Next(next) {
+			missed++
+			lastMissed = next
+			if missed == maxMissedScan {
+				// Don't walk the rest of a long outage again on the next check
+				lastMissed = cutoff
+				break
+			}
+		}
+		if missed > 0 {
+			log.Printf("Missed %d scheduled %s backup(s), the last at %s",
+				missed, backupType, lastMissed.Format(time.RFC3339))
+			state.MissedRuns += missed
+			state.LastMissedAt = &lastMissed
+			metrics.ScheduleMissedRuns.WithLabelValues(backupType, "missed").Add(float64(missed))
+		}
+
+		if err := s.runStates.SaveRunState(state); err != nil {
+			log.Printf("Failed to save run state of %s schedule: %v", backupType, err)
+		}
+
+		if (missed > 0 || incomplete) && !pending && typeConfig.CatchUp == config.CatchUpOnce {
+			catchUp = append(catchUp, backupType)
+		}
+	}
+
+	sort.Strings(catchUp)
+	return catchUp
+}
+
+// completeQueuedRuns marks runs whose queued backups have all been finished
+// by workers as complete, failing the run when any of its tasks failed
+func (s *Scheduler) completeQueuedRuns() {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	for backupType, typeConfig := range s.cfg.BackupTypes {
+		if s.isPending(backupType) {
+			continue
+		}
+		state, err := s.loadRunState(backupType, typeConfig, time.Now())
+		if err != nil {
+			log.Printf("Failed to load run state of %s schedule: %v", backupType, err)
+			continue
+		}
+		if s.superseded(state) || state.LastStatus != dbmeta.RunStatusRunning || state.LastStartedAt == nil {
+			continue
+		}
+
+		finished, failed, err := s.backupManager.QueuedRunProgress(backupType, *state.LastStartedAt)
+		if err != nil {
+			log.Printf("Failed to check queued %s backups: %v", backupType, err)
+			continue
+		}
+		if !finished {
+			continue
+		}
+
+		completed := time.Now()
+		state.LastCompletedAt = &completed
+		state.LastStatus = dbmeta.RunStatusSuccess
+		state.LastError = ""
+		if failed > 0 {
+			state.LastStatus = dbmeta.RunStatusError
+			state.LastError = fmt.Sprintf("%d queued backup(s) failed", failed)
+		}
+		log.Printf("Queued %s backup completed with status %s", backupType, state.LastStatus)
+		if err := s.runStates.SaveRunState(state); err != nil {
+			log.Printf("Failed to save run state of %s schedule: %v", backupType, err)
+		}
+	}
+}
+
+// hasTaskQueue reports whether scheduled backups are queued for workers
+func (s *Scheduler) hasTaskQueue() bool {
+	return s.backupManager != nil && s.backupManager.HasTaskQueue()
+}
+
+// formatRunTime formats an optional run time for log messages
+func formatRunTime(t *time.Time) string {
+	if t == nil {
+		return "an unknown time"
+	}
+	return t.Format(time.RFC3339)
+}
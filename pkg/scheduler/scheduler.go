@@ -2,13 +2,19 @@
 package scheduler
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
 	"github.com/supporttools/GoSQLGuard/pkg/backup"
 	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metrics"
 )
 
 // Scheduler handles cron scheduling for backups and retention
@@ -16,16 +22,45 @@ type Scheduler struct {
 	cronScheduler *cron.Cron
 	backupManager *backup.Manager
 	cfg           *config.AppConfig
-	jobIDs        map[string]cron.EntryID // Track job IDs for dynamic updates
+	maintenance   []cron.EntryID // Retention and missed-run checks
+
+	// jobIDs tracks job IDs for dynamic updates, deferredRuns holds runs
+	// postponed until a blackout window ends, running the backup types with
+	// a run in progress, controls the operator overrides of each schedule and
+	// leadership whether this replica may run jobs at all
+	mu           sync.Mutex
+	jobIDs       map[string]cron.EntryID
+	deferredRuns map[string]*time.Timer
+	running      map[string]bool
+	controls     map[string]Control
+	resumeTimers map[string]*time.Timer
+	leadership   Leadership
+	stop         chan struct{}
+	stopOnce     sync.Once
+
+	// runStates records each schedule's runs for missed-run detection
+	stateMu   sync.Mutex
+	runStates RunStateStore
 }
 
 // NewScheduler creates a new scheduler
 func NewScheduler(backupManager *backup.Manager) (*Scheduler, error) {
+	loc, err := config.LoadLocation(config.CFG.Scheduler.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scheduler timezone: %w", err)
+	}
+
 	return &Scheduler{
-		cronScheduler: cron.New(),
+		cronScheduler: cron.New(cron.WithLocation(loc)),
 		backupManager: backupManager,
 		cfg:           &config.CFG,
 		jobIDs:        make(map[string]cron.EntryID),
+		deferredRuns:  make(map[string]*time.Timer),
+		running:       make(map[string]bool),
+		controls:      make(map[string]Control),
+		resumeTimers:  make(map[string]*time.Timer),
+		stop:          make(chan struct{}),
+		runStates:     newMemoryRunStateStore(),
 	}, nil
 }
 
@@ -39,55 +74,289 @@ func (s *Scheduler) SetupJobs() error {
 			continue
 		}
 
-		// Create a closure to capture the backup type for the cron job
-		backupFunc := func(bType string) func() {
-			return func() {
-				log.Printf("Starting %s backup...", bType)
-				if err := s.backupManager.PerformBackup(bType); err != nil {
-					log.Printf("Error performing %s backup: %v", bType, err)
-				}
-			}
-		}
-
-		// Add the cron job with the specified schedule
-		jobID, err := s.cronScheduler.AddFunc(typeConfig.Schedule, backupFunc(backupType))
+		loc := s.location(typeConfig)
+		schedule, err := config.ParseSchedule(typeConfig.Schedule, loc)
 		if err != nil {
 			log.Printf("Failed to schedule %s backup with cron expression '%s': %v",
 				backupType, typeConfig.Schedule, err)
 			continue
 		}
 
+		// Create a closure to capture the backup type and its settings for the cron job
+		backupFunc := func(bType string, typeConfig config.BackupTypeConfig) func() {
+			return func() {
+				s.runScheduled(bType, typeConfig)
+			}
+		}
+
+		// Add the cron job with the specified schedule
+		jobID := s.cronScheduler.Schedule(schedule, cron.FuncJob(backupFunc(backupType, typeConfig)))
+
 		// Store the job ID for later updates
+		s.mu.Lock()
 		s.jobIDs[backupType] = jobID
+		s.mu.Unlock()
 
-		log.Printf("Scheduled %s backup with cron expression: %s", backupType, typeConfig.Schedule)
+		log.Printf("Scheduled %s backup with cron expression: %s in %s (%s)",
+			backupType, typeConfig.Schedule, loc, typeConfig.Targets.Description())
 	}
 
 	// Schedule retention policy enforcement job
-	_, err := s.cronScheduler.AddFunc("15 * * * *", func() {
-		s.backupManager.EnforceRetentionPolicies()
+	retentionID, err := s.cronScheduler.AddFunc("15 * * * *", func() {
+		if !s.isLeader() {
+			log.Println("Not the leader, skipping retention policy enforcement")
+			return
+		}
+		if err := s.backupManager.EnforceRetentionPolicies(); err != nil {
+			log.Printf("Retention policy enforcement finished with errors: %v", err)
+		}
 	})
 	if err != nil {
 		return fmt.Errorf("failed to schedule retention policy enforcement: %w", err)
 	}
+	s.maintenance = append(s.maintenance, retentionID)
 	log.Println("Scheduled retention policy enforcement at minute 15 of every hour")
 
+	// Schedule the missed-run check
+	checkID, err := s.cronScheduler.AddFunc(missedRunCheckSchedule, s.CheckMissedRuns)
+	if err != nil {
+		return fmt.Errorf("failed to schedule missed-run check: %w", err)
+	}
+	s.maintenance = append(s.maintenance, checkID)
+	log.Println("Scheduled missed-run check every 5 minutes")
+
+	// Take back tasks from workers that stopped sending heartbeats
+	if s.hasTaskQueue() {
+		recoveryID, err := s.cronScheduler.AddFunc("* * * * *", func() {
+			if s.isLeader() {
+				s.backupManager.RecoverTasks()
+				s.completeQueuedRuns()
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("failed to schedule backup task recovery: %w", err)
+		}
+		s.maintenance = append(s.maintenance, recoveryID)
+		log.Println("Scheduled backup task recovery every minute")
+	}
+
 	return nil
 }
 
+// location returns the time zone a backup type's schedule runs in
+func (s *Scheduler) location(typeConfig config.BackupTypeConfig) *time.Location {
+	name := typeConfig.Timezone
+	if name == "" {
+		name = s.cfg.Scheduler.Timezone
+	}
+	loc, err := config.LoadLocation(name)
+	if err != nil {
+		log.Printf("Invalid timezone %q, using local time: %v", name, err)
+		return time.Local
+	}
+	return loc
+}
+
+// runScheduled performs a scheduled backup, honoring blackout windows, start
+// jitter and the maximum run window
+func (s *Scheduler) runScheduled(backupType string, typeConfig config.BackupTypeConfig) {
+	start := time.Now()
+
+	leadership := s.currentLeadership()
+	if leadership != nil && !leadership.IsLeader() {
+		log.Printf("Not the leader, skipping scheduled %s backup", backupType)
+		return
+	}
+
+	s.mu.Lock()
+	s.running[backupType] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, backupType)
+		s.mu.Unlock()
+	}()
+
+	s.updateRunState(backupType, typeConfig, func(state *dbmeta.ScheduleRunState) {
+		state.LastScheduledAt = &start
+		state.LastStatus = dbmeta.RunStatusRunning
+		state.LastError = ""
+	})
+
+	if reason, detail := s.checkControl(backupType, start); reason != "" {
+		log.Printf("Skipping %s backup: %s", backupType, detail)
+		metrics.ScheduleDeferrals.WithLabelValues(backupType, reason).Inc()
+		s.updateRunState(backupType, typeConfig, func(state *dbmeta.ScheduleRunState) {
+			state.LastStatus = dbmeta.RunStatusSkipped
+			state.LastError = detail
+		})
+		return
+	}
+
+	if window, end, active := typeConfig.ActiveBlackout(start, s.location(typeConfig)); active {
+		if window.ActionOrDefault() == config.BlackoutDefer {
+			// Either this run or an earlier one is now waiting for the window to end
+			s.deferRun(backupType, typeConfig, window, end)
+			s.updateRunState(backupType, typeConfig, func(state *dbmeta.ScheduleRunState) {
+				state.LastStatus = dbmeta.RunStatusDeferred
+			})
+			return
+		}
+		log.Printf("Skipping %s backup: blackout window %s is active until %s",
+			backupType, window.Description(), end.Format(time.RFC3339))
+		metrics.ScheduleDeferrals.WithLabelValues(backupType, "blackout_skip").Inc()
+		s.updateRunState(backupType, typeConfig, func(state *dbmeta.ScheduleRunState) {
+			state.LastStatus = dbmeta.RunStatusSkipped
+			state.LastError = "blackout window " + window.Description()
+		})
+		return
+	}
+
+	opts := backup.OptionsFromTargets(typeConfig.Targets)
+	if leadership != nil {
+		token := leadership.Token()
+		opts.FencingToken = token
+		opts.Fence = func() error { return leadership.Fence(token) }
+	}
+
+	// The run window is measured from the scheduled start, so jitter counts against it
+	if window := parseDuration(typeConfig.MaxRunWindow); window > 0 {
+		opts.Deadline = start.Add(window)
+	}
+
+	if jitter := parseDuration(typeConfig.Jitter); jitter > 0 {
+		delay := time.Duration(rand.Int63n(int64(jitter)))
+		log.Printf("Delaying %s backup by %s of jitter", backupType, delay.Round(time.Second))
+		select {
+		case <-time.After(delay):
+		case <-s.stop:
+			log.Printf("Scheduler stopping, abandoning delayed %s backup", backupType)
+			return
+		}
+	}
+
+	started := time.Now()
+	s.updateRunState(backupType, typeConfig, func(state *dbmeta.ScheduleRunState) {
+		state.LastStartedAt = &started
+	})
+
+	log.Printf("Starting %s backup...", backupType)
+	err := s.backupManager.PerformBackup(backupType, opts)
+	if errors.Is(err, backup.ErrShuttingDown) {
+		// Leave the run marked running so the missed-run check reports it
+		// as incomplete, and catches it up, after the restart
+		log.Printf("Scheduled %s backup stopped by shutdown: %v", backupType, err)
+		return
+	}
+	if err == nil && s.backupManager.HasTaskQueue() {
+		// Workers run the queued backups, the run stays running until
+		// completeQueuedRuns sees its tasks finish
+		log.Printf("Queued %s backup for workers", backupType)
+		return
+	}
+	if err != nil {
+		log.Printf("Error performing %s backup: %v", backupType, err)
+	}
+
+	completed := time.Now()
+	s.updateRunState(backupType, typeConfig, func(state *dbmeta.ScheduleRunState) {
+		state.LastCompletedAt = &completed
+		state.LastStatus = dbmeta.RunStatusSuccess
+		state.LastError = ""
+		if err != nil {
+			state.LastStatus = dbmeta.RunStatusError
+			state.LastError = err.Error()
+		}
+	})
+}
+
+// deferRun postpones a run until a blackout window ends. Only one deferred
+// run is kept per backup type; later runs during the same window are dropped.
+func (s *Scheduler) deferRun(backupType string, typeConfig config.BackupTypeConfig, window config.BlackoutWindow, end time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, pending := s.deferredRuns[backupType]; pending {
+		log.Printf("Skipping %s backup: a run is already deferred until blackout window %s ends",
+			backupType, window.Description())
+		metrics.ScheduleDeferrals.WithLabelValues(backupType, "blackout_skip").Inc()
+		return
+	}
+
+	log.Printf("Deferring %s backup until blackout window %s ends at %s",
+		backupType, window.Description(), end.Format(time.RFC3339))
+	metrics.ScheduleDeferrals.WithLabelValues(backupType, "blackout_defer").Inc()
+
+	s.deferredRuns[backupType] = time.AfterFunc(time.Until(end), func() {
+		s.mu.Lock()
+		delete(s.deferredRuns, backupType)
+		s.mu.Unlock()
+
+		s.runScheduled(backupType, typeConfig)
+	})
+}
+
+// cancelDeferredRuns stops runs waiting for a blackout window to end
+func (s *Scheduler) cancelDeferredRuns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for backupType, timer := range s.deferredRuns {
+		timer.Stop()
+		delete(s.deferredRuns, backupType)
+		log.Printf("Cancelled deferred %s backup", backupType)
+	}
+}
+
+// parseDuration parses an optional duration setting, treating invalid values as unset
+func parseDuration(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Ignoring invalid duration %q: %v", value, err)
+		return 0
+	}
+	return d
+}
+
 // Start begins the scheduled jobs
 func (s *Scheduler) Start() {
 	s.cronScheduler.Start()
 	log.Println("Backup scheduler started successfully")
 }
 
-// Stop halts all scheduled jobs
+// halt stops new runs from starting: cron, deferred runs, resume timers and
+// runs waiting out their jitter. It returns a context that is done once the
+// running jobs have returned.
+func (s *Scheduler) halt() context.Context {
+	s.cancelDeferredRuns()
+	s.mu.Lock()
+	s.stopResumeTimers()
+	s.mu.Unlock()
+	s.stopOnce.Do(func() { close(s.stop) })
+	return s.cronScheduler.Stop()
+}
+
+// Stop halts all scheduled jobs, waiting for running ones to finish
 func (s *Scheduler) Stop() {
-	ctx := s.cronScheduler.Stop()
+	ctx := s.halt()
 	<-ctx.Done()
 	log.Println("Backup scheduler stopped")
 }
 
+// Shutdown halts all scheduled jobs and gives running backups up to grace to
+// finish before they are cancelled. It returns an error when backups had to
+// be cancelled.
+func (s *Scheduler) Shutdown(grace time.Duration) error {
+	ctx := s.halt()
+	err := s.backupManager.Shutdown(grace)
+	<-ctx.Done()
+	log.Println("Backup scheduler stopped")
+	return err
+}
+
 // WaitForever blocks indefinitely to keep the application running
 func (s *Scheduler) WaitForever() {
 	// Create a channel that never receives any values to block forever
@@ -100,11 +369,22 @@ func (s *Scheduler) ReloadSchedules() error {
 	log.Println("Reloading backup schedules...")
 
 	// Remove all existing backup jobs
+	s.mu.Lock()
 	for backupType, jobID := range s.jobIDs {
 		s.cronScheduler.Remove(jobID)
 		delete(s.jobIDs, backupType)
 		log.Printf("Removed schedule for %s backup", backupType)
 	}
+	s.mu.Unlock()
+
+	// Remove the maintenance jobs so they are not registered twice
+	for _, jobID := range s.maintenance {
+		s.cronScheduler.Remove(jobID)
+	}
+	s.maintenance = nil
+
+	// Deferred runs captured the old settings
+	s.cancelDeferredRuns()
 
 	// Re-setup jobs with new configuration
 	err := s.SetupJobs()
@@ -138,20 +418,19 @@ func (s *Scheduler) RunOnce(backupType string, servers []string, databases []str
 // RunRetentionOnce runs retention policy enforcement once
 func (s *Scheduler) RunRetentionOnce() {
 	log.Println("Running one-time retention policy enforcement")
-	s.backupManager.EnforceRetentionPolicies()
+	if err := s.backupManager.EnforceRetentionPolicies(); err != nil {
+		log.Printf("Retention policy enforcement finished with errors: %v", err)
+	}
 }
 
 // GetNextRunTime returns the next scheduled run time for a backup type
 func (s *Scheduler) GetNextRunTime(backupType string) (time.Time, error) {
-	// Find the entry for the specified backup type
-	for _, entry := range s.cronScheduler.Entries() {
-		// We need to compare the function address, which is not directly possible
-		// So this is a simplification; in a real implementation, you might need
-		// to track entry IDs or use another approach
-
-		// For now, just return the next time for any backup
-		return entry.Next, nil
+	runs, err := s.UpcomingRuns(backupType, time.Now(), 1)
+	if err != nil {
+		return time.Time{}, err
 	}
-
-	return time.Time{}, fmt.Errorf("no scheduled job found for backup type: %s", backupType)
+	if len(runs) == 0 {
+		return time.Time{}, fmt.Errorf("backup type %s has no future runs", backupType)
+	}
+	return runs[0].Time, nil
 }
@@ -0,0 +1,439 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/supporttools/GoSQLGuard/pkg/backup"
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+)
+
+// newTestScheduler returns a scheduler without a backup manager, so any
+// attempt to actually run a backup panics
+func newTestScheduler() *Scheduler {
+	return &Scheduler{
+		cronScheduler: cron.New(),
+		cfg:           &config.AppConfig{},
+		jobIDs:        make(map[string]cron.EntryID),
+		deferredRuns:  make(map[string]*time.Timer),
+		running:       make(map[string]bool),
+		controls:      make(map[string]Control),
+		resumeTimers:  make(map[string]*time.Timer),
+		stop:          make(chan struct{}),
+		runStates:     newMemoryRunStateStore(),
+	}
+}
+
+// activeWindow returns a date range blackout covering the current time
+func activeWindow(action string) config.BlackoutWindow {
+	now := time.Now()
+	return config.BlackoutWindow{
+		Name:   "test",
+		Start:  now.Add(-time.Hour).Format(time.RFC3339),
+		End:    now.Add(time.Hour).Format(time.RFC3339),
+		Action: action,
+	}
+}
+
+func TestRunScheduledSkipsDuringBlackout(t *testing.T) {
+	s := newTestScheduler()
+	typeConfig := config.BackupTypeConfig{Blackouts: []config.BlackoutWindow{activeWindow(config.BlackoutSkip)}}
+
+	s.runScheduled("hourly", typeConfig)
+
+	if len(s.deferredRuns) != 0 {
+		t.Errorf("skipped run was deferred: %v", s.deferredRuns)
+	}
+	state, _ := s.runStates.GetRunState("hourly")
+	if state == nil || state.LastStatus != dbmeta.RunStatusSkipped || state.LastScheduledAt == nil {
+		t.Errorf("run state = %+v, want a skipped run", state)
+	}
+}
+
+func TestRunScheduledDefersDuringBlackout(t *testing.T) {
+	s := newTestScheduler()
+	typeConfig := config.BackupTypeConfig{Blackouts: []config.BlackoutWindow{activeWindow(config.BlackoutDefer)}}
+
+	s.runScheduled("hourly", typeConfig)
+	first := s.deferredRuns["hourly"]
+	if first == nil {
+		t.Fatal("run was not deferred")
+	}
+
+	// A second run inside the same window does not queue another
+	s.runScheduled("hourly", typeConfig)
+	if len(s.deferredRuns) != 1 || s.deferredRuns["hourly"] != first {
+		t.Errorf("deferred runs = %v, want the first one only", s.deferredRuns)
+	}
+
+	s.cancelDeferredRuns()
+	if len(s.deferredRuns) != 0 {
+		t.Errorf("deferred runs not cancelled: %v", s.deferredRuns)
+	}
+}
+
+func TestScheduleTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	s := newTestScheduler()
+	s.cfg.Scheduler.Timezone = "UTC"
+
+	// A schedule's own zone wins over the scheduler default
+	typeConfig := config.BackupTypeConfig{Schedule: "0 2 * * *", Timezone: "Asia/Tokyo"}
+	if got := s.location(typeConfig); got.String() != loc.String() {
+		t.Fatalf("location = %s, want Asia/Tokyo", got)
+	}
+	if got := s.location(config.BackupTypeConfig{}); got.String() != "UTC" {
+		t.Errorf("default location = %s, want UTC", got)
+	}
+
+	schedule, err := config.ParseSchedule(typeConfig.Schedule, loc)
+	if err != nil {
+		t.Fatalf("ParseSchedule() = %v", err)
+	}
+	next := schedule.Next(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)).In(loc)
+	if next.Hour() != 2 || next.Minute() != 0 {
+		t.Errorf("next run = %s, want 02:00 Tokyo time", next)
+	}
+}
+
+// hourlyScheduler returns a scheduler with an hourly UTC schedule whose last
+// run fired at lastRun with the given status
+func hourlyScheduler(lastRun time.Time, status, catchUp string) *Scheduler {
+	s := newTestScheduler()
+	s.cfg.Scheduler.Timezone = "UTC"
+	s.cfg.BackupTypes = map[string]config.BackupTypeConfig{
+		"hourly": {Schedule: "0 * * * *", CatchUp: catchUp},
+	}
+	s.runStates.SaveRunState(&dbmeta.ScheduleRunState{
+		BackupType:      "hourly",
+		Schedule:        "0 * * * *",
+		TrackedSince:    lastRun.Add(-24 * time.Hour),
+		LastScheduledAt: &lastRun,
+		LastStatus:      status,
+	})
+	return s
+}
+
+func TestCheckMissedRunsAfterDowntime(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC)
+	s := hourlyScheduler(time.Date(2026, 10, 18, 7, 0, 1, 0, time.UTC), dbmeta.RunStatusSuccess, config.CatchUpOnce)
+
+	catchUp := s.checkMissedRuns(now)
+	if len(catchUp) != 1 || catchUp[0] != "hourly" {
+		t.Errorf("catch up = %v, want [hourly]", catchUp)
+	}
+
+	// The 08:00 through 12:00 runs never fired
+	state, _ := s.runStates.GetRunState("hourly")
+	if state.MissedRuns != 5 {
+		t.Errorf("missed runs = %d, want 5", state.MissedRuns)
+	}
+	if want := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC); state.LastMissedAt == nil || !state.LastMissedAt.Equal(want) {
+		t.Errorf("last missed = %v, want %s", state.LastMissedAt, want)
+	}
+
+	// Missed runs are counted once
+	if catchUp := s.checkMissedRuns(now.Add(time.Minute)); len(catchUp) != 0 {
+		t.Errorf("second check caught up again: %v", catchUp)
+	}
+	state, _ = s.runStates.GetRunState("hourly")
+	if state.MissedRuns != 5 {
+		t.Errorf("missed runs after second check = %d, want 5", state.MissedRuns)
+	}
+}
+
+func TestCheckMissedRunsIncomplete(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC)
+	lastRun := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	// A run still in progress in this process is not incomplete
+	s := hourlyScheduler(lastRun, dbmeta.RunStatusRunning, config.CatchUpSkip)
+	s.running["hourly"] = true
+	s.checkMissedRuns(now)
+	if state, _ := s.runStates.GetRunState("hourly"); state.LastStatus != dbmeta.RunStatusRunning {
+		t.Errorf("status of a running backup = %s, want running", state.LastStatus)
+	}
+
+	// After a restart nothing is running, so the run was interrupted
+	s = hourlyScheduler(lastRun, dbmeta.RunStatusRunning, config.CatchUpSkip)
+	if catchUp := s.checkMissedRuns(now); len(catchUp) != 0 {
+		t.Errorf("catch up = %v, want none with catchUp skip", catchUp)
+	}
+	state, _ := s.runStates.GetRunState("hourly")
+	if state.LastStatus != dbmeta.RunStatusIncomplete || state.LastError == "" {
+		t.Errorf("run state = %+v, want an incomplete run", state)
+	}
+	if state.MissedRuns != 0 {
+		t.Errorf("missed runs = %d, want 0", state.MissedRuns)
+	}
+}
+
+func TestCheckMissedRunsScheduleChange(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC)
+	s := hourlyScheduler(time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), dbmeta.RunStatusSuccess, config.CatchUpOnce)
+	s.cfg.BackupTypes["hourly"] = config.BackupTypeConfig{Schedule: "30 * * * *", CatchUp: config.CatchUpOnce}
+
+	// Fire times of the old schedule are not held against the new one
+	if catchUp := s.checkMissedRuns(now); len(catchUp) != 0 {
+		t.Errorf("catch up = %v, want none after a schedule change", catchUp)
+	}
+	state, _ := s.runStates.GetRunState("hourly")
+	if state.Schedule != "30 * * * *" || !state.TrackedSince.Equal(now) || state.MissedRuns != 0 {
+		t.Errorf("run state = %+v, want tracking of the new schedule from now", state)
+	}
+}
+
+func TestGetNextRunTime(t *testing.T) {
+	s := newTestScheduler()
+	s.cfg.Scheduler.Timezone = "UTC"
+	s.cfg.BackupTypes = map[string]config.BackupTypeConfig{
+		"hourly": {Schedule: "0 * * * *"},
+		"daily":  {Schedule: "0 2 * * *"},
+	}
+	if err := s.SetupJobs(); err != nil {
+		t.Fatalf("SetupJobs() = %v", err)
+	}
+
+	// Each backup type gets its own next run, not the first entry's
+	daily, err := s.GetNextRunTime("daily")
+	if err != nil {
+		t.Fatalf("GetNextRunTime(daily) = %v", err)
+	}
+	if daily.UTC().Hour() != 2 || daily.Minute() != 0 {
+		t.Errorf("next daily run = %s, want 02:00 UTC", daily)
+	}
+	hourly, err := s.GetNextRunTime("hourly")
+	if err != nil {
+		t.Fatalf("GetNextRunTime(hourly) = %v", err)
+	}
+	if hourly.Minute() != 0 || time.Until(hourly) > time.Hour {
+		t.Errorf("next hourly run = %s, want the top of the next hour", hourly)
+	}
+
+	if _, err := s.GetNextRunTime("weekly"); err == nil {
+		t.Error("expected an error for a backup type without a schedule")
+	}
+}
+
+func TestPlanRunsOverlap(t *testing.T) {
+	s := newTestScheduler()
+	s.cfg.Scheduler.Timezone = "UTC"
+	s.cfg.BackupTypes = map[string]config.BackupTypeConfig{
+		"daily":   {Schedule: "0 2 * * *"},
+		"reports": {Schedule: "0 3 * * *"},
+		"late": {
+			Schedule:  "0 5 * * *",
+			Blackouts: []config.BlackoutWindow{{Start: "2026-10-18", End: "2026-10-18"}},
+		},
+	}
+	if err := s.SetupJobs(); err != nil {
+		t.Fatalf("SetupJobs() = %v", err)
+	}
+
+	// The last daily run took 90 minutes, so it runs into the 03:00 reports run
+	started := time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC)
+	completed := started.Add(90 * time.Minute)
+	s.runStates.SaveRunState(&dbmeta.ScheduleRunState{
+		BackupType:      "daily",
+		Schedule:        "0 2 * * *",
+		TrackedSince:    started,
+		LastStartedAt:   &started,
+		LastCompletedAt: &completed,
+		LastStatus:      dbmeta.RunStatusSuccess,
+	})
+
+	from := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	runs, err := s.PlanRuns(from, from.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("PlanRuns() = %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("planned %d runs, want 3: %+v", len(runs), runs)
+	}
+
+	daily, reports, late := runs[0], runs[1], runs[2]
+	if daily.BackupType != "daily" || daily.Duration != 90*time.Minute {
+		t.Errorf("first run = %+v, want daily lasting 90m", daily)
+	}
+	if len(daily.OverlapsWith) != 1 || daily.OverlapsWith[0] != "reports" {
+		t.Errorf("daily overlaps %v, want [reports]", daily.OverlapsWith)
+	}
+	if len(reports.OverlapsWith) != 1 || reports.OverlapsWith[0] != "daily" {
+		t.Errorf("reports overlaps %v, want [daily]", reports.OverlapsWith)
+	}
+	if len(late.OverlapsWith) != 0 || late.Blackout == "" || late.Action != config.BlackoutSkip {
+		t.Errorf("late run = %+v, want a skipped run without overlaps", late)
+	}
+}
+
+func TestRunScheduledWhilePaused(t *testing.T) {
+	s := newTestScheduler()
+	s.SetControl("hourly", Control{Paused: true, PausedAt: time.Now(), Reason: "storage maintenance"})
+	defer s.SetControl("hourly", Control{})
+
+	// The scheduler has no backup manager, so a run that went ahead would panic
+	s.runScheduled("hourly", config.BackupTypeConfig{Schedule: "0 * * * *"})
+
+	state, _ := s.runStates.GetRunState("hourly")
+	if state == nil || state.LastStatus != dbmeta.RunStatusSkipped || state.LastError != "schedule paused: storage maintenance" {
+		t.Errorf("run state = %+v, want a run skipped by the pause", state)
+	}
+}
+
+func TestControlPause(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	if (Control{PausedAt: now}).IsPaused(now) {
+		t.Error("control without Paused reported paused")
+	}
+	if !(Control{Paused: true}).IsPaused(now) {
+		t.Error("open-ended pause not in effect")
+	}
+
+	// A pause with an end resumes by itself
+	c := Control{Paused: true, PausedUntil: now.Add(time.Hour)}
+	if !c.IsPaused(now.Add(59 * time.Minute)) {
+		t.Error("pause ended early")
+	}
+	if c.IsPaused(now.Add(time.Hour)) {
+		t.Error("pause did not end at PausedUntil")
+	}
+}
+
+func TestSkipNext(t *testing.T) {
+	s := newTestScheduler()
+	s.cfg.Scheduler.Timezone = "UTC"
+	s.cfg.BackupTypes = map[string]config.BackupTypeConfig{"hourly": {Schedule: "0 * * * *"}}
+	if err := s.SetupJobs(); err != nil {
+		t.Fatalf("SetupJobs() = %v", err)
+	}
+
+	next, err := s.SkipNext("hourly")
+	if err != nil {
+		t.Fatalf("SkipNext() = %v", err)
+	}
+	if next.Minute() != 0 || time.Until(next) > time.Hour {
+		t.Errorf("skipped run at %s, want the top of the next hour", next)
+	}
+
+	// Earlier runs such as catch-ups still go ahead
+	if reason, _ := s.checkControl("hourly", next.Add(-time.Minute)); reason != "" {
+		t.Errorf("run before the skipped one stopped: %s", reason)
+	}
+	if reason, _ := s.checkControl("hourly", next.Add(time.Second)); reason != "skip_next" {
+		t.Errorf("skipped run reason = %q, want skip_next", reason)
+	}
+
+	// Only one occurrence is skipped
+	if reason, _ := s.checkControl("hourly", next.Add(time.Hour)); reason != "" {
+		t.Errorf("following run stopped: %s", reason)
+	}
+
+	// A skip whose occurrence passed while the process was down no longer applies
+	s.SetControl("hourly", Control{SkipNextAt: next})
+	if reason, _ := s.checkControl("hourly", next.Add(time.Hour)); reason != "" {
+		t.Errorf("stale skip stopped a later run: %s", reason)
+	}
+}
+
+// fakeLeadership reports a fixed role and token
+type fakeLeadership struct {
+	leader bool
+	token  int64
+}
+
+func (f fakeLeadership) IsLeader() bool { return f.leader }
+func (f fakeLeadership) Token() int64   { return f.token }
+func (f fakeLeadership) Fence(token int64) error {
+	if !f.leader || token != f.token {
+		return fmt.Errorf("no longer the leader")
+	}
+	return nil
+}
+
+func TestRunScheduledOnFollower(t *testing.T) {
+	s := newTestScheduler()
+	s.SetLeadership(fakeLeadership{leader: false, token: 1})
+
+	// The scheduler has no backup manager, so a run that went ahead would panic
+	s.runScheduled("hourly", config.BackupTypeConfig{Schedule: "0 * * * *"})
+
+	if state, _ := s.runStates.GetRunState("hourly"); state != nil {
+		t.Errorf("follower recorded run state %+v", state)
+	}
+}
+
+func TestRunStateFencing(t *testing.T) {
+	s := newTestScheduler()
+	typeConfig := config.BackupTypeConfig{Schedule: "0 * * * *"}
+
+	s.SetLeadership(fakeLeadership{leader: true, token: 3})
+	s.updateRunState("hourly", typeConfig, func(state *dbmeta.ScheduleRunState) {
+		state.LastStatus = dbmeta.RunStatusRunning
+	})
+	state, _ := s.runStates.GetRunState("hourly")
+	if state == nil || state.FencingToken != 3 {
+		t.Fatalf("run state = %+v, want fencing token 3", state)
+	}
+
+	// A replica still finishing a run from an earlier term must not
+	// overwrite what the current leader recorded
+	s.SetLeadership(fakeLeadership{leader: true, token: 2})
+	s.updateRunState("hourly", typeConfig, func(state *dbmeta.ScheduleRunState) {
+		state.LastStatus = dbmeta.RunStatusSuccess
+	})
+	state, _ = s.runStates.GetRunState("hourly")
+	if state.LastStatus != dbmeta.RunStatusRunning || state.FencingToken != 3 {
+		t.Errorf("stale leader overwrote run state: %+v", state)
+	}
+}
+
+// progressQueue is a task queue reporting fixed progress for every run
+type progressQueue struct {
+	pending, failed int
+}
+
+func (q *progressQueue) Enqueue(tasks []dbmeta.BackupTask) (int, error) { return len(tasks), nil }
+func (q *progressQueue) RequeueStale(time.Duration, int) (int, int, error) {
+	return 0, 0, nil
+}
+func (q *progressQueue) PurgeTasks(time.Duration) (int64, error) { return 0, nil }
+func (q *progressQueue) Progress(string, time.Time) (int, int, error) {
+	return q.pending, q.failed, nil
+}
+
+func TestCompleteQueuedRuns(t *testing.T) {
+	lastRun := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	s := hourlyScheduler(lastRun, dbmeta.RunStatusRunning, config.CatchUpOnce)
+	state, _ := s.runStates.GetRunState("hourly")
+	state.LastStartedAt = &lastRun
+	s.runStates.SaveRunState(state)
+
+	queue := &progressQueue{pending: 2}
+	s.backupManager = &backup.Manager{}
+	s.backupManager.SetTaskQueue(queue)
+
+	// Queued runs are neither incomplete nor caught up while tasks remain
+	if catchUp := s.checkMissedRuns(lastRun.Add(10 * time.Minute)); len(catchUp) != 0 {
+		t.Errorf("catch up = %v, want none while tasks are queued", catchUp)
+	}
+	s.completeQueuedRuns()
+	if state, _ := s.runStates.GetRunState("hourly"); state.LastStatus != dbmeta.RunStatusRunning {
+		t.Errorf("status with pending tasks = %s, want running", state.LastStatus)
+	}
+
+	// Once the workers finish, the run takes the outcome of its tasks
+	queue.pending, queue.failed = 0, 1
+	s.completeQueuedRuns()
+	state, _ = s.runStates.GetRunState("hourly")
+	if state.LastStatus != dbmeta.RunStatusError || state.LastCompletedAt == nil {
+		t.Errorf("run state = %+v, want a completed run with an error", state)
+	}
+}
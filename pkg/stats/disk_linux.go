@@ -0,0 +1,13 @@
+package stats
+
+import "syscall"
+
+// DiskUsage returns the size of the file system holding path and the space
+// left on it for unprivileged users
+func DiskUsage(path string) (total, available int64, err error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return 0, 0, err
+	}
+	return int64(fs.Blocks) * int64(fs.Bsize), int64(fs.Bavail) * int64(fs.Bsize), nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package stats
+
+import "errors"
+
+// DiskUsage is only available on Linux
+func DiskUsage(path string) (total, available int64, err error) {
+	return 0, 0, errors.New("disk usage is not supported on this platform")
+}
@@ -0,0 +1,135 @@
+package stats
+
+import (
+	"log"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+)
+
+// MinForecastDays is the number of days of history needed for a forecast
+const MinForecastDays = 3
+
+// maxForecastDays is how far ahead a forecast looks
+const maxForecastDays = 10 * 365
+
+// Storage locations of a forecast
+const (
+	LocationLocal = "local"
+	LocationS3    = "s3"
+)
+
+// Forecast estimates when a storage location runs out of space if the size
+// of the backups it holds keeps growing as it did over the history
+type Forecast struct {
+	Location     string     `json:"location"`
+	Capacity     int64      `json:"capacity"`              // Disk size or S3 quota, 0 when unknown
+	Available    int64      `json:"available"`             // Space left now
+	Used         int64      `json:"used"`                  // Size of the backups held on the last day of the history
+	GrowthPerDay float64    `json:"growthPerDay"`          // Bytes per day, by linear regression over the history
+	Days         int        `json:"days"`                  // Days of history the regression used
+	DaysLeft     *float64   `json:"daysLeft,omitempty"`    // Unset when the space lasts ten years or more at this rate
+	ExhaustedAt  *time.Time `json:"exhaustedAt,omitempty"` // When the space runs out at this rate
+}
+
+// LinearRegression fits y = intercept + slope*x to the points by least
+// squares. It fails with fewer than two distinct x values.
+func LinearRegression(xs, ys []float64) (slope, intercept float64, ok bool) {
+	n := float64(len(xs))
+	if len(xs) < 2 || len(xs) != len(ys) {
+		return 0, 0, false
+	}
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var sxx, sxy float64
+	for i := range xs {
+		dx := xs[i] - meanX
+		sxx += dx * dx
+		sxy += dx * (ys[i] - meanY)
+	}
+	if sxx == 0 {
+		return 0, 0, false
+	}
+	slope = sxy / sxx
+	return slope, meanY - slope*meanX, true
+}
+
+// ForecastExhaustion fits the size of the backups held at location, taken
+// from the daily totals, and returns when the available space runs out at
+// that rate. available is the space left now and capacity the total, 0 when
+// unknown; space already used up is exhausted now whatever the trend.
+func ForecastExhaustion(location string, totals []Point, capacity, available int64, now time.Time) Forecast {
+	forecast := Forecast{Location: location, Capacity: capacity, Available: available, Days: len(totals)}
+	size := func(p Point) int64 {
+		if location == LocationS3 {
+			return p.S3Size
+		}
+		return p.LocalSize
+	}
+	if len(totals) > 0 {
+		forecast.Used = size(totals[len(totals)-1])
+	}
+
+	if len(totals) >= MinForecastDays {
+		first := totals[0].Day
+		xs := make([]float64, len(totals))
+		ys := make([]float64, len(totals))
+		for i, p := range totals {
+			xs[i] = p.Day.Sub(first).Hours() / 24
+			ys[i] = float64(size(p))
+		}
+		if slope, _, ok := LinearRegression(xs, ys); ok {
+			forecast.GrowthPerDay = slope
+		}
+	}
+
+	var daysLeft float64
+	switch {
+	case capacity <= 0:
+		return forecast
+	case available <= 0:
+		daysLeft = 0
+	case forecast.GrowthPerDay <= 0:
+		return forecast
+	default:
+		daysLeft = float64(available) / forecast.GrowthPerDay
+		if daysLeft >= maxForecastDays {
+			return forecast
+		}
+	}
+	exhaustedAt := now.Add(time.Duration(daysLeft * float64(24*time.Hour)))
+	forecast.DaysLeft = &daysLeft
+	forecast.ExhaustedAt = &exhaustedAt
+	return forecast
+}
+
+// Forecasts returns the forecast of each enabled storage location from the
+// daily totals over all databases: local storage against the space left on
+// the backup directory's file system, S3 against the configured quota
+func Forecasts(totals []Point, now time.Time) []Forecast {
+	var forecasts []Forecast
+	if config.CFG.Local.Enabled {
+		capacity, available, err := DiskUsage(config.CFG.Local.BackupDirectory)
+		if err != nil {
+			log.Printf("Warning: Failed to read disk usage of %s: %v", config.CFG.Local.BackupDirectory, err)
+		}
+		forecasts = append(forecasts, ForecastExhaustion(LocationLocal, totals, capacity, available, now))
+	}
+	if config.CFG.S3.Enabled {
+		quota := config.CFG.S3.QuotaBytes()
+		var available int64
+		if quota > 0 {
+			available = quota
+			if len(totals) > 0 {
+				available -= totals[len(totals)-1].S3Size
+			}
+		}
+		forecasts = append(forecasts, ForecastExhaustion(LocationS3, totals, quota, available, now))
+	}
+	return forecasts
+}
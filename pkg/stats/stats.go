@@ -0,0 +1,159 @@
+// Package stats builds the daily backup statistics history and forecasts
+// when storage runs out from it.
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+// Snapshots returns the stats snapshot of each database for the day t falls
+// on, computed from the backups in the catalog. Databases without backups
+// created that day or held at its end get none.
+func Snapshots(backups []types.BackupMeta, t time.Time) []types.StatsSnapshot {
+	start := types.StatsDay(t)
+	end := start.AddDate(0, 0, 1)
+
+	type key struct{ server, database string }
+	byDatabase := make(map[key]*types.StatsSnapshot)
+	snapshot := func(b types.BackupMeta) *types.StatsSnapshot {
+		k := key{b.ServerName, b.Database}
+		if byDatabase[k] == nil {
+			byDatabase[k] = &types.StatsSnapshot{Day: start, ServerName: b.ServerName, Database: b.Database}
+		}
+		return byDatabase[k]
+	}
+
+	for _, b := range backups {
+		if !b.CreatedAt.Before(end) {
+			continue
+		}
+		if !b.CreatedAt.Before(start) {
+			switch b.Status {
+			case types.StatusSuccess, types.StatusDeleted:
+				s := snapshot(b)
+				s.BackupCount++
+				s.BackupSize += b.Size
+				if b.CompletedAt.After(b.CreatedAt) {
+					s.DurationSeconds += b.CompletedAt.Sub(b.CreatedAt).Seconds()
+				}
+			case types.StatusError, types.StatusInterrupted:
+				snapshot(b).FailedCount++
+			}
+		}
+		if heldLocally(b, end) {
+			snapshot(b).LocalSize += b.Size
+		}
+		if heldInS3(b, end) {
+			snapshot(b).S3Size += b.Size
+		}
+	}
+
+	snapshots := make([]types.StatsSnapshot, 0, len(byDatabase))
+	for _, s := range byDatabase {
+		snapshots = append(snapshots, *s)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].ServerName != snapshots[j].ServerName {
+			return snapshots[i].ServerName < snapshots[j].ServerName
+		}
+		return snapshots[i].Database < snapshots[j].Database
+	})
+	return snapshots
+}
+
+// heldLocally reports whether local storage still held a backup at end.
+// Retention records when it removed the last local copy; a backup deleted
+// otherwise is only known to be gone now.
+func heldLocally(b types.BackupMeta, end time.Time) bool {
+	if b.Status != types.StatusSuccess && b.Status != types.StatusDeleted {
+		return false
+	}
+	if !b.LocalDeletedAt.IsZero() {
+		return !b.LocalDeletedAt.Before(end)
+	}
+	return b.Status == types.StatusSuccess && (len(b.LocalPaths) > 0 || b.LocalPath != "")
+}
+
+// heldInS3 reports whether S3 held a backup at end
+func heldInS3(b types.BackupMeta, end time.Time) bool {
+	if b.S3UploadStatus != types.StatusSuccess {
+		return false
+	}
+	if !b.S3UploadComplete.IsZero() && !b.S3UploadComplete.Before(end) {
+		return false
+	}
+	if !b.S3DeletedAt.IsZero() {
+		return !b.S3DeletedAt.Before(end)
+	}
+	return b.Status == types.StatusSuccess && (len(b.S3Keys) > 0 || b.S3Key != "")
+}
+
+// Point is the stats of one day in a time series
+type Point struct {
+	Day                time.Time `json:"day"`
+	BackupCount        int       `json:"backupCount"`
+	FailedCount        int       `json:"failedCount"`
+	BackupSize         int64     `json:"backupSize"`
+	AvgDurationSeconds float64   `json:"avgDurationSeconds"`
+	LocalSize          int64     `json:"localSize"`
+	S3Size             int64     `json:"s3Size"`
+
+	durationSeconds float64
+}
+
+// add adds a snapshot to the point
+func (p *Point) add(s types.StatsSnapshot) {
+	p.BackupCount += s.BackupCount
+	p.FailedCount += s.FailedCount
+	p.BackupSize += s.BackupSize
+	p.LocalSize += s.LocalSize
+	p.S3Size += s.S3Size
+	p.durationSeconds += s.DurationSeconds
+	if p.BackupCount > 0 {
+		p.AvgDurationSeconds = p.durationSeconds / float64(p.BackupCount)
+	}
+}
+
+// Series is the daily stats of one database
+type Series struct {
+	ServerName string  `json:"serverName"`
+	Database   string  `json:"database"`
+	Points     []Point `json:"points"`
+}
+
+// History turns snapshots, oldest first, into a series per database and the
+// totals over all of them for each day
+func History(snapshots []types.StatsSnapshot) ([]Series, []Point) {
+	var series []Series
+	index := make(map[[2]string]int)
+	var totals []Point
+
+	for _, s := range snapshots {
+		k := [2]string{s.ServerName, s.Database}
+		i, ok := index[k]
+		if !ok {
+			i = len(series)
+			index[k] = i
+			series = append(series, Series{ServerName: s.ServerName, Database: s.Database})
+		}
+		point := Point{Day: s.Day}
+		point.add(s)
+		series[i].Points = append(series[i].Points, point)
+
+		if n := len(totals); n == 0 || !totals[n-1].Day.Equal(s.Day) {
+			totals = append(totals, Point{Day: s.Day})
+		}
+		totals[len(totals)-1].add(s)
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].ServerName != series[j].ServerName {
+			return series[i].ServerName < series[j].ServerName
+		}
+		return series[i].Database < series[j].Database
+	})
+	return series, totals
+}
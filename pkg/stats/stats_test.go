@@ -0,0 +1,112 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
+)
+
+func TestSnapshots(t *testing.T) {
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return day.Add(time.Duration(hours) * time.Hour) }
+	local := map[string]string{"by-server": "/backups/app.sql.gz"}
+	s3 := map[string]string{"by-server": "backups/app.sql.gz"}
+
+	backups := []types.BackupMeta{
+		// Created that day and still held in both locations
+		{ServerName: "prod", Database: "app", Status: types.StatusSuccess, Size: 100,
+			CreatedAt: at(2), CompletedAt: at(2).Add(time.Minute), LocalPaths: local,
+			S3UploadStatus: types.StatusSuccess, S3UploadComplete: at(3), S3Keys: s3},
+		// Created that day, local copy removed by retention the next day
+		{ServerName: "prod", Database: "app", Status: types.StatusSuccess, Size: 50,
+			CreatedAt: at(10), CompletedAt: at(10).Add(3 * time.Minute), LocalDeletedAt: at(30)},
+		// Failed that day
+		{ServerName: "prod", Database: "app", Status: types.StatusError, CreatedAt: at(12)},
+		// Created earlier; S3 copy removed that day, local copy after it
+		{ServerName: "prod", Database: "old", Status: types.StatusDeleted, Size: 20,
+			CreatedAt: at(-48), LocalDeletedAt: at(26),
+			S3UploadStatus: types.StatusSuccess, S3UploadComplete: at(-47), S3DeletedAt: at(5)},
+		// Created the next day
+		{ServerName: "prod", Database: "app", Status: types.StatusSuccess, Size: 1000, CreatedAt: at(25), LocalPaths: local},
+		// Deleted outside retention, so no longer known to be held
+		{ServerName: "prod", Database: "gone", Status: types.StatusDeleted, Size: 5, CreatedAt: at(-30)},
+	}
+
+	snapshots := Snapshots(backups, at(18))
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, types.StatsSnapshot{
+		Day: day, ServerName: "prod", Database: "app",
+		BackupCount: 2, FailedCount: 1, BackupSize: 150, DurationSeconds: 240,
+		LocalSize: 150, S3Size: 100,
+	}, snapshots[0])
+	assert.Equal(t, types.StatsSnapshot{
+		Day: day, ServerName: "prod", Database: "old", LocalSize: 20,
+	}, snapshots[1])
+}
+
+func TestHistory(t *testing.T) {
+	day1 := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	series, totals := History([]types.StatsSnapshot{
+		{Day: day1, ServerName: "prod", Database: "b", BackupCount: 1, DurationSeconds: 60, LocalSize: 10},
+		{Day: day1, ServerName: "prod", Database: "a", BackupCount: 3, DurationSeconds: 60, LocalSize: 20},
+		{Day: day2, ServerName: "prod", Database: "a", BackupCount: 1, DurationSeconds: 30, LocalSize: 25},
+	})
+
+	require.Len(t, series, 2)
+	assert.Equal(t, "a", series[0].Database)
+	assert.Len(t, series[0].Points, 2)
+	assert.Equal(t, 20.0, series[0].Points[0].AvgDurationSeconds)
+	assert.Equal(t, "b", series[1].Database)
+
+	require.Len(t, totals, 2)
+	assert.Equal(t, Point{Day: day1, BackupCount: 4, AvgDurationSeconds: 30, LocalSize: 30, durationSeconds: 120}, totals[0])
+	assert.Equal(t, int64(25), totals[1].LocalSize)
+}
+
+func TestLinearRegression(t *testing.T) {
+	slope, intercept, ok := LinearRegression([]float64{0, 1, 2, 3}, []float64{1, 3, 5, 7})
+	require.True(t, ok)
+	assert.InDelta(t, 2, slope, 1e-9)
+	assert.InDelta(t, 1, intercept, 1e-9)
+
+	_, _, ok = LinearRegression([]float64{1, 1}, []float64{1, 2})
+	assert.False(t, ok)
+	_, _, ok = LinearRegression([]float64{1}, []float64{1})
+	assert.False(t, ok)
+}
+
+func TestForecastExhaustion(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+	series := func(sizes ...int64) []Point {
+		points := make([]Point, len(sizes))
+		for i, size := range sizes {
+			points[i] = Point{Day: types.StatsDay(now).AddDate(0, 0, i-len(sizes)+1), LocalSize: size, S3Size: size}
+		}
+		return points
+	}
+
+	// Growing 10 a day with 50 left runs out in 5 days
+	forecast := ForecastExhaustion(LocationLocal, series(10, 20, 30, 40), 1000, 50, now)
+	assert.InDelta(t, 10, forecast.GrowthPerDay, 1e-9)
+	assert.Equal(t, int64(40), forecast.Used)
+	require.NotNil(t, forecast.DaysLeft)
+	assert.InDelta(t, 5, *forecast.DaysLeft, 1e-9)
+	assert.Equal(t, now.AddDate(0, 0, 5), *forecast.ExhaustedAt)
+
+	// Not growing, no capacity, too little history or too far ahead
+	assert.Nil(t, ForecastExhaustion(LocationS3, series(40, 30, 20), 1000, 50, now).DaysLeft)
+	assert.Nil(t, ForecastExhaustion(LocationS3, series(10, 20, 30), 0, 0, now).DaysLeft)
+	assert.Nil(t, ForecastExhaustion(LocationS3, series(10, 20), 1000, 50, now).DaysLeft)
+	assert.Nil(t, ForecastExhaustion(LocationS3, series(10, 11, 12), 1e9, 1e9, now).DaysLeft)
+
+	// Space already used up is exhausted now
+	forecast = ForecastExhaustion(LocationS3, series(30, 20, 10), 100, -10, now)
+	require.NotNil(t, forecast.DaysLeft)
+	assert.Zero(t, *forecast.DaysLeft)
+	assert.Equal(t, now, *forecast.ExhaustedAt)
+}
@@ -2,14 +2,18 @@
 package local
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/supporttools/GoSQLGuard/pkg/config"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
 	"github.com/supporttools/GoSQLGuard/pkg/metrics"
 )
 
@@ -52,7 +56,7 @@ func (c *Client) GetBackupPath(backupType, backupFileName string) (string, error
 }
 
 // RecordBackupMetrics records metrics for a local backup
-func (c *Client) RecordBackupMetrics(backupPath, backupType, database string) error {
+func (c *Client) RecordBackupMetrics(backupPath, serverName, backupType, database string) error {
 	// Get file size for metrics
 	fileInfo, err := os.Stat(backupPath)
 	if err != nil {
@@ -60,82 +64,86 @@ func (c *Client) RecordBackupMetrics(backupPath, backupType, database string) er
 	}
 
 	sizeBytes := float64(fileInfo.Size())
-	metrics.BackupSize.WithLabelValues(backupType, database, "local").Set(sizeBytes)
+	metrics.BackupSize.WithLabelValues(backupType, serverName, database, "local").Set(sizeBytes)
 
 	return nil
 }
 
-// EnforceRetention implements retention policy for local backups
-func (c *Client) EnforceRetention() error {
-	for backupType, typeConfig := range c.cfg.BackupTypes {
-		// Skip if local backup is not enabled for this type
-		if !typeConfig.Local.Enabled {
-			if c.cfg.Debug {
-				log.Printf("Local backup not enabled for %s, skipping retention enforcement", backupType)
-			}
-			continue
-		}
+// DeleteBackup removes every local file recorded for a backup, along with
+// their manifests. It returns the paths that could not be removed, keyed by
+// organization, so a partial failure can be retried. Files that are already
+// gone count as removed.
+func (c *Client) DeleteBackup(backup metadata.BackupMeta) (map[string]string, error) {
+	paths := make(map[string]string, len(backup.LocalPaths)+1)
+	for org, path := range backup.LocalPaths {
+		paths[org] = path
+	}
+	if backup.LocalPath != "" && len(paths) == 0 {
+		paths["legacy"] = backup.LocalPath
+	}
 
-		// Skip if keep forever is set
-		if typeConfig.Local.Retention.Forever {
-			if c.cfg.Debug {
-				log.Printf("Local backups for %s set to keep forever, skipping retention enforcement", backupType)
-			}
+	remaining := make(map[string]string)
+	var errs []error
+	for org, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			remaining[org] = path
+			errs = append(errs, fmt.Errorf("failed to remove %s: %w", path, err))
 			continue
 		}
+		log.Printf("Removed local backup file: %s", path)
+		removeManifest(path)
+	}
 
-		// Parse duration string
-		duration, err := time.ParseDuration(typeConfig.Local.Retention.Duration)
-		if err != nil {
-			log.Printf("Invalid duration for %s local retention: %v", backupType, err)
-			continue
-		}
+	return remaining, errors.Join(errs...)
+}
+
+// StoredFile describes a backup file found in local storage
+type StoredFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
 
-		backupDir := filepath.Join(c.cfg.Local.BackupDirectory, backupType)
+// ListBackupFiles returns every backup file under the backup directory,
+// regardless of which organization layout wrote it
+func (c *Client) ListBackupFiles() ([]StoredFile, error) {
+	var files []StoredFile
 
-		// Find all backups of this type
-		files, err := filepath.Glob(filepath.Join(backupDir, "*.sql.gz"))
+	err := filepath.WalkDir(c.cfg.Local.BackupDirectory, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			log.Printf("Error finding backups: %v", err)
-			continue
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".sql.gz") {
+			return nil
 		}
 
-		// Check each file against retention policy
-		for _, file := range files {
-			fileInfo, err := os.Stat(file)
-			if err != nil {
-				continue
-			}
-
-			fileAge := time.Since(fileInfo.ModTime())
-
-			if fileAge > duration {
-				// Delete expired backup
-				err = os.Remove(file)
-				if err != nil {
-					log.Printf("Failed to remove expired backup %s: %v", file, err)
-				} else {
-					// Extract backup ID from filename
-					baseName := filepath.Base(file)
-					// Find backup in metadata that matches this path and mark it deleted
-					backups := metadata.DefaultStore.GetBackupsFiltered("", "", backupType, true)
-					for _, backup := range backups {
-						if backup.LocalPath == file || filepath.Base(backup.LocalPath) == baseName {
-							if err := metadata.DefaultStore.MarkBackupDeleted(backup.ID); err != nil {
-								log.Printf("Warning: Failed to mark backup %s as deleted in metadata: %v", backup.ID, err)
-							} else {
-								log.Printf("Marked backup %s as deleted in metadata", backup.ID)
-							}
-							break
-						}
-					}
-
-					log.Printf("Removed expired local backup: %s", file)
-					metrics.BackupRetentionDeletes.WithLabelValues(backupType, "local").Inc()
-				}
-			}
+		info, err := d.Info()
+		if err != nil {
+			return err
 		}
+		files = append(files, StoredFile{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local backups: %w", err)
 	}
 
+	return files, nil
+}
+
+// DeleteFile removes a single backup file, and its manifest, from local storage
+func (c *Client) DeleteFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	removeManifest(path)
 	return nil
 }
+
+// removeManifest removes the manifest of a deleted backup file, if it has one
+func removeManifest(path string) {
+	manifest := types.ManifestPath(path)
+	if err := os.Remove(manifest); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: Failed to remove backup manifest %s: %v", manifest, err)
+	}
+}
@@ -2,11 +2,13 @@
 package s3
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -18,8 +20,10 @@ import (
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/supporttools/GoSQLGuard/pkg/config"
 	"github.com/supporttools/GoSQLGuard/pkg/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metadata/types"
 	"github.com/supporttools/GoSQLGuard/pkg/metrics"
 )
 
@@ -145,8 +149,9 @@ func getS3Client() (*s3.Client, error) {
 	return s3Client, nil
 }
 
-// UploadBackupWithKey uploads a backup file to S3 with the provided full object key
-func (c *Client) UploadBackupWithKey(backupPath, objectKey string) error {
+// UploadBackupWithKey uploads a backup file to S3 with the provided full
+// object key, giving up when ctx is cancelled
+func (c *Client) UploadBackupWithKey(ctx context.Context, backupPath, objectKey string) error {
 	startTime := time.Now()
 
 	// Extract backup type and database from the object key for metrics
@@ -204,7 +209,7 @@ func (c *Client) UploadBackupWithKey(backupPath, objectKey string) error {
 	}
 
 	// Upload to S3
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
 	putInput := &s3.PutObjectInput{
@@ -242,18 +247,44 @@ func (c *Client) UploadBackupWithKey(backupPath, objectKey string) error {
 	metrics.S3UploadDuration.WithLabelValues(backupType, database).Observe(duration.Seconds())
 	metrics.S3UploadCount.WithLabelValues(backupType, database, "success").Inc()
 
-	// Get file size for metrics if we don't have it already
-	if fileInfo == nil {
-		fileInfo, err = os.Stat(backupPath)
+	log.Printf("Successfully uploaded backup to S3: s3://%s/%s", c.cfg.S3.Bucket, objectKey)
+	return nil
+}
+
+// UploadBytes stores a small object, such as a backup manifest, under the
+// given key
+func (c *Client) UploadBytes(ctx context.Context, objectKey string, data []byte, contentType string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	_, err := c.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(c.cfg.S3.Bucket),
+		Key:         aws.String(objectKey),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload s3://%s/%s: %w", c.cfg.S3.Bucket, objectKey, err)
 	}
+	return nil
+}
 
-	if err == nil && fileInfo != nil {
-		sizeBytes := float64(fileInfo.Size())
-		metrics.BackupSize.WithLabelValues(backupType, database, "s3").Set(sizeBytes)
+// ReadObject returns the contents of a small object, such as a backup manifest
+func (c *Client) ReadObject(key string) ([]byte, error) {
+	out, err := c.s3Client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(c.cfg.S3.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read s3://%s/%s: %w", c.cfg.S3.Bucket, key, err)
 	}
+	defer out.Body.Close()
 
-	log.Printf("Successfully uploaded backup to S3: s3://%s/%s", c.cfg.S3.Bucket, objectKey)
-	return nil
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read s3://%s/%s: %w", c.cfg.S3.Bucket, key, err)
+	}
+	return data, nil
 }
 
 // UploadBackup uploads a backup file to S3
@@ -325,96 +356,158 @@ func (c *Client) UploadBackup(backupPath, backupType, database, backupFileName s
 	metrics.S3UploadDuration.WithLabelValues(backupType, database).Observe(duration.Seconds())
 	metrics.S3UploadCount.WithLabelValues(backupType, database, "success").Inc()
 
-	// Get file size for metrics if we don't have it already
-	if fileInfo == nil {
-		fileInfo, err = os.Stat(backupPath)
-	}
-
-	if err == nil && fileInfo != nil {
-		sizeBytes := float64(fileInfo.Size())
-		metrics.BackupSize.WithLabelValues(backupType, database, "s3").Set(sizeBytes)
-	}
-
 	log.Printf("Successfully uploaded backup to S3: s3://%s/%s", c.cfg.S3.Bucket, objectKey)
 	return nil
 }
 
-// EnforceRetention implements retention policy for S3 backups
-func (c *Client) EnforceRetention() error {
-	for backupType, typeConfig := range c.cfg.BackupTypes {
-		// Skip if S3 backup is not enabled for this type
-		if !typeConfig.S3.Enabled {
-			if c.cfg.Debug {
-				log.Printf("S3 backup not enabled for %s, skipping retention enforcement", backupType)
-			}
+// DeleteBackup removes every S3 object recorded for a backup, along with
+// their manifests. It returns the keys that could not be deleted, keyed by
+// organization, so a partial failure can be retried.
+func (c *Client) DeleteBackup(backup metadata.BackupMeta) (map[string]string, error) {
+	keys := make(map[string]string, len(backup.S3Keys)+1)
+	for org, key := range backup.S3Keys {
+		keys[org] = key
+	}
+	if backup.S3Key != "" && len(keys) == 0 {
+		keys["legacy"] = backup.S3Key
+	}
+
+	ctx := context.Background()
+	remaining := make(map[string]string)
+	var errs []error
+	for org, key := range keys {
+		_, err := c.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(c.cfg.S3.Bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			remaining[org] = key
+			errs = append(errs, fmt.Errorf("failed to delete s3://%s/%s: %w", c.cfg.S3.Bucket, key, err))
 			continue
 		}
+		log.Printf("Removed S3 backup object: %s", key)
 
-		// Skip if keep forever is set
-		if typeConfig.S3.Retention.Forever {
-			if c.cfg.Debug {
-				log.Printf("S3 backups for %s set to keep forever, skipping retention enforcement", backupType)
-			}
-			continue
+		if err := c.DeleteObject(types.ManifestPath(key)); err != nil {
+			log.Printf("Warning: Failed to remove manifest of S3 backup object %s: %v", key, err)
 		}
+	}
+
+	return remaining, errors.Join(errs...)
+}
+
+// SetLegalHold turns the S3 Object Lock legal hold of every object recorded
+// for a backup on or off. The bucket must have Object Lock enabled.
+func (c *Client) SetLegalHold(backup metadata.BackupMeta, on bool) error {
+	keys := make(map[string]bool, len(backup.S3Keys)+1)
+	for _, key := range backup.S3Keys {
+		keys[key] = true
+	}
+	if backup.S3Key != "" {
+		keys[backup.S3Key] = true
+	}
 
-		// Parse duration string
-		duration, err := time.ParseDuration(typeConfig.S3.Retention.Duration)
+	status := s3types.ObjectLockLegalHoldStatusOff
+	if on {
+		status = s3types.ObjectLockLegalHoldStatusOn
+	}
+
+	var errs []error
+	for key := range keys {
+		_, err := c.s3Client.PutObjectLegalHold(context.Background(), &s3.PutObjectLegalHoldInput{
+			Bucket:    aws.String(c.cfg.S3.Bucket),
+			Key:       aws.String(key),
+			LegalHold: &s3types.ObjectLockLegalHold{Status: status},
+		})
 		if err != nil {
-			log.Printf("Invalid duration for %s S3 retention: %v", backupType, err)
-			continue
+			errs = append(errs, fmt.Errorf("failed to set legal hold on s3://%s/%s: %w", c.cfg.S3.Bucket, key, err))
 		}
+	}
+	return errors.Join(errs...)
+}
 
-		// List objects with prefix
-		prefix := buildPrefix(c.cfg.S3.Prefix, backupType)
+// StoredObject describes a backup object found in S3
+type StoredObject struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
 
-		ctx := context.Background()
-		paginator := s3.NewListObjectsV2Paginator(c.s3Client, &s3.ListObjectsV2Input{
-			Bucket: aws.String(c.cfg.S3.Bucket),
-			Prefix: aws.String(prefix),
-		})
+// ListBackupObjects returns every backup object under the configured prefix,
+// regardless of which organization layout wrote it
+func (c *Client) ListBackupObjects() ([]StoredObject, error) {
+	return c.listObjects(".sql.gz")
+}
 
-		expirationTime := time.Now().Add(-duration)
+// ListManifests returns every backup manifest under the configured prefix
+func (c *Client) ListManifests() ([]StoredObject, error) {
+	return c.listObjects(types.ManifestSuffix)
+}
 
-		// Process each page of results
-		for paginator.HasMorePages() {
-			page, err := paginator.NextPage(ctx)
-			if err != nil {
-				log.Printf("Failed to list S3 objects: %v", err)
-				break
-			}
+// listObjects returns the objects under the configured prefix whose keys end
+// in suffix
+func (c *Client) listObjects(suffix string) ([]StoredObject, error) {
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(c.cfg.S3.Bucket)}
+	if c.cfg.S3.Prefix != "" {
+		input.Prefix = aws.String(strings.TrimSuffix(c.cfg.S3.Prefix, "/") + "/")
+	}
 
-			for _, obj := range page.Contents {
-				// Check if object is older than retention period
-				if obj.LastModified.Before(expirationTime) {
-					_, err := c.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
-						Bucket: aws.String(c.cfg.S3.Bucket),
-						Key:    obj.Key,
-					})
-
-					if err != nil {
-						log.Printf("Failed to delete expired S3 backup %s: %v", *obj.Key, err)
-					} else {
-						// Look for corresponding metadata entry
-						backups := metadata.DefaultStore.GetBackupsFiltered("", "", backupType, true)
-						for _, backup := range backups {
-							if backup.S3Key == *obj.Key || strings.HasSuffix(backup.S3Key, *obj.Key) {
-								if err := metadata.DefaultStore.MarkBackupDeleted(backup.ID); err != nil {
-									log.Printf("Warning: Failed to mark backup %s as deleted in metadata: %v", backup.ID, err)
-								} else {
-									log.Printf("Marked backup %s as deleted in metadata", backup.ID)
-								}
-								break
-							}
-						}
-
-						log.Printf("Removed expired S3 backup: %s", *obj.Key)
-						metrics.BackupRetentionDeletes.WithLabelValues(backupType, "s3").Inc()
-					}
-				}
+	var objects []StoredObject
+	paginator := s3.NewListObjectsV2Paginator(c.s3Client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list S3 objects: %w", err)
+		}
+
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if !strings.HasSuffix(key, suffix) {
+				continue
 			}
+			objects = append(objects, StoredObject{
+				Key:          key,
+				Size:         aws.ToInt64(obj.Size),
+				LastModified: aws.ToTime(obj.LastModified),
+			})
 		}
 	}
+
+	return objects, nil
+}
+
+// StatObject returns the size and modification time of a single object
+func (c *Client) StatObject(key string) (StoredObject, error) {
+	out, err := c.s3Client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(c.cfg.S3.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return StoredObject{}, fmt.Errorf("failed to stat s3://%s/%s: %w", c.cfg.S3.Bucket, key, err)
+	}
+	return StoredObject{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		LastModified: aws.ToTime(out.LastModified),
+	}, nil
+}
+
+// IsNotFound reports whether an error from StatObject or ReadObject means the
+// object does not exist
+func IsNotFound(err error) bool {
+	var notFound *s3types.NotFound
+	var noSuchKey *s3types.NoSuchKey
+	return errors.As(err, &notFound) || errors.As(err, &noSuchKey)
+}
+
+// DeleteObject removes a single object from S3
+func (c *Client) DeleteObject(key string) error {
+	_, err := c.s3Client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+		Bucket: aws.String(c.cfg.S3.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete s3://%s/%s: %w", c.cfg.S3.Bucket, key, err)
+	}
 	return nil
 }
 
@@ -428,13 +521,3 @@ func buildObjectKey(prefix, backupType, backupFileName string) string {
 	}
 	return fmt.Sprintf("%s/%s", backupType, backupFileName)
 }
-
-// Helper function to build prefix for listing objects
-func buildPrefix(prefix, backupType string) string {
-	if prefix != "" {
-		return fmt.Sprintf("%s/%s/",
-			strings.TrimSuffix(prefix, "/"),
-			backupType)
-	}
-	return fmt.Sprintf("%s/", backupType)
-}
@@ -0,0 +1,199 @@
+// Package worker runs database backups queued by the scheduler, so backups
+// can run close to the database servers while a single process holds the
+// schedules and metadata.
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+	"github.com/supporttools/GoSQLGuard/pkg/metrics"
+)
+
+// TaskStore is the queue workers claim tasks from
+type TaskStore interface {
+	Claim(workerID string, servers []string) (*dbmeta.BackupTask, error)
+	Heartbeat(id uint, workerID string) error
+	Started(id uint, workerID, backupID string) error
+	Complete(id uint, workerID, status, errorMsg string) error
+	Release(id uint, workerID string) error
+}
+
+// Runner runs the backup of a claimed task, calling started with the ID of
+// the backup entry it creates. A queued status hands the task back to the
+// queue, as when the backup was stopped by a shutdown.
+type Runner interface {
+	RunTask(task dbmeta.BackupTask, started func(backupID string)) (string, error)
+}
+
+// Worker claims backup tasks one at a time and runs them
+type Worker struct {
+	id        string
+	store     TaskStore
+	runner    Runner
+	servers   []string
+	poll      time.Duration
+	heartbeat time.Duration
+	prepare   func()
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// New creates a worker with the given settings
+func New(store TaskStore, runner Runner, cfg config.WorkerConfig) (*Worker, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	poll, _ := time.ParseDuration(cfg.PollInterval)
+	heartbeat, _ := time.ParseDuration(cfg.HeartbeatInterval)
+
+	return &Worker{
+		id:        cfg.ID,
+		store:     store,
+		runner:    runner,
+		servers:   cfg.Servers,
+		poll:      poll,
+		heartbeat: heartbeat,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// SetPrepare registers a function to call before each task runs, typically
+// to reload server and schedule settings changed since the last task
+func (w *Worker) SetPrepare(fn func()) {
+	w.prepare = fn
+}
+
+// Run claims and runs tasks until Stop is called
+func (w *Worker) Run() {
+	defer close(w.done)
+
+	log.Printf("Worker %s waiting for backup tasks (%s)", w.id, w.serverDescription())
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		if !w.runNext() {
+			select {
+			case <-time.After(w.poll):
+			case <-w.stop:
+				return
+			}
+		}
+	}
+}
+
+// Stop stops claiming tasks and waits for the running task to finish
+func (w *Worker) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+	})
+}
+
+// runNext claims and runs one task, reporting whether there was one
+func (w *Worker) runNext() bool {
+	task, err := w.store.Claim(w.id, w.servers)
+	if err != nil {
+		log.Printf("Failed to claim a backup task: %v", err)
+		return false
+	}
+	if task == nil {
+		return false
+	}
+
+	// A stop that arrived while claiming leaves the task to another worker
+	select {
+	case <-w.stop:
+		if err := w.store.Release(task.ID, w.id); err != nil {
+			log.Printf("Failed to release task %d: %v", task.ID, err)
+		}
+		return true
+	default:
+	}
+
+	w.runTask(*task)
+	return true
+}
+
+// runTask runs a claimed task, sending heartbeats until it finishes
+func (w *Worker) runTask(task dbmeta.BackupTask) {
+	log.Printf("Running %s backup of %s on server %s (task %d, attempt %d)",
+		task.BackupType, task.DatabaseName, task.ServerName, task.ID, task.Attempts)
+
+	if w.prepare != nil {
+		w.prepare()
+	}
+
+	finished := make(chan struct{})
+	go w.sendHeartbeats(task.ID, finished)
+
+	// The backup entry is recorded on the task so that it is marked
+	// interrupted if this worker dies before the backup finishes
+	started := func(backupID string) {
+		if err := w.store.Started(task.ID, w.id, backupID); err != nil {
+			log.Printf("Failed to record backup %s of task %d: %v", backupID, task.ID, err)
+		}
+	}
+
+	status, err := w.runner.RunTask(task, started)
+	close(finished)
+
+	errorMsg := ""
+	if err != nil {
+		errorMsg = err.Error()
+		log.Printf("Task %d finished with status %s: %v", task.ID, status, err)
+	}
+
+	if status == dbmeta.TaskStatusQueued {
+		if err := w.store.Release(task.ID, w.id); err != nil {
+			log.Printf("Failed to release task %d: %v", task.ID, err)
+		}
+		return
+	}
+	metrics.TasksCompleted.WithLabelValues(task.BackupType, status).Inc()
+
+	if err := w.store.Complete(task.ID, w.id, status, errorMsg); err != nil {
+		if errors.Is(err, dbmeta.ErrTaskNotClaimed) {
+			log.Printf("Task %d was handed to another worker before it finished", task.ID)
+			return
+		}
+		log.Printf("Failed to record the outcome of task %d: %v", task.ID, err)
+	}
+}
+
+// sendHeartbeats reports that a task is still running until finished is closed
+func (w *Worker) sendHeartbeats(id uint, finished <-chan struct{}) {
+	ticker := time.NewTicker(w.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.store.Heartbeat(id, w.id); err != nil {
+				log.Printf("Failed to send heartbeat for task %d: %v", id, err)
+			}
+		case <-finished:
+			return
+		}
+	}
+}
+
+// serverDescription describes the servers this worker backs up
+func (w *Worker) serverDescription() string {
+	if len(w.servers) == 0 {
+		return "all servers"
+	}
+	return fmt.Sprintf("servers %v", w.servers)
+}
@@ -0,0 +1,206 @@
+package worker
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/supporttools/GoSQLGuard/pkg/config"
+	dbmeta "github.com/supporttools/GoSQLGuard/pkg/database/metadata"
+)
+
+// fakeTaskStore keeps queued tasks in memory
+type fakeTaskStore struct {
+	mu         sync.Mutex
+	tasks      []dbmeta.BackupTask
+	heartbeats int
+}
+
+func (f *fakeTaskStore) Claim(workerID string, servers []string) (*dbmeta.BackupTask, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	targets := config.BackupTargets{Servers: servers}
+	for i := range f.tasks {
+		task := &f.tasks[i]
+		if task.Status != dbmeta.TaskStatusQueued || !targets.MatchesServer(config.DatabaseServerConfig{Name: task.ServerName}) {
+			continue
+		}
+		task.Status = dbmeta.TaskStatusRunning
+		task.WorkerID = workerID
+		task.Attempts++
+		claimed := *task
+		return &claimed, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeTaskStore) Heartbeat(id uint, workerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.heartbeats++
+	return nil
+}
+
+func (f *fakeTaskStore) Started(id uint, workerID, backupID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.tasks {
+		task := &f.tasks[i]
+		if task.ID == id && task.WorkerID == workerID && task.Status == dbmeta.TaskStatusRunning {
+			task.BackupID = backupID
+			return nil
+		}
+	}
+	return dbmeta.ErrTaskNotClaimed
+}
+
+func (f *fakeTaskStore) Complete(id uint, workerID, status, errorMsg string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.tasks {
+		task := &f.tasks[i]
+		if task.ID == id && task.WorkerID == workerID && task.Status == dbmeta.TaskStatusRunning {
+			task.Status = status
+			task.Error = errorMsg
+			return nil
+		}
+	}
+	return dbmeta.ErrTaskNotClaimed
+}
+
+func (f *fakeTaskStore) Release(id uint, workerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.tasks {
+		task := &f.tasks[i]
+		if task.ID == id && task.WorkerID == workerID && task.Status == dbmeta.TaskStatusRunning {
+			task.Status = dbmeta.TaskStatusQueued
+			task.WorkerID = ""
+			task.Attempts--
+		}
+	}
+	return nil
+}
+
+func (f *fakeTaskStore) task(id uint) dbmeta.BackupTask {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, task := range f.tasks {
+		if task.ID == id {
+			return task
+		}
+	}
+	return dbmeta.BackupTask{}
+}
+
+// fakeRunner succeeds except for the database named "broken", and hands
+// back the database named "interrupted" as a shutdown would
+type fakeRunner struct {
+	delay time.Duration
+}
+
+func (r fakeRunner) RunTask(task dbmeta.BackupTask, started func(backupID string)) (string, error) {
+	started("backup-" + task.DatabaseName)
+	time.Sleep(r.delay)
+	if task.DatabaseName == "interrupted" {
+		return dbmeta.TaskStatusQueued, errors.New("backup manager is shutting down")
+	}
+	if task.DatabaseName == "broken" {
+		return dbmeta.TaskStatusError, errors.New("mysqldump failed")
+	}
+	return dbmeta.TaskStatusSuccess, nil
+}
+
+func testConfig(servers ...string) config.WorkerConfig {
+	return config.WorkerConfig{
+		ID:                "worker-eu",
+		Servers:           servers,
+		PollInterval:      "10ms",
+		HeartbeatInterval: "5ms",
+		HeartbeatTimeout:  "1m",
+		MaxAttempts:       3,
+	}
+}
+
+func TestWorkerRunsMatchingTasks(t *testing.T) {
+	store := &fakeTaskStore{tasks: []dbmeta.BackupTask{
+		{ID: 1, ServerName: "eu-db1", DatabaseName: "app", Status: dbmeta.TaskStatusQueued},
+		{ID: 2, ServerName: "us-db1", DatabaseName: "app", Status: dbmeta.TaskStatusQueued},
+		{ID: 3, ServerName: "eu-db2", DatabaseName: "broken", Status: dbmeta.TaskStatusQueued},
+	}}
+	w, err := New(store, fakeRunner{delay: 20 * time.Millisecond}, testConfig("eu-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for w.runNext() {
+	}
+
+	if task := store.task(1); task.Status != dbmeta.TaskStatusSuccess || task.WorkerID != "worker-eu" {
+		t.Errorf("task 1 = %+v, want success by worker-eu", task)
+	}
+	if task := store.task(1); task.BackupID != "backup-app" {
+		t.Errorf("task 1 recorded backup %q, want backup-app", task.BackupID)
+	}
+	if task := store.task(2); task.Status != dbmeta.TaskStatusQueued {
+		t.Errorf("task 2 for another datacenter was claimed: %+v", task)
+	}
+	if task := store.task(3); task.Status != dbmeta.TaskStatusError || task.Error != "mysqldump failed" {
+		t.Errorf("task 3 = %+v, want the backup error recorded", task)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.heartbeats == 0 {
+		t.Error("no heartbeats sent while tasks ran")
+	}
+}
+
+func TestWorkerReleasesInterruptedTask(t *testing.T) {
+	store := &fakeTaskStore{tasks: []dbmeta.BackupTask{
+		{ID: 1, ServerName: "eu-db1", DatabaseName: "interrupted", Status: dbmeta.TaskStatusQueued},
+	}}
+	w, err := New(store, fakeRunner{}, testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !w.runNext() {
+		t.Fatal("task was not claimed")
+	}
+	if task := store.task(1); task.Status != dbmeta.TaskStatusQueued || task.WorkerID != "" || task.Attempts != 0 {
+		t.Errorf("task 1 = %+v, want it back in the queue without the attempt counted", task)
+	}
+}
+
+func TestWorkerStop(t *testing.T) {
+	w, err := New(&fakeTaskStore{}, fakeRunner{}, testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go w.Run()
+	stopped := make(chan struct{})
+	go func() {
+		w.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop")
+	}
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	cfg := testConfig()
+	cfg.HeartbeatInterval = "2m"
+	if _, err := New(&fakeTaskStore{}, fakeRunner{}, cfg); err == nil {
+		t.Error("expected an error for a heartbeat interval longer than the timeout")
+	}
+}